@@ -0,0 +1,258 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AvalancheAlarmes representa um período de avalanche de alarmes numa eclusa
+type AvalancheAlarmes struct {
+	ID                   int64      `json:"id"`
+	EclusaCodigo         string     `json:"eclusa_codigo"`
+	EclusaNome           string     `json:"eclusa_nome"`
+	TimestampInicio      time.Time  `json:"timestamp_inicio"`
+	TimestampFim         *time.Time `json:"timestamp_fim,omitempty"`
+	TotalAlarmes         int        `json:"total_alarmes"`
+	FirstOutOcorrenciaID *int64     `json:"first_out_ocorrencia_id,omitempty"`
+	FirstOutCodigo       string     `json:"first_out_codigo,omitempty"`
+	FirstOutDescricao    string     `json:"first_out_descricao,omitempty"`
+	Ativa                bool       `json:"ativa"`
+}
+
+// RelacaoSupressao representa uma relação pai/filha entre definições de falhas
+type RelacaoSupressao struct {
+	ID               int    `json:"id"`
+	DefinicaoPaiID   int    `json:"definicao_pai_id"`
+	CodigoPai        string `json:"codigo_pai"`
+	DescricaoPai     string `json:"descricao_pai"`
+	DefinicaoFilhaID int    `json:"definicao_filha_id"`
+	CodigoFilha      string `json:"codigo_filha"`
+	DescricaoFilha   string `json:"descricao_filha"`
+	Ativa            bool   `json:"ativa"`
+}
+
+// obterAvalanchesAlarmes retorna as avalanches de alarmes registradas
+func (s *ServidorHTTP) obterAvalanchesAlarmes(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	limite := 50
+	if valor := r.URL.Query().Get("limite"); valor != "" {
+		l, err := strconv.Atoi(valor)
+		if err != nil || l <= 0 {
+			http.Error(w, "Parâmetro 'limite' inválido", http.StatusBadRequest)
+			return
+		}
+		limite = l
+	}
+
+	where := ""
+	args := []interface{}{limite}
+	if eclusa := r.URL.Query().Get("eclusa"); eclusa != "" {
+		args = append(args, strings.ToUpper(eclusa))
+		where = " WHERE e.codigo = $2"
+	}
+
+	rows, err := s.bancoDados.Query(`
+		SELECT
+			a.id, e.codigo, e.nome, a.timestamp_inicio, a.timestamp_fim, a.total_alarmes,
+			a.first_out_ocorrencia_id, COALESCE(df.codigo, ''), COALESCE(df.descricao, '')
+		FROM avalanches_alarmes a
+		JOIN eclusas e ON a.eclusa_id = e.id
+		LEFT JOIN ocorrencias_falhas o ON a.first_out_ocorrencia_id = o.id
+		LEFT JOIN definicoes_falhas df ON o.definicao_id = df.id`+where+`
+		ORDER BY a.timestamp_inicio DESC
+		LIMIT $1`, args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar avalanches: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var avalanches []AvalancheAlarmes
+
+	for rows.Next() {
+		var av AvalancheAlarmes
+		var timestampFim sql.NullTime
+		var firstOut sql.NullInt64
+
+		err := rows.Scan(
+			&av.ID, &av.EclusaCodigo, &av.EclusaNome, &av.TimestampInicio, &timestampFim,
+			&av.TotalAlarmes, &firstOut, &av.FirstOutCodigo, &av.FirstOutDescricao)
+		if err != nil {
+			continue
+		}
+
+		if timestampFim.Valid {
+			av.TimestampFim = &timestampFim.Time
+		}
+		av.FirstOutOcorrenciaID = ponteiroNullInt64(firstOut)
+		av.Ativa = !timestampFim.Valid
+
+		avalanches = append(avalanches, av)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    avalanches,
+		"total":   len(avalanches),
+	})
+}
+
+// obterRelacoesSupressao retorna as relações de supressão pai/filha configuradas
+func (s *ServidorHTTP) obterRelacoesSupressao(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Com eclusa, as relações cuja definição pai pertence a essa eclusa
+	where := ""
+	var args []interface{}
+	if eclusa := r.URL.Query().Get("eclusa"); eclusa != "" {
+		args = append(args, strings.ToUpper(eclusa))
+		where = " WHERE pai.eclusa_id IN (SELECT id FROM eclusas WHERE codigo = $1)"
+	}
+
+	rows, err := s.bancoDados.Query(`
+		SELECT
+			r.id, r.definicao_pai_id, pai.codigo, pai.descricao,
+			r.definicao_filha_id, filha.codigo, filha.descricao, r.ativa
+		FROM relacoes_supressao r
+		JOIN definicoes_falhas pai ON r.definicao_pai_id = pai.id
+		JOIN definicoes_falhas filha ON r.definicao_filha_id = filha.id`+where+`
+		ORDER BY pai.point_index, filha.point_index`, args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar relações de supressão: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var relacoes []RelacaoSupressao
+
+	for rows.Next() {
+		var rel RelacaoSupressao
+		err := rows.Scan(
+			&rel.ID, &rel.DefinicaoPaiID, &rel.CodigoPai, &rel.DescricaoPai,
+			&rel.DefinicaoFilhaID, &rel.CodigoFilha, &rel.DescricaoFilha, &rel.Ativa)
+		if err != nil {
+			continue
+		}
+		relacoes = append(relacoes, rel)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    relacoes,
+		"total":   len(relacoes),
+	})
+}
+
+// criarRelacaoSupressao cria (ou reativa) uma relação de supressão entre definições
+func (s *ServidorHTTP) criarRelacaoSupressao(w http.ResponseWriter, r *http.Request) {
+	var entrada struct {
+		DefinicaoPaiID   int `json:"definicao_pai_id"`
+		DefinicaoFilhaID int `json:"definicao_filha_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	if entrada.DefinicaoPaiID <= 0 || entrada.DefinicaoFilhaID <= 0 {
+		http.Error(w, "Campos 'definicao_pai_id' e 'definicao_filha_id' são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	if entrada.DefinicaoPaiID == entrada.DefinicaoFilhaID {
+		http.Error(w, "Uma definição não pode suprimir a si própria", http.StatusBadRequest)
+		return
+	}
+
+	var id int
+	err := s.bancoDados.QueryRow(`
+		INSERT INTO relacoes_supressao (definicao_pai_id, definicao_filha_id)
+		VALUES ($1, $2)
+		ON CONFLICT (definicao_pai_id, definicao_filha_id) DO UPDATE SET ativa = true
+		RETURNING id`, entrada.DefinicaoPaiID, entrada.DefinicaoFilhaID).Scan(&id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao criar relação de supressão: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]int{
+			"id":                 id,
+			"definicao_pai_id":   entrada.DefinicaoPaiID,
+			"definicao_filha_id": entrada.DefinicaoFilhaID,
+		},
+	})
+}
+
+// removerRelacaoSupressao remove uma relação de supressão
+func (s *ServidorHTTP) removerRelacaoSupressao(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.bancoDados.Exec("DELETE FROM relacoes_supressao WHERE id = $1", id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao remover relação de supressão: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		http.Error(w, "Relação de supressão não encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Relação de supressão removida com sucesso",
+	})
+}
+
+// ponteiroNullInt64 converte um sql.NullInt64 em ponteiro (nil quando NULL)
+func ponteiroNullInt64(valor sql.NullInt64) *int64 {
+	if !valor.Valid {
+		return nil
+	}
+	return &valor.Int64
+}
+
+// ponteiroNullInt converte um sql.NullInt64 em ponteiro para int (nil quando NULL)
+func ponteiroNullInt(valor sql.NullInt64) *int {
+	if !valor.Valid {
+		return nil
+	}
+	inteiro := int(valor.Int64)
+	return &inteiro
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/relatorios"
+)
+
+// lerFiltrosConfiabilidade lê período, eclusa, setor e prioridade (padrão: últimos 30 dias)
+func lerFiltrosConfiabilidade(r *http.Request) (relatorios.Filtros, error) {
+	q := r.URL.Query()
+	filtros := relatorios.Filtros{
+		Eclusa:            strings.ToUpper(q.Get("eclusa")),
+		Setor:             q.Get("setor"),
+		Prioridade:        strings.ToUpper(q.Get("prioridade")),
+		IncluirSuprimidas: q.Get("incluir_suprimidas") == "true",
+		IncluirManutencao: q.Get("incluir_manutencao") == "true",
+	}
+
+	inicio, err := lerDataHora(q.Get("inicio"), "inicio")
+	if err != nil {
+		return filtros, err
+	}
+	fim, err := lerDataHora(q.Get("fim"), "fim")
+	if err != nil {
+		return filtros, err
+	}
+
+	if fim == nil {
+		agora := time.Now()
+		fim = &agora
+	}
+	if inicio == nil {
+		trintaDiasAntes := fim.AddDate(0, 0, -30)
+		inicio = &trintaDiasAntes
+	}
+	if !fim.After(*inicio) {
+		return filtros, fmt.Errorf("'fim' deve ser posterior a 'inicio'")
+	}
+	filtros.Inicio, filtros.Fim = *inicio, *fim
+
+	if filtros.Prioridade != "" && !prioridadesValidas[filtros.Prioridade] {
+		return filtros, fmt.Errorf("prioridade inválida: %s", filtros.Prioridade)
+	}
+
+	return filtros, nil
+}
+
+// obterConfiabilidade retorna disponibilidade, MTBF, MTTR e tempo parado por eclusa, setor e falha
+func (s *ServidorHTTP) obterConfiabilidade(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	filtros, err := lerFiltrosConfiabilidade(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	relatorio, err := relatorios.CalcularConfiabilidade(s.bancoDados, filtros, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao calcular confiabilidade: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    relatorio,
+	})
+}
+
+// obterParetoParagens retorna os maiores contribuintes do tempo parado no período
+func (s *ServidorHTTP) obterParetoParagens(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	filtros, err := lerFiltrosConfiabilidade(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	agrupar := r.URL.Query().Get("agrupar")
+	if agrupar == "" {
+		agrupar = relatorios.AgruparFalha
+	}
+	if agrupar != relatorios.AgruparFalha && agrupar != relatorios.AgruparSetor && agrupar != relatorios.AgruparEclusa {
+		http.Error(w, "Parâmetro 'agrupar' inválido: use falha, setor ou eclusa", http.StatusBadRequest)
+		return
+	}
+
+	limite := 0
+	if valor := r.URL.Query().Get("limite"); valor != "" {
+		limite, err = strconv.Atoi(valor)
+		if err != nil || limite <= 0 {
+			http.Error(w, "Parâmetro 'limite' inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	relatorio, err := relatorios.CalcularConfiabilidade(s.bancoDados, filtros, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao calcular confiabilidade: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	itens, err := relatorios.Pareto(relatorio, agrupar, limite)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"data":        itens,
+		"total":       len(itens),
+		"agrupar":     agrupar,
+		"filtros":     filtros,
+		"fim_efetivo": relatorio.FimEfetivo,
+	})
+}
@@ -0,0 +1,376 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+	"github.com/gorilla/mux"
+)
+
+const (
+	limitePadraoEclusagens = 200
+	limiteMaximoEclusagens = 2000
+)
+
+// colunasEclusagem é a projeção comum da lista e do detalhe
+const colunasEclusagem = `
+	c.id, e.codigo, COALESCE(c.direcao, ''), c.status, c.fase,
+	c.timestamp_inicio, c.timestamp_inicio_nivelamento, c.timestamp_fim_nivelamento, c.timestamp_fim,
+	c.duracao_preparacao_segundos, c.duracao_nivelamento_segundos, c.duracao_abertura_segundos,
+	c.duracao_total_segundos, COALESCE(c.motivo, ''),
+	(SELECT COUNT(*) FROM ocorrencias_falhas o WHERE o.eclusagem_id = c.id)`
+
+// KPIEclusagensDia resume as eclusagens de uma eclusa num dia
+type KPIEclusagensDia struct {
+	Dia                      string   `json:"dia"`
+	EclusaCodigo             string   `json:"eclusa_codigo"`
+	Total                    int      `json:"total"`
+	Concluidas               int      `json:"concluidas"`
+	Abortadas                int      `json:"abortadas"`
+	Timeout                  int      `json:"timeout"`
+	Subidas                  int      `json:"subidas"`
+	Descidas                 int      `json:"descidas"`
+	DuracaoMediaSegundos     *float64 `json:"duracao_media_segundos,omitempty"` // Apenas concluídas
+	DuracaoP95Segundos       *float64 `json:"duracao_p95_segundos,omitempty"`
+	PreparacaoMediaSegundos  *float64 `json:"preparacao_media_segundos,omitempty"`
+	NivelamentoMedioSegundos *float64 `json:"nivelamento_medio_segundos,omitempty"`
+	AberturaMediaSegundos    *float64 `json:"abertura_media_segundos,omitempty"`
+	EclusagensComFalhas      int      `json:"eclusagens_com_falhas"`
+	TotalFalhas              int      `json:"total_falhas"`
+	TaxaSucessoPercentual    float64  `json:"taxa_sucesso_percentual"`
+}
+
+// lerFiltrosEclusagens lê eclusa, intervalo, status e direção (padrão: últimos 7 dias)
+func lerFiltrosEclusagens(r *http.Request) (string, []interface{}, map[string]interface{}, error) {
+	q := r.URL.Query()
+
+	inicio, err := lerDataHora(q.Get("inicio"), "inicio")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	fim, err := lerDataHora(q.Get("fim"), "fim")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if fim == nil {
+		agora := time.Now()
+		fim = &agora
+	}
+	if inicio == nil {
+		seteDiasAntes := fim.AddDate(0, 0, -7)
+		inicio = &seteDiasAntes
+	}
+
+	where := " WHERE c.timestamp_inicio >= $1 AND c.timestamp_inicio < $2"
+	args := []interface{}{*inicio, *fim}
+
+	if eclusa := q.Get("eclusa"); eclusa != "" {
+		args = append(args, strings.ToUpper(eclusa))
+		where += fmt.Sprintf(" AND e.codigo = $%d", len(args))
+	}
+	if status := q.Get("status"); status != "" {
+		status = strings.ToUpper(status)
+		switch status {
+		case modelos.StatusEclusagemEmCurso, modelos.StatusEclusagemConcluida,
+			modelos.StatusEclusagemAbortada, modelos.StatusEclusagemTimeout:
+		default:
+			return "", nil, nil, fmt.Errorf("parâmetro 'status' inválido: use EM_CURSO, CONCLUIDA, ABORTADA ou TIMEOUT")
+		}
+		args = append(args, status)
+		where += fmt.Sprintf(" AND c.status = $%d", len(args))
+	}
+	if direcao := q.Get("direcao"); direcao != "" {
+		direcao = strings.ToUpper(direcao)
+		if direcao != modelos.DirecaoSubida && direcao != modelos.DirecaoDescida {
+			return "", nil, nil, fmt.Errorf("parâmetro 'direcao' inválido: use SUBIDA ou DESCIDA")
+		}
+		args = append(args, direcao)
+		where += fmt.Sprintf(" AND c.direcao = $%d", len(args))
+	}
+
+	filtros := map[string]interface{}{
+		"inicio":  inicio,
+		"fim":     fim,
+		"eclusa":  q.Get("eclusa"),
+		"status":  q.Get("status"),
+		"direcao": q.Get("direcao"),
+	}
+	return where, args, filtros, nil
+}
+
+// obterEclusagens lista os ciclos de eclusagem detetados
+func (s *ServidorHTTP) obterEclusagens(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	where, args, filtros, err := lerFiltrosEclusagens(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limite := limitePadraoEclusagens
+	if valor := r.URL.Query().Get("limite"); valor != "" {
+		limite, err = strconv.Atoi(valor)
+		if err != nil || limite <= 0 || limite > limiteMaximoEclusagens {
+			http.Error(w, fmt.Sprintf("Parâmetro 'limite' inválido (1 a %d)", limiteMaximoEclusagens), http.StatusBadRequest)
+			return
+		}
+	}
+	filtros["limite"] = limite
+
+	rows, err := s.bancoDados.Query(`
+		SELECT `+colunasEclusagem+`
+		FROM eclusagens c
+		JOIN eclusas e ON c.eclusa_id = e.id`+where+`
+		ORDER BY c.timestamp_inicio DESC
+		LIMIT `+strconv.Itoa(limite), args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar eclusagens: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	eclusagens := []modelos.Eclusagem{}
+	for rows.Next() {
+		eclusagem, err := lerEclusagem(rows)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler eclusagem: %v", err), http.StatusInternalServerError)
+			return
+		}
+		eclusagens = append(eclusagens, eclusagem)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    eclusagens,
+		"total":   len(eclusagens),
+		"filtros": filtros,
+	})
+}
+
+// obterDetalheEclusagem retorna um ciclo e as falhas que ocorreram durante ele
+func (s *ServidorHTTP) obterDetalheEclusagem(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	eclusagem, err := lerEclusagem(s.bancoDados.QueryRow(`
+		SELECT `+colunasEclusagem+`
+		FROM eclusagens c
+		JOIN eclusas e ON c.eclusa_id = e.id
+		WHERE c.id = $1`, id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Eclusagem não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar eclusagem: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Num ciclo em curso as falhas ainda não foram ligadas: usar o intervalo do ciclo
+	rows, err := s.bancoDados.Query(`
+		SELECT o.id, df.codigo, df.descricao, df.prioridade, s.nome, o.status,
+			o.timestamp_inicio, o.timestamp_fim
+		FROM eclusagens c
+		JOIN ocorrencias_falhas o ON o.eclusagem_id = c.id
+			OR (c.status = 'EM_CURSO' AND o.timestamp_inicio >= c.timestamp_inicio)
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		JOIN setores s ON df.setor_id = s.id
+		WHERE c.id = $1 AND df.eclusa_id = c.eclusa_id AND df.tipo = 'FALHA'
+		ORDER BY o.timestamp_inicio`, id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar falhas da eclusagem: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	falhas := []map[string]interface{}{}
+	for rows.Next() {
+		var ocorrenciaID int64
+		var codigo, descricao, prioridade, setor, status string
+		var inicio time.Time
+		var fim sql.NullTime
+		if err := rows.Scan(&ocorrenciaID, &codigo, &descricao, &prioridade, &setor, &status, &inicio, &fim); err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler falha da eclusagem: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		falha := map[string]interface{}{
+			"id":               ocorrenciaID,
+			"codigo":           codigo,
+			"descricao":        descricao,
+			"prioridade":       prioridade,
+			"setor_nome":       setor,
+			"status":           status,
+			"timestamp_inicio": inicio,
+			"fase":             faseNoInstante(eclusagem, inicio),
+		}
+		if fim.Valid {
+			falha["timestamp_fim"] = fim.Time
+		}
+		falhas = append(falhas, falha)
+	}
+	eclusagem.TotalFalhas = len(falhas)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    eclusagem,
+		"falhas":  falhas,
+	})
+}
+
+// obterKPIsEclusagens retorna os indicadores diários das eclusagens por eclusa
+func (s *ServidorHTTP) obterKPIsEclusagens(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	where, args, filtros, err := lerFiltrosEclusagens(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := s.bancoDados.Query(`
+		SELECT to_char(date_trunc('day', c.timestamp_inicio), 'YYYY-MM-DD') AS dia, e.codigo,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE c.status = 'CONCLUIDA'),
+			COUNT(*) FILTER (WHERE c.status = 'ABORTADA'),
+			COUNT(*) FILTER (WHERE c.status = 'TIMEOUT'),
+			COUNT(*) FILTER (WHERE c.direcao = 'SUBIDA'),
+			COUNT(*) FILTER (WHERE c.direcao = 'DESCIDA'),
+			AVG(c.duracao_total_segundos) FILTER (WHERE c.status = 'CONCLUIDA'),
+			PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY c.duracao_total_segundos)
+				FILTER (WHERE c.status = 'CONCLUIDA'),
+			AVG(c.duracao_preparacao_segundos) FILTER (WHERE c.status = 'CONCLUIDA'),
+			AVG(c.duracao_nivelamento_segundos) FILTER (WHERE c.status = 'CONCLUIDA'),
+			AVG(c.duracao_abertura_segundos) FILTER (WHERE c.status = 'CONCLUIDA'),
+			COUNT(*) FILTER (WHERE f.falhas > 0),
+			COALESCE(SUM(f.falhas), 0)
+		FROM eclusagens c
+		JOIN eclusas e ON c.eclusa_id = e.id
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS falhas FROM ocorrencias_falhas o WHERE o.eclusagem_id = c.id
+		) f ON true`+where+`
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao calcular indicadores de eclusagens: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	kpis := []KPIEclusagensDia{}
+	for rows.Next() {
+		var kpi KPIEclusagensDia
+		var media, p95, preparacao, nivelamento, abertura sql.NullFloat64
+		err := rows.Scan(&kpi.Dia, &kpi.EclusaCodigo, &kpi.Total, &kpi.Concluidas, &kpi.Abortadas,
+			&kpi.Timeout, &kpi.Subidas, &kpi.Descidas, &media, &p95, &preparacao, &nivelamento,
+			&abertura, &kpi.EclusagensComFalhas, &kpi.TotalFalhas)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler indicadores de eclusagens: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		kpi.DuracaoMediaSegundos = valorOuNulo(media)
+		kpi.DuracaoP95Segundos = valorOuNulo(p95)
+		kpi.PreparacaoMediaSegundos = valorOuNulo(preparacao)
+		kpi.NivelamentoMedioSegundos = valorOuNulo(nivelamento)
+		kpi.AberturaMediaSegundos = valorOuNulo(abertura)
+		if kpi.Total > 0 {
+			kpi.TaxaSucessoPercentual = float64(kpi.Concluidas) * 100 / float64(kpi.Total)
+		}
+		kpis = append(kpis, kpi)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    kpis,
+		"total":   len(kpis),
+		"filtros": filtros,
+	})
+}
+
+// lerEclusagem lê uma linha com as colunas de colunasEclusagem
+func lerEclusagem(linha interface{ Scan(...interface{}) error }) (modelos.Eclusagem, error) {
+	var c modelos.Eclusagem
+	var inicioNivelamento, fimNivelamento, fim sql.NullTime
+	var preparacao, nivelamento, abertura, total sql.NullFloat64
+
+	err := linha.Scan(&c.ID, &c.EclusaCodigo, &c.Direcao, &c.Status, &c.Fase,
+		&c.TimestampInicio, &inicioNivelamento, &fimNivelamento, &fim,
+		&preparacao, &nivelamento, &abertura, &total, &c.Motivo, &c.TotalFalhas)
+	if err != nil {
+		return c, err
+	}
+
+	if inicioNivelamento.Valid {
+		c.TimestampInicioNivelamento = &inicioNivelamento.Time
+	}
+	if fimNivelamento.Valid {
+		c.TimestampFimNivelamento = &fimNivelamento.Time
+	}
+	if fim.Valid {
+		c.TimestampFim = &fim.Time
+	}
+	c.DuracaoPreparacaoSegundos = valorOuNulo(preparacao)
+	c.DuracaoNivelamentoSegundos = valorOuNulo(nivelamento)
+	c.DuracaoAberturaSegundos = valorOuNulo(abertura)
+	c.DuracaoTotalSegundos = valorOuNulo(total)
+	return c, nil
+}
+
+// faseNoInstante indica em que fase do ciclo uma falha começou
+func faseNoInstante(c modelos.Eclusagem, instante time.Time) string {
+	switch {
+	case c.TimestampInicioNivelamento == nil || instante.Before(*c.TimestampInicioNivelamento):
+		return "PREPARACAO"
+	case c.TimestampFimNivelamento == nil || instante.Before(*c.TimestampFimNivelamento):
+		return modelos.FaseEclusagemNivelamento
+	}
+	return modelos.FaseEclusagemAbertura
+}
+
+// valorOuNulo converte um NullFloat64 em ponteiro (nil quando nulo)
+func valorOuNulo(valor sql.NullFloat64) *float64 {
+	if !valor.Valid {
+		return nil
+	}
+	return &valor.Float64
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"github.com/edp/falhas-backend/config"
+	"github.com/gorilla/mux"
+)
+
+// embutido indica se o servidor corre sobre o banco SQLite de uma eclusa isolada
+func (s *ServidorHTTP) embutido() bool {
+	return s.configuracoes != nil && s.configuracoes.DB_Tipo == config.BancoSQLite
+}
+
+// configurarRotasEmbutido regista só as rotas servidas pelos repositórios (ocorrências, dashboard,
+// definições, setores, eclusas, sincronização e saúde); as restantes dependem de tabelas do
+// PostgreSQL
+func (s *ServidorHTTP) configurarRotasEmbutido(api *mux.Router) {
+	// Rotas de ocorrências
+	api.HandleFunc("/ocorrencias/ativas", s.obterOcorrenciasAtivas).Methods("GET")
+	api.HandleFunc("/ocorrencias/historico", s.obterHistoricoOcorrencias).Methods("GET")
+	api.HandleFunc("/ocorrencias/{id}/resolver", s.resolverOcorrencia).Methods("POST")
+
+	// Rotas de estatísticas
+	api.HandleFunc("/estatisticas/dashboard", s.obterEstatisticasDashboard).Methods("GET")
+
+	// Rotas de definições
+	api.HandleFunc("/definicoes/falhas", s.obterDefinicoesFalhas).Methods("GET")
+	api.HandleFunc("/setores", s.obterSetores).Methods("GET")
+	api.HandleFunc("/eclusas", s.obterEclusas).Methods("GET")
+
+	// Rotas da sincronização com o central
+	s.configurarRotasSincronizacao(api)
+
+	// Rota de saúde
+	api.HandleFunc("/health", s.verificarSaude).Methods("GET")
+	api.HandleFunc("/health/banco", s.verificarSaudeBanco).Methods("GET")
+}
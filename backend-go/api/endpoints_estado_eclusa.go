@@ -0,0 +1,289 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+	"github.com/gorilla/mux"
+)
+
+// intervaloKeepAliveEstado mantém a ligação do stream aberta através de proxies
+const intervaloKeepAliveEstado = 15 * time.Second
+
+// FonteEstadoEclusa fornece o estado de processo das eclusas calculado a partir dos frames do PLC
+type FonteEstadoEclusa interface {
+	Estado(codigo string) (modelos.EstadoEclusa, bool)
+	Assinar(codigo string) (<-chan modelos.EstadoEclusa, func())
+}
+
+// DefinirFonteEstado liga a API ao projetor de estado do servidor TCP
+func (s *ServidorHTTP) DefinirFonteEstado(fonte FonteEstadoEclusa) {
+	s.fonteEstado = fonte
+}
+
+// obterEstadoEclusa retorna o estado atual da eclusa (enchimento, esvaziamento, portas e nível)
+func (s *ServidorHTTP) obterEstadoEclusa(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	codigo, ok := s.validarEclusaEstado(w, r)
+	if !ok {
+		return
+	}
+
+	estado, _ := s.fonteEstado.Estado(codigo)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"data":       estado,
+		"disponivel": !estado.UltimaAtualizacao.IsZero(), // Falso até chegar o primeiro frame
+	})
+}
+
+// transmitirEstadoEclusa envia o estado atual e cada mudança como Server-Sent Events
+func (s *ServidorHTTP) transmitirEstadoEclusa(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	flusher, suportado := w.(http.Flusher)
+	if !suportado {
+		http.Error(w, "Streaming não suportado", http.StatusInternalServerError)
+		return
+	}
+
+	codigo, ok := s.validarEclusaEstado(w, r)
+	if !ok {
+		return
+	}
+
+	// Assinar antes de ler o estado atual para não perder mudanças entre os dois
+	mudancas, cancelar := s.fonteEstado.Assinar(codigo)
+	defer cancelar()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	enviar := func(estado modelos.EstadoEclusa) bool {
+		dados, err := json.Marshal(estado)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: estado\ndata: %s\n\n", dados); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	if estado, _ := s.fonteEstado.Estado(codigo); !enviar(estado) {
+		return
+	}
+
+	keepAlive := time.NewTicker(intervaloKeepAliveEstado)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case estado := <-mudancas:
+			if !enviar(estado) {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// validarEclusaEstado confirma que a eclusa existe e tem sinais de estado mapeados
+func (s *ServidorHTTP) validarEclusaEstado(w http.ResponseWriter, r *http.Request) (string, bool) {
+	codigo := strings.ToUpper(mux.Vars(r)["codigo"])
+
+	var id int
+	err := s.bancoDados.QueryRow("SELECT id FROM eclusas WHERE codigo = $1", codigo).Scan(&id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Eclusa não encontrada", http.StatusNotFound)
+		return "", false
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar eclusa: %v", err), http.StatusInternalServerError)
+		return "", false
+	}
+
+	if s.fonteEstado == nil {
+		http.Error(w, "Estado das eclusas indisponível", http.StatusServiceUnavailable)
+		return "", false
+	}
+	if _, mapeada := s.fonteEstado.Estado(codigo); !mapeada {
+		http.Error(w, "Eclusa sem mapeamento de estado configurado", http.StatusNotFound)
+		return "", false
+	}
+
+	return codigo, true
+}
+
+// MapeamentoEstado liga um campo do estado da eclusa a uma definição (bit) ou a uma tag analógica
+type MapeamentoEstado struct {
+	ID                 int    `json:"id"`
+	Campo              string `json:"campo"`
+	DefinicaoCodigo    string `json:"definicao_codigo,omitempty"`
+	DefinicaoDescricao string `json:"definicao_descricao,omitempty"`
+	TagAnalogica       string `json:"tag_analogica,omitempty"`
+	Invertido          bool   `json:"invertido"`
+	Ativo              bool   `json:"ativo"`
+}
+
+// obterMapeamentosEstado lista os sinais que formam o estado da eclusa
+func (s *ServidorHTTP) obterMapeamentosEstado(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	rows, err := s.bancoDados.Query(`
+		SELECT m.id, m.campo, COALESCE(df.codigo, ''), COALESCE(df.descricao, ''),
+			COALESCE(t.tag, ''), m.invertido, m.ativo
+		FROM mapeamentos_estado_eclusa m
+		JOIN eclusas e ON m.eclusa_id = e.id
+		LEFT JOIN definicoes_falhas df ON m.definicao_id = df.id
+		LEFT JOIN tags_analogicas t ON m.tag_analogica_id = t.id
+		WHERE e.codigo = $1
+		ORDER BY m.campo, m.id`, strings.ToUpper(mux.Vars(r)["codigo"]))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar mapeamento de estado: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	mapeamentos := []MapeamentoEstado{}
+	for rows.Next() {
+		var m MapeamentoEstado
+		if err := rows.Scan(&m.ID, &m.Campo, &m.DefinicaoCodigo, &m.DefinicaoDescricao,
+			&m.TagAnalogica, &m.Invertido, &m.Ativo); err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler mapeamento de estado: %v", err), http.StatusInternalServerError)
+			return
+		}
+		mapeamentos = append(mapeamentos, m)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    mapeamentos,
+		"total":   len(mapeamentos),
+	})
+}
+
+// criarMapeamentoEstado liga um campo do estado a uma definição ou tag analógica.
+// O projetor relê o mapeamento a cada minuto.
+func (s *ServidorHTTP) criarMapeamentoEstado(w http.ResponseWriter, r *http.Request) {
+	var entrada struct {
+		Campo           string `json:"campo"`
+		DefinicaoCodigo string `json:"definicao_codigo"`
+		TagAnalogica    string `json:"tag_analogica"`
+		Invertido       bool   `json:"invertido"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	if entrada.Campo == "nivel_agua" {
+		if entrada.TagAnalogica == "" || entrada.DefinicaoCodigo != "" {
+			http.Error(w, "O campo 'nivel_agua' usa apenas 'tag_analogica'", http.StatusBadRequest)
+			return
+		}
+	} else if entrada.DefinicaoCodigo == "" || entrada.TagAnalogica != "" {
+		http.Error(w, "Campos booleanos usam apenas 'definicao_codigo'", http.StatusBadRequest)
+		return
+	}
+
+	var id int
+	err := s.bancoDados.QueryRow(`
+		INSERT INTO mapeamentos_estado_eclusa (eclusa_id, campo, definicao_id, tag_analogica_id, invertido)
+		SELECT e.id, $2,
+			(SELECT df.id FROM definicoes_falhas df WHERE df.codigo = $3 AND df.eclusa_id = e.id),
+			(SELECT t.id FROM tags_analogicas t WHERE t.tag = $4),
+			$5
+		FROM eclusas e WHERE e.codigo = $1
+		RETURNING id`,
+		strings.ToUpper(mux.Vars(r)["codigo"]), entrada.Campo,
+		entrada.DefinicaoCodigo, entrada.TagAnalogica, entrada.Invertido).Scan(&id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Eclusa não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		// As restrições da tabela rejeitam campo inválido, definição/tag inexistente e duplicados
+		http.Error(w, fmt.Sprintf("Erro ao criar mapeamento de estado: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    map[string]int{"id": id},
+	})
+}
+
+// removerMapeamentoEstado remove um sinal do estado da eclusa
+func (s *ServidorHTTP) removerMapeamentoEstado(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.bancoDados.Exec(`
+		DELETE FROM mapeamentos_estado_eclusa
+		WHERE id = $1 AND eclusa_id = (SELECT id FROM eclusas WHERE codigo = $2)`,
+		id, strings.ToUpper(mux.Vars(r)["codigo"]))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao remover mapeamento de estado: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		http.Error(w, "Mapeamento de estado não encontrado", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Mapeamento de estado removido com sucesso",
+	})
+}
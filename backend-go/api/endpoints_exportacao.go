@@ -0,0 +1,170 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/database"
+	"github.com/edp/falhas-backend/exportacao"
+	"github.com/edp/falhas-backend/repositorio"
+)
+
+// limiteExportacao protege o servidor de exportações acidentais da tabela inteira: acima dele a
+// exportação é recusada antes de começar, em vez de sair truncada
+const limiteExportacao = 1000000
+
+// colunasExportacao define a ordem, os cabeçalhos (pt-PT) e os nomes Parquet das colunas exportadas
+var colunasExportacao = []exportacao.Coluna{
+	{Titulo: "ID", Nome: "id", Tipo: exportacao.TipoInteiro},
+	{Titulo: "Eclusa", Nome: "eclusa", Tipo: exportacao.TipoTexto},
+	{Titulo: "Setor", Nome: "setor", Tipo: exportacao.TipoTexto},
+	{Titulo: "Código", Nome: "codigo", Tipo: exportacao.TipoTexto},
+	{Titulo: "Tipo", Nome: "tipo", Tipo: exportacao.TipoTexto},
+	{Titulo: "Descrição", Nome: "descricao", Tipo: exportacao.TipoTexto},
+	{Titulo: "Prioridade", Nome: "prioridade", Tipo: exportacao.TipoTexto},
+	{Titulo: "Criticidade", Nome: "criticidade", Tipo: exportacao.TipoInteiro},
+	{Titulo: "Relacionada com segurança", Nome: "relacionada_seguranca", Tipo: exportacao.TipoBooleano},
+	{Titulo: "Estado", Nome: "status", Tipo: exportacao.TipoTexto},
+	{Titulo: "Início", Nome: "timestamp_inicio", Tipo: exportacao.TipoDataHora},
+	{Titulo: "Fim", Nome: "timestamp_fim", Tipo: exportacao.TipoDataHora},
+	{Titulo: "Duração (s)", Nome: "duracao_segundos", Tipo: exportacao.TipoInteiro},
+	{Titulo: "SLA violado", Nome: "sla_violado", Tipo: exportacao.TipoBooleano},
+	{Titulo: "Word", Nome: "word_index", Tipo: exportacao.TipoInteiro},
+	{Titulo: "Bit", Nome: "bit_index", Tipo: exportacao.TipoInteiro},
+	{Titulo: "First-out", Nome: "first_out", Tipo: exportacao.TipoBooleano},
+	{Titulo: "Suprimida por", Nome: "suprimida_por", Tipo: exportacao.TipoInteiro},
+	{Titulo: "Resolvido por", Nome: "resolvido_por", Tipo: exportacao.TipoTexto},
+	{Titulo: "Observações", Nome: "observacoes", Tipo: exportacao.TipoTexto},
+}
+
+// exportarOcorrencias exporta o histórico (mesmos filtros e ordenação) em CSV, XLSX ou Parquet.
+// As linhas são escritas à medida que o repositório as lê; cursor e limite são ignorados.
+func (s *ServidorHTTP) exportarOcorrencias(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	formato := strings.ToLower(r.URL.Query().Get("formato"))
+	if formato == "" {
+		formato = exportacao.FormatoCSV
+	}
+	if !exportacao.FormatoValido(formato) {
+		http.Error(w, "Formato inválido: use csv, xlsx ou parquet", http.StatusBadRequest)
+		return
+	}
+
+	fuso := time.Local
+	if nome := r.URL.Query().Get("fuso"); nome != "" {
+		var err error
+		fuso, err = time.LoadLocation(nome)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Fuso horário inválido: %s (use um nome IANA, ex.: Europe/Lisbon)", nome), http.StatusBadRequest)
+			return
+		}
+	}
+
+	filtros, err := lerFiltrosHistorico(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filtro, err := filtros.filtroRepositorio()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filtro.Apos, filtro.Limite = nil, 0
+
+	total, err := s.repositorios.Ocorrencias.ContarHistorico(filtro)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao contar ocorrências para exportação: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if total > limiteExportacao {
+		http.Error(w, fmt.Sprintf("A exportação excede %d linhas: restrinja os filtros (ex.: inicio/fim ou eclusa)", limiteExportacao),
+			http.StatusBadRequest)
+		return
+	}
+
+	// O escritor só começa com a primeira linha: um erro ao abrir a leitura ainda pode ter resposta 500
+	var escritor exportacao.Escritor
+	iniciar := func() error {
+		nomeArquivo := fmt.Sprintf("ocorrencias_%s.%s", time.Now().In(fuso).Format("20060102_1504"), formato)
+		w.Header().Set("Content-Type", exportacao.TipoConteudo(formato))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, nomeArquivo))
+
+		var err error
+		escritor, err = exportacao.NovoEscritor(formato, w, colunasExportacao, fuso)
+		return err
+	}
+
+	err = s.repositorios.Ocorrencias.PercorrerHistorico(filtro, func(oc repositorio.Ocorrencia) error {
+		if escritor == nil {
+			if err := iniciar(); err != nil {
+				return err
+			}
+		}
+		if err := escritor.EscreverLinha(linhaExportacao(oc)); err != nil {
+			return fmt.Errorf("erro ao escrever exportação (%s): %v", formato, err)
+		}
+		return nil
+	})
+	if err != nil {
+		if escritor == nil {
+			http.Error(w, fmt.Sprintf("Erro ao exportar ocorrências: %v", err), http.StatusInternalServerError)
+			return
+		}
+		// A resposta já começou: o erro só pode ser registado no log
+		log.Printf("❌ %v", err)
+		return
+	}
+
+	if escritor == nil {
+		if err := iniciar(); err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao iniciar exportação: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := escritor.Fechar(); err != nil {
+		log.Printf("❌ Erro ao terminar exportação (%s): %v", formato, err)
+	}
+}
+
+// linhaExportacao converte a ocorrência nos valores das colunasExportacao (nil = vazio)
+func linhaExportacao(oc repositorio.Ocorrencia) []interface{} {
+	var fim, duracao, suprimidaPor interface{}
+	if oc.TimestampFim != nil {
+		fim = database.HoraLocal(*oc.TimestampFim)
+	}
+	if oc.DuracaoSegundos != nil {
+		duracao = *oc.DuracaoSegundos
+	}
+	if oc.SuprimidaPor != nil {
+		suprimidaPor = *oc.SuprimidaPor
+	}
+	return []interface{}{
+		oc.ID, oc.EclusaCodigo, oc.SetorCodigo, oc.Codigo, oc.Tipo, oc.Descricao, oc.Prioridade,
+		int64(oc.Criticidade), oc.RelacionadaSeguranca, oc.Status,
+		database.HoraLocal(oc.TimestampInicio), fim, duracao, oc.SLAViolado,
+		int64(oc.WordIndex), int64(oc.BitIndex), oc.FirstOut, suprimidaPor,
+		textoOuNulo(oc.ResolvidoPor), textoOuNulo(oc.Observacoes),
+	}
+}
+
+// textoOuNulo exporta um texto vazio como célula vazia
+func textoOuNulo(valor string) interface{} {
+	if valor == "" {
+		return nil
+	}
+	return valor
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestExportacaoCSVSegueFiltrosEOrdenacaoDoHistorico(t *testing.T) {
+	s := servidorTeste(t)
+
+	gravador := httptest.NewRecorder()
+	s.router.ServeHTTP(gravador, httptest.NewRequest(http.MethodGet,
+		"/api/v1/ocorrencias/exportar?formato=csv&eclusa=rg&status=RESOLVIDO&ordenar=duracao&ordem=asc&limite=2", nil))
+	if gravador.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", gravador.Code, gravador.Body.String())
+	}
+	if !strings.HasPrefix(gravador.Header().Get("Content-Disposition"), `attachment; filename="ocorrencias_`) {
+		t.Fatalf("Content-Disposition = %q", gravador.Header().Get("Content-Disposition"))
+	}
+
+	leitor := csv.NewReader(strings.NewReader(strings.TrimPrefix(gravador.Body.String(), "\uFEFF")))
+	leitor.Comma = ';'
+	linhas, err := leitor.ReadAll()
+	if err != nil {
+		t.Fatalf("CSV inválido: %v", err)
+	}
+	// Cabeçalho e as oito resolvidas da RG: o limite da página não se aplica à exportação
+	if len(linhas) != 9 || linhas[0][0] != "ID" {
+		t.Fatalf("%d linhas exportadas; esperadas o cabeçalho e 8 ocorrências", len(linhas))
+	}
+
+	coluna := -1
+	for i, titulo := range linhas[0] {
+		if titulo == "Duração (s)" {
+			coluna = i
+		}
+	}
+	anterior := int64(-1)
+	for _, linha := range linhas[1:] {
+		if linha[1] != "RG" || linha[9] != "RESOLVIDO" {
+			t.Fatalf("linha fora do filtro: %v", linha)
+		}
+		duracao, err := strconv.ParseInt(linha[coluna], 10, 64)
+		if err != nil || duracao < anterior {
+			t.Fatalf("duração %q depois de %d; esperada a ordem ascendente", linha[coluna], anterior)
+		}
+		anterior = duracao
+	}
+}
+
+func TestExportacaoRecusaFiltrosInvalidos(t *testing.T) {
+	s := servidorTeste(t)
+	for _, consulta := range []string{"formato=pdf", "ordenar=nome", "fuso=Lua/Base"} {
+		gravador := httptest.NewRecorder()
+		s.router.ServeHTTP(gravador, httptest.NewRequest(http.MethodGet, "/api/v1/ocorrencias/exportar?"+consulta, nil))
+		if gravador.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d; esperado %d", consulta, gravador.Code, http.StatusBadRequest)
+		}
+	}
+}
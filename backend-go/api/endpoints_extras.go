@@ -3,31 +3,20 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/edp/falhas-backend/repositorio"
 	"github.com/gorilla/mux"
 )
 
 // EstatisticasDashboard representa as estatísticas principais do sistema
-type EstatisticasDashboard struct {
-	OcorrenciasAtivas    int                    `json:"ocorrencias_ativas"`
-	FalhasUltimas24h     int                    `json:"falhas_ultimas_24h"`
-	EventosUltimas24h    int                    `json:"eventos_ultimas_24h"`
-	TotalOcorrencias     int                    `json:"total_ocorrencias"`
-	PorSetor             map[string]int         `json:"por_setor"`
-	PorPrioridade        map[string]int         `json:"por_prioridade"`
-	TopFalhasFrequentes  []FalhaFrequente       `json:"top_falhas_frequentes"`
-	TempoMedioResolucao  float64                `json:"tempo_medio_resolucao_horas"`
-}
+type EstatisticasDashboard = repositorio.EstatisticasDashboard
 
 // FalhaFrequente representa uma falha com sua frequência
-type FalhaFrequente struct {
-	Codigo      string `json:"codigo"`
-	Descricao   string `json:"descricao"`
-	SetorNome   string `json:"setor_nome"`
-	Frequencia  int    `json:"frequencia"`
-}
+type FalhaFrequente = repositorio.FalhaFrequente
 
 // EstatisticaPorSetor representa estatísticas agrupadas por setor
 type EstatisticaPorSetor struct {
@@ -40,38 +29,13 @@ type EstatisticaPorSetor struct {
 }
 
 // DefinicaoFalhaAPI representa uma definição de falha para o front-end
-type DefinicaoFalhaAPI struct {
-	ID             int    `json:"id"`
-	Codigo         string `json:"codigo"`
-	Tipo           string `json:"tipo"`
-	Descricao      string `json:"descricao"`
-	Prioridade     string `json:"prioridade"`
-	WordIndex      int    `json:"word_index"`
-	BitIndex       int    `json:"bit_index"`
-	ClasseMensagem string `json:"classe_mensagem"`
-	SetorCodigo    string `json:"setor_codigo"`
-	SetorNome      string `json:"setor_nome"`
-	EclusaCodigo   string `json:"eclusa_codigo"`
-	EclusaNome     string `json:"eclusa_nome"`
-	Ativa          bool   `json:"ativa"`
-}
+type DefinicaoFalhaAPI = repositorio.Definicao
 
 // Setor representa um setor do sistema
-type Setor struct {
-	ID       int    `json:"id"`
-	Codigo   string `json:"codigo"`
-	Nome     string `json:"nome"`
-	CorTema  string `json:"cor_tema"`
-}
+type Setor = repositorio.Setor
 
 // Eclusa representa uma eclusa do sistema
-type Eclusa struct {
-	ID         int    `json:"id"`
-	Codigo     string `json:"codigo"`
-	Nome       string `json:"nome"`
-	Localizacao string `json:"localizacao"`
-	Ativa      bool   `json:"ativa"`
-}
+type Eclusa = repositorio.Eclusa
 
 // obterEstatisticasDashboard retorna estatísticas gerais para o dashboard
 func (s *ServidorHTTP) obterEstatisticasDashboard(w http.ResponseWriter, r *http.Request) {
@@ -85,107 +49,13 @@ func (s *ServidorHTTP) obterEstatisticasDashboard(w http.ResponseWriter, r *http
 		return
 	}
 
-	stats := EstatisticasDashboard{
-		PorSetor:      make(map[string]int),
-		PorPrioridade: make(map[string]int),
-	}
-	
-	// Ocorrências ativas
-	err := s.bancoDados.QueryRow("SELECT COUNT(*) FROM ocorrencias_falhas WHERE status = 'ATIVO'").Scan(&stats.OcorrenciasAtivas)
+	// Filtro opcional por eclusa, aplicado a todos os indicadores
+	stats, err := s.repositorios.Estatisticas.Dashboard(strings.ToUpper(r.URL.Query().Get("eclusa")))
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Erro ao buscar ocorrências ativas: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Erro ao buscar estatísticas: %v", err), http.StatusInternalServerError)
 		return
 	}
 	
-	// Falhas e eventos últimas 24h
-	err = s.bancoDados.QueryRow(`
-		SELECT COUNT(*) FROM ocorrencias_falhas o
-		JOIN definicoes_falhas df ON o.definicao_id = df.id
-		WHERE df.tipo = 'FALHA' AND o.timestamp_inicio >= NOW() - INTERVAL '24 hours'`).Scan(&stats.FalhasUltimas24h)
-	if err != nil {
-		stats.FalhasUltimas24h = 0
-	}
-	
-	err = s.bancoDados.QueryRow(`
-		SELECT COUNT(*) FROM ocorrencias_falhas o
-		JOIN definicoes_falhas df ON o.definicao_id = df.id
-		WHERE df.tipo = 'EVENTO' AND o.timestamp_inicio >= NOW() - INTERVAL '24 hours'`).Scan(&stats.EventosUltimas24h)
-	if err != nil {
-		stats.EventosUltimas24h = 0
-	}
-	
-	// Total de ocorrências
-	err = s.bancoDados.QueryRow("SELECT COUNT(*) FROM ocorrencias_falhas").Scan(&stats.TotalOcorrencias)
-	if err != nil {
-		stats.TotalOcorrencias = 0
-	}
-	
-	// Por setor
-	rows, err := s.bancoDados.Query(`
-		SELECT s.nome, COUNT(o.id)
-		FROM ocorrencias_falhas o
-		JOIN definicoes_falhas df ON o.definicao_id = df.id
-		JOIN setores s ON df.setor_id = s.id
-		WHERE o.status = 'ATIVO'
-		GROUP BY s.nome`)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var setor string
-			var count int
-			if rows.Scan(&setor, &count) == nil {
-				stats.PorSetor[setor] = count
-			}
-		}
-	}
-	
-	// Por prioridade
-	rows, err = s.bancoDados.Query(`
-		SELECT df.prioridade, COUNT(o.id)
-		FROM ocorrencias_falhas o
-		JOIN definicoes_falhas df ON o.definicao_id = df.id
-		WHERE o.status = 'ATIVO'
-		GROUP BY df.prioridade`)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var prioridade string
-			var count int
-			if rows.Scan(&prioridade, &count) == nil {
-				stats.PorPrioridade[prioridade] = count
-			}
-		}
-	}
-	
-	// Top falhas frequentes (últimos 7 dias)
-	rows, err = s.bancoDados.Query(`
-		SELECT df.codigo, df.descricao, s.nome, COUNT(o.id) as freq
-		FROM ocorrencias_falhas o
-		JOIN definicoes_falhas df ON o.definicao_id = df.id
-		JOIN setores s ON df.setor_id = s.id
-		WHERE o.timestamp_inicio >= NOW() - INTERVAL '7 days'
-		GROUP BY df.codigo, df.descricao, s.nome
-		ORDER BY freq DESC
-		LIMIT 10`)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var falha FalhaFrequente
-			if rows.Scan(&falha.Codigo, &falha.Descricao, &falha.SetorNome, &falha.Frequencia) == nil {
-				stats.TopFalhasFrequentes = append(stats.TopFalhasFrequentes, falha)
-			}
-		}
-	}
-	
-	// Tempo médio de resolução (em horas)
-	err = s.bancoDados.QueryRow(`
-		SELECT AVG(EXTRACT(EPOCH FROM (timestamp_fim - timestamp_inicio)) / 3600)
-		FROM ocorrencias_falhas
-		WHERE status = 'RESOLVIDO' AND timestamp_fim IS NOT NULL`).Scan(&stats.TempoMedioResolucao)
-	if err != nil {
-		stats.TempoMedioResolucao = 0
-	}
-	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
@@ -195,6 +65,14 @@ func (s *ServidorHTTP) obterEstatisticasDashboard(w http.ResponseWriter, r *http
 
 // obterEstatisticasPorSetor retorna estatísticas agrupadas por setor
 func (s *ServidorHTTP) obterEstatisticasPorSetor(w http.ResponseWriter, r *http.Request) {
+	// Com eclusa, só contam as definições dessa eclusa (os setores aparecem todos)
+	juncaoEclusa := ""
+	var args []interface{}
+	if eclusa := strings.ToUpper(r.URL.Query().Get("eclusa")); eclusa != "" {
+		args = append(args, eclusa)
+		juncaoEclusa = " AND df.eclusa_id IN (SELECT id FROM eclusas WHERE codigo = $1)"
+	}
+	
 	rows, err := s.bancoDados.Query(`
 		SELECT 
 			s.codigo, s.nome,
@@ -204,10 +82,10 @@ func (s *ServidorHTTP) obterEstatisticasPorSetor(w http.ResponseWriter, r *http.
 				THEN EXTRACT(EPOCH FROM (o.timestamp_fim - o.timestamp_inicio)) / 3600 END) as tempo_medio,
 			MAX(o.timestamp_inicio) as ultima
 		FROM setores s
-		LEFT JOIN definicoes_falhas df ON s.id = df.setor_id
+		LEFT JOIN definicoes_falhas df ON s.id = df.setor_id` + juncaoEclusa + `
 		LEFT JOIN ocorrencias_falhas o ON df.id = o.definicao_id
 		GROUP BY s.codigo, s.nome
-		ORDER BY s.nome`)
+		ORDER BY s.nome`, args...)
 	
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Erro ao buscar estatísticas por setor: %v", err), http.StatusInternalServerError)
@@ -227,7 +105,8 @@ func (s *ServidorHTTP) obterEstatisticasPorSetor(w http.ResponseWriter, r *http.
 			&tempoMedio, &ultima)
 		
 		if err != nil {
-			continue
+			http.Error(w, fmt.Sprintf("Erro ao ler estatísticas por setor: %v", err), http.StatusInternalServerError)
+			return
 		}
 		
 		if tempoMedio != nil {
@@ -265,61 +144,16 @@ func (s *ServidorHTTP) obterDefinicoesFalhas(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Filtros opcionais
-	setor := r.URL.Query().Get("setor")
-	tipo := r.URL.Query().Get("tipo")
-	
-	baseQuery := `
-		SELECT 
-			df.id, df.codigo, df.tipo, df.descricao, df.prioridade,
-			df.word_index, df.bit_index, df.classe_mensagem, df.ativa,
-			s.codigo as setor_codigo, s.nome as setor_nome,
-			e.codigo as eclusa_codigo, e.nome as eclusa_nome
-		FROM definicoes_falhas df
-		JOIN setores s ON df.setor_id = s.id
-		JOIN eclusas e ON df.eclusa_id = e.id
-		WHERE 1=1`
-	
-	args := []interface{}{}
-	argIndex := 1
-	
-	if setor != "" {
-		baseQuery += fmt.Sprintf(" AND s.codigo = $%d", argIndex)
-		args = append(args, setor)
-		argIndex++
-	}
-	
-	if tipo != "" {
-		baseQuery += fmt.Sprintf(" AND df.tipo = $%d", argIndex)
-		args = append(args, tipo)
-		argIndex++
-	}
-	
-	baseQuery += " ORDER BY df.word_index, df.bit_index"
-	
-	rows, err := s.bancoDados.Query(baseQuery, args...)
+	definicoes, err := s.repositorios.Definicoes.Listar(repositorio.FiltroDefinicoes{
+		Eclusa:     strings.ToUpper(r.URL.Query().Get("eclusa")),
+		Setor:      r.URL.Query().Get("setor"),
+		Tipo:       r.URL.Query().Get("tipo"),
+		Prioridade: strings.ToUpper(r.URL.Query().Get("prioridade")),
+	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Erro ao buscar definições: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-	
-	var definicoes []DefinicaoFalhaAPI
-	
-	for rows.Next() {
-		var def DefinicaoFalhaAPI
-		
-		err := rows.Scan(
-			&def.ID, &def.Codigo, &def.Tipo, &def.Descricao, &def.Prioridade,
-			&def.WordIndex, &def.BitIndex, &def.ClasseMensagem, &def.Ativa,
-			&def.SetorCodigo, &def.SetorNome,
-			&def.EclusaCodigo, &def.EclusaNome)
-		
-		if err != nil {
-			continue
-		}
-		
-		definicoes = append(definicoes, def)
-	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -341,32 +175,12 @@ func (s *ServidorHTTP) obterSetores(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := s.bancoDados.Query("SELECT id, codigo, nome, cor_tema FROM setores ORDER BY nome")
+	// Com eclusa, só os setores que têm definições nessa eclusa
+	setores, err := s.repositorios.Setores.Listar(strings.ToUpper(r.URL.Query().Get("eclusa")))
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Erro ao buscar setores: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-	
-	var setores []Setor
-	
-	for rows.Next() {
-		var setor Setor
-		var corTema interface{}
-		
-		err := rows.Scan(&setor.ID, &setor.Codigo, &setor.Nome, &corTema)
-		if err != nil {
-			continue
-		}
-		
-		if corTema != nil {
-			if cor, ok := corTema.(string); ok {
-				setor.CorTema = cor
-			}
-		}
-		
-		setores = append(setores, setor)
-	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -387,31 +201,20 @@ func (s *ServidorHTTP) obterEclusas(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := s.bancoDados.Query("SELECT id, codigo, nome, localizacao, ativa FROM eclusas ORDER BY nome")
+	eclusas, err := s.repositorios.Eclusas.Listar()
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Erro ao buscar eclusas: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 	
-	var eclusas []Eclusa
-	
-	for rows.Next() {
-		var eclusa Eclusa
-		var localizacao interface{}
-		
-		err := rows.Scan(&eclusa.ID, &eclusa.Codigo, &eclusa.Nome, &localizacao, &eclusa.Ativa)
-		if err != nil {
-			continue
-		}
-		
-		if localizacao != nil {
-			if loc, ok := localizacao.(string); ok {
-				eclusa.Localizacao = loc
+	if codigo := strings.ToUpper(r.URL.Query().Get("eclusa")); codigo != "" {
+		filtradas := []Eclusa{}
+		for _, eclusa := range eclusas {
+			if eclusa.Codigo == codigo {
+				filtradas = append(filtradas, eclusa)
 			}
 		}
-		
-		eclusas = append(eclusas, eclusa)
+		eclusas = filtradas
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
@@ -433,25 +236,32 @@ func (s *ServidorHTTP) resolverOcorrencia(w http.ResponseWriter, r *http.Request
 	}
 	
 	// Atualizar ocorrência
-	result, err := s.bancoDados.Exec(`
-		UPDATE ocorrencias_falhas 
-		SET status = 'RESOLVIDO', timestamp_fim = NOW(), resolvido_por = 'USUARIO_MANUAL'
-		WHERE id = $1 AND status = 'ATIVO'`, id)
-	
+	resolvida, err := s.repositorios.Ocorrencias.ResolverManualmente(id)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Erro ao resolver ocorrência: %v", err), http.StatusInternalServerError)
 		return
 	}
 	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		http.Error(w, "Erro ao verificar resultado", http.StatusInternalServerError)
+	if !resolvida {
+		http.Error(w, "Ocorrência não encontrada ou já resolvida", http.StatusNotFound)
 		return
 	}
 	
-	if rowsAffected == 0 {
-		http.Error(w, "Ocorrência não encontrada ou já resolvida", http.StatusNotFound)
-		return
+	// Observação opcional enviada no corpo da requisição
+	var entrada struct {
+		Observacao string `json:"observacao"`
+	}
+	json.NewDecoder(r.Body).Decode(&entrada)
+	
+	err = s.repositorios.Ocorrencias.RegistrarTransicao(repositorio.Transicao{
+		OcorrenciaID:   id,
+		StatusAnterior: "ATIVO",
+		StatusNovo:     "RESOLVIDO",
+		Origem:         "USUARIO_MANUAL",
+		Observacao:     entrada.Observacao,
+	})
+	if err != nil {
+		log.Printf("⚠️ %v", err)
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
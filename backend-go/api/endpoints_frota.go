@@ -0,0 +1,453 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/repositorio"
+)
+
+const (
+	limitePadraoComparacao = 50
+	limiteMaximoComparacao = 500
+
+	// Penalizações do índice de saúde (0 a 100) de cada eclusa
+	pesoAtivaAlta           = 15
+	pesoAtivaMedia          = 5
+	pesoAtivaBaixa          = 1
+	maximoPenalAtivas       = 40
+	pesoSLAViolado          = 10
+	maximoPenalSLA          = 30
+	pesoFalha24h            = 2
+	maximoPenalFalhas24h    = 20
+	penalSincronizacao      = 10
+	atrasoMaximoSincronizar = time.Hour
+
+	// Limites do estado da eclusa pelo índice de saúde
+	saudeMinimaOK      = 80
+	saudeMinimaAtencao = 50
+)
+
+// condicaoOcorrenciaNaEclusa limita as ocorrências (alias o) às definições da eclusa no parâmetro $n
+func condicaoOcorrenciaNaEclusa(n int) string {
+	return fmt.Sprintf(`o.definicao_id IN (
+			SELECT dfe.id FROM definicoes_falhas dfe JOIN eclusas ee ON dfe.eclusa_id = ee.id WHERE ee.codigo = $%d)`, n)
+}
+
+// ResumoAtivasEclusa conta as ocorrências ativas de uma eclusa
+type ResumoAtivasEclusa struct {
+	EclusaCodigo  string         `json:"eclusa_codigo"`
+	EclusaNome    string         `json:"eclusa_nome"`
+	Total         int            `json:"total"`
+	PorPrioridade map[string]int `json:"por_prioridade"`
+	SLAViolado    int            `json:"sla_violado"`
+}
+
+// ComparacaoEclusa é a taxa de uma falha equivalente numa eclusa
+type ComparacaoEclusa struct {
+	EclusaCodigo     string   `json:"eclusa_codigo"`
+	EclusaNome       string   `json:"eclusa_nome"`
+	DefinicaoID      int      `json:"definicao_id"`
+	Codigo           string   `json:"codigo"`
+	Ocorrencias      int      `json:"ocorrencias"`
+	TaxaPorDia       float64  `json:"taxa_por_dia"`
+	IndiceFrota      *float64 `json:"indice_frota,omitempty"` // Taxa / média da frota (vazio se a média é 0)
+	DuracaoMediaSegs *float64 `json:"duracao_media_segundos,omitempty"`
+}
+
+// ComparacaoFalha compara a mesma falha (mesmo point_index) nas eclusas que a têm definida
+type ComparacaoFalha struct {
+	PointIndex     int                `json:"point_index"`
+	Descricao      string             `json:"descricao"`
+	Tipo           string             `json:"tipo"`
+	TaxaMediaFrota float64            `json:"taxa_media_frota"`
+	DesvioPadrao   float64            `json:"desvio_padrao"`
+	Amplitude      float64            `json:"amplitude"` // Maior taxa - menor taxa
+	Eclusas        []ComparacaoEclusa `json:"eclusas"`
+}
+
+// SaudeEclusa é o índice de saúde de uma eclusa e os números que o compõem
+type SaudeEclusa struct {
+	EclusaCodigo          string     `json:"eclusa_codigo"`
+	EclusaNome            string     `json:"eclusa_nome"`
+	Indice                int        `json:"indice"`
+	Estado                string     `json:"estado"` // OK, ATENCAO ou CRITICO
+	AtivasAlta            int        `json:"ativas_alta"`
+	AtivasMedia           int        `json:"ativas_media"`
+	AtivasBaixa           int        `json:"ativas_baixa"`
+	EventosAtivos         int        `json:"eventos_ativos"`
+	SLAViolado            int        `json:"sla_violado"`
+	Falhas24h             int        `json:"falhas_24h"`
+	UltimoLote            *time.Time `json:"ultimo_lote,omitempty"`
+	SincronizacaoAtrasada bool       `json:"sincronizacao_atrasada"`
+}
+
+// obterAtivasFrota retorna as ocorrências ativas de todas as eclusas e um resumo por eclusa
+func (s *ServidorHTTP) obterAtivasFrota(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	filtrosSeveridade, err := lerFiltrosSeveridade(r)
+	if err == nil {
+		err = filtrosSeveridade.validarOrdenacao()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	eclusa := strings.ToUpper(r.URL.Query().Get("eclusa"))
+	ocorrencias, err := s.repositorios.Ocorrencias.ListarAtivas(repositorio.FiltroAtivas{
+		Eclusa:            eclusa,
+		IncluirSuprimidas: r.URL.Query().Get("incluir_suprimidas") == "true",
+		IncluirManutencao: r.URL.Query().Get("incluir_manutencao") == "true",
+		Prioridades:       filtrosSeveridade.Prioridades,
+		SLAViolado:        filtrosSeveridade.SLAViolado,
+		Ordenar:           filtrosSeveridade.Ordenar,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar ocorrências: %v", err), http.StatusInternalServerError)
+		return
+	}
+	eclusas, err := s.repositorios.Eclusas.Listar()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar eclusas: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Todas as eclusas aparecem no resumo, mesmo sem ocorrências ativas
+	resumos := []*ResumoAtivasEclusa{}
+	porCodigo := make(map[string]*ResumoAtivasEclusa)
+	for _, e := range eclusas {
+		if eclusa != "" && e.Codigo != eclusa {
+			continue
+		}
+		resumo := &ResumoAtivasEclusa{EclusaCodigo: e.Codigo, EclusaNome: e.Nome, PorPrioridade: map[string]int{}}
+		resumos = append(resumos, resumo)
+		porCodigo[e.Codigo] = resumo
+	}
+	for _, o := range ocorrencias {
+		resumo := porCodigo[o.EclusaCodigo]
+		if resumo == nil {
+			continue
+		}
+		resumo.Total++
+		resumo.PorPrioridade[o.Prioridade]++
+		if o.SLAViolado {
+			resumo.SLAViolado++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"data":       ocorrencias,
+		"total":      len(ocorrencias),
+		"por_eclusa": resumos,
+		"filtros":    filtrosSeveridade,
+	})
+}
+
+// obterComparacaoFrota compara a taxa de cada falha entre as eclusas que têm a mesma definição
+// (mesmo point_index). Só entram as falhas definidas em pelo menos duas eclusas; com eclusa, só
+// as falhas que essa eclusa tem. Ordenado pela maior diferença de taxa entre eclusas.
+func (s *ServidorHTTP) obterComparacaoFrota(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	filtros, err := lerFiltrosConfiabilidade(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tipo := strings.ToUpper(r.URL.Query().Get("tipo"))
+	if tipo == "" {
+		tipo = "FALHA"
+	}
+	if tipo != "FALHA" && tipo != "EVENTO" {
+		http.Error(w, "Parâmetro 'tipo' inválido: use FALHA ou EVENTO", http.StatusBadRequest)
+		return
+	}
+	limite := limitePadraoComparacao
+	if valor := r.URL.Query().Get("limite"); valor != "" {
+		limite, err = strconv.Atoi(valor)
+		if err != nil || limite <= 0 || limite > limiteMaximoComparacao {
+			http.Error(w, fmt.Sprintf("Parâmetro 'limite' inválido: use um inteiro entre 1 e %d", limiteMaximoComparacao), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Os filtros das ocorrências ficam na junção para as definições sem ocorrências contarem com 0
+	juncao := " AND o.timestamp_inicio >= $1 AND o.timestamp_inicio < $2"
+	args := []interface{}{filtros.Inicio, filtros.Fim, tipo}
+	if !filtros.IncluirSuprimidas {
+		juncao += " AND o.suprimida_por IS NULL"
+	}
+	if !filtros.IncluirManutencao {
+		juncao += " AND " + condicaoForaJanelaOculta
+	}
+	where := " WHERE df.tipo = $3"
+	if filtros.Setor != "" {
+		args = append(args, filtros.Setor)
+		where += fmt.Sprintf(" AND s.codigo = $%d", len(args))
+	}
+	if filtros.Prioridade != "" {
+		args = append(args, filtros.Prioridade)
+		where += fmt.Sprintf(" AND df.prioridade = $%d", len(args))
+	}
+
+	rows, err := s.bancoDados.Query(`
+		SELECT df.point_index, df.id, df.codigo, df.descricao, e.codigo, e.nome, COUNT(o.id),
+			AVG(CASE WHEN o.timestamp_fim IS NOT NULL
+				THEN EXTRACT(EPOCH FROM (o.timestamp_fim - o.timestamp_inicio)) END)
+		FROM definicoes_falhas df
+		JOIN eclusas e ON df.eclusa_id = e.id
+		JOIN setores s ON df.setor_id = s.id
+		LEFT JOIN ocorrencias_falhas o ON o.definicao_id = df.id`+juncao+where+`
+		GROUP BY df.point_index, df.id, df.codigo, df.descricao, e.codigo, e.nome
+		ORDER BY df.point_index, e.codigo`, args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao comparar eclusas: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	dias := filtros.Fim.Sub(filtros.Inicio).Hours() / 24
+	grupos := []*ComparacaoFalha{}
+	porPonto := make(map[int]*ComparacaoFalha)
+	for rows.Next() {
+		var pointIndex int
+		var descricao string
+		var c ComparacaoEclusa
+		if err := rows.Scan(&pointIndex, &c.DefinicaoID, &c.Codigo, &descricao, &c.EclusaCodigo, &c.EclusaNome,
+			&c.Ocorrencias, &c.DuracaoMediaSegs); err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler comparação: %v", err), http.StatusInternalServerError)
+			return
+		}
+		c.TaxaPorDia = arredondar(float64(c.Ocorrencias) / dias)
+		if c.DuracaoMediaSegs != nil {
+			duracao := arredondar(*c.DuracaoMediaSegs)
+			c.DuracaoMediaSegs = &duracao
+		}
+
+		grupo := porPonto[pointIndex]
+		if grupo == nil {
+			grupo = &ComparacaoFalha{PointIndex: pointIndex, Descricao: descricao, Tipo: tipo}
+			porPonto[pointIndex] = grupo
+			grupos = append(grupos, grupo)
+		}
+		grupo.Eclusas = append(grupo.Eclusas, c)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao ler comparação: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	comparacoes := []*ComparacaoFalha{}
+	for _, grupo := range grupos {
+		if len(grupo.Eclusas) < 2 || (filtros.Eclusa != "" && !grupo.temEclusa(filtros.Eclusa)) {
+			continue
+		}
+		grupo.calcularDispersao()
+		comparacoes = append(comparacoes, grupo)
+	}
+	sort.SliceStable(comparacoes, func(i, j int) bool {
+		return comparacoes[i].Amplitude > comparacoes[j].Amplitude
+	})
+	total := len(comparacoes)
+	if len(comparacoes) > limite {
+		comparacoes = comparacoes[:limite]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    comparacoes,
+		"total":   total,
+		"filtros": filtros,
+		"tipo":    tipo,
+	})
+}
+
+func (c *ComparacaoFalha) temEclusa(codigo string) bool {
+	for _, e := range c.Eclusas {
+		if e.EclusaCodigo == codigo {
+			return true
+		}
+	}
+	return false
+}
+
+// calcularDispersao preenche a média, o desvio padrão e a amplitude das taxas e o índice de cada eclusa
+func (c *ComparacaoFalha) calcularDispersao() {
+	soma, menor, maior := 0.0, math.Inf(1), math.Inf(-1)
+	for _, e := range c.Eclusas {
+		soma += e.TaxaPorDia
+		menor = math.Min(menor, e.TaxaPorDia)
+		maior = math.Max(maior, e.TaxaPorDia)
+	}
+	media := soma / float64(len(c.Eclusas))
+
+	variancia := 0.0
+	for i, e := range c.Eclusas {
+		variancia += (e.TaxaPorDia - media) * (e.TaxaPorDia - media)
+		if media > 0 {
+			indice := arredondar(e.TaxaPorDia / media)
+			c.Eclusas[i].IndiceFrota = &indice
+		}
+	}
+	c.TaxaMediaFrota = arredondar(media)
+	c.DesvioPadrao = arredondar(math.Sqrt(variancia / float64(len(c.Eclusas))))
+	c.Amplitude = arredondar(maior - menor)
+}
+
+// arredondar deixa 3 casas decimais
+func arredondar(valor float64) float64 {
+	return math.Round(valor*1000) / 1000
+}
+
+// obterVisaoGeralFrota retorna o índice de saúde de cada eclusa. O índice parte de 100 e desconta
+// as falhas ativas (por prioridade), as ocorrências com SLA violado, as falhas das últimas 24h e,
+// no central, o atraso da sincronização da eclusa.
+func (s *ServidorHTTP) obterVisaoGeralFrota(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	where := ""
+	var args []interface{}
+	if eclusa := r.URL.Query().Get("eclusa"); eclusa != "" {
+		args = append(args, strings.ToUpper(eclusa))
+		where = " WHERE e.codigo = $1"
+	}
+
+	// Só contam as causas (não as consequências suprimidas) fora das janelas de manutenção que as ocultam
+	rows, err := s.bancoDados.Query(`
+		SELECT e.codigo, e.nome,
+			COUNT(CASE WHEN df.tipo = 'FALHA' AND df.prioridade = 'ALTA' THEN o.id END),
+			COUNT(CASE WHEN df.tipo = 'FALHA' AND df.prioridade = 'MEDIA' THEN o.id END),
+			COUNT(CASE WHEN df.tipo = 'FALHA' AND df.prioridade NOT IN ('ALTA', 'MEDIA') THEN o.id END),
+			COUNT(CASE WHEN df.tipo = 'EVENTO' THEN o.id END),
+			COUNT(CASE WHEN `+expressaoSLAViolado+` THEN o.id END),
+			(SELECT COUNT(*) FROM ocorrencias_falhas o
+				JOIN definicoes_falhas df24 ON o.definicao_id = df24.id
+				WHERE df24.eclusa_id = e.id AND df24.tipo = 'FALHA' AND o.suprimida_por IS NULL
+				AND o.timestamp_inicio >= NOW() - INTERVAL '24 hours' AND `+condicaoForaJanelaOculta+`)
+		FROM eclusas e
+		LEFT JOIN definicoes_falhas df ON df.eclusa_id = e.id
+		LEFT JOIN ocorrencias_falhas o ON o.definicao_id = df.id AND o.status = 'ATIVO'
+			AND o.suprimida_por IS NULL AND `+condicaoForaJanelaOculta+where+`
+		GROUP BY e.id, e.codigo, e.nome
+		ORDER BY e.codigo`, args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao calcular a saúde das eclusas: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	eclusas := []SaudeEclusa{}
+	for rows.Next() {
+		var e SaudeEclusa
+		if err := rows.Scan(&e.EclusaCodigo, &e.EclusaNome, &e.AtivasAlta, &e.AtivasMedia, &e.AtivasBaixa,
+			&e.EventosAtivos, &e.SLAViolado, &e.Falhas24h); err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler saúde da eclusa: %v", err), http.StatusInternalServerError)
+			return
+		}
+		eclusas = append(eclusas, e)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao ler saúde das eclusas: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// No central, o último lote recebido de cada eclusa (as eclusas que não sincronizam não são penalizadas)
+	ultimosLotes := make(map[string]*time.Time)
+	if s.configuracoes != nil && s.configuracoes.Sync_Central {
+		origens, err := s.repositorios.Sincronizacao.ListarOrigens()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, origem := range origens {
+			ultimosLotes[origem.Origem] = origem.UltimoLote
+		}
+	}
+
+	porEstado := map[string]int{"OK": 0, "ATENCAO": 0, "CRITICO": 0}
+	somaIndices := 0
+	for i := range eclusas {
+		e := &eclusas[i]
+		if ultimo, sincroniza := ultimosLotes[e.EclusaCodigo]; sincroniza {
+			e.UltimoLote = ultimo
+			e.SincronizacaoAtrasada = ultimo == nil || time.Since(*ultimo) > atrasoMaximoSincronizar
+		}
+		e.calcularIndice()
+		porEstado[e.Estado]++
+		somaIndices += e.Indice
+	}
+	indiceMedio := 0.0
+	if len(eclusas) > 0 {
+		indiceMedio = arredondar(float64(somaIndices) / float64(len(eclusas)))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    eclusas,
+		"total":   len(eclusas),
+		"resumo": map[string]interface{}{
+			"indice_medio": indiceMedio,
+			"por_estado":   porEstado,
+		},
+	})
+}
+
+// calcularIndice aplica as penalizações (cada grupo com um máximo) e classifica a eclusa
+func (e *SaudeEclusa) calcularIndice() {
+	indice := 100
+	indice -= min(maximoPenalAtivas, pesoAtivaAlta*e.AtivasAlta+pesoAtivaMedia*e.AtivasMedia+pesoAtivaBaixa*e.AtivasBaixa)
+	indice -= min(maximoPenalSLA, pesoSLAViolado*e.SLAViolado)
+	indice -= min(maximoPenalFalhas24h, pesoFalha24h*e.Falhas24h)
+	if e.SincronizacaoAtrasada {
+		indice -= penalSincronizacao
+	}
+	if indice < 0 {
+		indice = 0
+	}
+	e.Indice = indice
+
+	switch {
+	case indice >= saudeMinimaOK:
+		e.Estado = "OK"
+	case indice >= saudeMinimaAtencao:
+		e.Estado = "ATENCAO"
+	default:
+		e.Estado = "CRITICO"
+	}
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/edp/falhas-backend/manutencao"
+	"github.com/gorilla/mux"
+)
+
+const (
+	limitePadraoJanelas = 100
+	limiteMaximoJanelas = 1000
+)
+
+// condicaoForaJanelaOculta exclui as ocorrências abertas numa janela de manutenção que as oculta
+const condicaoForaJanelaOculta = `NOT EXISTS (
+			SELECT 1 FROM janelas_manutencao jm WHERE jm.id = o.janela_manutencao_id AND jm.ocultar)`
+
+// obterJanelasManutencao lista as janelas de manutenção (filtros: eclusa, setor, estado, ativas, limite)
+func (s *ServidorHTTP) obterJanelasManutencao(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	q := r.URL.Query()
+	filtro := manutencao.FiltroJanelas{
+		Eclusa: q.Get("eclusa"),
+		Setor:  q.Get("setor"),
+		Estado: strings.ToUpper(q.Get("estado")),
+		Limite: limitePadraoJanelas,
+	}
+	if q.Get("ativas") == "true" {
+		filtro.Estado = manutencao.JanelaEmCurso
+	}
+	switch filtro.Estado {
+	case "", manutencao.JanelaAgendada, manutencao.JanelaEmCurso, manutencao.JanelaTerminada:
+	default:
+		http.Error(w, "Parâmetro 'estado' inválido: use AGENDADA, EM_CURSO ou TERMINADA", http.StatusBadRequest)
+		return
+	}
+	if valor := q.Get("limite"); valor != "" {
+		var err error
+		filtro.Limite, err = strconv.Atoi(valor)
+		if err != nil || filtro.Limite <= 0 || filtro.Limite > limiteMaximoJanelas {
+			http.Error(w, fmt.Sprintf("Parâmetro 'limite' inválido: use um inteiro entre 1 e %d", limiteMaximoJanelas), http.StatusBadRequest)
+			return
+		}
+	}
+
+	janelas, err := manutencao.ListarJanelas(s.bancoDados, filtro)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    janelas,
+		"total":   len(janelas),
+	})
+}
+
+// obterJanelaManutencao devolve uma janela de manutenção
+func (s *ServidorHTTP) obterJanelaManutencao(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	s.responderJanelaManutencao(w, id, http.StatusOK)
+}
+
+// criarJanelaManutencao regista uma janela de manutenção planeada. Sem 'ocultar', as ocorrências
+// da janela ficam ocultas; com "ocultar": false são apenas marcadas.
+func (s *ServidorHTTP) criarJanelaManutencao(w http.ResponseWriter, r *http.Request) {
+	var entrada struct {
+		Eclusa      string `json:"eclusa"`
+		Setor       string `json:"setor"`
+		Inicio      string `json:"inicio"`
+		Fim         string `json:"fim"`
+		Motivo      string `json:"motivo"`
+		Responsavel string `json:"responsavel"`
+		Ocultar     *bool  `json:"ocultar"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	janela := manutencao.JanelaManutencao{
+		Eclusa:      entrada.Eclusa,
+		Setor:       entrada.Setor,
+		Motivo:      entrada.Motivo,
+		Responsavel: entrada.Responsavel,
+		Ocultar:     entrada.Ocultar == nil || *entrada.Ocultar,
+	}
+	inicio, err := lerDataHora(entrada.Inicio, "inicio")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fim, err := lerDataHora(entrada.Fim, "fim")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if inicio != nil {
+		janela.Inicio = *inicio
+	}
+	if fim != nil {
+		janela.Fim = *fim
+	}
+
+	if err := manutencao.CriarJanela(s.bancoDados, &janela); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.responderJanelaManutencao(w, janela.ID, http.StatusCreated)
+}
+
+// terminarJanelaManutencao termina antecipadamente uma janela em curso (ou cancela uma agendada)
+func (s *ServidorHTTP) terminarJanelaManutencao(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var entrada struct {
+		Por string `json:"por"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+			http.Error(w, "JSON inválido", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(strings.TrimSpace(entrada.Por)) > 100 {
+		http.Error(w, "Campo 'por' tem mais de 100 caracteres", http.StatusBadRequest)
+		return
+	}
+
+	terminada, err := manutencao.TerminarJanela(s.bancoDados, id, entrada.Por)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !terminada {
+		if _, err := manutencao.CarregarJanela(s.bancoDados, id); err == sql.ErrNoRows {
+			http.Error(w, "Janela de manutenção não encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Janela de manutenção já terminada", http.StatusConflict)
+		return
+	}
+
+	s.responderJanelaManutencao(w, id, http.StatusOK)
+}
+
+// responderJanelaManutencao envia a janela gravada
+func (s *ServidorHTTP) responderJanelaManutencao(w http.ResponseWriter, id int, status int) {
+	janela, err := manutencao.CarregarJanela(s.bancoDados, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Janela de manutenção não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    janela,
+	})
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/notificacoes"
+	"github.com/gorilla/mux"
+)
+
+const (
+	limitePadraoEnvios = 100
+	limiteMaximoEnvios = 1000
+)
+
+// EnvioNotificacao é uma linha do registo de envios
+type EnvioNotificacao struct {
+	ID               int64      `json:"id"`
+	OcorrenciaID     *int64     `json:"ocorrencia_id,omitempty"`
+	RegraID          *int64     `json:"regra_id,omitempty"`
+	Motivo           string     `json:"motivo"`
+	Canal            string     `json:"canal"`
+	Endereco         string     `json:"endereco"`
+	Assunto          string     `json:"assunto"`
+	Status           string     `json:"status"`
+	Tentativas       int        `json:"tentativas"`
+	ProximaTentativa *time.Time `json:"proxima_tentativa,omitempty"`
+	UltimoErro       string     `json:"ultimo_erro,omitempty"`
+	CriadoEm         time.Time  `json:"criado_em"`
+	EnviadoEm        *time.Time `json:"enviado_em,omitempty"`
+}
+
+// obterRegrasNotificacao lista as regras de notificação com os destinos
+func (s *ServidorHTTP) obterRegrasNotificacao(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	regras, err := notificacoes.CarregarRegras(s.bancoDados, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Com eclusa, as regras que se aplicam a ela (as regras sem eclusa aplicam-se a todas)
+	if eclusa := strings.ToUpper(r.URL.Query().Get("eclusa")); eclusa != "" {
+		filtradas := regras[:0]
+		for _, regra := range regras {
+			if regra.Eclusa == "" || regra.Eclusa == eclusa {
+				filtradas = append(filtradas, regra)
+			}
+		}
+		regras = filtradas
+	}
+	if len(regras) == 0 {
+		regras = []notificacoes.Regra{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    regras,
+		"total":   len(regras),
+	})
+}
+
+// salvarRegraNotificacao cria (POST) ou substitui (PUT /{id}) uma regra e os seus destinos
+func (s *ServidorHTTP) salvarRegraNotificacao(w http.ResponseWriter, r *http.Request) {
+	regra := notificacoes.Regra{Ativa: true}
+	if err := json.NewDecoder(r.Body).Decode(&regra); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	regra.ID = 0
+	status := http.StatusCreated
+	if valor, existe := mux.Vars(r)["id"]; existe {
+		regra.ID, _ = strconv.Atoi(valor)
+		status = http.StatusOK
+	}
+
+	if err := regra.Validar(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := notificacoes.SalvarRegra(s.bancoDados, &regra)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Regra de notificação não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    regra,
+	})
+}
+
+// removerRegraNotificacao remove uma regra (os envios registados ficam no histórico)
+func (s *ServidorHTTP) removerRegraNotificacao(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.bancoDados.Exec("DELETE FROM regras_notificacao WHERE id = $1", id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao remover regra de notificação: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		http.Error(w, "Regra de notificação não encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Regra de notificação removida com sucesso",
+	})
+}
+
+// testarRegraNotificacao envia de imediato uma mensagem de teste a todos os destinos da regra
+func (s *ServidorHTTP) testarRegraNotificacao(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	regras, err := notificacoes.CarregarRegras(s.bancoDados, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, regra := range regras {
+		if regra.ID != id {
+			continue
+		}
+
+		resultados, err := notificacoes.TestarRegra(s.bancoDados, notificacoes.NovosCanais(s.configuracoes), regra)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		enviados := 0
+		for _, resultado := range resultados {
+			if resultado.Enviado {
+				enviados++
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":  enviados == len(resultados),
+			"data":     resultados,
+			"total":    len(resultados),
+			"enviados": enviados,
+		})
+		return
+	}
+
+	http.Error(w, "Regra de notificação não encontrada", http.StatusNotFound)
+}
+
+// obterEnviosNotificacao lista o registo de envios (filtros: ocorrencia, regra, status, canal, motivo, limite)
+func (s *ServidorHTTP) obterEnviosNotificacao(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	q := r.URL.Query()
+	query := `
+		SELECT id, ocorrencia_id, regra_id, motivo, canal, endereco, assunto, status, tentativas,
+			proxima_tentativa, COALESCE(ultimo_erro, ''), criado_em, enviado_em
+		FROM envios_notificacao
+		WHERE 1=1`
+	args := []interface{}{}
+	argIndex := 1
+
+	for _, filtro := range []struct{ parametro, coluna string }{
+		{"ocorrencia", "ocorrencia_id"},
+		{"regra", "regra_id"},
+	} {
+		if valor := q.Get(filtro.parametro); valor != "" {
+			id, err := strconv.ParseInt(valor, 10, 64)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Parâmetro '%s' inválido", filtro.parametro), http.StatusBadRequest)
+				return
+			}
+			query += fmt.Sprintf(" AND %s = $%d", filtro.coluna, argIndex)
+			args = append(args, id)
+			argIndex++
+		}
+	}
+	for _, filtro := range []string{"status", "canal", "motivo"} {
+		if valor := q.Get(filtro); valor != "" {
+			query += fmt.Sprintf(" AND %s = $%d", filtro, argIndex)
+			args = append(args, strings.ToUpper(valor))
+			argIndex++
+		}
+	}
+	if eclusa := q.Get("eclusa"); eclusa != "" {
+		query += " AND ocorrencia_id IN (SELECT o.id FROM ocorrencias_falhas o WHERE " + condicaoOcorrenciaNaEclusa(argIndex) + ")"
+		args = append(args, strings.ToUpper(eclusa))
+		argIndex++
+	}
+
+	limite := limitePadraoEnvios
+	if valor := q.Get("limite"); valor != "" {
+		var err error
+		limite, err = strconv.Atoi(valor)
+		if err != nil || limite <= 0 || limite > limiteMaximoEnvios {
+			http.Error(w, fmt.Sprintf("Parâmetro 'limite' inválido: use um inteiro entre 1 e %d", limiteMaximoEnvios), http.StatusBadRequest)
+			return
+		}
+	}
+	query += fmt.Sprintf(" ORDER BY criado_em DESC, id DESC LIMIT $%d", argIndex)
+	args = append(args, limite)
+
+	rows, err := s.bancoDados.Query(query, args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar envios: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	envios := []EnvioNotificacao{}
+	for rows.Next() {
+		var e EnvioNotificacao
+		var ocorrenciaID, regraID sql.NullInt64
+		var proximaTentativa, enviadoEm sql.NullTime
+
+		err := rows.Scan(&e.ID, &ocorrenciaID, &regraID, &e.Motivo, &e.Canal, &e.Endereco, &e.Assunto,
+			&e.Status, &e.Tentativas, &proximaTentativa, &e.UltimoErro, &e.CriadoEm, &enviadoEm)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler envio: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		e.OcorrenciaID = ponteiroNullInt64(ocorrenciaID)
+		e.RegraID = ponteiroNullInt64(regraID)
+		if proximaTentativa.Valid && e.Status == notificacoes.EnvioPendente {
+			e.ProximaTentativa = &proximaTentativa.Time
+		}
+		if enviadoEm.Valid {
+			e.EnviadoEm = &enviadoEm.Time
+		}
+		envios = append(envios, e)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    envios,
+		"total":   len(envios),
+	})
+}
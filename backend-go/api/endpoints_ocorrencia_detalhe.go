@@ -0,0 +1,303 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/edp/falhas-backend/manutencao"
+	"github.com/gorilla/mux"
+)
+
+// TransicaoOcorrencia representa uma mudança de estado de uma ocorrência
+type TransicaoOcorrencia struct {
+	StatusAnterior *string   `json:"status_anterior,omitempty"`
+	StatusNovo     string    `json:"status_novo"`
+	Timestamp      time.Time `json:"timestamp"`
+	Origem         string    `json:"origem"`
+	Observacao     *string   `json:"observacao,omitempty"`
+}
+
+// AlarmeSimultaneo representa outra ocorrência ativa no instante em que a ocorrência consultada começou
+type AlarmeSimultaneo struct {
+	ID              int64      `json:"id"`
+	Codigo          string     `json:"codigo"`
+	Descricao       string     `json:"descricao"`
+	Prioridade      string     `json:"prioridade"`
+	SetorNome       string     `json:"setor_nome"`
+	TimestampInicio time.Time  `json:"timestamp_inicio"`
+	TimestampFim    *time.Time `json:"timestamp_fim,omitempty"`
+	FirstOut        bool       `json:"first_out"`
+}
+
+// OcorrenciaDetalhe reúne a ocorrência, a sua linha do tempo e o contexto da ativação
+type OcorrenciaDetalhe struct {
+	Ocorrencia         OcorrenciaCompleta           `json:"ocorrencia"`
+	PointIndex         int                          `json:"point_index"`
+	EclusaLocalizacao  string                       `json:"eclusa_localizacao,omitempty"`
+	SetorCorTema       string                       `json:"setor_cor_tema,omitempty"`
+	ResolvidoPor       *string                      `json:"resolvido_por,omitempty"`
+	Observacoes        *string                      `json:"observacoes,omitempty"`
+	Transicoes         []TransicaoOcorrencia        `json:"transicoes"`
+	AlarmesSimultaneos []AlarmeSimultaneo           `json:"alarmes_simultaneos"`
+	OrdensTrabalho     []ResumoOrdemTrabalho        `json:"ordens_trabalho"`
+	JanelaManutencao   *manutencao.JanelaManutencao `json:"janela_manutencao,omitempty"`
+	Contexto           json.RawMessage              `json:"contexto,omitempty"`
+}
+
+// ResumoOrdemTrabalho identifica uma ordem de trabalho ligada à ocorrência
+type ResumoOrdemTrabalho struct {
+	ID                int64  `json:"id"`
+	Numero            string `json:"numero"`
+	Titulo            string `json:"titulo"`
+	Status            string `json:"status"`
+	Equipa            string `json:"equipa,omitempty"`
+	ReferenciaExterna string `json:"referencia_externa,omitempty"`
+}
+
+// obterDetalheOcorrencia retorna uma ocorrência com linha do tempo, alarmes simultâneos e contexto
+func (s *ServidorHTTP) obterDetalheOcorrencia(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	detalhe, err := s.buscarDetalheOcorrencia(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Ocorrência não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar ocorrência: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	detalhe.Transicoes, err = s.buscarTransicoes(detalhe.Ocorrencia)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar transições: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	detalhe.AlarmesSimultaneos, err = s.buscarAlarmesSimultaneos(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar alarmes simultâneos: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	detalhe.OrdensTrabalho, err = s.buscarOrdensTrabalhoOcorrencia(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar ordens de trabalho: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if janelaID := detalhe.Ocorrencia.JanelaManutencaoID; janelaID != nil {
+		detalhe.JanelaManutencao, err = manutencao.CarregarJanela(s.bancoDados, int(*janelaID))
+		if err != nil && err != sql.ErrNoRows {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    detalhe,
+	})
+}
+
+// buscarDetalheOcorrencia carrega a ocorrência com definição, setor, eclusa e contexto
+func (s *ServidorHTTP) buscarDetalheOcorrencia(id int64) (OcorrenciaDetalhe, error) {
+	var detalhe OcorrenciaDetalhe
+	oc := &detalhe.Ocorrencia
+	var timestampFim sql.NullTime
+	var duracaoSegundos sql.NullFloat64
+	var grupoFirstOut, avalanche, suprimidaPor, tempoResposta, janelaManutencao sql.NullInt64
+	var localizacao, corTema, resolvidoPor, observacoes, contexto sql.NullString
+
+	err := s.bancoDados.QueryRow(`
+		SELECT
+			o.id, o.status, o.timestamp_inicio, o.timestamp_fim,
+			df.id, df.codigo, df.tipo, df.descricao, df.prioridade,
+			df.word_index, df.bit_index, df.classe_mensagem, df.point_index,
+			s.codigo, s.nome, s.cor_tema,
+			e.codigo, e.nome, e.localizacao,
+			EXTRACT(EPOCH FROM (COALESCE(o.timestamp_fim, NOW()) - o.timestamp_inicio)),
+			o.first_out, o.grupo_first_out_id, o.avalanche_id, o.suprimida_por,
+			(SELECT COUNT(*) FROM ocorrencias_falhas f WHERE f.suprimida_por = o.id) as total_suprimidas,
+			df.criticidade, df.relacionada_seguranca, df.tempo_resposta_minutos,
+			`+expressaoSLAViolado+`,
+			o.resolvido_por, o.observacoes, o.dados_contexto::text, o.janela_manutencao_id
+		FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		JOIN setores s ON df.setor_id = s.id
+		JOIN eclusas e ON df.eclusa_id = e.id
+		WHERE o.id = $1`, id).Scan(
+		&oc.ID, &oc.Status, &oc.TimestampInicio, &timestampFim,
+		&oc.DefinicaoID, &oc.Codigo, &oc.Tipo, &oc.Descricao, &oc.Prioridade,
+		&oc.WordIndex, &oc.BitIndex, &oc.ClasseMensagem, &detalhe.PointIndex,
+		&oc.SetorCodigo, &oc.SetorNome, &corTema,
+		&oc.EclusaCodigo, &oc.EclusaNome, &localizacao,
+		&duracaoSegundos,
+		&oc.FirstOut, &grupoFirstOut, &avalanche, &suprimidaPor, &oc.TotalSuprimidas,
+		&oc.Criticidade, &oc.RelacionadaSeguranca, &tempoResposta, &oc.SLAViolado,
+		&resolvidoPor, &observacoes, &contexto, &janelaManutencao)
+	if err != nil {
+		return detalhe, err
+	}
+
+	if timestampFim.Valid {
+		oc.TimestampFim = &timestampFim.Time
+	}
+	if duracaoSegundos.Valid {
+		duracao := int64(duracaoSegundos.Float64)
+		oc.DuracaoSegundos = &duracao
+	}
+	oc.GrupoFirstOutID = ponteiroNullInt64(grupoFirstOut)
+	oc.AvalancheID = ponteiroNullInt64(avalanche)
+	oc.SuprimidaPor = ponteiroNullInt64(suprimidaPor)
+	oc.TempoRespostaMinutos = ponteiroNullInt(tempoResposta)
+	oc.JanelaManutencaoID = ponteiroNullInt64(janelaManutencao)
+
+	detalhe.EclusaLocalizacao = localizacao.String
+	detalhe.SetorCorTema = corTema.String
+	if resolvidoPor.Valid {
+		detalhe.ResolvidoPor = &resolvidoPor.String
+	}
+	if observacoes.Valid {
+		detalhe.Observacoes = &observacoes.String
+	}
+	if contexto.Valid {
+		detalhe.Contexto = json.RawMessage(contexto.String)
+	}
+
+	return detalhe, nil
+}
+
+// buscarTransicoes retorna a linha do tempo da ocorrência. Ocorrências gravadas antes do
+// registro de transições têm a linha do tempo reconstruída a partir dos timestamps.
+func (s *ServidorHTTP) buscarTransicoes(oc OcorrenciaCompleta) ([]TransicaoOcorrencia, error) {
+	rows, err := s.bancoDados.Query(`
+		SELECT status_anterior, status_novo, timestamp, origem, observacao
+		FROM transicoes_ocorrencias
+		WHERE ocorrencia_id = $1
+		ORDER BY timestamp, id`, oc.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transicoes := []TransicaoOcorrencia{}
+	for rows.Next() {
+		var t TransicaoOcorrencia
+		var anterior, observacao sql.NullString
+		if err := rows.Scan(&anterior, &t.StatusNovo, &t.Timestamp, &t.Origem, &observacao); err != nil {
+			return nil, err
+		}
+		if anterior.Valid {
+			t.StatusAnterior = &anterior.String
+		}
+		if observacao.Valid {
+			t.Observacao = &observacao.String
+		}
+		transicoes = append(transicoes, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(transicoes) == 0 {
+		transicoes = append(transicoes, TransicaoOcorrencia{
+			StatusNovo: "ATIVO",
+			Timestamp:  oc.TimestampInicio,
+			Origem:     "RECONSTRUIDA",
+		})
+		if oc.TimestampFim != nil {
+			anterior := "ATIVO"
+			transicoes = append(transicoes, TransicaoOcorrencia{
+				StatusAnterior: &anterior,
+				StatusNovo:     oc.Status,
+				Timestamp:      *oc.TimestampFim,
+				Origem:         "RECONSTRUIDA",
+			})
+		}
+	}
+
+	return transicoes, nil
+}
+
+// buscarAlarmesSimultaneos retorna as outras ocorrências da mesma eclusa ativas no início da ocorrência
+func (s *ServidorHTTP) buscarAlarmesSimultaneos(id int64) ([]AlarmeSimultaneo, error) {
+	rows, err := s.bancoDados.Query(`
+		SELECT o.id, df.codigo, df.descricao, df.prioridade, s.nome,
+			o.timestamp_inicio, o.timestamp_fim, o.first_out
+		FROM ocorrencias_falhas alvo
+		JOIN definicoes_falhas dfa ON alvo.definicao_id = dfa.id
+		JOIN definicoes_falhas df ON df.eclusa_id = dfa.eclusa_id
+		JOIN ocorrencias_falhas o ON o.definicao_id = df.id
+		JOIN setores s ON df.setor_id = s.id
+		WHERE alvo.id = $1 AND o.id <> alvo.id
+		AND o.timestamp_inicio <= alvo.timestamp_inicio
+		AND (o.timestamp_fim IS NULL OR o.timestamp_fim > alvo.timestamp_inicio)
+		ORDER BY o.timestamp_inicio, o.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alarmes := []AlarmeSimultaneo{}
+	for rows.Next() {
+		var a AlarmeSimultaneo
+		var timestampFim sql.NullTime
+		if err := rows.Scan(&a.ID, &a.Codigo, &a.Descricao, &a.Prioridade, &a.SetorNome,
+			&a.TimestampInicio, &timestampFim, &a.FirstOut); err != nil {
+			return nil, err
+		}
+		if timestampFim.Valid {
+			a.TimestampFim = &timestampFim.Time
+		}
+		alarmes = append(alarmes, a)
+	}
+
+	return alarmes, rows.Err()
+}
+
+// buscarOrdensTrabalhoOcorrencia retorna as ordens de trabalho ligadas à ocorrência
+func (s *ServidorHTTP) buscarOrdensTrabalhoOcorrencia(id int64) ([]ResumoOrdemTrabalho, error) {
+	rows, err := s.bancoDados.Query(`
+		SELECT ot.id, ot.titulo, ot.status, COALESCE(eq.codigo, ''), COALESCE(ot.referencia_externa, '')
+		FROM ordens_trabalho_ocorrencias l
+		JOIN ordens_trabalho ot ON l.ordem_id = ot.id
+		LEFT JOIN equipas eq ON ot.equipa_id = eq.id
+		WHERE l.ocorrencia_id = $1
+		ORDER BY ot.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ordens := []ResumoOrdemTrabalho{}
+	for rows.Next() {
+		var o ResumoOrdemTrabalho
+		if err := rows.Scan(&o.ID, &o.Titulo, &o.Status, &o.Equipa, &o.ReferenciaExterna); err != nil {
+			return nil, err
+		}
+		o.Numero = manutencao.NumeroOrdem(o.ID)
+		ordens = append(ordens, o)
+	}
+
+	return ordens, rows.Err()
+}
@@ -0,0 +1,348 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/edp/falhas-backend/manutencao"
+	"github.com/edp/falhas-backend/notificacoes"
+	"github.com/gorilla/mux"
+)
+
+const (
+	limitePadraoOrdens = 100
+	limiteMaximoOrdens = 1000
+)
+
+// obterOrdensTrabalho lista as ordens de trabalho (filtros: status, equipa, eclusa, estado_cmms, ocorrencia, limite)
+func (s *ServidorHTTP) obterOrdensTrabalho(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	q := r.URL.Query()
+	filtro := manutencao.FiltroOrdens{
+		Status:     q.Get("status"),
+		Equipa:     q.Get("equipa"),
+		Eclusa:     q.Get("eclusa"),
+		EstadoCMMS: q.Get("estado_cmms"),
+		Limite:     limitePadraoOrdens,
+	}
+	if valor := q.Get("ocorrencia"); valor != "" {
+		var err error
+		filtro.OcorrenciaID, err = strconv.ParseInt(valor, 10, 64)
+		if err != nil {
+			http.Error(w, "Parâmetro 'ocorrencia' inválido", http.StatusBadRequest)
+			return
+		}
+	}
+	if valor := q.Get("limite"); valor != "" {
+		var err error
+		filtro.Limite, err = strconv.Atoi(valor)
+		if err != nil || filtro.Limite <= 0 || filtro.Limite > limiteMaximoOrdens {
+			http.Error(w, fmt.Sprintf("Parâmetro 'limite' inválido: use um inteiro entre 1 e %d", limiteMaximoOrdens), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ordens, err := manutencao.ListarOrdens(s.bancoDados, filtro)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    ordens,
+		"total":   len(ordens),
+	})
+}
+
+// obterOrdemTrabalho devolve uma ordem de trabalho com peças e ocorrências
+func (s *ServidorHTTP) obterOrdemTrabalho(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	ordem, err := manutencao.CarregarOrdem(s.bancoDados, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Ordem de trabalho não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    ordem,
+	})
+}
+
+// criarOrdemTrabalho abre manualmente uma ordem para as ocorrências indicadas. Em
+// POST /ocorrencias/{id}/ordem-trabalho a ocorrência vem do caminho.
+// Sem título, usa o código e a descrição da primeira ocorrência.
+func (s *ServidorHTTP) criarOrdemTrabalho(w http.ResponseWriter, r *http.Request) {
+	var ordem manutencao.OrdemTrabalho
+	if err := json.NewDecoder(r.Body).Decode(&ordem); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	if valor, existe := mux.Vars(r)["id"]; existe {
+		id, err := strconv.ParseInt(valor, 10, 64)
+		if err != nil {
+			http.Error(w, "ID inválido", http.StatusBadRequest)
+			return
+		}
+		ordem.Ocorrencias = []int64{id}
+	}
+	ordem.ID, ordem.RegraID, ordem.Origem = 0, 0, manutencao.OrigemManual
+
+	if ordem.Titulo == "" && len(ordem.Ocorrencias) > 0 {
+		ocorrencia, err := notificacoes.BuscarOcorrencia(s.bancoDados, ordem.Ocorrencias[0])
+		if err == sql.ErrNoRows {
+			http.Error(w, "Ocorrência não encontrada", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao buscar ocorrência: %v", err), http.StatusInternalServerError)
+			return
+		}
+		ordem.Titulo = ocorrencia.Codigo + " - " + ocorrencia.Descricao
+		if len(ordem.Titulo) > 200 {
+			ordem.Titulo = ocorrencia.Codigo
+		}
+		if ordem.Prioridade == "" {
+			ordem.Prioridade = ocorrencia.Prioridade
+		}
+	}
+
+	if err := ordem.Validar(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := manutencao.SalvarOrdem(s.bancoDados, &ordem, manutencao.SincronizacaoAtiva(s.configuracoes)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.responderOrdemTrabalho(w, ordem.ID, http.StatusCreated)
+}
+
+// atualizarOrdemTrabalho altera uma ordem: só os campos enviados mudam (peças e ocorrências são substituídas)
+func (s *ServidorHTTP) atualizarOrdemTrabalho(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	ordem, err := manutencao.CarregarOrdem(s.bancoDados, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Ordem de trabalho não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(ordem); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	ordem.ID = id
+
+	if err := ordem.Validar(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = manutencao.SalvarOrdem(s.bancoDados, ordem, manutencao.SincronizacaoAtiva(s.configuracoes))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Ordem de trabalho não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.responderOrdemTrabalho(w, id, http.StatusOK)
+}
+
+// sincronizarOrdemTrabalho volta a pôr a ordem na fila de envio para o CMMS
+func (s *ServidorHTTP) sincronizarOrdemTrabalho(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	if !manutencao.SincronizacaoAtiva(s.configuracoes) {
+		http.Error(w, "Sincronização com o CMMS desativada (configure CMMS_TIPO)", http.StatusConflict)
+		return
+	}
+
+	result, err := s.bancoDados.Exec(`
+		UPDATE ordens_trabalho
+		SET estado_cmms = 'PENDENTE', tentativas_cmms = 0, proxima_sincronizacao = NOW()
+		WHERE id = $1`, id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao agendar sincronização: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		http.Error(w, "Ordem de trabalho não encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Ordem de trabalho agendada para envio ao CMMS",
+	})
+}
+
+// obterRegrasOrdemTrabalho lista as regras de abertura automática de ordens
+func (s *ServidorHTTP) obterRegrasOrdemTrabalho(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	regras, err := manutencao.CarregarRegrasOrdem(s.bancoDados)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Com eclusa, as regras que se aplicam a ela (as regras sem eclusa aplicam-se a todas)
+	if eclusa := strings.ToUpper(r.URL.Query().Get("eclusa")); eclusa != "" {
+		filtradas := regras[:0]
+		for _, regra := range regras {
+			if regra.Eclusa == "" || regra.Eclusa == eclusa {
+				filtradas = append(filtradas, regra)
+			}
+		}
+		regras = filtradas
+	}
+	if len(regras) == 0 {
+		regras = []manutencao.RegraOrdem{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    regras,
+		"total":   len(regras),
+	})
+}
+
+// salvarRegraOrdemTrabalho cria (POST) ou substitui (PUT /{id}) uma regra de abertura de ordens
+func (s *ServidorHTTP) salvarRegraOrdemTrabalho(w http.ResponseWriter, r *http.Request) {
+	regra := manutencao.RegraOrdem{Ativa: true, Agrupar: true}
+	if err := json.NewDecoder(r.Body).Decode(&regra); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	regra.ID = 0
+	status := http.StatusCreated
+	if valor, existe := mux.Vars(r)["id"]; existe {
+		regra.ID, _ = strconv.Atoi(valor)
+		status = http.StatusOK
+	}
+
+	if err := regra.Validar(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := manutencao.SalvarRegraOrdem(s.bancoDados, &regra)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Regra de ordem de trabalho não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    regra,
+	})
+}
+
+// removerRegraOrdemTrabalho remove uma regra (as ordens já abertas ficam)
+func (s *ServidorHTTP) removerRegraOrdemTrabalho(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.bancoDados.Exec("DELETE FROM regras_ordem_trabalho WHERE id = $1", id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao remover regra de ordem de trabalho: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		http.Error(w, "Regra de ordem de trabalho não encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Regra de ordem de trabalho removida com sucesso",
+	})
+}
+
+// responderOrdemTrabalho relê a ordem gravada (com o estado do CMMS) e devolve-a
+func (s *ServidorHTTP) responderOrdemTrabalho(w http.ResponseWriter, id int64, status int) {
+	ordem, err := manutencao.CarregarOrdem(s.bancoDados, id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao ler ordem de trabalho: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    ordem,
+	})
+}
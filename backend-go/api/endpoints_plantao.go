@@ -0,0 +1,718 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/database"
+	"github.com/edp/falhas-backend/plantao"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+const (
+	diasPadraoEscala  = 14
+	diasMaximosEscala = 92
+)
+
+// EquipaPlantao é uma equipa com quem está de plantão neste momento
+type EquipaPlantao struct {
+	*plantao.Equipa
+	DePlantao *plantao.Membro `json:"de_plantao"`
+	Origem    string          `json:"origem,omitempty"`
+}
+
+// EscalonamentoOcorrencia é o estado do escalonamento de uma ocorrência
+type EscalonamentoOcorrencia struct {
+	OcorrenciaID   int64      `json:"ocorrencia_id"`
+	Codigo         string     `json:"codigo"`
+	Descricao      string     `json:"descricao"`
+	Eclusa         string     `json:"eclusa"`
+	Politica       string     `json:"politica,omitempty"`
+	NivelAtual     int        `json:"nivel_atual"`
+	Estado         string     `json:"estado"`
+	ProximoNivelEm *time.Time `json:"proximo_nivel_em,omitempty"`
+	IniciadoEm     time.Time  `json:"iniciado_em"`
+	AtualizadoEm   time.Time  `json:"atualizado_em"`
+	ReconhecidaPor string     `json:"reconhecida_por,omitempty"`
+	ReconhecidaEm  *time.Time `json:"reconhecida_em,omitempty"`
+}
+
+// obterEquipasPlantao lista as equipas com os membros e quem está de plantão agora
+func (s *ServidorHTTP) obterEquipasPlantao(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	rows, err := s.bancoDados.Query("SELECT codigo FROM equipas ORDER BY id")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar equipas: %v", err), http.StatusInternalServerError)
+		return
+	}
+	var codigos []string
+	for rows.Next() {
+		var codigo string
+		if err := rows.Scan(&codigo); err != nil {
+			rows.Close()
+			http.Error(w, fmt.Sprintf("Erro ao ler equipa: %v", err), http.StatusInternalServerError)
+			return
+		}
+		codigos = append(codigos, codigo)
+	}
+	rows.Close()
+
+	agora := time.Now()
+	equipas := []EquipaPlantao{}
+	for _, codigo := range codigos {
+		equipa, err := plantao.CarregarEquipa(s.bancoDados, codigo)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao carregar equipa %s: %v", codigo, err), http.StatusInternalServerError)
+			return
+		}
+		membro, origem := equipa.DePlantao(agora)
+		equipas = append(equipas, EquipaPlantao{Equipa: equipa, DePlantao: membro, Origem: origem})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    equipas,
+		"total":   len(equipas),
+	})
+}
+
+// salvarMembroPlantao cria (POST) ou atualiza (PUT /{id}) um membro de equipa
+func (s *ServidorHTTP) salvarMembroPlantao(w http.ResponseWriter, r *http.Request) {
+	entrada := struct {
+		Equipa   string `json:"equipa"`
+		Nome     string `json:"nome"`
+		Email    string `json:"email"`
+		Telefone string `json:"telefone"`
+		Ativo    *bool  `json:"ativo"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	membro := plantao.Membro{
+		Nome:     strings.TrimSpace(entrada.Nome),
+		Email:    strings.TrimSpace(entrada.Email),
+		Telefone: strings.TrimSpace(entrada.Telefone),
+		Ativo:    entrada.Ativo == nil || *entrada.Ativo,
+	}
+	if membro.Nome == "" || entrada.Equipa == "" {
+		http.Error(w, "Campos 'equipa' e 'nome' são obrigatórios", http.StatusBadRequest)
+		return
+	}
+	if membro.Email != "" && !strings.Contains(membro.Email, "@") {
+		http.Error(w, "Email inválido", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	membro.EquipaID, err = s.obterEquipaID(entrada.Equipa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := http.StatusCreated
+	if valor, existe := mux.Vars(r)["id"]; existe {
+		membro.ID, _ = strconv.Atoi(valor)
+		status = http.StatusOK
+		err = s.bancoDados.QueryRow(`
+			UPDATE membros_equipa
+			SET equipa_id = $1, nome = $2, email = NULLIF($3, ''), telefone = NULLIF($4, ''), ativo = $5
+			WHERE id = $6
+			RETURNING id`, membro.EquipaID, membro.Nome, membro.Email, membro.Telefone, membro.Ativo, membro.ID).Scan(&membro.ID)
+	} else {
+		err = s.bancoDados.QueryRow(`
+			INSERT INTO membros_equipa (equipa_id, nome, email, telefone, ativo)
+			VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)
+			RETURNING id`, membro.EquipaID, membro.Nome, membro.Email, membro.Telefone, membro.Ativo).Scan(&membro.ID)
+	}
+	if err == sql.ErrNoRows {
+		http.Error(w, "Membro não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao salvar membro: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    membro,
+	})
+}
+
+// obterRotacoesPlantao lista as rotações de uma equipa (?equipa=, obrigatório)
+func (s *ServidorHTTP) obterRotacoesPlantao(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	equipa, ok := s.carregarEquipaPedido(w, r)
+	if !ok {
+		return
+	}
+	rotacoes := equipa.Rotacoes
+	if rotacoes == nil {
+		rotacoes = []plantao.Rotacao{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    rotacoes,
+		"total":   len(rotacoes),
+	})
+}
+
+// criarRotacaoPlantao cria uma rotação; substitui as anteriores da equipa a partir do seu início
+func (s *ServidorHTTP) criarRotacaoPlantao(w http.ResponseWriter, r *http.Request) {
+	var entrada struct {
+		Equipa       string  `json:"equipa"`
+		Nome         string  `json:"nome"`
+		Inicio       string  `json:"inicio"`
+		PeriodoHoras int     `json:"periodo_horas"`
+		Membros      []int64 `json:"membros"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	rotacao := plantao.Rotacao{Nome: strings.TrimSpace(entrada.Nome), PeriodoHoras: entrada.PeriodoHoras, Membros: entrada.Membros}
+	if rotacao.Nome == "" || entrada.Equipa == "" || entrada.Inicio == "" {
+		http.Error(w, "Campos 'equipa', 'nome' e 'inicio' são obrigatórios", http.StatusBadRequest)
+		return
+	}
+	if rotacao.PeriodoHoras <= 0 {
+		http.Error(w, "Campo 'periodo_horas' deve ser maior que zero", http.StatusBadRequest)
+		return
+	}
+	if len(rotacao.Membros) == 0 {
+		http.Error(w, "A rotação precisa de pelo menos um membro", http.StatusBadRequest)
+		return
+	}
+	inicio, err := lerDataHora(entrada.Inicio, "inicio")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rotacao.Inicio = inicio.In(time.Local)
+
+	rotacao.EquipaID, err = s.obterEquipaID(entrada.Equipa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.verificarMembrosEquipa(rotacao.EquipaID, rotacao.Membros); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.bancoDados.QueryRow(`
+		INSERT INTO rotacoes_plantao (equipa_id, nome, inicio, periodo_horas, membros)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`, rotacao.EquipaID, rotacao.Nome, rotacao.Inicio, rotacao.PeriodoHoras,
+		pq.Array(rotacao.Membros)).Scan(&rotacao.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao criar rotação: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    rotacao,
+	})
+}
+
+// removerRotacaoPlantao remove uma rotação
+func (s *ServidorHTTP) removerRotacaoPlantao(w http.ResponseWriter, r *http.Request) {
+	s.removerRegistoPlantao(w, r, "rotacoes_plantao", "Rotação")
+}
+
+// obterSubstituicoesPlantao lista as substituições de uma equipa (?equipa=; ?todas=true inclui as terminadas)
+func (s *ServidorHTTP) obterSubstituicoesPlantao(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	equipa, ok := s.carregarEquipaPedido(w, r)
+	if !ok {
+		return
+	}
+
+	todas := r.URL.Query().Get("todas") == "true"
+	agora := time.Now()
+	substituicoes := []plantao.Substituicao{}
+	for _, substituicao := range equipa.Substituicoes {
+		if todas || substituicao.Fim.After(agora) {
+			substituicoes = append(substituicoes, substituicao)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    substituicoes,
+		"total":   len(substituicoes),
+	})
+}
+
+// criarSubstituicaoPlantao põe um membro de plantão num intervalo, por cima da rotação
+func (s *ServidorHTTP) criarSubstituicaoPlantao(w http.ResponseWriter, r *http.Request) {
+	var entrada struct {
+		Equipa   string `json:"equipa"`
+		MembroID int    `json:"membro_id"`
+		Inicio   string `json:"inicio"`
+		Fim      string `json:"fim"`
+		Motivo   string `json:"motivo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if entrada.Equipa == "" || entrada.MembroID <= 0 || entrada.Inicio == "" || entrada.Fim == "" {
+		http.Error(w, "Campos 'equipa', 'membro_id', 'inicio' e 'fim' são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	inicio, err := lerDataHora(entrada.Inicio, "inicio")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fim, err := lerDataHora(entrada.Fim, "fim")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !fim.After(*inicio) {
+		http.Error(w, "'fim' deve ser posterior a 'inicio'", http.StatusBadRequest)
+		return
+	}
+
+	substituicao := plantao.Substituicao{
+		MembroID: entrada.MembroID,
+		Inicio:   inicio.In(time.Local),
+		Fim:      fim.In(time.Local),
+		Motivo:   strings.TrimSpace(entrada.Motivo),
+	}
+	substituicao.EquipaID, err = s.obterEquipaID(entrada.Equipa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.verificarMembrosEquipa(substituicao.EquipaID, []int64{int64(substituicao.MembroID)}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.bancoDados.QueryRow(`
+		INSERT INTO substituicoes_plantao (equipa_id, membro_id, inicio, fim, motivo)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id`, substituicao.EquipaID, substituicao.MembroID, substituicao.Inicio, substituicao.Fim,
+		substituicao.Motivo).Scan(&substituicao.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao criar substituição: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    substituicao,
+	})
+}
+
+// removerSubstituicaoPlantao remove uma substituição
+func (s *ServidorHTTP) removerSubstituicaoPlantao(w http.ResponseWriter, r *http.Request) {
+	s.removerRegistoPlantao(w, r, "substituicoes_plantao", "Substituição")
+}
+
+// obterEscalaPlantao devolve os turnos de plantão de uma equipa (?equipa=&inicio=&fim=, por omissão 14 dias)
+func (s *ServidorHTTP) obterEscalaPlantao(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	q := r.URL.Query()
+	inicio := time.Now()
+	if valor, err := lerDataHora(q.Get("inicio"), "inicio"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if valor != nil {
+		inicio = *valor
+	}
+	fim := inicio.AddDate(0, 0, diasPadraoEscala)
+	if valor, err := lerDataHora(q.Get("fim"), "fim"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if valor != nil {
+		fim = *valor
+	}
+	if !fim.After(inicio) || fim.After(inicio.AddDate(0, 0, diasMaximosEscala)) {
+		http.Error(w, fmt.Sprintf("Intervalo inválido: 'fim' deve ser posterior a 'inicio' (máximo %d dias)", diasMaximosEscala), http.StatusBadRequest)
+		return
+	}
+
+	equipa, ok := s.carregarEquipaPedido(w, r)
+	if !ok {
+		return
+	}
+	turnos := equipa.Escala(inicio, fim)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"equipa":  equipa.Codigo,
+		"inicio":  inicio,
+		"fim":     fim,
+		"data":    turnos,
+		"total":   len(turnos),
+	})
+}
+
+// obterPoliticasEscalonamento lista as políticas de escalonamento com os níveis
+func (s *ServidorHTTP) obterPoliticasEscalonamento(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	politicas, err := plantao.CarregarPoliticas(s.bancoDados)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Com eclusa, as políticas que se aplicam a ela (as políticas sem eclusa aplicam-se a todas)
+	if eclusa := strings.ToUpper(r.URL.Query().Get("eclusa")); eclusa != "" {
+		filtradas := politicas[:0]
+		for _, politica := range politicas {
+			if politica.Eclusa == "" || politica.Eclusa == eclusa {
+				filtradas = append(filtradas, politica)
+			}
+		}
+		politicas = filtradas
+	}
+	if len(politicas) == 0 {
+		politicas = []plantao.Politica{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    politicas,
+		"total":   len(politicas),
+	})
+}
+
+// salvarPoliticaEscalonamento cria (POST) ou substitui (PUT /{id}) uma política e os seus níveis
+func (s *ServidorHTTP) salvarPoliticaEscalonamento(w http.ResponseWriter, r *http.Request) {
+	politica := plantao.Politica{Ativa: true}
+	if err := json.NewDecoder(r.Body).Decode(&politica); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	politica.ID = 0
+	status := http.StatusCreated
+	if valor, existe := mux.Vars(r)["id"]; existe {
+		politica.ID, _ = strconv.Atoi(valor)
+		status = http.StatusOK
+	}
+
+	if err := politica.Validar(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := plantao.SalvarPolitica(s.bancoDados, &politica)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Política de escalonamento não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    politica,
+	})
+}
+
+// removerPoliticaEscalonamento remove uma política (os escalonamentos em curso ficam sem política e terminam)
+func (s *ServidorHTTP) removerPoliticaEscalonamento(w http.ResponseWriter, r *http.Request) {
+	s.removerRegistoPlantao(w, r, "politicas_escalonamento", "Política de escalonamento")
+}
+
+// obterEscalonamentosOcorrencias lista o estado do escalonamento das ocorrências (?estado=, ?limite=)
+func (s *ServidorHTTP) obterEscalonamentosOcorrencias(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	q := r.URL.Query()
+	query := `
+		SELECT eo.ocorrencia_id, df.codigo, df.descricao, e.codigo, COALESCE(p.nome, ''), eo.nivel_atual, eo.estado,
+			eo.proximo_nivel_em, eo.iniciado_em, eo.atualizado_em, COALESCE(o.reconhecida_por, ''), o.reconhecida_em
+		FROM escalonamentos_ocorrencias eo
+		JOIN ocorrencias_falhas o ON eo.ocorrencia_id = o.id
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		JOIN eclusas e ON df.eclusa_id = e.id
+		LEFT JOIN politicas_escalonamento p ON eo.politica_id = p.id
+		WHERE 1=1`
+	args := []interface{}{}
+	argIndex := 1
+
+	if estado := q.Get("estado"); estado != "" {
+		query += fmt.Sprintf(" AND eo.estado = $%d", argIndex)
+		args = append(args, strings.ToUpper(estado))
+		argIndex++
+	}
+	if eclusa := q.Get("eclusa"); eclusa != "" {
+		query += fmt.Sprintf(" AND e.codigo = $%d", argIndex)
+		args = append(args, strings.ToUpper(eclusa))
+		argIndex++
+	}
+
+	limite := limitePadraoEnvios
+	if valor := q.Get("limite"); valor != "" {
+		var err error
+		limite, err = strconv.Atoi(valor)
+		if err != nil || limite <= 0 || limite > limiteMaximoEnvios {
+			http.Error(w, fmt.Sprintf("Parâmetro 'limite' inválido: use um inteiro entre 1 e %d", limiteMaximoEnvios), http.StatusBadRequest)
+			return
+		}
+	}
+	query += fmt.Sprintf(" ORDER BY eo.iniciado_em DESC LIMIT $%d", argIndex)
+	args = append(args, limite)
+
+	rows, err := s.bancoDados.Query(query, args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar escalonamentos: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	escalonamentos := []EscalonamentoOcorrencia{}
+	for rows.Next() {
+		var e EscalonamentoOcorrencia
+		var proximoNivel, reconhecidaEm sql.NullTime
+
+		err := rows.Scan(&e.OcorrenciaID, &e.Codigo, &e.Descricao, &e.Eclusa, &e.Politica, &e.NivelAtual, &e.Estado,
+			&proximoNivel, &e.IniciadoEm, &e.AtualizadoEm, &e.ReconhecidaPor, &reconhecidaEm)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler escalonamento: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		e.IniciadoEm, e.AtualizadoEm = database.HoraLocal(e.IniciadoEm), database.HoraLocal(e.AtualizadoEm)
+		if proximoNivel.Valid {
+			t := database.HoraLocal(proximoNivel.Time)
+			e.ProximoNivelEm = &t
+		}
+		if reconhecidaEm.Valid {
+			t := database.HoraLocal(reconhecidaEm.Time)
+			e.ReconhecidaEm = &t
+		}
+		escalonamentos = append(escalonamentos, e)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    escalonamentos,
+		"total":   len(escalonamentos),
+	})
+}
+
+// reconhecerOcorrencia regista quem assumiu uma ocorrência ativa e para o escalonamento.
+// O status não muda: a ocorrência continua ATIVA até o PLC (ou um utilizador) a resolver.
+func (s *ServidorHTTP) reconhecerOcorrencia(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var entrada struct {
+		Por        string `json:"por"`
+		Observacao string `json:"observacao"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	entrada.Por = strings.TrimSpace(entrada.Por)
+	if entrada.Por == "" || len(entrada.Por) > 100 {
+		http.Error(w, "Campo 'por' é obrigatório (até 100 caracteres)", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.bancoDados.Exec(`
+		UPDATE ocorrencias_falhas
+		SET reconhecida_por = $2, reconhecida_em = NOW()
+		WHERE id = $1 AND status = 'ATIVO' AND reconhecida_em IS NULL`, id, entrada.Por)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao reconhecer ocorrência: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		http.Error(w, "Ocorrência não encontrada, não ativa ou já reconhecida", http.StatusConflict)
+		return
+	}
+
+	_, err = s.bancoDados.Exec(`
+		INSERT INTO transicoes_ocorrencias (ocorrencia_id, status_anterior, status_novo, origem, observacao)
+		VALUES ($1, 'ATIVO', 'ATIVO', $2, COALESCE(NULLIF($3, ''), 'Reconhecida'))`, id, entrada.Por, entrada.Observacao)
+	if err != nil {
+		log.Printf("⚠️ Erro ao registrar reconhecimento da ocorrência %d: %v", id, err)
+	}
+
+	// O escalonador também o deteta no ciclo seguinte; aqui evita um nível enviado entretanto
+	_, err = s.bancoDados.Exec(`
+		UPDATE escalonamentos_ocorrencias
+		SET estado = 'RECONHECIDO', proximo_nivel_em = NULL, atualizado_em = NOW()
+		WHERE ocorrencia_id = $1 AND estado IN ('ATIVO', 'ESGOTADO')`, id)
+	if err != nil {
+		log.Printf("⚠️ Erro ao parar escalonamento da ocorrência %d: %v", id, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Ocorrência reconhecida com sucesso",
+	})
+}
+
+// carregarEquipaPedido carrega a equipa do parâmetro ?equipa= e responde com o erro quando falha
+func (s *ServidorHTTP) carregarEquipaPedido(w http.ResponseWriter, r *http.Request) (*plantao.Equipa, bool) {
+	codigo := r.URL.Query().Get("equipa")
+	if codigo == "" {
+		http.Error(w, "Parâmetro 'equipa' é obrigatório", http.StatusBadRequest)
+		return nil, false
+	}
+
+	equipa, err := plantao.CarregarEquipa(s.bancoDados, codigo)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Equipa não encontrada", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao carregar equipa: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+	return equipa, true
+}
+
+// obterEquipaID resolve o código de uma equipa no seu id
+func (s *ServidorHTTP) obterEquipaID(codigo string) (int, error) {
+	var id int
+	err := s.bancoDados.QueryRow("SELECT id FROM equipas WHERE codigo = $1",
+		strings.ToUpper(strings.TrimSpace(codigo))).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("equipa não encontrada: %s", codigo)
+	}
+	return id, nil
+}
+
+// verificarMembrosEquipa confirma que todos os membros pertencem à equipa
+func (s *ServidorHTTP) verificarMembrosEquipa(equipaID int, membros []int64) error {
+	var encontrados int
+	err := s.bancoDados.QueryRow(`
+		SELECT COUNT(DISTINCT id) FROM membros_equipa WHERE equipa_id = $1 AND id = ANY($2)`,
+		equipaID, pq.Array(membros)).Scan(&encontrados)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar membros: %v", err)
+	}
+
+	distintos := make(map[int64]bool)
+	for _, id := range membros {
+		distintos[id] = true
+	}
+	if encontrados != len(distintos) {
+		return fmt.Errorf("todos os membros devem pertencer à equipa")
+	}
+	return nil
+}
+
+// removerRegistoPlantao remove por id um registo das tabelas de plantão
+func (s *ServidorHTTP) removerRegistoPlantao(w http.ResponseWriter, r *http.Request, tabela, nome string) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.bancoDados.Exec("DELETE FROM "+tabela+" WHERE id = $1", id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao remover %s: %v", strings.ToLower(nome), err), http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		http.Error(w, nome+" não encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": nome + " removida com sucesso",
+	})
+}
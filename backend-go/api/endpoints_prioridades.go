@@ -0,0 +1,365 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// expressaoSLAViolado indica se a ocorrência ultrapassou o tempo de resposta exigido
+const expressaoSLAViolado = `(df.tempo_resposta_minutos IS NOT NULL AND
+	COALESCE(o.timestamp_fim, NOW()) - o.timestamp_inicio > df.tempo_resposta_minutos * INTERVAL '1 minute')`
+
+// expressaoOrdemPrioridade converte a prioridade textual numa ordem numérica (ALTA primeiro)
+const expressaoOrdemPrioridade = `CASE df.prioridade WHEN 'ALTA' THEN 1 WHEN 'MEDIA' THEN 2 ELSE 3 END`
+
+// prioridadesValidas lista as prioridades aceitas pela tabela definicoes_falhas
+var prioridadesValidas = map[string]bool{"ALTA": true, "MEDIA": true, "BAIXA": true}
+
+// ordenacoesSeveridade mapeia o parâmetro 'ordenar' para a cláusula ORDER BY
+var ordenacoesSeveridade = map[string]string{
+	"inicio":      "o.timestamp_inicio DESC, o.id DESC",
+	"prioridade":  expressaoOrdemPrioridade + ", df.criticidade DESC, o.timestamp_inicio DESC, o.id DESC",
+	"criticidade": "df.criticidade DESC, " + expressaoOrdemPrioridade + ", o.timestamp_inicio DESC, o.id DESC",
+	"sla":         expressaoSLAViolado + " DESC, " + expressaoOrdemPrioridade + ", o.timestamp_inicio DESC, o.id DESC",
+}
+
+// FiltrosSeveridade agrupa os filtros de prioridade e SLA aceitos pelas rotas de ocorrências
+type FiltrosSeveridade struct {
+	Prioridades []string `json:"prioridades,omitempty"`
+	SLAViolado  *bool    `json:"sla_violado,omitempty"`
+	Ordenar     string   `json:"ordenar,omitempty"`
+}
+
+// RecarregadorDefinicoes volta a ler as definições usadas no processamento dos frames do PLC
+type RecarregadorDefinicoes interface {
+	Recarregar() error
+}
+
+// DefinirRecarregadorDefinicoes liga a API ao mapeamento de tags do servidor TCP, para que as
+// alterações de severidade cheguem às novas ocorrências sem reiniciar o serviço
+func (s *ServidorHTTP) DefinirRecarregadorDefinicoes(recarregador RecarregadorDefinicoes) {
+	s.recarregadorDefinicoes = recarregador
+}
+
+// recarregarDefinicoes atualiza o mapeamento em memória depois de uma alteração gravada
+func (s *ServidorHTTP) recarregarDefinicoes() {
+	if s.recarregadorDefinicoes == nil {
+		return
+	}
+	if err := s.recarregadorDefinicoes.Recarregar(); err != nil {
+		log.Printf("⚠️ Erro ao recarregar mapeamento de definições: %v", err)
+	}
+}
+
+// AtualizacaoSeveridade representa os campos editáveis do modelo de severidade.
+// O código só identifica a definição dentro de uma eclusa, por isso exige também 'eclusa'.
+type AtualizacaoSeveridade struct {
+	ID                   int     `json:"id,omitempty"`
+	Codigo               string  `json:"codigo,omitempty"`
+	Eclusa               string  `json:"eclusa,omitempty"`
+	Prioridade           *string `json:"prioridade,omitempty"`
+	Criticidade          *int    `json:"criticidade,omitempty"`
+	RelacionadaSeguranca *bool   `json:"relacionada_seguranca,omitempty"`
+	TempoRespostaMinutos *int    `json:"tempo_resposta_minutos,omitempty"`
+}
+
+// lerFiltrosSeveridade lê os parâmetros 'prioridade', 'sla_violado' e 'ordenar' da requisição.
+// A ordenação é validada por cada rota, que tem o seu próprio conjunto de opções.
+func lerFiltrosSeveridade(r *http.Request) (FiltrosSeveridade, error) {
+	var filtros FiltrosSeveridade
+
+	if valor := r.URL.Query().Get("prioridade"); valor != "" {
+		for _, prioridade := range strings.Split(valor, ",") {
+			prioridade = strings.ToUpper(strings.TrimSpace(prioridade))
+			if !prioridadesValidas[prioridade] {
+				return filtros, fmt.Errorf("prioridade inválida: %s", prioridade)
+			}
+			filtros.Prioridades = append(filtros.Prioridades, prioridade)
+		}
+	}
+
+	if valor := r.URL.Query().Get("sla_violado"); valor != "" {
+		violado, err := strconv.ParseBool(valor)
+		if err != nil {
+			return filtros, fmt.Errorf("parâmetro 'sla_violado' inválido: %s", valor)
+		}
+		filtros.SLAViolado = &violado
+	}
+
+	filtros.Ordenar = r.URL.Query().Get("ordenar")
+
+	return filtros, nil
+}
+
+// validarOrdenacao verifica se a ordenação pedida é suportada pela lista de ocorrências ativas
+func (f FiltrosSeveridade) validarOrdenacao() error {
+	if _, existe := ordenacoesSeveridade[f.Ordenar]; f.Ordenar != "" && !existe {
+		return fmt.Errorf("ordenação inválida: %s (use inicio, prioridade, criticidade ou sla)", f.Ordenar)
+	}
+	return nil
+}
+
+// ordenacao retorna a cláusula ORDER BY pedida ou a padrão da rota
+func (f FiltrosSeveridade) ordenacao(padrao string) string {
+	if clausula, existe := ordenacoesSeveridade[f.Ordenar]; existe {
+		return clausula
+	}
+	return padrao
+}
+
+// validar verifica os valores de uma atualização de severidade
+func (a AtualizacaoSeveridade) validar() error {
+	if a.Prioridade != nil && !prioridadesValidas[strings.ToUpper(*a.Prioridade)] {
+		return fmt.Errorf("prioridade inválida: %s", *a.Prioridade)
+	}
+	if a.Criticidade != nil && (*a.Criticidade < 1 || *a.Criticidade > 5) {
+		return fmt.Errorf("criticidade deve estar entre 1 e 5")
+	}
+	if a.TempoRespostaMinutos != nil && *a.TempoRespostaMinutos < 0 {
+		return fmt.Errorf("tempo_resposta_minutos não pode ser negativo")
+	}
+	if a.Prioridade == nil && a.Criticidade == nil && a.RelacionadaSeguranca == nil && a.TempoRespostaMinutos == nil {
+		return fmt.Errorf("nenhum campo de severidade informado")
+	}
+	return nil
+}
+
+// aplicarAtualizacaoSeveridade grava uma atualização de severidade identificada por ID ou por
+// código e eclusa
+func aplicarAtualizacaoSeveridade(executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}, a AtualizacaoSeveridade) (int64, error) {
+	if err := a.validar(); err != nil {
+		return 0, err
+	}
+
+	var prioridade interface{}
+	if a.Prioridade != nil {
+		prioridade = strings.ToUpper(*a.Prioridade)
+	}
+
+	// tempo_resposta_minutos = 0 remove o SLA da definição
+	var tempoResposta interface{}
+	removerSLA := false
+	if a.TempoRespostaMinutos != nil {
+		if *a.TempoRespostaMinutos == 0 {
+			removerSLA = true
+		} else {
+			tempoResposta = *a.TempoRespostaMinutos
+		}
+	}
+
+	query := `
+		UPDATE definicoes_falhas SET
+			prioridade = COALESCE($1, prioridade),
+			criticidade = COALESCE($2, criticidade),
+			relacionada_seguranca = COALESCE($3, relacionada_seguranca),
+			tempo_resposta_minutos = CASE WHEN $4 THEN NULL ELSE COALESCE($5, tempo_resposta_minutos) END,
+			updated_at = NOW()
+		WHERE `
+	args := []interface{}{prioridade, a.Criticidade, a.RelacionadaSeguranca, removerSLA, tempoResposta}
+
+	switch {
+	case a.ID > 0:
+		query += "id = $6"
+		args = append(args, a.ID)
+	case a.Codigo != "" && a.Eclusa != "":
+		query += "codigo = $6 AND eclusa_id = (SELECT id FROM eclusas WHERE codigo = $7)"
+		args = append(args, a.Codigo, a.Eclusa)
+	case a.Codigo != "":
+		return 0, fmt.Errorf("informe 'eclusa' junto com o 'codigo' %s (o código repete-se entre eclusas)", a.Codigo)
+	default:
+		return 0, fmt.Errorf("informe 'id' ou 'codigo' e 'eclusa' da definição")
+	}
+
+	result, err := executor.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// atualizarSeveridadeDefinicao atualiza prioridade e severidade de uma definição
+func (s *ServidorHTTP) atualizarSeveridadeDefinicao(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var atualizacao AtualizacaoSeveridade
+	if err := json.NewDecoder(r.Body).Decode(&atualizacao); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	atualizacao.ID = id
+	atualizacao.Codigo = ""
+	atualizacao.Eclusa = ""
+
+	linhas, err := aplicarAtualizacaoSeveridade(s.bancoDados, atualizacao)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao atualizar severidade: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if linhas == 0 {
+		http.Error(w, "Definição não encontrada", http.StatusNotFound)
+		return
+	}
+	s.recarregarDefinicoes()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Severidade da definição atualizada com sucesso",
+	})
+}
+
+// importarSeveridadeDefinicoes importa prioridades em lote (JSON ou CSV) numa única transação
+//
+// CSV esperado (com cabeçalho): eclusa,codigo,prioridade,criticidade,relacionada_seguranca,tempo_resposta_minutos
+func (s *ServidorHTTP) importarSeveridadeDefinicoes(w http.ResponseWriter, r *http.Request) {
+	var atualizacoes []AtualizacaoSeveridade
+	var err error
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		atualizacoes, err = lerSeveridadeCSV(r.Body)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&atualizacoes)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Conteúdo inválido: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if len(atualizacoes) == 0 {
+		http.Error(w, "Nenhuma definição informada", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := s.bancoDados.Begin()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao iniciar transação: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	atualizadas := 0
+	var naoEncontradas []string
+
+	for i, atualizacao := range atualizacoes {
+		linhas, err := aplicarAtualizacaoSeveridade(tx, atualizacao)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro na linha %d: %v", i+1, err), http.StatusBadRequest)
+			return
+		}
+		if linhas == 0 {
+			naoEncontradas = append(naoEncontradas, identificadorAtualizacao(atualizacao))
+			continue
+		}
+		atualizadas++
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao gravar importação: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if atualizadas > 0 {
+		s.recarregarDefinicoes()
+	}
+
+	log.Printf("📥 Importação de prioridades: %d definições atualizadas, %d não encontradas", atualizadas, len(naoEncontradas))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":         true,
+		"atualizadas":     atualizadas,
+		"nao_encontradas": naoEncontradas,
+	})
+}
+
+// lerSeveridadeCSV converte um CSV de prioridades em atualizações de severidade
+func lerSeveridadeCSV(leitor io.Reader) ([]AtualizacaoSeveridade, error) {
+	leitorCSV := csv.NewReader(leitor)
+	leitorCSV.TrimLeadingSpace = true
+
+	cabecalho, err := leitorCSV.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cabeçalho CSV ausente: %v", err)
+	}
+
+	colunas := make(map[string]int)
+	for i, nome := range cabecalho {
+		colunas[strings.ToLower(strings.TrimSpace(nome))] = i
+	}
+	for _, obrigatoria := range []string{"eclusa", "codigo"} {
+		if _, existe := colunas[obrigatoria]; !existe {
+			return nil, fmt.Errorf("coluna '%s' obrigatória", obrigatoria)
+		}
+	}
+
+	var atualizacoes []AtualizacaoSeveridade
+	for linha := 2; ; linha++ {
+		registro, err := leitorCSV.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: %v", linha, err)
+		}
+
+		valor := func(coluna string) string {
+			if i, existe := colunas[coluna]; existe && i < len(registro) {
+				return strings.TrimSpace(registro[i])
+			}
+			return ""
+		}
+
+		atualizacao := AtualizacaoSeveridade{Codigo: valor("codigo"), Eclusa: valor("eclusa")}
+		if v := valor("prioridade"); v != "" {
+			atualizacao.Prioridade = &v
+		}
+		if v := valor("criticidade"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("linha %d: criticidade inválida", linha)
+			}
+			atualizacao.Criticidade = &n
+		}
+		if v := valor("relacionada_seguranca"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("linha %d: relacionada_seguranca inválida", linha)
+			}
+			atualizacao.RelacionadaSeguranca = &b
+		}
+		if v := valor("tempo_resposta_minutos"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("linha %d: tempo_resposta_minutos inválido", linha)
+			}
+			atualizacao.TempoRespostaMinutos = &n
+		}
+
+		atualizacoes = append(atualizacoes, atualizacao)
+	}
+
+	return atualizacoes, nil
+}
+
+// identificadorAtualizacao descreve a definição alvo de uma atualização para mensagens
+func identificadorAtualizacao(a AtualizacaoSeveridade) string {
+	if a.Codigo != "" {
+		return a.Eclusa + "/" + a.Codigo
+	}
+	return strconv.Itoa(a.ID)
+}
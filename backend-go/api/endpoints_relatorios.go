@@ -0,0 +1,386 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/relatorios"
+	"github.com/gorilla/mux"
+)
+
+const (
+	limitePadraoRelatorios = 50
+	limiteMaximoRelatorios = 500
+)
+
+// RelatorioArquivado descreve um relatório gerado (sem o conteúdo)
+type RelatorioArquivado struct {
+	ID            int64           `json:"id"`
+	Tipo          string          `json:"tipo"`
+	EclusaCodigo  string          `json:"eclusa_codigo"`
+	PeriodoInicio time.Time       `json:"periodo_inicio"`
+	PeriodoFim    time.Time       `json:"periodo_fim"`
+	Origem        string          `json:"origem"`
+	GeradoEm      time.Time       `json:"gerado_em"`
+	TamanhoPDF    int             `json:"tamanho_pdf_bytes"`
+	Resumo        json.RawMessage `json:"resumo,omitempty"`
+	URLHTML       string          `json:"url_html"`
+	URLPDF        string          `json:"url_pdf"`
+}
+
+// NotaOperador é uma nota livre de turno associada a uma eclusa
+type NotaOperador struct {
+	ID           int64     `json:"id"`
+	EclusaCodigo string    `json:"eclusa_codigo"`
+	Autor        string    `json:"autor"`
+	Texto        string    `json:"texto"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// obterRelatorios lista os relatórios arquivados (filtros: tipo, eclusa, inicio, fim, limite)
+func (s *ServidorHTTP) obterRelatorios(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	q := r.URL.Query()
+	query := `
+		SELECT r.id, r.tipo, e.codigo, r.periodo_inicio, r.periodo_fim, r.origem, r.gerado_em,
+			octet_length(r.pdf), COALESCE(r.resumo::text, '')
+		FROM relatorios_gerados r
+		JOIN eclusas e ON r.eclusa_id = e.id
+		WHERE 1=1`
+	args := []interface{}{}
+
+	if tipo := strings.ToUpper(q.Get("tipo")); tipo != "" {
+		if !relatorios.TipoValido(tipo) {
+			http.Error(w, "Parâmetro 'tipo' inválido: use TURNO, DIARIO ou MENSAL", http.StatusBadRequest)
+			return
+		}
+		args = append(args, tipo)
+		query += fmt.Sprintf(" AND r.tipo = $%d", len(args))
+	}
+	if eclusa := q.Get("eclusa"); eclusa != "" {
+		args = append(args, strings.ToUpper(eclusa))
+		query += fmt.Sprintf(" AND e.codigo = $%d", len(args))
+	}
+
+	inicio, err := lerDataHora(q.Get("inicio"), "inicio")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if inicio != nil {
+		args = append(args, *inicio)
+		query += fmt.Sprintf(" AND r.periodo_fim > $%d", len(args))
+	}
+	fim, err := lerDataHora(q.Get("fim"), "fim")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if fim != nil {
+		args = append(args, *fim)
+		query += fmt.Sprintf(" AND r.periodo_inicio < $%d", len(args))
+	}
+
+	limite := limitePadraoRelatorios
+	if valor := q.Get("limite"); valor != "" {
+		limite, err = strconv.Atoi(valor)
+		if err != nil || limite <= 0 || limite > limiteMaximoRelatorios {
+			http.Error(w, fmt.Sprintf("Parâmetro 'limite' inválido (1 a %d)", limiteMaximoRelatorios), http.StatusBadRequest)
+			return
+		}
+	}
+	query += fmt.Sprintf(" ORDER BY r.periodo_inicio DESC, r.tipo, e.codigo LIMIT %d", limite)
+
+	rows, err := s.bancoDados.Query(query, args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar relatórios: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	lista := []RelatorioArquivado{}
+	for rows.Next() {
+		var rel RelatorioArquivado
+		var resumo string
+		if err := rows.Scan(&rel.ID, &rel.Tipo, &rel.EclusaCodigo, &rel.PeriodoInicio, &rel.PeriodoFim,
+			&rel.Origem, &rel.GeradoEm, &rel.TamanhoPDF, &resumo); err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler relatório: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if resumo != "" {
+			rel.Resumo = json.RawMessage(resumo)
+		}
+		rel.URLHTML = fmt.Sprintf("/api/v1/relatorios/%d?formato=html", rel.ID)
+		rel.URLPDF = fmt.Sprintf("/api/v1/relatorios/%d?formato=pdf", rel.ID)
+		lista = append(lista, rel)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    lista,
+		"total":   len(lista),
+	})
+}
+
+// baixarRelatorio devolve o conteúdo arquivado em HTML (padrão) ou PDF
+func (s *ServidorHTTP) baixarRelatorio(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	formato := strings.ToLower(r.URL.Query().Get("formato"))
+	if formato == "" {
+		formato = "html"
+	}
+	coluna, tipoConteudo := "r.html", "text/html; charset=utf-8"
+	switch formato {
+	case "html":
+	case "pdf":
+		coluna, tipoConteudo = "r.pdf", "application/pdf"
+	default:
+		http.Error(w, "Parâmetro 'formato' inválido: use html ou pdf", http.StatusBadRequest)
+		return
+	}
+
+	var conteudo []byte
+	var tipo, eclusa string
+	var inicio time.Time
+	err = s.bancoDados.QueryRow(`
+		SELECT `+coluna+`, r.tipo, e.codigo, r.periodo_inicio
+		FROM relatorios_gerados r
+		JOIN eclusas e ON r.eclusa_id = e.id
+		WHERE r.id = $1`, id).Scan(&conteudo, &tipo, &eclusa, &inicio)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Relatório não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar relatório: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	disposicao := "inline"
+	if r.URL.Query().Get("download") == "true" || formato == "pdf" {
+		disposicao = "attachment"
+	}
+	nomeArquivo := fmt.Sprintf("relatorio_%s_%s_%s.%s", strings.ToLower(tipo), strings.ToLower(eclusa),
+		inicio.Format("20060102_1504"), formato)
+
+	w.Header().Set("Content-Type", tipoConteudo)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`%s; filename="%s"`, disposicao, nomeArquivo))
+	w.Header().Set("Content-Length", strconv.Itoa(len(conteudo)))
+	w.Write(conteudo)
+}
+
+// gerarRelatorio gera (ou volta a gerar) um relatório a pedido. Sem 'inicio'/'fim' usa o último
+// período completo do tipo até 'referencia' (padrão: agora).
+func (s *ServidorHTTP) gerarRelatorio(w http.ResponseWriter, r *http.Request) {
+	var entrada struct {
+		Tipo       string `json:"tipo"`
+		Eclusa     string `json:"eclusa"`
+		Inicio     string `json:"inicio"`
+		Fim        string `json:"fim"`
+		Referencia string `json:"referencia"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	entrada.Tipo = strings.ToUpper(entrada.Tipo)
+	if !relatorios.TipoValido(entrada.Tipo) || entrada.Eclusa == "" {
+		http.Error(w, "Campos 'tipo' (TURNO, DIARIO ou MENSAL) e 'eclusa' são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	inicio, err := lerDataHora(entrada.Inicio, "inicio")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fim, err := lerDataHora(entrada.Fim, "fim")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	referencia, err := lerDataHora(entrada.Referencia, "referencia")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	agora := time.Now()
+	if (inicio == nil) != (fim == nil) {
+		http.Error(w, "Informe 'inicio' e 'fim' juntos, ou apenas 'referencia'", http.StatusBadRequest)
+		return
+	}
+	if inicio == nil {
+		if referencia == nil {
+			referencia = &agora
+		}
+		periodoInicio, periodoFim := relatorios.Periodo(entrada.Tipo, *referencia, s.configuracoes.Relatorios_DuracaoTurno)
+		inicio, fim = &periodoInicio, &periodoFim
+	}
+	if !fim.After(*inicio) {
+		http.Error(w, "'fim' deve ser posterior a 'inicio'", http.StatusBadRequest)
+		return
+	}
+
+	id, err := relatorios.GerarRelatorio(s.bancoDados, entrada.Tipo, entrada.Eclusa, *inicio, *fim, relatorios.OrigemManual, agora)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao gerar relatório: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"id":             id,
+			"periodo_inicio": inicio,
+			"periodo_fim":    fim,
+			"url_html":       fmt.Sprintf("/api/v1/relatorios/%d?formato=html", id),
+			"url_pdf":        fmt.Sprintf("/api/v1/relatorios/%d?formato=pdf", id),
+		},
+	})
+}
+
+// obterNotasOperador lista as notas de turno (filtros: eclusa, inicio, fim; padrão: últimas 24 horas)
+func (s *ServidorHTTP) obterNotasOperador(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	q := r.URL.Query()
+	inicio, err := lerDataHora(q.Get("inicio"), "inicio")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fim, err := lerDataHora(q.Get("fim"), "fim")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if fim == nil {
+		agora := time.Now()
+		fim = &agora
+	}
+	if inicio == nil {
+		umDiaAntes := fim.Add(-24 * time.Hour)
+		inicio = &umDiaAntes
+	}
+
+	query := `
+		SELECT n.id, e.codigo, n.autor, n.texto, n.timestamp
+		FROM notas_operador n
+		JOIN eclusas e ON n.eclusa_id = e.id
+		WHERE n.timestamp >= $1 AND n.timestamp < $2`
+	args := []interface{}{*inicio, *fim}
+	if eclusa := q.Get("eclusa"); eclusa != "" {
+		args = append(args, strings.ToUpper(eclusa))
+		query += " AND e.codigo = $3"
+	}
+	query += " ORDER BY n.timestamp"
+
+	rows, err := s.bancoDados.Query(query, args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar notas: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	notas := []NotaOperador{}
+	for rows.Next() {
+		var nota NotaOperador
+		if err := rows.Scan(&nota.ID, &nota.EclusaCodigo, &nota.Autor, &nota.Texto, &nota.Timestamp); err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler nota: %v", err), http.StatusInternalServerError)
+			return
+		}
+		notas = append(notas, nota)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    notas,
+		"total":   len(notas),
+	})
+}
+
+// criarNotaOperador regista uma nota de turno que entra nos relatórios do período
+func (s *ServidorHTTP) criarNotaOperador(w http.ResponseWriter, r *http.Request) {
+	var entrada struct {
+		Eclusa string `json:"eclusa"`
+		Autor  string `json:"autor"`
+		Texto  string `json:"texto"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	entrada.Autor = strings.TrimSpace(entrada.Autor)
+	entrada.Texto = strings.TrimSpace(entrada.Texto)
+	if entrada.Eclusa == "" || entrada.Autor == "" || entrada.Texto == "" {
+		http.Error(w, "Campos 'eclusa', 'autor' e 'texto' são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	var id int64
+	err := s.bancoDados.QueryRow(`
+		INSERT INTO notas_operador (eclusa_id, autor, texto)
+		SELECT id, $2, $3 FROM eclusas WHERE codigo = $1
+		RETURNING id`, strings.ToUpper(entrada.Eclusa), entrada.Autor, entrada.Texto).Scan(&id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Eclusa não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao criar nota: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    map[string]int64{"id": id},
+	})
+}
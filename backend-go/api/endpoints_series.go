@@ -0,0 +1,289 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/database"
+	"github.com/edp/falhas-backend/modelos"
+	"github.com/edp/falhas-backend/series"
+	"github.com/gorilla/mux"
+)
+
+// TagAnalogicaResumo é uma tag analógica com o último valor gravado
+type TagAnalogicaResumo struct {
+	modelos.TagAnalogica
+	UltimoValor     *float64   `json:"ultimo_valor,omitempty"`
+	UltimaAmostraEm *time.Time `json:"ultima_amostra_em,omitempty"`
+}
+
+// obterTagsAnalogicas lista as tags analógicas e o último valor de cada uma
+func (s *ServidorHTTP) obterTagsAnalogicas(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	query := `
+		SELECT t.id, t.tag, t.eclusa_id, COALESCE(e.codigo, ''), COALESCE(t.descricao, ''),
+			COALESCE(t.unidade, ''), t.word_index, t.tipo_dado, t.escala, t.deslocamento,
+			t.banda_morta, t.intervalo_maximo_segundos, t.ativa,
+			ultima.valor, ultima.timestamp
+		FROM tags_analogicas t
+		LEFT JOIN eclusas e ON t.eclusa_id = e.id
+		LEFT JOIN LATERAL (
+			SELECT b.valor, b.timestamp FROM series_brutas b
+			WHERE b.tag_id = t.id ORDER BY b.timestamp DESC LIMIT 1
+		) ultima ON true`
+	args := []interface{}{}
+	if eclusa := r.URL.Query().Get("eclusa"); eclusa != "" {
+		args = append(args, strings.ToUpper(eclusa))
+		query += " WHERE e.codigo = $1"
+	}
+	query += " ORDER BY e.codigo NULLS LAST, t.tag"
+
+	rows, err := s.bancoDados.Query(query, args...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar tags analógicas: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	tags := []TagAnalogicaResumo{}
+	for rows.Next() {
+		var tag TagAnalogicaResumo
+		var eclusaID sql.NullInt64
+		var ultimoValor sql.NullFloat64
+		var ultimaAmostra sql.NullTime
+
+		err := rows.Scan(&tag.ID, &tag.Tag, &eclusaID, &tag.EclusaCodigo, &tag.Descricao,
+			&tag.Unidade, &tag.WordIndex, &tag.TipoDado, &tag.Escala, &tag.Deslocamento,
+			&tag.BandaMorta, &tag.IntervaloMaximoSegundos, &tag.Ativa,
+			&ultimoValor, &ultimaAmostra)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao ler tag analógica: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		if eclusaID.Valid {
+			id := int(eclusaID.Int64)
+			tag.EclusaID = &id
+		}
+		if ultimoValor.Valid {
+			tag.UltimoValor = &ultimoValor.Float64
+			dataHora := database.HoraLocal(ultimaAmostra.Time)
+			tag.UltimaAmostraEm = &dataHora
+		}
+
+		tags = append(tags, tag)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    tags,
+		"total":   len(tags),
+	})
+}
+
+// salvarTagAnalogica cria ou atualiza (pelo nome) uma tag analógica
+func (s *ServidorHTTP) salvarTagAnalogica(w http.ResponseWriter, r *http.Request) {
+	var entrada struct {
+		Tag                     string   `json:"tag"`
+		EclusaCodigo            string   `json:"eclusa_codigo"`
+		Descricao               string   `json:"descricao"`
+		Unidade                 string   `json:"unidade"`
+		WordIndex               *int     `json:"word_index"`
+		TipoDado                string   `json:"tipo_dado"`
+		Escala                  *float64 `json:"escala"`
+		Deslocamento            float64  `json:"deslocamento"`
+		BandaMorta              float64  `json:"banda_morta"`
+		IntervaloMaximoSegundos *int     `json:"intervalo_maximo_segundos"`
+		Ativa                   *bool    `json:"ativa"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&entrada); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	entrada.Tag = strings.TrimSpace(entrada.Tag)
+	if entrada.Tag == "" || entrada.WordIndex == nil || *entrada.WordIndex < 0 {
+		http.Error(w, "Campos 'tag' e 'word_index' são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	entrada.TipoDado = strings.ToUpper(entrada.TipoDado)
+	if entrada.TipoDado == "" {
+		entrada.TipoDado = modelos.TipoDadoInt16
+	}
+	switch entrada.TipoDado {
+	case modelos.TipoDadoInt16, modelos.TipoDadoUint16, modelos.TipoDadoInt32, modelos.TipoDadoReal:
+	default:
+		http.Error(w, "Campo 'tipo_dado' inválido: use INT16, UINT16, INT32 ou REAL", http.StatusBadRequest)
+		return
+	}
+
+	escala := 1.0
+	if entrada.Escala != nil {
+		escala = *entrada.Escala
+	}
+	intervaloMaximo := 60
+	if entrada.IntervaloMaximoSegundos != nil {
+		intervaloMaximo = *entrada.IntervaloMaximoSegundos
+	}
+	ativa := true
+	if entrada.Ativa != nil {
+		ativa = *entrada.Ativa
+	}
+
+	var eclusaID sql.NullInt64
+	if entrada.EclusaCodigo != "" {
+		err := s.bancoDados.QueryRow("SELECT id FROM eclusas WHERE codigo = $1",
+			strings.ToUpper(entrada.EclusaCodigo)).Scan(&eclusaID)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Eclusa não encontrada", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao buscar eclusa: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var id int
+	err := s.bancoDados.QueryRow(`
+		INSERT INTO tags_analogicas (tag, eclusa_id, descricao, unidade, word_index, tipo_dado,
+			escala, deslocamento, banda_morta, intervalo_maximo_segundos, ativa)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (tag) DO UPDATE SET
+			eclusa_id = EXCLUDED.eclusa_id,
+			descricao = EXCLUDED.descricao,
+			unidade = EXCLUDED.unidade,
+			word_index = EXCLUDED.word_index,
+			tipo_dado = EXCLUDED.tipo_dado,
+			escala = EXCLUDED.escala,
+			deslocamento = EXCLUDED.deslocamento,
+			banda_morta = EXCLUDED.banda_morta,
+			intervalo_maximo_segundos = EXCLUDED.intervalo_maximo_segundos,
+			ativa = EXCLUDED.ativa,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id`,
+		entrada.Tag, eclusaID, entrada.Descricao, entrada.Unidade, *entrada.WordIndex, entrada.TipoDado,
+		escala, entrada.Deslocamento, entrada.BandaMorta, intervaloMaximo, ativa).Scan(&id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao salvar tag analógica: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"id":  id,
+			"tag": entrada.Tag,
+		},
+	})
+}
+
+// obterSerie retorna os valores de uma tag analógica num intervalo, prontos para gráfico
+func (s *ServidorHTTP) obterSerie(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	q := r.URL.Query()
+
+	inicio, err := lerDataHora(q.Get("inicio"), "inicio")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fim, err := lerDataHora(q.Get("fim"), "fim")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Sem intervalo informado, retorna as últimas 24 horas
+	if fim == nil {
+		agora := time.Now()
+		fim = &agora
+	}
+	if inicio == nil {
+		umDiaAntes := fim.Add(-24 * time.Hour)
+		inicio = &umDiaAntes
+	}
+	if !inicio.Before(*fim) {
+		http.Error(w, "Parâmetro 'inicio' deve ser anterior a 'fim'", http.StatusBadRequest)
+		return
+	}
+
+	resolucao := q.Get("resolucao")
+	if resolucao == "" {
+		resolucao = series.ResolucaoAutomatica
+	}
+	if !s.consultorSeries.ResolucaoValida(resolucao) {
+		http.Error(w, "Parâmetro 'resolucao' inválido: use auto, bruto, 1m, 15m ou 1h", http.StatusBadRequest)
+		return
+	}
+
+	pontos := s.consultorSeries.PontosMaximos()
+	if valor := q.Get("pontos"); valor != "" {
+		pontos, err = strconv.Atoi(valor)
+		if err != nil || pontos < 3 {
+			http.Error(w, "Parâmetro 'pontos' inválido (mínimo 3)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tag, err := s.consultorSeries.BuscarTag(mux.Vars(r)["tag"])
+	if err == sql.ErrNoRows {
+		http.Error(w, "Tag analógica não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar tag analógica: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	resultado, err := s.consultorSeries.Consultar(tag, *inicio, *fim, resolucao, pontos)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":          true,
+		"tag":              resultado.Tag,
+		"resolucao":        resultado.Resolucao,
+		"decimado":         resultado.Decimado,
+		"pontos_originais": resultado.PontosOriginais,
+		"data":             resultado.Pontos,
+		"total":            len(resultado.Pontos),
+		"filtros": map[string]interface{}{
+			"inicio":    inicio,
+			"fim":       fim,
+			"resolucao": q.Get("resolucao"),
+			"pontos":    pontos,
+		},
+	})
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/edp/falhas-backend/repositorio"
+	"github.com/edp/falhas-backend/sincronizacao"
+	"github.com/gorilla/mux"
+)
+
+// tamanhoMaximoLote limita o corpo de um lote recebido de uma eclusa
+const tamanhoMaximoLote = 32 << 20
+
+// configurarRotasSincronizacao regista o estado da sincronização e, no central, a receção de lotes
+func (s *ServidorHTTP) configurarRotasSincronizacao(api *mux.Router) {
+	api.HandleFunc("/sincronizacao/estado", s.obterEstadoSincronizacao).Methods("GET")
+	if s.configuracoes != nil && s.configuracoes.Sync_Central {
+		api.HandleFunc("/sincronizacao/lotes", s.receberLoteSincronizacao).Methods("POST")
+	}
+}
+
+// receberLoteSincronizacao aplica um lote da fila de uma eclusa (só no central). O lote pode
+// repetir itens já aplicados: a resposta indica sempre a última sequência aplicada da origem.
+func (s *ServidorHTTP) receberLoteSincronizacao(w http.ResponseWriter, r *http.Request) {
+	token := s.configuracoes.Sync_Token
+	if token == "" {
+		http.Error(w, "SYNC_TOKEN não configurado no central", http.StatusServiceUnavailable)
+		return
+	}
+	recebido := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(recebido), []byte(token)) != 1 {
+		http.Error(w, "Token de sincronização inválido", http.StatusUnauthorized)
+		return
+	}
+
+	var lote sincronizacao.Lote
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, tamanhoMaximoLote)).Decode(&lote); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	eclusas, err := s.repositorios.Eclusas.Listar()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar eclusas: %v", err), http.StatusInternalServerError)
+		return
+	}
+	conhecida := false
+	for _, eclusa := range eclusas {
+		if eclusa.Codigo == lote.Origem {
+			conhecida = true
+			break
+		}
+	}
+	if !conhecida {
+		http.Error(w, fmt.Sprintf("Origem '%s' não é uma eclusa conhecida", lote.Origem), http.StatusBadRequest)
+		return
+	}
+	// Uma eclusa só envia ocorrências das suas definições
+	for _, item := range lote.Itens {
+		if item.Ocorrencia != nil && item.Ocorrencia.EclusaCodigo != lote.Origem {
+			http.Error(w, fmt.Sprintf("Item %d pertence à eclusa %s e não a %s",
+				item.Sequencia, item.Ocorrencia.EclusaCodigo, lote.Origem), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Os itens rejeitados (ex.: definição inexistente no central) não param a fila da eclusa:
+	// seguem na resposta para o enviador os registar
+	resultado, err := s.repositorios.Sincronizacao.AplicarLote(lote.Origem, lote.Itens)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao aplicar lote: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for _, rejeitado := range resultado.Rejeitados {
+		log.Printf("⚠️ Item %d de %s rejeitado: %s", rejeitado.Sequencia, lote.Origem, rejeitado.Motivo)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sincronizacao.RespostaLote{
+		Success:         true,
+		UltimaSequencia: resultado.UltimaSequencia,
+		Aplicados:       resultado.Aplicados,
+		Rejeitados:      resultado.Rejeitados,
+	})
+}
+
+// obterEstadoSincronizacao devolve a fila local (eclusa) e as origens recebidas (central)
+func (s *ServidorHTTP) obterEstadoSincronizacao(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	fila, err := s.repositorios.Sincronizacao.EstadoFila()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	origens, err := s.repositorios.Sincronizacao.ListarOrigens()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if eclusa := strings.ToUpper(r.URL.Query().Get("eclusa")); eclusa != "" {
+		filtradas := []repositorio.OrigemSincronizacao{}
+		for _, origem := range origens {
+			if origem.Origem == eclusa {
+				filtradas = append(filtradas, origem)
+			}
+		}
+		origens = filtradas
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"envio_ativo": s.configuracoes.Sync_Ativo,
+			"central":     s.configuracoes.Sync_Central,
+			"origem":      s.configuracoes.Sync_Origem,
+			"fila":        fila,
+			"origens":     origens,
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/modelos"
+	"github.com/edp/falhas-backend/repositorio"
+	"github.com/edp/falhas-backend/sincronizacao"
+)
+
+func TestLoteComDefinicaoInexistenteRejeitaItensEAvanca(t *testing.T) {
+	memoria := repositorio.NovaMemoria()
+	eclusa := memoria.AdicionarEclusa(repositorio.Eclusa{Codigo: "RG", Nome: "Régua", Ativa: true})
+	setor := memoria.AdicionarSetor(repositorio.Setor{Codigo: "ENCHIMENTO", Nome: "Enchimento"})
+	memoria.AdicionarDefinicao(modelos.DefinicaoFalha{
+		EclusaID: eclusa, SetorID: setor, Codigo: "RG_001", Tipo: "FALHA",
+		Descricao: "falha de teste", Prioridade: "ALTA", PointIndex: 1, Ativa: true,
+	})
+
+	s := NovoServidorHTTP(nil, &config.Configuracoes{
+		DB_Tipo: config.BancoPostgres, Sync_Central: true, Sync_Token: "segredo",
+	})
+	s.DefinirRepositorios(memoria.Repositorios())
+
+	inicio := time.Now().Add(-time.Hour).Truncate(time.Second)
+	ocorrencia := func(pointIndex int) *repositorio.OcorrenciaSincronizada {
+		return &repositorio.OcorrenciaSincronizada{EclusaCodigo: "RG", PointIndex: pointIndex, Status: "ATIVO", TimestampInicio: inicio}
+	}
+	transicao := &repositorio.TransicaoSincronizada{StatusNovo: "ATIVO", Timestamp: inicio, Origem: "PLC"}
+	lote := sincronizacao.Lote{Origem: "RG", Itens: []repositorio.ItemSincronizacao{
+		{Sequencia: 1, Tipo: repositorio.ItemOcorrencia, OcorrenciaID: 10, Ocorrencia: ocorrencia(99)},
+		{Sequencia: 2, Tipo: repositorio.ItemTransicao, OcorrenciaID: 10, Transicao: transicao},
+		{Sequencia: 3, Tipo: repositorio.ItemOcorrencia, OcorrenciaID: 11, Ocorrencia: ocorrencia(1)},
+	}}
+
+	enviar := func() sincronizacao.RespostaLote {
+		t.Helper()
+		corpo, _ := json.Marshal(lote)
+		pedido := httptest.NewRequest(http.MethodPost, sincronizacao.CaminhoLotes, bytes.NewReader(corpo))
+		pedido.Header.Set("Authorization", "Bearer segredo")
+		gravador := httptest.NewRecorder()
+		s.router.ServeHTTP(gravador, pedido)
+		if gravador.Code != http.StatusOK {
+			t.Fatalf("POST %s = %d: %s", sincronizacao.CaminhoLotes, gravador.Code, gravador.Body.String())
+		}
+		var resposta sincronizacao.RespostaLote
+		if err := json.NewDecoder(gravador.Body).Decode(&resposta); err != nil {
+			t.Fatalf("resposta inválida: %v", err)
+		}
+		return resposta
+	}
+
+	// A transição da ocorrência rejeitada também é rejeitada (não se perde em silêncio)
+	resposta := enviar()
+	if resposta.UltimaSequencia != 3 || resposta.Aplicados != 1 || len(resposta.Rejeitados) != 2 ||
+		resposta.Rejeitados[0].Sequencia != 1 || resposta.Rejeitados[1].Sequencia != 2 {
+		t.Fatalf("resposta = %+v; esperado sequência 3, 1 aplicado e os itens 1 e 2 rejeitados", resposta)
+	}
+
+	// O reenvio do mesmo lote já não aplica nem rejeita nada
+	if resposta := enviar(); resposta.UltimaSequencia != 3 || resposta.Aplicados != 0 || len(resposta.Rejeitados) != 0 {
+		t.Fatalf("reenvio = %+v; esperado sequência 3 sem itens", resposta)
+	}
+
+	origens, err := memoria.Repositorios().Sincronizacao.ListarOrigens()
+	if err != nil {
+		t.Fatalf("ListarOrigens: %v", err)
+	}
+	if len(origens) != 1 || origens[0].TotalItens != 1 || origens[0].TotalRejeitados != 2 {
+		t.Fatalf("origens = %+v; esperado 1 item aplicado e 2 rejeitados", origens)
+	}
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	limitePadraoSOE = 1000
+	limiteMaximoSOE = 10000
+)
+
+// ordenacoesSOE define como reconstruir a ordem das mudanças de bits
+var ordenacoesSOE = map[string]string{
+	// Ordem de chegada ao servidor (sempre disponível)
+	"recebimento": "r.timestamp_recebimento, r.sequencia_frame, r.ordem_no_frame",
+	// Ordem pelo relógio do PLC quando o frame traz timestamp
+	"plc": "COALESCE(r.timestamp_plc, r.timestamp_recebimento), r.sequencia_frame, r.ordem_no_frame",
+}
+
+// RegistroSOE representa uma mudança de bit gravada no sequence-of-events
+type RegistroSOE struct {
+	ID                   int64      `json:"id"`
+	SequenciaFrame       int64      `json:"sequencia_frame"`
+	OrdemNoFrame         int        `json:"ordem_no_frame"`
+	Origem               string     `json:"origem,omitempty"`
+	EclusaCodigo         string     `json:"eclusa_codigo,omitempty"`
+	DefinicaoID          *int       `json:"definicao_id,omitempty"`
+	Codigo               string     `json:"codigo,omitempty"`
+	Descricao            string     `json:"descricao,omitempty"`
+	SetorNome            string     `json:"setor_nome,omitempty"`
+	WordIndex            int        `json:"word_index"`
+	BitIndex             int        `json:"bit_index"`
+	ValorAntigo          bool       `json:"valor_antigo"`
+	ValorNovo            bool       `json:"valor_novo"`
+	LeituraInicial       bool       `json:"leitura_inicial"`
+	TimestampPLC         *time.Time `json:"timestamp_plc,omitempty"`
+	TimestampRecebimento time.Time  `json:"timestamp_recebimento"`
+	DeltaMs              float64    `json:"delta_ms"` // Tempo desde a primeira mudança da consulta
+}
+
+// obterSOE retorna as mudanças de bits num intervalo, na ordem exata em que ocorreram
+func (s *ServidorHTTP) obterSOE(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	q := r.URL.Query()
+
+	inicio, err := lerDataHora(q.Get("inicio"), "inicio")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fim, err := lerDataHora(q.Get("fim"), "fim")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Sem intervalo informado, retorna a última hora
+	if fim == nil {
+		agora := time.Now()
+		fim = &agora
+	}
+	if inicio == nil {
+		umaHoraAntes := fim.Add(-time.Hour)
+		inicio = &umaHoraAntes
+	}
+
+	where := " WHERE r.timestamp_recebimento >= $1 AND r.timestamp_recebimento < $2"
+	args := []interface{}{*inicio, *fim}
+
+	if eclusa := q.Get("eclusa"); eclusa != "" {
+		args = append(args, strings.ToUpper(eclusa))
+		where += fmt.Sprintf(" AND e.codigo = $%d", len(args))
+	}
+	if codigo := q.Get("codigo"); codigo != "" {
+		args = append(args, codigo)
+		where += fmt.Sprintf(" AND df.codigo = $%d", len(args))
+	}
+	if q.Get("apenas_mapeados") == "true" {
+		where += " AND r.definicao_id IS NOT NULL"
+	}
+	if q.Get("incluir_leitura_inicial") != "true" {
+		where += " AND r.leitura_inicial = false"
+	}
+
+	registros, ordenar, limite, err := s.buscarSOE(where, args, q.Get("ordenar"), q.Get("limite"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    registros,
+		"total":   len(registros),
+		"filtros": map[string]interface{}{
+			"inicio":  inicio,
+			"fim":     fim,
+			"eclusa":  q.Get("eclusa"),
+			"codigo":  q.Get("codigo"),
+			"ordenar": ordenar,
+			"limite":  limite,
+		},
+	})
+}
+
+// obterSOEOcorrencia retorna as mudanças de bits da eclusa em torno do início de uma ocorrência
+func (s *ServidorHTTP) obterSOEOcorrencia(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	antes, err := lerDuracao(r.URL.Query().Get("antes"), 30*time.Second)
+	if err != nil {
+		http.Error(w, "Parâmetro 'antes' inválido (ex.: 30s, 2m)", http.StatusBadRequest)
+		return
+	}
+	depois, err := lerDuracao(r.URL.Query().Get("depois"), 60*time.Second)
+	if err != nil {
+		http.Error(w, "Parâmetro 'depois' inválido (ex.: 30s, 2m)", http.StatusBadRequest)
+		return
+	}
+
+	var eclusaID int
+	var inicio time.Time
+	err = s.bancoDados.QueryRow(`
+		SELECT df.eclusa_id, o.timestamp_inicio
+		FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		WHERE o.id = $1`, id).Scan(&eclusaID, &inicio)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Ocorrência não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar ocorrência: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	where := ` WHERE r.eclusa_id = $1 AND r.leitura_inicial = false
+		AND r.timestamp_recebimento >= $2 AND r.timestamp_recebimento <= $3`
+	args := []interface{}{eclusaID, inicio.Add(-antes), inicio.Add(depois)}
+
+	registros, ordenar, _, err := s.buscarSOE(where, args, r.URL.Query().Get("ordenar"), r.URL.Query().Get("limite"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":          true,
+		"data":             registros,
+		"total":            len(registros),
+		"ocorrencia_id":    id,
+		"timestamp_inicio": inicio,
+		"antes":            antes.String(),
+		"depois":           depois.String(),
+		"ordenar":          ordenar,
+	})
+}
+
+// buscarSOE executa a consulta ao SOE com as condições dadas, na ordem pedida
+func (s *ServidorHTTP) buscarSOE(where string, args []interface{}, ordenar, limiteTexto string) ([]RegistroSOE, string, int, error) {
+	if ordenar == "" {
+		ordenar = "recebimento"
+	}
+	clausulaOrdem, existe := ordenacoesSOE[ordenar]
+	if !existe {
+		return nil, ordenar, 0, fmt.Errorf("ordenação inválida: %s (use recebimento ou plc)", ordenar)
+	}
+
+	limite := limitePadraoSOE
+	if limiteTexto != "" {
+		l, err := strconv.Atoi(limiteTexto)
+		if err != nil || l <= 0 || l > limiteMaximoSOE {
+			return nil, ordenar, 0, fmt.Errorf("parâmetro 'limite' inválido: use um inteiro entre 1 e %d", limiteMaximoSOE)
+		}
+		limite = l
+	}
+
+	args = append(args, limite)
+	rows, err := s.bancoDados.Query(`
+		SELECT
+			r.id, r.sequencia_frame, r.ordem_no_frame, COALESCE(r.origem, ''),
+			COALESCE(e.codigo, ''), r.definicao_id, COALESCE(df.codigo, ''),
+			COALESCE(df.descricao, ''), COALESCE(s.nome, ''),
+			r.word_index, r.bit_index, r.valor_antigo, r.valor_novo, r.leitura_inicial,
+			r.timestamp_plc, r.timestamp_recebimento
+		FROM registros_soe r
+		LEFT JOIN eclusas e ON r.eclusa_id = e.id
+		LEFT JOIN definicoes_falhas df ON r.definicao_id = df.id
+		LEFT JOIN setores s ON df.setor_id = s.id`+where+
+		" ORDER BY "+clausulaOrdem+fmt.Sprintf(" LIMIT $%d", len(args)), args...)
+	if err != nil {
+		return nil, ordenar, limite, fmt.Errorf("erro ao buscar SOE: %v", err)
+	}
+	defer rows.Close()
+
+	registros := []RegistroSOE{}
+	var referencia time.Time
+
+	for rows.Next() {
+		var reg RegistroSOE
+		var definicaoID sql.NullInt64
+		var timestampPLC sql.NullTime
+
+		err := rows.Scan(
+			&reg.ID, &reg.SequenciaFrame, &reg.OrdemNoFrame, &reg.Origem,
+			&reg.EclusaCodigo, &definicaoID, &reg.Codigo,
+			&reg.Descricao, &reg.SetorNome,
+			&reg.WordIndex, &reg.BitIndex, &reg.ValorAntigo, &reg.ValorNovo, &reg.LeituraInicial,
+			&timestampPLC, &reg.TimestampRecebimento)
+		if err != nil {
+			return nil, ordenar, limite, fmt.Errorf("erro ao ler SOE: %v", err)
+		}
+
+		reg.DefinicaoID = ponteiroNullInt(definicaoID)
+		instante := reg.TimestampRecebimento
+		if timestampPLC.Valid {
+			reg.TimestampPLC = &timestampPLC.Time
+			if ordenar == "plc" {
+				instante = timestampPLC.Time
+			}
+		}
+
+		if referencia.IsZero() {
+			referencia = instante
+		}
+		reg.DeltaMs = float64(instante.Sub(referencia).Microseconds()) / 1000
+
+		registros = append(registros, reg)
+	}
+
+	return registros, ordenar, limite, rows.Err()
+}
+
+// lerDuracao interpreta uma duração Go (ex.: 30s) ou devolve o valor padrão
+func lerDuracao(valor string, padrao time.Duration) (time.Duration, error) {
+	if valor == "" {
+		return padrao, nil
+	}
+	duracao, err := time.ParseDuration(valor)
+	if err != nil || duracao < 0 {
+		return 0, fmt.Errorf("duração inválida: %s", valor)
+	}
+	return duracao, nil
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/repositorio"
+)
+
+const (
+	limitePadraoHistorico = 100
+	limiteMaximoHistorico = 1000
+)
+
+// formatoReferencia grava o instante de referência do cursor como literal TIMESTAMP (hora local)
+const formatoReferencia = "2006-01-02 15:04:05.999999"
+
+// formatosDataHora são os formatos aceitos nos parâmetros 'inicio' e 'fim'
+var formatosDataHora = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// CursorHistorico marca a última linha entregue numa página do histórico. Guarda a ordenação com
+// que foi gerado (só vale para ela) e o instante de referência das chaves que dependem de NOW().
+type CursorHistorico struct {
+	Chave      string `json:"c"`
+	Inicio     string `json:"t"`
+	ID         int64  `json:"id"`
+	Ordenar    string `json:"o"`
+	Ascendente bool   `json:"a,omitempty"`
+	Referencia string `json:"r"`
+}
+
+// FiltrosHistorico agrupa todos os filtros aceitos pelo histórico de ocorrências
+type FiltrosHistorico struct {
+	Inicio        *time.Time        `json:"inicio,omitempty"`
+	Fim           *time.Time        `json:"fim,omitempty"`
+	Eclusa        string            `json:"eclusa,omitempty"`
+	Setor         string            `json:"setor,omitempty"`
+	Tipo          string            `json:"tipo,omitempty"`
+	Status        string            `json:"status,omitempty"`
+	Codigo        string            `json:"codigo,omitempty"`
+	Busca         string            `json:"busca,omitempty"`
+	DuracaoMinima int64             `json:"duracao_min,omitempty"` // segundos
+	Manutencao    *bool             `json:"manutencao,omitempty"`  // true = só as abertas numa janela de manutenção
+	Severidade    FiltrosSeveridade `json:"severidade"`
+	Ordenar       string            `json:"ordenar"`
+	Ascendente    bool              `json:"ascendente"`
+	Limite        int               `json:"limite"`
+	Cursor        *CursorHistorico  `json:"-"`
+	Referencia    time.Time         `json:"-"` // Substitui NOW() na chave de ordenação
+}
+
+// lerFiltrosHistorico lê e valida os parâmetros do histórico de ocorrências
+func lerFiltrosHistorico(r *http.Request) (FiltrosHistorico, error) {
+	q := r.URL.Query()
+	filtros := FiltrosHistorico{
+		Eclusa:  strings.ToUpper(q.Get("eclusa")),
+		Setor:   q.Get("setor"),
+		Tipo:    q.Get("tipo"),
+		Status:  q.Get("status"),
+		Codigo:  q.Get("codigo"),
+		Busca:   strings.TrimSpace(q.Get("busca")),
+		Ordenar: q.Get("ordenar"),
+		Limite:  limitePadraoHistorico,
+	}
+
+	var err error
+	if filtros.Inicio, err = lerDataHora(q.Get("inicio"), "inicio"); err != nil {
+		return filtros, err
+	}
+	if filtros.Fim, err = lerDataHora(q.Get("fim"), "fim"); err != nil {
+		return filtros, err
+	}
+	if filtros.Inicio != nil && filtros.Fim != nil && !filtros.Fim.After(*filtros.Inicio) {
+		return filtros, fmt.Errorf("'fim' deve ser posterior a 'inicio'")
+	}
+
+	if valor := q.Get("limite"); valor != "" {
+		limite, err := strconv.Atoi(valor)
+		if err != nil || limite <= 0 || limite > limiteMaximoHistorico {
+			return filtros, fmt.Errorf("parâmetro 'limite' inválido: use um inteiro entre 1 e %d", limiteMaximoHistorico)
+		}
+		filtros.Limite = limite
+	}
+
+	if valor := q.Get("duracao_min"); valor != "" {
+		duracao, err := strconv.ParseInt(valor, 10, 64)
+		if err != nil || duracao < 0 {
+			return filtros, fmt.Errorf("parâmetro 'duracao_min' inválido: use segundos (inteiro >= 0)")
+		}
+		filtros.DuracaoMinima = duracao
+	}
+
+	switch q.Get("manutencao") {
+	case "":
+	case "true", "false":
+		manutencao := q.Get("manutencao") == "true"
+		filtros.Manutencao = &manutencao
+	default:
+		return filtros, fmt.Errorf("parâmetro 'manutencao' inválido: use true ou false")
+	}
+
+	if filtros.Ordenar == "" {
+		filtros.Ordenar = "inicio"
+	}
+	if !ordenacaoValida(filtros.Ordenar) {
+		return filtros, fmt.Errorf("ordenação inválida: %s (use inicio, duracao, prioridade, criticidade ou sla)", filtros.Ordenar)
+	}
+
+	switch strings.ToLower(q.Get("ordem")) {
+	case "", "desc":
+	case "asc":
+		filtros.Ascendente = true
+	default:
+		return filtros, fmt.Errorf("parâmetro 'ordem' inválido: use asc ou desc")
+	}
+
+	if filtros.Severidade, err = lerFiltrosSeveridade(r); err != nil {
+		return filtros, err
+	}
+
+	filtros.Referencia = time.Now()
+	if valor := q.Get("cursor"); valor != "" {
+		cursor, err := decodificarCursor(valor)
+		if err != nil {
+			return filtros, err
+		}
+		if cursor.Ordenar != filtros.Ordenar || cursor.Ascendente != filtros.Ascendente {
+			return filtros, fmt.Errorf("cursor gerado para outra ordenação (%s); repita a consulta sem cursor", cursor.Ordenar)
+		}
+		if err := validarChaveCursor(cursor.Chave, filtros.Ordenar); err != nil {
+			return filtros, err
+		}
+		if filtros.Referencia, err = time.ParseInLocation(formatoReferencia, cursor.Referencia, time.Local); err != nil {
+			return filtros, fmt.Errorf("cursor inválido")
+		}
+		filtros.Cursor = cursor
+	}
+
+	return filtros, nil
+}
+
+// filtroRepositorio converte os filtros (e o cursor recebido) para o repositório de ocorrências
+func (f FiltrosHistorico) filtroRepositorio() (repositorio.FiltroHistorico, error) {
+	filtro := repositorio.FiltroHistorico{
+		Inicio:        f.Inicio,
+		Fim:           f.Fim,
+		Eclusa:        f.Eclusa,
+		Setor:         f.Setor,
+		Tipo:          f.Tipo,
+		Status:        f.Status,
+		Codigo:        f.Codigo,
+		Busca:         f.Busca,
+		DuracaoMinima: f.DuracaoMinima,
+		Manutencao:    f.Manutencao,
+		Prioridades:   f.Severidade.Prioridades,
+		SLAViolado:    f.Severidade.SLAViolado,
+		Ordenar:       f.Ordenar,
+		Ascendente:    f.Ascendente,
+		Referencia:    f.Referencia,
+		Limite:        f.Limite,
+	}
+	if f.Cursor != nil {
+		inicio, err := time.Parse(time.RFC3339Nano, f.Cursor.Inicio)
+		if err != nil {
+			return filtro, fmt.Errorf("cursor inválido")
+		}
+		filtro.Apos = &repositorio.PosicaoHistorico{Chave: f.Cursor.Chave, Inicio: inicio, ID: f.Cursor.ID}
+	}
+	return filtro, nil
+}
+
+// cursorDaPosicao monta o cursor da próxima página com a ordenação e o instante de referência da consulta
+func (f FiltrosHistorico) cursorDaPosicao(posicao repositorio.PosicaoHistorico) CursorHistorico {
+	return CursorHistorico{
+		Chave:      posicao.Chave,
+		Inicio:     posicao.Inicio.Format(time.RFC3339Nano),
+		ID:         posicao.ID,
+		Ordenar:    f.Ordenar,
+		Ascendente: f.Ascendente,
+		Referencia: f.Referencia.Format(formatoReferencia),
+	}
+}
+
+// ordenacaoValida indica se a ordenação pedida é uma das repositorio.OrdenacoesHistorico
+func ordenacaoValida(ordenar string) bool {
+	for _, ordenacao := range repositorio.OrdenacoesHistorico {
+		if ordenacao == ordenar {
+			return true
+		}
+	}
+	return false
+}
+
+// parametros devolve os filtros no formato textual usado nas respostas da API
+func (f FiltrosHistorico) parametros(r *http.Request) map[string]string {
+	parametros := make(map[string]string)
+	for chave, valores := range r.URL.Query() {
+		if chave != "cursor" && len(valores) > 0 {
+			parametros[chave] = valores[0]
+		}
+	}
+	parametros["ordenar"] = f.Ordenar
+	parametros["limite"] = strconv.Itoa(f.Limite)
+	return parametros
+}
+
+// codificarCursor serializa o cursor em base64 URL-safe
+func codificarCursor(cursor CursorHistorico) string {
+	dados, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(dados)
+}
+
+// decodificarCursor lê um cursor recebido do cliente
+func decodificarCursor(valor string) (*CursorHistorico, error) {
+	dados, err := base64.RawURLEncoding.DecodeString(valor)
+	if err != nil {
+		return nil, fmt.Errorf("cursor inválido")
+	}
+
+	var cursor CursorHistorico
+	if err := json.Unmarshal(dados, &cursor); err != nil || cursor.ID <= 0 || cursor.Inicio == "" {
+		return nil, fmt.Errorf("cursor inválido")
+	}
+	return &cursor, nil
+}
+
+// validarChaveCursor confere que a chave do cursor converte para o tipo da ordenação
+// (um cursor adulterado daria erro no banco)
+func validarChaveCursor(chave, ordenar string) error {
+	var err error
+	switch ordenar {
+	case "prioridade", "criticidade", "sla":
+		_, err = strconv.Atoi(chave)
+	case "duracao":
+		_, err = strconv.ParseFloat(chave, 64)
+	}
+	if err != nil {
+		return fmt.Errorf("cursor inválido")
+	}
+	return nil
+}
+
+// lerDataHora interpreta datas nos formatos aceitos pela API (sem fuso = hora local)
+func lerDataHora(valor, parametro string) (*time.Time, error) {
+	if valor == "" {
+		return nil, nil
+	}
+	for _, formato := range formatosDataHora {
+		if dataHora, err := time.ParseInLocation(formato, valor, time.Local); err == nil {
+			return &dataHora, nil
+		}
+	}
+	return nil, fmt.Errorf("parâmetro '%s' inválido: use RFC3339 (2006-01-02T15:04:05Z) ou AAAA-MM-DD", parametro)
+}
@@ -6,58 +6,54 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/database"
+	"github.com/edp/falhas-backend/repositorio"
+	"github.com/edp/falhas-backend/series"
 	"github.com/gorilla/mux"
 )
 
 // ServidorHTTP gerencia a API REST para o front-end
 type ServidorHTTP struct {
-	bancoDados *sql.DB
-	router     *mux.Router
+	bancoDados      *sql.DB
+	router          *mux.Router
+	configuracoes   *config.Configuracoes
+	consultorSeries *series.Consultor
+	fonteEstado     FonteEstadoEclusa
+	recarregadorDefinicoes RecarregadorDefinicoes
+	repositorios    repositorio.Repositorios
 }
 
 // OcorrenciaCompleta representa uma ocorrência com todas as informações para o front-end
-type OcorrenciaCompleta struct {
-	ID             int64     `json:"id"`
-	Status         string    `json:"status"`
-	TimestampInicio time.Time `json:"timestamp_inicio"`
-	TimestampFim   *time.Time `json:"timestamp_fim,omitempty"`
-	
-	// Dados da Definição de Falha
-	DefinicaoID    int    `json:"definicao_id"`
-	Codigo         string `json:"codigo"`
-	Tipo           string `json:"tipo"`
-	Descricao      string `json:"descricao"`
-	Prioridade     string `json:"prioridade"`
-	WordIndex      int    `json:"word_index"`
-	BitIndex       int    `json:"bit_index"`
-	ClasseMensagem string `json:"classe_mensagem"`
-	
-	// Dados do Setor
-	SetorCodigo string `json:"setor_codigo"`
-	SetorNome   string `json:"setor_nome"`
-	
-	// Dados da Eclusa
-	EclusaCodigo string `json:"eclusa_codigo"`
-	EclusaNome   string `json:"eclusa_nome"`
-	
-	// Dados calculados
-	DuracaoSegundos *int64 `json:"duracao_segundos,omitempty"`
-}
+type OcorrenciaCompleta = repositorio.Ocorrencia
 
 // NovoServidorHTTP cria uma nova instância do servidor HTTP
-func NovoServidorHTTP(db *sql.DB) *ServidorHTTP {
+func NovoServidorHTTP(db *sql.DB, cfg *config.Configuracoes) *ServidorHTTP {
 	s := &ServidorHTTP{
-		bancoDados: db,
-		router:     mux.NewRouter(),
+		bancoDados:      db,
+		router:          mux.NewRouter(),
+		configuracoes:   cfg,
+		consultorSeries: series.NovoConsultor(db, cfg),
+		repositorios:    repositorio.Novo(db, cfg.DB_Tipo),
+	}
+	// Resoluções manuais também seguem para o central
+	if cfg.Sync_Ativo {
+		s.repositorios = s.repositorios.ComFilaSincronizacao()
 	}
 	
 	s.configurarRotas()
 	return s
 }
 
+// DefinirRepositorios troca os repositórios de ocorrências, definições, eclusas e setores
+// (ex.: repositorio.Memoria nos testes)
+func (s *ServidorHTTP) DefinirRepositorios(repositorios repositorio.Repositorios) {
+	s.repositorios = repositorios
+}
+
 // configurarRotas configura todas as rotas da API
 func (s *ServidorHTTP) configurarRotas() {
 	// Aplicar CORS em TODAS as rotas
@@ -65,22 +61,121 @@ func (s *ServidorHTTP) configurarRotas() {
 	
 	api := s.router.PathPrefix("/api/v1").Subrouter()
 	
+	// Banco embutido (SQLite): só ocorrências, definições e histórico
+	if s.embutido() {
+		s.configurarRotasEmbutido(api)
+		return
+	}
+	
 	// Rotas de ocorrências
 	api.HandleFunc("/ocorrencias/ativas", s.obterOcorrenciasAtivas).Methods("GET")
 	api.HandleFunc("/ocorrencias/historico", s.obterHistoricoOcorrencias).Methods("GET")
+	api.HandleFunc("/ocorrencias/exportar", s.exportarOcorrencias).Methods("GET")
+	api.HandleFunc("/ocorrencias/{id:[0-9]+}", s.obterDetalheOcorrencia).Methods("GET")
 	api.HandleFunc("/ocorrencias/{id}/resolver", s.resolverOcorrencia).Methods("POST")
+	api.HandleFunc("/ocorrencias/{id:[0-9]+}/reconhecer", s.reconhecerOcorrencia).Methods("POST")
+	api.HandleFunc("/ocorrencias/{id:[0-9]+}/ordem-trabalho", s.criarOrdemTrabalho).Methods("POST")
+	api.HandleFunc("/ocorrencias/{id:[0-9]+}/soe", s.obterSOEOcorrencia).Methods("GET")
+	
+	// Rotas do sequence-of-events (SOE)
+	api.HandleFunc("/soe", s.obterSOE).Methods("GET")
+	
+	// Rotas de séries temporais analógicas
+	api.HandleFunc("/series", s.obterTagsAnalogicas).Methods("GET")
+	api.HandleFunc("/series/tags", s.salvarTagAnalogica).Methods("POST")
+	api.HandleFunc("/series/{tag}", s.obterSerie).Methods("GET")
+	
+	// Rotas de análise de alarmes (avalanche, first-out e supressão)
+	api.HandleFunc("/alarmes/avalanches", s.obterAvalanchesAlarmes).Methods("GET")
+	api.HandleFunc("/alarmes/supressoes", s.obterRelacoesSupressao).Methods("GET")
+	api.HandleFunc("/alarmes/supressoes", s.criarRelacaoSupressao).Methods("POST")
+	api.HandleFunc("/alarmes/supressoes/{id}", s.removerRelacaoSupressao).Methods("DELETE")
 	
 	// Rotas de estatísticas
 	api.HandleFunc("/estatisticas/dashboard", s.obterEstatisticasDashboard).Methods("GET")
 	api.HandleFunc("/estatisticas/por-setor", s.obterEstatisticasPorSetor).Methods("GET")
+	api.HandleFunc("/estatisticas/confiabilidade", s.obterConfiabilidade).Methods("GET")
+	api.HandleFunc("/estatisticas/pareto", s.obterParetoParagens).Methods("GET")
 	
 	// Rotas de definições
 	api.HandleFunc("/definicoes/falhas", s.obterDefinicoesFalhas).Methods("GET")
+	api.HandleFunc("/definicoes/falhas/prioridades/importar", s.importarSeveridadeDefinicoes).Methods("POST")
+	api.HandleFunc("/definicoes/falhas/{id}/prioridade", s.atualizarSeveridadeDefinicao).Methods("PUT")
 	api.HandleFunc("/setores", s.obterSetores).Methods("GET")
 	api.HandleFunc("/eclusas", s.obterEclusas).Methods("GET")
 	
+	// Rotas do estado de processo das eclusas
+	api.HandleFunc("/eclusas/{codigo}/estado", s.obterEstadoEclusa).Methods("GET")
+	api.HandleFunc("/eclusas/{codigo}/estado/stream", s.transmitirEstadoEclusa).Methods("GET")
+	api.HandleFunc("/eclusas/{codigo}/estado/mapeamentos", s.obterMapeamentosEstado).Methods("GET")
+	api.HandleFunc("/eclusas/{codigo}/estado/mapeamentos", s.criarMapeamentoEstado).Methods("POST")
+	api.HandleFunc("/eclusas/{codigo}/estado/mapeamentos/{id}", s.removerMapeamentoEstado).Methods("DELETE")
+
+	// Rotas dos ciclos de eclusagem
+	api.HandleFunc("/eclusagens", s.obterEclusagens).Methods("GET")
+	api.HandleFunc("/eclusagens/kpis", s.obterKPIsEclusagens).Methods("GET")
+	api.HandleFunc("/eclusagens/{id:[0-9]+}", s.obterDetalheEclusagem).Methods("GET")
+
+	// Rotas dos relatórios de turno, diários e mensais
+	api.HandleFunc("/relatorios", s.obterRelatorios).Methods("GET")
+	api.HandleFunc("/relatorios", s.gerarRelatorio).Methods("POST")
+	api.HandleFunc("/relatorios/notas", s.obterNotasOperador).Methods("GET")
+	api.HandleFunc("/relatorios/notas", s.criarNotaOperador).Methods("POST")
+	api.HandleFunc("/relatorios/{id:[0-9]+}", s.baixarRelatorio).Methods("GET")
+
+	// Rotas das notificações (regras, destinos e registo de envios)
+	api.HandleFunc("/notificacoes/regras", s.obterRegrasNotificacao).Methods("GET")
+	api.HandleFunc("/notificacoes/regras", s.salvarRegraNotificacao).Methods("POST")
+	api.HandleFunc("/notificacoes/regras/{id:[0-9]+}", s.salvarRegraNotificacao).Methods("PUT")
+	api.HandleFunc("/notificacoes/regras/{id:[0-9]+}", s.removerRegraNotificacao).Methods("DELETE")
+	api.HandleFunc("/notificacoes/regras/{id:[0-9]+}/testar", s.testarRegraNotificacao).Methods("POST")
+	api.HandleFunc("/notificacoes/envios", s.obterEnviosNotificacao).Methods("GET")
+
+	// Rotas do plantão (equipas, rotações, substituições) e do escalonamento
+	api.HandleFunc("/plantao/equipas", s.obterEquipasPlantao).Methods("GET")
+	api.HandleFunc("/plantao/membros", s.salvarMembroPlantao).Methods("POST")
+	api.HandleFunc("/plantao/membros/{id:[0-9]+}", s.salvarMembroPlantao).Methods("PUT")
+	api.HandleFunc("/plantao/rotacoes", s.obterRotacoesPlantao).Methods("GET")
+	api.HandleFunc("/plantao/rotacoes", s.criarRotacaoPlantao).Methods("POST")
+	api.HandleFunc("/plantao/rotacoes/{id:[0-9]+}", s.removerRotacaoPlantao).Methods("DELETE")
+	api.HandleFunc("/plantao/substituicoes", s.obterSubstituicoesPlantao).Methods("GET")
+	api.HandleFunc("/plantao/substituicoes", s.criarSubstituicaoPlantao).Methods("POST")
+	api.HandleFunc("/plantao/substituicoes/{id:[0-9]+}", s.removerSubstituicaoPlantao).Methods("DELETE")
+	api.HandleFunc("/plantao/escala", s.obterEscalaPlantao).Methods("GET")
+	api.HandleFunc("/escalonamento/politicas", s.obterPoliticasEscalonamento).Methods("GET")
+	api.HandleFunc("/escalonamento/politicas", s.salvarPoliticaEscalonamento).Methods("POST")
+	api.HandleFunc("/escalonamento/politicas/{id:[0-9]+}", s.salvarPoliticaEscalonamento).Methods("PUT")
+	api.HandleFunc("/escalonamento/politicas/{id:[0-9]+}", s.removerPoliticaEscalonamento).Methods("DELETE")
+	api.HandleFunc("/escalonamento/ocorrencias", s.obterEscalonamentosOcorrencias).Methods("GET")
+
+	// Rotas das ordens de trabalho (manutenção e sincronização com o CMMS)
+	api.HandleFunc("/ordens-trabalho", s.obterOrdensTrabalho).Methods("GET")
+	api.HandleFunc("/ordens-trabalho", s.criarOrdemTrabalho).Methods("POST")
+	api.HandleFunc("/ordens-trabalho/regras", s.obterRegrasOrdemTrabalho).Methods("GET")
+	api.HandleFunc("/ordens-trabalho/regras", s.salvarRegraOrdemTrabalho).Methods("POST")
+	api.HandleFunc("/ordens-trabalho/regras/{id:[0-9]+}", s.salvarRegraOrdemTrabalho).Methods("PUT")
+	api.HandleFunc("/ordens-trabalho/regras/{id:[0-9]+}", s.removerRegraOrdemTrabalho).Methods("DELETE")
+	api.HandleFunc("/ordens-trabalho/{id:[0-9]+}", s.obterOrdemTrabalho).Methods("GET")
+	api.HandleFunc("/ordens-trabalho/{id:[0-9]+}", s.atualizarOrdemTrabalho).Methods("PUT")
+	api.HandleFunc("/ordens-trabalho/{id:[0-9]+}/sincronizar", s.sincronizarOrdemTrabalho).Methods("POST")
+	
+	// Rotas das janelas de manutenção planeada
+	api.HandleFunc("/manutencao/janelas", s.obterJanelasManutencao).Methods("GET")
+	api.HandleFunc("/manutencao/janelas", s.criarJanelaManutencao).Methods("POST")
+	api.HandleFunc("/manutencao/janelas/{id:[0-9]+}", s.obterJanelaManutencao).Methods("GET")
+	api.HandleFunc("/manutencao/janelas/{id:[0-9]+}/terminar", s.terminarJanelaManutencao).Methods("POST")
+	
+	// Rotas da frota (todas as eclusas)
+	api.HandleFunc("/frota/ocorrencias/ativas", s.obterAtivasFrota).Methods("GET")
+	api.HandleFunc("/frota/comparacao", s.obterComparacaoFrota).Methods("GET")
+	api.HandleFunc("/frota/visao-geral", s.obterVisaoGeralFrota).Methods("GET")
+	
+	// Rotas da sincronização com o central
+	s.configurarRotasSincronizacao(api)
+	
 	// Rota de saúde
 	api.HandleFunc("/health", s.verificarSaude).Methods("GET")
+	api.HandleFunc("/health/banco", s.verificarSaudeBanco).Methods("GET")
 }
 
 // middlewareCORS adiciona headers CORS
@@ -115,61 +210,45 @@ func (s *ServidorHTTP) obterOcorrenciasAtivas(w http.ResponseWriter, r *http.Req
 		return
 	}
 	
-	query := `
-		SELECT 
-			o.id, o.status, o.timestamp_inicio, o.timestamp_fim,
-			df.id as definicao_id, df.codigo, df.tipo, df.descricao, df.prioridade,
-			df.word_index, df.bit_index, df.classe_mensagem,
-			s.codigo as setor_codigo, s.nome as setor_nome,
-			e.codigo as eclusa_codigo, e.nome as eclusa_nome
-		FROM ocorrencias_falhas o
-		JOIN definicoes_falhas df ON o.definicao_id = df.id
-		JOIN setores s ON df.setor_id = s.id
-		JOIN eclusas e ON df.eclusa_id = e.id
-		WHERE o.status = 'ATIVO'
-		ORDER BY o.timestamp_inicio DESC`
-	
-	rows, err := s.bancoDados.Query(query)
+	// Alarmes consequentes ficam escondidos atrás da causa raiz enquanto ela estiver ativa
+	incluirSuprimidas := r.URL.Query().Get("incluir_suprimidas") == "true"
+	// Falhas esperadas durante uma janela de manutenção que as oculta
+	incluirManutencao := r.URL.Query().Get("incluir_manutencao") == "true"
+	
+	filtrosSeveridade, err := lerFiltrosSeveridade(r)
+	if err == nil {
+		err = filtrosSeveridade.validarOrdenacao()
+	}
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Erro ao buscar ocorrências: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer rows.Close()
 	
-	var ocorrencias []OcorrenciaCompleta
-	
-	for rows.Next() {
-		var oc OcorrenciaCompleta
-		var timestampFim sql.NullTime
-		
-		err := rows.Scan(
-			&oc.ID, &oc.Status, &oc.TimestampInicio, &timestampFim,
-			&oc.DefinicaoID, &oc.Codigo, &oc.Tipo, &oc.Descricao, &oc.Prioridade,
-			&oc.WordIndex, &oc.BitIndex, &oc.ClasseMensagem,
-			&oc.SetorCodigo, &oc.SetorNome,
-			&oc.EclusaCodigo, &oc.EclusaNome)
-		
-		if err != nil {
-			log.Printf("Erro ao ler linha: %v", err)
-			continue
-		}
-		
-		if timestampFim.Valid {
-			oc.TimestampFim = &timestampFim.Time
-		}
-		
-		ocorrencias = append(ocorrencias, oc)
+	ocorrencias, err := s.repositorios.Ocorrencias.ListarAtivas(repositorio.FiltroAtivas{
+		Eclusa:            strings.ToUpper(r.URL.Query().Get("eclusa")),
+		IncluirSuprimidas: incluirSuprimidas,
+		IncluirManutencao: incluirManutencao,
+		Prioridades:       filtrosSeveridade.Prioridades,
+		SLAViolado:        filtrosSeveridade.SLAViolado,
+		Ordenar:           filtrosSeveridade.Ordenar,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao buscar ocorrências: %v", err), http.StatusInternalServerError)
+		return
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    ocorrencias,
-		"total":   len(ocorrencias),
+		"success":            true,
+		"data":               ocorrencias,
+		"total":              len(ocorrencias),
+		"incluir_suprimidas": incluirSuprimidas,
+		"incluir_manutencao": incluirManutencao,
+		"filtros":            filtrosSeveridade,
 	})
 }
 
-// obterHistoricoOcorrencias retorna histórico com filtros
+// obterHistoricoOcorrencias retorna histórico com filtros, ordenação e paginação por cursor
 func (s *ServidorHTTP) obterHistoricoOcorrencias(w http.ResponseWriter, r *http.Request) {
 	// CORS direto no handler
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -182,114 +261,61 @@ func (s *ServidorHTTP) obterHistoricoOcorrencias(w http.ResponseWriter, r *http.
 	}
 	
 	// Parâmetros de filtro
-	limite := r.URL.Query().Get("limite")
-	if limite == "" {
-		limite = "100"
+	filtros, err := lerFiltrosHistorico(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 	
-	setor := r.URL.Query().Get("setor")
-	tipo := r.URL.Query().Get("tipo")
-	status := r.URL.Query().Get("status")
-	
-	// Construir query com filtros
-	baseQuery := `
-		SELECT 
-			o.id, o.status, o.timestamp_inicio, o.timestamp_fim,
-			df.id as definicao_id, df.codigo, df.tipo, df.descricao, df.prioridade,
-			df.word_index, df.bit_index, df.classe_mensagem,
-			s.codigo as setor_codigo, s.nome as setor_nome,
-			e.codigo as eclusa_codigo, e.nome as eclusa_nome,
-			CASE 
-				WHEN o.timestamp_fim IS NOT NULL THEN 
-					EXTRACT(EPOCH FROM (o.timestamp_fim - o.timestamp_inicio))
-				ELSE 
-					EXTRACT(EPOCH FROM (NOW() - o.timestamp_inicio))
-			END as duracao_segundos
-		FROM ocorrencias_falhas o
-		JOIN definicoes_falhas df ON o.definicao_id = df.id
-		JOIN setores s ON df.setor_id = s.id
-		JOIN eclusas e ON df.eclusa_id = e.id
-		WHERE 1=1`
-	
-	args := []interface{}{}
-	argIndex := 1
-	
-	if setor != "" {
-		baseQuery += fmt.Sprintf(" AND s.codigo = $%d", argIndex)
-		args = append(args, setor)
-		argIndex++
+	// O SQLite não guarda a chave de ordenação com precisão suficiente para as outras ordenações
+	if s.embutido() && filtros.Ordenar != "inicio" {
+		http.Error(w, fmt.Sprintf("ordenação %s indisponível no modo embutido (use inicio)", filtros.Ordenar), http.StatusBadRequest)
+		return
 	}
 	
-	if tipo != "" {
-		baseQuery += fmt.Sprintf(" AND df.tipo = $%d", argIndex)
-		args = append(args, tipo)
-		argIndex++
+	filtro, err := filtros.filtroRepositorio()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 	
-	if status != "" {
-		baseQuery += fmt.Sprintf(" AND o.status = $%d", argIndex)
-		args = append(args, status)
-		argIndex++
+	// Total de registros que atendem aos filtros (ignorando o cursor)
+	total := -1
+	if r.URL.Query().Get("contar") != "false" {
+		if total, err = s.repositorios.Ocorrencias.ContarHistorico(filtro); err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao contar histórico: %v", err), http.StatusInternalServerError)
+			return
+		}
 	}
 	
-	baseQuery += fmt.Sprintf(" ORDER BY o.timestamp_inicio DESC LIMIT $%d", argIndex)
-	limiteInt, _ := strconv.Atoi(limite)
-	args = append(args, limiteInt)
-	
-	rows, err := s.bancoDados.Query(baseQuery, args...)
+	pagina, err := s.repositorios.Ocorrencias.ListarHistorico(filtro)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Erro ao buscar histórico: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 	
-	var ocorrencias []OcorrenciaCompleta
-	
-	for rows.Next() {
-		var oc OcorrenciaCompleta
-		var timestampFim sql.NullTime
-		var duracaoSegundos sql.NullFloat64
-		
-		err := rows.Scan(
-			&oc.ID, &oc.Status, &oc.TimestampInicio, &timestampFim,
-			&oc.DefinicaoID, &oc.Codigo, &oc.Tipo, &oc.Descricao, &oc.Prioridade,
-			&oc.WordIndex, &oc.BitIndex, &oc.ClasseMensagem,
-			&oc.SetorCodigo, &oc.SetorNome,
-			&oc.EclusaCodigo, &oc.EclusaNome,
-			&duracaoSegundos)
-		
-		if err != nil {
-			log.Printf("Erro ao ler linha: %v", err)
-			continue
-		}
-		
-		if timestampFim.Valid {
-			oc.TimestampFim = &timestampFim.Time
-		}
-		
-		if duracaoSegundos.Valid {
-			duracao := int64(duracaoSegundos.Float64)
-			oc.DuracaoSegundos = &duracao
-		}
-		
-		ocorrencias = append(ocorrencias, oc)
+	resposta := map[string]interface{}{
+		"success":    true,
+		"data":       pagina.Ocorrencias,
+		"quantidade": len(pagina.Ocorrencias),
+		"tem_mais":   pagina.Proxima != nil,
+		"filtros":    filtros.parametros(r),
+	}
+	if total >= 0 {
+		resposta["total"] = total
+	}
+	if pagina.Proxima != nil {
+		resposta["proximo_cursor"] = codificarCursor(filtros.cursorDaPosicao(*pagina.Proxima))
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    ocorrencias,
-		"total":   len(ocorrencias),
-		"filtros": map[string]string{
-			"setor":  setor,
-			"tipo":   tipo,
-			"status": status,
-			"limite": limite,
-		},
-	})
+	json.NewEncoder(w).Encode(resposta)
 }
 
-// verificarSaude verifica se a API está funcionando
+// timeoutSaudeBanco limita o teste ao banco nas verificações de saúde
+const timeoutSaudeBanco = 2 * time.Second
+
+// verificarSaude verifica se a API e o banco de dados estão funcionando
 func (s *ServidorHTTP) verificarSaude(w http.ResponseWriter, r *http.Request) {
 	// CORS direto no handler
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -301,17 +327,56 @@ func (s *ServidorHTTP) verificarSaude(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// Sem banco a API não consegue servir ocorrências: responder 503 para o balanceador/monitorização
+	banco := database.VerificarSaude(s.bancoDados, timeoutSaudeBanco)
+	status, codigo := "OK", http.StatusOK
+	if !banco.Disponivel {
+		status, codigo = "ERRO", http.StatusServiceUnavailable
+	}
+	
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(codigo)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":    "OK",
+		"status":    status,
 		"timestamp": time.Now(),
 		"servico":   "API Falhas EDP",
+		"banco":     banco,
+	})
+}
+
+// verificarSaudeBanco devolve o estado do banco e as estatísticas do pool de ligações
+func (s *ServidorHTTP) verificarSaudeBanco(w http.ResponseWriter, r *http.Request) {
+	// CORS direto no handler
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	
+	banco := database.VerificarSaude(s.bancoDados, timeoutSaudeBanco)
+	codigo := http.StatusOK
+	if !banco.Disponivel {
+		codigo = http.StatusServiceUnavailable
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(codigo)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": banco.Disponivel,
+		"data":    banco,
 	})
 }
 
 // Iniciar inicia o servidor HTTP
 func (s *ServidorHTTP) Iniciar(porta string) error {
 	log.Printf("🌐 Servidor HTTP iniciado na porta %s", porta)
+	if s.configuracoes != nil && s.configuracoes.ServidorHTTP_CertificadoTLS != "" && s.configuracoes.ServidorHTTP_ChaveTLS != "" {
+		log.Printf("📖 Documentação da API disponível em: https://localhost%s/api/v1/health", porta)
+		return http.ListenAndServeTLS(porta, s.configuracoes.ServidorHTTP_CertificadoTLS, s.configuracoes.ServidorHTTP_ChaveTLS, s.router)
+	}
 	log.Printf("📖 Documentação da API disponível em: http://localhost%s/api/v1/health", porta)
 	return http.ListenAndServe(porta, s.router)
 }
\ No newline at end of file
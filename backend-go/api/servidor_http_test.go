@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/modelos"
+	"github.com/edp/falhas-backend/repositorio"
+)
+
+// servidorTeste monta o servidor HTTP sobre um repositório em memória com duas eclusas:
+// RG com 11 ocorrências (3 ativas) e PB com 1 ocorrência ativa
+func servidorTeste(t *testing.T) *ServidorHTTP {
+	t.Helper()
+	memoria := repositorio.NovaMemoria()
+	rg := memoria.AdicionarEclusa(repositorio.Eclusa{Codigo: "RG", Nome: "Régua", Ativa: true})
+	pb := memoria.AdicionarEclusa(repositorio.Eclusa{Codigo: "PB", Nome: "Pocinho", Ativa: true})
+	setor := memoria.AdicionarSetor(repositorio.Setor{Codigo: "ENCHIMENTO", Nome: "Enchimento"})
+
+	definicao := func(eclusa int, codigo, prioridade string, criticidade, sla int) int {
+		return memoria.AdicionarDefinicao(modelos.DefinicaoFalha{
+			EclusaID: eclusa, SetorID: setor, Codigo: codigo, Tipo: "FALHA",
+			Descricao: "falha de teste", Prioridade: prioridade, Ativa: true,
+			Criticidade: criticidade, TempoRespostaMinutos: sla,
+		})
+	}
+	definicoes := []int{
+		definicao(rg, "RG_001", "ALTA", 5, 10),
+		definicao(rg, "RG_002", "MEDIA", 3, 0),
+		definicao(rg, "RG_003", "BAIXA", 1, 60),
+	}
+	outra := definicao(pb, "PB_001", "ALTA", 4, 0)
+
+	ocorrencias := memoria.Repositorios().Ocorrencias
+	base := time.Now().Add(-6 * time.Hour).Truncate(time.Second)
+	abrir := func(definicaoID int, inicio time.Time) {
+		if _, err := ocorrencias.Abrir(repositorio.NovaOcorrencia{DefinicaoID: definicaoID, Inicio: inicio}); err != nil {
+			t.Fatalf("Abrir: %v", err)
+		}
+	}
+	// Oito ocorrências resolvidas com durações diferentes; algumas partilham o instante de início
+	for i := 0; i < 8; i++ {
+		definicaoID := definicoes[i%len(definicoes)]
+		abrir(definicaoID, base.Add(time.Duration(i/2)*time.Minute))
+		if _, err := ocorrencias.ResolverAtivas(definicaoID, base.Add(time.Duration(i+1)*7*time.Minute)); err != nil {
+			t.Fatalf("ResolverAtivas: %v", err)
+		}
+	}
+	for _, definicaoID := range definicoes {
+		abrir(definicaoID, base.Add(time.Hour))
+	}
+	abrir(outra, base.Add(time.Hour))
+
+	s := NovoServidorHTTP(nil, &config.Configuracoes{DB_Tipo: config.BancoPostgres})
+	s.DefinirRepositorios(memoria.Repositorios())
+	return s
+}
+
+// pedir executa um GET no router e descodifica a resposta JSON
+func pedir(t *testing.T, s *ServidorHTTP, caminho string, resposta interface{}) {
+	t.Helper()
+	gravador := httptest.NewRecorder()
+	s.router.ServeHTTP(gravador, httptest.NewRequest(http.MethodGet, caminho, nil))
+	if gravador.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d: %s", caminho, gravador.Code, gravador.Body.String())
+	}
+	if err := json.NewDecoder(gravador.Body).Decode(resposta); err != nil {
+		t.Fatalf("GET %s: resposta inválida: %v", caminho, err)
+	}
+}
+
+func TestHistoricoPaginaPorCursorEmTodasAsOrdenacoes(t *testing.T) {
+	s := servidorTeste(t)
+
+	for _, ordenar := range repositorio.OrdenacoesHistorico {
+		for _, ordem := range []string{"asc", "desc"} {
+			t.Run(ordenar+"_"+ordem, func(t *testing.T) {
+				vistos := make(map[int64]bool)
+				total := -1
+				cursor := ""
+				for pagina := 0; ; pagina++ {
+					if pagina > 10 {
+						t.Fatal("a paginação não terminou")
+					}
+					parametros := url.Values{"eclusa": {"rg"}, "ordenar": {ordenar}, "ordem": {ordem}, "limite": {"4"}}
+					if cursor != "" {
+						parametros.Set("cursor", cursor)
+					}
+					var resposta struct {
+						Data []struct {
+							ID int64 `json:"id"`
+						} `json:"data"`
+						Total         *int   `json:"total"`
+						TemMais       bool   `json:"tem_mais"`
+						ProximoCursor string `json:"proximo_cursor"`
+					}
+					pedir(t, s, "/api/v1/ocorrencias/historico?"+parametros.Encode(), &resposta)
+					if resposta.Total != nil {
+						total = *resposta.Total
+					}
+					for _, ocorrencia := range resposta.Data {
+						if vistos[ocorrencia.ID] {
+							t.Fatalf("ocorrência %d repetida na página %d", ocorrencia.ID, pagina)
+						}
+						vistos[ocorrencia.ID] = true
+					}
+					if !resposta.TemMais {
+						break
+					}
+					cursor = resposta.ProximoCursor
+				}
+				if total != 11 || len(vistos) != total {
+					t.Fatalf("total = %d, ocorrências percorridas = %d; esperado 11", total, len(vistos))
+				}
+			})
+		}
+	}
+}
+
+func TestHistoricoRejeitaCursorInvalido(t *testing.T) {
+	s := servidorTeste(t)
+	gravador := httptest.NewRecorder()
+	s.router.ServeHTTP(gravador, httptest.NewRequest(http.MethodGet, "/api/v1/ocorrencias/historico?cursor=invalido", nil))
+	if gravador.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d; esperado %d", gravador.Code, http.StatusBadRequest)
+	}
+}
+
+func TestDashboardContaOcorrenciasPorEclusa(t *testing.T) {
+	s := servidorTeste(t)
+
+	casos := []struct {
+		eclusa       string
+		ativas       int
+		total        int
+		ativasAltas  int
+		falhas24h    int
+		tempoNaoNulo bool
+	}{
+		{eclusa: "", ativas: 4, total: 12, ativasAltas: 2, falhas24h: 12, tempoNaoNulo: true},
+		{eclusa: "rg", ativas: 3, total: 11, ativasAltas: 1, falhas24h: 11, tempoNaoNulo: true},
+		{eclusa: "pb", ativas: 1, total: 1, ativasAltas: 1, falhas24h: 1},
+	}
+	for _, caso := range casos {
+		t.Run(fmt.Sprintf("eclusa=%q", caso.eclusa), func(t *testing.T) {
+			var resposta struct {
+				Success bool                              `json:"success"`
+				Data    repositorio.EstatisticasDashboard `json:"data"`
+			}
+			pedir(t, s, "/api/v1/estatisticas/dashboard?eclusa="+caso.eclusa, &resposta)
+			stats := resposta.Data
+			if !resposta.Success || stats.OcorrenciasAtivas != caso.ativas || stats.TotalOcorrencias != caso.total {
+				t.Fatalf("ativas = %d, total = %d; esperado %d e %d", stats.OcorrenciasAtivas, stats.TotalOcorrencias, caso.ativas, caso.total)
+			}
+			if stats.PorPrioridade["ALTA"] != caso.ativasAltas || stats.PorSetor["Enchimento"] != caso.ativas {
+				t.Fatalf("por prioridade = %v, por setor = %v", stats.PorPrioridade, stats.PorSetor)
+			}
+			if stats.FalhasUltimas24h != caso.falhas24h || (stats.TempoMedioResolucao > 0) != caso.tempoNaoNulo {
+				t.Fatalf("falhas 24h = %d, tempo médio = %v", stats.FalhasUltimas24h, stats.TempoMedioResolucao)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Package backup cria e restaura cópias de conjuntos de dados (definições, equipas, regras, notas e
+// histórico) num único arquivo tar.gz: um manifesto com a versão do esquema e o sha256 de cada
+// ficheiro, seguido de um ficheiro JSON (uma linha por registo) ou CSV por tabela.
+package backup
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// formatoBackup identifica o manifesto; versaoFormato muda quando a estrutura do arquivo muda
+	formatoBackup = "falhas-backend-backup"
+	versaoFormato = 1
+
+	// nomeManifesto é sempre o primeiro ficheiro do arquivo
+	nomeManifesto = "manifesto.json"
+
+	// FormatoJSON e FormatoCSV são os formatos dos ficheiros das tabelas
+	FormatoJSON = "json"
+	FormatoCSV  = "csv"
+
+	// nuloCSV representa NULL nos ficheiros CSV (como no COPY do PostgreSQL)
+	nuloCSV = `\N`
+
+	// linhasPorInsercao agrupa os registos restaurados numa única instrução
+	linhasPorInsercao = 500
+)
+
+// Conjunto é um grupo de tabelas copiado e restaurado em conjunto
+type Conjunto struct {
+	Nome      string
+	Descricao string
+	Tabelas   []string // Pela ordem de restauro (as referenciadas primeiro)
+}
+
+// Conjuntos são os conjuntos de dados disponíveis, pela ordem de restauro: cada um só depende dos
+// anteriores. Os registos do SOE, as séries e os estados de envio não entram (são append-only ou
+// reconstruídos pelo próprio sistema).
+var Conjuntos = []Conjunto{
+	{
+		Nome:      "definicoes",
+		Descricao: "eclusas, setores, definições de falhas e eventos, supressões, tags analógicas e mapeamentos de estado",
+		Tabelas: []string{"eclusas", "setores", "definicoes_falhas", "relacoes_supressao",
+			"tags_analogicas", "mapeamentos_estado_eclusa"},
+	},
+	{
+		Nome:      "equipas",
+		Descricao: "equipas, membros (utilizadores notificados), rotações e substituições de plantão",
+		Tabelas:   []string{"equipas", "membros_equipa", "rotacoes_plantao", "substituicoes_plantao"},
+	},
+	{
+		Nome:      "regras",
+		Descricao: "regras e destinos de notificação, políticas de escalonamento, regras de ordens de trabalho e janelas de manutenção",
+		Tabelas: []string{"regras_notificacao", "destinos_notificacao", "politicas_escalonamento",
+			"niveis_escalonamento", "regras_ordem_trabalho", "janelas_manutencao"},
+	},
+	{
+		Nome:      "notas",
+		Descricao: "notas dos operadores (base de conhecimento das eclusas)",
+		Tabelas:   []string{"notas_operador"},
+	},
+	{
+		Nome:      "historico",
+		Descricao: "ocorrências, transições, avalanches, eclusagens e ordens de trabalho",
+		Tabelas: []string{"avalanches_alarmes", "eclusagens", "ocorrencias_falhas", "transicoes_ocorrencias",
+			"ordens_trabalho", "pecas_ordem_trabalho", "ordens_trabalho_ocorrencias"},
+	},
+}
+
+// Manifesto descreve o arquivo: versões, conjuntos e, por tabela, o ficheiro e o seu sha256
+type Manifesto struct {
+	Formato       string           `json:"formato"`
+	Versao        int              `json:"versao"`
+	VersaoEsquema int              `json:"versao_esquema"`
+	CriadoEm      time.Time        `json:"criado_em"`
+	FormatoDados  string           `json:"formato_dados"`
+	Conjuntos     []string         `json:"conjuntos"`
+	Ficheiros     []FicheiroTabela `json:"ficheiros"`
+}
+
+// FicheiroTabela é o ficheiro de uma tabela dentro do arquivo
+type FicheiroTabela struct {
+	Conjunto     string   `json:"conjunto"`
+	Tabela       string   `json:"tabela"`
+	Ficheiro     string   `json:"ficheiro"`
+	Colunas      []string `json:"colunas"`
+	Registos     int      `json:"registos"`
+	TamanhoBytes int64    `json:"tamanho_bytes"`
+	SHA256       string   `json:"sha256"`
+}
+
+// SelecionarConjuntos devolve os conjuntos pedidos (separados por vírgula) pela ordem de restauro;
+// vazio seleciona todos
+func SelecionarConjuntos(lista string) ([]Conjunto, error) {
+	if strings.TrimSpace(lista) == "" {
+		return Conjuntos, nil
+	}
+
+	pedidos := make(map[string]bool)
+	for _, nome := range strings.Split(lista, ",") {
+		nome = strings.TrimSpace(nome)
+		if _, existe := conjuntoPorNome(nome); !existe {
+			return nil, fmt.Errorf("conjunto desconhecido: %s (disponíveis: %s)", nome, nomesConjuntos())
+		}
+		pedidos[nome] = true
+	}
+
+	var selecionados []Conjunto
+	for _, c := range Conjuntos {
+		if pedidos[c.Nome] {
+			selecionados = append(selecionados, c)
+		}
+	}
+	return selecionados, nil
+}
+
+func conjuntoPorNome(nome string) (Conjunto, bool) {
+	for _, c := range Conjuntos {
+		if c.Nome == nome {
+			return c, true
+		}
+	}
+	return Conjunto{}, false
+}
+
+func nomesConjuntos() string {
+	nomes := make([]string, len(Conjuntos))
+	for i, c := range Conjuntos {
+		nomes[i] = c.Nome
+	}
+	return strings.Join(nomes, ", ")
+}
+
+// consultor é um *sql.DB ou *sql.Tx
+type consultor interface {
+	Query(consulta string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(consulta string, args ...interface{}) *sql.Row
+}
+
+// coluna é uma coluna de uma tabela com o seu tipo SQL (ex.: "timestamp without time zone")
+type coluna struct {
+	Nome string
+	Tipo string
+}
+
+// existeTabela indica se a tabela existe (as mais recentes podem faltar em esquemas antigos)
+func existeTabela(q consultor, tabela string) (bool, error) {
+	var existe bool
+	if err := q.QueryRow("SELECT to_regclass($1) IS NOT NULL", tabela).Scan(&existe); err != nil {
+		return false, fmt.Errorf("erro ao verificar tabela %s: %v", tabela, err)
+	}
+	return existe, nil
+}
+
+// colunasTabela devolve as colunas da tabela pela ordem em que foram criadas
+func colunasTabela(q consultor, tabela string) ([]coluna, error) {
+	rows, err := q.Query(`
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod)
+		FROM pg_attribute a
+		WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, tabela)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler colunas de %s: %v", tabela, err)
+	}
+	defer rows.Close()
+
+	var colunas []coluna
+	for rows.Next() {
+		var c coluna
+		if err := rows.Scan(&c.Nome, &c.Tipo); err != nil {
+			return nil, err
+		}
+		colunas = append(colunas, c)
+	}
+	return colunas, rows.Err()
+}
+
+// chavePrimaria devolve as colunas da chave primária da tabela
+func chavePrimaria(q consultor, tabela string) ([]string, error) {
+	rows, err := q.Query(`
+		SELECT a.attname
+		FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1::regclass AND i.indisprimary
+		ORDER BY array_position(i.indkey::int2[], a.attnum)`, tabela)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler chave primária de %s: %v", tabela, err)
+	}
+	defer rows.Close()
+
+	var chave []string
+	for rows.Next() {
+		var nome string
+		if err := rows.Scan(&nome); err != nil {
+			return nil, err
+		}
+		chave = append(chave, nome)
+	}
+	if len(chave) == 0 && rows.Err() == nil {
+		return nil, fmt.Errorf("tabela %s sem chave primária", tabela)
+	}
+	return chave, rows.Err()
+}
+
+// listaIdentificadores junta os nomes entre aspas, com o prefixo indicado (ex.: "r.")
+func listaIdentificadores(nomes []string, prefixo string) string {
+	partes := make([]string, len(nomes))
+	for i, nome := range nomes {
+		partes[i] = prefixo + pq.QuoteIdentifier(nome)
+	}
+	return strings.Join(partes, ", ")
+}
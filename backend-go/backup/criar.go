@@ -0,0 +1,280 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/edp/falhas-backend/database"
+)
+
+// Criar grava num arquivo tar.gz as tabelas dos conjuntos indicados, lidas numa única transação
+// (uma fotografia coerente do banco). Ao lado fica <arquivo>.sha256 com o sha256 do arquivo,
+// no formato do sha256sum. Devolve o manifesto e o sha256.
+func Criar(db *sql.DB, caminho string, conjuntos []Conjunto, formato string, agora time.Time) (*Manifesto, string, error) {
+	if formato != FormatoJSON && formato != FormatoCSV {
+		return nil, "", fmt.Errorf("formato inválido: %s (use %s ou %s)", formato, FormatoJSON, FormatoCSV)
+	}
+	versaoEsquema, err := database.VersaoEsquema(db)
+	if err != nil {
+		return nil, "", err
+	}
+
+	temporario, err := os.MkdirTemp("", "falhas-backup-")
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao criar diretório temporário: %v", err)
+	}
+	defer os.RemoveAll(temporario)
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	manifesto := &Manifesto{
+		Formato: formatoBackup, Versao: versaoFormato, VersaoEsquema: versaoEsquema,
+		CriadoEm: agora, FormatoDados: formato, Conjuntos: []string{}, Ficheiros: []FicheiroTabela{},
+	}
+	for _, conjunto := range conjuntos {
+		manifesto.Conjuntos = append(manifesto.Conjuntos, conjunto.Nome)
+		for _, tabela := range conjunto.Tabelas {
+			existe, err := existeTabela(tx, tabela)
+			if err != nil {
+				return nil, "", err
+			}
+			if !existe {
+				continue
+			}
+
+			ficheiro, err := exportarTabela(tx, temporario, conjunto.Nome, tabela, formato)
+			if err != nil {
+				return nil, "", err
+			}
+			manifesto.Ficheiros = append(manifesto.Ficheiros, *ficheiro)
+		}
+	}
+	tx.Rollback()
+
+	soma, err := escreverArquivo(caminho, temporario, manifesto)
+	if err != nil {
+		return nil, "", err
+	}
+	conteudo := fmt.Sprintf("%s  %s\n", soma, filepath.Base(caminho))
+	if err := os.WriteFile(caminho+".sha256", []byte(conteudo), 0o644); err != nil {
+		return nil, "", fmt.Errorf("erro ao gravar sha256 do arquivo: %v", err)
+	}
+	return manifesto, soma, nil
+}
+
+// exportarTabela grava a tabela em <temporario>/<conjunto>/<tabela>.<formato>
+func exportarTabela(tx *sql.Tx, temporario, conjunto, tabela, formato string) (*FicheiroTabela, error) {
+	colunas, err := colunasTabela(tx, tabela)
+	if err != nil {
+		return nil, err
+	}
+	chave, err := chavePrimaria(tx, tabela)
+	if err != nil {
+		return nil, err
+	}
+	ficheiro := &FicheiroTabela{
+		Conjunto: conjunto, Tabela: tabela, Ficheiro: path.Join(conjunto, tabela+"."+formato),
+	}
+	for _, c := range colunas {
+		ficheiro.Colunas = append(ficheiro.Colunas, c.Nome)
+	}
+
+	if err := os.MkdirAll(filepath.Join(temporario, conjunto), 0o755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório temporário: %v", err)
+	}
+	f, err := os.Create(filepath.Join(temporario, filepath.FromSlash(ficheiro.Ficheiro)))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar ficheiro de %s: %v", tabela, err)
+	}
+	defer f.Close()
+
+	soma := sha256.New()
+	tamanho := &contadorBytes{}
+	saida := bufio.NewWriter(io.MultiWriter(f, soma, tamanho))
+
+	ordem := " ORDER BY " + listaIdentificadores(chave, "t.")
+	if formato == FormatoJSON {
+		ficheiro.Registos, err = exportarJSON(tx, saida, "SELECT row_to_json(t)::text FROM "+tabela+" t"+ordem)
+	} else {
+		textos := make([]string, len(colunas))
+		for i, c := range colunas {
+			textos[i] = "t." + pq.QuoteIdentifier(c.Nome) + "::text"
+		}
+		ficheiro.Registos, err = exportarCSV(tx, saida, ficheiro.Colunas,
+			"SELECT "+strings.Join(textos, ", ")+" FROM "+tabela+" t"+ordem)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao exportar %s: %v", tabela, err)
+	}
+	if err := saida.Flush(); err != nil {
+		return nil, fmt.Errorf("erro ao escrever ficheiro de %s: %v", tabela, err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("erro ao fechar ficheiro de %s: %v", tabela, err)
+	}
+
+	ficheiro.SHA256 = hex.EncodeToString(soma.Sum(nil))
+	ficheiro.TamanhoBytes = tamanho.total
+	return ficheiro, nil
+}
+
+// exportarJSON escreve uma linha JSON por registo
+func exportarJSON(tx *sql.Tx, saida io.Writer, consulta string) (int, error) {
+	rows, err := tx.Query(consulta)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	registos := 0
+	for rows.Next() {
+		var linha string
+		if err := rows.Scan(&linha); err != nil {
+			return registos, err
+		}
+		if _, err := io.WriteString(saida, linha+"\n"); err != nil {
+			return registos, err
+		}
+		registos++
+	}
+	return registos, rows.Err()
+}
+
+// exportarCSV escreve o cabeçalho com as colunas e uma linha por registo, com os valores em texto
+func exportarCSV(tx *sql.Tx, saida io.Writer, colunas []string, consulta string) (int, error) {
+	rows, err := tx.Query(consulta)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	escritor := csv.NewWriter(saida)
+	if err := escritor.Write(colunas); err != nil {
+		return 0, err
+	}
+
+	valores := make([]sql.NullString, len(colunas))
+	destinos := make([]interface{}, len(colunas))
+	for i := range valores {
+		destinos[i] = &valores[i]
+	}
+	linha := make([]string, len(colunas))
+
+	registos := 0
+	for rows.Next() {
+		if err := rows.Scan(destinos...); err != nil {
+			return registos, err
+		}
+		for i, v := range valores {
+			linha[i] = nuloCSV
+			if v.Valid {
+				linha[i] = v.String
+			}
+		}
+		if err := escritor.Write(linha); err != nil {
+			return registos, err
+		}
+		registos++
+	}
+	if err := rows.Err(); err != nil {
+		return registos, err
+	}
+	escritor.Flush()
+	return registos, escritor.Error()
+}
+
+// escreverArquivo junta o manifesto e os ficheiros das tabelas no tar.gz (primeiro como .parcial)
+// e devolve o sha256 do arquivo
+func escreverArquivo(caminho, temporario string, manifesto *Manifesto) (string, error) {
+	conteudoManifesto, err := json.MarshalIndent(manifesto, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if diretorio := filepath.Dir(caminho); diretorio != "" {
+		if err := os.MkdirAll(diretorio, 0o755); err != nil {
+			return "", fmt.Errorf("erro ao criar diretório do backup: %v", err)
+		}
+	}
+	f, err := os.Create(caminho + ".parcial")
+	if err != nil {
+		return "", fmt.Errorf("erro ao criar arquivo: %v", err)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(caminho + ".parcial")
+	}()
+
+	soma := sha256.New()
+	compressor := gzip.NewWriter(io.MultiWriter(f, soma))
+	arquivo := tar.NewWriter(compressor)
+
+	cabecalho := &tar.Header{Name: nomeManifesto, Mode: 0o644, Size: int64(len(conteudoManifesto)), ModTime: manifesto.CriadoEm}
+	if err := arquivo.WriteHeader(cabecalho); err != nil {
+		return "", fmt.Errorf("erro ao escrever arquivo: %v", err)
+	}
+	if _, err := arquivo.Write(conteudoManifesto); err != nil {
+		return "", fmt.Errorf("erro ao escrever arquivo: %v", err)
+	}
+
+	for _, ficheiro := range manifesto.Ficheiros {
+		origem, err := os.Open(filepath.Join(temporario, filepath.FromSlash(ficheiro.Ficheiro)))
+		if err != nil {
+			return "", fmt.Errorf("erro ao abrir ficheiro de %s: %v", ficheiro.Tabela, err)
+		}
+		cabecalho := &tar.Header{Name: ficheiro.Ficheiro, Mode: 0o644, Size: ficheiro.TamanhoBytes, ModTime: manifesto.CriadoEm}
+		if err := arquivo.WriteHeader(cabecalho); err != nil {
+			origem.Close()
+			return "", fmt.Errorf("erro ao escrever arquivo: %v", err)
+		}
+		_, err = io.Copy(arquivo, origem)
+		origem.Close()
+		if err != nil {
+			return "", fmt.Errorf("erro ao escrever %s no arquivo: %v", ficheiro.Ficheiro, err)
+		}
+	}
+
+	if err := arquivo.Close(); err != nil {
+		return "", fmt.Errorf("erro ao escrever arquivo: %v", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return "", fmt.Errorf("erro ao escrever arquivo: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		return "", fmt.Errorf("erro ao gravar arquivo no disco: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("erro ao fechar arquivo: %v", err)
+	}
+	if err := os.Rename(caminho+".parcial", caminho); err != nil {
+		return "", fmt.Errorf("erro ao gravar arquivo: %v", err)
+	}
+	return hex.EncodeToString(soma.Sum(nil)), nil
+}
+
+type contadorBytes struct{ total int64 }
+
+func (c *contadorBytes) Write(p []byte) (int, error) {
+	c.total += int64(len(p))
+	return len(p), nil
+}
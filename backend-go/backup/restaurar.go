@@ -0,0 +1,462 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/edp/falhas-backend/database"
+	"github.com/edp/falhas-backend/retencao"
+)
+
+// tamanhoMaximoManifesto limita a leitura do manifesto (um arquivo estranho não enche a memória)
+const tamanhoMaximoManifesto = 10 << 20
+
+// OpcoesRestauro escolhe o que restaurar de um arquivo
+type OpcoesRestauro struct {
+	Conjuntos []Conjunto // Vazio = todos os conjuntos do arquivo
+	Ensaio    bool       // Verifica e aplica numa transação que é desfeita no fim
+}
+
+// ResultadoRestauro descreve o que foi restaurado
+type ResultadoRestauro struct {
+	Manifesto     *Manifesto       `json:"manifesto"`
+	VersaoEsquema int              `json:"versao_esquema"` // Versão do banco restaurado
+	Restaurados   []FicheiroTabela `json:"restaurados"`
+	Ensaio        bool             `json:"ensaio"`
+}
+
+// Verificar lê o manifesto de um arquivo e confere o sha256 do arquivo (se houver <arquivo>.sha256)
+// e o de cada ficheiro, sem tocar no banco
+func Verificar(caminho string) (*Manifesto, error) {
+	var manifesto *Manifesto
+	err := lerArquivo(caminho, func(m *Manifesto) error {
+		manifesto = m
+		return nil
+	}, func(FicheiroTabela, io.Reader) (int, error) {
+		return -1, nil
+	})
+	return manifesto, err
+}
+
+// Restaurar insere ou atualiza, pela chave primária, os registos dos conjuntos escolhidos, numa
+// única transação. Os registos que já existem ficam com os valores do arquivo; os criados depois do
+// backup não são apagados. O arquivo tem de ter sido criado com a versão do esquema do banco ou
+// uma anterior, e as suas colunas têm de existir no banco.
+func Restaurar(db *sql.DB, caminho string, opcoes OpcoesRestauro) (*ResultadoRestauro, error) {
+	versaoEsquema, err := database.VersaoEsquema(db)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	resultado := &ResultadoRestauro{VersaoEsquema: versaoEsquema, Restaurados: []FicheiroTabela{}, Ensaio: opcoes.Ensaio}
+	selecionados := make(map[string]bool)
+	var formato string
+
+	err = lerArquivo(caminho, func(m *Manifesto) error {
+		if m.VersaoEsquema > versaoEsquema {
+			return fmt.Errorf("arquivo criado com a versão %d do esquema, o banco está na %d: aplique 'migrate up' antes de restaurar",
+				m.VersaoEsquema, versaoEsquema)
+		}
+		if len(opcoes.Conjuntos) == 0 {
+			for _, nome := range m.Conjuntos {
+				selecionados[nome] = true
+			}
+		}
+		for _, c := range opcoes.Conjuntos {
+			if !contem(m.Conjuntos, c.Nome) {
+				return fmt.Errorf("o conjunto %s não está no arquivo (contém: %s)", c.Nome, strings.Join(m.Conjuntos, ", "))
+			}
+			selecionados[c.Nome] = true
+		}
+		resultado.Manifesto, formato = m, m.FormatoDados
+		return nil
+	}, func(ficheiro FicheiroTabela, leitor io.Reader) (int, error) {
+		if !selecionados[ficheiro.Conjunto] {
+			return -1, nil
+		}
+		registos, err := restaurarTabela(tx, leitor, ficheiro, formato)
+		if err != nil {
+			return registos, err
+		}
+		resultado.Restaurados = append(resultado.Restaurados, ficheiro)
+		return registos, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// As referências a ocorrências não têm chave estrangeira: o arquivo não pode deixar órfãs
+	if restauraVinculosOcorrencias(resultado.Restaurados) {
+		if err := database.VerificarVinculosOcorrencias(tx, ""); err != nil {
+			return nil, fmt.Errorf("restauro incoerente: %v", err)
+		}
+	}
+
+	// No ensaio a transação é desfeita; as sequências não seriam (setval não é transacional)
+	if opcoes.Ensaio {
+		return resultado, nil
+	}
+	for _, ficheiro := range resultado.Restaurados {
+		if err := avancarSequencia(tx, ficheiro); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar restauro: %v", err)
+	}
+
+	for _, ficheiro := range resultado.Restaurados {
+		if ficheiro.Tabela == "ocorrencias_falhas" {
+			distribuirParticoes(db)
+		}
+	}
+	return resultado, nil
+}
+
+// restauraVinculosOcorrencias indica se algum ficheiro restaurado tem ocorrências ou referências a elas
+func restauraVinculosOcorrencias(restaurados []FicheiroTabela) bool {
+	for _, ficheiro := range restaurados {
+		for _, v := range database.VinculosOcorrencia {
+			if ficheiro.Tabela == v.Tabela {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lerArquivo percorre o arquivo: valida o manifesto, entrega cada ficheiro a lerFicheiro (que
+// devolve os registos lidos, ou -1 para não os contar) e confere tamanhos, sha256 e contagens
+func lerArquivo(caminho string, validar func(*Manifesto) error, lerFicheiro func(FicheiroTabela, io.Reader) (int, error)) error {
+	if err := verificarSomaArquivo(caminho); err != nil {
+		return err
+	}
+
+	f, err := os.Open(caminho)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo: %v", err)
+	}
+	defer f.Close()
+	descompressor, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s não é um arquivo de backup (gzip): %v", caminho, err)
+	}
+	defer descompressor.Close()
+	arquivo := tar.NewReader(descompressor)
+
+	cabecalho, err := arquivo.Next()
+	if err != nil || cabecalho.Name != nomeManifesto {
+		return fmt.Errorf("%s não é um arquivo de backup (sem %s no início)", caminho, nomeManifesto)
+	}
+	conteudo, err := io.ReadAll(io.LimitReader(arquivo, tamanhoMaximoManifesto))
+	if err != nil {
+		return fmt.Errorf("erro ao ler manifesto: %v", err)
+	}
+	var manifesto Manifesto
+	if err := json.Unmarshal(conteudo, &manifesto); err != nil || manifesto.Formato != formatoBackup {
+		return fmt.Errorf("%s não é um arquivo de backup (manifesto inválido)", caminho)
+	}
+	if manifesto.Versao != versaoFormato {
+		return fmt.Errorf("versão %d do arquivo não suportada (esperada %d)", manifesto.Versao, versaoFormato)
+	}
+	if manifesto.FormatoDados != FormatoJSON && manifesto.FormatoDados != FormatoCSV {
+		return fmt.Errorf("formato de dados inválido no manifesto: %s", manifesto.FormatoDados)
+	}
+	if err := validar(&manifesto); err != nil {
+		return err
+	}
+
+	esperados := make(map[string]FicheiroTabela)
+	for _, ficheiro := range manifesto.Ficheiros {
+		esperados[ficheiro.Ficheiro] = ficheiro
+	}
+	for {
+		cabecalho, err := arquivo.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler arquivo: %v", err)
+		}
+		ficheiro, existe := esperados[cabecalho.Name]
+		if !existe {
+			return fmt.Errorf("ficheiro %s não consta do manifesto", cabecalho.Name)
+		}
+		delete(esperados, cabecalho.Name)
+
+		soma := sha256.New()
+		tamanho := &contadorBytes{}
+		leitor := io.TeeReader(arquivo, io.MultiWriter(soma, tamanho))
+		registos, err := lerFicheiro(ficheiro, leitor)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.Discard, leitor); err != nil {
+			return fmt.Errorf("erro ao ler %s: %v", ficheiro.Ficheiro, err)
+		}
+		if tamanho.total != ficheiro.TamanhoBytes || hex.EncodeToString(soma.Sum(nil)) != ficheiro.SHA256 {
+			return fmt.Errorf("ficheiro %s corrompido (sha256 diferente do manifesto)", ficheiro.Ficheiro)
+		}
+		if registos >= 0 && registos != ficheiro.Registos {
+			return fmt.Errorf("ficheiro %s com %d registos, o manifesto indica %d", ficheiro.Ficheiro, registos, ficheiro.Registos)
+		}
+	}
+	if len(esperados) > 0 {
+		var faltam []string
+		for nome := range esperados {
+			faltam = append(faltam, nome)
+		}
+		sort.Strings(faltam)
+		return fmt.Errorf("arquivo incompleto: faltam %s", strings.Join(faltam, ", "))
+	}
+	return nil
+}
+
+// verificarSomaArquivo confere o arquivo com <arquivo>.sha256, quando existe
+func verificarSomaArquivo(caminho string) error {
+	conteudo, err := os.ReadFile(caminho + ".sha256")
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s.sha256: %v", caminho, err)
+	}
+	campos := strings.Fields(string(conteudo))
+	if len(campos) == 0 {
+		return fmt.Errorf("%s.sha256 vazio", caminho)
+	}
+
+	f, err := os.Open(caminho)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo: %v", err)
+	}
+	defer f.Close()
+	soma := sha256.New()
+	if _, err := io.Copy(soma, f); err != nil {
+		return fmt.Errorf("erro ao ler arquivo: %v", err)
+	}
+	if calculada := hex.EncodeToString(soma.Sum(nil)); calculada != strings.ToLower(campos[0]) {
+		return fmt.Errorf("sha256 do arquivo (%s) diferente de %s.sha256 (%s)", calculada, caminho, campos[0])
+	}
+	return nil
+}
+
+// restaurarTabela insere ou atualiza os registos de um ficheiro, em lotes
+func restaurarTabela(tx *sql.Tx, leitor io.Reader, ficheiro FicheiroTabela, formato string) (int, error) {
+	instrucao, err := instrucaoRestauro(tx, ficheiro, formato)
+	if err != nil {
+		return 0, err
+	}
+
+	var lote []json.RawMessage
+	registos := 0
+	inserir := func() error {
+		if len(lote) == 0 {
+			return nil
+		}
+		conteudo, err := json.Marshal(lote)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(instrucao, string(conteudo)); err != nil {
+			return fmt.Errorf("erro ao restaurar %s: %v", ficheiro.Tabela, err)
+		}
+		lote = lote[:0]
+		return nil
+	}
+	adicionar := func(registo json.RawMessage) error {
+		lote = append(lote, registo)
+		registos++
+		if len(lote) >= linhasPorInsercao {
+			return inserir()
+		}
+		return nil
+	}
+
+	if formato == FormatoJSON {
+		linhas := bufio.NewReaderSize(leitor, 1<<20)
+		for {
+			linha, err := linhas.ReadBytes('\n')
+			if err == io.EOF && len(linha) == 0 {
+				break
+			}
+			if err != nil && err != io.EOF {
+				return registos, fmt.Errorf("erro ao ler %s: %v", ficheiro.Ficheiro, err)
+			}
+			if !json.Valid(linha) {
+				return registos, fmt.Errorf("linha %d inválida em %s", registos+1, ficheiro.Ficheiro)
+			}
+			if err := adicionar(json.RawMessage(linha)); err != nil {
+				return registos, err
+			}
+		}
+	} else {
+		leitorCSV := csv.NewReader(leitor)
+		cabecalho, err := leitorCSV.Read()
+		if err != nil {
+			return 0, fmt.Errorf("erro ao ler cabeçalho de %s: %v", ficheiro.Ficheiro, err)
+		}
+		if strings.Join(cabecalho, ",") != strings.Join(ficheiro.Colunas, ",") {
+			return 0, fmt.Errorf("cabeçalho de %s diferente das colunas do manifesto", ficheiro.Ficheiro)
+		}
+		for {
+			linha, err := leitorCSV.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return registos, fmt.Errorf("erro ao ler %s: %v", ficheiro.Ficheiro, err)
+			}
+			valores := make(map[string]interface{}, len(linha))
+			for i, valor := range linha {
+				valores[cabecalho[i]] = valor
+				if valor == nuloCSV {
+					valores[cabecalho[i]] = nil
+				}
+			}
+			registo, err := json.Marshal(valores)
+			if err != nil {
+				return registos, err
+			}
+			if err := adicionar(registo); err != nil {
+				return registos, err
+			}
+		}
+	}
+	return registos, inserir()
+}
+
+// instrucaoRestauro monta o INSERT ... ON CONFLICT de um lote (JSON em $1) com as colunas do
+// ficheiro. No CSV os valores chegam em texto e são convertidos para o tipo de cada coluna.
+func instrucaoRestauro(tx *sql.Tx, ficheiro FicheiroTabela, formato string) (string, error) {
+	existe, err := existeTabela(tx, ficheiro.Tabela)
+	if err != nil {
+		return "", err
+	}
+	if !existe {
+		return "", fmt.Errorf("tabela %s do arquivo não existe no banco", ficheiro.Tabela)
+	}
+	colunas, err := colunasTabela(tx, ficheiro.Tabela)
+	if err != nil {
+		return "", err
+	}
+	chave, err := chavePrimaria(tx, ficheiro.Tabela)
+	if err != nil {
+		return "", err
+	}
+
+	tipos := make(map[string]string, len(colunas))
+	for _, c := range colunas {
+		tipos[c.Nome] = c.Tipo
+	}
+	definicoes := make([]string, len(ficheiro.Colunas))
+	valores := make([]string, len(ficheiro.Colunas))
+	var atualizacoes []string
+	for i, nome := range ficheiro.Colunas {
+		tipo, existe := tipos[nome]
+		if !existe {
+			return "", fmt.Errorf("coluna %s.%s do arquivo não existe no banco (esquema incompatível)", ficheiro.Tabela, nome)
+		}
+		identificador := pq.QuoteIdentifier(nome)
+		if formato == FormatoCSV {
+			definicoes[i] = identificador + " text"
+			valores[i] = "r." + identificador + "::" + tipo
+		} else {
+			definicoes[i] = identificador + " " + tipo
+			valores[i] = "r." + identificador
+		}
+		if !contem(chave, nome) {
+			atualizacoes = append(atualizacoes, identificador+" = EXCLUDED."+identificador)
+		}
+	}
+	for _, nome := range chave {
+		if !contem(ficheiro.Colunas, nome) {
+			return "", fmt.Errorf("coluna %s.%s da chave primária não está no arquivo (esquema incompatível)", ficheiro.Tabela, nome)
+		}
+	}
+
+	conflito := "DO NOTHING"
+	if len(atualizacoes) > 0 {
+		conflito = "DO UPDATE SET " + strings.Join(atualizacoes, ", ")
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM json_to_recordset($1) AS r(%s) ON CONFLICT (%s) %s",
+		ficheiro.Tabela, listaIdentificadores(ficheiro.Colunas, ""), strings.Join(valores, ", "),
+		strings.Join(definicoes, ", "), listaIdentificadores(chave, ""), conflito), nil
+}
+
+// avancarSequencia põe a sequência do id depois do maior id restaurado (nunca a faz recuar: ids
+// de ocorrências arquivadas não podem voltar a ser usados)
+func avancarSequencia(tx *sql.Tx, ficheiro FicheiroTabela) error {
+	if !contem(ficheiro.Colunas, "id") {
+		return nil
+	}
+	var sequencia sql.NullString
+	if err := tx.QueryRow("SELECT pg_get_serial_sequence($1, 'id')", ficheiro.Tabela).Scan(&sequencia); err != nil {
+		return fmt.Errorf("erro ao buscar sequência de %s: %v", ficheiro.Tabela, err)
+	}
+	if !sequencia.Valid {
+		return nil
+	}
+	_, err := tx.Exec(`SELECT setval($1, MAX(id)) FROM `+ficheiro.Tabela+`
+		HAVING MAX(id) > (SELECT last_value FROM `+sequencia.String+`)`, sequencia.String)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar sequência de %s: %v", ficheiro.Tabela, err)
+	}
+	return nil
+}
+
+// distribuirParticoes cria as partições mensais das ocorrências restauradas que caíram na
+// partição padrão (meses já removidos pela retenção ou anteriores à instalação)
+func distribuirParticoes(db *sql.DB) {
+	rows, err := db.Query(`SELECT DISTINCT date_trunc('month', timestamp_inicio) FROM ocorrencias_falhas_padrao`)
+	if err != nil {
+		// Esquema anterior ao particionamento: não há partições
+		return
+	}
+	var meses []sql.NullTime
+	for rows.Next() {
+		var mes sql.NullTime
+		if err := rows.Scan(&mes); err == nil {
+			meses = append(meses, mes)
+		}
+	}
+	rows.Close()
+
+	for _, mes := range meses {
+		if !mes.Valid {
+			continue
+		}
+		if err := retencao.GarantirParticao(db, mes.Time); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+	}
+}
+
+func contem(lista []string, valor string) bool {
+	for _, item := range lista {
+		if item == valor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/edp/falhas-backend/backup"
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/database"
+)
+
+// executarBackup grava os conjuntos de dados escolhidos num arquivo tar.gz com manifesto
+func executarBackup(argumentos []string) {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	saida := flags.String("saida", "", "arquivo a criar (padrão: backup_<data>.tar.gz)")
+	conjuntos := flags.String("conjuntos", "", "conjuntos separados por vírgula (padrão: todos)")
+	formato := flags.String("formato", backup.FormatoJSON, "formato das tabelas: json ou csv")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: falhas-backend backup [-saida arquivo.tar.gz] [-conjuntos definicoes,equipas] [-formato json|csv]")
+		fmt.Fprintln(flags.Output(), "Conjuntos:")
+		for _, c := range backup.Conjuntos {
+			fmt.Fprintf(flags.Output(), "  %-11s %s\n", c.Nome, c.Descricao)
+		}
+		flags.PrintDefaults()
+	}
+	flags.Parse(argumentos)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	selecionados, err := backup.SelecionarConjuntos(*conjuntos)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	agora := time.Now()
+	if *saida == "" {
+		*saida = "backup_" + agora.Format("20060102T150405") + ".tar.gz"
+	}
+
+	db := conectarPostgres("backup")
+	defer db.Close()
+
+	manifesto, soma, err := backup.Criar(db, *saida, selecionados, *formato, agora)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	for _, f := range manifesto.Ficheiros {
+		fmt.Printf("  📄 %-45s %8d registo(s)\n", f.Ficheiro, f.Registos)
+	}
+	fmt.Printf("✅ Backup %s criado (esquema v%d, sha256 %s)\n", *saida, manifesto.VersaoEsquema, soma)
+}
+
+// executarRestore restaura um arquivo criado por backup, no todo ou só alguns conjuntos
+func executarRestore(argumentos []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	conjuntos := flags.String("conjuntos", "", "conjuntos a restaurar, separados por vírgula (padrão: todos os do arquivo)")
+	ensaio := flags.Bool("ensaio", false, "aplica e desfaz no fim, para validar o arquivo contra o banco")
+	verificar := flags.Bool("verificar", false, "só confere o manifesto e os sha256, sem ligar ao banco")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: falhas-backend restore <arquivo.tar.gz> [-conjuntos definicoes] [-ensaio] [-verificar]")
+		flags.PrintDefaults()
+	}
+
+	// O caminho vem antes das opções: o resto dos argumentos volta a passar pelas flags
+	flags.Parse(argumentos)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	caminho := flags.Arg(0)
+	flags.Parse(flags.Args()[1:])
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if *verificar {
+		manifesto, err := backup.Verificar(caminho)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("✅ %s íntegro: criado em %s, esquema v%d, %s, conjuntos %v\n", caminho,
+			manifesto.CriadoEm.Format("2006-01-02 15:04:05"), manifesto.VersaoEsquema, manifesto.FormatoDados, manifesto.Conjuntos)
+		return
+	}
+
+	selecionados, err := backup.SelecionarConjuntos(*conjuntos)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if *conjuntos == "" {
+		selecionados = nil
+	}
+
+	db := conectarPostgres("restore")
+	defer db.Close()
+
+	resultado, err := backup.Restaurar(db, caminho, backup.OpcoesRestauro{Conjuntos: selecionados, Ensaio: *ensaio})
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	for _, f := range resultado.Restaurados {
+		fmt.Printf("  📄 %-45s %8d registo(s)\n", f.Ficheiro, f.Registos)
+	}
+	if resultado.Ensaio {
+		fmt.Printf("✅ Ensaio concluído: o arquivo (esquema v%d) pode ser restaurado no banco (v%d); nada foi alterado\n",
+			resultado.Manifesto.VersaoEsquema, resultado.VersaoEsquema)
+		return
+	}
+	fmt.Printf("✅ %s restaurado (esquema v%d no banco v%d)\n", caminho, resultado.Manifesto.VersaoEsquema, resultado.VersaoEsquema)
+}
+
+// conectarPostgres liga ao banco configurado, que tem de ser PostgreSQL
+func conectarPostgres(comando string) *sql.DB {
+	configuracoes := config.CarregarConfiguracoes()
+	if configuracoes.DB_Tipo != config.BancoPostgres {
+		log.Fatalf("❌ O %s só existe no PostgreSQL (DB_TIPO=%s); no modo embutido copie o ficheiro %s",
+			comando, configuracoes.DB_Tipo, configuracoes.SQLite_Arquivo)
+	}
+	db, err := database.Conectar(configuracoes)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	return db
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/database"
+)
+
+// executarMigrate aplica, reverte ou lista as migrações do esquema e insere os dados iniciais
+func executarMigrate(argumentos []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	passos := flags.Int("passos", 1, "número de migrações a reverter (down)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: falhas-backend migrate up|down|status|seed [-passos n]")
+		fmt.Fprintln(flags.Output(), "  up      aplica as migrações pendentes (cria o banco se não existir)")
+		fmt.Fprintln(flags.Output(), "  down    reverte as últimas migrações aplicadas (-passos)")
+		fmt.Fprintln(flags.Output(), "  status  lista as migrações e se estão aplicadas")
+		fmt.Fprintln(flags.Output(), "  seed    insere os dados iniciais (eclusas, setores, equipas, falhas da Régua)")
+		flags.PrintDefaults()
+	}
+	if len(argumentos) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	acao := argumentos[0]
+	flags.Parse(argumentos[1:])
+
+	configuracoes := config.CarregarConfiguracoes()
+	if acao == "up" {
+		if err := database.CriarBancoSeNecessario(configuracoes); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+
+	db, err := database.Conectar(configuracoes)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer db.Close()
+
+	switch acao {
+	case "up":
+		if _, err := database.Migrar(db); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+
+	case "down":
+		revertidas, err := database.ReverterMigracoes(db, *passos)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("✅ %d migração(ões) revertida(s)\n", revertidas)
+
+	case "status":
+		estados, err := database.EstadoMigracoes(db)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		pendentes := 0
+		for _, e := range estados {
+			switch {
+			case !e.Aplicada:
+				pendentes++
+				fmt.Printf("  ⏳ %04d_%-30s pendente\n", e.Versao, e.Nome)
+			case e.Alterada:
+				fmt.Printf("  ⚠️  %04d_%-30s aplicada em %s (o SQL mudou depois de aplicado)\n",
+					e.Versao, e.Nome, e.AplicadaEm.Format("2006-01-02 15:04:05"))
+			default:
+				fmt.Printf("  ✅ %04d_%-30s aplicada em %s\n", e.Versao, e.Nome, e.AplicadaEm.Format("2006-01-02 15:04:05"))
+			}
+		}
+		fmt.Printf("\n%d migração(ões), %d pendente(s)\n", len(estados), pendentes)
+
+	case "seed":
+		if versao, err := database.VersaoEsquema(db); err != nil {
+			log.Fatalf("❌ %v", err)
+		} else if versao == 0 {
+			log.Fatal("❌ Esquema sem migrações: execute primeiro 'migrate up'")
+		}
+		if err := database.InserirDadosIniciais(db); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
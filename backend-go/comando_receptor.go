@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/edp/falhas-backend/notificacoes"
+)
+
+// executarReceptor sobe um servidor SMTP e um HTTP locais que mostram as notificações recebidas
+func executarReceptor(argumentos []string) {
+	flags := flag.NewFlagSet("receptor", flag.ExitOnError)
+	enderecoSMTP := flags.String("smtp", "127.0.0.1:2525", "endereço do servidor SMTP de teste (vazio = desligado)")
+	enderecoHTTP := flags.String("http", "127.0.0.1:8025", "endereço do webhook / gateway SMS de teste (vazio = desligado)")
+	taxaFalha := flags.Float64("falhar", 0, "fração (0 a 1) das entregas recusadas, para testar as novas tentativas")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: falhas-backend receptor [-smtp host:porta] [-http host:porta] [-falhar 0.3]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(argumentos)
+
+	receptor := notificacoes.NovoReceptorTeste(*taxaFalha)
+
+	if *enderecoSMTP != "" {
+		endereco, err := receptor.IniciarSMTP(*enderecoSMTP)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("📧 SMTP de teste em %s (SMTP_HOST / SMTP_PORT)\n", endereco)
+	}
+	if *enderecoHTTP != "" {
+		endereco, err := receptor.IniciarHTTP(*enderecoHTTP)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("🌐 Webhook / gateway SMS de teste em http://%s/ (qualquer caminho)\n", endereco)
+	}
+
+	canalSinal := make(chan os.Signal, 1)
+	signal.Notify(canalSinal, os.Interrupt, syscall.SIGTERM)
+	<-canalSinal
+
+	receptor.Parar()
+}
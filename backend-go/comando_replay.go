@@ -0,0 +1,131 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/database"
+	"github.com/edp/falhas-backend/modelos"
+	"github.com/edp/falhas-backend/plc"
+)
+
+// executarReplay reinjeta uma captura de frames no ProcessadorDados contra um banco de teste
+func executarReplay(argumentos []string) {
+	configuracoes := config.CarregarConfiguracoes()
+
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	arquivo := flags.String("arquivo", "", "arquivo de captura (.ndjson) gravado com CAPTURA_ATIVA=true")
+	velocidade := flags.Float64("velocidade", 1, "1 = tempo real, 10 = dez vezes mais rápido, 0 = sem espera")
+	banco := flags.String("banco", configuracoes.DB_Nome+"_replay", "banco de teste onde as ocorrências serão gravadas")
+	formato := flags.String("formato-timestamp", configuracoes.PLC_TimestampFrame, "cabeçalho de timestamp dos frames: vazio, unix_ms ou s7_dt")
+	limpar := flags.Bool("limpar", false, "apagar ocorrências, avalanches e SOE do banco de teste antes de reproduzir")
+	saida := flags.String("saida", "", "gravar as mudanças de bits detectadas em NDJSON (para comparar execuções)")
+	forcar := flags.Bool("forcar", false, "permitir usar o banco de produção (DB_NAME)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: falhas-backend replay -arquivo captura.ndjson [opções]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(argumentos)
+
+	if *arquivo == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *velocidade < 0 {
+		log.Fatal("❌ -velocidade não pode ser negativa")
+	}
+	if *banco == configuracoes.DB_Nome && !*forcar {
+		log.Fatalf("❌ O replay grava ocorrências: use um banco de teste ou -forcar para usar '%s'", *banco)
+	}
+
+	leitor, err := plc.AbrirCaptura(*arquivo)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer leitor.Fechar()
+
+	// Criar/verificar o banco de teste com a mesma estrutura e mapeamento da produção
+	cfgTeste := *configuracoes
+	cfgTeste.DB_Nome = *banco
+	cfgTeste.DB_Tipo = config.BancoPostgres
+	fmt.Printf("🔧 Preparando banco de teste '%s'...\n", *banco)
+	db, err := database.CriarBancoCompleto(&cfgTeste)
+	if err != nil {
+		log.Fatalf("❌ Erro ao criar/verificar banco de teste: %v", err)
+	}
+	defer db.Close()
+
+	if *limpar {
+		if err := limparBancoReplay(db); err != nil {
+			log.Fatalf("❌ Erro ao limpar banco de teste: %v", err)
+		}
+		fmt.Println("🧹 Ocorrências, avalanches e SOE apagados")
+	}
+
+	var mudancasSaida *json.Encoder
+	if *saida != "" {
+		arquivoSaida, err := os.Create(*saida)
+		if err != nil {
+			log.Fatalf("❌ Erro ao criar arquivo de saída: %v", err)
+		}
+		defer arquivoSaida.Close()
+		mudancasSaida = json.NewEncoder(arquivoSaida)
+	}
+
+	// Interromper com Ctrl+C sem perder o resumo
+	parada := make(chan struct{})
+	canalSinal := make(chan os.Signal, 1)
+	signal.Notify(canalSinal, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-canalSinal
+		close(parada)
+	}()
+
+	processador := plc.NovoProcessadorDados(&cfgTeste, plc.NovoMapeamentoTags(db), db)
+
+	fmt.Printf("▶️  Reproduzindo %s (velocidade %gx)...\n", *arquivo, *velocidade)
+	resultado, err := plc.ReproduzirCaptura(processador, leitor, plc.OpcoesReproducao{
+		FormatoTimestamp: *formato,
+		Velocidade:       *velocidade,
+		Parada:           parada,
+	}, func(mensagem modelos.MensagemPLC, mudancas []modelos.MudancaBit) {
+		if mudancasSaida == nil {
+			return
+		}
+		for _, mudanca := range mudancas {
+			if err := mudancasSaida.Encode(mudanca); err != nil {
+				log.Printf("⚠️  Erro ao gravar mudança na saída: %v", err)
+			}
+		}
+	})
+	processador.Encerrar()
+	if err != nil {
+		log.Fatalf("❌ Erro na reprodução: %v", err)
+	}
+
+	if resultado.Interrompida {
+		fmt.Println("🛑 Reprodução interrompida")
+	}
+	fmt.Printf("✅ Replay concluído: %s\n", resultado)
+}
+
+// limparBancoReplay apaga os dados gerados por reproduções anteriores (as definições são mantidas)
+func limparBancoReplay(db *sql.DB) error {
+	// TRUNCATE não dispara o trigger append-only do SOE (que protege UPDATE/DELETE linha a linha).
+	// As tabelas que referem ocorrências não têm chave estrangeira, por isso o CASCADE não as alcança.
+	_, err := db.Exec(`TRUNCATE registros_soe, ocorrencias_falhas, ` + strings.Join(database.TabelasDependentesOcorrencia, ", ") + `,
+		avalanches_alarmes, series_brutas, series_1m, series_15m, series_1h, series_agregacao_estado, eclusagens
+		RESTART IDENTITY CASCADE`)
+	if err != nil {
+		return err
+	}
+	return database.VerificarVinculosOcorrencias(db, "")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/database"
+	"github.com/edp/falhas-backend/retencao"
+)
+
+// executarRetencao mostra as partições e arquivos de ocorrências, aplica a retenção ou restaura um arquivo
+func executarRetencao(argumentos []string) {
+	flags := flag.NewFlagSet("retencao", flag.ExitOnError)
+	manter := flags.Duration("manter", 30*24*time.Hour, "tempo que as ocorrências restauradas ficam protegidas da retenção")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: falhas-backend retencao status|executar|restaurar <arquivo> [-manter 720h]")
+		fmt.Fprintln(flags.Output(), "  status     lista as partições de ocorrências e os arquivos gerados")
+		fmt.Fprintln(flags.Output(), "  executar   arquiva e apaga as ocorrências fora da retenção (RETENCAO_EVENTOS, RETENCAO_FALHAS)")
+		fmt.Fprintln(flags.Output(), "  restaurar  volta a inserir no banco as ocorrências de um arquivo (-manter)")
+		flags.PrintDefaults()
+	}
+	if len(argumentos) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	acao := argumentos[0]
+	flags.Parse(argumentos[1:])
+
+	configuracoes := config.CarregarConfiguracoes()
+	if configuracoes.DB_Tipo != config.BancoPostgres {
+		log.Fatalf("❌ A retenção de ocorrências só existe no PostgreSQL (DB_TIPO=%s)", configuracoes.DB_Tipo)
+	}
+	db, err := database.Conectar(configuracoes)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer db.Close()
+
+	switch acao {
+	case "status":
+		particoes, err := retencao.ListarParticoes(db)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Println("Partições:")
+		for _, p := range particoes {
+			fmt.Printf("  %-32s %8d evento(s) %8d falha(s) %6d ativa(s)\n", p.Nome, p.Eventos, p.Falhas, p.Ativas)
+		}
+
+		arquivos, err := retencao.ListarArquivos(db)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("\nArquivos (%s):\n", configuracoes.Retencao_Diretorio)
+		for _, a := range arquivos {
+			fmt.Printf("  %s  %s %-6s %8d ocorrência(s) %10d bytes", a.Arquivo, a.Mes.Format("2006-01"), a.Tipo, a.Ocorrencias, a.TamanhoBytes)
+			if a.ManterAte != nil {
+				fmt.Printf("  restaurado, mantido até %s", a.ManterAte.Format("2006-01-02 15:04"))
+			}
+			fmt.Println()
+		}
+		if len(arquivos) == 0 {
+			fmt.Println("  (nenhum)")
+		}
+
+	case "executar":
+		configuracoes.Retencao_Ativo = true
+		resultado, err := retencao.NovoServico(db, configuracoes).AplicarRetencao(time.Now())
+		if resultado != nil {
+			fmt.Printf("✅ %d arquivo(s) gerado(s), %d partição(ões) removida(s)\n",
+				len(resultado.Arquivos), len(resultado.ParticoesRemovidas))
+		}
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+
+	case "restaurar":
+		// O caminho vem antes das opções: o resto dos argumentos volta a passar pelas flags
+		if flags.NArg() == 0 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		caminho := flags.Arg(0)
+		flags.Parse(flags.Args()[1:])
+		if flags.NArg() != 0 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		arquivo, err := retencao.Restaurar(db, caminho, *manter)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("✅ %d ocorrência(s) e %d transição(ões) de %s (%s) restaurada(s), mantidas até %s\n",
+			arquivo.Ocorrencias, arquivo.Transicoes, arquivo.Mes.Format("2006-01"), arquivo.Tipo,
+			arquivo.ManterAte.Format("2006-01-02 15:04"))
+
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/simulador"
+)
+
+// executarSimulador envia frames no layout da Régua ao ServidorTCP, no lugar do PLC
+func executarSimulador(argumentos []string) {
+	configuracoes := config.CarregarConfiguracoes()
+
+	flags := flag.NewFlagSet("simulador", flag.ExitOnError)
+	alvo := flags.String("alvo", "127.0.0.1:"+configuracoes.ServidorTCP_Porta, "backend host:porta (lista separada por vírgulas para uma por eclusa)")
+	cenario := flags.String("cenario", "aleatorio", "aleatorio, ciclo, avalanche, desconexao ou script")
+	roteiro := flags.String("script", "", "roteiro YAML (cenário script)")
+	eclusas := flags.Int("eclusas", 1, "número de eclusas simuladas, cada uma com a sua ligação")
+	periodo := flags.Duration("periodo", time.Second, "reenvio periódico do frame completo")
+	duracao := flags.Duration("duracao", 0, "tempo total da simulação (0 = até Ctrl+C)")
+	formato := flags.String("formato-timestamp", configuracoes.PLC_TimestampFrame, "cabeçalho de timestamp dos frames: vazio, unix_ms ou s7_dt")
+	semente := flags.Int64("semente", time.Now().UnixNano(), "semente dos cenários aleatórios (repetir a sequência)")
+	intervalo := flags.Duration("intervalo", 5*time.Second, "aleatorio: tempo médio entre falhas")
+	passo := flags.Duration("passo", 3*time.Second, "ciclo: tempo entre etapas da eclusagem")
+	alarmes := flags.Int("alarmes", configuracoes.Alarme_AvalancheLimite*2, "avalanche: número de alarmes")
+	janela := flags.Duration("janela", 10*time.Second, "avalanche: tempo em que os alarmes são ativados")
+	queda := flags.Duration("queda", 15*time.Second, "desconexao: duração de cada queda")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: falhas-backend simulador [-cenario aleatorio|ciclo|avalanche|desconexao|script] [opções]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(argumentos)
+
+	var roteiroCarregado *simulador.CenarioScript
+	if *cenario == "script" {
+		if *roteiro == "" {
+			log.Fatal("❌ O cenário script precisa de -script roteiro.yaml")
+		}
+		var err error
+		if roteiroCarregado, err = simulador.CarregarCenarioScript(*roteiro); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+
+	// Cada eclusa recebe o seu próprio cenário (sementes diferentes para não repetirem as falhas)
+	cenarioPara := func(indice int) simulador.Cenario {
+		sementeEclusa := *semente + int64(indice)
+		switch *cenario {
+		case "aleatorio":
+			return simulador.CenarioAleatorio{
+				Intervalo:     *intervalo,
+				DuracaoMinima: 5 * time.Second,
+				DuracaoMaxima: 2 * time.Minute,
+				FalhasMaximas: 10,
+				Semente:       sementeEclusa,
+			}
+		case "ciclo":
+			return simulador.CenarioCiclo{Passo: *passo}
+		case "avalanche":
+			return simulador.CenarioAvalanche{
+				Alarmes: *alarmes,
+				Janela:  *janela,
+				Manter:  time.Minute,
+				Pausa:   5 * time.Second,
+				Semente: sementeEclusa,
+			}
+		case "desconexao":
+			return simulador.CenarioDesconexao{Apos: 30 * time.Second, Duracao: *queda}
+		case "script":
+			return roteiroCarregado
+		}
+		return nil
+	}
+	if cenarioPara(0) == nil {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancelar := context.WithCancel(context.Background())
+	defer cancelar()
+	if *duracao > 0 {
+		ctx, cancelar = context.WithTimeout(ctx, *duracao)
+		defer cancelar()
+	}
+
+	canalSinal := make(chan os.Signal, 1)
+	signal.Notify(canalSinal, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-canalSinal
+		cancelar()
+	}()
+
+	sim, err := simulador.Novo(simulador.Configuracao{
+		Enderecos:        strings.Split(*alvo, ","),
+		Eclusas:          *eclusas,
+		Periodo:          *periodo,
+		FormatoTimestamp: *formato,
+	})
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	fmt.Printf("🧪 Simulador: %d eclusa(s), cenário %s, alvo %s (semente %d)\n", *eclusas, *cenario, *alvo, *semente)
+	if err := sim.Executar(ctx, cenarioPara); err != nil {
+		log.Fatalf("❌ Erro no simulador: %v", err)
+	}
+
+	for _, eclusa := range sim.Eclusas {
+		fmt.Printf("📤 %s: %d frames enviados\n", eclusa.Nome, eclusa.FramesEnviados())
+	}
+}
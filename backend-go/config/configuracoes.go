@@ -2,19 +2,50 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"time"
 )
 
+// Tipos de armazenamento aceites em DB_TIPO
+const (
+	BancoPostgres = "postgres"
+	BancoSQLite   = "sqlite"
+)
+
 // Configuracoes armazena todas as configurações do sistema
 type Configuracoes struct {
 	// Servidor TCP
 	ServidorTCP_Host string
 	ServidorTCP_Porta string
 
+	// Servidor HTTP (API)
+	ServidorHTTP_Porta string
+	ServidorHTTP_CertificadoTLS string // Com a chave: servir HTTPS (ex.: central que recebe as eclusas)
+	ServidorHTTP_ChaveTLS       string
+
 	// PLC
 	PLC_Host    string
 	PLC_Porta    string
 	PLC_Timeout time.Duration
+	PLC_TimestampFrame string // Cabeçalho de timestamp no frame: "", "unix_ms" ou "s7_dt"
+
+	// Sequence-of-events (SOE)
+	SOE_Ativo bool
+
+	// Captura bruta de frames (replay)
+	Captura_Ativa           bool
+	Captura_Diretorio       string
+	Captura_TamanhoMaximoMB int64
+	Captura_ArquivosMaximos int
+
+	// Séries temporais de valores analógicos
+	Series_Ativo              bool
+	Series_RetencaoBruto      time.Duration // 0 = sem limite
+	Series_Retencao1m         time.Duration
+	Series_Retencao15m        time.Duration
+	Series_Retencao1h         time.Duration
+	Series_IntervaloAgregacao time.Duration
+	Series_PontosMaximos      int // Pontos devolvidos por consulta antes da decimação
 
 	// Logs
 	Log_Nivel string
@@ -26,6 +57,92 @@ type Configuracoes struct {
 	DB_Nome     string
 	DB_Usuario     string
 	DB_Senha string
+
+	// Armazenamento: "postgres" (servidor) ou "sqlite" (embutido, instalação isolada numa eclusa)
+	DB_Tipo        string
+	SQLite_Arquivo string
+
+	// Pool de ligações ao banco e tentativas de ligação no arranque
+	DB_SSLMode           string
+	DB_ConexoesMaximas   int // 0 = sem limite
+	DB_ConexoesOciosas   int
+	DB_VidaMaximaConexao time.Duration
+	DB_OciosidadeMaxima  time.Duration
+	DB_TimeoutConexao    time.Duration
+	DB_TentativasConexao int
+	DB_EsperaTentativa   time.Duration // Duplica a cada tentativa falhada, até 30s
+
+	// Análise de alarmes
+	Alarme_AvalancheLimite int
+	Alarme_AvalancheJanela time.Duration
+	Alarme_FirstOutJanela  time.Duration
+
+	// Deteção de eclusagens
+	Eclusagem_Ativa            bool
+	Eclusagem_TempoMaximoFase  time.Duration // Fase mais longa que isto fecha o ciclo como TIMEOUT
+	Eclusagem_TempoMaximoCiclo time.Duration
+
+	// Relatórios agendados (expressões cron de 5 campos; "-" = tipo desativado)
+	Relatorios_Ativo        bool
+	Relatorios_AgendaTurno  string
+	Relatorios_AgendaDiario string
+	Relatorios_AgendaMensal string
+	Relatorios_DuracaoTurno time.Duration
+	Relatorios_Eclusas      string // Códigos separados por vírgula; vazio = todas as eclusas ativas
+
+	// Notificações de alarmes (regras e destinos no banco)
+	Notificacoes_Ativo         bool
+	Notificacoes_Intervalo     time.Duration
+	Notificacoes_MaxTentativas int
+	Notificacoes_EsperaInicial time.Duration // Espera antes da 2.ª tentativa; duplica a cada falha
+	Notificacoes_Timeout       time.Duration
+
+	// Canal de email (SMTP)
+	SMTP_Host      string
+	SMTP_Porta     string
+	SMTP_Usuario   string
+	SMTP_Senha     string
+	SMTP_Remetente string
+
+	// Canal de SMS (gateway HTTP)
+	SMS_URL   string
+	SMS_Token string
+
+	// Plantão e escalonamento (envios entregues pelo notificador)
+	Escalonamento_Ativo     bool
+	Escalonamento_Intervalo time.Duration
+
+	// Ordens de trabalho (regras de abertura e sincronização com o CMMS)
+	OrdensTrabalho_Ativo     bool
+	OrdensTrabalho_Intervalo time.Duration
+
+	// CMMS externo (vazio = sem sincronização; "mock" = simulado; "http" = API JSON)
+	CMMS_Tipo    string
+	CMMS_URL     string
+	CMMS_Token   string
+	CMMS_Usuario string
+	CMMS_Senha   string
+	CMMS_Timeout time.Duration
+
+	// Sincronização com a instância central (store-and-forward)
+	Sync_Ativo            bool   // Esta instalação envia as suas ocorrências ao central
+	Sync_URLCentral       string // URL base do central (ex.: https://central.falhas.local:8443)
+	Sync_Origem           string // Código da eclusa desta instalação
+	Sync_Token            string // Token partilhado (Bearer), validado pelo central
+	Sync_CAArquivo        string // Certificado da autoridade que assina o do central (vazio = sistema)
+	Sync_Intervalo        time.Duration
+	Sync_Lote             int
+	Sync_Timeout          time.Duration
+	Sync_EsperaMaxima     time.Duration // Limite da espera crescente entre tentativas falhadas
+	Sync_RetencaoEnviados time.Duration // Tempo que os itens já enviados ficam na fila
+	Sync_Central          bool          // Esta instância aceita lotes das eclusas
+
+	// Retenção de ocorrencias_falhas (partições mensais; ocorrências expiradas vão para ficheiros gzip)
+	Retencao_Ativo     bool
+	Retencao_Eventos   time.Duration // 0 = sem limite
+	Retencao_Falhas    time.Duration // 0 = sem limite
+	Retencao_Diretorio string
+	Retencao_Intervalo time.Duration
 }
 
 // CarregarConfiguracoes carrega as configurações das variáveis de ambiente
@@ -35,10 +152,34 @@ func CarregarConfiguracoes() *Configuracoes {
 		ServidorTCP_Host: obterVariavelAmbiente("TCP_HOST", "0.0.0.0"),
 		ServidorTCP_Porta: obterVariavelAmbiente("TCP_PORT", "8502"),
 
+		// Servidor HTTP
+		ServidorHTTP_Porta: obterVariavelAmbiente("HTTP_PORT", "8080"),
+		ServidorHTTP_CertificadoTLS: obterVariavelAmbiente("HTTP_TLS_CERT", ""),
+		ServidorHTTP_ChaveTLS:       obterVariavelAmbiente("HTTP_TLS_KEY", ""),
+
 		// PLC
 		PLC_Host:    obterVariavelAmbiente("PLC_IP", "192.168.1.100"),
 		PLC_Porta:    obterVariavelAmbiente("PLC_PORT", "502"),
 		PLC_Timeout: obterDuracaoAmbiente("PLC_TIMEOUT", 5*time.Second),
+		PLC_TimestampFrame: obterVariavelAmbiente("PLC_TIMESTAMP_FRAME", ""),
+
+		// Sequence-of-events (SOE)
+		SOE_Ativo: obterBooleanoAmbiente("SOE_ATIVO", true),
+
+		// Captura bruta de frames (replay)
+		Captura_Ativa:           obterBooleanoAmbiente("CAPTURA_ATIVA", false),
+		Captura_Diretorio:       obterVariavelAmbiente("CAPTURA_DIRETORIO", "./capturas"),
+		Captura_TamanhoMaximoMB: int64(obterInteiroAmbiente("CAPTURA_TAMANHO_MAX_MB", 50)),
+		Captura_ArquivosMaximos: obterInteiroAmbiente("CAPTURA_ARQUIVOS_MAX", 20),
+
+		// Séries temporais de valores analógicos
+		Series_Ativo:              obterBooleanoAmbiente("SERIES_ATIVO", true),
+		Series_RetencaoBruto:      obterDuracaoAmbiente("SERIES_RETENCAO_BRUTO", 7*24*time.Hour),
+		Series_Retencao1m:         obterDuracaoAmbiente("SERIES_RETENCAO_1M", 30*24*time.Hour),
+		Series_Retencao15m:        obterDuracaoAmbiente("SERIES_RETENCAO_15M", 365*24*time.Hour),
+		Series_Retencao1h:         obterDuracaoAmbiente("SERIES_RETENCAO_1H", 0),
+		Series_IntervaloAgregacao: obterDuracaoAmbiente("SERIES_INTERVALO_AGREGACAO", time.Minute),
+		Series_PontosMaximos:      obterInteiroAmbiente("SERIES_PONTOS_MAXIMOS", 1500),
 
 		// Logs
 		Log_Nivel: obterVariavelAmbiente("LOG_LEVEL", "info"),
@@ -50,6 +191,91 @@ func CarregarConfiguracoes() *Configuracoes {
 		DB_Nome:     obterVariavelAmbiente("DB_NAME", "falhas_edp"),
 		DB_Usuario:     obterVariavelAmbiente("DB_USER", "postgres"),
 		DB_Senha: obterVariavelAmbiente("DB_PASSWORD", "postgres"),
+
+		// Armazenamento (servidor PostgreSQL ou SQLite embutido)
+		DB_Tipo:        obterVariavelAmbiente("DB_TIPO", BancoPostgres),
+		SQLite_Arquivo: obterVariavelAmbiente("SQLITE_ARQUIVO", "./dados/falhas.db"),
+
+		// Pool de ligações ao banco
+		DB_SSLMode:           obterVariavelAmbiente("DB_SSLMODE", "disable"),
+		DB_ConexoesMaximas:   obterInteiroAmbiente("DB_MAX_OPEN_CONNS", 20),
+		DB_ConexoesOciosas:   obterInteiroAmbiente("DB_MAX_IDLE_CONNS", 5),
+		DB_VidaMaximaConexao: obterDuracaoAmbiente("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DB_OciosidadeMaxima:  obterDuracaoAmbiente("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		DB_TimeoutConexao:    obterDuracaoAmbiente("DB_CONNECT_TIMEOUT", 10*time.Second),
+		DB_TentativasConexao: obterInteiroAmbiente("DB_CONNECT_RETRIES", 10),
+		DB_EsperaTentativa:   obterDuracaoAmbiente("DB_RETRY_BACKOFF", time.Second),
+
+		// Análise de alarmes
+		Alarme_AvalancheLimite: obterInteiroAmbiente("ALARME_AVALANCHE_LIMITE", 10),
+		Alarme_AvalancheJanela: obterDuracaoAmbiente("ALARME_AVALANCHE_JANELA", 10*time.Minute),
+		Alarme_FirstOutJanela:  obterDuracaoAmbiente("ALARME_FIRST_OUT_JANELA", 2*time.Second),
+
+		// Deteção de eclusagens
+		Eclusagem_Ativa:            obterBooleanoAmbiente("ECLUSAGEM_ATIVA", true),
+		Eclusagem_TempoMaximoFase:  obterDuracaoAmbiente("ECLUSAGEM_TEMPO_MAXIMO_FASE", 30*time.Minute),
+		Eclusagem_TempoMaximoCiclo: obterDuracaoAmbiente("ECLUSAGEM_TEMPO_MAXIMO_CICLO", time.Hour),
+
+		// Relatórios agendados
+		Relatorios_Ativo:        obterBooleanoAmbiente("RELATORIOS_ATIVO", true),
+		Relatorios_AgendaTurno:  obterVariavelAmbiente("RELATORIOS_AGENDA_TURNO", "0 6,14,22 * * *"),
+		Relatorios_AgendaDiario: obterVariavelAmbiente("RELATORIOS_AGENDA_DIARIO", "5 0 * * *"),
+		Relatorios_AgendaMensal: obterVariavelAmbiente("RELATORIOS_AGENDA_MENSAL", "10 0 1 * *"),
+		Relatorios_DuracaoTurno: obterDuracaoAmbiente("RELATORIOS_DURACAO_TURNO", 8*time.Hour),
+		Relatorios_Eclusas:      obterVariavelAmbiente("RELATORIOS_ECLUSAS", ""),
+
+		// Notificações de alarmes
+		Notificacoes_Ativo:         obterBooleanoAmbiente("NOTIFICACOES_ATIVO", true),
+		Notificacoes_Intervalo:     obterDuracaoAmbiente("NOTIFICACOES_INTERVALO", 5*time.Second),
+		Notificacoes_MaxTentativas: obterInteiroAmbiente("NOTIFICACOES_MAX_TENTATIVAS", 5),
+		Notificacoes_EsperaInicial: obterDuracaoAmbiente("NOTIFICACOES_ESPERA_INICIAL", 30*time.Second),
+		Notificacoes_Timeout:       obterDuracaoAmbiente("NOTIFICACOES_TIMEOUT", 10*time.Second),
+
+		// Canal de email (SMTP)
+		SMTP_Host:      obterVariavelAmbiente("SMTP_HOST", ""),
+		SMTP_Porta:     obterVariavelAmbiente("SMTP_PORT", "25"),
+		SMTP_Usuario:   obterVariavelAmbiente("SMTP_USER", ""),
+		SMTP_Senha:     obterVariavelAmbiente("SMTP_PASSWORD", ""),
+		SMTP_Remetente: obterVariavelAmbiente("SMTP_FROM", "alarmes@falhas-edp.local"),
+
+		// Canal de SMS (gateway HTTP)
+		SMS_URL:   obterVariavelAmbiente("SMS_GATEWAY_URL", ""),
+		SMS_Token: obterVariavelAmbiente("SMS_GATEWAY_TOKEN", ""),
+
+		// Plantão e escalonamento
+		Escalonamento_Ativo:     obterBooleanoAmbiente("ESCALONAMENTO_ATIVO", true),
+		Escalonamento_Intervalo: obterDuracaoAmbiente("ESCALONAMENTO_INTERVALO", 15*time.Second),
+
+		// Ordens de trabalho
+		OrdensTrabalho_Ativo:     obterBooleanoAmbiente("ORDENS_TRABALHO_ATIVO", true),
+		OrdensTrabalho_Intervalo: obterDuracaoAmbiente("ORDENS_TRABALHO_INTERVALO", 10*time.Second),
+
+		// CMMS externo
+		CMMS_Tipo:    obterVariavelAmbiente("CMMS_TIPO", ""),
+		CMMS_URL:     obterVariavelAmbiente("CMMS_URL", ""),
+		CMMS_Token:   obterVariavelAmbiente("CMMS_TOKEN", ""),
+		CMMS_Usuario: obterVariavelAmbiente("CMMS_USER", ""),
+		CMMS_Senha:   obterVariavelAmbiente("CMMS_PASSWORD", ""),
+		CMMS_Timeout: obterDuracaoAmbiente("CMMS_TIMEOUT", 15*time.Second),
+
+		// Sincronização com o central
+		Sync_Ativo:            obterBooleanoAmbiente("SYNC_ATIVO", false),
+		Sync_URLCentral:       obterVariavelAmbiente("SYNC_URL_CENTRAL", ""),
+		Sync_Origem:           obterVariavelAmbiente("SYNC_ORIGEM", ""),
+		Sync_Token:            obterVariavelAmbiente("SYNC_TOKEN", ""),
+		Sync_CAArquivo:        obterVariavelAmbiente("SYNC_CA_ARQUIVO", ""),
+		Sync_Intervalo:        obterDuracaoAmbiente("SYNC_INTERVALO", 10*time.Second),
+		Sync_Lote:             obterInteiroAmbiente("SYNC_LOTE", 200),
+		Sync_Timeout:          obterDuracaoAmbiente("SYNC_TIMEOUT", 30*time.Second),
+		Sync_EsperaMaxima:     obterDuracaoAmbiente("SYNC_ESPERA_MAXIMA", 5*time.Minute),
+		Sync_RetencaoEnviados: obterDuracaoAmbiente("SYNC_RETENCAO_ENVIADOS", 7*24*time.Hour),
+		Sync_Central:          obterBooleanoAmbiente("SYNC_CENTRAL", false),
+
+		Retencao_Ativo:     obterBooleanoAmbiente("RETENCAO_ATIVO", false),
+		Retencao_Eventos:   obterDuracaoAmbiente("RETENCAO_EVENTOS", 90*24*time.Hour),
+		Retencao_Falhas:    obterDuracaoAmbiente("RETENCAO_FALHAS", 5*365*24*time.Hour),
+		Retencao_Diretorio: obterVariavelAmbiente("RETENCAO_DIRETORIO", "./arquivo"),
+		Retencao_Intervalo: obterDuracaoAmbiente("RETENCAO_INTERVALO", time.Hour),
 	}
 }
 
@@ -69,4 +295,24 @@ func obterDuracaoAmbiente(chave string, valorPadrao time.Duration) time.Duration
 		}
 	}
 	return valorPadrao
+}
+
+// obterInteiroAmbiente retorna o inteiro da variável de ambiente ou o valor padrão
+func obterInteiroAmbiente(chave string, valorPadrao int) int {
+	if valor := os.Getenv(chave); valor != "" {
+		if inteiro, err := strconv.Atoi(valor); err == nil {
+			return inteiro
+		}
+	}
+	return valorPadrao
+}
+
+// obterBooleanoAmbiente retorna o booleano da variável de ambiente ou o valor padrão
+func obterBooleanoAmbiente(chave string, valorPadrao bool) bool {
+	if valor := os.Getenv(chave); valor != "" {
+		if booleano, err := strconv.ParseBool(valor); err == nil {
+			return booleano
+		}
+	}
+	return valorPadrao
 }
\ No newline at end of file
@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	_ "github.com/lib/pq"
+)
+
+// esperaMaximaTentativa limita a espera entre tentativas de ligação no arranque
+const esperaMaximaTentativa = 30 * time.Second
+
+// DSN monta a string de ligação ao banco indicado com os parâmetros de Configuracoes
+func DSN(cfg *config.Configuracoes, banco string) string {
+	parametros := []struct{ chave, valor string }{
+		{"host", cfg.DB_Host},
+		{"port", cfg.DB_Porta},
+		{"user", cfg.DB_Usuario},
+		{"password", cfg.DB_Senha},
+		{"dbname", banco},
+		{"sslmode", cfg.DB_SSLMode},
+	}
+	if cfg.DB_TimeoutConexao > 0 {
+		segundos := int((cfg.DB_TimeoutConexao + time.Second - 1) / time.Second)
+		parametros = append(parametros, struct{ chave, valor string }{"connect_timeout", fmt.Sprint(segundos)})
+	}
+
+	partes := make([]string, 0, len(parametros))
+	for _, p := range parametros {
+		if p.valor == "" {
+			continue
+		}
+		// Valores entre plicas: as senhas podem ter espaços, plicas ou barras
+		valor := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(p.valor)
+		partes = append(partes, fmt.Sprintf("%s='%s'", p.chave, valor))
+	}
+	return strings.Join(partes, " ")
+}
+
+// Abrir cria o pool de ligações ao banco indicado, com os limites configurados, sem se ligar
+func Abrir(cfg *config.Configuracoes, banco string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", DSN(cfg, banco))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir banco %s: %v", banco, err)
+	}
+	db.SetMaxOpenConns(cfg.DB_ConexoesMaximas)
+	db.SetMaxIdleConns(cfg.DB_ConexoesOciosas)
+	db.SetConnMaxLifetime(cfg.DB_VidaMaximaConexao)
+	db.SetConnMaxIdleTime(cfg.DB_OciosidadeMaxima)
+	return db, nil
+}
+
+// Conectar abre o pool do banco da aplicação (DB_NAME) e espera que responda
+func Conectar(cfg *config.Configuracoes) (*sql.DB, error) {
+	return conectarBanco(cfg, cfg.DB_Nome)
+}
+
+// conectarBanco abre o pool e testa a ligação, repetindo com espera crescente enquanto o
+// PostgreSQL não responde (ex.: servidor a arrancar ao mesmo tempo que o backend)
+func conectarBanco(cfg *config.Configuracoes, banco string) (*sql.DB, error) {
+	db, err := Abrir(cfg, banco)
+	if err != nil {
+		return nil, err
+	}
+
+	tentativas := cfg.DB_TentativasConexao
+	if tentativas < 1 {
+		tentativas = 1
+	}
+	espera := cfg.DB_EsperaTentativa
+	if espera <= 0 {
+		espera = time.Second
+	}
+
+	for tentativa := 1; ; tentativa++ {
+		err = pingar(db, cfg.DB_TimeoutConexao)
+		if err == nil {
+			return db, nil
+		}
+		if tentativa >= tentativas {
+			db.Close()
+			return nil, fmt.Errorf("erro ao conectar ao banco %s em %s:%s (%d tentativas): %v",
+				banco, cfg.DB_Host, cfg.DB_Porta, tentativas, err)
+		}
+
+		log.Printf("⚠️  Banco %s indisponível (tentativa %d/%d, nova tentativa em %v): %v",
+			banco, tentativa, tentativas, espera, err)
+		time.Sleep(espera)
+		espera *= 2
+		if espera > esperaMaximaTentativa {
+			espera = esperaMaximaTentativa
+		}
+	}
+}
+
+// pingar testa a ligação com limite de tempo (0 = sem limite)
+func pingar(db *sql.DB, limite time.Duration) error {
+	ctx := context.Background()
+	if limite > 0 {
+		var cancelar context.CancelFunc
+		ctx, cancelar = context.WithTimeout(ctx, limite)
+		defer cancelar()
+	}
+	return db.PingContext(ctx)
+}
+
+// EstatisticasPool resume o uso do pool de ligações (sql.DBStats)
+type EstatisticasPool struct {
+	ConexoesMaximas   int     `json:"conexoes_maximas"` // 0 = sem limite
+	ConexoesAbertas   int     `json:"conexoes_abertas"`
+	EmUso             int     `json:"em_uso"`
+	Ociosas           int     `json:"ociosas"`
+	Esperas           int64   `json:"esperas"` // Pedidos que esperaram por uma ligação livre
+	EsperaTotalMs     float64 `json:"espera_total_ms"`
+	FechadasOciosas   int64   `json:"fechadas_ociosas"`
+	FechadasPorIdade  int64   `json:"fechadas_por_idade"`
+	FechadasPorInacao int64   `json:"fechadas_por_inacao"`
+}
+
+// EstadoBanco é o resultado da verificação de saúde do banco
+type EstadoBanco struct {
+	Disponivel    bool             `json:"disponivel"`
+	LatenciaMs    float64          `json:"latencia_ms"`
+	Erro          string           `json:"erro,omitempty"`
+	VersaoEsquema int              `json:"versao_esquema"`
+	Pool          EstatisticasPool `json:"pool"`
+}
+
+// Estatisticas lê as estatísticas do pool
+func Estatisticas(db *sql.DB) EstatisticasPool {
+	stats := db.Stats()
+	return EstatisticasPool{
+		ConexoesMaximas:   stats.MaxOpenConnections,
+		ConexoesAbertas:   stats.OpenConnections,
+		EmUso:             stats.InUse,
+		Ociosas:           stats.Idle,
+		Esperas:           stats.WaitCount,
+		EsperaTotalMs:     float64(stats.WaitDuration) / float64(time.Millisecond),
+		FechadasOciosas:   stats.MaxIdleClosed,
+		FechadasPorIdade:  stats.MaxLifetimeClosed,
+		FechadasPorInacao: stats.MaxIdleTimeClosed,
+	}
+}
+
+// VerificarSaude testa o banco com limite de tempo e junta a versão do esquema e o estado do pool
+func VerificarSaude(db *sql.DB, limite time.Duration) EstadoBanco {
+	estado := EstadoBanco{Pool: Estatisticas(db)}
+
+	inicio := time.Now()
+	err := pingar(db, limite)
+	estado.LatenciaMs = float64(time.Since(inicio)) / float64(time.Millisecond)
+	if err != nil {
+		estado.Erro = err.Error()
+		return estado
+	}
+	estado.Disponivel = true
+
+	if versao, err := VersaoEsquema(db); err == nil {
+		estado.VersaoEsquema = versao
+	}
+	return estado
+}
+
+// HoraLocal reinterpreta como hora local um TIMESTAMP lido do banco. As colunas guardam o relógio
+// de parede local sem fuso e o driver devolve-o como se fosse UTC.
+func HoraLocal(instante time.Time) time.Time {
+	return time.Date(instante.Year(), instante.Month(), instante.Day(),
+		instante.Hour(), instante.Minute(), instante.Second(), instante.Nanosecond(), time.Local)
+}
@@ -0,0 +1,8 @@
+//go:build sqlite
+
+package database
+
+// Driver SQLite em Go puro (sem cgo, versão fixada no go.mod), só nos binários do modo embutido:
+//
+//	go build -tags sqlite
+import _ "modernc.org/sqlite"
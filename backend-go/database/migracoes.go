@@ -0,0 +1,342 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// As migrações são ficheiros migracoes/NNNN_nome.up.sql e NNNN_nome.down.sql
+//
+//go:embed migracoes/*.sql
+var arquivosMigracoes embed.FS
+
+// chaveBloqueioMigracoes identifica o advisory lock que impede dois processos de migrar ao mesmo tempo
+const chaveBloqueioMigracoes int64 = 0x45445046616c6861 // "EDPFalha"
+
+// Migracao é uma alteração numerada do esquema
+type Migracao struct {
+	Versao   int
+	Nome     string
+	Subir    string
+	Descer   string
+	Checksum string // sha256 do SQL de subida, para detetar ficheiros alterados depois de aplicados
+}
+
+// EstadoMigracao indica se uma migração foi aplicada
+type EstadoMigracao struct {
+	Versao     int        `json:"versao"`
+	Nome       string     `json:"nome"`
+	Aplicada   bool       `json:"aplicada"`
+	AplicadaEm *time.Time `json:"aplicada_em,omitempty"`
+	Alterada   bool       `json:"alterada"` // O SQL mudou depois de a migração ser aplicada
+}
+
+// CarregarMigracoes lê as migrações embutidas, por versão
+func CarregarMigracoes() ([]Migracao, error) {
+	arquivos, err := fs.Glob(arquivosMigracoes, "migracoes/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	porVersao := make(map[int]*Migracao)
+	for _, arquivo := range arquivos {
+		base := strings.TrimPrefix(arquivo, "migracoes/")
+		var direcao string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direcao = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direcao = "down"
+		default:
+			return nil, fmt.Errorf("migração %s: use NNNN_nome.up.sql ou NNNN_nome.down.sql", base)
+		}
+		partes := strings.SplitN(strings.TrimSuffix(base, "."+direcao+".sql"), "_", 2)
+		versao, err := strconv.Atoi(partes[0])
+		if err != nil || versao <= 0 || len(partes) != 2 {
+			return nil, fmt.Errorf("migração %s: nome sem versão numérica (NNNN_nome)", base)
+		}
+
+		conteudo, err := arquivosMigracoes.ReadFile(arquivo)
+		if err != nil {
+			return nil, err
+		}
+
+		m := porVersao[versao]
+		if m == nil {
+			m = &Migracao{Versao: versao, Nome: partes[1]}
+			porVersao[versao] = m
+		}
+		if m.Nome != partes[1] {
+			return nil, fmt.Errorf("migração %d com nomes diferentes: %s e %s", versao, m.Nome, partes[1])
+		}
+		if direcao == "up" {
+			m.Subir = string(conteudo)
+			soma := sha256.Sum256(conteudo)
+			m.Checksum = hex.EncodeToString(soma[:])
+		} else {
+			m.Descer = string(conteudo)
+		}
+	}
+
+	migracoes := make([]Migracao, 0, len(porVersao))
+	for _, m := range porVersao {
+		if strings.TrimSpace(m.Subir) == "" {
+			return nil, fmt.Errorf("migração %04d_%s sem subida (.up.sql)", m.Versao, m.Nome)
+		}
+		if strings.TrimSpace(m.Descer) == "" {
+			return nil, fmt.Errorf("migração %04d_%s sem descida (.down.sql)", m.Versao, m.Nome)
+		}
+		migracoes = append(migracoes, *m)
+	}
+	sort.Slice(migracoes, func(i, j int) bool { return migracoes[i].Versao < migracoes[j].Versao })
+	return migracoes, nil
+}
+
+// Migrar aplica, por ordem, as migrações pendentes e devolve quantas aplicou
+func Migrar(db *sql.DB) (int, error) {
+	migracoes, err := CarregarMigracoes()
+	if err != nil {
+		return 0, err
+	}
+
+	aplicadas := 0
+	err = comBloqueio(db, func() error {
+		estado, err := lerMigracoesAplicadas(db)
+		if err != nil {
+			return err
+		}
+		// Numa base anterior às migrações, o esquema base (idempotente) só cria o que falta
+		if len(estado) == 0 && existeTabela(db, "ocorrencias_falhas") {
+			fmt.Println("  📋 Instalação anterior às migrações: o esquema base completa as tabelas existentes")
+		}
+		for _, m := range migracoes {
+			if _, existe := estado[m.Versao]; existe {
+				continue
+			}
+			fmt.Printf("  ⬆️  Migração %04d_%s...\n", m.Versao, m.Nome)
+			if err := aplicarMigracao(db, m); err != nil {
+				return err
+			}
+			aplicadas++
+		}
+		return nil
+	})
+	if err != nil {
+		return aplicadas, err
+	}
+
+	if aplicadas == 0 {
+		fmt.Println("  ✅ Esquema atualizado (sem migrações pendentes)")
+	} else {
+		fmt.Printf("  ✅ %d migração(ões) aplicada(s)\n", aplicadas)
+	}
+	return aplicadas, nil
+}
+
+// ReverterMigracoes desfaz as últimas migrações aplicadas (passos > 0) e devolve quantas reverteu
+func ReverterMigracoes(db *sql.DB, passos int) (int, error) {
+	if passos <= 0 {
+		return 0, fmt.Errorf("número de migrações a reverter deve ser positivo")
+	}
+
+	migracoes, err := CarregarMigracoes()
+	if err != nil {
+		return 0, err
+	}
+	porVersao := make(map[int]Migracao, len(migracoes))
+	for _, m := range migracoes {
+		porVersao[m.Versao] = m
+	}
+
+	revertidas := 0
+	err = comBloqueio(db, func() error {
+		estado, err := lerMigracoesAplicadas(db)
+		if err != nil {
+			return err
+		}
+		versoes := make([]int, 0, len(estado))
+		for versao := range estado {
+			versoes = append(versoes, versao)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versoes)))
+
+		for _, versao := range versoes {
+			if revertidas == passos {
+				break
+			}
+			m, existe := porVersao[versao]
+			if !existe {
+				return fmt.Errorf("migração %d aplicada mas desconhecida por esta versão do programa", versao)
+			}
+			fmt.Printf("  ⬇️  Revertendo migração %04d_%s...\n", m.Versao, m.Nome)
+			if err := reverterMigracao(db, m); err != nil {
+				return err
+			}
+			revertidas++
+		}
+		return nil
+	})
+	return revertidas, err
+}
+
+// EstadoMigracoes lista as migrações conhecidas e as aplicadas na base (incluindo as desconhecidas)
+func EstadoMigracoes(db *sql.DB) ([]EstadoMigracao, error) {
+	migracoes, err := CarregarMigracoes()
+	if err != nil {
+		return nil, err
+	}
+	if err := criarTabelaMigracoes(db); err != nil {
+		return nil, err
+	}
+	aplicadas, err := lerMigracoesAplicadas(db)
+	if err != nil {
+		return nil, err
+	}
+
+	estados := make([]EstadoMigracao, 0, len(migracoes))
+	for _, m := range migracoes {
+		e := EstadoMigracao{Versao: m.Versao, Nome: m.Nome}
+		if a, existe := aplicadas[m.Versao]; existe {
+			e.Aplicada = true
+			e.AplicadaEm = &a.aplicadaEm
+			e.Alterada = a.checksum != m.Checksum
+			delete(aplicadas, m.Versao)
+		}
+		estados = append(estados, e)
+	}
+	for versao, a := range aplicadas {
+		aplicadaEm := a.aplicadaEm
+		estados = append(estados, EstadoMigracao{Versao: versao, Nome: a.nome, Aplicada: true, AplicadaEm: &aplicadaEm})
+	}
+	sort.Slice(estados, func(i, j int) bool { return estados[i].Versao < estados[j].Versao })
+	return estados, nil
+}
+
+// VersaoEsquema devolve a última migração aplicada (0 = nenhuma); no SQLite, a do esquema embutido
+func VersaoEsquema(db *sql.DB) (int, error) {
+	if EhSQLite(db) {
+		return versaoEsquemaSQLite(db)
+	}
+	if !existeTabela(db, "schema_migrations") {
+		return 0, nil
+	}
+	var versao int
+	err := db.QueryRow("SELECT COALESCE(MAX(versao), 0) FROM schema_migrations").Scan(&versao)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao ler versão do esquema: %v", err)
+	}
+	return versao, nil
+}
+
+// comBloqueio executa a função com o advisory lock das migrações. O lock é de sessão, por isso
+// fica preso a uma ligação dedicada até ao fim.
+func comBloqueio(db *sql.DB, f func() error) error {
+	ctx := context.Background()
+	conexao, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao obter ligação para as migrações: %v", err)
+	}
+	defer conexao.Close()
+
+	var obtido bool
+	if err := conexao.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", chaveBloqueioMigracoes).Scan(&obtido); err != nil {
+		return fmt.Errorf("erro ao bloquear migrações: %v", err)
+	}
+	if !obtido {
+		fmt.Println("  ⏳ Outro processo está a aplicar migrações; aguardando...")
+		if _, err := conexao.ExecContext(ctx, "SELECT pg_advisory_lock($1)", chaveBloqueioMigracoes); err != nil {
+			return fmt.Errorf("erro ao bloquear migrações: %v", err)
+		}
+	}
+	defer conexao.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", chaveBloqueioMigracoes)
+
+	if err := criarTabelaMigracoes(db); err != nil {
+		return err
+	}
+	return f()
+}
+
+func criarTabelaMigracoes(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		versao INTEGER PRIMARY KEY,
+		nome VARCHAR(100) NOT NULL,
+		checksum VARCHAR(64) NOT NULL DEFAULT '',
+		aplicada_em TIMESTAMP NOT NULL DEFAULT NOW()
+	)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela schema_migrations: %v", err)
+	}
+	return nil
+}
+
+type migracaoAplicada struct {
+	nome       string
+	checksum   string
+	aplicadaEm time.Time
+}
+
+func lerMigracoesAplicadas(db *sql.DB) (map[int]migracaoAplicada, error) {
+	rows, err := db.Query("SELECT versao, nome, checksum, aplicada_em FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	aplicadas := make(map[int]migracaoAplicada)
+	for rows.Next() {
+		var versao int
+		var a migracaoAplicada
+		if err := rows.Scan(&versao, &a.nome, &a.checksum, &a.aplicadaEm); err != nil {
+			return nil, fmt.Errorf("erro ao ler schema_migrations: %v", err)
+		}
+		a.aplicadaEm = HoraLocal(a.aplicadaEm)
+		aplicadas[versao] = a
+	}
+	return aplicadas, rows.Err()
+}
+
+// aplicarMigracao sobe uma migração numa transação com o registo em schema_migrations
+func aplicarMigracao(db *sql.DB, m Migracao) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.Subir); err != nil {
+		return fmt.Errorf("erro na migração %04d_%s: %v", m.Versao, m.Nome, err)
+	}
+	_, err = tx.Exec("INSERT INTO schema_migrations (versao, nome, checksum) VALUES ($1, $2, $3)",
+		m.Versao, m.Nome, m.Checksum)
+	if err != nil {
+		return fmt.Errorf("erro ao registar migração %04d_%s: %v", m.Versao, m.Nome, err)
+	}
+	return tx.Commit()
+}
+
+// reverterMigracao desce uma migração e remove o seu registo, na mesma transação
+func reverterMigracao(db *sql.DB, m Migracao) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.Descer); err != nil {
+		return fmt.Errorf("erro ao reverter migração %04d_%s: %v", m.Versao, m.Nome, err)
+	}
+	if _, err := tx.Exec("DELETE FROM schema_migrations WHERE versao = $1", m.Versao); err != nil {
+		return fmt.Errorf("erro ao registar reversão de %04d_%s: %v", m.Versao, m.Nome, err)
+	}
+	return tx.Commit()
+}
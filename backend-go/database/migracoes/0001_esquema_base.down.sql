@@ -0,0 +1,37 @@
+-- Remove todo o esquema base (e os dados). As partições de series_brutas caem com a tabela.
+DROP TABLE IF EXISTS
+	ordens_trabalho_ocorrencias,
+	pecas_ordem_trabalho,
+	ordens_trabalho,
+	regras_ordem_trabalho,
+	escalonamentos_ocorrencias,
+	niveis_escalonamento,
+	politicas_escalonamento,
+	substituicoes_plantao,
+	rotacoes_plantao,
+	membros_equipa,
+	equipas,
+	envios_notificacao,
+	destinos_notificacao,
+	regras_notificacao,
+	notas_operador,
+	relatorios_gerados,
+	eclusagens,
+	mapeamentos_estado_eclusa,
+	series_agregacao_estado,
+	series_1h,
+	series_15m,
+	series_1m,
+	series_brutas,
+	tags_analogicas,
+	registros_soe,
+	transicoes_ocorrencias,
+	relacoes_supressao,
+	ocorrencias_falhas,
+	avalanches_alarmes,
+	definicoes_falhas,
+	setores,
+	eclusas
+CASCADE;
+
+DROP FUNCTION IF EXISTS soe_somente_insercao();
@@ -0,0 +1,478 @@
+-- Esquema base: as tabelas que existiam antes do controlo de versões. Corre também nas instalações
+-- anteriores às migrações (que só têm eclusas, setores, definições e ocorrências com as colunas
+-- originais), por isso tudo é idempotente: as tabelas e os índices em falta são criados e as colunas
+-- acrescentadas depois da criação das tabelas entram com ADD COLUMN IF NOT EXISTS, no fim, pela
+-- mesma ordem nos dois casos.
+
+CREATE TABLE IF NOT EXISTS eclusas (
+	id SERIAL PRIMARY KEY,
+	codigo VARCHAR(20) UNIQUE NOT NULL,
+	nome VARCHAR(100) NOT NULL,
+	localizacao VARCHAR(200),
+	ativa BOOLEAN DEFAULT true,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS setores (
+	id SERIAL PRIMARY KEY,
+	codigo VARCHAR(50) UNIQUE NOT NULL,
+	nome VARCHAR(100) NOT NULL,
+	cor_tema VARCHAR(50),
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Definições de falhas/eventos, com o modelo de severidade
+CREATE TABLE IF NOT EXISTS definicoes_falhas (
+	id SERIAL PRIMARY KEY,
+	eclusa_id INTEGER REFERENCES eclusas(id),
+	setor_id INTEGER REFERENCES setores(id),
+	codigo VARCHAR(50) NOT NULL,
+	tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('FALHA', 'EVENTO')),
+	descricao TEXT NOT NULL,
+	prioridade VARCHAR(20) NOT NULL DEFAULT 'MEDIA' CHECK (prioridade IN ('ALTA', 'MEDIA', 'BAIXA')),
+	point_index INTEGER NOT NULL,
+	classe_mensagem VARCHAR(100),
+	word_index INTEGER,
+	bit_index INTEGER,
+	ativa BOOLEAN DEFAULT true,
+	created_at TIMESTAMP DEFAULT NOW(),
+	UNIQUE(eclusa_id, point_index)
+);
+
+ALTER TABLE definicoes_falhas
+	ADD COLUMN IF NOT EXISTS criticidade SMALLINT NOT NULL DEFAULT 3 CHECK (criticidade BETWEEN 1 AND 5),
+	ADD COLUMN IF NOT EXISTS relacionada_seguranca BOOLEAN NOT NULL DEFAULT false,
+	ADD COLUMN IF NOT EXISTS tempo_resposta_minutos INTEGER CHECK (tempo_resposta_minutos > 0),
+	ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_definicoes_eclusa_setor ON definicoes_falhas(eclusa_id, setor_id);
+CREATE INDEX IF NOT EXISTS idx_definicoes_prioridade ON definicoes_falhas(prioridade, criticidade);
+CREATE INDEX IF NOT EXISTS idx_definicoes_descricao_fts ON definicoes_falhas USING GIN (to_tsvector('portuguese', descricao));
+CREATE INDEX IF NOT EXISTS idx_definicoes_codigo ON definicoes_falhas(codigo);
+
+-- Avalanches de alarmes e relações de supressão (pai → filha)
+CREATE TABLE IF NOT EXISTS avalanches_alarmes (
+	id BIGSERIAL PRIMARY KEY,
+	eclusa_id INTEGER REFERENCES eclusas(id),
+	timestamp_inicio TIMESTAMP NOT NULL,
+	timestamp_fim TIMESTAMP,
+	total_alarmes INTEGER NOT NULL DEFAULT 0,
+	first_out_ocorrencia_id BIGINT,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS relacoes_supressao (
+	id SERIAL PRIMARY KEY,
+	definicao_pai_id INTEGER NOT NULL REFERENCES definicoes_falhas(id) ON DELETE CASCADE,
+	definicao_filha_id INTEGER NOT NULL REFERENCES definicoes_falhas(id) ON DELETE CASCADE,
+	ativa BOOLEAN DEFAULT true,
+	created_at TIMESTAMP DEFAULT NOW(),
+	UNIQUE(definicao_pai_id, definicao_filha_id),
+	CHECK (definicao_pai_id <> definicao_filha_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_relacoes_supressao_filha ON relacoes_supressao(definicao_filha_id);
+
+-- Ciclos de eclusagem
+CREATE TABLE IF NOT EXISTS eclusagens (
+	id BIGSERIAL PRIMARY KEY,
+	eclusa_id INTEGER NOT NULL REFERENCES eclusas(id),
+	direcao VARCHAR(10) CHECK (direcao IN ('SUBIDA', 'DESCIDA')),
+	status VARCHAR(15) NOT NULL DEFAULT 'EM_CURSO' CHECK (status IN ('EM_CURSO', 'CONCLUIDA', 'ABORTADA', 'TIMEOUT')),
+	fase VARCHAR(15) NOT NULL CHECK (fase IN ('NIVELAMENTO', 'ABERTURA', 'FIM')),
+	timestamp_inicio TIMESTAMP(3) NOT NULL,
+	timestamp_inicio_nivelamento TIMESTAMP(3),
+	timestamp_fim_nivelamento TIMESTAMP(3),
+	timestamp_fim TIMESTAMP(3),
+	duracao_preparacao_segundos DOUBLE PRECISION,
+	duracao_nivelamento_segundos DOUBLE PRECISION,
+	duracao_abertura_segundos DOUBLE PRECISION,
+	duracao_total_segundos DOUBLE PRECISION,
+	motivo TEXT,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_eclusagens_eclusa_inicio ON eclusagens(eclusa_id, timestamp_inicio);
+
+-- Ocorrências, com first-out/avalanche/supressão, o ciclo de eclusagem e o reconhecimento
+-- (que não altera o status: a ocorrência continua ATIVA até o PLC a resolver)
+CREATE TABLE IF NOT EXISTS ocorrencias_falhas (
+	id BIGSERIAL PRIMARY KEY,
+	definicao_id INTEGER REFERENCES definicoes_falhas(id),
+	status VARCHAR(20) NOT NULL DEFAULT 'ATIVO' CHECK (status IN ('ATIVO', 'RESOLVIDO', 'EM_ANALISE')),
+	timestamp_inicio TIMESTAMP NOT NULL DEFAULT NOW(),
+	timestamp_fim TIMESTAMP,
+	dados_contexto JSONB,
+	resolvido_por VARCHAR(100),
+	observacoes TEXT,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+ALTER TABLE ocorrencias_falhas
+	ADD COLUMN IF NOT EXISTS first_out BOOLEAN NOT NULL DEFAULT false,
+	ADD COLUMN IF NOT EXISTS grupo_first_out_id BIGINT,
+	ADD COLUMN IF NOT EXISTS avalanche_id BIGINT REFERENCES avalanches_alarmes(id),
+	ADD COLUMN IF NOT EXISTS suprimida_por BIGINT REFERENCES ocorrencias_falhas(id),
+	ADD COLUMN IF NOT EXISTS eclusagem_id BIGINT REFERENCES eclusagens(id),
+	ADD COLUMN IF NOT EXISTS reconhecida_por VARCHAR(100),
+	ADD COLUMN IF NOT EXISTS reconhecida_em TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_timestamp ON ocorrencias_falhas(timestamp_inicio DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_status ON ocorrencias_falhas(status);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_suprimida_por ON ocorrencias_falhas(suprimida_por);
+-- Paginação por cursor (timestamp_inicio, id) e intervalos de datas
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_inicio_id ON ocorrencias_falhas(timestamp_inicio DESC, id DESC);
+-- Histórico de uma definição/código específico
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_definicao_inicio ON ocorrencias_falhas(definicao_id, timestamp_inicio DESC);
+-- Filtro por status dentro de um período
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_status_inicio ON ocorrencias_falhas(status, timestamp_inicio DESC);
+-- Busca da ocorrência ativa de uma definição pelo processador PLC
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_definicao_ativa ON ocorrencias_falhas(definicao_id) WHERE status = 'ATIVO';
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_eclusagem ON ocorrencias_falhas(eclusagem_id);
+
+-- Mudanças de estado de cada ocorrência
+CREATE TABLE IF NOT EXISTS transicoes_ocorrencias (
+	id BIGSERIAL PRIMARY KEY,
+	ocorrencia_id BIGINT NOT NULL REFERENCES ocorrencias_falhas(id) ON DELETE CASCADE,
+	status_anterior VARCHAR(20),
+	status_novo VARCHAR(20) NOT NULL,
+	timestamp TIMESTAMP NOT NULL DEFAULT NOW(),
+	origem VARCHAR(100) NOT NULL,
+	observacao TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_transicoes_ocorrencia ON transicoes_ocorrencias(ocorrencia_id, timestamp);
+
+-- Sequence-of-events das mudanças de bits; nunca é alterado nem apagado
+CREATE TABLE IF NOT EXISTS registros_soe (
+	id BIGSERIAL PRIMARY KEY,
+	sequencia_frame BIGINT NOT NULL,
+	ordem_no_frame INTEGER NOT NULL,
+	origem VARCHAR(100),
+	eclusa_id INTEGER REFERENCES eclusas(id),
+	definicao_id INTEGER REFERENCES definicoes_falhas(id),
+	word_index INTEGER NOT NULL,
+	bit_index SMALLINT NOT NULL,
+	valor_antigo BOOLEAN NOT NULL,
+	valor_novo BOOLEAN NOT NULL,
+	leitura_inicial BOOLEAN NOT NULL DEFAULT false,
+	timestamp_plc TIMESTAMP(3),
+	timestamp_recebimento TIMESTAMP(6) NOT NULL,
+	UNIQUE(sequencia_frame, ordem_no_frame)
+);
+
+CREATE OR REPLACE FUNCTION soe_somente_insercao() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'registros_soe é append-only: % não permitido', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_soe_somente_insercao ON registros_soe;
+CREATE TRIGGER trg_soe_somente_insercao
+BEFORE UPDATE OR DELETE ON registros_soe
+FOR EACH ROW EXECUTE PROCEDURE soe_somente_insercao();
+
+CREATE INDEX IF NOT EXISTS idx_soe_recebimento ON registros_soe(timestamp_recebimento, sequencia_frame, ordem_no_frame);
+CREATE INDEX IF NOT EXISTS idx_soe_eclusa_recebimento ON registros_soe(eclusa_id, timestamp_recebimento);
+CREATE INDEX IF NOT EXISTS idx_soe_definicao_recebimento ON registros_soe(definicao_id, timestamp_recebimento);
+
+-- Séries analógicas: as partições diárias de series_brutas (series_brutas_AAAAMMDD) são criadas
+-- pelo gravador; series_1m/15m/1h são os agregados contínuos (min/max/média por intervalo)
+CREATE TABLE IF NOT EXISTS tags_analogicas (
+	id SERIAL PRIMARY KEY,
+	tag VARCHAR(100) UNIQUE NOT NULL,
+	eclusa_id INTEGER REFERENCES eclusas(id),
+	descricao TEXT,
+	unidade VARCHAR(20),
+	word_index INTEGER NOT NULL,
+	tipo_dado VARCHAR(10) NOT NULL DEFAULT 'INT16' CHECK (tipo_dado IN ('INT16', 'UINT16', 'INT32', 'REAL')),
+	escala DOUBLE PRECISION NOT NULL DEFAULT 1,
+	deslocamento DOUBLE PRECISION NOT NULL DEFAULT 0,
+	banda_morta DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (banda_morta >= 0),
+	intervalo_maximo_segundos INTEGER NOT NULL DEFAULT 60 CHECK (intervalo_maximo_segundos > 0),
+	ativa BOOLEAN DEFAULT true,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS series_brutas (
+	tag_id INTEGER NOT NULL REFERENCES tags_analogicas(id) ON DELETE CASCADE,
+	timestamp TIMESTAMP(3) NOT NULL,
+	valor DOUBLE PRECISION NOT NULL,
+	PRIMARY KEY (tag_id, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+CREATE TABLE IF NOT EXISTS series_1m (
+	tag_id INTEGER NOT NULL REFERENCES tags_analogicas(id) ON DELETE CASCADE,
+	bucket TIMESTAMP NOT NULL,
+	minimo DOUBLE PRECISION NOT NULL,
+	maximo DOUBLE PRECISION NOT NULL,
+	media DOUBLE PRECISION NOT NULL,
+	amostras INTEGER NOT NULL,
+	PRIMARY KEY (tag_id, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS series_15m (
+	tag_id INTEGER NOT NULL REFERENCES tags_analogicas(id) ON DELETE CASCADE,
+	bucket TIMESTAMP NOT NULL,
+	minimo DOUBLE PRECISION NOT NULL,
+	maximo DOUBLE PRECISION NOT NULL,
+	media DOUBLE PRECISION NOT NULL,
+	amostras INTEGER NOT NULL,
+	PRIMARY KEY (tag_id, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS series_1h (
+	tag_id INTEGER NOT NULL REFERENCES tags_analogicas(id) ON DELETE CASCADE,
+	bucket TIMESTAMP NOT NULL,
+	minimo DOUBLE PRECISION NOT NULL,
+	maximo DOUBLE PRECISION NOT NULL,
+	media DOUBLE PRECISION NOT NULL,
+	amostras INTEGER NOT NULL,
+	PRIMARY KEY (tag_id, bucket)
+);
+
+-- Até onde cada agregado já foi calculado
+CREATE TABLE IF NOT EXISTS series_agregacao_estado (
+	resolucao VARCHAR(5) PRIMARY KEY,
+	processado_ate TIMESTAMP NOT NULL
+);
+
+-- Que sinais do PLC formam o EstadoEclusa: os campos booleanos vêm de bits (definições) e o nível
+-- de água de uma tag analógica; vários bits no mesmo campo combinam-se em OU
+CREATE TABLE IF NOT EXISTS mapeamentos_estado_eclusa (
+	id SERIAL PRIMARY KEY,
+	eclusa_id INTEGER NOT NULL REFERENCES eclusas(id) ON DELETE CASCADE,
+	campo VARCHAR(30) NOT NULL CHECK (campo IN ('enchimento_ativo', 'esvaziamento_ativo',
+		'porta_jusante_aberta', 'porta_montante_aberta', 'nivel_agua')),
+	definicao_id INTEGER REFERENCES definicoes_falhas(id) ON DELETE CASCADE,
+	tag_analogica_id INTEGER REFERENCES tags_analogicas(id) ON DELETE CASCADE,
+	invertido BOOLEAN NOT NULL DEFAULT false,
+	ativo BOOLEAN NOT NULL DEFAULT true,
+	created_at TIMESTAMP DEFAULT NOW(),
+	CHECK ((definicao_id IS NULL) <> (tag_analogica_id IS NULL)),
+	CHECK ((campo = 'nivel_agua') = (tag_analogica_id IS NOT NULL)),
+	UNIQUE (eclusa_id, campo, definicao_id),
+	UNIQUE (eclusa_id, campo, tag_analogica_id)
+);
+
+-- Relatórios gerados e notas dos operadores
+CREATE TABLE IF NOT EXISTS relatorios_gerados (
+	id BIGSERIAL PRIMARY KEY,
+	tipo VARCHAR(10) NOT NULL CHECK (tipo IN ('TURNO', 'DIARIO', 'MENSAL')),
+	eclusa_id INTEGER NOT NULL REFERENCES eclusas(id),
+	periodo_inicio TIMESTAMP NOT NULL,
+	periodo_fim TIMESTAMP NOT NULL,
+	origem VARCHAR(10) NOT NULL DEFAULT 'AGENDADO' CHECK (origem IN ('AGENDADO', 'MANUAL')),
+	html TEXT NOT NULL,
+	pdf BYTEA NOT NULL,
+	resumo JSONB,
+	gerado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	UNIQUE(tipo, eclusa_id, periodo_inicio)
+);
+
+CREATE INDEX IF NOT EXISTS idx_relatorios_periodo ON relatorios_gerados(periodo_inicio DESC);
+
+CREATE TABLE IF NOT EXISTS notas_operador (
+	id BIGSERIAL PRIMARY KEY,
+	eclusa_id INTEGER NOT NULL REFERENCES eclusas(id),
+	autor VARCHAR(100) NOT NULL,
+	texto TEXT NOT NULL,
+	timestamp TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notas_operador_eclusa ON notas_operador(eclusa_id, timestamp);
+
+-- Regras de notificação, os seus destinos e o registo de envios
+CREATE TABLE IF NOT EXISTS regras_notificacao (
+	id SERIAL PRIMARY KEY,
+	nome VARCHAR(100) NOT NULL,
+	ativa BOOLEAN NOT NULL DEFAULT true,
+	eclusa_codigo VARCHAR(20),
+	setor_codigo VARCHAR(50),
+	prioridade VARCHAR(20) CHECK (prioridade IN ('ALTA', 'MEDIA', 'BAIXA')),
+	definicao_id INTEGER REFERENCES definicoes_falhas(id) ON DELETE CASCADE,
+	hora_inicio TIME,
+	hora_fim TIME,
+	dias_semana VARCHAR(20),
+	incluir_suprimidas BOOLEAN NOT NULL DEFAULT false,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS destinos_notificacao (
+	id SERIAL PRIMARY KEY,
+	regra_id INTEGER NOT NULL REFERENCES regras_notificacao(id) ON DELETE CASCADE,
+	canal VARCHAR(10) NOT NULL CHECK (canal IN ('EMAIL', 'WEBHOOK', 'SMS')),
+	endereco VARCHAR(500) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_destinos_notificacao_regra ON destinos_notificacao(regra_id);
+
+-- UNIQUE(ocorrencia_id, motivo, canal, endereco) garante um único envio por ocorrência e destino,
+-- mesmo que várias regras o incluam
+CREATE TABLE IF NOT EXISTS envios_notificacao (
+	id BIGSERIAL PRIMARY KEY,
+	ocorrencia_id BIGINT REFERENCES ocorrencias_falhas(id) ON DELETE CASCADE,
+	regra_id INTEGER REFERENCES regras_notificacao(id) ON DELETE SET NULL,
+	motivo VARCHAR(20) NOT NULL DEFAULT 'ATIVACAO',
+	canal VARCHAR(10) NOT NULL,
+	endereco VARCHAR(500) NOT NULL,
+	assunto TEXT NOT NULL,
+	mensagem TEXT NOT NULL,
+	dados JSONB,
+	status VARCHAR(10) NOT NULL DEFAULT 'PENDENTE' CHECK (status IN ('PENDENTE', 'ENVIADO', 'FALHOU')),
+	tentativas INTEGER NOT NULL DEFAULT 0,
+	proxima_tentativa TIMESTAMP NOT NULL DEFAULT NOW(),
+	ultimo_erro TEXT,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	enviado_em TIMESTAMP,
+	UNIQUE(ocorrencia_id, motivo, canal, endereco)
+);
+
+CREATE INDEX IF NOT EXISTS idx_envios_notificacao_pendentes ON envios_notificacao(proxima_tentativa) WHERE status = 'PENDENTE';
+CREATE INDEX IF NOT EXISTS idx_envios_notificacao_criado ON envios_notificacao(criado_em DESC);
+
+-- Equipas, escalas de plantão (membros guarda a ordem da rotação: ids de membros_equipa)
+-- e políticas de escalonamento
+CREATE TABLE IF NOT EXISTS equipas (
+	id SERIAL PRIMARY KEY,
+	codigo VARCHAR(20) UNIQUE NOT NULL,
+	nome VARCHAR(100) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS membros_equipa (
+	id SERIAL PRIMARY KEY,
+	equipa_id INTEGER NOT NULL REFERENCES equipas(id) ON DELETE CASCADE,
+	nome VARCHAR(100) NOT NULL,
+	email VARCHAR(200),
+	telefone VARCHAR(30),
+	ativo BOOLEAN NOT NULL DEFAULT true,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_membros_equipa_equipa ON membros_equipa(equipa_id);
+
+CREATE TABLE IF NOT EXISTS rotacoes_plantao (
+	id SERIAL PRIMARY KEY,
+	equipa_id INTEGER NOT NULL REFERENCES equipas(id) ON DELETE CASCADE,
+	nome VARCHAR(100) NOT NULL,
+	inicio TIMESTAMP NOT NULL,
+	periodo_horas INTEGER NOT NULL CHECK (periodo_horas > 0),
+	membros INTEGER[] NOT NULL,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rotacoes_plantao_equipa ON rotacoes_plantao(equipa_id, inicio);
+
+CREATE TABLE IF NOT EXISTS substituicoes_plantao (
+	id SERIAL PRIMARY KEY,
+	equipa_id INTEGER NOT NULL REFERENCES equipas(id) ON DELETE CASCADE,
+	membro_id INTEGER NOT NULL REFERENCES membros_equipa(id) ON DELETE CASCADE,
+	inicio TIMESTAMP NOT NULL,
+	fim TIMESTAMP NOT NULL,
+	motivo VARCHAR(200),
+	created_at TIMESTAMP DEFAULT NOW(),
+	CHECK (fim > inicio)
+);
+
+CREATE INDEX IF NOT EXISTS idx_substituicoes_plantao_equipa ON substituicoes_plantao(equipa_id, inicio);
+
+CREATE TABLE IF NOT EXISTS politicas_escalonamento (
+	id SERIAL PRIMARY KEY,
+	nome VARCHAR(100) NOT NULL,
+	ativa BOOLEAN NOT NULL DEFAULT true,
+	equipa_id INTEGER NOT NULL REFERENCES equipas(id),
+	eclusa_codigo VARCHAR(20),
+	setor_codigo VARCHAR(50),
+	prioridade VARCHAR(20) CHECK (prioridade IN ('ALTA', 'MEDIA', 'BAIXA')),
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS niveis_escalonamento (
+	id SERIAL PRIMARY KEY,
+	politica_id INTEGER NOT NULL REFERENCES politicas_escalonamento(id) ON DELETE CASCADE,
+	nivel INTEGER NOT NULL,
+	espera_minutos INTEGER NOT NULL DEFAULT 0 CHECK (espera_minutos >= 0),
+	alvo VARCHAR(10) NOT NULL CHECK (alvo IN ('PLANTAO', 'EQUIPA', 'MEMBRO')),
+	equipa_id INTEGER REFERENCES equipas(id),
+	membro_id INTEGER REFERENCES membros_equipa(id) ON DELETE CASCADE,
+	canais VARCHAR(50) NOT NULL DEFAULT 'SMS,EMAIL',
+	UNIQUE(politica_id, nivel)
+);
+
+-- nivel_atual = último nível notificado; proximo_nivel_em = prazo para notificar o seguinte
+CREATE TABLE IF NOT EXISTS escalonamentos_ocorrencias (
+	ocorrencia_id BIGINT PRIMARY KEY REFERENCES ocorrencias_falhas(id) ON DELETE CASCADE,
+	politica_id INTEGER REFERENCES politicas_escalonamento(id) ON DELETE SET NULL,
+	nivel_atual INTEGER NOT NULL DEFAULT 0,
+	estado VARCHAR(12) NOT NULL DEFAULT 'ATIVO' CHECK (estado IN ('ATIVO', 'RECONHECIDO', 'RESOLVIDO', 'ESGOTADO')),
+	proximo_nivel_em TIMESTAMP,
+	iniciado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	atualizado_em TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_escalonamentos_pendentes ON escalonamentos_ocorrencias(proximo_nivel_em) WHERE estado = 'ATIVO';
+
+-- Ordens de trabalho, as suas peças e ocorrências e as regras de abertura.
+-- referencia_externa = número da ordem no CMMS; proxima_sincronizacao = próxima tentativa de envio
+CREATE TABLE IF NOT EXISTS regras_ordem_trabalho (
+	id SERIAL PRIMARY KEY,
+	nome VARCHAR(100) NOT NULL,
+	ativa BOOLEAN NOT NULL DEFAULT true,
+	eclusa_codigo VARCHAR(20),
+	setor_codigo VARCHAR(50),
+	prioridade VARCHAR(20) CHECK (prioridade IN ('ALTA', 'MEDIA', 'BAIXA')),
+	definicao_id INTEGER REFERENCES definicoes_falhas(id) ON DELETE CASCADE,
+	equipa_id INTEGER REFERENCES equipas(id),
+	prioridade_ordem VARCHAR(20) CHECK (prioridade_ordem IN ('ALTA', 'MEDIA', 'BAIXA')),
+	agrupar BOOLEAN NOT NULL DEFAULT true,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS ordens_trabalho (
+	id BIGSERIAL PRIMARY KEY,
+	titulo VARCHAR(200) NOT NULL,
+	descricao TEXT,
+	equipa_id INTEGER REFERENCES equipas(id),
+	prioridade VARCHAR(20) NOT NULL DEFAULT 'MEDIA' CHECK (prioridade IN ('ALTA', 'MEDIA', 'BAIXA')),
+	status VARCHAR(20) NOT NULL DEFAULT 'ABERTA' CHECK (status IN ('ABERTA', 'EM_EXECUCAO', 'CONCLUIDA', 'CANCELADA')),
+	origem VARCHAR(10) NOT NULL DEFAULT 'MANUAL' CHECK (origem IN ('MANUAL', 'REGRA')),
+	regra_id INTEGER REFERENCES regras_ordem_trabalho(id) ON DELETE SET NULL,
+	eclusa_codigo VARCHAR(20) NOT NULL,
+	setor_codigo VARCHAR(50) NOT NULL,
+	horas_mao_obra NUMERIC(8,2) NOT NULL DEFAULT 0 CHECK (horas_mao_obra >= 0),
+	criada_por VARCHAR(100),
+	criada_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	atualizada_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	fechada_em TIMESTAMP,
+	referencia_externa VARCHAR(100),
+	estado_cmms VARCHAR(15) NOT NULL DEFAULT 'NAO_APLICAVEL'
+		CHECK (estado_cmms IN ('NAO_APLICAVEL', 'PENDENTE', 'SINCRONIZADA', 'ERRO')),
+	erro_cmms TEXT,
+	tentativas_cmms INTEGER NOT NULL DEFAULT 0,
+	proxima_sincronizacao TIMESTAMP NOT NULL DEFAULT NOW(),
+	sincronizada_em TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ordens_trabalho_status ON ordens_trabalho(status);
+CREATE INDEX IF NOT EXISTS idx_ordens_trabalho_cmms ON ordens_trabalho(proxima_sincronizacao) WHERE estado_cmms IN ('PENDENTE', 'ERRO');
+
+CREATE TABLE IF NOT EXISTS pecas_ordem_trabalho (
+	id SERIAL PRIMARY KEY,
+	ordem_id BIGINT NOT NULL REFERENCES ordens_trabalho(id) ON DELETE CASCADE,
+	codigo VARCHAR(50),
+	descricao VARCHAR(200),
+	quantidade NUMERIC(10,3) NOT NULL CHECK (quantidade > 0),
+	unidade VARCHAR(10)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pecas_ordem_trabalho_ordem ON pecas_ordem_trabalho(ordem_id);
+
+-- Uma ordem pode cobrir várias ocorrências (ativações repetidas) e uma ocorrência várias ordens
+CREATE TABLE IF NOT EXISTS ordens_trabalho_ocorrencias (
+	ordem_id BIGINT NOT NULL REFERENCES ordens_trabalho(id) ON DELETE CASCADE,
+	ocorrencia_id BIGINT NOT NULL REFERENCES ocorrencias_falhas(id) ON DELETE CASCADE,
+	PRIMARY KEY (ordem_id, ocorrencia_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ordens_trabalho_ocorrencias_ocorrencia ON ordens_trabalho_ocorrencias(ocorrencia_id);
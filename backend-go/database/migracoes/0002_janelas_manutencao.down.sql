@@ -0,0 +1,3 @@
+ALTER TABLE ocorrencias_falhas DROP COLUMN IF EXISTS janela_manutencao_id;
+
+DROP TABLE IF EXISTS janelas_manutencao;
@@ -0,0 +1,25 @@
+-- Janelas de manutenção planeada e a ligação das ocorrências abertas dentro de uma janela.
+-- setor_id NULL = eclusa inteira; terminada_em = fim antecipado (a janela deixa de valer nesse instante);
+-- ocultar = as ocorrências da janela ficam fora da lista de ativas, das notificações e dos indicadores.
+-- IF NOT EXISTS: as instalações anteriores às migrações já podem ter a tabela.
+CREATE TABLE IF NOT EXISTS janelas_manutencao (
+	id SERIAL PRIMARY KEY,
+	eclusa_id INTEGER NOT NULL REFERENCES eclusas(id),
+	setor_id INTEGER REFERENCES setores(id),
+	inicio TIMESTAMP NOT NULL,
+	fim TIMESTAMP NOT NULL,
+	motivo TEXT NOT NULL,
+	responsavel VARCHAR(100) NOT NULL,
+	ocultar BOOLEAN NOT NULL DEFAULT true,
+	terminada_em TIMESTAMP,
+	terminada_por VARCHAR(100),
+	created_at TIMESTAMP DEFAULT NOW(),
+	CHECK (fim > inicio)
+);
+
+CREATE INDEX IF NOT EXISTS idx_janelas_manutencao_eclusa ON janelas_manutencao(eclusa_id, inicio);
+
+ALTER TABLE ocorrencias_falhas
+	ADD COLUMN IF NOT EXISTS janela_manutencao_id INTEGER REFERENCES janelas_manutencao(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_janela_manutencao ON ocorrencias_falhas(janela_manutencao_id);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS origens_sincronizacao;
+
+DROP INDEX IF EXISTS idx_ocorrencias_origem;
+
+ALTER TABLE ocorrencias_falhas
+	DROP COLUMN IF EXISTS origem_id,
+	DROP COLUMN IF EXISTS origem_eclusa;
+
+DROP TABLE IF EXISTS fila_sincronizacao;
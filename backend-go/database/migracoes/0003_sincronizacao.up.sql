@@ -0,0 +1,35 @@
+-- Sincronização das eclusas com a instância central.
+-- Na eclusa: fila_sincronizacao guarda, por ordem, as alterações de ocorrências e as transições
+-- ainda por enviar (enviado_em NULL). O estado da ocorrência é lido no envio, por isso os itens
+-- repetidos da mesma ocorrência num lote seguem uma só vez.
+-- No central: origem_eclusa/origem_id identificam a ocorrência na instalação de origem (upsert
+-- idempotente) e origens_sincronizacao guarda a última sequência aplicada de cada origem.
+CREATE TABLE IF NOT EXISTS fila_sincronizacao (
+	id BIGSERIAL PRIMARY KEY,
+	tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('OCORRENCIA', 'TRANSICAO')),
+	ocorrencia_id BIGINT NOT NULL,
+	status_anterior VARCHAR(20),
+	status_novo VARCHAR(20),
+	timestamp TIMESTAMP,
+	origem VARCHAR(50),
+	observacao TEXT,
+	tentativas INTEGER NOT NULL DEFAULT 0,
+	ultimo_erro TEXT,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	enviado_em TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_fila_sincronizacao_pendentes ON fila_sincronizacao(id) WHERE enviado_em IS NULL;
+
+ALTER TABLE ocorrencias_falhas
+	ADD COLUMN IF NOT EXISTS origem_eclusa VARCHAR(50),
+	ADD COLUMN IF NOT EXISTS origem_id BIGINT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ocorrencias_origem ON ocorrencias_falhas(origem_eclusa, origem_id);
+
+CREATE TABLE IF NOT EXISTS origens_sincronizacao (
+	origem VARCHAR(50) PRIMARY KEY,
+	ultima_sequencia BIGINT NOT NULL DEFAULT 0,
+	total_itens BIGINT NOT NULL DEFAULT 0,
+	ultimo_lote_em TIMESTAMP
+);
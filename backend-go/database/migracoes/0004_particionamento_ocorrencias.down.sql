@@ -0,0 +1,53 @@
+-- Volta a uma tabela única. As transições, envios, escalonamentos e ligações a ordens de trabalho
+-- de ocorrências já arquivadas são apagados para as chaves estrangeiras poderem ser recriadas.
+DROP TABLE IF EXISTS arquivos_ocorrencias;
+
+ALTER TABLE ocorrencias_falhas RENAME TO ocorrencias_falhas_particionada;
+ALTER INDEX ocorrencias_falhas_pkey RENAME TO ocorrencias_falhas_particionada_pkey;
+ALTER SEQUENCE ocorrencias_falhas_id_seq OWNED BY NONE;
+DROP INDEX IF EXISTS idx_ocorrencias_timestamp, idx_ocorrencias_status, idx_ocorrencias_suprimida_por,
+	idx_ocorrencias_inicio_id, idx_ocorrencias_definicao_inicio,
+	idx_ocorrencias_status_inicio, idx_ocorrencias_definicao_ativa, idx_ocorrencias_eclusagem,
+	idx_ocorrencias_janela_manutencao, idx_ocorrencias_origem;
+
+CREATE TABLE ocorrencias_falhas (
+	LIKE ocorrencias_falhas_particionada INCLUDING DEFAULTS INCLUDING CONSTRAINTS,
+	PRIMARY KEY (id),
+	FOREIGN KEY (definicao_id) REFERENCES definicoes_falhas(id),
+	FOREIGN KEY (avalanche_id) REFERENCES avalanches_alarmes(id),
+	FOREIGN KEY (eclusagem_id) REFERENCES eclusagens(id),
+	FOREIGN KEY (janela_manutencao_id) REFERENCES janelas_manutencao(id) ON DELETE SET NULL
+);
+
+INSERT INTO ocorrencias_falhas SELECT * FROM ocorrencias_falhas_particionada;
+DROP TABLE ocorrencias_falhas_particionada;
+ALTER SEQUENCE ocorrencias_falhas_id_seq OWNED BY ocorrencias_falhas.id;
+
+UPDATE ocorrencias_falhas SET suprimida_por = NULL
+WHERE suprimida_por IS NOT NULL AND suprimida_por NOT IN (SELECT id FROM ocorrencias_falhas);
+DELETE FROM transicoes_ocorrencias WHERE ocorrencia_id NOT IN (SELECT id FROM ocorrencias_falhas);
+DELETE FROM envios_notificacao WHERE ocorrencia_id NOT IN (SELECT id FROM ocorrencias_falhas);
+DELETE FROM escalonamentos_ocorrencias WHERE ocorrencia_id NOT IN (SELECT id FROM ocorrencias_falhas);
+DELETE FROM ordens_trabalho_ocorrencias WHERE ocorrencia_id NOT IN (SELECT id FROM ocorrencias_falhas);
+
+ALTER TABLE ocorrencias_falhas
+	ADD FOREIGN KEY (suprimida_por) REFERENCES ocorrencias_falhas(id);
+ALTER TABLE transicoes_ocorrencias
+	ADD FOREIGN KEY (ocorrencia_id) REFERENCES ocorrencias_falhas(id) ON DELETE CASCADE;
+ALTER TABLE envios_notificacao
+	ADD FOREIGN KEY (ocorrencia_id) REFERENCES ocorrencias_falhas(id) ON DELETE CASCADE;
+ALTER TABLE escalonamentos_ocorrencias
+	ADD FOREIGN KEY (ocorrencia_id) REFERENCES ocorrencias_falhas(id) ON DELETE CASCADE;
+ALTER TABLE ordens_trabalho_ocorrencias
+	ADD FOREIGN KEY (ocorrencia_id) REFERENCES ocorrencias_falhas(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_timestamp ON ocorrencias_falhas(timestamp_inicio DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_status ON ocorrencias_falhas(status);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_suprimida_por ON ocorrencias_falhas(suprimida_por);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_inicio_id ON ocorrencias_falhas(timestamp_inicio DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_definicao_inicio ON ocorrencias_falhas(definicao_id, timestamp_inicio DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_status_inicio ON ocorrencias_falhas(status, timestamp_inicio DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_definicao_ativa ON ocorrencias_falhas(definicao_id) WHERE status = 'ATIVO';
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_eclusagem ON ocorrencias_falhas(eclusagem_id);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_janela_manutencao ON ocorrencias_falhas(janela_manutencao_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ocorrencias_origem ON ocorrencias_falhas(origem_eclusa, origem_id);
@@ -0,0 +1,71 @@
+-- Particionamento mensal de ocorrencias_falhas por timestamp_inicio (ocorrencias_falhas_AAAAMM).
+-- A chave primária passa a (id, timestamp_inicio), como o PostgreSQL exige numa tabela
+-- particionada, e as chaves estrangeiras que apontavam para a ocorrência (transições, envios,
+-- escalonamentos, ordens de trabalho e suprimida_por) deixam de existir: a retenção apaga essas
+-- linhas explicitamente. As partições dos meses seguintes são criadas pelo serviço de retenção;
+-- ocorrencias_falhas_padrao recebe o que ficar fora delas (ex.: relógio do PLC errado).
+ALTER TABLE ocorrencias_falhas RENAME TO ocorrencias_falhas_antiga;
+ALTER INDEX ocorrencias_falhas_pkey RENAME TO ocorrencias_falhas_antiga_pkey;
+ALTER SEQUENCE ocorrencias_falhas_id_seq OWNED BY NONE;
+
+CREATE TABLE ocorrencias_falhas (
+	LIKE ocorrencias_falhas_antiga INCLUDING DEFAULTS INCLUDING CONSTRAINTS,
+	PRIMARY KEY (id, timestamp_inicio),
+	FOREIGN KEY (definicao_id) REFERENCES definicoes_falhas(id),
+	FOREIGN KEY (avalanche_id) REFERENCES avalanches_alarmes(id),
+	FOREIGN KEY (eclusagem_id) REFERENCES eclusagens(id),
+	FOREIGN KEY (janela_manutencao_id) REFERENCES janelas_manutencao(id) ON DELETE SET NULL
+) PARTITION BY RANGE (timestamp_inicio);
+
+CREATE TABLE ocorrencias_falhas_padrao PARTITION OF ocorrencias_falhas DEFAULT;
+
+-- Um mês por partição, do mês da ocorrência mais antiga até dois meses depois do atual
+DO $$
+DECLARE
+	mes DATE;
+	ultimo DATE := date_trunc('month', NOW() + INTERVAL '2 months');
+BEGIN
+	SELECT COALESCE(date_trunc('month', MIN(timestamp_inicio)), date_trunc('month', NOW()))
+		INTO mes FROM ocorrencias_falhas_antiga;
+	WHILE mes <= ultimo LOOP
+		EXECUTE format('CREATE TABLE %I PARTITION OF ocorrencias_falhas FOR VALUES FROM (%L) TO (%L)',
+			'ocorrencias_falhas_' || to_char(mes, 'YYYYMM'), mes, mes + INTERVAL '1 month');
+		mes := mes + INTERVAL '1 month';
+	END LOOP;
+END $$;
+
+INSERT INTO ocorrencias_falhas SELECT * FROM ocorrencias_falhas_antiga;
+
+-- CASCADE remove as chaves estrangeiras das outras tabelas para a tabela antiga
+DROP TABLE ocorrencias_falhas_antiga CASCADE;
+ALTER SEQUENCE ocorrencias_falhas_id_seq OWNED BY ocorrencias_falhas.id;
+
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_timestamp ON ocorrencias_falhas(timestamp_inicio DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_status ON ocorrencias_falhas(status);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_suprimida_por ON ocorrencias_falhas(suprimida_por);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_inicio_id ON ocorrencias_falhas(timestamp_inicio DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_definicao_inicio ON ocorrencias_falhas(definicao_id, timestamp_inicio DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_status_inicio ON ocorrencias_falhas(status, timestamp_inicio DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_definicao_ativa ON ocorrencias_falhas(definicao_id) WHERE status = 'ATIVO';
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_eclusagem ON ocorrencias_falhas(eclusagem_id);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_janela_manutencao ON ocorrencias_falhas(janela_manutencao_id);
+-- O início de uma ocorrência sincronizada não muda, por isso entra na chave sem alterar o upsert
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ocorrencias_origem ON ocorrencias_falhas(origem_eclusa, origem_id, timestamp_inicio);
+
+-- Ficheiros gerados pela retenção. Enquanto manter_ate não passar, o mês e tipo de um arquivo
+-- restaurado não voltam a ser arquivados.
+CREATE TABLE IF NOT EXISTS arquivos_ocorrencias (
+	id SERIAL PRIMARY KEY,
+	arquivo TEXT NOT NULL UNIQUE,
+	mes DATE NOT NULL,
+	tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('EVENTO', 'FALHA')),
+	ocorrencias INTEGER NOT NULL,
+	transicoes INTEGER NOT NULL,
+	tamanho_bytes BIGINT NOT NULL,
+	sha256 VARCHAR(64) NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	restaurado_em TIMESTAMP,
+	manter_ate TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_arquivos_ocorrencias_mes ON arquivos_ocorrencias(mes, tipo);
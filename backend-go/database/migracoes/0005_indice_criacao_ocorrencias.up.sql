@@ -0,0 +1,3 @@
+-- Os serviços de notificações, escalonamento e ordens de trabalho leem as ocorrências novas por
+-- (created_at, id), relendo alguns minutos para trás em cada ciclo.
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_criacao ON ocorrencias_falhas(created_at, id);
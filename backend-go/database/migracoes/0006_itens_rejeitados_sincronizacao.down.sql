@@ -0,0 +1,3 @@
+ALTER TABLE origens_sincronizacao DROP COLUMN IF EXISTS total_rejeitados;
+
+DROP TABLE IF EXISTS itens_rejeitados_sincronizacao;
@@ -0,0 +1,16 @@
+-- Itens de uma eclusa que o central não consegue aplicar (ex.: definição que não existe no central).
+-- Ficam aqui com o conteúdo recebido e o motivo, e a sequência da origem avança: um item inválido
+-- não pára a fila da eclusa.
+CREATE TABLE IF NOT EXISTS itens_rejeitados_sincronizacao (
+	id BIGSERIAL PRIMARY KEY,
+	origem VARCHAR(50) NOT NULL,
+	sequencia BIGINT NOT NULL,
+	tipo VARCHAR(20) NOT NULL,
+	ocorrencia_id BIGINT NOT NULL,
+	motivo TEXT NOT NULL,
+	conteudo TEXT NOT NULL,
+	rejeitado_em TIMESTAMP NOT NULL DEFAULT NOW(),
+	UNIQUE (origem, sequencia)
+);
+
+ALTER TABLE origens_sincronizacao ADD COLUMN IF NOT EXISTS total_rejeitados BIGINT NOT NULL DEFAULT 0;
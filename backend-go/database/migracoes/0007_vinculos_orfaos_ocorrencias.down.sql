@@ -0,0 +1 @@
+-- Nada a desfazer: as linhas apagadas não referiam nenhuma ocorrência
@@ -0,0 +1,16 @@
+-- A migração 0004 removeu as chaves estrangeiras para ocorrencias_falhas. Apaga as linhas que
+-- entretanto ficaram a referir ocorrências que já não existem (como o ON DELETE CASCADE fazia) e
+-- tira suprimida_por das ocorrências cuja ocorrência first-out já não existe. Daqui em diante quem
+-- apaga ocorrências faz o mesmo e confirma que não ficam referências órfãs.
+DELETE FROM transicoes_ocorrencias x
+	WHERE NOT EXISTS (SELECT 1 FROM ocorrencias_falhas o WHERE o.id = x.ocorrencia_id);
+DELETE FROM envios_notificacao x
+	WHERE x.ocorrencia_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM ocorrencias_falhas o WHERE o.id = x.ocorrencia_id);
+DELETE FROM escalonamentos_ocorrencias x
+	WHERE NOT EXISTS (SELECT 1 FROM ocorrencias_falhas o WHERE o.id = x.ocorrencia_id);
+DELETE FROM ordens_trabalho_ocorrencias x
+	WHERE NOT EXISTS (SELECT 1 FROM ocorrencias_falhas o WHERE o.id = x.ocorrencia_id);
+DELETE FROM fila_sincronizacao x
+	WHERE NOT EXISTS (SELECT 1 FROM ocorrencias_falhas o WHERE o.id = x.ocorrencia_id);
+UPDATE ocorrencias_falhas x SET suprimida_por = NULL
+	WHERE x.suprimida_por IS NOT NULL AND NOT EXISTS (SELECT 1 FROM ocorrencias_falhas o WHERE o.id = x.suprimida_por);
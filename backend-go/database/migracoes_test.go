@@ -0,0 +1,175 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+)
+
+// esquemaInstalacaoAnterior é o esquema criado pelo servidor anterior às migrações (setup.go do
+// commit 039ae71): só eclusas, setores, definições e ocorrências, com as colunas originais
+const esquemaInstalacaoAnterior = `
+CREATE TABLE eclusas (
+	id SERIAL PRIMARY KEY,
+	codigo VARCHAR(20) UNIQUE NOT NULL,
+	nome VARCHAR(100) NOT NULL,
+	localizacao VARCHAR(200),
+	ativa BOOLEAN DEFAULT true,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE TABLE setores (
+	id SERIAL PRIMARY KEY,
+	codigo VARCHAR(50) UNIQUE NOT NULL,
+	nome VARCHAR(100) NOT NULL,
+	cor_tema VARCHAR(50),
+	created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE TABLE definicoes_falhas (
+	id SERIAL PRIMARY KEY,
+	eclusa_id INTEGER REFERENCES eclusas(id),
+	setor_id INTEGER REFERENCES setores(id),
+	codigo VARCHAR(50) NOT NULL,
+	tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('FALHA', 'EVENTO')),
+	descricao TEXT NOT NULL,
+	prioridade VARCHAR(20) NOT NULL DEFAULT 'MEDIA' CHECK (prioridade IN ('ALTA', 'MEDIA', 'BAIXA')),
+	point_index INTEGER NOT NULL,
+	classe_mensagem VARCHAR(100),
+	word_index INTEGER,
+	bit_index INTEGER,
+	ativa BOOLEAN DEFAULT true,
+	created_at TIMESTAMP DEFAULT NOW(),
+	UNIQUE(eclusa_id, point_index)
+);
+CREATE TABLE ocorrencias_falhas (
+	id BIGSERIAL PRIMARY KEY,
+	definicao_id INTEGER REFERENCES definicoes_falhas(id),
+	status VARCHAR(20) NOT NULL DEFAULT 'ATIVO' CHECK (status IN ('ATIVO', 'RESOLVIDO', 'EM_ANALISE')),
+	timestamp_inicio TIMESTAMP NOT NULL DEFAULT NOW(),
+	timestamp_fim TIMESTAMP,
+	dados_contexto JSONB,
+	resolvido_por VARCHAR(100),
+	observacoes TEXT,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_timestamp ON ocorrencias_falhas(timestamp_inicio DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_status ON ocorrencias_falhas(status);
+CREATE INDEX IF NOT EXISTS idx_definicoes_eclusa_setor ON definicoes_falhas(eclusa_id, setor_id);
+
+INSERT INTO eclusas (codigo, nome, localizacao) VALUES ('REGUA', 'Eclusa da Régua', 'Rio Douro - Peso da Régua');
+INSERT INTO setores (codigo, nome, cor_tema) VALUES ('ENCHIMENTO', 'Enchimento', 'edp-cobalt');
+INSERT INTO definicoes_falhas (eclusa_id, setor_id, codigo, tipo, descricao, prioridade, point_index, word_index, bit_index)
+	VALUES (1, 1, 'RG_ENCHIMENTO_009', 'FALHA', 'EMERGÊNCIA ATIVADA', 'ALTA', 9, 0, 8);
+INSERT INTO ocorrencias_falhas (definicao_id, status, timestamp_inicio, timestamp_fim, resolvido_por)
+	VALUES (1, 'RESOLVIDO', NOW() - INTERVAL '2 hours', NOW() - INTERVAL '1 hour', 'PLC'),
+	       (1, 'ATIVO', NOW() - INTERVAL '10 minutes', NULL, NULL);
+`
+
+// bancoTestePostgres cria um banco vazio para o teste e apaga-o no fim. Os testes com PostgreSQL
+// só correm com TESTE_POSTGRES=1 e as variáveis DB_* de um servidor onde o utilizador cria bancos.
+func bancoTestePostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	if os.Getenv("TESTE_POSTGRES") != "1" {
+		t.Skip("defina TESTE_POSTGRES=1 (e DB_HOST, DB_USER, DB_PASSWORD) para correr os testes com PostgreSQL")
+	}
+
+	cfg := config.CarregarConfiguracoes()
+	admin, err := Abrir(cfg, "postgres")
+	if err != nil {
+		t.Fatalf("Abrir: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	nome := fmt.Sprintf("falhas_teste_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE DATABASE " + nome); err != nil {
+		t.Fatalf("criar banco de teste: %v", err)
+	}
+	db, err := Abrir(cfg, nome)
+	if err != nil {
+		t.Fatalf("Abrir %s: %v", nome, err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		if _, err := admin.Exec("DROP DATABASE IF EXISTS " + nome); err != nil {
+			t.Logf("apagar banco de teste %s: %v", nome, err)
+		}
+	})
+	return db
+}
+
+// colunasEsquema lista tabela.coluna (tipo) de todas as tabelas do esquema public
+func colunasEsquema(t *testing.T, db *sql.DB) map[string]string {
+	t.Helper()
+	rows, err := db.Query(`
+		SELECT table_name, column_name, data_type, is_nullable
+		FROM information_schema.columns WHERE table_schema = 'public'`)
+	if err != nil {
+		t.Fatalf("ler colunas: %v", err)
+	}
+	defer rows.Close()
+
+	colunas := make(map[string]string)
+	for rows.Next() {
+		var tabela, coluna, tipo, nulo string
+		if err := rows.Scan(&tabela, &coluna, &tipo, &nulo); err != nil {
+			t.Fatalf("ler colunas: %v", err)
+		}
+		colunas[tabela+"."+coluna] = tipo + " " + nulo
+	}
+	return colunas
+}
+
+func TestMigrarInstalacaoAnteriorAsMigracoes(t *testing.T) {
+	vazio := bancoTestePostgres(t)
+	anterior := bancoTestePostgres(t)
+
+	if _, err := anterior.Exec(esquemaInstalacaoAnterior); err != nil {
+		t.Fatalf("criar esquema da instalação anterior: %v", err)
+	}
+
+	migracoes, err := CarregarMigracoes()
+	if err != nil {
+		t.Fatalf("CarregarMigracoes: %v", err)
+	}
+	for nome, db := range map[string]*sql.DB{"banco vazio": vazio, "instalação anterior": anterior} {
+		aplicadas, err := Migrar(db)
+		if err != nil {
+			t.Fatalf("%s: Migrar: %v", nome, err)
+		}
+		if aplicadas != len(migracoes) {
+			t.Fatalf("%s: %d migrações aplicadas; esperadas %d", nome, aplicadas, len(migracoes))
+		}
+		if aplicadas, err := Migrar(db); err != nil || aplicadas != 0 {
+			t.Fatalf("%s: segunda Migrar = %d, %v; esperado 0 sem erro", nome, aplicadas, err)
+		}
+	}
+
+	// A instalação anterior fica com o mesmo esquema de uma instalação nova
+	esperadas, obtidas := colunasEsquema(t, vazio), colunasEsquema(t, anterior)
+	for coluna, tipo := range esperadas {
+		if obtidas[coluna] != tipo {
+			t.Errorf("%s = %q na instalação anterior; esperado %q", coluna, obtidas[coluna], tipo)
+		}
+	}
+	for coluna := range obtidas {
+		if _, existe := esperadas[coluna]; !existe {
+			t.Errorf("%s só existe na instalação anterior", coluna)
+		}
+	}
+
+	// E mantém os dados, agora na tabela particionada
+	var total, ativas int
+	err = anterior.QueryRow(`
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE status = 'ATIVO' AND first_out = false AND avalanche_id IS NULL)
+		FROM ocorrencias_falhas`).Scan(&total, &ativas)
+	if err != nil || total != 2 || ativas != 1 {
+		t.Fatalf("ocorrências depois de migrar = %d (%d ativas), %v; esperadas 2 (1 ativa)", total, ativas, err)
+	}
+	var criticidade int
+	if err := anterior.QueryRow("SELECT criticidade FROM definicoes_falhas WHERE codigo = 'RG_ENCHIMENTO_009'").Scan(&criticidade); err != nil || criticidade != 3 {
+		t.Fatalf("criticidade da definição existente = %d, %v; esperado o padrão 3", criticidade, err)
+	}
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/edp/falhas-backend/config"
+)
+
+// O modo embutido guarda ocorrências, definições e histórico num ficheiro SQLite, para eclusas sem
+// servidor PostgreSQL. O driver não faz parte da compilação normal: o binário para estas
+// instalações é compilado com -tags sqlite (ver driver_sqlite.go).
+
+// O esquema evolui por ficheiros sqlite/NNNN_nome.sql, aplicados por ordem; a versão aplicada fica
+// em PRAGMA user_version.
+//
+//go:embed sqlite/*.sql
+var arquivosSQLite embed.FS
+
+// driverSQLite é o nome com que o driver (modernc.org/sqlite) se regista em database/sql
+const driverSQLite = "sqlite"
+
+// bancosSQLite guarda os pools abertos por AbrirSQLite, para as funções comuns aos dois bancos
+// (saúde, versão do esquema) saberem com qual estão a falar
+var bancosSQLite sync.Map
+
+// AbrirSQLite abre (ou cria) o ficheiro SQLITE_ARQUIVO, atualiza o esquema embutido e insere as
+// eclusas, os setores e as definições da Régua se ainda não existirem
+func AbrirSQLite(cfg *config.Configuracoes) (*sql.DB, error) {
+	if !driverDisponivel(driverSQLite) {
+		return nil, fmt.Errorf("driver SQLite não incluído neste binário: compile com 'go build -tags sqlite'")
+	}
+
+	if diretorio := filepath.Dir(cfg.SQLite_Arquivo); diretorio != "" {
+		if err := os.MkdirAll(diretorio, 0755); err != nil {
+			return nil, fmt.Errorf("erro ao criar diretório do banco SQLite: %v", err)
+		}
+	}
+
+	db, err := sql.Open(driverSQLite, cfg.SQLite_Arquivo)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir banco SQLite %s: %v", cfg.SQLite_Arquivo, err)
+	}
+	// Uma só ligação: o SQLite serializa as escritas e os PRAGMA valem por ligação
+	db.SetMaxOpenConns(1)
+	bancosSQLite.Store(db, true)
+
+	fmt.Printf("🗄️ Abrindo banco embutido %s...\n", cfg.SQLite_Arquivo)
+	for _, pragma := range []string{
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA foreign_keys = ON",
+		"PRAGMA busy_timeout = 5000",
+	} {
+		if _, err := db.Exec(pragma); err != nil {
+			fecharSQLite(db)
+			return nil, fmt.Errorf("erro ao configurar banco SQLite (%s): %v", pragma, err)
+		}
+	}
+
+	if err := atualizarEsquemaSQLite(db); err != nil {
+		fecharSQLite(db)
+		return nil, err
+	}
+
+	// Só os dados de referência com tabelas no esquema embutido (sem equipas nem mapeamento de estado)
+	if err := inserirDadosIniciais(db); err != nil {
+		fecharSQLite(db)
+		return nil, err
+	}
+	if err := inserirFalhasEventosRegua(db); err != nil {
+		fecharSQLite(db)
+		return nil, err
+	}
+
+	fmt.Println("✅ Banco embutido pronto!")
+	return db, nil
+}
+
+// alteracaoSQLite é um ficheiro NNNN_nome.sql do esquema embutido
+type alteracaoSQLite struct {
+	versao int
+	nome   string
+	sql    string
+}
+
+// alteracoesSQLite lê os ficheiros do esquema embutido, por versão
+func alteracoesSQLite() ([]alteracaoSQLite, error) {
+	arquivos, err := fs.Glob(arquivosSQLite, "sqlite/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var alteracoes []alteracaoSQLite
+	for _, arquivo := range arquivos {
+		base := strings.TrimSuffix(strings.TrimPrefix(arquivo, "sqlite/"), ".sql")
+		partes := strings.SplitN(base, "_", 2)
+		versao, err := strconv.Atoi(partes[0])
+		if err != nil || versao <= 0 || len(partes) != 2 {
+			return nil, fmt.Errorf("esquema SQLite %s: nome sem versão numérica (NNNN_nome.sql)", arquivo)
+		}
+		conteudo, err := arquivosSQLite.ReadFile(arquivo)
+		if err != nil {
+			return nil, err
+		}
+		alteracoes = append(alteracoes, alteracaoSQLite{versao: versao, nome: partes[1], sql: string(conteudo)})
+	}
+	sort.Slice(alteracoes, func(i, j int) bool { return alteracoes[i].versao < alteracoes[j].versao })
+	return alteracoes, nil
+}
+
+// VersaoEsquemaSQLite devolve a versão mais recente do esquema embutido neste binário
+func VersaoEsquemaSQLite() (int, error) {
+	alteracoes, err := alteracoesSQLite()
+	if err != nil || len(alteracoes) == 0 {
+		return 0, err
+	}
+	return alteracoes[len(alteracoes)-1].versao, nil
+}
+
+// atualizarEsquemaSQLite aplica, cada uma numa transação, as alterações posteriores a user_version
+func atualizarEsquemaSQLite(db *sql.DB) error {
+	alteracoes, err := alteracoesSQLite()
+	if err != nil {
+		return err
+	}
+	atual, err := versaoEsquemaSQLite(db)
+	if err != nil {
+		return err
+	}
+
+	for _, alteracao := range alteracoes {
+		if alteracao.versao <= atual {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("erro ao iniciar alteração %04d do esquema SQLite: %v", alteracao.versao, err)
+		}
+		if _, err := tx.Exec(alteracao.sql); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("erro ao aplicar alteração %04d_%s do esquema SQLite: %v", alteracao.versao, alteracao.nome, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", alteracao.versao)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("erro ao gravar versão do esquema SQLite: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("erro ao confirmar alteração %04d do esquema SQLite: %v", alteracao.versao, err)
+		}
+		fmt.Printf("  ✅ Esquema embutido %04d_%s aplicado\n", alteracao.versao, alteracao.nome)
+	}
+	return nil
+}
+
+// EhSQLite indica se o pool foi aberto por AbrirSQLite
+func EhSQLite(db *sql.DB) bool {
+	_, existe := bancosSQLite.Load(db)
+	return existe
+}
+
+// versaoEsquemaSQLite lê a versão aplicada ao ficheiro (PRAGMA user_version)
+func versaoEsquemaSQLite(db *sql.DB) (int, error) {
+	var versao int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&versao); err != nil {
+		return 0, fmt.Errorf("erro ao ler versão do esquema SQLite: %v", err)
+	}
+	return versao, nil
+}
+
+func fecharSQLite(db *sql.DB) {
+	bancosSQLite.Delete(db)
+	db.Close()
+}
+
+// driverDisponivel indica se o driver foi registado em database/sql
+func driverDisponivel(nome string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == nome {
+			return true
+		}
+	}
+	return false
+}
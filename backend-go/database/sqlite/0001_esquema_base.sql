@@ -0,0 +1,112 @@
+-- Esquema do modo embutido (SQLite) para eclusas sem servidor PostgreSQL.
+-- Mesma semântica das tabelas PostgreSQL de eclusas, setores, definições, ocorrências e histórico.
+-- Datas em hora local no formato 'AAAA-MM-DD HH:MM:SS.fff' (comparáveis como texto);
+-- booleanos em INTEGER 0/1. As alterações seguintes são novos ficheiros NNNN_nome.sql.
+
+CREATE TABLE IF NOT EXISTS eclusas (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	codigo TEXT UNIQUE NOT NULL,
+	nome TEXT NOT NULL,
+	localizacao TEXT,
+	ativa INTEGER DEFAULT 1,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime'))
+);
+
+CREATE TABLE IF NOT EXISTS setores (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	codigo TEXT UNIQUE NOT NULL,
+	nome TEXT NOT NULL,
+	cor_tema TEXT,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime'))
+);
+
+CREATE TABLE IF NOT EXISTS definicoes_falhas (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	eclusa_id INTEGER REFERENCES eclusas(id),
+	setor_id INTEGER REFERENCES setores(id),
+	codigo TEXT NOT NULL,
+	tipo TEXT NOT NULL CHECK (tipo IN ('FALHA', 'EVENTO')),
+	descricao TEXT NOT NULL,
+	prioridade TEXT NOT NULL DEFAULT 'MEDIA' CHECK (prioridade IN ('ALTA', 'MEDIA', 'BAIXA')),
+	point_index INTEGER NOT NULL,
+	classe_mensagem TEXT,
+	word_index INTEGER,
+	bit_index INTEGER,
+	ativa INTEGER DEFAULT 1,
+	criticidade INTEGER NOT NULL DEFAULT 3 CHECK (criticidade BETWEEN 1 AND 5),
+	relacionada_seguranca INTEGER NOT NULL DEFAULT 0,
+	tempo_resposta_minutos INTEGER CHECK (tempo_resposta_minutos > 0),
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime')),
+	UNIQUE(eclusa_id, point_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_definicoes_eclusa_setor ON definicoes_falhas(eclusa_id, setor_id);
+CREATE INDEX IF NOT EXISTS idx_definicoes_word_bit ON definicoes_falhas(word_index, bit_index);
+
+CREATE TABLE IF NOT EXISTS relacoes_supressao (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	definicao_pai_id INTEGER NOT NULL REFERENCES definicoes_falhas(id) ON DELETE CASCADE,
+	definicao_filha_id INTEGER NOT NULL REFERENCES definicoes_falhas(id) ON DELETE CASCADE,
+	ativa INTEGER DEFAULT 1,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime')),
+	UNIQUE(definicao_pai_id, definicao_filha_id),
+	CHECK (definicao_pai_id <> definicao_filha_id)
+);
+
+CREATE TABLE IF NOT EXISTS janelas_manutencao (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	eclusa_id INTEGER NOT NULL REFERENCES eclusas(id),
+	setor_id INTEGER REFERENCES setores(id),
+	inicio TIMESTAMP NOT NULL,
+	fim TIMESTAMP NOT NULL,
+	motivo TEXT NOT NULL,
+	responsavel TEXT NOT NULL,
+	ocultar INTEGER NOT NULL DEFAULT 1,
+	terminada_em TIMESTAMP,
+	terminada_por TEXT,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime')),
+	CHECK (fim > inicio)
+);
+
+CREATE TABLE IF NOT EXISTS avalanches_alarmes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	eclusa_id INTEGER REFERENCES eclusas(id),
+	timestamp_inicio TIMESTAMP NOT NULL,
+	timestamp_fim TIMESTAMP,
+	total_alarmes INTEGER NOT NULL DEFAULT 0,
+	first_out_ocorrencia_id INTEGER,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime'))
+);
+
+CREATE TABLE IF NOT EXISTS ocorrencias_falhas (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	definicao_id INTEGER REFERENCES definicoes_falhas(id),
+	status TEXT NOT NULL DEFAULT 'ATIVO' CHECK (status IN ('ATIVO', 'RESOLVIDO', 'EM_ANALISE')),
+	timestamp_inicio TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime')),
+	timestamp_fim TIMESTAMP,
+	dados_contexto TEXT,
+	resolvido_por TEXT,
+	observacoes TEXT,
+	first_out INTEGER NOT NULL DEFAULT 0,
+	grupo_first_out_id INTEGER,
+	avalanche_id INTEGER REFERENCES avalanches_alarmes(id),
+	suprimida_por INTEGER REFERENCES ocorrencias_falhas(id),
+	janela_manutencao_id INTEGER REFERENCES janelas_manutencao(id) ON DELETE SET NULL,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_timestamp ON ocorrencias_falhas(timestamp_inicio DESC);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_status ON ocorrencias_falhas(status);
+CREATE INDEX IF NOT EXISTS idx_ocorrencias_definicao_status ON ocorrencias_falhas(definicao_id, status);
+
+CREATE TABLE IF NOT EXISTS transicoes_ocorrencias (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	ocorrencia_id INTEGER NOT NULL REFERENCES ocorrencias_falhas(id) ON DELETE CASCADE,
+	status_anterior TEXT,
+	status_novo TEXT NOT NULL,
+	timestamp TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime')),
+	origem TEXT NOT NULL,
+	observacao TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_transicoes_ocorrencia ON transicoes_ocorrencias(ocorrencia_id, timestamp);
@@ -0,0 +1,29 @@
+-- Sincronização com a instância central (ver migração PostgreSQL 0003_sincronizacao).
+CREATE TABLE IF NOT EXISTS fila_sincronizacao (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	tipo TEXT NOT NULL CHECK (tipo IN ('OCORRENCIA', 'TRANSICAO')),
+	ocorrencia_id INTEGER NOT NULL,
+	status_anterior TEXT,
+	status_novo TEXT,
+	timestamp TIMESTAMP,
+	origem TEXT,
+	observacao TEXT,
+	tentativas INTEGER NOT NULL DEFAULT 0,
+	ultimo_erro TEXT,
+	criado_em TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime')),
+	enviado_em TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_fila_sincronizacao_pendentes ON fila_sincronizacao(id) WHERE enviado_em IS NULL;
+
+ALTER TABLE ocorrencias_falhas ADD COLUMN origem_eclusa TEXT;
+ALTER TABLE ocorrencias_falhas ADD COLUMN origem_id INTEGER;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ocorrencias_origem ON ocorrencias_falhas(origem_eclusa, origem_id);
+
+CREATE TABLE IF NOT EXISTS origens_sincronizacao (
+	origem TEXT PRIMARY KEY,
+	ultima_sequencia INTEGER NOT NULL DEFAULT 0,
+	total_itens INTEGER NOT NULL DEFAULT 0,
+	ultimo_lote_em TIMESTAMP
+);
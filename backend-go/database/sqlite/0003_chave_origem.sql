@@ -0,0 +1,4 @@
+-- Mesma chave de origem do PostgreSQL particionado (ver migração 0004_particionamento_ocorrencias):
+-- o upsert da sincronização usa (origem_eclusa, origem_id, timestamp_inicio) nos dois bancos.
+DROP INDEX IF EXISTS idx_ocorrencias_origem;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ocorrencias_origem ON ocorrencias_falhas(origem_eclusa, origem_id, timestamp_inicio);
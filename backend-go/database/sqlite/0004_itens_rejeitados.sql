@@ -0,0 +1,14 @@
+-- Itens rejeitados pelo central (ver migração PostgreSQL 0006_itens_rejeitados_sincronizacao).
+CREATE TABLE IF NOT EXISTS itens_rejeitados_sincronizacao (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	origem TEXT NOT NULL,
+	sequencia INTEGER NOT NULL,
+	tipo TEXT NOT NULL,
+	ocorrencia_id INTEGER NOT NULL,
+	motivo TEXT NOT NULL,
+	conteudo TEXT NOT NULL,
+	rejeitado_em TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime')),
+	UNIQUE (origem, sequencia)
+);
+
+ALTER TABLE origens_sincronizacao ADD COLUMN total_rejeitados INTEGER NOT NULL DEFAULT 0;
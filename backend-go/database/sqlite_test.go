@@ -0,0 +1,61 @@
+//go:build sqlite
+
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/edp/falhas-backend/config"
+)
+
+func TestAbrirSQLiteCriaEsquemaEDadosIniciais(t *testing.T) {
+	cfg := &config.Configuracoes{SQLite_Arquivo: filepath.Join(t.TempDir(), "dados", "falhas.db")}
+
+	db, err := AbrirSQLite(cfg)
+	if err != nil {
+		t.Fatalf("AbrirSQLite: %v", err)
+	}
+	if !EhSQLite(db) {
+		t.Fatal("EhSQLite = false para um banco aberto por AbrirSQLite")
+	}
+
+	esperada, err := VersaoEsquemaSQLite()
+	if err != nil {
+		t.Fatalf("VersaoEsquemaSQLite: %v", err)
+	}
+	versao, err := versaoEsquemaSQLite(db)
+	if err != nil {
+		t.Fatalf("versaoEsquemaSQLite: %v", err)
+	}
+	if versao != esperada || versao == 0 {
+		t.Fatalf("user_version = %d; esperado %d", versao, esperada)
+	}
+
+	contar := func(tabela string) int {
+		t.Helper()
+		var total int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + tabela).Scan(&total); err != nil {
+			t.Fatalf("contar %s: %v", tabela, err)
+		}
+		return total
+	}
+	eclusas, definicoes := contar("eclusas"), contar("definicoes_falhas")
+	if eclusas == 0 || definicoes == 0 {
+		t.Fatalf("eclusas = %d, definições = %d; esperados os dados da Régua", eclusas, definicoes)
+	}
+	fecharSQLite(db)
+
+	// Reabrir o mesmo ficheiro não reaplica o esquema nem duplica os dados iniciais
+	db, err = AbrirSQLite(cfg)
+	if err != nil {
+		t.Fatalf("AbrirSQLite (reabrir): %v", err)
+	}
+	defer fecharSQLite(db)
+	if versao, _ := versaoEsquemaSQLite(db); versao != esperada {
+		t.Fatalf("user_version depois de reabrir = %d; esperado %d", versao, esperada)
+	}
+	if contar("eclusas") != eclusas || contar("definicoes_falhas") != definicoes {
+		t.Fatal("reabrir o banco duplicou os dados iniciais")
+	}
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// VinculoOcorrencia é uma coluna que guarda o id de uma ocorrência. Desde a migração 0004 nenhuma
+// tem chave estrangeira (a tabela particionada só aceitaria referências a (id, timestamp_inicio)):
+// quem apaga ocorrências apaga também as linhas que as referem e confirma com VerificarVinculosOcorrencias.
+type VinculoOcorrencia struct {
+	Tabela string
+	Coluna string
+}
+
+// VinculosOcorrencia são as referências que tinham chave estrangeira para ocorrencias_falhas
+var VinculosOcorrencia = []VinculoOcorrencia{
+	{"transicoes_ocorrencias", "ocorrencia_id"},
+	{"envios_notificacao", "ocorrencia_id"},
+	{"escalonamentos_ocorrencias", "ocorrencia_id"},
+	{"ordens_trabalho_ocorrencias", "ocorrencia_id"},
+	{"ocorrencias_falhas", "suprimida_por"},
+}
+
+// TabelasDependentesOcorrencia são as tabelas cujas linhas são apagadas com a ocorrência, como
+// antes com ON DELETE CASCADE (a fila de sincronização nunca teve chave estrangeira, mas também
+// não sobrevive à ocorrência). Uma ocorrência suprimida por uma apagada fica com suprimida_por NULL.
+var TabelasDependentesOcorrencia = []string{
+	"transicoes_ocorrencias", "envios_notificacao", "escalonamentos_ocorrencias",
+	"ordens_trabalho_ocorrencias", "fila_sincronizacao",
+}
+
+// consultorLinha é um *sql.DB ou *sql.Tx
+type consultorLinha interface {
+	QueryRow(consulta string, args ...interface{}) *sql.Row
+}
+
+// VerificarVinculosOcorrencias devolve um erro se alguma linha referir uma ocorrência que não
+// existe. selecao (um SELECT de ids) limita a verificação às referências a essas ocorrências;
+// vazia verifica todas.
+func VerificarVinculosOcorrencias(q consultorLinha, selecao string) error {
+	var orfaos []string
+	for _, v := range VinculosOcorrencia {
+		condicao := "x." + v.Coluna + " IS NOT NULL"
+		if selecao != "" {
+			condicao = "x." + v.Coluna + " IN (" + selecao + ")"
+		}
+		var total int
+		err := q.QueryRow(`SELECT COUNT(*) FROM ` + v.Tabela + ` x WHERE ` + condicao + `
+			AND NOT EXISTS (SELECT 1 FROM ocorrencias_falhas o WHERE o.id = x.` + v.Coluna + `)`).Scan(&total)
+		if err != nil {
+			return fmt.Errorf("erro ao verificar %s.%s: %v", v.Tabela, v.Coluna, err)
+		}
+		if total > 0 {
+			orfaos = append(orfaos, fmt.Sprintf("%d em %s.%s", total, v.Tabela, v.Coluna))
+		}
+	}
+	if len(orfaos) > 0 {
+		return fmt.Errorf("referências a ocorrências que não existem: %s", strings.Join(orfaos, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package exportacao
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// formatoDataHoraCSV é reconhecido como data pelo Excel e pelo LibreOffice em pt-PT
+const formatoDataHoraCSV = "2006-01-02 15:04:05"
+
+// escritorCSV usa ';' como separador (a vírgula é o separador decimal em pt-PT) e BOM UTF-8
+// para o Excel reconhecer os acentos
+type escritorCSV struct {
+	buffer  *bufio.Writer
+	csv     *csv.Writer
+	colunas []Coluna
+	fuso    *time.Location
+	campos  []string
+}
+
+// novoEscritorCSV escreve o BOM e o cabeçalho
+func novoEscritorCSV(destino io.Writer, colunas []Coluna, fuso *time.Location) (*escritorCSV, error) {
+	buffer := bufio.NewWriter(destino)
+	if _, err := buffer.WriteString("\uFEFF"); err != nil {
+		return nil, err
+	}
+
+	e := &escritorCSV{
+		buffer:  buffer,
+		csv:     csv.NewWriter(buffer),
+		colunas: colunas,
+		fuso:    fuso,
+		campos:  make([]string, len(colunas)),
+	}
+	e.csv.Comma = ';'
+	e.csv.UseCRLF = true
+
+	for i, coluna := range colunas {
+		e.campos[i] = tituloComFuso(coluna, fuso)
+	}
+	if err := e.csv.Write(e.campos); err != nil {
+		return nil, fmt.Errorf("erro ao escrever cabeçalho CSV: %v", err)
+	}
+	return e, nil
+}
+
+// EscreverLinha converte os valores em texto (booleanos como Sim/Não, datas no fuso pedido)
+func (e *escritorCSV) EscreverLinha(valores []interface{}) error {
+	for i, valor := range valores {
+		switch v := valor.(type) {
+		case nil:
+			e.campos[i] = ""
+		case string:
+			e.campos[i] = v
+		case int64:
+			e.campos[i] = strconv.FormatInt(v, 10)
+		case bool:
+			e.campos[i] = textoBooleano(v)
+		case time.Time:
+			e.campos[i] = v.In(e.fuso).Format(formatoDataHoraCSV)
+		default:
+			e.campos[i] = fmt.Sprint(v)
+		}
+	}
+	return e.csv.Write(e.campos)
+}
+
+// Fechar descarrega o que falta no buffer
+func (e *escritorCSV) Fechar() error {
+	e.csv.Flush()
+	if err := e.csv.Error(); err != nil {
+		return err
+	}
+	return e.buffer.Flush()
+}
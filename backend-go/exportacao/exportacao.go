@@ -0,0 +1,85 @@
+package exportacao
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	// Os fusos pedidos na exportação não dependem da base tz do sistema
+	_ "time/tzdata"
+)
+
+// Formatos de exportação suportados
+const (
+	FormatoCSV     = "csv"
+	FormatoXLSX    = "xlsx"
+	FormatoParquet = "parquet"
+)
+
+// Tipos de coluna (determinam a célula XLSX e o tipo físico Parquet)
+const (
+	TipoTexto = iota
+	TipoInteiro
+	TipoDataHora
+	TipoBooleano
+)
+
+// Coluna descreve uma coluna exportada
+type Coluna struct {
+	Titulo string // Cabeçalho em pt-PT (CSV e XLSX)
+	Nome   string // Nome técnico (Parquet)
+	Tipo   int
+}
+
+// Escritor recebe as linhas uma a uma e escreve-as no destino sem acumular o resultado.
+// Os valores de cada linha seguem a ordem das colunas: string, int64, time.Time, bool ou nil (vazio).
+type Escritor interface {
+	EscreverLinha(valores []interface{}) error
+	Fechar() error
+}
+
+// NovoEscritor cria o escritor do formato; as datas são escritas no fuso indicado
+// (no Parquet ficam como instantes UTC, que é o que o formato define)
+func NovoEscritor(formato string, destino io.Writer, colunas []Coluna, fuso *time.Location) (Escritor, error) {
+	switch formato {
+	case FormatoCSV:
+		return novoEscritorCSV(destino, colunas, fuso)
+	case FormatoXLSX:
+		return novoEscritorXLSX(destino, colunas, fuso)
+	case FormatoParquet:
+		return novoEscritorParquet(destino, colunas)
+	}
+	return nil, fmt.Errorf("formato de exportação inválido: %s (use csv, xlsx ou parquet)", formato)
+}
+
+// FormatoValido indica se o formato é suportado
+func FormatoValido(formato string) bool {
+	return formato == FormatoCSV || formato == FormatoXLSX || formato == FormatoParquet
+}
+
+// TipoConteudo devolve o Content-Type HTTP do formato
+func TipoConteudo(formato string) string {
+	switch formato {
+	case FormatoCSV:
+		return "text/csv; charset=utf-8"
+	case FormatoXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/vnd.apache.parquet"
+}
+
+// tituloComFuso acrescenta o fuso ao cabeçalho das colunas de data/hora
+func tituloComFuso(coluna Coluna, fuso *time.Location) string {
+	if coluna.Tipo == TipoDataHora {
+		return fmt.Sprintf("%s (%s)", coluna.Titulo, fuso.String())
+	}
+	return coluna.Titulo
+}
+
+// textoBooleano escreve booleanos como Sim/Não nas folhas de cálculo
+func textoBooleano(valor bool) string {
+	if valor {
+		return "Sim"
+	}
+	return "Não"
+}
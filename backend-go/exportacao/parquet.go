@@ -0,0 +1,432 @@
+package exportacao
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// linhasPorGrupoParquet limita as linhas em memória: cada grupo é escrito quando fica cheio
+const linhasPorGrupoParquet = 10000
+
+// Valores do formato Parquet (parquet.thrift) usados pelo escritor
+const (
+	tipoFisicoBoolean   = 0
+	tipoFisicoInt64     = 2
+	tipoFisicoByteArray = 6
+
+	repeticaoOpcional = 1
+
+	tipoConvertidoUTF8            = 0
+	tipoConvertidoTimestampMillis = 9
+
+	codificacaoPlain = 0
+	codificacaoRLE   = 3
+
+	tipoPaginaDados = 0
+)
+
+var magicParquet = []byte("PAR1")
+
+// colunaParquet acumula os valores de uma coluna no grupo de linhas atual
+type colunaParquet struct {
+	Coluna
+	definidos []bool // Nível de definição (todas as colunas são opcionais)
+	valores   bytes.Buffer
+}
+
+// metadadosColuna guarda o necessário para o rodapé depois de o grupo ser escrito
+type metadadosColuna struct {
+	posicao int64
+	tamanho int64
+	valores int64
+	tipo    int32
+	caminho string
+}
+
+// grupoParquet descreve um grupo de linhas já escrito
+type grupoParquet struct {
+	colunas []metadadosColuna
+	linhas  int64
+	tamanho int64
+}
+
+// escritorParquet escreve grupos de linhas com páginas PLAIN sem compressão e o rodapé
+// (FileMetaData em Thrift compact) no fecho
+type escritorParquet struct {
+	destino *bufio.Writer
+	posicao int64
+	colunas []*colunaParquet
+	linhas  int
+	grupos  []grupoParquet
+	total   int64
+}
+
+// novoEscritorParquet escreve o cabeçalho mágico
+func novoEscritorParquet(destino io.Writer, colunas []Coluna) (*escritorParquet, error) {
+	e := &escritorParquet{destino: bufio.NewWriter(destino)}
+	for _, coluna := range colunas {
+		e.colunas = append(e.colunas, &colunaParquet{Coluna: coluna})
+	}
+	if err := e.escrever(magicParquet); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// EscreverLinha acrescenta a linha ao grupo atual e escreve o grupo quando fica cheio
+func (e *escritorParquet) EscreverLinha(valores []interface{}) error {
+	for i, valor := range valores {
+		coluna := e.colunas[i]
+		if valor == nil {
+			coluna.definidos = append(coluna.definidos, false)
+			continue
+		}
+		coluna.definidos = append(coluna.definidos, true)
+
+		switch coluna.Tipo {
+		case TipoTexto:
+			texto, ok := valor.(string)
+			if !ok {
+				texto = fmt.Sprint(valor)
+			}
+			binary.Write(&coluna.valores, binary.LittleEndian, uint32(len(texto)))
+			coluna.valores.WriteString(texto)
+		case TipoInteiro:
+			inteiro, ok := valor.(int64)
+			if !ok {
+				return fmt.Errorf("coluna %s: inteiro esperado, recebido %T", coluna.Nome, valor)
+			}
+			binary.Write(&coluna.valores, binary.LittleEndian, inteiro)
+		case TipoDataHora:
+			instante, ok := valor.(time.Time)
+			if !ok {
+				return fmt.Errorf("coluna %s: data/hora esperada, recebido %T", coluna.Nome, valor)
+			}
+			binary.Write(&coluna.valores, binary.LittleEndian, instante.UnixMilli())
+		case TipoBooleano:
+			// Os booleanos são empacotados em bits só ao escrever a página
+			booleano, ok := valor.(bool)
+			if !ok {
+				return fmt.Errorf("coluna %s: booleano esperado, recebido %T", coluna.Nome, valor)
+			}
+			if booleano {
+				coluna.valores.WriteByte(1)
+			} else {
+				coluna.valores.WriteByte(0)
+			}
+		}
+	}
+
+	e.linhas++
+	if e.linhas == linhasPorGrupoParquet {
+		return e.escreverGrupo()
+	}
+	return nil
+}
+
+// Fechar escreve o último grupo e o rodapé
+func (e *escritorParquet) Fechar() error {
+	if e.linhas > 0 {
+		if err := e.escreverGrupo(); err != nil {
+			return err
+		}
+	}
+
+	rodape := e.metadadosArquivo()
+	if err := e.escrever(rodape); err != nil {
+		return err
+	}
+	tamanho := make([]byte, 4)
+	binary.LittleEndian.PutUint32(tamanho, uint32(len(rodape)))
+	if err := e.escrever(tamanho); err != nil {
+		return err
+	}
+	if err := e.escrever(magicParquet); err != nil {
+		return err
+	}
+	return e.destino.Flush()
+}
+
+// escreverGrupo escreve uma página de dados por coluna e esvazia os buffers
+func (e *escritorParquet) escreverGrupo() error {
+	grupo := grupoParquet{linhas: int64(e.linhas)}
+
+	for _, coluna := range e.colunas {
+		var pagina bytes.Buffer
+
+		// Níveis de definição: comprimento (4 bytes) + RLE/bit-packing híbrido de largura 1
+		niveis := codificarNiveis(coluna.definidos)
+		binary.Write(&pagina, binary.LittleEndian, uint32(len(niveis)))
+		pagina.Write(niveis)
+
+		if coluna.Tipo == TipoBooleano {
+			pagina.Write(empacotarBits(coluna.valores.Bytes()))
+		} else {
+			pagina.Write(coluna.valores.Bytes())
+		}
+
+		cabecalho := novoCompacto()
+		cabecalho.campoI32(1, tipoPaginaDados)
+		cabecalho.campoI32(2, int32(pagina.Len()))
+		cabecalho.campoI32(3, int32(pagina.Len()))
+		cabecalho.inicioEstrutura(5)
+		cabecalho.campoI32(1, int32(len(coluna.definidos)))
+		cabecalho.campoI32(2, codificacaoPlain)
+		cabecalho.campoI32(3, codificacaoRLE)
+		cabecalho.campoI32(4, codificacaoRLE)
+		cabecalho.fimEstrutura()
+		cabecalho.fimEstrutura()
+
+		posicao := e.posicao
+		if err := e.escrever(cabecalho.Bytes()); err != nil {
+			return err
+		}
+		if err := e.escrever(pagina.Bytes()); err != nil {
+			return err
+		}
+
+		tamanho := int64(cabecalho.Len() + pagina.Len())
+		grupo.colunas = append(grupo.colunas, metadadosColuna{
+			posicao: posicao,
+			tamanho: tamanho,
+			valores: int64(len(coluna.definidos)),
+			tipo:    tipoFisico(coluna.Tipo),
+			caminho: coluna.Nome,
+		})
+		grupo.tamanho += tamanho
+
+		coluna.definidos = coluna.definidos[:0]
+		coluna.valores.Reset()
+	}
+
+	e.grupos = append(e.grupos, grupo)
+	e.total += grupo.linhas
+	e.linhas = 0
+	return nil
+}
+
+// metadadosArquivo serializa o FileMetaData: esquema, grupos de linhas e colunas
+func (e *escritorParquet) metadadosArquivo() []byte {
+	m := novoCompacto()
+	m.campoI32(1, 1)
+
+	// Esquema: raiz seguida das colunas
+	m.campoLista(2, tipoCompactoEstrutura, len(e.colunas)+1)
+	m.elementoEstrutura()
+	m.campoTexto(4, "ocorrencias")
+	m.campoI32(5, int32(len(e.colunas)))
+	m.fimEstrutura()
+	for _, coluna := range e.colunas {
+		m.elementoEstrutura()
+		m.campoI32(1, tipoFisico(coluna.Tipo))
+		m.campoI32(3, repeticaoOpcional)
+		m.campoTexto(4, coluna.Nome)
+		switch coluna.Tipo {
+		case TipoTexto:
+			m.campoI32(6, tipoConvertidoUTF8)
+			m.inicioEstrutura(10) // LogicalType
+			m.inicioEstrutura(1)  // STRING
+			m.fimEstrutura()
+			m.fimEstrutura()
+		case TipoDataHora:
+			m.campoI32(6, tipoConvertidoTimestampMillis)
+			m.inicioEstrutura(10) // LogicalType
+			m.inicioEstrutura(8)  // TIMESTAMP
+			m.campoBooleano(1, true)
+			m.inicioEstrutura(2) // TimeUnit
+			m.inicioEstrutura(1) // MILLIS
+			m.fimEstrutura()
+			m.fimEstrutura()
+			m.fimEstrutura()
+			m.fimEstrutura()
+		}
+		m.fimEstrutura()
+	}
+
+	m.campoI64(3, e.total)
+
+	m.campoLista(4, tipoCompactoEstrutura, len(e.grupos))
+	for _, grupo := range e.grupos {
+		m.elementoEstrutura()
+		m.campoLista(1, tipoCompactoEstrutura, len(grupo.colunas))
+		for _, coluna := range grupo.colunas {
+			m.elementoEstrutura()
+			m.campoI64(2, coluna.posicao)
+			m.inicioEstrutura(3) // ColumnMetaData
+			m.campoI32(1, coluna.tipo)
+			m.campoLista(2, tipoCompactoI32, 2)
+			m.valorI32(codificacaoPlain)
+			m.valorI32(codificacaoRLE)
+			m.campoLista(3, tipoCompactoBinario, 1)
+			m.valorTexto(coluna.caminho)
+			m.campoI32(4, 0) // UNCOMPRESSED
+			m.campoI64(5, coluna.valores)
+			m.campoI64(6, coluna.tamanho)
+			m.campoI64(7, coluna.tamanho)
+			m.campoI64(9, coluna.posicao)
+			m.fimEstrutura()
+			m.fimEstrutura()
+		}
+		m.campoI64(2, grupo.tamanho)
+		m.campoI64(3, grupo.linhas)
+		m.fimEstrutura()
+	}
+
+	m.campoTexto(6, "falhas-backend")
+	m.fimEstrutura()
+	return m.Bytes()
+}
+
+// escrever envia os bytes ao destino e atualiza a posição no arquivo
+func (e *escritorParquet) escrever(dados []byte) error {
+	n, err := e.destino.Write(dados)
+	e.posicao += int64(n)
+	return err
+}
+
+// tipoFisico converte o tipo da coluna no tipo físico Parquet
+func tipoFisico(tipo int) int32 {
+	switch tipo {
+	case TipoTexto:
+		return tipoFisicoByteArray
+	case TipoBooleano:
+		return tipoFisicoBoolean
+	}
+	return tipoFisicoInt64
+}
+
+// codificarNiveis escreve os níveis de definição como uma única sequência bit-packed
+func codificarNiveis(definidos []bool) []byte {
+	grupos := (len(definidos) + 7) / 8
+	var saida bytes.Buffer
+	escreverVarint(&saida, uint64(grupos)<<1|1)
+
+	bytesNiveis := make([]byte, grupos)
+	for i, definido := range definidos {
+		if definido {
+			bytesNiveis[i/8] |= 1 << (i % 8)
+		}
+	}
+	saida.Write(bytesNiveis)
+	return saida.Bytes()
+}
+
+// empacotarBits converte um byte por booleano em bits (LSB primeiro), como exige o PLAIN
+func empacotarBits(valores []byte) []byte {
+	saida := make([]byte, (len(valores)+7)/8)
+	for i, valor := range valores {
+		if valor != 0 {
+			saida[i/8] |= 1 << (i % 8)
+		}
+	}
+	return saida
+}
+
+// Tipos do protocolo Thrift compact
+const (
+	tipoCompactoVerdadeiro = 1
+	tipoCompactoFalso      = 2
+	tipoCompactoI32        = 5
+	tipoCompactoI64        = 6
+	tipoCompactoBinario    = 8
+	tipoCompactoLista      = 9
+	tipoCompactoEstrutura  = 12
+)
+
+// compacto serializa estruturas Thrift no protocolo compact (o usado pelos metadados Parquet)
+type compacto struct {
+	bytes.Buffer
+	ultimoCampo []int16 // Pilha com o último id de campo de cada estrutura aberta
+}
+
+func novoCompacto() *compacto {
+	return &compacto{ultimoCampo: []int16{0}}
+}
+
+// cabecalhoCampo escreve o id do campo em delta quando possível
+func (c *compacto) cabecalhoCampo(id int16, tipo byte) {
+	topo := len(c.ultimoCampo) - 1
+	delta := id - c.ultimoCampo[topo]
+	if delta > 0 && delta <= 15 {
+		c.WriteByte(byte(delta)<<4 | tipo)
+	} else {
+		c.WriteByte(tipo)
+		escreverVarint(&c.Buffer, zigzag(int64(id)))
+	}
+	c.ultimoCampo[topo] = id
+}
+
+func (c *compacto) campoI32(id int16, valor int32) {
+	c.cabecalhoCampo(id, tipoCompactoI32)
+	c.valorI32(valor)
+}
+
+func (c *compacto) campoI64(id int16, valor int64) {
+	c.cabecalhoCampo(id, tipoCompactoI64)
+	escreverVarint(&c.Buffer, zigzag(valor))
+}
+
+func (c *compacto) campoBooleano(id int16, valor bool) {
+	if valor {
+		c.cabecalhoCampo(id, tipoCompactoVerdadeiro)
+	} else {
+		c.cabecalhoCampo(id, tipoCompactoFalso)
+	}
+}
+
+func (c *compacto) campoTexto(id int16, valor string) {
+	c.cabecalhoCampo(id, tipoCompactoBinario)
+	c.valorTexto(valor)
+}
+
+func (c *compacto) campoLista(id int16, tipoElemento byte, tamanho int) {
+	c.cabecalhoCampo(id, tipoCompactoLista)
+	if tamanho < 15 {
+		c.WriteByte(byte(tamanho)<<4 | tipoElemento)
+	} else {
+		c.WriteByte(0xF0 | tipoElemento)
+		escreverVarint(&c.Buffer, uint64(tamanho))
+	}
+}
+
+func (c *compacto) valorI32(valor int32) {
+	escreverVarint(&c.Buffer, zigzag(int64(valor)))
+}
+
+func (c *compacto) valorTexto(valor string) {
+	escreverVarint(&c.Buffer, uint64(len(valor)))
+	c.WriteString(valor)
+}
+
+// inicioEstrutura abre um campo do tipo estrutura
+func (c *compacto) inicioEstrutura(id int16) {
+	c.cabecalhoCampo(id, tipoCompactoEstrutura)
+	c.ultimoCampo = append(c.ultimoCampo, 0)
+}
+
+// elementoEstrutura abre uma estrutura dentro de uma lista
+func (c *compacto) elementoEstrutura() {
+	c.ultimoCampo = append(c.ultimoCampo, 0)
+}
+
+// fimEstrutura escreve o STOP e volta à estrutura anterior
+func (c *compacto) fimEstrutura() {
+	c.WriteByte(0)
+	if len(c.ultimoCampo) > 1 {
+		c.ultimoCampo = c.ultimoCampo[:len(c.ultimoCampo)-1]
+	}
+}
+
+func zigzag(valor int64) uint64 {
+	return uint64(valor<<1) ^ uint64(valor>>63)
+}
+
+func escreverVarint(destino *bytes.Buffer, valor uint64) {
+	var buffer [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buffer[:], valor)
+	destino.Write(buffer[:n])
+}
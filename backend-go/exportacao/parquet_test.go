@@ -0,0 +1,297 @@
+package exportacao
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// O teste lê o arquivo com um leitor independente do escritor: Thrift compact genérico para o
+// rodapé e os cabeçalhos de página, níveis de definição RLE/bit-packing híbridos e valores PLAIN.
+
+func TestParquetIdaEVolta(t *testing.T) {
+	colunas := []Coluna{
+		{Titulo: "Código", Nome: "codigo", Tipo: TipoTexto},
+		{Titulo: "Duração", Nome: "duracao", Tipo: TipoInteiro},
+		{Titulo: "Início", Nome: "inicio", Tipo: TipoDataHora},
+		{Titulo: "SLA violado", Nome: "sla_violado", Tipo: TipoBooleano},
+	}
+
+	// Mais linhas do que um grupo, para haver vários grupos e um último incompleto
+	total := linhasPorGrupoParquet*2 + 37
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	linhas := make([][]interface{}, total)
+	for i := range linhas {
+		linha := []interface{}{
+			fmt.Sprintf("RG_ENCHIMENTO_%03d ção", i%736),
+			int64(i*37 - 5000),
+			base.Add(time.Duration(i) * 1500 * time.Millisecond),
+			i%3 == 0,
+		}
+		// Valores vazios em padrões diferentes por coluna
+		for c := range linha {
+			if (i+c)%(5+c) == 0 {
+				linha[c] = nil
+			}
+		}
+		if i == 7 {
+			linha[0] = ""
+		}
+		linhas[i] = linha
+	}
+
+	var saida bytes.Buffer
+	escritor, err := NovoEscritor(FormatoParquet, &saida, colunas, time.Local)
+	if err != nil {
+		t.Fatalf("NovoEscritor: %v", err)
+	}
+	for _, linha := range linhas {
+		if err := escritor.EscreverLinha(linha); err != nil {
+			t.Fatalf("EscreverLinha: %v", err)
+		}
+	}
+	if err := escritor.Fechar(); err != nil {
+		t.Fatalf("Fechar: %v", err)
+	}
+
+	lidas, nomes := lerParquet(t, saida.Bytes())
+	for i, coluna := range colunas {
+		if nomes[i] != coluna.Nome {
+			t.Errorf("coluna %d chama-se %q; esperado %q", i, nomes[i], coluna.Nome)
+		}
+	}
+	if len(lidas) != total {
+		t.Fatalf("%d linhas lidas; esperadas %d", len(lidas), total)
+	}
+	for i, linha := range linhas {
+		for c, valor := range linha {
+			if instante, ok := valor.(time.Time); ok {
+				valor = instante.UnixMilli()
+			}
+			if lidas[i][c] != valor {
+				t.Fatalf("linha %d, coluna %s = %#v; esperado %#v", i, colunas[c].Nome, lidas[i][c], valor)
+			}
+		}
+	}
+}
+
+func TestParquetSemLinhas(t *testing.T) {
+	var saida bytes.Buffer
+	escritor, err := NovoEscritor(FormatoParquet, &saida, []Coluna{{Nome: "id", Tipo: TipoInteiro}}, time.Local)
+	if err != nil {
+		t.Fatalf("NovoEscritor: %v", err)
+	}
+	if err := escritor.Fechar(); err != nil {
+		t.Fatalf("Fechar: %v", err)
+	}
+	if lidas, _ := lerParquet(t, saida.Bytes()); len(lidas) != 0 {
+		t.Errorf("%d linhas lidas; esperadas 0", len(lidas))
+	}
+}
+
+// lerParquet devolve as linhas (string, int64, millis em int64, bool ou nil) e os nomes das colunas
+func lerParquet(t *testing.T, arquivo []byte) ([][]interface{}, []string) {
+	t.Helper()
+	if len(arquivo) < 12 || string(arquivo[:4]) != "PAR1" || string(arquivo[len(arquivo)-4:]) != "PAR1" {
+		t.Fatal("arquivo sem o PAR1 no início ou no fim")
+	}
+	tamanhoRodape := int(binary.LittleEndian.Uint32(arquivo[len(arquivo)-8:]))
+	inicioRodape := len(arquivo) - 8 - tamanhoRodape
+	leitor := &leitorCompacto{dados: arquivo[inicioRodape : len(arquivo)-8]}
+	metadados := leitor.estrutura()
+	if leitor.pos != tamanhoRodape {
+		t.Fatalf("rodapé com %d bytes, lidos %d", tamanhoRodape, leitor.pos)
+	}
+
+	// Esquema: raiz com N filhos, colunas opcionais
+	esquema := metadados[2].([]interface{})
+	raiz := esquema[0].(map[int16]interface{})
+	if raiz[5] != int64(len(esquema)-1) {
+		t.Fatalf("raiz com num_children %v para %d colunas", raiz[5], len(esquema)-1)
+	}
+	var nomes []string
+	var tipos []int64
+	for _, elemento := range esquema[1:] {
+		coluna := elemento.(map[int16]interface{})
+		if coluna[3] != int64(repeticaoOpcional) {
+			t.Fatalf("coluna %v não é OPTIONAL", coluna[4])
+		}
+		nomes = append(nomes, coluna[4].(string))
+		tipos = append(tipos, coluna[1].(int64))
+	}
+
+	var linhas [][]interface{}
+	for _, g := range metadados[4].([]interface{}) {
+		grupo := g.(map[int16]interface{})
+		numeroLinhas := int(grupo[3].(int64))
+		base := len(linhas)
+		for i := 0; i < numeroLinhas; i++ {
+			linhas = append(linhas, make([]interface{}, len(nomes)))
+		}
+
+		var tamanhoGrupo int64
+		for c, cc := range grupo[1].([]interface{}) {
+			meta := cc.(map[int16]interface{})[3].(map[int16]interface{})
+			if meta[1] != tipos[c] || meta[3].([]interface{})[0] != nomes[c] || meta[4] != int64(0) {
+				t.Fatalf("metadados da coluna %s incoerentes com o esquema: %v", nomes[c], meta)
+			}
+			if meta[5] != int64(numeroLinhas) {
+				t.Fatalf("coluna %s com %v valores num grupo de %d linhas", nomes[c], meta[5], numeroLinhas)
+			}
+			valores := lerPagina(t, arquivo, int(meta[9].(int64)), int(meta[7].(int64)), tipos[c], numeroLinhas)
+			for i, valor := range valores {
+				linhas[base+i][c] = valor
+			}
+			tamanhoGrupo += meta[6].(int64)
+		}
+		if grupo[2] != tamanhoGrupo {
+			t.Fatalf("total_byte_size %v; soma das colunas %d", grupo[2], tamanhoGrupo)
+		}
+	}
+	if metadados[3] != int64(len(linhas)) {
+		t.Fatalf("num_rows %v; linhas nos grupos %d", metadados[3], len(linhas))
+	}
+	return linhas, nomes
+}
+
+// lerPagina lê a única página de dados de uma coluna e devolve os valores (nil = vazio)
+func lerPagina(t *testing.T, arquivo []byte, posicao, tamanhoColuna int, tipo int64, quantidade int) []interface{} {
+	t.Helper()
+	leitor := &leitorCompacto{dados: arquivo[posicao : posicao+tamanhoColuna]}
+	cabecalho := leitor.estrutura()
+	dadosPagina := cabecalho[5].(map[int16]interface{})
+	if cabecalho[1] != int64(tipoPaginaDados) || dadosPagina[1] != int64(quantidade) {
+		t.Fatalf("cabeçalho de página inesperado: %v", cabecalho)
+	}
+	tamanhoPagina := int(cabecalho[3].(int64))
+	if leitor.pos+tamanhoPagina != tamanhoColuna {
+		t.Fatalf("cabeçalho (%d) + página (%d) != tamanho da coluna (%d)", leitor.pos, tamanhoPagina, tamanhoColuna)
+	}
+	pagina := leitor.dados[leitor.pos:]
+
+	// Níveis de definição (largura 1), precedidos do tamanho
+	tamanhoNiveis := int(binary.LittleEndian.Uint32(pagina))
+	definidos := lerHibrido(t, pagina[4:4+tamanhoNiveis], quantidade)
+	dados := pagina[4+tamanhoNiveis:]
+
+	valores := make([]interface{}, quantidade)
+	pos, bit := 0, 0
+	for i, definido := range definidos {
+		if !definido {
+			continue
+		}
+		switch tipo {
+		case tipoFisicoBoolean:
+			valores[i] = dados[bit/8]&(1<<(bit%8)) != 0
+			bit++
+			pos = (bit + 7) / 8
+		case tipoFisicoInt64:
+			valores[i] = int64(binary.LittleEndian.Uint64(dados[pos:]))
+			pos += 8
+		case tipoFisicoByteArray:
+			n := int(binary.LittleEndian.Uint32(dados[pos:]))
+			valores[i] = string(dados[pos+4 : pos+4+n])
+			pos += 4 + n
+		default:
+			t.Fatalf("tipo físico inesperado %d", tipo)
+		}
+	}
+	if pos != len(dados) {
+		t.Fatalf("página com %d bytes de valores, lidos %d", len(dados), pos)
+	}
+	return valores
+}
+
+// lerHibrido descodifica níveis de largura 1 em RLE/bit-packing híbrido
+func lerHibrido(t *testing.T, dados []byte, quantidade int) []bool {
+	t.Helper()
+	var niveis []bool
+	for pos := 0; pos < len(dados); {
+		cabecalho, n := binary.Uvarint(dados[pos:])
+		pos += n
+		if cabecalho&1 == 1 {
+			for _, b := range dados[pos : pos+int(cabecalho>>1)] {
+				for bit := 0; bit < 8; bit++ {
+					niveis = append(niveis, b&(1<<bit) != 0)
+				}
+			}
+			pos += int(cabecalho >> 1)
+		} else {
+			for i := uint64(0); i < cabecalho>>1; i++ {
+				niveis = append(niveis, dados[pos] == 1)
+			}
+			pos++
+		}
+	}
+	if len(niveis) < quantidade {
+		t.Fatalf("%d níveis de definição para %d valores", len(niveis), quantidade)
+	}
+	return niveis[:quantidade]
+}
+
+// leitorCompacto lê o protocolo Thrift compact: estruturas como mapas id → valor, inteiros como
+// int64, binários como string e listas como []interface{}
+type leitorCompacto struct {
+	dados []byte
+	pos   int
+}
+
+func (l *leitorCompacto) varint() uint64 {
+	valor, n := binary.Uvarint(l.dados[l.pos:])
+	l.pos += n
+	return valor
+}
+
+func (l *leitorCompacto) estrutura() map[int16]interface{} {
+	campos := make(map[int16]interface{})
+	var id int16
+	for {
+		cabecalho := l.dados[l.pos]
+		l.pos++
+		if cabecalho == 0 {
+			return campos
+		}
+		if delta := int16(cabecalho >> 4); delta != 0 {
+			id += delta
+		} else {
+			v := l.varint()
+			id = int16(int64(v>>1) ^ -int64(v&1))
+		}
+		switch tipo := cabecalho & 0x0F; tipo {
+		case tipoCompactoVerdadeiro, tipoCompactoFalso:
+			campos[id] = tipo == tipoCompactoVerdadeiro
+		default:
+			campos[id] = l.valor(tipo)
+		}
+	}
+}
+
+func (l *leitorCompacto) valor(tipo byte) interface{} {
+	switch tipo {
+	case tipoCompactoI32, tipoCompactoI64:
+		v := l.varint()
+		return int64(v>>1) ^ -int64(v&1)
+	case tipoCompactoBinario:
+		n := int(l.varint())
+		texto := string(l.dados[l.pos : l.pos+n])
+		l.pos += n
+		return texto
+	case tipoCompactoLista:
+		cabecalho := l.dados[l.pos]
+		l.pos++
+		tamanho := int(cabecalho >> 4)
+		if tamanho == 15 {
+			tamanho = int(l.varint())
+		}
+		lista := make([]interface{}, tamanho)
+		for i := range lista {
+			lista[i] = l.valor(cabecalho & 0x0F)
+		}
+		return lista
+	case tipoCompactoEstrutura:
+		return l.estrutura()
+	}
+	panic(fmt.Sprintf("tipo Thrift compact %d não suportado pelo leitor de teste", tipo))
+}
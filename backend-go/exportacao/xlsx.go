@@ -0,0 +1,215 @@
+package exportacao
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Estilos definidos em estilosXLSX: 0 = normal, 1 = data/hora, 2 = cabeçalho
+const (
+	estiloDataHoraXLSX  = 1
+	estiloCabecalhoXLSX = 2
+)
+
+// epocaXLSX é o dia 0 das datas seriais do Excel (sistema 1900)
+var epocaXLSX = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+const tiposConteudoXLSX = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const relacoesPacoteXLSX = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const livroXLSX = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Ocorrências" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const relacoesLivroXLSX = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+const estilosXLSX = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="dd/mm/yyyy hh:mm:ss"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+</cellXfs>
+</styleSheet>`
+
+// escritorXLSX escreve a folha diretamente no zip, com strings inline (sem tabela partilhada
+// em memória); o zip é gerado em fluxo com descritores de dados
+type escritorXLSX struct {
+	zip     *zip.Writer
+	folha   *bufio.Writer
+	colunas []Coluna
+	fuso    *time.Location
+	letras  []string
+	linha   int
+}
+
+// novoEscritorXLSX escreve as partes fixas do pacote e abre a folha com o cabeçalho
+func novoEscritorXLSX(destino io.Writer, colunas []Coluna, fuso *time.Location) (*escritorXLSX, error) {
+	arquivo := zip.NewWriter(destino)
+
+	partes := []struct{ nome, conteudo string }{
+		{"[Content_Types].xml", tiposConteudoXLSX},
+		{"_rels/.rels", relacoesPacoteXLSX},
+		{"xl/workbook.xml", livroXLSX},
+		{"xl/_rels/workbook.xml.rels", relacoesLivroXLSX},
+		{"xl/styles.xml", estilosXLSX},
+	}
+	for _, parte := range partes {
+		w, err := criarParteXLSX(arquivo, parte.nome)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao criar %s: %v", parte.nome, err)
+		}
+		if _, err := io.WriteString(w, parte.conteudo); err != nil {
+			return nil, err
+		}
+	}
+
+	w, err := criarParteXLSX(arquivo, "xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar folha XLSX: %v", err)
+	}
+
+	e := &escritorXLSX{
+		zip:     arquivo,
+		folha:   bufio.NewWriter(w),
+		colunas: colunas,
+		fuso:    fuso,
+		letras:  make([]string, len(colunas)),
+	}
+	for i := range colunas {
+		e.letras[i] = letraColuna(i)
+	}
+
+	// Cabeçalho fixo no topo
+	e.folha.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>
+<cols>`)
+	for i, coluna := range colunas {
+		largura := 14
+		switch coluna.Tipo {
+		case TipoTexto:
+			largura = 24
+		case TipoDataHora:
+			largura = 20
+		}
+		fmt.Fprintf(e.folha, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, largura)
+	}
+	e.folha.WriteString("</cols>\n<sheetData>\n")
+
+	cabecalho := make([]interface{}, len(colunas))
+	for i, coluna := range colunas {
+		cabecalho[i] = tituloComFuso(coluna, fuso)
+	}
+	if err := e.escrever(cabecalho, estiloCabecalhoXLSX); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// EscreverLinha acrescenta uma linha à folha
+func (e *escritorXLSX) EscreverLinha(valores []interface{}) error {
+	return e.escrever(valores, 0)
+}
+
+// escrever gera o XML de uma linha; estilo > 0 aplica-se às células de texto (cabeçalho)
+func (e *escritorXLSX) escrever(valores []interface{}, estilo int) error {
+	e.linha++
+	fmt.Fprintf(e.folha, `<row r="%d">`, e.linha)
+
+	for i, valor := range valores {
+		referencia := e.letras[i] + strconv.Itoa(e.linha)
+		switch v := valor.(type) {
+		case nil:
+			continue
+		case int64:
+			fmt.Fprintf(e.folha, `<c r="%s"><v>%d</v></c>`, referencia, v)
+		case time.Time:
+			fmt.Fprintf(e.folha, `<c r="%s" s="%d"><v>%s</v></c>`, referencia, estiloDataHoraXLSX,
+				strconv.FormatFloat(serialXLSX(v.In(e.fuso)), 'f', -1, 64))
+		case bool:
+			e.celulaTexto(referencia, textoBooleano(v), estilo)
+		case string:
+			e.celulaTexto(referencia, v, estilo)
+		default:
+			e.celulaTexto(referencia, fmt.Sprint(v), estilo)
+		}
+	}
+
+	_, err := e.folha.WriteString("</row>\n")
+	return err
+}
+
+// celulaTexto escreve uma string inline com escape XML
+func (e *escritorXLSX) celulaTexto(referencia, texto string, estilo int) {
+	if estilo > 0 {
+		fmt.Fprintf(e.folha, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, referencia, estilo)
+	} else {
+		fmt.Fprintf(e.folha, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, referencia)
+	}
+	xml.EscapeText(e.folha, []byte(texto))
+	e.folha.WriteString("</t></is></c>")
+}
+
+// Fechar termina a folha e o pacote zip
+func (e *escritorXLSX) Fechar() error {
+	fmt.Fprintf(e.folha, "</sheetData>\n<autoFilter ref=\"A1:%s%d\"/>\n</worksheet>", e.letras[len(e.letras)-1], e.linha)
+	if err := e.folha.Flush(); err != nil {
+		return err
+	}
+	return e.zip.Close()
+}
+
+// criarParteXLSX abre uma entrada comprimida do pacote com a data atual
+func criarParteXLSX(arquivo *zip.Writer, nome string) (io.Writer, error) {
+	return arquivo.CreateHeader(&zip.FileHeader{Name: nome, Method: zip.Deflate, Modified: time.Now()})
+}
+
+// serialXLSX converte a hora de parede do fuso no número de dias do Excel
+func serialXLSX(instante time.Time) float64 {
+	parede := time.Date(instante.Year(), instante.Month(), instante.Day(),
+		instante.Hour(), instante.Minute(), instante.Second(), instante.Nanosecond(), time.UTC)
+	return parede.Sub(epocaXLSX).Hours() / 24
+}
+
+// letraColuna converte o índice (0 = A) na letra da coluna
+func letraColuna(indice int) string {
+	var letras strings.Builder
+	for indice >= 0 {
+		letras.WriteByte(byte('A' + indice%26))
+		indice = indice/26 - 1
+	}
+	runas := []byte(letras.String())
+	for i, j := 0, len(runas)-1; i < j; i, j = i+1, j-1 {
+		runas[i], runas[j] = runas[j], runas[i]
+	}
+	return string(runas)
+}
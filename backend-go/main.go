@@ -11,9 +11,16 @@ import (
 	"github.com/edp/falhas-backend/api"
 	"github.com/edp/falhas-backend/config"
 	"github.com/edp/falhas-backend/database"
+	"github.com/edp/falhas-backend/manutencao"
+	"github.com/edp/falhas-backend/notificacoes"
+	"github.com/edp/falhas-backend/plantao"
 	"github.com/edp/falhas-backend/plc"
+	"github.com/edp/falhas-backend/relatorios"
+	"github.com/edp/falhas-backend/repositorio"
+	"github.com/edp/falhas-backend/retencao"
+	"github.com/edp/falhas-backend/series"
+	"github.com/edp/falhas-backend/sincronizacao"
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
 )
 
 func main() {
@@ -22,59 +29,129 @@ func main() {
 		log.Println("Aviso: arquivo .env não encontrado, usando variáveis do sistema")
 	}
 
-	// SEMPRE criar/verificar banco de dados ao iniciar
-	fmt.Println("🔧 Verificando e criando banco de dados...")
-	err := database.CriarBancoCompleto()
-	if err != nil {
-		log.Fatalf("❌ Erro ao criar/verificar banco: %v", err)
+	// Subcomandos de ferramentas (sem subcomando = servidor completo)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			executarReplay(os.Args[2:])
+			return
+		case "simulador":
+			executarSimulador(os.Args[2:])
+			return
+		case "receptor":
+			executarReceptor(os.Args[2:])
+			return
+		case "migrate":
+			executarMigrate(os.Args[2:])
+			return
+		case "retencao":
+			executarRetencao(os.Args[2:])
+			return
+		case "backup":
+			executarBackup(os.Args[2:])
+			return
+		case "restore":
+			executarRestore(os.Args[2:])
+			return
+		default:
+			log.Fatalf("❌ Subcomando desconhecido: %s (disponíveis: replay, simulador, receptor, migrate, retencao, backup, restore)", os.Args[1])
+		}
 	}
-	fmt.Println("✅ Banco de dados pronto!\n")
 
 	// Carregar configurações
 	configuracoes := config.CarregarConfiguracoes()
 
-	// Conectar ao banco de dados
-	fmt.Println("🔗 Conectando ao banco de dados...")
-	dbHost := os.Getenv("DB_HOST")
-	if dbHost == "" {
-		dbHost = "localhost"
-	}
-	dbPort := os.Getenv("DB_PORT")
-	if dbPort == "" {
-		dbPort = "5432"
-	}
-	dbUser := os.Getenv("DB_USER")
-	if dbUser == "" {
-		dbUser = "postgres"
-	}
-	dbPassword := os.Getenv("DB_PASSWORD")
-	if dbPassword == "" {
-		log.Fatal("❌ DB_PASSWORD não configurado no .env")
-	}
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		dbName = "falhas_edp"
+	// SEMPRE criar/verificar banco de dados ao iniciar; o pool fica aberto para os servidores
+	fmt.Println("🔧 Verificando e criando banco de dados...")
+	var db *sql.DB
+	var err error
+	switch configuracoes.DB_Tipo {
+	case config.BancoPostgres:
+		db, err = database.CriarBancoCompleto(configuracoes)
+	case config.BancoSQLite:
+		db, err = database.AbrirSQLite(configuracoes)
+		desativarRecursosPostgres(configuracoes)
+	default:
+		log.Fatalf("❌ DB_TIPO inválido: %s (use %s ou %s)", configuracoes.DB_Tipo, config.BancoPostgres, config.BancoSQLite)
 	}
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPassword, dbName)
-	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		log.Fatalf("❌ Erro ao conectar ao banco: %v", err)
+		log.Fatalf("❌ Erro ao criar/verificar banco: %v", err)
 	}
 	defer db.Close()
-
-	// Testar conexão
-	if err = db.Ping(); err != nil {
-		log.Fatalf("❌ Erro ao testar conexão com banco: %v", err)
+	if configuracoes.DB_Tipo == config.BancoSQLite {
+		fmt.Printf("✅ Banco embutido pronto! (%s)\n", configuracoes.SQLite_Arquivo)
+	} else {
+		fmt.Printf("✅ Banco de dados pronto! (pool: até %d ligações, %d ociosas)\n",
+			configuracoes.DB_ConexoesMaximas, configuracoes.DB_ConexoesOciosas)
 	}
-	fmt.Println("✅ Conectado ao banco de dados!\n")
+	fmt.Println()
 
 	// Exibir banner
 	exibirBanner(configuracoes)
 
 	// Criar servidores
 	servidorTCP := plc.NovoServidorTCP(configuracoes, db)
-	servidorHTTP := api.NovoServidorHTTP(db)
+	servidorHTTP := api.NovoServidorHTTP(db, configuracoes)
+	servidorHTTP.DefinirFonteEstado(servidorTCP.ProjetorEstado())
+	servidorHTTP.DefinirRecarregadorDefinicoes(servidorTCP.Mapeamento())
+
+	// Agregação e retenção das séries analógicas
+	var agregadorSeries *series.Agregador
+	if configuracoes.Series_Ativo {
+		agregadorSeries = series.NovoAgregador(db, configuracoes)
+		agregadorSeries.Iniciar()
+	}
+
+	// Partições mensais e retenção das ocorrências (só PostgreSQL)
+	var servicoRetencao *retencao.Servico
+	if configuracoes.DB_Tipo == config.BancoPostgres {
+		servicoRetencao = retencao.NovoServico(db, configuracoes)
+		servicoRetencao.Iniciar()
+	}
+
+	// Relatórios agendados de turno, diários e mensais
+	var agendadorRelatorios *relatorios.Agendador
+	if configuracoes.Relatorios_Ativo {
+		agendadorRelatorios, err = relatorios.NovoAgendador(db, configuracoes)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		agendadorRelatorios.Iniciar()
+	}
+
+	// Notificações de alarmes por email, webhook e SMS
+	var notificador *notificacoes.Notificador
+	if configuracoes.Notificacoes_Ativo {
+		notificador = notificacoes.NovoNotificador(db, configuracoes)
+		notificador.Iniciar()
+	}
+
+	// Escalonamento das ocorrências não reconhecidas pelas equipas de plantão
+	var escalonador *plantao.Escalonador
+	if configuracoes.Escalonamento_Ativo {
+		escalonador = plantao.NovoEscalonador(db, configuracoes)
+		escalonador.Iniciar()
+	}
+
+	// Ordens de trabalho abertas por regra e sincronizadas com o CMMS
+	var integradorManutencao *manutencao.Integrador
+	if configuracoes.OrdensTrabalho_Ativo {
+		integradorManutencao, err = manutencao.NovoIntegrador(db, configuracoes)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		integradorManutencao.Iniciar()
+	}
+
+	// Envio das ocorrências desta eclusa para a instância central
+	var enviadorSincronizacao *sincronizacao.Enviador
+	if configuracoes.Sync_Ativo {
+		enviadorSincronizacao, err = sincronizacao.NovoEnviador(repositorio.Novo(db, configuracoes.DB_Tipo).Sincronizacao, configuracoes)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		enviadorSincronizacao.Iniciar()
+	}
 
 	// Canal para capturar sinais de interrupção
 	canalSinal := make(chan os.Signal, 1)
@@ -89,15 +166,15 @@ func main() {
 
 	// Iniciar servidor HTTP em goroutine
 	go func() {
-		if err := servidorHTTP.Iniciar(":8080"); err != nil {
+		if err := servidorHTTP.Iniciar(":" + configuracoes.ServidorHTTP_Porta); err != nil {
 			log.Fatalf("❌ Erro ao iniciar servidor HTTP: %v", err)
 		}
 	}()
 
 	fmt.Println("🚀 Sistema completo iniciado:")
 	fmt.Printf("   📡 TCP Server: %s:%s (recebimento PLC)\n", configuracoes.ServidorTCP_Host, configuracoes.ServidorTCP_Porta)
-	fmt.Println("   🌐 HTTP API: http://localhost:8080 (front-end)")
-	fmt.Println("   📋 Health Check: http://localhost:8080/api/v1/health")
+	fmt.Printf("   🌐 HTTP API: http://localhost:%s (front-end)\n", configuracoes.ServidorHTTP_Porta)
+	fmt.Printf("   📋 Health Check: http://localhost:%s/api/v1/health\n", configuracoes.ServidorHTTP_Porta)
 	fmt.Println("\n⏳ Aguardando conexões...")
 
 	// Aguardar sinal de interrupção
@@ -106,9 +183,54 @@ func main() {
 
 	// Encerrar servidor TCP gracefully
 	servidorTCP.Parar()
+	if agregadorSeries != nil {
+		agregadorSeries.Parar()
+	}
+	if servicoRetencao != nil {
+		servicoRetencao.Parar()
+	}
+	if agendadorRelatorios != nil {
+		agendadorRelatorios.Parar()
+	}
+	if escalonador != nil {
+		escalonador.Parar()
+	}
+	if integradorManutencao != nil {
+		integradorManutencao.Parar()
+	}
+	if enviadorSincronizacao != nil {
+		enviadorSincronizacao.Parar()
+	}
+	if notificador != nil {
+		notificador.Parar()
+	}
 	fmt.Println("✅ Servidores encerrados com sucesso")
 }
 
+// desativarRecursosPostgres desliga os módulos cujas tabelas só existem no PostgreSQL. No modo
+// embutido ficam as ocorrências, as definições, o histórico e a captura de frames.
+func desativarRecursosPostgres(cfg *config.Configuracoes) {
+	recursos := []struct {
+		nome  string
+		ativo *bool
+	}{
+		{"SOE", &cfg.SOE_Ativo},
+		{"séries analógicas", &cfg.Series_Ativo},
+		{"eclusagens", &cfg.Eclusagem_Ativa},
+		{"relatórios", &cfg.Relatorios_Ativo},
+		{"notificações", &cfg.Notificacoes_Ativo},
+		{"escalonamento", &cfg.Escalonamento_Ativo},
+		{"ordens de trabalho", &cfg.OrdensTrabalho_Ativo},
+		{"retenção de ocorrências", &cfg.Retencao_Ativo},
+	}
+	for _, recurso := range recursos {
+		if *recurso.ativo {
+			log.Printf("⚠️  Modo embutido (SQLite): %s desativado", recurso.nome)
+			*recurso.ativo = false
+		}
+	}
+}
+
 func exibirBanner(cfg *config.Configuracoes) {
 	fmt.Println("╔═══════════════════════════════════════════════════════╗")
 	fmt.Println("║     🔌 SISTEMA DE MONITORAMENTO DE FALHAS - EDP      ║")
@@ -0,0 +1,190 @@
+package manutencao
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+)
+
+// Tipos de conector CMMS (CMMS_TIPO)
+const (
+	CMMSNenhum = ""
+	CMMSMock   = "mock"
+	CMMSHTTP   = "http"
+)
+
+// Conector envia ordens de trabalho para um CMMS externo. Enviar cria a ordem quando
+// ReferenciaExterna está vazia (e devolve a referência atribuída pelo CMMS) ou atualiza-a.
+type Conector interface {
+	Nome() string
+	Enviar(ordem OrdemTrabalho) (string, error)
+}
+
+// NovoConector cria o conector configurado em CMMS_TIPO (nil = sem CMMS)
+func NovoConector(cfg *config.Configuracoes) (Conector, error) {
+	switch strings.ToLower(cfg.CMMS_Tipo) {
+	case CMMSNenhum:
+		return nil, nil
+	case CMMSMock:
+		return NovoConectorMock(), nil
+	case CMMSHTTP:
+		if cfg.CMMS_URL == "" {
+			return nil, fmt.Errorf("CMMS_URL é obrigatório com CMMS_TIPO=http")
+		}
+		if _, err := url.ParseRequestURI(cfg.CMMS_URL); err != nil {
+			return nil, fmt.Errorf("CMMS_URL inválido: %v", err)
+		}
+		return &ConectorHTTP{
+			url:     strings.TrimRight(cfg.CMMS_URL, "/"),
+			token:   cfg.CMMS_Token,
+			usuario: cfg.CMMS_Usuario,
+			senha:   cfg.CMMS_Senha,
+			cliente: &http.Client{Timeout: cfg.CMMS_Timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("CMMS_TIPO inválido: %s (use mock ou http)", cfg.CMMS_Tipo)
+	}
+}
+
+// DocumentoCMMS é o corpo JSON enviado ao CMMS. A correspondência para os campos do sistema
+// de destino (ex.: ordem PM do SAP: tipo, local técnico, centro de trabalho) fica no gateway.
+type DocumentoCMMS struct {
+	Numero       string    `json:"numero"`
+	Titulo       string    `json:"titulo"`
+	Descricao    string    `json:"descricao,omitempty"`
+	LocalTecnico string    `json:"local_tecnico"` // ECLUSA-SETOR
+	Equipa       string    `json:"equipa,omitempty"`
+	Prioridade   string    `json:"prioridade"`
+	Status       string    `json:"status"`
+	HorasMaoObra float64   `json:"horas_mao_obra"`
+	Pecas        []Peca    `json:"pecas"`
+	Ocorrencias  []int64   `json:"ocorrencias"`
+	CriadaEm     time.Time `json:"criada_em"`
+	AtualizadaEm time.Time `json:"atualizada_em"`
+}
+
+// NovoDocumentoCMMS converte a ordem no corpo enviado ao CMMS
+func NovoDocumentoCMMS(ordem OrdemTrabalho) DocumentoCMMS {
+	return DocumentoCMMS{
+		Numero:       NumeroOrdem(ordem.ID),
+		Titulo:       ordem.Titulo,
+		Descricao:    ordem.Descricao,
+		LocalTecnico: ordem.Eclusa + "-" + ordem.Setor,
+		Equipa:       ordem.Equipa,
+		Prioridade:   ordem.Prioridade,
+		Status:       ordem.Status,
+		HorasMaoObra: ordem.HorasMaoObra,
+		Pecas:        ordem.Pecas,
+		Ocorrencias:  ordem.Ocorrencias,
+		CriadaEm:     ordem.CriadaEm,
+		AtualizadaEm: ordem.AtualizadaEm,
+	}
+}
+
+// ConectorHTTP fala JSON com o CMMS (ou um gateway, ex.: SAP PM):
+// POST {url}/ordens cria e devolve {"id": "<referência>"}; PUT {url}/ordens/{referência} atualiza.
+type ConectorHTTP struct {
+	url     string
+	token   string
+	usuario string
+	senha   string
+	cliente *http.Client
+}
+
+// Nome identifica o conector nos logs
+func (c *ConectorHTTP) Nome() string {
+	return "HTTP " + c.url
+}
+
+// Enviar cria ou atualiza a ordem no CMMS
+func (c *ConectorHTTP) Enviar(ordem OrdemTrabalho) (string, error) {
+	corpo, err := json.Marshal(NovoDocumentoCMMS(ordem))
+	if err != nil {
+		return "", err
+	}
+
+	metodo, endereco := http.MethodPost, c.url+"/ordens"
+	if ordem.ReferenciaExterna != "" {
+		metodo, endereco = http.MethodPut, c.url+"/ordens/"+url.PathEscape(ordem.ReferenciaExterna)
+	}
+
+	pedido, err := http.NewRequest(metodo, endereco, bytes.NewReader(corpo))
+	if err != nil {
+		return "", err
+	}
+	pedido.Header.Set("Content-Type", "application/json")
+	pedido.Header.Set("Accept", "application/json")
+	switch {
+	case c.token != "":
+		pedido.Header.Set("Authorization", "Bearer "+c.token)
+	case c.usuario != "":
+		pedido.SetBasicAuth(c.usuario, c.senha)
+	}
+
+	resposta, err := c.cliente.Do(pedido)
+	if err != nil {
+		return "", err
+	}
+	defer resposta.Body.Close()
+
+	conteudo, _ := io.ReadAll(io.LimitReader(resposta.Body, 1<<20))
+	if resposta.StatusCode < 200 || resposta.StatusCode > 299 {
+		return "", fmt.Errorf("CMMS respondeu %s: %s", resposta.Status, strings.TrimSpace(string(conteudo)))
+	}
+
+	if ordem.ReferenciaExterna != "" {
+		return ordem.ReferenciaExterna, nil
+	}
+	var criada struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(conteudo, &criada); err != nil || len(criada.ID) == 0 {
+		return "", fmt.Errorf("resposta do CMMS sem 'id': %s", strings.TrimSpace(string(conteudo)))
+	}
+	// O id pode vir como texto ou como número
+	referencia := strings.Trim(string(criada.ID), `"`)
+	if referencia == "" || referencia == "null" {
+		return "", fmt.Errorf("resposta do CMMS sem 'id'")
+	}
+	return referencia, nil
+}
+
+// ConectorMock simula um CMMS (desenvolvimento e testes): atribui a referência MOCK-<id da ordem>
+// e regista no log cada criação e atualização.
+type ConectorMock struct{}
+
+// NovoConectorMock cria o CMMS simulado
+func NovoConectorMock() *ConectorMock {
+	return &ConectorMock{}
+}
+
+// Nome identifica o conector nos logs
+func (c *ConectorMock) Nome() string {
+	return "CMMS simulado"
+}
+
+// Enviar aceita sempre a ordem
+func (c *ConectorMock) Enviar(ordem OrdemTrabalho) (string, error) {
+	referencia := ordem.ReferenciaExterna
+	if referencia == "" {
+		referencia = fmt.Sprintf("MOCK-%06d", ordem.ID)
+		log.Printf("🧰 CMMS simulado: %s criada como %s (%s)", NumeroOrdem(ordem.ID), referencia, ordem.Titulo)
+	} else {
+		log.Printf("🧰 CMMS simulado: %s atualizada (status %s, %.1f h, %d peças)",
+			referencia, ordem.Status, ordem.HorasMaoObra, len(ordem.Pecas))
+	}
+	return referencia, nil
+}
+
+// SincronizacaoAtiva indica se as ordens gravadas devem ficar pendentes de envio para o CMMS
+func SincronizacaoAtiva(cfg *config.Configuracoes) bool {
+	return cfg.OrdensTrabalho_Ativo && cfg.CMMS_Tipo != CMMSNenhum
+}
@@ -0,0 +1,259 @@
+package manutencao
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/notificacoes"
+)
+
+const (
+	// janelaRecuperacao: ao arrancar, as ocorrências criadas há menos que isto ainda passam pelas regras
+	janelaRecuperacao = 15 * time.Minute
+	loteSincronizacao = 50
+
+	esperaInicialCMMS = time.Minute
+	esperaMaximaCMMS  = time.Hour
+)
+
+// Integrador abre ordens de trabalho pelas regras para as novas ocorrências e envia as ordens
+// pendentes para o CMMS (quando há conector). Os envios que falham são repetidos com espera crescente.
+type Integrador struct {
+	bancoDados  *sql.DB
+	conector    Conector
+	intervalo   time.Duration
+	ocorrencias *notificacoes.LeitorOcorrencias
+	canalParada chan struct{}
+	grupoWait   sync.WaitGroup
+}
+
+// NovoIntegrador cria o integrador com o conector configurado em CMMS_TIPO
+func NovoIntegrador(db *sql.DB, cfg *config.Configuracoes) (*Integrador, error) {
+	conector, err := NovoConector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &Integrador{
+		bancoDados:  db,
+		conector:    conector,
+		intervalo:   cfg.OrdensTrabalho_Intervalo,
+		ocorrencias: notificacoes.NovoLeitorOcorrencias(db, janelaRecuperacao),
+		canalParada: make(chan struct{}),
+	}
+	if i.intervalo <= 0 {
+		i.intervalo = 10 * time.Second
+	}
+	return i, nil
+}
+
+// Iniciar aplica as regras e sincroniza a cada intervalo, até Parar
+func (i *Integrador) Iniciar() {
+	if i.conector != nil {
+		log.Printf("🧰 Ordens de trabalho sincronizadas com %s", i.conector.Nome())
+	}
+
+	i.grupoWait.Add(1)
+	go func() {
+		defer i.grupoWait.Done()
+
+		temporizador := time.NewTicker(i.intervalo)
+		defer temporizador.Stop()
+
+		for {
+			i.ciclo()
+
+			select {
+			case <-i.canalParada:
+				return
+			case <-temporizador.C:
+			}
+		}
+	}()
+}
+
+// Parar espera o ciclo em curso terminar
+func (i *Integrador) Parar() {
+	close(i.canalParada)
+	i.grupoWait.Wait()
+}
+
+func (i *Integrador) ciclo() {
+	if err := i.aplicarRegras(); err != nil {
+		log.Printf("❌ Erro ao abrir ordens de trabalho: %v", err)
+	}
+	if i.conector != nil {
+		if err := i.sincronizarPendentes(); err != nil {
+			log.Printf("❌ Erro ao sincronizar ordens de trabalho: %v", err)
+		}
+	}
+}
+
+// aplicarRegras abre (ou agrupa) ordens para as novas ocorrências
+func (i *Integrador) aplicarRegras() error {
+	regras, err := CarregarRegrasOrdem(i.bancoDados)
+	if err != nil {
+		return err
+	}
+
+	return i.ocorrencias.Percorrer(func(o notificacoes.Ocorrencia) error {
+		for _, regra := range regras {
+			if !regra.Corresponde(o) {
+				continue
+			}
+			if err := i.aplicarRegra(regra, o); err != nil {
+				log.Printf("❌ Regra de ordem de trabalho %q, ocorrência %d: %v", regra.Nome, o.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (i *Integrador) aplicarRegra(regra RegraOrdem, o notificacoes.Ocorrencia) error {
+	// A releitura das ocorrências recentes repete ocorrências que podem já ter ordem desta regra
+	var existe bool
+	err := i.bancoDados.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM ordens_trabalho_ocorrencias l
+			JOIN ordens_trabalho ot ON l.ordem_id = ot.id
+			WHERE l.ocorrencia_id = $1 AND ot.regra_id = $2)`, o.ID, regra.ID).Scan(&existe)
+	if err != nil || existe {
+		return err
+	}
+
+	if regra.Agrupar {
+		var ordemID int64
+		err := i.bancoDados.QueryRow(`
+			SELECT ot.id
+			FROM ordens_trabalho ot
+			JOIN ordens_trabalho_ocorrencias l ON l.ordem_id = ot.id
+			JOIN ocorrencias_falhas oc ON l.ocorrencia_id = oc.id
+			WHERE ot.regra_id = $1 AND ot.status IN ('ABERTA', 'EM_EXECUCAO') AND oc.definicao_id = $2
+			ORDER BY ot.id DESC
+			LIMIT 1`, regra.ID, o.DefinicaoID).Scan(&ordemID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			return i.agrupar(ordemID, o.ID)
+		}
+	}
+
+	prioridade := regra.PrioridadeOrdem
+	if prioridade == "" {
+		prioridade = o.Prioridade
+	}
+	ordem := OrdemTrabalho{
+		Titulo: truncar(fmt.Sprintf("%s - %s", o.Codigo, o.Descricao), 200),
+		Descricao: fmt.Sprintf("Aberta pela regra %q a partir da ocorrência #%d (%s, %s) de %s.",
+			regra.Nome, o.ID, o.EclusaNome, o.SetorNome, o.Inicio.Format("02/01/2006 15:04:05")),
+		Equipa:      regra.Equipa,
+		Prioridade:  prioridade,
+		Origem:      OrigemRegra,
+		RegraID:     regra.ID,
+		Ocorrencias: []int64{o.ID},
+		CriadaPor:   "SISTEMA",
+	}
+	if err := SalvarOrdem(i.bancoDados, &ordem, i.conector != nil); err != nil {
+		return err
+	}
+	log.Printf("🧰 %s aberta pela regra %q (ocorrência %d)", ordem.Numero, regra.Nome, o.ID)
+	return nil
+}
+
+// agrupar liga a ocorrência a uma ordem já aberta
+func (i *Integrador) agrupar(ordemID, ocorrenciaID int64) error {
+	_, err := i.bancoDados.Exec(`
+		INSERT INTO ordens_trabalho_ocorrencias (ordem_id, ocorrencia_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, ordemID, ocorrenciaID)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.bancoDados.Exec(`
+		UPDATE ordens_trabalho
+		SET atualizada_em = NOW(),
+			estado_cmms = CASE WHEN $2 THEN 'PENDENTE' ELSE estado_cmms END,
+			proxima_sincronizacao = NOW()
+		WHERE id = $1`, ordemID, i.conector != nil)
+	if err != nil {
+		return err
+	}
+	log.Printf("🧰 Ocorrência %d agrupada em %s", ocorrenciaID, NumeroOrdem(ordemID))
+	return nil
+}
+
+// sincronizarPendentes envia para o CMMS as ordens pendentes ou com erro cuja espera terminou
+func (i *Integrador) sincronizarPendentes() error {
+	rows, err := i.bancoDados.Query(`
+		SELECT id, tentativas_cmms FROM ordens_trabalho
+		WHERE estado_cmms IN ('PENDENTE', 'ERRO') AND proxima_sincronizacao <= NOW()
+		ORDER BY proxima_sincronizacao
+		LIMIT $1`, loteSincronizacao)
+	if err != nil {
+		return err
+	}
+
+	tentativas := make(map[int64]int)
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			rows.Close()
+			return err
+		}
+		tentativas[id] = n
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		ordem, err := CarregarOrdem(i.bancoDados, id)
+		if err != nil {
+			log.Printf("❌ Erro ao carregar %s: %v", NumeroOrdem(id), err)
+			continue
+		}
+
+		referencia, err := i.conector.Enviar(*ordem)
+		if err != nil {
+			espera := esperaInicialCMMS << uint(tentativas[id])
+			if espera <= 0 || espera > esperaMaximaCMMS {
+				espera = esperaMaximaCMMS
+			}
+			log.Printf("⚠️  %s não enviada para o CMMS (nova tentativa em %v): %v", ordem.Numero, espera, err)
+			i.bancoDados.Exec(`
+				UPDATE ordens_trabalho
+				SET estado_cmms = 'ERRO', erro_cmms = $2, tentativas_cmms = tentativas_cmms + 1,
+					proxima_sincronizacao = NOW() + $3 * INTERVAL '1 second'
+				WHERE id = $1`, id, err.Error(), espera.Seconds())
+			continue
+		}
+
+		// Se a ordem mudou durante o envio, continua pendente para enviar a versão nova
+		_, err = i.bancoDados.Exec(`
+			UPDATE ordens_trabalho
+			SET referencia_externa = $2, erro_cmms = NULL, tentativas_cmms = 0, sincronizada_em = NOW(),
+				estado_cmms = CASE WHEN atualizada_em = $3 THEN 'SINCRONIZADA' ELSE 'PENDENTE' END
+			WHERE id = $1`, id, referencia, ordem.AtualizadaEm)
+		if err != nil {
+			log.Printf("❌ Erro ao registar sincronização de %s: %v", ordem.Numero, err)
+		}
+	}
+	return nil
+}
+
+// truncar corta o texto em limite bytes sem partir caracteres
+func truncar(texto string, limite int) string {
+	if len(texto) <= limite {
+		return texto
+	}
+	for limite > 0 && (texto[limite]&0xC0) == 0x80 {
+		limite--
+	}
+	return texto[:limite]
+}
@@ -0,0 +1,225 @@
+package manutencao
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/database"
+)
+
+// Estado de uma janela de manutenção (calculado a partir do início, do fim e do fim antecipado)
+const (
+	JanelaAgendada  = "AGENDADA"
+	JanelaEmCurso   = "EM_CURSO"
+	JanelaTerminada = "TERMINADA"
+)
+
+// JanelaManutencao é um período de manutenção planeada numa eclusa (ou só num setor). As ocorrências
+// abertas dentro da janela ficam ligadas a ela; com Ocultar ficam também fora da lista de ativas,
+// das notificações, do escalonamento, das regras de ordens de trabalho e dos indicadores.
+type JanelaManutencao struct {
+	ID               int        `json:"id"`
+	Eclusa           string     `json:"eclusa"`
+	Setor            string     `json:"setor,omitempty"` // Vazio = eclusa inteira
+	Inicio           time.Time  `json:"inicio"`
+	Fim              time.Time  `json:"fim"`
+	Motivo           string     `json:"motivo"`
+	Responsavel      string     `json:"responsavel"`
+	Ocultar          bool       `json:"ocultar"`
+	TerminadaEm      *time.Time `json:"terminada_em,omitempty"`
+	TerminadaPor     string     `json:"terminada_por,omitempty"`
+	Estado           string     `json:"estado"`
+	TotalOcorrencias int        `json:"total_ocorrencias"`
+	CriadaEm         time.Time  `json:"created_at"`
+}
+
+// FiltroJanelas seleciona as janelas listadas (campos vazios = sem filtro)
+type FiltroJanelas struct {
+	Eclusa string
+	Setor  string
+	Estado string
+	Limite int
+}
+
+// Validar normaliza os campos e verifica-os
+func (j *JanelaManutencao) Validar() error {
+	j.Eclusa = strings.ToUpper(strings.TrimSpace(j.Eclusa))
+	j.Setor = strings.TrimSpace(j.Setor)
+	j.Motivo = strings.TrimSpace(j.Motivo)
+	j.Responsavel = strings.TrimSpace(j.Responsavel)
+
+	if j.Eclusa == "" {
+		return fmt.Errorf("campo 'eclusa' é obrigatório")
+	}
+	if j.Motivo == "" {
+		return fmt.Errorf("campo 'motivo' é obrigatório")
+	}
+	if j.Responsavel == "" {
+		return fmt.Errorf("campo 'responsavel' é obrigatório")
+	}
+	if len(j.Responsavel) > 100 {
+		return fmt.Errorf("campo 'responsavel' tem mais de 100 caracteres")
+	}
+	if j.Inicio.IsZero() || j.Fim.IsZero() {
+		return fmt.Errorf("campos 'inicio' e 'fim' são obrigatórios")
+	}
+	if !j.Fim.After(j.Inicio) {
+		return fmt.Errorf("'fim' deve ser posterior a 'inicio'")
+	}
+	return nil
+}
+
+// expressaoEstadoJanela calcula o estado da janela jm no instante atual
+const expressaoEstadoJanela = `CASE
+			WHEN COALESCE(jm.terminada_em, jm.fim) <= NOW() THEN 'TERMINADA'
+			WHEN jm.inicio > NOW() THEN 'AGENDADA'
+			ELSE 'EM_CURSO' END`
+
+// CarregarJanela lê uma janela de manutenção; sql.ErrNoRows se não existir
+func CarregarJanela(db *sql.DB, id int) (*JanelaManutencao, error) {
+	janelas, err := consultarJanelas(db, " WHERE jm.id = $1", []interface{}{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(janelas) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &janelas[0], nil
+}
+
+// ListarJanelas lê as janelas mais recentes (por início) que correspondem ao filtro
+func ListarJanelas(db *sql.DB, filtro FiltroJanelas) ([]JanelaManutencao, error) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	argIndex := 1
+
+	if filtro.Eclusa != "" {
+		where += fmt.Sprintf(" AND e.codigo = $%d", argIndex)
+		args = append(args, strings.ToUpper(filtro.Eclusa))
+		argIndex++
+	}
+	if filtro.Setor != "" {
+		// As janelas da eclusa inteira também cobrem o setor
+		where += fmt.Sprintf(" AND (jm.setor_id IS NULL OR s.codigo = $%d)", argIndex)
+		args = append(args, filtro.Setor)
+		argIndex++
+	}
+	if filtro.Estado != "" {
+		where += fmt.Sprintf(" AND "+expressaoEstadoJanela+" = $%d", argIndex)
+		args = append(args, strings.ToUpper(filtro.Estado))
+		argIndex++
+	}
+	where += fmt.Sprintf(" ORDER BY jm.inicio DESC, jm.id DESC LIMIT $%d", argIndex)
+	args = append(args, filtro.Limite)
+
+	return consultarJanelas(db, where, args)
+}
+
+// consultarJanelas lê as janelas com o total de ocorrências ligadas a cada uma
+func consultarJanelas(db *sql.DB, where string, args []interface{}) ([]JanelaManutencao, error) {
+	rows, err := db.Query(`
+		SELECT jm.id, e.codigo, COALESCE(s.codigo, ''), jm.inicio, jm.fim, jm.motivo, jm.responsavel,
+			jm.ocultar, jm.terminada_em, COALESCE(jm.terminada_por, ''), `+expressaoEstadoJanela+`,
+			(SELECT COUNT(*) FROM ocorrencias_falhas o WHERE o.janela_manutencao_id = jm.id),
+			jm.created_at
+		FROM janelas_manutencao jm
+		JOIN eclusas e ON jm.eclusa_id = e.id
+		LEFT JOIN setores s ON jm.setor_id = s.id`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar janelas de manutenção: %v", err)
+	}
+	defer rows.Close()
+
+	janelas := []JanelaManutencao{}
+	for rows.Next() {
+		var j JanelaManutencao
+		var terminadaEm sql.NullTime
+		err := rows.Scan(&j.ID, &j.Eclusa, &j.Setor, &j.Inicio, &j.Fim, &j.Motivo, &j.Responsavel,
+			&j.Ocultar, &terminadaEm, &j.TerminadaPor, &j.Estado, &j.TotalOcorrencias, &j.CriadaEm)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler janela de manutenção: %v", err)
+		}
+		j.Inicio = database.HoraLocal(j.Inicio)
+		j.Fim = database.HoraLocal(j.Fim)
+		j.CriadaEm = database.HoraLocal(j.CriadaEm)
+		if terminadaEm.Valid {
+			t := database.HoraLocal(terminadaEm.Time)
+			j.TerminadaEm = &t
+		}
+		janelas = append(janelas, j)
+	}
+	return janelas, rows.Err()
+}
+
+// CriarJanela grava uma nova janela. As ocorrências já abertas no período coberto (ex.: janela
+// registada depois de a manutenção começar) ficam também ligadas a ela.
+func CriarJanela(db *sql.DB, j *JanelaManutencao) error {
+	if err := j.Validar(); err != nil {
+		return err
+	}
+
+	var eclusaID int
+	if err := db.QueryRow("SELECT id FROM eclusas WHERE codigo = $1", j.Eclusa).Scan(&eclusaID); err != nil {
+		return fmt.Errorf("eclusa não encontrada: %s", j.Eclusa)
+	}
+	var setorID interface{}
+	if j.Setor != "" {
+		var id int
+		err := db.QueryRow("SELECT id FROM setores WHERE codigo = $1 AND eclusa_id = $2", j.Setor, eclusaID).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("setor %s não encontrado na eclusa %s", j.Setor, j.Eclusa)
+		}
+		setorID = id
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO janelas_manutencao (eclusa_id, setor_id, inicio, fim, motivo, responsavel, ocultar)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`, eclusaID, setorID, j.Inicio.In(time.Local), j.Fim.In(time.Local),
+		j.Motivo, j.Responsavel, j.Ocultar).Scan(&j.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao criar janela de manutenção: %v", err)
+	}
+
+	// Uma janela que oculta tem preferência sobre outra que só marca
+	_, err = tx.Exec(`
+		UPDATE ocorrencias_falhas o
+		SET janela_manutencao_id = $1
+		FROM definicoes_falhas df
+		WHERE o.definicao_id = df.id
+		AND df.eclusa_id = $2 AND ($3::INTEGER IS NULL OR df.setor_id = $3)
+		AND o.timestamp_inicio >= $4 AND o.timestamp_inicio < $5
+		AND (o.janela_manutencao_id IS NULL OR ($6 AND NOT EXISTS (
+			SELECT 1 FROM janelas_manutencao outra WHERE outra.id = o.janela_manutencao_id AND outra.ocultar)))`,
+		j.ID, eclusaID, setorID, j.Inicio.In(time.Local), j.Fim.In(time.Local), j.Ocultar)
+	if err != nil {
+		return fmt.Errorf("erro ao ligar ocorrências à janela de manutenção: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar janela de manutenção: %v", err)
+	}
+	return nil
+}
+
+// TerminarJanela antecipa o fim da janela para agora (uma janela ainda agendada fica sem efeito).
+// Devolve false se a janela não existir ou já tiver terminado.
+func TerminarJanela(db *sql.DB, id int, terminadaPor string) (bool, error) {
+	result, err := db.Exec(`
+		UPDATE janelas_manutencao
+		SET terminada_em = GREATEST(inicio, NOW()), terminada_por = NULLIF($2, '')
+		WHERE id = $1 AND COALESCE(terminada_em, fim) > NOW()`, id, strings.TrimSpace(terminadaPor))
+	if err != nil {
+		return false, fmt.Errorf("erro ao terminar janela de manutenção: %v", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,402 @@
+package manutencao
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/edp/falhas-backend/database"
+)
+
+// Status de uma ordem de trabalho
+const (
+	StatusAberta     = "ABERTA"
+	StatusEmExecucao = "EM_EXECUCAO"
+	StatusConcluida  = "CONCLUIDA"
+	StatusCancelada  = "CANCELADA"
+)
+
+// Origem de uma ordem de trabalho
+const (
+	OrigemManual = "MANUAL"
+	OrigemRegra  = "REGRA"
+)
+
+// Estado da sincronização com o CMMS externo
+const (
+	CMMSNaoAplicavel = "NAO_APLICAVEL" // Sem CMMS configurado quando a ordem foi gravada
+	CMMSPendente     = "PENDENTE"
+	CMMSSincronizada = "SINCRONIZADA"
+	CMMSErro         = "ERRO" // Nova tentativa em proxima_sincronizacao
+)
+
+// Peca é um material usado (ou a usar) na ordem de trabalho
+type Peca struct {
+	Codigo     string  `json:"codigo"`
+	Descricao  string  `json:"descricao"`
+	Quantidade float64 `json:"quantidade"`
+	Unidade    string  `json:"unidade,omitempty"`
+}
+
+// OrdemTrabalho é uma ordem de manutenção aberta a partir de uma ou mais ocorrências
+type OrdemTrabalho struct {
+	ID                int64      `json:"id"`
+	Numero            string     `json:"numero"`
+	Titulo            string     `json:"titulo"`
+	Descricao         string     `json:"descricao,omitempty"`
+	Equipa            string     `json:"equipa,omitempty"`
+	Prioridade        string     `json:"prioridade"`
+	Status            string     `json:"status"`
+	Origem            string     `json:"origem"`
+	RegraID           int        `json:"regra_id,omitempty"`
+	Eclusa            string     `json:"eclusa"`
+	Setor             string     `json:"setor"`
+	HorasMaoObra      float64    `json:"horas_mao_obra"`
+	Pecas             []Peca     `json:"pecas"`
+	Ocorrencias       []int64    `json:"ocorrencias"`
+	CriadaPor         string     `json:"criada_por,omitempty"`
+	CriadaEm          time.Time  `json:"criada_em"`
+	AtualizadaEm      time.Time  `json:"atualizada_em"`
+	FechadaEm         *time.Time `json:"fechada_em,omitempty"`
+	ReferenciaExterna string     `json:"referencia_externa,omitempty"`
+	EstadoCMMS        string     `json:"estado_cmms"`
+	ErroCMMS          string     `json:"erro_cmms,omitempty"`
+	SincronizadaEm    *time.Time `json:"sincronizada_em,omitempty"`
+}
+
+// FiltroOrdens seleciona as ordens listadas (campos vazios = sem filtro)
+type FiltroOrdens struct {
+	Status       string
+	Equipa       string
+	Eclusa       string
+	EstadoCMMS   string
+	OcorrenciaID int64
+	Limite       int
+}
+
+// NumeroOrdem formata o número de uma ordem a partir do id
+func NumeroOrdem(id int64) string {
+	return fmt.Sprintf("OT-%06d", id)
+}
+
+// Validar normaliza os campos editáveis e verifica-os
+func (o *OrdemTrabalho) Validar() error {
+	o.Titulo = strings.TrimSpace(o.Titulo)
+	o.Equipa = strings.ToUpper(strings.TrimSpace(o.Equipa))
+	o.Prioridade = strings.ToUpper(strings.TrimSpace(o.Prioridade))
+	o.Status = strings.ToUpper(strings.TrimSpace(o.Status))
+
+	if o.Titulo == "" {
+		return fmt.Errorf("campo 'titulo' é obrigatório")
+	}
+	if len(o.Titulo) > 200 {
+		return fmt.Errorf("campo 'titulo' tem mais de 200 caracteres")
+	}
+	if o.Prioridade == "" {
+		o.Prioridade = "MEDIA"
+	}
+	if o.Prioridade != "ALTA" && o.Prioridade != "MEDIA" && o.Prioridade != "BAIXA" {
+		return fmt.Errorf("prioridade inválida: %s (use ALTA, MEDIA ou BAIXA)", o.Prioridade)
+	}
+	if o.Status == "" {
+		o.Status = StatusAberta
+	}
+	switch o.Status {
+	case StatusAberta, StatusEmExecucao, StatusConcluida, StatusCancelada:
+	default:
+		return fmt.Errorf("status inválido: %s (use ABERTA, EM_EXECUCAO, CONCLUIDA ou CANCELADA)", o.Status)
+	}
+	if o.HorasMaoObra < 0 {
+		return fmt.Errorf("campo 'horas_mao_obra' não pode ser negativo")
+	}
+	if len(o.Ocorrencias) == 0 {
+		return fmt.Errorf("a ordem de trabalho precisa de pelo menos uma ocorrência")
+	}
+
+	for i := range o.Pecas {
+		p := &o.Pecas[i]
+		p.Codigo = strings.TrimSpace(p.Codigo)
+		p.Descricao = strings.TrimSpace(p.Descricao)
+		p.Unidade = strings.TrimSpace(p.Unidade)
+		if p.Codigo == "" && p.Descricao == "" {
+			return fmt.Errorf("peça %d: indique 'codigo' ou 'descricao'", i+1)
+		}
+		if p.Quantidade <= 0 {
+			return fmt.Errorf("peça %d: 'quantidade' deve ser maior que zero", i+1)
+		}
+	}
+	if o.Pecas == nil {
+		o.Pecas = []Peca{}
+	}
+	return nil
+}
+
+// fechada indica se o status é final
+func fechada(status string) bool {
+	return status == StatusConcluida || status == StatusCancelada
+}
+
+// CarregarOrdem lê uma ordem com as peças e as ocorrências
+func CarregarOrdem(db *sql.DB, id int64) (*OrdemTrabalho, error) {
+	ordens, err := consultarOrdens(db, " WHERE ot.id = $1", []interface{}{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(ordens) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &ordens[0], nil
+}
+
+// ListarOrdens lê as ordens mais recentes que correspondem ao filtro
+func ListarOrdens(db *sql.DB, filtro FiltroOrdens) ([]OrdemTrabalho, error) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	argIndex := 1
+
+	for _, f := range []struct{ valor, coluna string }{
+		{filtro.Status, "ot.status"},
+		{filtro.Equipa, "eq.codigo"},
+		{filtro.Eclusa, "ot.eclusa_codigo"},
+		{filtro.EstadoCMMS, "ot.estado_cmms"},
+	} {
+		if f.valor != "" {
+			where += fmt.Sprintf(" AND %s = $%d", f.coluna, argIndex)
+			args = append(args, strings.ToUpper(f.valor))
+			argIndex++
+		}
+	}
+	if filtro.OcorrenciaID != 0 {
+		where += fmt.Sprintf(" AND ot.id IN (SELECT ordem_id FROM ordens_trabalho_ocorrencias WHERE ocorrencia_id = $%d)", argIndex)
+		args = append(args, filtro.OcorrenciaID)
+		argIndex++
+	}
+	where += fmt.Sprintf(" ORDER BY ot.id DESC LIMIT $%d", argIndex)
+	args = append(args, filtro.Limite)
+
+	return consultarOrdens(db, where, args)
+}
+
+// consultarOrdens lê as ordens da consulta e junta-lhes as peças e as ocorrências
+func consultarOrdens(db *sql.DB, where string, args []interface{}) ([]OrdemTrabalho, error) {
+	rows, err := db.Query(`
+		SELECT ot.id, ot.titulo, COALESCE(ot.descricao, ''), COALESCE(eq.codigo, ''), ot.prioridade, ot.status,
+			ot.origem, COALESCE(ot.regra_id, 0), ot.eclusa_codigo, ot.setor_codigo, ot.horas_mao_obra,
+			COALESCE(ot.criada_por, ''), ot.criada_em, ot.atualizada_em, ot.fechada_em,
+			COALESCE(ot.referencia_externa, ''), ot.estado_cmms, COALESCE(ot.erro_cmms, ''), ot.sincronizada_em
+		FROM ordens_trabalho ot
+		LEFT JOIN equipas eq ON ot.equipa_id = eq.id`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar ordens de trabalho: %v", err)
+	}
+
+	ordens := []OrdemTrabalho{}
+	indices := make(map[int64]int)
+	var ids []int64
+	for rows.Next() {
+		var o OrdemTrabalho
+		var fechadaEm, sincronizadaEm sql.NullTime
+		err := rows.Scan(&o.ID, &o.Titulo, &o.Descricao, &o.Equipa, &o.Prioridade, &o.Status,
+			&o.Origem, &o.RegraID, &o.Eclusa, &o.Setor, &o.HorasMaoObra,
+			&o.CriadaPor, &o.CriadaEm, &o.AtualizadaEm, &fechadaEm,
+			&o.ReferenciaExterna, &o.EstadoCMMS, &o.ErroCMMS, &sincronizadaEm)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("erro ao ler ordem de trabalho: %v", err)
+		}
+
+		o.Numero = NumeroOrdem(o.ID)
+		o.CriadaEm, o.AtualizadaEm = database.HoraLocal(o.CriadaEm), database.HoraLocal(o.AtualizadaEm)
+		if fechadaEm.Valid {
+			t := database.HoraLocal(fechadaEm.Time)
+			o.FechadaEm = &t
+		}
+		if sincronizadaEm.Valid {
+			t := database.HoraLocal(sincronizadaEm.Time)
+			o.SincronizadaEm = &t
+		}
+		o.Pecas, o.Ocorrencias = []Peca{}, []int64{}
+
+		indices[o.ID] = len(ordens)
+		ids = append(ids, o.ID)
+		ordens = append(ordens, o)
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return ordens, nil
+	}
+
+	pecas, err := db.Query(`
+		SELECT ordem_id, COALESCE(codigo, ''), COALESCE(descricao, ''), quantidade, COALESCE(unidade, '')
+		FROM pecas_ordem_trabalho WHERE ordem_id = ANY($1) ORDER BY id`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar peças: %v", err)
+	}
+	for pecas.Next() {
+		var ordemID int64
+		var p Peca
+		if err := pecas.Scan(&ordemID, &p.Codigo, &p.Descricao, &p.Quantidade, &p.Unidade); err != nil {
+			pecas.Close()
+			return nil, fmt.Errorf("erro ao ler peça: %v", err)
+		}
+		o := &ordens[indices[ordemID]]
+		o.Pecas = append(o.Pecas, p)
+	}
+	pecas.Close()
+
+	ligacoes, err := db.Query(`
+		SELECT ordem_id, ocorrencia_id FROM ordens_trabalho_ocorrencias
+		WHERE ordem_id = ANY($1) ORDER BY ocorrencia_id`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar ocorrências das ordens: %v", err)
+	}
+	defer ligacoes.Close()
+	for ligacoes.Next() {
+		var ordemID, ocorrenciaID int64
+		if err := ligacoes.Scan(&ordemID, &ocorrenciaID); err != nil {
+			return nil, fmt.Errorf("erro ao ler ocorrência da ordem: %v", err)
+		}
+		o := &ordens[indices[ordemID]]
+		o.Ocorrencias = append(o.Ocorrencias, ocorrenciaID)
+	}
+	return ordens, ligacoes.Err()
+}
+
+// SalvarOrdem cria a ordem (ID = 0) ou grava as alterações, com peças e ocorrências, numa transação.
+// Com sincronizar, a ordem fica pendente de envio para o CMMS.
+// Uma ordem CONCLUIDA ou CANCELADA já não pode mudar de status.
+func SalvarOrdem(db *sql.DB, o *OrdemTrabalho, sincronizar bool) error {
+	if err := o.Validar(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	var equipaID interface{}
+	if o.Equipa != "" {
+		var id int
+		if err := tx.QueryRow("SELECT id FROM equipas WHERE codigo = $1", o.Equipa).Scan(&id); err != nil {
+			return fmt.Errorf("equipa não encontrada: %s", o.Equipa)
+		}
+		equipaID = id
+	}
+
+	var encontradas int
+	err = tx.QueryRow("SELECT COUNT(*) FROM ocorrencias_falhas WHERE id = ANY($1)", pq.Array(o.Ocorrencias)).Scan(&encontradas)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar ocorrências: %v", err)
+	}
+	if encontradas != len(distintos(o.Ocorrencias)) {
+		return fmt.Errorf("ocorrência não encontrada na lista 'ocorrencias'")
+	}
+
+	estadoCMMS := CMMSNaoAplicavel
+	if sincronizar {
+		estadoCMMS = CMMSPendente
+	}
+
+	if o.ID == 0 {
+		// Eclusa e setor (local técnico no CMMS) vêm da primeira ocorrência
+		err = tx.QueryRow(`
+			SELECT e.codigo, s.codigo
+			FROM ocorrencias_falhas oc
+			JOIN definicoes_falhas df ON oc.definicao_id = df.id
+			JOIN setores s ON df.setor_id = s.id
+			JOIN eclusas e ON df.eclusa_id = e.id
+			WHERE oc.id = $1`, o.Ocorrencias[0]).Scan(&o.Eclusa, &o.Setor)
+		if err != nil {
+			return fmt.Errorf("erro ao ler eclusa da ocorrência %d: %v", o.Ocorrencias[0], err)
+		}
+		if o.Origem == "" {
+			o.Origem = OrigemManual
+		}
+
+		err = tx.QueryRow(`
+			INSERT INTO ordens_trabalho (titulo, descricao, equipa_id, prioridade, status, origem, regra_id,
+				eclusa_codigo, setor_codigo, horas_mao_obra, criada_por, estado_cmms, fechada_em)
+			VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, NULLIF($7, 0), $8, $9, $10, NULLIF($11, ''), $12,
+				CASE WHEN $5 IN ('CONCLUIDA', 'CANCELADA') THEN NOW() END)
+			RETURNING id`,
+			o.Titulo, o.Descricao, equipaID, o.Prioridade, o.Status, o.Origem, o.RegraID,
+			o.Eclusa, o.Setor, o.HorasMaoObra, o.CriadaPor, estadoCMMS).Scan(&o.ID)
+		if err != nil {
+			return fmt.Errorf("erro ao criar ordem de trabalho: %v", err)
+		}
+	} else {
+		var statusAtual string
+		err = tx.QueryRow("SELECT status FROM ordens_trabalho WHERE id = $1 FOR UPDATE", o.ID).Scan(&statusAtual)
+		if err == sql.ErrNoRows {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler ordem de trabalho: %v", err)
+		}
+		if fechada(statusAtual) && o.Status != statusAtual {
+			return fmt.Errorf("a ordem de trabalho já está %s", statusAtual)
+		}
+
+		// Cada alteração volta a ser enviada ao CMMS (atualiza a ordem já criada lá)
+		_, err = tx.Exec(`
+			UPDATE ordens_trabalho
+			SET titulo = $2, descricao = NULLIF($3, ''), equipa_id = $4, prioridade = $5, status = $6,
+				horas_mao_obra = $7, atualizada_em = NOW(),
+				fechada_em = CASE WHEN $6 IN ('CONCLUIDA', 'CANCELADA') THEN COALESCE(fechada_em, NOW()) END,
+				estado_cmms = CASE WHEN $8 THEN 'PENDENTE' ELSE estado_cmms END,
+				tentativas_cmms = CASE WHEN $8 THEN 0 ELSE tentativas_cmms END,
+				proxima_sincronizacao = NOW()
+			WHERE id = $1`,
+			o.ID, o.Titulo, o.Descricao, equipaID, o.Prioridade, o.Status, o.HorasMaoObra, sincronizar)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar ordem de trabalho: %v", err)
+		}
+
+		for _, tabela := range []string{"pecas_ordem_trabalho", "ordens_trabalho_ocorrencias"} {
+			if _, err := tx.Exec("DELETE FROM "+tabela+" WHERE ordem_id = $1", o.ID); err != nil {
+				return fmt.Errorf("erro ao substituir %s: %v", tabela, err)
+			}
+		}
+	}
+
+	for _, ocorrenciaID := range distintos(o.Ocorrencias) {
+		_, err := tx.Exec(`
+			INSERT INTO ordens_trabalho_ocorrencias (ordem_id, ocorrencia_id) VALUES ($1, $2)`, o.ID, ocorrenciaID)
+		if err != nil {
+			return fmt.Errorf("erro ao ligar ocorrência %d: %v", ocorrenciaID, err)
+		}
+	}
+	for _, p := range o.Pecas {
+		_, err := tx.Exec(`
+			INSERT INTO pecas_ordem_trabalho (ordem_id, codigo, descricao, quantidade, unidade)
+			VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, NULLIF($5, ''))`,
+			o.ID, p.Codigo, p.Descricao, p.Quantidade, p.Unidade)
+		if err != nil {
+			return fmt.Errorf("erro ao salvar peça: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao gravar ordem de trabalho: %v", err)
+	}
+	o.Numero = NumeroOrdem(o.ID)
+	return nil
+}
+
+// distintos remove ids repetidos mantendo a ordem
+func distintos(ids []int64) []int64 {
+	vistos := make(map[int64]bool, len(ids))
+	var resultado []int64
+	for _, id := range ids {
+		if !vistos[id] {
+			vistos[id] = true
+			resultado = append(resultado, id)
+		}
+	}
+	return resultado
+}
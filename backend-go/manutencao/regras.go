@@ -0,0 +1,125 @@
+package manutencao
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/notificacoes"
+)
+
+// RegraOrdem abre uma ordem de trabalho para cada nova ocorrência que corresponde aos filtros
+// (vazio = qualquer valor). Com Agrupar, as ativações repetidas da mesma falha juntam-se à
+// ordem ainda aberta dessa regra em vez de abrirem outra.
+type RegraOrdem struct {
+	ID              int       `json:"id"`
+	Nome            string    `json:"nome"`
+	Ativa           bool      `json:"ativa"`
+	Eclusa          string    `json:"eclusa,omitempty"`
+	Setor           string    `json:"setor,omitempty"`
+	Prioridade      string    `json:"prioridade,omitempty"`
+	DefinicaoID     int       `json:"definicao_id,omitempty"`
+	Equipa          string    `json:"equipa,omitempty"`
+	PrioridadeOrdem string    `json:"prioridade_ordem,omitempty"` // Vazio = prioridade da falha
+	Agrupar         bool      `json:"agrupar"`
+	CriadaEm        time.Time `json:"created_at"`
+}
+
+// Validar normaliza os campos e verifica-os
+func (r *RegraOrdem) Validar() error {
+	r.Nome = strings.TrimSpace(r.Nome)
+	r.Eclusa = strings.ToUpper(strings.TrimSpace(r.Eclusa))
+	r.Setor = strings.TrimSpace(r.Setor)
+	r.Prioridade = strings.ToUpper(strings.TrimSpace(r.Prioridade))
+	r.Equipa = strings.ToUpper(strings.TrimSpace(r.Equipa))
+	r.PrioridadeOrdem = strings.ToUpper(strings.TrimSpace(r.PrioridadeOrdem))
+
+	if r.Nome == "" {
+		return fmt.Errorf("campo 'nome' é obrigatório")
+	}
+	for _, prioridade := range []string{r.Prioridade, r.PrioridadeOrdem} {
+		if prioridade != "" && prioridade != "ALTA" && prioridade != "MEDIA" && prioridade != "BAIXA" {
+			return fmt.Errorf("prioridade inválida: %s (use ALTA, MEDIA ou BAIXA)", prioridade)
+		}
+	}
+	return nil
+}
+
+// Corresponde indica se a regra se aplica à ocorrência (as consequências suprimidas e as falhas
+// esperadas numa janela de manutenção nunca abrem ordens)
+func (r RegraOrdem) Corresponde(o notificacoes.Ocorrencia) bool {
+	return r.Ativa && !o.Suprimida && !o.EmManutencao &&
+		(r.Eclusa == "" || r.Eclusa == o.EclusaCodigo) &&
+		(r.Setor == "" || r.Setor == o.SetorCodigo) &&
+		(r.Prioridade == "" || r.Prioridade == o.Prioridade) &&
+		(r.DefinicaoID == 0 || r.DefinicaoID == o.DefinicaoID)
+}
+
+// CarregarRegrasOrdem lê as regras de abertura de ordens, por id
+func CarregarRegrasOrdem(db *sql.DB) ([]RegraOrdem, error) {
+	rows, err := db.Query(`
+		SELECT r.id, r.nome, r.ativa, COALESCE(r.eclusa_codigo, ''), COALESCE(r.setor_codigo, ''),
+			COALESCE(r.prioridade, ''), COALESCE(r.definicao_id, 0), COALESCE(eq.codigo, ''),
+			COALESCE(r.prioridade_ordem, ''), r.agrupar, r.created_at
+		FROM regras_ordem_trabalho r
+		LEFT JOIN equipas eq ON r.equipa_id = eq.id
+		ORDER BY r.id`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras de ordens de trabalho: %v", err)
+	}
+	defer rows.Close()
+
+	var regras []RegraOrdem
+	for rows.Next() {
+		var r RegraOrdem
+		err := rows.Scan(&r.ID, &r.Nome, &r.Ativa, &r.Eclusa, &r.Setor, &r.Prioridade, &r.DefinicaoID,
+			&r.Equipa, &r.PrioridadeOrdem, &r.Agrupar, &r.CriadaEm)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler regra de ordem de trabalho: %v", err)
+		}
+		regras = append(regras, r)
+	}
+	return regras, rows.Err()
+}
+
+// SalvarRegraOrdem cria a regra (ID = 0) ou substitui-a; sql.ErrNoRows se o id não existir
+func SalvarRegraOrdem(db *sql.DB, r *RegraOrdem) error {
+	if err := r.Validar(); err != nil {
+		return err
+	}
+
+	var equipaID interface{}
+	if r.Equipa != "" {
+		var id int
+		if err := db.QueryRow("SELECT id FROM equipas WHERE codigo = $1", r.Equipa).Scan(&id); err != nil {
+			return fmt.Errorf("equipa não encontrada: %s", r.Equipa)
+		}
+		equipaID = id
+	}
+
+	args := []interface{}{r.Nome, r.Ativa, r.Eclusa, r.Setor, r.Prioridade, r.DefinicaoID, equipaID, r.PrioridadeOrdem, r.Agrupar}
+	var err error
+	if r.ID == 0 {
+		err = db.QueryRow(`
+			INSERT INTO regras_ordem_trabalho (nome, ativa, eclusa_codigo, setor_codigo, prioridade, definicao_id,
+				equipa_id, prioridade_ordem, agrupar)
+			VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, 0), $7, NULLIF($8, ''), $9)
+			RETURNING id, created_at`, args...).Scan(&r.ID, &r.CriadaEm)
+	} else {
+		err = db.QueryRow(`
+			UPDATE regras_ordem_trabalho
+			SET nome = $1, ativa = $2, eclusa_codigo = NULLIF($3, ''), setor_codigo = NULLIF($4, ''),
+				prioridade = NULLIF($5, ''), definicao_id = NULLIF($6, 0), equipa_id = $7,
+				prioridade_ordem = NULLIF($8, ''), agrupar = $9
+			WHERE id = $10
+			RETURNING created_at`, append(args, r.ID)...).Scan(&r.CriadaEm)
+	}
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao salvar regra de ordem de trabalho: %v", err)
+	}
+	return nil
+}
@@ -20,6 +20,11 @@ type MudancaBit struct {
 	DataHora     time.Time `json:"data_hora"`
 	Setor        string    `json:"setor"`        // Agora é string para flexibilidade
 	Tipo         string    `json:"tipo"`         // Agora é string para código da falha
+	
+	// Sequence-of-events (SOE)
+	SequenciaFrame uint64     `json:"sequencia_frame"`
+	DataHoraPLC    *time.Time `json:"data_hora_plc,omitempty"` // Timestamp enviado pelo PLC (se houver)
+	LeituraInicial bool       `json:"leitura_inicial"`         // Bit já ativo na primeira leitura da WORD
 }
 
 // MensagemPLC representa uma mensagem completa recebida do PLC
@@ -27,6 +32,9 @@ type MensagemPLC struct {
 	Words    []DadosWord `json:"words"`
 	DataHora time.Time   `json:"data_hora"`
 	IdPLC    string      `json:"id_plc"`
+	
+	Sequencia   uint64     `json:"sequencia"`               // Número sequencial do frame
+	DataHoraPLC *time.Time `json:"data_hora_plc,omitempty"` // Timestamp do cabeçalho do frame (se houver)
 }
 
 // TagEclusa representa um tag mapeado da eclusa
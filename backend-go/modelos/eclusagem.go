@@ -0,0 +1,44 @@
+package modelos
+
+import "time"
+
+// Direção da eclusagem
+const (
+	DirecaoSubida  = "SUBIDA"  // Enchimento da caldeira
+	DirecaoDescida = "DESCIDA" // Esvaziamento da caldeira
+)
+
+// Status da eclusagem
+const (
+	StatusEclusagemEmCurso   = "EM_CURSO"
+	StatusEclusagemConcluida = "CONCLUIDA"
+	StatusEclusagemAbortada  = "ABORTADA"
+	StatusEclusagemTimeout   = "TIMEOUT"
+)
+
+// Fases de uma eclusagem gravada (a preparação, entre o fecho da porta e o início do
+// nivelamento, só é gravada quando o nivelamento começa)
+const (
+	FaseEclusagemNivelamento = "NIVELAMENTO" // Enchimento ou esvaziamento
+	FaseEclusagemAbertura    = "ABERTURA"    // Nivelamento concluído, à espera da abertura da porta de saída
+	FaseEclusagemFim         = "FIM"
+)
+
+// Eclusagem é um ciclo fecho de porta -> enchimento/esvaziamento -> abertura da porta oposta
+type Eclusagem struct {
+	ID                         int64      `json:"id"`
+	EclusaCodigo               string     `json:"eclusa_codigo"`
+	Direcao                    string     `json:"direcao"`
+	Status                     string     `json:"status"`
+	Fase                       string     `json:"fase"`
+	TimestampInicio            time.Time  `json:"timestamp_inicio"`
+	TimestampInicioNivelamento *time.Time `json:"timestamp_inicio_nivelamento,omitempty"`
+	TimestampFimNivelamento    *time.Time `json:"timestamp_fim_nivelamento,omitempty"`
+	TimestampFim               *time.Time `json:"timestamp_fim,omitempty"`
+	DuracaoPreparacaoSegundos  *float64   `json:"duracao_preparacao_segundos,omitempty"`
+	DuracaoNivelamentoSegundos *float64   `json:"duracao_nivelamento_segundos,omitempty"`
+	DuracaoAberturaSegundos    *float64   `json:"duracao_abertura_segundos,omitempty"`
+	DuracaoTotalSegundos       *float64   `json:"duracao_total_segundos,omitempty"`
+	Motivo                     string     `json:"motivo,omitempty"` // Causa de ABORTADA/TIMEOUT
+	TotalFalhas                int        `json:"total_falhas"`
+}
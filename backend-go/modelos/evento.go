@@ -17,6 +17,7 @@ type RegistroEvento struct {
 
 // EstadoEclusa representa o estado atual da eclusa
 type EstadoEclusa struct {
+	EclusaCodigo        string    `json:"eclusa_codigo"`
 	EnchimentoAtivo     bool      `json:"enchimento_ativo"`
 	EsvaziamentoAtivo   bool      `json:"esvaziamento_ativo"`
 	PortaJusanteAberta  bool      `json:"porta_jusante_aberta"`
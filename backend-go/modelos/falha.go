@@ -39,6 +39,11 @@ type DefinicaoFalha struct {
 	ClasseMensagem string `json:"classe_mensagem"`
 	Ativa          bool   `json:"ativa"`
 	
+	// Modelo de severidade
+	Criticidade          int  `json:"criticidade"`            // 1 (mínima) a 5 (máxima)
+	RelacionadaSeguranca bool `json:"relacionada_seguranca"`
+	TempoRespostaMinutos int  `json:"tempo_resposta_minutos"` // SLA de resposta (0 = sem SLA)
+	
 	// Campos adicionais para mapeamento
 	SetorCodigo   string `json:"setor_codigo"`
 	SetorNome     string `json:"setor_nome"`
@@ -56,4 +61,19 @@ type RegistroFalha struct {
 	Ativo           bool         `json:"ativo"`
 	DataHora        time.Time    `json:"data_hora"`
 	DuracaoSegundos int64        `json:"duracao_segundos"` // Duração em segundos (se desativado)
-}
\ No newline at end of file
+}
+
+// ContextoOcorrencia é o retrato do frame PLC gravado em dados_contexto na ativação
+type ContextoOcorrencia struct {
+	DataHoraFrame time.Time           `json:"data_hora_frame"`
+	WordIndex     int                 `json:"word_index"`
+	BitIndex      int                 `json:"bit_index"`
+	Words         []ValorWordContexto `json:"words"`
+}
+
+// ValorWordContexto guarda o valor bruto de uma WORD no momento da ativação
+type ValorWordContexto struct {
+	Endereco int    `json:"endereco"`
+	Valor    uint16 `json:"valor"`
+	Hex      string `json:"hex"`
+}
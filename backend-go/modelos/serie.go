@@ -0,0 +1,52 @@
+package modelos
+
+import "time"
+
+// Tipos de dado das tags analógicas (uma ou duas WORDs do frame, Big Endian)
+const (
+	TipoDadoInt16  = "INT16"
+	TipoDadoUint16 = "UINT16"
+	TipoDadoInt32  = "INT32" // Duas WORDs, a primeira é a mais significativa
+	TipoDadoReal   = "REAL"  // IEEE 754 de 32 bits em duas WORDs
+)
+
+// TagAnalogica descreve um valor de processo lido das WORDs do frame
+type TagAnalogica struct {
+	ID                      int     `json:"id"`
+	Tag                     string  `json:"tag"`
+	EclusaID                *int    `json:"eclusa_id,omitempty"`
+	EclusaCodigo            string  `json:"eclusa_codigo,omitempty"`
+	Descricao               string  `json:"descricao"`
+	Unidade                 string  `json:"unidade"`
+	WordIndex               int     `json:"word_index"`
+	TipoDado                string  `json:"tipo_dado"`
+	Escala                  float64 `json:"escala"` // valor = bruto * escala + deslocamento
+	Deslocamento            float64 `json:"deslocamento"`
+	BandaMorta              float64 `json:"banda_morta"`               // Variação mínima para gravar nova amostra
+	IntervaloMaximoSegundos int     `json:"intervalo_maximo_segundos"` // Grava ao menos uma amostra neste intervalo
+	Ativa                   bool    `json:"ativa"`
+}
+
+// QuantidadeWords devolve quantas WORDs o tipo de dado ocupa
+func (t TagAnalogica) QuantidadeWords() int {
+	if t.TipoDado == TipoDadoInt32 || t.TipoDado == TipoDadoReal {
+		return 2
+	}
+	return 1
+}
+
+// AmostraSerie é um valor analógico num instante
+type AmostraSerie struct {
+	TagID     int       `json:"tag_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Valor     float64   `json:"valor"`
+}
+
+// PontoSerie é um ponto devolvido pela API de séries (bruto ou agregado)
+type PontoSerie struct {
+	Timestamp time.Time `json:"t"`
+	Valor     float64   `json:"v"`             // Valor bruto ou média do intervalo
+	Minimo    *float64  `json:"min,omitempty"` // Apenas em agregados
+	Maximo    *float64  `json:"max,omitempty"`
+	Amostras  int       `json:"n,omitempty"`
+}
@@ -0,0 +1,227 @@
+package notificacoes
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+)
+
+// Canais de entrega suportados
+const (
+	CanalEmail   = "EMAIL"
+	CanalWebhook = "WEBHOOK"
+	CanalSMS     = "SMS"
+)
+
+// limiteTextoSMS evita que o gateway parta a mensagem em muitos SMS
+const limiteTextoSMS = 300
+
+var expressaoTelefone = regexp.MustCompile(`^\+?[0-9]{6,15}$`)
+
+// Mensagem é o conteúdo entregue a um destino. Dados segue no corpo dos webhooks.
+type Mensagem struct {
+	Assunto string                 `json:"assunto"`
+	Texto   string                 `json:"mensagem"`
+	Motivo  string                 `json:"motivo"`
+	Dados   map[string]interface{} `json:"ocorrencia,omitempty"`
+}
+
+// Canal entrega uma mensagem a um endereço (email, URL ou número de telefone)
+type Canal interface {
+	Enviar(endereco string, mensagem Mensagem) error
+}
+
+// NovosCanais cria os canais a partir da configuração; canais sem configuração devolvem erro ao enviar
+func NovosCanais(cfg *config.Configuracoes) map[string]Canal {
+	cliente := &http.Client{Timeout: cfg.Notificacoes_Timeout}
+	return map[string]Canal{
+		CanalEmail: &canalEmail{
+			servidor:  cfg.SMTP_Host,
+			porta:     cfg.SMTP_Porta,
+			usuario:   cfg.SMTP_Usuario,
+			senha:     cfg.SMTP_Senha,
+			remetente: cfg.SMTP_Remetente,
+			timeout:   cfg.Notificacoes_Timeout,
+		},
+		CanalWebhook: &canalWebhook{cliente: cliente},
+		CanalSMS:     &canalSMS{cliente: cliente, url: cfg.SMS_URL, token: cfg.SMS_Token},
+	}
+}
+
+// CanalValido indica se o canal é suportado
+func CanalValido(canal string) bool {
+	return canal == CanalEmail || canal == CanalWebhook || canal == CanalSMS
+}
+
+// ValidarEndereco verifica se o endereço tem o formato esperado pelo canal
+func ValidarEndereco(canal, endereco string) error {
+	switch canal {
+	case CanalEmail:
+		if _, err := mail.ParseAddress(endereco); err != nil {
+			return fmt.Errorf("email inválido: %s", endereco)
+		}
+	case CanalWebhook:
+		u, err := url.Parse(endereco)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("URL de webhook inválida: %s (use http:// ou https://)", endereco)
+		}
+	case CanalSMS:
+		if !expressaoTelefone.MatchString(strings.ReplaceAll(endereco, " ", "")) {
+			return fmt.Errorf("número de telefone inválido: %s (use o formato internacional, ex.: +351912345678)", endereco)
+		}
+	default:
+		return fmt.Errorf("canal inválido: %s (use EMAIL, WEBHOOK ou SMS)", canal)
+	}
+	return nil
+}
+
+// canalEmail envia por SMTP; a autenticação só é usada quando há utilizador configurado
+type canalEmail struct {
+	servidor  string
+	porta     string
+	usuario   string
+	senha     string
+	remetente string
+	timeout   time.Duration
+}
+
+func (c *canalEmail) Enviar(endereco string, mensagem Mensagem) error {
+	if c.servidor == "" {
+		return fmt.Errorf("canal EMAIL não configurado (SMTP_HOST vazio)")
+	}
+
+	var corpo bytes.Buffer
+	fmt.Fprintf(&corpo, "From: %s\r\n", c.remetente)
+	fmt.Fprintf(&corpo, "To: %s\r\n", endereco)
+	fmt.Fprintf(&corpo, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mensagem.Assunto))
+	fmt.Fprintf(&corpo, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	corpo.WriteString("MIME-Version: 1.0\r\n")
+	corpo.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	corpo.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	corpo.WriteString(strings.ReplaceAll(mensagem.Texto, "\n", "\r\n"))
+	corpo.WriteString("\r\n")
+
+	return c.enviarSMTP(endereco, corpo.Bytes())
+}
+
+// enviarSMTP faz o mesmo que smtp.SendMail, mas com limite de tempo na ligação
+func (c *canalEmail) enviarSMTP(endereco string, corpo []byte) error {
+	conexao, err := net.DialTimeout("tcp", net.JoinHostPort(c.servidor, c.porta), c.timeout)
+	if err != nil {
+		return fmt.Errorf("erro ao ligar ao servidor SMTP: %v", err)
+	}
+	conexao.SetDeadline(time.Now().Add(c.timeout))
+
+	cliente, err := smtp.NewClient(conexao, c.servidor)
+	if err != nil {
+		conexao.Close()
+		return fmt.Errorf("erro ao iniciar sessão SMTP: %v", err)
+	}
+	defer cliente.Close()
+
+	if ok, _ := cliente.Extension("STARTTLS"); ok {
+		if err := cliente.StartTLS(&tls.Config{ServerName: c.servidor}); err != nil {
+			return fmt.Errorf("erro no STARTTLS: %v", err)
+		}
+	}
+	if c.usuario != "" {
+		if err := cliente.Auth(smtp.PlainAuth("", c.usuario, c.senha, c.servidor)); err != nil {
+			return fmt.Errorf("erro na autenticação SMTP: %v", err)
+		}
+	}
+	if err := cliente.Mail(c.remetente); err != nil {
+		return fmt.Errorf("remetente recusado: %v", err)
+	}
+	if err := cliente.Rcpt(endereco); err != nil {
+		return fmt.Errorf("destinatário recusado: %v", err)
+	}
+
+	escritor, err := cliente.Data()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar DATA: %v", err)
+	}
+	if _, err := escritor.Write(corpo); err != nil {
+		return fmt.Errorf("erro ao enviar mensagem: %v", err)
+	}
+	if err := escritor.Close(); err != nil {
+		return fmt.Errorf("mensagem recusada: %v", err)
+	}
+	return cliente.Quit()
+}
+
+// canalWebhook faz POST da mensagem em JSON para a URL do destino
+type canalWebhook struct {
+	cliente *http.Client
+}
+
+func (c *canalWebhook) Enviar(endereco string, mensagem Mensagem) error {
+	corpo, err := json.Marshal(mensagem)
+	if err != nil {
+		return err
+	}
+	return enviarPOST(c.cliente, endereco, "", corpo)
+}
+
+// canalSMS envia {"para", "texto"} ao gateway configurado; o texto é o assunto, truncado
+type canalSMS struct {
+	cliente *http.Client
+	url     string
+	token   string
+}
+
+func (c *canalSMS) Enviar(endereco string, mensagem Mensagem) error {
+	if c.url == "" {
+		return fmt.Errorf("canal SMS não configurado (SMS_GATEWAY_URL vazio)")
+	}
+
+	texto := []rune(mensagem.Assunto)
+	if len(texto) > limiteTextoSMS {
+		texto = append(texto[:limiteTextoSMS-1], '…')
+	}
+	corpo, err := json.Marshal(map[string]string{
+		"para":  strings.ReplaceAll(endereco, " ", ""),
+		"texto": string(texto),
+	})
+	if err != nil {
+		return err
+	}
+	return enviarPOST(c.cliente, c.url, c.token, corpo)
+}
+
+// enviarPOST envia JSON e trata qualquer resposta fora de 2xx como falha
+func enviarPOST(cliente *http.Client, endereco, token string, corpo []byte) error {
+	pedido, err := http.NewRequest(http.MethodPost, endereco, bytes.NewReader(corpo))
+	if err != nil {
+		return fmt.Errorf("erro ao criar pedido HTTP: %v", err)
+	}
+	pedido.Header.Set("Content-Type", "application/json")
+	pedido.Header.Set("User-Agent", "falhas-backend")
+	if token != "" {
+		pedido.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resposta, err := cliente.Do(pedido)
+	if err != nil {
+		return fmt.Errorf("erro no pedido HTTP: %v", err)
+	}
+	defer resposta.Body.Close()
+
+	if resposta.StatusCode < 200 || resposta.StatusCode > 299 {
+		detalhe, _ := io.ReadAll(io.LimitReader(resposta.Body, 200))
+		return fmt.Errorf("resposta HTTP %d: %s", resposta.StatusCode, strings.TrimSpace(string(detalhe)))
+	}
+	return nil
+}
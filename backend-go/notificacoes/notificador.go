@@ -0,0 +1,506 @@
+package notificacoes
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/database"
+)
+
+// Motivos de envio (um envio por ocorrência, motivo e destino)
+const (
+	MotivoAtivacao = "ATIVACAO"
+	MotivoTeste    = "TESTE"
+)
+
+// Estados de um envio no registo
+const (
+	EnvioPendente = "PENDENTE"
+	EnvioEnviado  = "ENVIADO"
+	EnvioFalhou   = "FALHOU"
+)
+
+const (
+	// janelaRecuperacao: ao arrancar, as ocorrências criadas há menos que isto ainda são avaliadas
+	// (o UNIQUE do registo de envios impede duplicados)
+	janelaRecuperacao = 15 * time.Minute
+	// esperaMaxima limita o crescimento exponencial entre tentativas
+	esperaMaxima    = time.Hour
+	loteOcorrencias = 500
+	loteEnvios      = 100
+	// margemReleitura cobre as ocorrências confirmadas depois de outras criadas mais tarde
+	margemReleitura = 2 * time.Minute
+)
+
+// IdadeMaximaAtivacao é o tempo, desde o início, durante o qual uma ocorrência ativa ainda notifica
+// e inicia escalonamento (ex.: não os alarmes antigos de uma eclusa que esteve sem ligação ao central)
+const IdadeMaximaAtivacao = time.Hour
+
+// formatoDataHora é usado no texto das mensagens
+const formatoDataHora = "02/01/2006 15:04:05"
+
+// Ocorrencia reúne os dados de uma ocorrência usados pelas regras e pelas mensagens
+type Ocorrencia struct {
+	ID                   int64
+	DefinicaoID          int
+	Codigo               string
+	Tipo                 string
+	Descricao            string
+	Prioridade           string
+	Criticidade          int
+	RelacionadaSeguranca bool
+	SetorCodigo          string
+	SetorNome            string
+	EclusaCodigo         string
+	EclusaNome           string
+	Status               string
+	Inicio               time.Time
+	FirstOut             bool
+	Suprimida            bool
+	EmManutencao         bool      // Aberta numa janela de manutenção que oculta as ocorrências
+	Criacao              time.Time // created_at, a posição do LeitorOcorrencias
+}
+
+// colunasOcorrencia são as colunas lidas por lerOcorrencia (com os JOINs de definição, setor, eclusa e
+// janela de manutenção)
+const colunasOcorrencia = `
+	o.id, df.id, df.codigo, df.tipo, df.descricao, df.prioridade, df.criticidade, df.relacionada_seguranca,
+	s.codigo, s.nome, e.codigo, e.nome, o.status, o.timestamp_inicio, o.first_out, o.suprimida_por IS NOT NULL,
+	COALESCE(jm.ocultar, false), o.created_at
+	FROM ocorrencias_falhas o
+	JOIN definicoes_falhas df ON o.definicao_id = df.id
+	JOIN setores s ON df.setor_id = s.id
+	JOIN eclusas e ON df.eclusa_id = e.id
+	LEFT JOIN janelas_manutencao jm ON o.janela_manutencao_id = jm.id`
+
+// lerOcorrencia lê uma linha com colunasOcorrencia
+func lerOcorrencia(linha interface{ Scan(...interface{}) error }) (Ocorrencia, error) {
+	var o Ocorrencia
+	err := linha.Scan(&o.ID, &o.DefinicaoID, &o.Codigo, &o.Tipo, &o.Descricao, &o.Prioridade, &o.Criticidade,
+		&o.RelacionadaSeguranca, &o.SetorCodigo, &o.SetorNome, &o.EclusaCodigo, &o.EclusaNome, &o.Status,
+		&o.Inicio, &o.FirstOut, &o.Suprimida, &o.EmManutencao, &o.Criacao)
+	o.Inicio = database.HoraLocal(o.Inicio)
+	o.Criacao = database.HoraLocal(o.Criacao)
+	return o, err
+}
+
+// AtivaRecente indica se a ocorrência está ativa e começou há menos que IdadeMaximaAtivacao
+func (o Ocorrencia) AtivaRecente(agora time.Time) bool {
+	return o.Status == "ATIVO" && agora.Sub(o.Inicio) < IdadeMaximaAtivacao
+}
+
+// BuscarOcorrencia lê os dados de notificação de uma ocorrência
+func BuscarOcorrencia(db *sql.DB, id int64) (Ocorrencia, error) {
+	return lerOcorrencia(db.QueryRow("SELECT"+colunasOcorrencia+" WHERE o.id = $1", id))
+}
+
+// buscarOcorrenciasCriadasDepois lê, por ordem de created_at e id, as ocorrências depois da posição indicada
+func buscarOcorrenciasCriadasDepois(db *sql.DB, criacao time.Time, id int64, limite int) ([]Ocorrencia, error) {
+	rows, err := db.Query("SELECT"+colunasOcorrencia+`
+		WHERE (o.created_at, o.id) > ($1, $2)
+		ORDER BY o.created_at, o.id
+		LIMIT $3`, criacao, id, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ocorrencias []Ocorrencia
+	for rows.Next() {
+		o, err := lerOcorrencia(rows)
+		if err != nil {
+			return nil, err
+		}
+		ocorrencias = append(ocorrencias, o)
+	}
+	return ocorrencias, rows.Err()
+}
+
+// LeitorOcorrencias percorre as ocorrências novas por created_at. O id e o created_at são atribuídos
+// antes do COMMIT, por isso uma ocorrência pode ficar visível depois de outras mais recentes; cada
+// leitura recomeça margemReleitura antes da anterior e quem processa tem de tolerar repetições
+// (o UNIQUE do registo de envios, o ON CONFLICT dos escalonamentos, a verificação de ordem existente).
+type LeitorOcorrencias struct {
+	bancoDados *sql.DB
+	janela     time.Duration // Na primeira leitura, as ocorrências criadas há menos que isto ainda são lidas
+	desde      time.Time     // Maior created_at já lido
+}
+
+// NovoLeitorOcorrencias cria o leitor; a primeira leitura começa nas ocorrências criadas há menos que a janela
+func NovoLeitorOcorrencias(db *sql.DB, janela time.Duration) *LeitorOcorrencias {
+	return &LeitorOcorrencias{bancoDados: db, janela: janela}
+}
+
+// Percorrer chama processar para cada ocorrência criada desde a leitura anterior (menos a margem),
+// por ordem de created_at e id. Se processar falhar, a leitura seguinte recomeça na mesma posição.
+func (l *LeitorOcorrencias) Percorrer(processar func(Ocorrencia) error) error {
+	if l.desde.IsZero() {
+		err := l.bancoDados.QueryRow(`SELECT LOCALTIMESTAMP - $1 * INTERVAL '1 second'`,
+			l.janela.Seconds()).Scan(&l.desde)
+		if err != nil {
+			return fmt.Errorf("erro ao ler início das ocorrências: %v", err)
+		}
+		l.desde = database.HoraLocal(l.desde)
+	}
+
+	criacao, id := l.desde.Add(-margemReleitura), int64(0)
+	maisRecente := l.desde
+	for {
+		ocorrencias, err := buscarOcorrenciasCriadasDepois(l.bancoDados, criacao, id, loteOcorrencias)
+		if err != nil {
+			return err
+		}
+		for _, o := range ocorrencias {
+			if err := processar(o); err != nil {
+				return err
+			}
+			criacao, id = o.Criacao, o.ID
+			if o.Criacao.After(maisRecente) {
+				maisRecente = o.Criacao
+			}
+		}
+		if len(ocorrencias) < loteOcorrencias {
+			break
+		}
+	}
+	l.desde = maisRecente
+	return nil
+}
+
+// Mensagem monta o assunto (também usado no SMS), o texto e os dados estruturados da ocorrência
+func (o Ocorrencia) Mensagem(motivo string) Mensagem {
+	assunto := fmt.Sprintf("[%s] %s %s: %s", o.Prioridade, o.EclusaCodigo, o.Codigo, o.Descricao)
+
+	var texto strings.Builder
+	fmt.Fprintf(&texto, "%s ativo na eclusa %s (%s)\n\n", titulo(o.Tipo), o.EclusaNome, o.EclusaCodigo)
+	fmt.Fprintf(&texto, "Código: %s\n", o.Codigo)
+	fmt.Fprintf(&texto, "Descrição: %s\n", o.Descricao)
+	fmt.Fprintf(&texto, "Setor: %s (%s)\n", o.SetorNome, o.SetorCodigo)
+	fmt.Fprintf(&texto, "Prioridade: %s | Criticidade: %d/5", o.Prioridade, o.Criticidade)
+	if o.RelacionadaSeguranca {
+		texto.WriteString(" | Relacionada com segurança")
+	}
+	fmt.Fprintf(&texto, "\nInício: %s\n", o.Inicio.Format(formatoDataHora))
+	fmt.Fprintf(&texto, "Estado: %s\n", o.Status)
+	fmt.Fprintf(&texto, "Ocorrência: #%d", o.ID)
+	if o.FirstOut {
+		texto.WriteString(" (first-out)")
+	}
+	texto.WriteString("\n")
+
+	return Mensagem{
+		Assunto: assunto,
+		Texto:   texto.String(),
+		Motivo:  motivo,
+		Dados: map[string]interface{}{
+			"id":                    o.ID,
+			"definicao_id":          o.DefinicaoID,
+			"codigo":                o.Codigo,
+			"tipo":                  o.Tipo,
+			"descricao":             o.Descricao,
+			"prioridade":            o.Prioridade,
+			"criticidade":           o.Criticidade,
+			"relacionada_seguranca": o.RelacionadaSeguranca,
+			"setor_codigo":          o.SetorCodigo,
+			"eclusa_codigo":         o.EclusaCodigo,
+			"status":                o.Status,
+			"timestamp_inicio":      o.Inicio,
+			"first_out":             o.FirstOut,
+		},
+	}
+}
+
+func titulo(tipo string) string {
+	if tipo == "EVENTO" {
+		return "Evento"
+	}
+	return "Alarme"
+}
+
+// Enfileirar regista um envio pendente; devolve false se o destino já tinha um envio para a
+// mesma ocorrência e motivo (deduplicação)
+func Enfileirar(db *sql.DB, ocorrenciaID int64, regraID int, destino Destino, mensagem Mensagem) (bool, error) {
+	dados, err := json.Marshal(mensagem.Dados)
+	if err != nil {
+		return false, err
+	}
+
+	var ocorrencia, regra interface{}
+	if ocorrenciaID != 0 {
+		ocorrencia = ocorrenciaID
+	}
+	if regraID != 0 {
+		regra = regraID
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO envios_notificacao (ocorrencia_id, regra_id, motivo, canal, endereco, assunto, mensagem, dados)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb)
+		ON CONFLICT (ocorrencia_id, motivo, canal, endereco) DO NOTHING`,
+		ocorrencia, regra, mensagem.Motivo, destino.Canal, destino.Endereco, mensagem.Assunto, mensagem.Texto, string(dados))
+	if err != nil {
+		return false, fmt.Errorf("erro ao registar envio: %v", err)
+	}
+	inseridas, _ := result.RowsAffected()
+	return inseridas > 0, nil
+}
+
+// Notificador avalia as regras para cada nova ocorrência e entrega os envios pendentes,
+// com novas tentativas e espera exponencial
+type Notificador struct {
+	bancoDados    *sql.DB
+	canais        map[string]Canal
+	intervalo     time.Duration
+	maxTentativas int
+	esperaInicial time.Duration
+	ocorrencias   *LeitorOcorrencias
+	canalParada   chan struct{}
+	grupoWait     sync.WaitGroup
+}
+
+// NovoNotificador cria o notificador com os canais configurados
+func NovoNotificador(db *sql.DB, cfg *config.Configuracoes) *Notificador {
+	n := &Notificador{
+		bancoDados:    db,
+		canais:        NovosCanais(cfg),
+		intervalo:     cfg.Notificacoes_Intervalo,
+		maxTentativas: cfg.Notificacoes_MaxTentativas,
+		esperaInicial: cfg.Notificacoes_EsperaInicial,
+		ocorrencias:   NovoLeitorOcorrencias(db, janelaRecuperacao),
+		canalParada:   make(chan struct{}),
+	}
+	if n.intervalo <= 0 {
+		n.intervalo = 5 * time.Second
+	}
+	if n.maxTentativas <= 0 {
+		n.maxTentativas = 1
+	}
+	return n
+}
+
+// Iniciar avalia as ocorrências e entrega os envios a cada intervalo, até Parar
+func (n *Notificador) Iniciar() {
+	n.grupoWait.Add(1)
+	go func() {
+		defer n.grupoWait.Done()
+
+		temporizador := time.NewTicker(n.intervalo)
+		defer temporizador.Stop()
+
+		for {
+			n.ciclo()
+
+			select {
+			case <-n.canalParada:
+				return
+			case <-temporizador.C:
+			}
+		}
+	}()
+}
+
+// Parar espera o ciclo em curso terminar
+func (n *Notificador) Parar() {
+	close(n.canalParada)
+	n.grupoWait.Wait()
+}
+
+func (n *Notificador) ciclo() {
+	if err := n.avaliarOcorrencias(); err != nil {
+		log.Printf("❌ Erro ao avaliar ocorrências para notificação: %v", err)
+	}
+	if err := n.entregarPendentes(); err != nil {
+		log.Printf("❌ Erro ao entregar notificações: %v", err)
+	}
+}
+
+// avaliarOcorrencias enfileira um envio por destino das regras que correspondem às novas ocorrências
+func (n *Notificador) avaliarOcorrencias() error {
+	carregadas, err := CarregarRegras(n.bancoDados, true)
+	if err != nil {
+		return err
+	}
+	var regras []Regra
+	for _, regra := range carregadas {
+		if err := regra.ValidarJanela(); err != nil {
+			log.Printf("⚠️ Regra de notificação %q (id %d) ignorada: %v", regra.Nome, regra.ID, err)
+			continue
+		}
+		regras = append(regras, regra)
+	}
+
+	return n.ocorrencias.Percorrer(func(o Ocorrencia) error {
+		agora := time.Now()
+		for _, regra := range regras {
+			if !regra.Corresponde(o, agora) {
+				continue
+			}
+			mensagem := o.Mensagem(MotivoAtivacao)
+			for _, destino := range regra.Destinos {
+				novo, err := Enfileirar(n.bancoDados, o.ID, regra.ID, destino, mensagem)
+				if err != nil {
+					return err
+				}
+				if novo {
+					log.Printf("📨 Notificação %s para %s (regra %q, ocorrência %d)", destino.Canal, destino.Endereco, regra.Nome, o.ID)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// envioPendente é um envio lido do registo para entrega
+type envioPendente struct {
+	id         int64
+	canal      string
+	endereco   string
+	tentativas int
+	mensagem   Mensagem
+}
+
+// entregarPendentes tenta os envios pendentes cuja próxima tentativa já chegou
+func (n *Notificador) entregarPendentes() error {
+	rows, err := n.bancoDados.Query(`
+		SELECT id, canal, endereco, motivo, assunto, mensagem, COALESCE(dados::text, ''), tentativas
+		FROM envios_notificacao
+		WHERE status = 'PENDENTE' AND proxima_tentativa <= NOW()
+		ORDER BY id
+		LIMIT $1`, loteEnvios)
+	if err != nil {
+		return err
+	}
+
+	var pendentes []envioPendente
+	for rows.Next() {
+		var e envioPendente
+		var dados string
+		err := rows.Scan(&e.id, &e.canal, &e.endereco, &e.mensagem.Motivo, &e.mensagem.Assunto,
+			&e.mensagem.Texto, &dados, &e.tentativas)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		if dados != "" && dados != "null" {
+			if err := json.Unmarshal([]byte(dados), &e.mensagem.Dados); err != nil {
+				log.Printf("⚠️ Dados inválidos no envio de notificação %d (enviado sem eles): %v", e.id, err)
+			}
+		}
+		pendentes = append(pendentes, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range pendentes {
+		select {
+		case <-n.canalParada:
+			return nil
+		default:
+		}
+		n.entregar(e)
+	}
+	return nil
+}
+
+// entregar envia e regista o resultado; depois de maxTentativas falhadas o envio fica FALHOU
+func (n *Notificador) entregar(e envioPendente) {
+	err := enviarPorCanal(n.canais, e.canal, e.endereco, e.mensagem)
+	tentativas := e.tentativas + 1
+
+	if err == nil {
+		_, errRegisto := n.bancoDados.Exec(`
+			UPDATE envios_notificacao
+			SET status = 'ENVIADO', tentativas = $2, enviado_em = NOW(), ultimo_erro = NULL
+			WHERE id = $1`, e.id, tentativas)
+		if errRegisto != nil {
+			// Fica PENDENTE e será enviada outra vez na próxima tentativa
+			log.Printf("❌ Notificação %d entregue mas não registada como enviada: %v", e.id, errRegisto)
+			return
+		}
+		log.Printf("✅ Notificação %d entregue por %s a %s", e.id, e.canal, e.endereco)
+		return
+	}
+
+	if tentativas >= n.maxTentativas {
+		_, errRegisto := n.bancoDados.Exec(`
+			UPDATE envios_notificacao SET status = 'FALHOU', tentativas = $2, ultimo_erro = $3
+			WHERE id = $1`, e.id, tentativas, err.Error())
+		if errRegisto != nil {
+			log.Printf("❌ Erro ao registar falha da notificação %d: %v", e.id, errRegisto)
+		}
+		log.Printf("❌ Notificação %d falhou definitivamente após %d tentativas: %v", e.id, tentativas, err)
+		return
+	}
+
+	espera := n.esperaInicial << (tentativas - 1)
+	if espera <= 0 || espera > esperaMaxima {
+		espera = esperaMaxima
+	}
+	_, errRegisto := n.bancoDados.Exec(`
+		UPDATE envios_notificacao
+		SET tentativas = $2, ultimo_erro = $3, proxima_tentativa = NOW() + $4 * INTERVAL '1 second'
+		WHERE id = $1`, e.id, tentativas, err.Error(), espera.Seconds())
+	if errRegisto != nil {
+		log.Printf("❌ Erro ao registar tentativa da notificação %d: %v", e.id, errRegisto)
+	}
+	log.Printf("⚠️  Notificação %d falhou (tentativa %d/%d, nova tentativa em %s): %v",
+		e.id, tentativas, n.maxTentativas, espera, err)
+}
+
+// ResultadoEnvio descreve um envio feito de imediato (teste de regra)
+type ResultadoEnvio struct {
+	Canal    string `json:"canal"`
+	Endereco string `json:"endereco"`
+	Enviado  bool   `json:"enviado"`
+	Erro     string `json:"erro,omitempty"`
+}
+
+// TestarRegra envia de imediato uma mensagem de teste a cada destino da regra, sem novas
+// tentativas, e regista o resultado; falha se algum envio não ficar registado
+func TestarRegra(db *sql.DB, canais map[string]Canal, regra Regra) ([]ResultadoEnvio, error) {
+	mensagem := Mensagem{
+		Assunto: fmt.Sprintf("[TESTE] Regra de notificação %q", regra.Nome),
+		Texto: fmt.Sprintf("Mensagem de teste da regra %q (id %d), enviada em %s.\n",
+			regra.Nome, regra.ID, time.Now().Format(formatoDataHora)),
+		Motivo: MotivoTeste,
+	}
+
+	resultados := []ResultadoEnvio{}
+	for _, destino := range regra.Destinos {
+		resultado := ResultadoEnvio{Canal: destino.Canal, Endereco: destino.Endereco, Enviado: true}
+		status, ultimoErro := EnvioEnviado, interface{}(nil)
+		if err := enviarPorCanal(canais, destino.Canal, destino.Endereco, mensagem); err != nil {
+			resultado.Enviado = false
+			resultado.Erro = err.Error()
+			status, ultimoErro = EnvioFalhou, err.Error()
+		}
+
+		_, err := db.Exec(`
+			INSERT INTO envios_notificacao
+			(regra_id, motivo, canal, endereco, assunto, mensagem, status, tentativas, ultimo_erro, enviado_em)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, 1, $8, CASE WHEN $7 = 'ENVIADO' THEN NOW() END)`,
+			regra.ID, MotivoTeste, destino.Canal, destino.Endereco, mensagem.Assunto, mensagem.Texto, status, ultimoErro)
+		if err != nil {
+			return resultados, fmt.Errorf("erro ao registar envio de teste para %s: %v", destino.Endereco, err)
+		}
+		resultados = append(resultados, resultado)
+	}
+	return resultados, nil
+}
+
+// enviarPorCanal escolhe o canal do envio
+func enviarPorCanal(canais map[string]Canal, canal, endereco string, mensagem Mensagem) error {
+	c, existe := canais[canal]
+	if !existe {
+		return fmt.Errorf("canal desconhecido: %s", canal)
+	}
+	return c.Enviar(endereco, mensagem)
+}
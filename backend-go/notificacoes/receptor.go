@@ -0,0 +1,191 @@
+package notificacoes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recebida é uma mensagem recebida pelo receptor de teste
+type Recebida struct {
+	Protocolo string // SMTP ou HTTP
+	Origem    string // Remetente SMTP ou caminho HTTP
+	Destino   string // Destinatários SMTP ou autorização HTTP
+	Conteudo  string
+	Instante  time.Time
+}
+
+// ReceptorTeste faz de servidor SMTP e de webhook/gateway SMS locais, para testar as regras sem
+// serviços externos. Com TaxaFalha > 0, essa fração dos pedidos é recusada (testa as novas tentativas).
+type ReceptorTeste struct {
+	TaxaFalha float64
+	AoReceber func(Recebida)
+
+	mutex     sync.Mutex
+	ouvintes  []net.Listener
+	servidor  *http.Server
+	aleatorio *rand.Rand
+}
+
+// NovoReceptorTeste cria o receptor; por omissão as mensagens vão para o log
+func NovoReceptorTeste(taxaFalha float64) *ReceptorTeste {
+	return &ReceptorTeste{
+		TaxaFalha: taxaFalha,
+		AoReceber: func(r Recebida) {
+			log.Printf("📥 %s de %s para %s\n%s", r.Protocolo, r.Origem, r.Destino, r.Conteudo)
+		},
+		aleatorio: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// IniciarSMTP escuta SMTP no endereço (host:porta) e devolve o endereço efetivo
+func (r *ReceptorTeste) IniciarSMTP(endereco string) (string, error) {
+	ouvinte, err := net.Listen("tcp", endereco)
+	if err != nil {
+		return "", fmt.Errorf("erro ao escutar SMTP em %s: %v", endereco, err)
+	}
+	r.mutex.Lock()
+	r.ouvintes = append(r.ouvintes, ouvinte)
+	r.mutex.Unlock()
+
+	go func() {
+		for {
+			conexao, err := ouvinte.Accept()
+			if err != nil {
+				return
+			}
+			go r.sessaoSMTP(conexao)
+		}
+	}()
+	return ouvinte.Addr().String(), nil
+}
+
+// IniciarHTTP escuta HTTP no endereço e aceita POST em qualquer caminho
+func (r *ReceptorTeste) IniciarHTTP(endereco string) (string, error) {
+	ouvinte, err := net.Listen("tcp", endereco)
+	if err != nil {
+		return "", fmt.Errorf("erro ao escutar HTTP em %s: %v", endereco, err)
+	}
+
+	r.mutex.Lock()
+	r.servidor = &http.Server{Handler: http.HandlerFunc(r.receberHTTP)}
+	servidor := r.servidor
+	r.mutex.Unlock()
+
+	go servidor.Serve(ouvinte)
+	return ouvinte.Addr().String(), nil
+}
+
+// Parar fecha os ouvintes
+func (r *ReceptorTeste) Parar() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, ouvinte := range r.ouvintes {
+		ouvinte.Close()
+	}
+	if r.servidor != nil {
+		r.servidor.Close()
+	}
+}
+
+func (r *ReceptorTeste) falhar() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.TaxaFalha > 0 && r.aleatorio.Float64() < r.TaxaFalha
+}
+
+func (r *ReceptorTeste) receberHTTP(w http.ResponseWriter, pedido *http.Request) {
+	if pedido.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	corpo, _ := io.ReadAll(io.LimitReader(pedido.Body, 1<<20))
+
+	if r.falhar() {
+		http.Error(w, "falha simulada", http.StatusServiceUnavailable)
+		return
+	}
+	r.AoReceber(Recebida{
+		Protocolo: "HTTP",
+		Origem:    pedido.URL.Path,
+		Destino:   pedido.Header.Get("Authorization"),
+		Conteudo:  string(corpo),
+		Instante:  time.Now(),
+	})
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sessaoSMTP implementa o mínimo do RFC 5321 que o net/smtp usa (sem STARTTLS nem AUTH)
+func (r *ReceptorTeste) sessaoSMTP(conexao net.Conn) {
+	defer conexao.Close()
+	conexao.SetDeadline(time.Now().Add(time.Minute))
+
+	leitor := bufio.NewReader(conexao)
+	responder := func(linha string) {
+		fmt.Fprintf(conexao, "%s\r\n", linha)
+	}
+
+	var remetente string
+	var destinatarios []string
+	responder("220 receptor-teste ESMTP")
+
+	for {
+		linha, err := leitor.ReadString('\n')
+		if err != nil {
+			return
+		}
+		linha = strings.TrimRight(linha, "\r\n")
+		comando := strings.ToUpper(linha)
+
+		switch {
+		case strings.HasPrefix(comando, "EHLO"), strings.HasPrefix(comando, "HELO"):
+			responder("250 receptor-teste")
+		case strings.HasPrefix(comando, "MAIL FROM:"):
+			remetente = strings.Trim(linha[len("MAIL FROM:"):], " <>")
+			destinatarios = nil
+			responder("250 OK")
+		case strings.HasPrefix(comando, "RCPT TO:"):
+			destinatarios = append(destinatarios, strings.Trim(linha[len("RCPT TO:"):], " <>"))
+			responder("250 OK")
+		case comando == "DATA":
+			responder("354 Termine com <CRLF>.<CRLF>")
+			var conteudo strings.Builder
+			for {
+				linhaDados, err := leitor.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if linhaDados == ".\r\n" || linhaDados == ".\n" {
+					break
+				}
+				conteudo.WriteString(strings.TrimPrefix(linhaDados, "."))
+			}
+			if r.falhar() {
+				responder("451 Falha simulada")
+				continue
+			}
+			r.AoReceber(Recebida{
+				Protocolo: "SMTP",
+				Origem:    remetente,
+				Destino:   strings.Join(destinatarios, ", "),
+				Conteudo:  conteudo.String(),
+				Instante:  time.Now(),
+			})
+			responder("250 OK")
+		case comando == "RSET", comando == "NOOP":
+			responder("250 OK")
+		case comando == "QUIT":
+			responder("221 Até logo")
+			return
+		default:
+			responder("502 Comando não suportado")
+		}
+	}
+}
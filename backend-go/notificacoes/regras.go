@@ -0,0 +1,290 @@
+package notificacoes
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Destino é um endereço de entrega de uma regra
+type Destino struct {
+	ID       int    `json:"id,omitempty"`
+	Canal    string `json:"canal"`
+	Endereco string `json:"endereco"`
+}
+
+// Regra escolhe as ocorrências a notificar. Os filtros vazios (ou 0) aceitam qualquer valor.
+// A janela horária pode atravessar a meia-noite (ex.: 22:00 a 06:00); nesse caso os dias da
+// semana referem-se ao dia em que a janela começa.
+type Regra struct {
+	ID                int       `json:"id"`
+	Nome              string    `json:"nome"`
+	Ativa             bool      `json:"ativa"`
+	Eclusa            string    `json:"eclusa,omitempty"`
+	Setor             string    `json:"setor,omitempty"`
+	Prioridade        string    `json:"prioridade,omitempty"`
+	DefinicaoID       int       `json:"definicao_id,omitempty"`
+	HoraInicio        string    `json:"hora_inicio,omitempty"` // HH:MM
+	HoraFim           string    `json:"hora_fim,omitempty"`
+	DiasSemana        string    `json:"dias_semana,omitempty"` // 0 = domingo, ex.: "1,2,3,4,5"
+	IncluirSuprimidas bool      `json:"incluir_suprimidas"`
+	Destinos          []Destino `json:"destinos"`
+	CriadaEm          time.Time `json:"created_at"`
+}
+
+// Validar normaliza os campos e verifica filtros, janela horária e destinos
+func (r *Regra) Validar() error {
+	r.Nome = strings.TrimSpace(r.Nome)
+	r.Eclusa = strings.ToUpper(strings.TrimSpace(r.Eclusa))
+	r.Setor = strings.TrimSpace(r.Setor)
+	r.Prioridade = strings.ToUpper(strings.TrimSpace(r.Prioridade))
+
+	if r.Nome == "" {
+		return fmt.Errorf("campo 'nome' é obrigatório")
+	}
+	if r.Prioridade != "" && r.Prioridade != "ALTA" && r.Prioridade != "MEDIA" && r.Prioridade != "BAIXA" {
+		return fmt.Errorf("prioridade inválida: %s (use ALTA, MEDIA ou BAIXA)", r.Prioridade)
+	}
+	if err := r.ValidarJanela(); err != nil {
+		return err
+	}
+
+	if len(r.Destinos) == 0 {
+		return fmt.Errorf("a regra precisa de pelo menos um destino")
+	}
+	for i := range r.Destinos {
+		destino := &r.Destinos[i]
+		destino.Canal = strings.ToUpper(strings.TrimSpace(destino.Canal))
+		destino.Endereco = strings.TrimSpace(destino.Endereco)
+		if err := ValidarEndereco(destino.Canal, destino.Endereco); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidarJanela verifica a janela horária e os dias da semana (também das regras lidas do banco,
+// que não podem corresponder a tudo por terem um destes campos ilegível)
+func (r Regra) ValidarJanela() error {
+	if (r.HoraInicio == "") != (r.HoraFim == "") {
+		return fmt.Errorf("informe 'hora_inicio' e 'hora_fim' juntos (ou nenhum para o dia todo)")
+	}
+	if r.HoraInicio != "" {
+		if _, err := minutosDoDia(r.HoraInicio); err != nil {
+			return err
+		}
+		if _, err := minutosDoDia(r.HoraFim); err != nil {
+			return err
+		}
+		if r.HoraInicio == r.HoraFim {
+			return fmt.Errorf("'hora_inicio' e 'hora_fim' não podem ser iguais")
+		}
+	}
+	_, err := interpretarDiasSemana(r.DiasSemana)
+	return err
+}
+
+// Corresponde indica se a ocorrência deve ser notificada pela regra no instante 'agora': só as
+// ativas há menos que IdadeMaximaAtivacao, e nunca as ocultadas por uma janela de manutenção
+func (r Regra) Corresponde(o Ocorrencia, agora time.Time) bool {
+	if !r.Ativa || o.EmManutencao || !o.AtivaRecente(agora) {
+		return false
+	}
+	if o.Suprimida && !r.IncluirSuprimidas {
+		return false
+	}
+	if r.Eclusa != "" && r.Eclusa != o.EclusaCodigo {
+		return false
+	}
+	if r.Setor != "" && r.Setor != o.SetorCodigo {
+		return false
+	}
+	if r.Prioridade != "" && r.Prioridade != o.Prioridade {
+		return false
+	}
+	if r.DefinicaoID != 0 && r.DefinicaoID != o.DefinicaoID {
+		return false
+	}
+	return r.dentroDaJanela(o.Inicio)
+}
+
+// dentroDaJanela verifica a janela horária e os dias da semana no instante (hora local); uma janela
+// inválida não corresponde a nenhum instante
+func (r Regra) dentroDaJanela(instante time.Time) bool {
+	if r.ValidarJanela() != nil {
+		return false
+	}
+	dia := instante.Weekday()
+
+	if r.HoraInicio != "" {
+		inicio, _ := minutosDoDia(r.HoraInicio)
+		fim, _ := minutosDoDia(r.HoraFim)
+		minuto := instante.Hour()*60 + instante.Minute()
+
+		if inicio < fim {
+			if minuto < inicio || minuto >= fim {
+				return false
+			}
+		} else {
+			if minuto < inicio && minuto >= fim {
+				return false
+			}
+			// Depois da meia-noite a janela pertence ao dia anterior
+			if minuto < fim {
+				dia = (dia + 6) % 7
+			}
+		}
+	}
+
+	dias, _ := interpretarDiasSemana(r.DiasSemana)
+	return dias == nil || dias[dia]
+}
+
+// minutosDoDia converte HH:MM em minutos desde a meia-noite
+func minutosDoDia(texto string) (int, error) {
+	hora, err := time.Parse("15:04", texto)
+	if err != nil {
+		return 0, fmt.Errorf("hora inválida: %s (use HH:MM)", texto)
+	}
+	return hora.Hour()*60 + hora.Minute(), nil
+}
+
+// interpretarDiasSemana lê a lista de dias (0 = domingo ... 6 = sábado); vazio = todos (nil)
+func interpretarDiasSemana(texto string) (map[time.Weekday]bool, error) {
+	if strings.TrimSpace(texto) == "" {
+		return nil, nil
+	}
+
+	dias := make(map[time.Weekday]bool)
+	for _, parte := range strings.Split(texto, ",") {
+		dia, err := strconv.Atoi(strings.TrimSpace(parte))
+		if err != nil || dia < 0 || dia > 6 {
+			return nil, fmt.Errorf("dias_semana inválido: %s (use 0 = domingo a 6 = sábado, separados por vírgula)", texto)
+		}
+		dias[time.Weekday(dia)] = true
+	}
+	return dias, nil
+}
+
+// CarregarRegras lê as regras e os respetivos destinos
+func CarregarRegras(db *sql.DB, apenasAtivas bool) ([]Regra, error) {
+	query := `
+		SELECT id, nome, ativa, COALESCE(eclusa_codigo, ''), COALESCE(setor_codigo, ''),
+			COALESCE(prioridade, ''), COALESCE(definicao_id, 0),
+			COALESCE(to_char(hora_inicio, 'HH24:MI'), ''), COALESCE(to_char(hora_fim, 'HH24:MI'), ''),
+			COALESCE(dias_semana, ''), incluir_suprimidas, created_at
+		FROM regras_notificacao`
+	if apenasAtivas {
+		query += " WHERE ativa = true"
+	}
+	query += " ORDER BY id"
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras de notificação: %v", err)
+	}
+	defer rows.Close()
+
+	var regras []Regra
+	indices := make(map[int]int)
+	for rows.Next() {
+		var r Regra
+		err := rows.Scan(&r.ID, &r.Nome, &r.Ativa, &r.Eclusa, &r.Setor, &r.Prioridade, &r.DefinicaoID,
+			&r.HoraInicio, &r.HoraFim, &r.DiasSemana, &r.IncluirSuprimidas, &r.CriadaEm)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler regra de notificação: %v", err)
+		}
+		r.Destinos = []Destino{}
+		indices[r.ID] = len(regras)
+		regras = append(regras, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	destinos, err := db.Query("SELECT id, regra_id, canal, endereco FROM destinos_notificacao ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar destinos de notificação: %v", err)
+	}
+	defer destinos.Close()
+
+	for destinos.Next() {
+		var d Destino
+		var regraID int
+		if err := destinos.Scan(&d.ID, &regraID, &d.Canal, &d.Endereco); err != nil {
+			return nil, fmt.Errorf("erro ao ler destino de notificação: %v", err)
+		}
+		if indice, existe := indices[regraID]; existe {
+			regras[indice].Destinos = append(regras[indice].Destinos, d)
+		}
+	}
+	return regras, destinos.Err()
+}
+
+// SalvarRegra cria a regra (ID = 0) ou substitui-a, incluindo os destinos, numa transação
+func SalvarRegra(db *sql.DB, r *Regra) error {
+	if err := r.Validar(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{r.Nome, r.Ativa, nuloSeVazio(r.Eclusa), nuloSeVazio(r.Setor), nuloSeVazio(r.Prioridade),
+		nuloSeZero(r.DefinicaoID), nuloSeVazio(r.HoraInicio), nuloSeVazio(r.HoraFim), nuloSeVazio(r.DiasSemana),
+		r.IncluirSuprimidas}
+
+	if r.ID == 0 {
+		err = tx.QueryRow(`
+			INSERT INTO regras_notificacao
+			(nome, ativa, eclusa_codigo, setor_codigo, prioridade, definicao_id, hora_inicio, hora_fim, dias_semana, incluir_suprimidas)
+			VALUES ($1, $2, $3, $4, $5, $6, $7::time, $8::time, $9, $10)
+			RETURNING id, created_at`, args...).Scan(&r.ID, &r.CriadaEm)
+	} else {
+		err = tx.QueryRow(`
+			UPDATE regras_notificacao
+			SET nome = $1, ativa = $2, eclusa_codigo = $3, setor_codigo = $4, prioridade = $5, definicao_id = $6,
+				hora_inicio = $7::time, hora_fim = $8::time, dias_semana = $9, incluir_suprimidas = $10
+			WHERE id = $11
+			RETURNING created_at`, append(args, r.ID)...).Scan(&r.CriadaEm)
+	}
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao salvar regra de notificação: %v", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM destinos_notificacao WHERE regra_id = $1", r.ID); err != nil {
+		return fmt.Errorf("erro ao substituir destinos: %v", err)
+	}
+	for i := range r.Destinos {
+		err := tx.QueryRow(`
+			INSERT INTO destinos_notificacao (regra_id, canal, endereco) VALUES ($1, $2, $3)
+			RETURNING id`, r.ID, r.Destinos[i].Canal, r.Destinos[i].Endereco).Scan(&r.Destinos[i].ID)
+		if err != nil {
+			return fmt.Errorf("erro ao salvar destino: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func nuloSeVazio(valor string) interface{} {
+	if valor == "" {
+		return nil
+	}
+	return valor
+}
+
+func nuloSeZero(valor int) interface{} {
+	if valor == 0 {
+		return nil
+	}
+	return valor
+}
@@ -0,0 +1,44 @@
+package notificacoes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegraCorresponde(t *testing.T) {
+	// Segunda-feira, 10:30, avaliada cinco minutos depois
+	inicio := time.Date(2024, 3, 4, 10, 30, 0, 0, time.Local)
+	agora := inicio.Add(5 * time.Minute)
+	ocorrencia := Ocorrencia{EclusaCodigo: "RG", SetorCodigo: "ENCHIMENTO", Prioridade: "ALTA", Status: "ATIVO", Inicio: inicio}
+
+	casos := []struct {
+		nome     string
+		regra    Regra
+		esperado bool
+	}{
+		{"sem filtros", Regra{Ativa: true}, true},
+		{"inativa", Regra{}, false},
+		{"outra eclusa", Regra{Ativa: true, Eclusa: "PB"}, false},
+		{"dentro da janela", Regra{Ativa: true, HoraInicio: "08:00", HoraFim: "18:00", DiasSemana: "1,2,3,4,5"}, true},
+		{"fora da janela", Regra{Ativa: true, HoraInicio: "22:00", HoraFim: "06:00"}, false},
+		{"dias da semana ilegíveis", Regra{Ativa: true, DiasSemana: "seg"}, false},
+		{"hora ilegível", Regra{Ativa: true, HoraInicio: "25:00", HoraFim: "06:00"}, false},
+		{"janela incompleta", Regra{Ativa: true, HoraInicio: "08:00"}, false},
+	}
+	for _, caso := range casos {
+		if obtido := caso.regra.Corresponde(ocorrencia, agora); obtido != caso.esperado {
+			t.Errorf("%s: Corresponde = %v; esperado %v", caso.nome, obtido, caso.esperado)
+		}
+	}
+
+	// Só as ocorrências ainda ativas e recentes notificam (ex.: atraso de uma eclusa sem ligação)
+	regra := Regra{Ativa: true}
+	resolvida := ocorrencia
+	resolvida.Status = "RESOLVIDO"
+	if regra.Corresponde(resolvida, agora) {
+		t.Error("uma ocorrência resolvida corresponde à regra")
+	}
+	if regra.Corresponde(ocorrencia, inicio.Add(IdadeMaximaAtivacao)) {
+		t.Errorf("uma ocorrência ativa há %v corresponde à regra", IdadeMaximaAtivacao)
+	}
+}
@@ -0,0 +1,267 @@
+package plantao
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/edp/falhas-backend/database"
+)
+
+// Origem de quem está de plantão num instante
+const (
+	OrigemRotacao      = "ROTACAO"
+	OrigemSubstituicao = "SUBSTITUICAO"
+)
+
+// limiteSegmentosEscala evita escalas enormes com rotações de períodos muito curtos
+const limiteSegmentosEscala = 5000
+
+// Membro é um elemento de uma equipa de manutenção
+type Membro struct {
+	ID       int    `json:"id"`
+	EquipaID int    `json:"equipa_id"`
+	Nome     string `json:"nome"`
+	Email    string `json:"email,omitempty"`
+	Telefone string `json:"telefone,omitempty"`
+	Ativo    bool   `json:"ativo"`
+}
+
+// Rotacao distribui o plantão pelos membros, por ordem, em turnos de PeriodoHoras a partir de Inicio.
+// Uma rotação mais recente substitui as anteriores da equipa a partir do seu início.
+type Rotacao struct {
+	ID           int       `json:"id"`
+	EquipaID     int       `json:"equipa_id"`
+	Nome         string    `json:"nome"`
+	Inicio       time.Time `json:"inicio"`
+	PeriodoHoras int       `json:"periodo_horas"`
+	Membros      []int64   `json:"membros"`
+}
+
+// Substituicao põe um membro de plantão num intervalo, por cima da rotação
+type Substituicao struct {
+	ID       int       `json:"id"`
+	EquipaID int       `json:"equipa_id"`
+	MembroID int       `json:"membro_id"`
+	Inicio   time.Time `json:"inicio"`
+	Fim      time.Time `json:"fim"`
+	Motivo   string    `json:"motivo,omitempty"`
+}
+
+// Turno é um segmento contínuo da escala com o mesmo membro de plantão
+type Turno struct {
+	Inicio time.Time `json:"inicio"`
+	Fim    time.Time `json:"fim"`
+	Membro *Membro   `json:"membro"`
+	Origem string    `json:"origem,omitempty"`
+}
+
+// Equipa reúne os dados de plantão de uma equipa
+type Equipa struct {
+	ID            int            `json:"id"`
+	Codigo        string         `json:"codigo"`
+	Nome          string         `json:"nome"`
+	Membros       []Membro       `json:"membros"`
+	Rotacoes      []Rotacao      `json:"-"`
+	Substituicoes []Substituicao `json:"-"`
+}
+
+// CarregarEquipa lê a equipa (por código) com membros, rotações e substituições
+func CarregarEquipa(db *sql.DB, codigo string) (*Equipa, error) {
+	equipa := &Equipa{Membros: []Membro{}}
+	err := db.QueryRow("SELECT id, codigo, nome FROM equipas WHERE codigo = $1",
+		strings.ToUpper(codigo)).Scan(&equipa.ID, &equipa.Codigo, &equipa.Nome)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, equipa_id, nome, COALESCE(email, ''), COALESCE(telefone, ''), ativo
+		FROM membros_equipa WHERE equipa_id = $1 ORDER BY nome`, equipa.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar membros: %v", err)
+	}
+	for rows.Next() {
+		var m Membro
+		if err := rows.Scan(&m.ID, &m.EquipaID, &m.Nome, &m.Email, &m.Telefone, &m.Ativo); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("erro ao ler membro: %v", err)
+		}
+		equipa.Membros = append(equipa.Membros, m)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT id, equipa_id, nome, inicio, periodo_horas, membros
+		FROM rotacoes_plantao WHERE equipa_id = $1 ORDER BY inicio`, equipa.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar rotações: %v", err)
+	}
+	for rows.Next() {
+		var r Rotacao
+		if err := rows.Scan(&r.ID, &r.EquipaID, &r.Nome, &r.Inicio, &r.PeriodoHoras, pq.Array(&r.Membros)); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("erro ao ler rotação: %v", err)
+		}
+		r.Inicio = database.HoraLocal(r.Inicio)
+		equipa.Rotacoes = append(equipa.Rotacoes, r)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT id, equipa_id, membro_id, inicio, fim, COALESCE(motivo, '')
+		FROM substituicoes_plantao WHERE equipa_id = $1 ORDER BY inicio`, equipa.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar substituições: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s Substituicao
+		if err := rows.Scan(&s.ID, &s.EquipaID, &s.MembroID, &s.Inicio, &s.Fim, &s.Motivo); err != nil {
+			return nil, fmt.Errorf("erro ao ler substituição: %v", err)
+		}
+		s.Inicio, s.Fim = database.HoraLocal(s.Inicio), database.HoraLocal(s.Fim)
+		equipa.Substituicoes = append(equipa.Substituicoes, s)
+	}
+	return equipa, rows.Err()
+}
+
+// DePlantao devolve o membro de plantão no instante (nil se ninguém) e a origem
+func (e *Equipa) DePlantao(instante time.Time) (*Membro, string) {
+	// A substituição mais recente que cobre o instante tem prioridade
+	for i := len(e.Substituicoes) - 1; i >= 0; i-- {
+		s := e.Substituicoes[i]
+		if !instante.Before(s.Inicio) && instante.Before(s.Fim) {
+			if membro := e.membro(int64(s.MembroID)); membro != nil {
+				return membro, OrigemSubstituicao
+			}
+		}
+	}
+
+	rotacao := e.rotacaoEm(instante)
+	if rotacao == nil {
+		return nil, ""
+	}
+
+	// Membros inativos são saltados: o turno passa ao seguinte da lista
+	indice := indiceTurno(rotacao, instante)
+	for tentativa := 0; tentativa < len(rotacao.Membros); tentativa++ {
+		id := rotacao.Membros[(indice+tentativa)%len(rotacao.Membros)]
+		if membro := e.membro(id); membro != nil {
+			return membro, OrigemRotacao
+		}
+	}
+	return nil, ""
+}
+
+// Escala calcula os turnos de plantão entre inicio e fim, juntando segmentos do mesmo membro
+func (e *Equipa) Escala(inicio, fim time.Time) []Turno {
+	pontos := []time.Time{inicio, fim}
+	adicionar := func(t time.Time) {
+		if t.After(inicio) && t.Before(fim) {
+			pontos = append(pontos, t)
+		}
+	}
+
+	for _, s := range e.Substituicoes {
+		adicionar(s.Inicio)
+		adicionar(s.Fim)
+	}
+	for i := range e.Rotacoes {
+		rotacao := &e.Rotacoes[i]
+		adicionar(rotacao.Inicio)
+		if len(rotacao.Membros) == 0 {
+			continue
+		}
+		// Fronteiras de turno dentro do intervalo
+		k := indiceTurno(rotacao, inicio)
+		if k < 0 {
+			k = 0
+		}
+		for n := 0; n < limiteSegmentosEscala; n++ {
+			fronteira := inicioTurno(rotacao, k+n)
+			if !fronteira.Before(fim) {
+				break
+			}
+			adicionar(fronteira)
+		}
+	}
+
+	sort.Slice(pontos, func(i, j int) bool { return pontos[i].Before(pontos[j]) })
+
+	turnos := []Turno{}
+	for i := 0; i+1 < len(pontos); i++ {
+		if !pontos[i+1].After(pontos[i]) {
+			continue
+		}
+		membro, origem := e.DePlantao(pontos[i])
+		if n := len(turnos); n > 0 && mesmoMembro(turnos[n-1].Membro, membro) && turnos[n-1].Origem == origem {
+			turnos[n-1].Fim = pontos[i+1]
+			continue
+		}
+		turnos = append(turnos, Turno{Inicio: pontos[i], Fim: pontos[i+1], Membro: membro, Origem: origem})
+	}
+	return turnos
+}
+
+// membro devolve o membro ativo com o id, ou nil
+func (e *Equipa) membro(id int64) *Membro {
+	for i := range e.Membros {
+		if int64(e.Membros[i].ID) == id && e.Membros[i].Ativo {
+			return &e.Membros[i]
+		}
+	}
+	return nil
+}
+
+// rotacaoEm devolve a rotação mais recente já iniciada no instante
+func (e *Equipa) rotacaoEm(instante time.Time) *Rotacao {
+	var atual *Rotacao
+	for i := range e.Rotacoes {
+		r := &e.Rotacoes[i]
+		if len(r.Membros) == 0 || r.Inicio.After(instante) {
+			continue
+		}
+		if atual == nil || !r.Inicio.Before(atual.Inicio) {
+			atual = r
+		}
+	}
+	return atual
+}
+
+// inicioTurno devolve o início do turno k da rotação. Períodos em dias inteiros seguem o
+// calendário (o turno começa sempre à mesma hora, mesmo com a mudança de hora).
+func inicioTurno(r *Rotacao, k int) time.Time {
+	if r.PeriodoHoras%24 == 0 {
+		return r.Inicio.AddDate(0, 0, k*r.PeriodoHoras/24)
+	}
+	return r.Inicio.Add(time.Duration(k*r.PeriodoHoras) * time.Hour)
+}
+
+// indiceTurno devolve o número do turno que contém o instante (negativo antes do início)
+func indiceTurno(r *Rotacao, instante time.Time) int {
+	periodo := time.Duration(r.PeriodoHoras) * time.Hour
+	k := int(instante.Sub(r.Inicio) / periodo)
+	if instante.Before(r.Inicio) {
+		k--
+	}
+	// Corrige a estimativa (mudanças de hora nos períodos de calendário)
+	for inicioTurno(r, k).After(instante) {
+		k--
+	}
+	for !inicioTurno(r, k+1).After(instante) {
+		k++
+	}
+	return k
+}
+
+func mesmoMembro(a, b *Membro) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ID == b.ID
+}
@@ -0,0 +1,310 @@
+package plantao
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/notificacoes"
+)
+
+// Estados do escalonamento de uma ocorrência
+const (
+	EscalonamentoAtivo       = "ATIVO"       // À espera do próximo nível
+	EscalonamentoReconhecido = "RECONHECIDO" // Parado pelo reconhecimento
+	EscalonamentoResolvido   = "RESOLVIDO"   // Parado porque a ocorrência foi resolvida
+	EscalonamentoEsgotado    = "ESGOTADO"    // Todos os níveis notificados sem reconhecimento
+)
+
+const (
+	// janelaRecuperacao: ao arrancar, as ocorrências criadas há menos que isto ainda iniciam escalonamento
+	janelaRecuperacao = 15 * time.Minute
+)
+
+// MotivoEscalonamento é o motivo do envio de um nível (um envio por nível e destino)
+func MotivoEscalonamento(nivel int) string {
+	return fmt.Sprintf("ESCALONAMENTO_%d", nivel)
+}
+
+// Escalonador inicia a cadeia de escalonamento das novas ocorrências ativas e sobe de nível
+// enquanto ninguém as reconhecer; o reconhecimento ou a resolução param a cadeia.
+// Os envios vão para o registo de notificações e são entregues pelo Notificador.
+type Escalonador struct {
+	bancoDados  *sql.DB
+	intervalo   time.Duration
+	ocorrencias *notificacoes.LeitorOcorrencias
+	canalParada chan struct{}
+	grupoWait   sync.WaitGroup
+}
+
+// NovoEscalonador cria o escalonador
+func NovoEscalonador(db *sql.DB, cfg *config.Configuracoes) *Escalonador {
+	e := &Escalonador{
+		bancoDados:  db,
+		intervalo:   cfg.Escalonamento_Intervalo,
+		ocorrencias: notificacoes.NovoLeitorOcorrencias(db, janelaRecuperacao),
+		canalParada: make(chan struct{}),
+	}
+	if e.intervalo <= 0 {
+		e.intervalo = 15 * time.Second
+	}
+	return e
+}
+
+// Iniciar verifica as ocorrências e os prazos a cada intervalo, até Parar
+func (e *Escalonador) Iniciar() {
+	e.grupoWait.Add(1)
+	go func() {
+		defer e.grupoWait.Done()
+
+		temporizador := time.NewTicker(e.intervalo)
+		defer temporizador.Stop()
+
+		for {
+			e.ciclo()
+
+			select {
+			case <-e.canalParada:
+				return
+			case <-temporizador.C:
+			}
+		}
+	}()
+}
+
+// Parar espera o ciclo em curso terminar
+func (e *Escalonador) Parar() {
+	close(e.canalParada)
+	e.grupoWait.Wait()
+}
+
+func (e *Escalonador) ciclo() {
+	politicas, err := CarregarPoliticas(e.bancoDados)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return
+	}
+
+	if err := e.iniciarNovas(politicas); err != nil {
+		log.Printf("❌ Erro ao iniciar escalonamentos: %v", err)
+	}
+	if err := e.encerrarTerminadas(); err != nil {
+		log.Printf("❌ Erro ao encerrar escalonamentos: %v", err)
+	}
+	if err := e.subirNiveis(politicas); err != nil {
+		log.Printf("❌ Erro ao escalonar ocorrências: %v", err)
+	}
+}
+
+// iniciarNovas associa cada nova ocorrência ativa e recente (não suprimida nem ocultada por
+// manutenção) à política que se lhe aplica
+func (e *Escalonador) iniciarNovas(politicas []Politica) error {
+	return e.ocorrencias.Percorrer(func(o notificacoes.Ocorrencia) error {
+		if !o.AtivaRecente(time.Now()) || o.Suprimida || o.EmManutencao {
+			return nil
+		}
+		politica := EscolherPolitica(politicas, o)
+		if politica == nil {
+			return nil
+		}
+
+		// A releitura repete ocorrências já em escalonamento; o ON CONFLICT ignora-as
+		result, err := e.bancoDados.Exec(`
+			INSERT INTO escalonamentos_ocorrencias (ocorrencia_id, politica_id, nivel_atual, estado, proximo_nivel_em)
+			VALUES ($1, $2, 0, 'ATIVO', NOW() + $3 * INTERVAL '1 minute')
+			ON CONFLICT (ocorrencia_id) DO NOTHING`,
+			o.ID, politica.ID, politica.Niveis[0].EsperaMinutos)
+		if err != nil {
+			return err
+		}
+		if inseridas, _ := result.RowsAffected(); inseridas > 0 {
+			log.Printf("📟 Ocorrência %d em escalonamento (política %q)", o.ID, politica.Nome)
+		}
+		return nil
+	})
+}
+
+// encerrarTerminadas acompanha o ciclo de vida da ocorrência: resolvida ou reconhecida para a cadeia
+func (e *Escalonador) encerrarTerminadas() error {
+	_, err := e.bancoDados.Exec(`
+		UPDATE escalonamentos_ocorrencias eo
+		SET estado = 'RESOLVIDO', proximo_nivel_em = NULL, atualizado_em = NOW()
+		FROM ocorrencias_falhas o
+		WHERE eo.ocorrencia_id = o.id AND eo.estado IN ('ATIVO', 'ESGOTADO') AND o.status = 'RESOLVIDO'`)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.bancoDados.Exec(`
+		UPDATE escalonamentos_ocorrencias eo
+		SET estado = 'RECONHECIDO', proximo_nivel_em = NULL, atualizado_em = NOW()
+		FROM ocorrencias_falhas o
+		WHERE eo.ocorrencia_id = o.id AND eo.estado IN ('ATIVO', 'ESGOTADO') AND o.reconhecida_em IS NOT NULL`)
+	return err
+}
+
+// subirNiveis notifica o nível seguinte das cadeias cujo prazo terminou
+func (e *Escalonador) subirNiveis(politicas []Politica) error {
+	rows, err := e.bancoDados.Query(`
+		SELECT ocorrencia_id, COALESCE(politica_id, 0), nivel_atual
+		FROM escalonamentos_ocorrencias
+		WHERE estado = 'ATIVO' AND proximo_nivel_em <= NOW()
+		ORDER BY proximo_nivel_em`)
+	if err != nil {
+		return err
+	}
+
+	type pendente struct {
+		ocorrenciaID int64
+		politicaID   int
+		nivelAtual   int
+	}
+	var pendentes []pendente
+	for rows.Next() {
+		var p pendente
+		if err := rows.Scan(&p.ocorrenciaID, &p.politicaID, &p.nivelAtual); err != nil {
+			rows.Close()
+			return err
+		}
+		pendentes = append(pendentes, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	porID := make(map[int]*Politica, len(politicas))
+	for i := range politicas {
+		porID[politicas[i].ID] = &politicas[i]
+	}
+	equipas := make(map[string]*Equipa)
+	agora := time.Now()
+
+	for _, p := range pendentes {
+		politica := porID[p.politicaID]
+		proximo := p.nivelAtual + 1
+		if politica == nil || proximo > len(politica.Niveis) {
+			e.marcarEsgotado(p.ocorrenciaID)
+			continue
+		}
+
+		ocorrencia, err := notificacoes.BuscarOcorrencia(e.bancoDados, p.ocorrenciaID)
+		if err != nil {
+			log.Printf("❌ Erro ao ler ocorrência %d para escalonamento: %v", p.ocorrenciaID, err)
+			continue
+		}
+
+		nivel := politica.Niveis[proximo-1]
+		destinos, err := e.destinosNivel(politica, nivel, equipas, agora)
+		if err != nil {
+			log.Printf("❌ Erro ao resolver destinos do nível %d (política %q): %v", nivel.Nivel, politica.Nome, err)
+		}
+		if len(destinos) == 0 {
+			log.Printf("⚠️  Nível %d da política %q sem destinos (ninguém de plantão ou sem contactos)", nivel.Nivel, politica.Nome)
+		}
+
+		mensagem := mensagemNivel(ocorrencia, nivel, agora)
+		for _, destino := range destinos {
+			if _, err := notificacoes.Enfileirar(e.bancoDados, ocorrencia.ID, 0, destino, mensagem); err != nil {
+				log.Printf("❌ %v", err)
+			}
+		}
+
+		if proximo < len(politica.Niveis) {
+			_, err = e.bancoDados.Exec(`
+				UPDATE escalonamentos_ocorrencias
+				SET nivel_atual = $2, proximo_nivel_em = NOW() + $3 * INTERVAL '1 minute', atualizado_em = NOW()
+				WHERE ocorrencia_id = $1`, p.ocorrenciaID, proximo, politica.Niveis[proximo].EsperaMinutos)
+		} else {
+			_, err = e.bancoDados.Exec(`
+				UPDATE escalonamentos_ocorrencias
+				SET nivel_atual = $2, estado = 'ESGOTADO', proximo_nivel_em = NULL, atualizado_em = NOW()
+				WHERE ocorrencia_id = $1`, p.ocorrenciaID, proximo)
+		}
+		if err != nil {
+			log.Printf("❌ Erro ao atualizar escalonamento da ocorrência %d: %v", p.ocorrenciaID, err)
+			continue
+		}
+		log.Printf("📟 Ocorrência %d escalonada para o nível %d (%d destinos)", p.ocorrenciaID, proximo, len(destinos))
+	}
+	return nil
+}
+
+func (e *Escalonador) marcarEsgotado(ocorrenciaID int64) {
+	e.bancoDados.Exec(`
+		UPDATE escalonamentos_ocorrencias SET estado = 'ESGOTADO', proximo_nivel_em = NULL, atualizado_em = NOW()
+		WHERE ocorrencia_id = $1`, ocorrenciaID)
+}
+
+// destinosNivel resolve os membros do nível nos endereços dos canais pedidos
+func (e *Escalonador) destinosNivel(politica *Politica, nivel Nivel, equipas map[string]*Equipa, agora time.Time) ([]notificacoes.Destino, error) {
+	codigo := nivel.Equipa
+	if codigo == "" {
+		codigo = politica.Equipa
+	}
+	equipa, existe := equipas[codigo]
+	if !existe {
+		var err error
+		if equipa, err = CarregarEquipa(e.bancoDados, codigo); err != nil {
+			return nil, fmt.Errorf("erro ao carregar equipa %s: %v", codigo, err)
+		}
+		equipas[codigo] = equipa
+	}
+
+	var membros []Membro
+	switch nivel.Alvo {
+	case AlvoPlantao:
+		if membro, _ := equipa.DePlantao(agora); membro != nil {
+			membros = append(membros, *membro)
+		}
+	case AlvoEquipa:
+		for _, membro := range equipa.Membros {
+			if membro.Ativo {
+				membros = append(membros, membro)
+			}
+		}
+	case AlvoMembro:
+		var m Membro
+		err := e.bancoDados.QueryRow(`
+			SELECT id, nome, COALESCE(email, ''), COALESCE(telefone, ''), ativo
+			FROM membros_equipa WHERE id = $1`, nivel.MembroID).Scan(&m.ID, &m.Nome, &m.Email, &m.Telefone, &m.Ativo)
+		if err != nil {
+			return nil, fmt.Errorf("membro %d não encontrado: %v", nivel.MembroID, err)
+		}
+		if m.Ativo {
+			membros = append(membros, m)
+		}
+	}
+
+	var destinos []notificacoes.Destino
+	for _, membro := range membros {
+		for _, canal := range nivel.Canais {
+			switch {
+			case canal == notificacoes.CanalSMS && membro.Telefone != "":
+				destinos = append(destinos, notificacoes.Destino{Canal: canal, Endereco: membro.Telefone})
+			case canal == notificacoes.CanalEmail && membro.Email != "":
+				destinos = append(destinos, notificacoes.Destino{Canal: canal, Endereco: membro.Email})
+			}
+		}
+	}
+	return destinos, nil
+}
+
+// mensagemNivel acrescenta à mensagem da ocorrência o nível e o tempo sem reconhecimento
+func mensagemNivel(o notificacoes.Ocorrencia, nivel Nivel, agora time.Time) notificacoes.Mensagem {
+	mensagem := o.Mensagem(MotivoEscalonamento(nivel.Nivel))
+	if nivel.Nivel > 1 {
+		minutos := int(agora.Sub(o.Inicio).Minutes())
+		mensagem.Assunto = fmt.Sprintf("[NÍVEL %d] %s", nivel.Nivel, mensagem.Assunto)
+		mensagem.Texto = fmt.Sprintf("Escalonamento nível %d: sem reconhecimento há %d min.\n\n%s",
+			nivel.Nivel, minutos, mensagem.Texto)
+	}
+	mensagem.Texto += "\nPara reconhecer: POST /api/v1/ocorrencias/" + fmt.Sprint(o.ID) + "/reconhecer\n"
+	mensagem.Dados["nivel_escalonamento"] = nivel.Nivel
+	mensagem.Dados["alvo"] = strings.ToLower(nivel.Alvo)
+	return mensagem
+}
@@ -0,0 +1,252 @@
+package plantao
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/notificacoes"
+)
+
+// Alvos de um nível de escalonamento
+const (
+	AlvoPlantao = "PLANTAO" // Quem está de plantão na equipa
+	AlvoEquipa  = "EQUIPA"  // Todos os membros ativos da equipa
+	AlvoMembro  = "MEMBRO"  // Um membro específico (ex.: supervisor)
+)
+
+// Nivel é um degrau da cadeia: EsperaMinutos conta a partir do nível anterior (no nível 1, a
+// partir da ativação). Equipa vazia = equipa da política.
+type Nivel struct {
+	Nivel         int      `json:"nivel"`
+	EsperaMinutos int      `json:"espera_minutos"`
+	Alvo          string   `json:"alvo"`
+	Equipa        string   `json:"equipa,omitempty"`
+	MembroID      int      `json:"membro_id,omitempty"`
+	Canais        []string `json:"canais"`
+}
+
+// Politica define a cadeia de escalonamento das ocorrências que correspondem aos filtros
+// (vazio = qualquer valor). Quando várias correspondem, vale a mais específica.
+type Politica struct {
+	ID         int       `json:"id"`
+	Nome       string    `json:"nome"`
+	Ativa      bool      `json:"ativa"`
+	Equipa     string    `json:"equipa"`
+	Eclusa     string    `json:"eclusa,omitempty"`
+	Setor      string    `json:"setor,omitempty"`
+	Prioridade string    `json:"prioridade,omitempty"`
+	Niveis     []Nivel   `json:"niveis"`
+	CriadaEm   time.Time `json:"created_at"`
+}
+
+// Validar normaliza os campos e verifica a cadeia de níveis
+func (p *Politica) Validar() error {
+	p.Nome = strings.TrimSpace(p.Nome)
+	p.Equipa = strings.ToUpper(strings.TrimSpace(p.Equipa))
+	p.Eclusa = strings.ToUpper(strings.TrimSpace(p.Eclusa))
+	p.Setor = strings.TrimSpace(p.Setor)
+	p.Prioridade = strings.ToUpper(strings.TrimSpace(p.Prioridade))
+
+	if p.Nome == "" || p.Equipa == "" {
+		return fmt.Errorf("campos 'nome' e 'equipa' são obrigatórios")
+	}
+	if p.Prioridade != "" && p.Prioridade != "ALTA" && p.Prioridade != "MEDIA" && p.Prioridade != "BAIXA" {
+		return fmt.Errorf("prioridade inválida: %s (use ALTA, MEDIA ou BAIXA)", p.Prioridade)
+	}
+	if len(p.Niveis) == 0 {
+		return fmt.Errorf("a política precisa de pelo menos um nível")
+	}
+
+	for i := range p.Niveis {
+		n := &p.Niveis[i]
+		n.Nivel = i + 1
+		n.Alvo = strings.ToUpper(strings.TrimSpace(n.Alvo))
+		n.Equipa = strings.ToUpper(strings.TrimSpace(n.Equipa))
+
+		if n.EsperaMinutos < 0 {
+			return fmt.Errorf("nível %d: 'espera_minutos' não pode ser negativo", n.Nivel)
+		}
+		switch n.Alvo {
+		case AlvoPlantao, AlvoEquipa:
+		case AlvoMembro:
+			if n.MembroID <= 0 {
+				return fmt.Errorf("nível %d: o alvo MEMBRO precisa de 'membro_id'", n.Nivel)
+			}
+		default:
+			return fmt.Errorf("nível %d: alvo inválido: %s (use PLANTAO, EQUIPA ou MEMBRO)", n.Nivel, n.Alvo)
+		}
+
+		if len(n.Canais) == 0 {
+			n.Canais = []string{notificacoes.CanalSMS, notificacoes.CanalEmail}
+		}
+		for j, canal := range n.Canais {
+			canal = strings.ToUpper(strings.TrimSpace(canal))
+			if canal != notificacoes.CanalSMS && canal != notificacoes.CanalEmail {
+				return fmt.Errorf("nível %d: canal inválido: %s (use SMS ou EMAIL)", n.Nivel, canal)
+			}
+			n.Canais[j] = canal
+		}
+	}
+	return nil
+}
+
+// Corresponde indica se a política se aplica à ocorrência
+func (p Politica) Corresponde(o notificacoes.Ocorrencia) bool {
+	return p.Ativa &&
+		(p.Eclusa == "" || p.Eclusa == o.EclusaCodigo) &&
+		(p.Setor == "" || p.Setor == o.SetorCodigo) &&
+		(p.Prioridade == "" || p.Prioridade == o.Prioridade)
+}
+
+// especificidade conta os filtros preenchidos
+func (p Politica) especificidade() int {
+	total := 0
+	for _, filtro := range []string{p.Eclusa, p.Setor, p.Prioridade} {
+		if filtro != "" {
+			total++
+		}
+	}
+	return total
+}
+
+// EscolherPolitica devolve a política mais específica que se aplica (empate: a mais antiga)
+func EscolherPolitica(politicas []Politica, o notificacoes.Ocorrencia) *Politica {
+	var escolhida *Politica
+	for i := range politicas {
+		p := &politicas[i]
+		if !p.Corresponde(o) {
+			continue
+		}
+		if escolhida == nil || p.especificidade() > escolhida.especificidade() {
+			escolhida = p
+		}
+	}
+	return escolhida
+}
+
+// CarregarPoliticas lê as políticas com os níveis, por id
+func CarregarPoliticas(db *sql.DB) ([]Politica, error) {
+	rows, err := db.Query(`
+		SELECT p.id, p.nome, p.ativa, eq.codigo, COALESCE(p.eclusa_codigo, ''), COALESCE(p.setor_codigo, ''),
+			COALESCE(p.prioridade, ''), p.created_at
+		FROM politicas_escalonamento p
+		JOIN equipas eq ON p.equipa_id = eq.id
+		ORDER BY p.id`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar políticas de escalonamento: %v", err)
+	}
+
+	var politicas []Politica
+	indices := make(map[int]int)
+	for rows.Next() {
+		var p Politica
+		if err := rows.Scan(&p.ID, &p.Nome, &p.Ativa, &p.Equipa, &p.Eclusa, &p.Setor, &p.Prioridade, &p.CriadaEm); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("erro ao ler política: %v", err)
+		}
+		p.Niveis = []Nivel{}
+		indices[p.ID] = len(politicas)
+		politicas = append(politicas, p)
+	}
+	rows.Close()
+
+	niveis, err := db.Query(`
+		SELECT n.politica_id, n.nivel, n.espera_minutos, n.alvo, COALESCE(eq.codigo, ''),
+			COALESCE(n.membro_id, 0), n.canais
+		FROM niveis_escalonamento n
+		LEFT JOIN equipas eq ON n.equipa_id = eq.id
+		ORDER BY n.politica_id, n.nivel`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar níveis de escalonamento: %v", err)
+	}
+	defer niveis.Close()
+
+	for niveis.Next() {
+		var n Nivel
+		var politicaID int
+		var canais string
+		if err := niveis.Scan(&politicaID, &n.Nivel, &n.EsperaMinutos, &n.Alvo, &n.Equipa, &n.MembroID, &canais); err != nil {
+			return nil, fmt.Errorf("erro ao ler nível: %v", err)
+		}
+		n.Canais = strings.Split(canais, ",")
+		if indice, existe := indices[politicaID]; existe {
+			politicas[indice].Niveis = append(politicas[indice].Niveis, n)
+		}
+	}
+	return politicas, niveis.Err()
+}
+
+// SalvarPolitica cria a política (ID = 0) ou substitui-a, com os níveis, numa transação
+func SalvarPolitica(db *sql.DB, p *Politica) error {
+	if err := p.Validar(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	var equipaID int
+	if err := tx.QueryRow("SELECT id FROM equipas WHERE codigo = $1", p.Equipa).Scan(&equipaID); err != nil {
+		return fmt.Errorf("equipa não encontrada: %s", p.Equipa)
+	}
+
+	args := []interface{}{p.Nome, p.Ativa, equipaID, nuloSeVazio(p.Eclusa), nuloSeVazio(p.Setor), nuloSeVazio(p.Prioridade)}
+	if p.ID == 0 {
+		err = tx.QueryRow(`
+			INSERT INTO politicas_escalonamento (nome, ativa, equipa_id, eclusa_codigo, setor_codigo, prioridade)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at`, args...).Scan(&p.ID, &p.CriadaEm)
+	} else {
+		err = tx.QueryRow(`
+			UPDATE politicas_escalonamento
+			SET nome = $1, ativa = $2, equipa_id = $3, eclusa_codigo = $4, setor_codigo = $5, prioridade = $6
+			WHERE id = $7
+			RETURNING created_at`, append(args, p.ID)...).Scan(&p.CriadaEm)
+	}
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao salvar política de escalonamento: %v", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM niveis_escalonamento WHERE politica_id = $1", p.ID); err != nil {
+		return fmt.Errorf("erro ao substituir níveis: %v", err)
+	}
+	for _, n := range p.Niveis {
+		var equipaNivel interface{}
+		if n.Equipa != "" {
+			var id int
+			if err := tx.QueryRow("SELECT id FROM equipas WHERE codigo = $1", n.Equipa).Scan(&id); err != nil {
+				return fmt.Errorf("nível %d: equipa não encontrada: %s", n.Nivel, n.Equipa)
+			}
+			equipaNivel = id
+		}
+		var membro interface{}
+		if n.Alvo == AlvoMembro {
+			membro = n.MembroID
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO niveis_escalonamento (politica_id, nivel, espera_minutos, alvo, equipa_id, membro_id, canais)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			p.ID, n.Nivel, n.EsperaMinutos, n.Alvo, equipaNivel, membro, strings.Join(n.Canais, ","))
+		if err != nil {
+			return fmt.Errorf("erro ao salvar nível %d: %v", n.Nivel, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func nuloSeVazio(valor string) interface{} {
+	if valor == "" {
+		return nil
+	}
+	return valor
+}
@@ -0,0 +1,148 @@
+package plc
+
+import (
+	"sync"
+	"time"
+)
+
+// ClassificacaoAlarme resume como uma ativação foi classificada pelo analisador
+type ClassificacaoAlarme struct {
+	FirstOut        bool  // Primeira ativação da janela (causa raiz provável)
+	GrupoFirstOutID int64 // Ocorrência first-out do grupo (0 se ainda não registrada)
+	InicioAvalanche bool  // Esta ativação ultrapassou o limite e abriu uma avalanche
+	AvalancheID     int64 // Avalanche em curso na eclusa (0 se não houver)
+	AtivacoesJanela int   // Ativações contadas na janela de avalanche
+}
+
+// estadoAlarmesEclusa guarda o estado de análise de uma eclusa
+type estadoAlarmesEclusa struct {
+	ativacoes      []time.Time // Ativações dentro da janela de avalanche
+	ultimaAtivacao time.Time
+	firstOutID     int64
+	avalancheID    int64
+	avalancheAtiva bool
+}
+
+// AnalisadorAlarmes detecta avalanches de alarmes e identifica o first-out por eclusa
+type AnalisadorAlarmes struct {
+	limiteAvalanche int
+	janelaAvalanche time.Duration
+	janelaFirstOut  time.Duration
+	estados         map[string]*estadoAlarmesEclusa // [eclusa_codigo]
+	mutex           sync.Mutex
+}
+
+// NovoAnalisadorAlarmes cria um novo analisador de alarmes
+func NovoAnalisadorAlarmes(limiteAvalanche int, janelaAvalanche, janelaFirstOut time.Duration) *AnalisadorAlarmes {
+	return &AnalisadorAlarmes{
+		limiteAvalanche: limiteAvalanche,
+		janelaAvalanche: janelaAvalanche,
+		janelaFirstOut:  janelaFirstOut,
+		estados:         make(map[string]*estadoAlarmesEclusa),
+	}
+}
+
+// ClassificarAtivacao classifica uma nova ativação de alarme numa eclusa
+//
+// A primeira ativação após um período de silêncio maior que a janela de first-out
+// abre um novo grupo e é marcada como first-out; as seguintes do mesmo frame ou da
+// mesma janela pertencem ao grupo dela.
+func (a *AnalisadorAlarmes) ClassificarAtivacao(eclusaCodigo string, dataHora time.Time) ClassificacaoAlarme {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	estado := a.obterEstado(eclusaCodigo)
+	var classificacao ClassificacaoAlarme
+
+	// First-out: nenhuma ativação recente nesta eclusa
+	if estado.ultimaAtivacao.IsZero() || dataHora.Sub(estado.ultimaAtivacao) > a.janelaFirstOut {
+		classificacao.FirstOut = true
+		estado.firstOutID = 0
+	} else {
+		classificacao.GrupoFirstOutID = estado.firstOutID
+	}
+	estado.ultimaAtivacao = dataHora
+
+	// Avalanche: mais de N ativações na janela deslizante
+	estado.ativacoes = append(a.podarJanela(estado.ativacoes, dataHora), dataHora)
+	classificacao.AtivacoesJanela = len(estado.ativacoes)
+
+	if a.limiteAvalanche > 0 && len(estado.ativacoes) > a.limiteAvalanche && !estado.avalancheAtiva {
+		estado.avalancheAtiva = true
+		estado.avalancheID = 0
+		classificacao.InicioAvalanche = true
+	}
+	if estado.avalancheAtiva {
+		classificacao.AvalancheID = estado.avalancheID
+	}
+
+	return classificacao
+}
+
+// ConfirmarFirstOut associa a ocorrência gravada ao grupo first-out aberto
+func (a *AnalisadorAlarmes) ConfirmarFirstOut(eclusaCodigo string, ocorrenciaID int64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.obterEstado(eclusaCodigo).firstOutID = ocorrenciaID
+}
+
+// ConfirmarAvalanche associa o registro de avalanche gravado à eclusa
+func (a *AnalisadorAlarmes) ConfirmarAvalanche(eclusaCodigo string, avalancheID int64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.obterEstado(eclusaCodigo).avalancheID = avalancheID
+}
+
+// VerificarFimAvalanche encerra a avalanche quando a taxa volta ao normal,
+// retornando o ID da avalanche encerrada (0 se nenhuma terminou)
+func (a *AnalisadorAlarmes) VerificarFimAvalanche(eclusaCodigo string, agora time.Time) int64 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	estado := a.obterEstado(eclusaCodigo)
+	estado.ativacoes = a.podarJanela(estado.ativacoes, agora)
+
+	if estado.avalancheAtiva && len(estado.ativacoes) <= a.limiteAvalanche {
+		avalancheID := estado.avalancheID
+		estado.avalancheAtiva = false
+		estado.avalancheID = 0
+		return avalancheID
+	}
+	return 0
+}
+
+// EclusasEmAvalanche retorna os códigos das eclusas com avalanche em curso
+func (a *AnalisadorAlarmes) EclusasEmAvalanche() []string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	var eclusas []string
+	for codigo, estado := range a.estados {
+		if estado.avalancheAtiva {
+			eclusas = append(eclusas, codigo)
+		}
+	}
+	return eclusas
+}
+
+// obterEstado retorna (criando se necessário) o estado da eclusa
+func (a *AnalisadorAlarmes) obterEstado(eclusaCodigo string) *estadoAlarmesEclusa {
+	estado, existe := a.estados[eclusaCodigo]
+	if !existe {
+		estado = &estadoAlarmesEclusa{}
+		a.estados[eclusaCodigo] = estado
+	}
+	return estado
+}
+
+// podarJanela remove ativações mais antigas que a janela de avalanche
+func (a *AnalisadorAlarmes) podarJanela(ativacoes []time.Time, agora time.Time) []time.Time {
+	limite := agora.Add(-a.janelaAvalanche)
+	indice := 0
+	for indice < len(ativacoes) && ativacoes[indice].Before(limite) {
+		indice++
+	}
+	return ativacoes[indice:]
+}
@@ -0,0 +1,239 @@
+package plc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+)
+
+// prefixoArquivoCaptura e extensaoCaptura formam o nome dos arquivos de captura
+const (
+	prefixoArquivoCaptura = "captura_"
+	extensaoCaptura       = ".ndjson"
+)
+
+// FrameCapturado é uma linha do arquivo de captura (um frame TCP bruto, antes de qualquer decodificação)
+type FrameCapturado struct {
+	DataHora time.Time `json:"ts"`     // Momento da recepção no servidor
+	Origem   string    `json:"origem"` // Endereço do cliente (PLC)
+	Dados    string    `json:"dados"`  // Bytes recebidos em hexadecimal
+}
+
+// Bytes devolve os bytes brutos do frame
+func (f FrameCapturado) Bytes() ([]byte, error) {
+	return hex.DecodeString(f.Dados)
+}
+
+// GravadorCapturas grava os frames recebidos em arquivos NDJSON com rotação por tamanho
+type GravadorCapturas struct {
+	diretorio       string
+	tamanhoMaximo   int64 // Bytes por arquivo antes de rodar
+	arquivosMaximos int   // Arquivos mantidos no diretório (0 = sem limite)
+
+	mutex    sync.Mutex
+	arquivo  *os.File
+	escritor *bufio.Writer
+	tamanho  int64
+}
+
+// NovoGravadorCapturas cria o gravador e abre o primeiro arquivo de captura
+func NovoGravadorCapturas(diretorio string, tamanhoMaximo int64, arquivosMaximos int) (*GravadorCapturas, error) {
+	if err := os.MkdirAll(diretorio, 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório de capturas: %w", err)
+	}
+
+	gravador := &GravadorCapturas{
+		diretorio:       diretorio,
+		tamanhoMaximo:   tamanhoMaximo,
+		arquivosMaximos: arquivosMaximos,
+	}
+
+	if err := gravador.rodarArquivo(); err != nil {
+		return nil, err
+	}
+	return gravador, nil
+}
+
+// Gravar acrescenta um frame à captura. Os bytes são copiados, o buffer pode ser reutilizado.
+func (g *GravadorCapturas) Gravar(dataHora time.Time, origem string, dados []byte) error {
+	linha, err := json.Marshal(FrameCapturado{
+		DataHora: dataHora,
+		Origem:   origem,
+		Dados:    hex.EncodeToString(dados),
+	})
+	if err != nil {
+		return err
+	}
+	linha = append(linha, '\n')
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.escritor == nil {
+		return fmt.Errorf("gravador de capturas fechado")
+	}
+
+	if g.tamanhoMaximo > 0 && g.tamanho > 0 && g.tamanho+int64(len(linha)) > g.tamanhoMaximo {
+		if err := g.rodarArquivo(); err != nil {
+			return err
+		}
+	}
+
+	n, err := g.escritor.Write(linha)
+	g.tamanho += int64(n)
+	if err != nil {
+		return err
+	}
+
+	// Descarregar a cada frame: uma queda do processo não pode perder o incidente
+	return g.escritor.Flush()
+}
+
+// Fechar descarrega e fecha o arquivo atual
+func (g *GravadorCapturas) Fechar() error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.fecharArquivo()
+}
+
+// rodarArquivo fecha o arquivo atual, abre um novo e remove as capturas mais antigas
+func (g *GravadorCapturas) rodarArquivo() error {
+	if err := g.fecharArquivo(); err != nil {
+		return err
+	}
+
+	nome := prefixoArquivoCaptura + time.Now().Format("20060102_150405.000") + extensaoCaptura
+	arquivo, err := os.OpenFile(filepath.Join(g.diretorio, nome), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo de captura: %w", err)
+	}
+
+	g.arquivo = arquivo
+	g.escritor = bufio.NewWriter(arquivo)
+	g.tamanho = 0
+
+	return g.removerCapturasAntigas()
+}
+
+// fecharArquivo fecha o arquivo atual (se houver)
+func (g *GravadorCapturas) fecharArquivo() error {
+	if g.arquivo == nil {
+		return nil
+	}
+
+	errFlush := g.escritor.Flush()
+	errClose := g.arquivo.Close()
+	g.arquivo = nil
+	g.escritor = nil
+
+	if errFlush != nil {
+		return errFlush
+	}
+	return errClose
+}
+
+// removerCapturasAntigas mantém apenas os arquivosMaximos arquivos mais recentes
+func (g *GravadorCapturas) removerCapturasAntigas() error {
+	if g.arquivosMaximos <= 0 {
+		return nil
+	}
+
+	arquivos, err := filepath.Glob(filepath.Join(g.diretorio, prefixoArquivoCaptura+"*"+extensaoCaptura))
+	if err != nil {
+		return err
+	}
+
+	// O nome contém o timestamp, a ordem alfabética é a ordem cronológica
+	sort.Strings(arquivos)
+	for len(arquivos) > g.arquivosMaximos {
+		if err := os.Remove(arquivos[0]); err != nil {
+			return fmt.Errorf("erro ao remover captura antiga: %w", err)
+		}
+		arquivos = arquivos[1:]
+	}
+	return nil
+}
+
+// LeitorCaptura lê sequencialmente os frames de um arquivo de captura
+type LeitorCaptura struct {
+	arquivo *os.File
+	scanner *bufio.Scanner
+	linha   int
+}
+
+// AbrirCaptura abre um arquivo de captura para leitura
+func AbrirCaptura(caminho string) (*LeitorCaptura, error) {
+	arquivo, err := os.Open(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir captura: %w", err)
+	}
+
+	scanner := bufio.NewScanner(arquivo)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	return &LeitorCaptura{arquivo: arquivo, scanner: scanner}, nil
+}
+
+// Proximo devolve o próximo frame, ou io.EOF no fim do arquivo
+func (l *LeitorCaptura) Proximo() (FrameCapturado, error) {
+	for l.scanner.Scan() {
+		l.linha++
+		if len(l.scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var frame FrameCapturado
+		if err := json.Unmarshal(l.scanner.Bytes(), &frame); err != nil {
+			return frame, fmt.Errorf("linha %d da captura inválida: %w", l.linha, err)
+		}
+		return frame, nil
+	}
+
+	if err := l.scanner.Err(); err != nil {
+		return FrameCapturado{}, err
+	}
+	return FrameCapturado{}, io.EOF
+}
+
+// Fechar fecha o arquivo de captura
+func (l *LeitorCaptura) Fechar() error {
+	return l.arquivo.Close()
+}
+
+// MontarMensagemPLC converte um frame bruto na mensagem processada pelo ProcessadorDados.
+// É o mesmo caminho usado pelo servidor TCP e pelo replay, para que ambos decodifiquem igual.
+func MontarMensagemPLC(formatoTimestamp string, dados []byte, origem string, recebimento time.Time) (modelos.MensagemPLC, error) {
+	// Separar o cabeçalho de timestamp do PLC (se configurado)
+	dataHoraPLC, dados, err := DecodificarTimestampFrame(formatoTimestamp, dados)
+
+	// Cada WORD tem 2 bytes (16 bits), lidas em Big Endian
+	numeroWords := len(dados) / 2
+	words := make([]modelos.DadosWord, 0, numeroWords)
+
+	for i := 0; i < numeroWords; i++ {
+		offset := i * 2
+		words = append(words, modelos.DadosWord{
+			Endereco: i,
+			Valor:    binary.BigEndian.Uint16(dados[offset : offset+2]),
+			DataHora: recebimento,
+		})
+	}
+
+	return modelos.MensagemPLC{
+		Words:       words,
+		DataHora:    recebimento,
+		IdPLC:       origem,
+		DataHoraPLC: dataHoraPLC,
+	}, err
+}
@@ -0,0 +1,341 @@
+package plc
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+)
+
+// Portas da caldeira
+const (
+	portaJusante  = "jusante"
+	portaMontante = "montante"
+)
+
+// cicloEmCurso é a eclusagem que está a decorrer numa eclusa. Enquanto está na preparação
+// (porta fechada, nivelamento ainda não iniciado) vive só em memória: uma eclusa parada com as
+// portas fechadas não é uma eclusagem.
+type cicloEmCurso struct {
+	id                     int64 // 0 enquanto não gravado
+	eclusaID               int
+	direcao                string
+	fase                   string // "" = preparação
+	portaFechada           string // Porta cujo fecho iniciou o ciclo ("" = início no nivelamento)
+	inicio                 time.Time
+	inicioNivelamento      time.Time     // Início do primeiro nivelamento
+	inicioNivelamentoAtual time.Time     // Início do nivelamento em curso (há re-nivelamentos)
+	nivelamentoTerminado   time.Duration // Soma dos nivelamentos já terminados
+	fimNivelamento         time.Time     // Fim do último nivelamento
+}
+
+// inicioFase devolve quando começou a fase atual
+func (c *cicloEmCurso) inicioFase() time.Time {
+	switch c.fase {
+	case modelos.FaseEclusagemNivelamento:
+		return c.inicioNivelamentoAtual
+	case modelos.FaseEclusagemAbertura:
+		return c.fimNivelamento
+	}
+	return c.inicio
+}
+
+// duracaoNivelamento soma as fases de nivelamento do ciclo (a em curso conta até dataHora),
+// sem o tempo em que a eclusa esperou entre um nivelamento e o seguinte
+func (c *cicloEmCurso) duracaoNivelamento(dataHora time.Time) time.Duration {
+	if c.fase == modelos.FaseEclusagemNivelamento {
+		return c.nivelamentoTerminado + dataHora.Sub(c.inicioNivelamentoAtual)
+	}
+	return c.nivelamentoTerminado
+}
+
+// DetectorEclusagens deriva os ciclos de eclusagem das mudanças do EstadoEclusa:
+// fecho de porta -> enchimento (subida) ou esvaziamento (descida) -> abertura da porta oposta
+type DetectorEclusagens struct {
+	bancoDados       *sql.DB
+	tempoMaximoFase  time.Duration
+	tempoMaximoCiclo time.Duration
+	mutex            sync.Mutex
+	ciclos           map[string]*cicloEmCurso // [eclusa] ciclo em curso
+	anteriores       map[string]modelos.EstadoEclusa
+	eclusaIDs        map[string]int
+}
+
+// NovoDetectorEclusagens cria o detector e fecha os ciclos que ficaram em curso num arranque anterior
+func NovoDetectorEclusagens(db *sql.DB, tempoMaximoFase, tempoMaximoCiclo time.Duration) *DetectorEclusagens {
+	detector := &DetectorEclusagens{
+		bancoDados:       db,
+		tempoMaximoFase:  tempoMaximoFase,
+		tempoMaximoCiclo: tempoMaximoCiclo,
+		ciclos:           make(map[string]*cicloEmCurso),
+		anteriores:       make(map[string]modelos.EstadoEclusa),
+		eclusaIDs:        make(map[string]int),
+	}
+
+	_, err := db.Exec(`
+		UPDATE eclusagens SET status = 'ABORTADA', fase = 'FIM',
+			motivo = 'ciclo interrompido por reinício do servidor'
+		WHERE status = 'EM_CURSO'`)
+	if err != nil {
+		log.Printf("⚠️ Erro ao fechar eclusagens pendentes: %v", err)
+	}
+
+	return detector
+}
+
+// Processar avança o ciclo da eclusa com um novo estado publicado pelo ProjetorEstado
+func (d *DetectorEclusagens) Processar(estado modelos.EstadoEclusa) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	anterior, existe := d.anteriores[estado.EclusaCodigo]
+	d.anteriores[estado.EclusaCodigo] = estado
+	if !existe {
+		// Primeiro estado: sem transições a detetar
+		return
+	}
+
+	codigo := estado.EclusaCodigo
+	dataHora := estado.UltimaAtualizacao
+
+	// Transições deste estado
+	var portaFechou, portaAbriu, nivelamentoIniciou, nivelamentoTerminou string
+	if anterior.PortaJusanteAberta && !estado.PortaJusanteAberta {
+		portaFechou = portaJusante
+	}
+	if anterior.PortaMontanteAberta && !estado.PortaMontanteAberta {
+		portaFechou = portaMontante
+	}
+	if !anterior.PortaJusanteAberta && estado.PortaJusanteAberta {
+		portaAbriu = portaJusante
+	}
+	if !anterior.PortaMontanteAberta && estado.PortaMontanteAberta {
+		portaAbriu = portaMontante
+	}
+	if !anterior.EnchimentoAtivo && estado.EnchimentoAtivo {
+		nivelamentoIniciou = modelos.DirecaoSubida
+	}
+	if !anterior.EsvaziamentoAtivo && estado.EsvaziamentoAtivo {
+		nivelamentoIniciou = modelos.DirecaoDescida
+	}
+	if anterior.EnchimentoAtivo && !estado.EnchimentoAtivo {
+		nivelamentoTerminou = modelos.DirecaoSubida
+	}
+	if anterior.EsvaziamentoAtivo && !estado.EsvaziamentoAtivo {
+		nivelamentoTerminou = modelos.DirecaoDescida
+	}
+	portasFechadas := !estado.PortaJusanteAberta && !estado.PortaMontanteAberta
+
+	ciclo := d.ciclos[codigo]
+	if ciclo != nil {
+		switch ciclo.fase {
+		case "":
+			// Preparação: a porta voltou a abrir sem nivelamento, não houve eclusagem
+			if portaAbriu != "" {
+				delete(d.ciclos, codigo)
+				ciclo = nil
+			} else if nivelamentoIniciou != "" {
+				d.iniciarNivelamento(codigo, ciclo, nivelamentoIniciou, dataHora)
+			}
+
+		case modelos.FaseEclusagemNivelamento:
+			switch {
+			case portaAbriu != "":
+				d.fechar(codigo, ciclo, modelos.StatusEclusagemAbortada,
+					fmt.Sprintf("porta %s aberta durante o nivelamento", portaAbriu), dataHora)
+				ciclo = nil
+			case nivelamentoIniciou != "" && nivelamentoIniciou != ciclo.direcao:
+				d.fechar(codigo, ciclo, modelos.StatusEclusagemAbortada, "nivelamento invertido", dataHora)
+				ciclo = nil
+			case nivelamentoTerminou == ciclo.direcao:
+				ciclo.nivelamentoTerminado = ciclo.duracaoNivelamento(dataHora)
+				ciclo.fase = modelos.FaseEclusagemAbertura
+				ciclo.fimNivelamento = dataHora
+				d.gravarFase(ciclo)
+			}
+
+		case modelos.FaseEclusagemAbertura:
+			switch {
+			case portaAbriu != "" && portaAbriu == portaSaida(ciclo.direcao):
+				d.fechar(codigo, ciclo, modelos.StatusEclusagemConcluida, "", dataHora)
+				ciclo = nil
+			case portaAbriu != "":
+				d.fechar(codigo, ciclo, modelos.StatusEclusagemAbortada,
+					fmt.Sprintf("porta %s reaberta após o nivelamento", portaAbriu), dataHora)
+				ciclo = nil
+			case nivelamentoIniciou == ciclo.direcao:
+				// Novo nivelamento no mesmo sentido (níveis ainda não iguais)
+				ciclo.fase = modelos.FaseEclusagemNivelamento
+				ciclo.inicioNivelamentoAtual = dataHora
+				d.gravarFase(ciclo)
+			case nivelamentoIniciou != "":
+				d.fechar(codigo, ciclo, modelos.StatusEclusagemAbortada, "nivelamento invertido", dataHora)
+				ciclo = nil
+			}
+		}
+	}
+
+	// Sem ciclo: o fecho de uma porta (com a outra fechada) ou o início do nivelamento abre um novo
+	if ciclo == nil {
+		switch {
+		case portaFechou != "" && portasFechadas && nivelamentoIniciou == "":
+			d.ciclos[codigo] = &cicloEmCurso{portaFechada: portaFechou, inicio: dataHora}
+		case nivelamentoIniciou != "" && portasFechadas:
+			novo := &cicloEmCurso{portaFechada: portaFechou, inicio: dataHora}
+			d.ciclos[codigo] = novo
+			d.iniciarNivelamento(codigo, novo, nivelamentoIniciou, dataHora)
+		}
+	}
+}
+
+// VerificarTempos fecha como TIMEOUT os ciclos com uma fase ou duração total acima do limite.
+// Uma preparação longa apenas é descartada (eclusa parada com as portas fechadas).
+func (d *DetectorEclusagens) VerificarTempos(agora time.Time) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for codigo, ciclo := range d.ciclos {
+		if d.tempoMaximoFase <= 0 || agora.Sub(ciclo.inicioFase()) <= d.tempoMaximoFase {
+			if d.tempoMaximoCiclo <= 0 || ciclo.fase == "" || agora.Sub(ciclo.inicio) <= d.tempoMaximoCiclo {
+				continue
+			}
+		}
+
+		if ciclo.fase == "" {
+			delete(d.ciclos, codigo)
+			continue
+		}
+
+		motivo := fmt.Sprintf("fase %s excedeu %s", ciclo.fase, d.tempoMaximoFase)
+		if d.tempoMaximoCiclo > 0 && agora.Sub(ciclo.inicio) > d.tempoMaximoCiclo {
+			motivo = fmt.Sprintf("eclusagem excedeu %s", d.tempoMaximoCiclo)
+		}
+		d.fechar(codigo, ciclo, modelos.StatusEclusagemTimeout, motivo, agora)
+	}
+}
+
+// iniciarNivelamento grava a eclusagem quando o enchimento/esvaziamento começa
+func (d *DetectorEclusagens) iniciarNivelamento(codigo string, ciclo *cicloEmCurso, direcao string, dataHora time.Time) {
+	eclusaID, err := d.obterEclusaID(codigo)
+	if err != nil {
+		log.Printf("❌ Erro ao registar eclusagem de %s: %v", codigo, err)
+		delete(d.ciclos, codigo)
+		return
+	}
+
+	ciclo.eclusaID = eclusaID
+	ciclo.direcao = direcao
+	ciclo.fase = modelos.FaseEclusagemNivelamento
+	ciclo.inicioNivelamento = dataHora
+	ciclo.inicioNivelamentoAtual = dataHora
+
+	var preparacao sql.NullFloat64
+	if ciclo.portaFechada != "" {
+		preparacao = sql.NullFloat64{Float64: dataHora.Sub(ciclo.inicio).Seconds(), Valid: true}
+	}
+
+	err = d.bancoDados.QueryRow(`
+		INSERT INTO eclusagens (eclusa_id, direcao, status, fase, timestamp_inicio,
+			timestamp_inicio_nivelamento, duracao_preparacao_segundos)
+		VALUES ($1, $2, 'EM_CURSO', $3, $4, $5, $6)
+		RETURNING id`,
+		eclusaID, direcao, ciclo.fase, ciclo.inicio, dataHora, preparacao).Scan(&ciclo.id)
+	if err != nil {
+		log.Printf("❌ Erro ao registar eclusagem de %s: %v", codigo, err)
+		delete(d.ciclos, codigo)
+		return
+	}
+
+	log.Printf("🚢 Eclusagem %d de %s iniciada (%s)", ciclo.id, codigo, direcao)
+}
+
+// gravarFase atualiza a fase e o fim do nivelamento da eclusagem em curso
+func (d *DetectorEclusagens) gravarFase(ciclo *cicloEmCurso) {
+	var fimNivelamento sql.NullTime
+	var duracaoNivelamento sql.NullFloat64
+	if ciclo.fase == modelos.FaseEclusagemAbertura {
+		fimNivelamento = sql.NullTime{Time: ciclo.fimNivelamento, Valid: true}
+		duracaoNivelamento = sql.NullFloat64{Float64: ciclo.duracaoNivelamento(ciclo.fimNivelamento).Seconds(), Valid: true}
+	}
+
+	_, err := d.bancoDados.Exec(`
+		UPDATE eclusagens SET fase = $2, timestamp_fim_nivelamento = $3, duracao_nivelamento_segundos = $4
+		WHERE id = $1`, ciclo.id, ciclo.fase, fimNivelamento, duracaoNivelamento)
+	if err != nil {
+		log.Printf("❌ Erro ao atualizar eclusagem %d: %v", ciclo.id, err)
+	}
+}
+
+// fechar termina a eclusagem, calcula as durações e liga-lhe as falhas ocorridas durante o ciclo
+func (d *DetectorEclusagens) fechar(codigo string, ciclo *cicloEmCurso, status, motivo string, dataHora time.Time) {
+	delete(d.ciclos, codigo)
+
+	var duracaoNivelamento, duracaoAbertura sql.NullFloat64
+	switch ciclo.fase {
+	case modelos.FaseEclusagemNivelamento:
+		duracaoNivelamento = sql.NullFloat64{Float64: ciclo.duracaoNivelamento(dataHora).Seconds(), Valid: true}
+	case modelos.FaseEclusagemAbertura:
+		duracaoNivelamento = sql.NullFloat64{Float64: ciclo.duracaoNivelamento(dataHora).Seconds(), Valid: true}
+		duracaoAbertura = sql.NullFloat64{Float64: dataHora.Sub(ciclo.fimNivelamento).Seconds(), Valid: true}
+	}
+
+	var motivoNulo sql.NullString
+	if motivo != "" {
+		motivoNulo = sql.NullString{String: motivo, Valid: true}
+	}
+
+	_, err := d.bancoDados.Exec(`
+		UPDATE eclusagens SET status = $2, fase = 'FIM', timestamp_fim = $3,
+			duracao_nivelamento_segundos = $4, duracao_abertura_segundos = $5,
+			duracao_total_segundos = $6, motivo = $7
+		WHERE id = $1`,
+		ciclo.id, status, dataHora, duracaoNivelamento, duracaoAbertura,
+		dataHora.Sub(ciclo.inicio).Seconds(), motivoNulo)
+	if err != nil {
+		log.Printf("❌ Erro ao fechar eclusagem %d: %v", ciclo.id, err)
+		return
+	}
+
+	// Falhas da eclusa que começaram durante o ciclo
+	_, err = d.bancoDados.Exec(`
+		UPDATE ocorrencias_falhas o SET eclusagem_id = $1
+		FROM definicoes_falhas df
+		WHERE o.definicao_id = df.id AND df.eclusa_id = $2
+		AND df.tipo = 'FALHA'
+		AND o.timestamp_inicio >= $3 AND o.timestamp_inicio <= $4
+		AND o.eclusagem_id IS NULL`, ciclo.id, ciclo.eclusaID, ciclo.inicio, dataHora)
+	if err != nil {
+		log.Printf("⚠️ Erro ao correlacionar falhas com a eclusagem %d: %v", ciclo.id, err)
+	}
+
+	if motivo != "" {
+		log.Printf("⚠️  Eclusagem %d de %s: %s (%s)", ciclo.id, codigo, status, motivo)
+	} else {
+		log.Printf("✅ Eclusagem %d de %s concluída em %s", ciclo.id, codigo, dataHora.Sub(ciclo.inicio).Round(time.Second))
+	}
+}
+
+// obterEclusaID devolve o id da eclusa pelo código (com cache)
+func (d *DetectorEclusagens) obterEclusaID(codigo string) (int, error) {
+	if id, existe := d.eclusaIDs[codigo]; existe {
+		return id, nil
+	}
+
+	var id int
+	if err := d.bancoDados.QueryRow("SELECT id FROM eclusas WHERE codigo = $1", codigo).Scan(&id); err != nil {
+		return 0, err
+	}
+	d.eclusaIDs[codigo] = id
+	return id, nil
+}
+
+// portaSaida devolve a porta que abre no fim da eclusagem: montante na subida, jusante na descida
+func portaSaida(direcao string) string {
+	if direcao == modelos.DirecaoSubida {
+		return portaMontante
+	}
+	return portaJusante
+}
@@ -0,0 +1,37 @@
+package plc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+)
+
+func TestDuracaoNivelamentoSomaReNivelamentos(t *testing.T) {
+	inicio := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+	ciclo := &cicloEmCurso{
+		fase:                   modelos.FaseEclusagemNivelamento,
+		inicioNivelamento:      inicio,
+		inicioNivelamentoAtual: inicio,
+	}
+
+	// Primeiro nivelamento: 5 min; espera de 2 min; segundo nivelamento: 1 min
+	fim := inicio.Add(5 * time.Minute)
+	ciclo.nivelamentoTerminado = ciclo.duracaoNivelamento(fim)
+	ciclo.fase, ciclo.fimNivelamento = modelos.FaseEclusagemAbertura, fim
+
+	ciclo.fase, ciclo.inicioNivelamentoAtual = modelos.FaseEclusagemNivelamento, fim.Add(2*time.Minute)
+	if got := ciclo.duracaoNivelamento(fim.Add(2*time.Minute + 30*time.Second)); got != 5*time.Minute+30*time.Second {
+		t.Errorf("nivelamento em curso = %s; esperado 5m30s", got)
+	}
+
+	fim = fim.Add(3 * time.Minute)
+	ciclo.nivelamentoTerminado = ciclo.duracaoNivelamento(fim)
+	ciclo.fase, ciclo.fimNivelamento = modelos.FaseEclusagemAbertura, fim
+	if got := ciclo.duracaoNivelamento(fim.Add(4 * time.Minute)); got != 6*time.Minute {
+		t.Errorf("nivelamento total = %s; esperado 6m (sem a espera nem a abertura)", got)
+	}
+	if got := ciclo.inicioFase(); !got.Equal(fim) {
+		t.Errorf("início da abertura = %s; esperado o fim do último nivelamento %s", got, fim)
+	}
+}
@@ -4,21 +4,34 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"sync"
 	
 	"github.com/edp/falhas-backend/modelos"
+	"github.com/edp/falhas-backend/repositorio"
 )
 
 // MapeamentoTags gerencia o mapeamento entre endereços PLC e falhas do banco de dados
 type MapeamentoTags struct {
 	falhasPorWord  map[int]map[int]modelos.DefinicaoFalha // [word_index][bit_index] = falha
-	bancoDados     *sql.DB
+	definicoes     repositorio.Definicoes
+	mutex          sync.RWMutex // A API recarrega o mapeamento enquanto os frames são processados
 }
 
 // NovoMapeamentoTags cria uma nova instância do mapeamento
 func NovoMapeamentoTags(db *sql.DB) *MapeamentoTags {
+	var definicoes repositorio.Definicoes
+	if db != nil {
+		definicoes = repositorio.NovoPostgres(db).Definicoes
+	}
+	return NovoMapeamentoDefinicoes(definicoes)
+}
+
+// NovoMapeamentoDefinicoes cria o mapeamento a partir de um repositório de definições
+// (ex.: repositorio.Memoria nos testes)
+func NovoMapeamentoDefinicoes(definicoes repositorio.Definicoes) *MapeamentoTags {
 	mapeamento := &MapeamentoTags{
 		falhasPorWord: make(map[int]map[int]modelos.DefinicaoFalha),
-		bancoDados:    db,
+		definicoes:    definicoes,
 	}
 	
 	// Carregar mapeamento do banco de dados
@@ -34,55 +47,63 @@ func NovoMapeamentoTags(db *sql.DB) *MapeamentoTags {
 
 // carregarMapeamentoBanco carrega o mapeamento das falhas do banco de dados
 func (m *MapeamentoTags) carregarMapeamentoBanco() error {
-	query := `
-		SELECT 
-			df.id, df.codigo, df.tipo, df.descricao, df.prioridade, 
-			df.point_index, df.word_index, df.bit_index, df.classe_mensagem,
-			s.codigo as setor_codigo, s.nome as setor_nome,
-			e.codigo as eclusa_codigo
-		FROM definicoes_falhas df
-		JOIN setores s ON df.setor_id = s.id
-		JOIN eclusas e ON df.eclusa_id = e.id
-		WHERE df.ativa = true 
-		AND df.word_index IS NOT NULL 
-		AND df.bit_index IS NOT NULL
-		ORDER BY df.word_index, df.bit_index`
+	if m.definicoes == nil {
+		return fmt.Errorf("sem conexão com o banco de dados")
+	}
+
+	definicoes, err := m.definicoes.ListarMapeadas()
+	if err != nil {
+		return err
+	}
+	
+	for _, falha := range definicoes {
+		m.adicionarFalha(falha.WordIndex, falha.BitIndex, falha)
+	}
 	
-	rows, err := m.bancoDados.Query(query)
+	log.Printf("✅ Carregadas %d definições de falhas/eventos do banco", len(definicoes))
+	return nil
+}
+
+// Recarregar volta a ler as definições do banco (ex.: depois de a API alterar prioridades ou
+// severidade). Em caso de erro o mapeamento atual mantém-se.
+func (m *MapeamentoTags) Recarregar() error {
+	m.mutex.RLock()
+	fonte := m.definicoes
+	m.mutex.RUnlock()
+	if fonte == nil {
+		return fmt.Errorf("sem conexão com o banco de dados")
+	}
+
+	definicoes, err := fonte.ListarMapeadas()
 	if err != nil {
-		return fmt.Errorf("erro ao buscar definições do banco: %v", err)
+		return fmt.Errorf("erro ao recarregar definições: %v", err)
 	}
-	defer rows.Close()
 	
-	contador := 0
-	for rows.Next() {
-		var falha modelos.DefinicaoFalha
-		var setorCodigo, setorNome, eclusaCodigo string
-		
-		err := rows.Scan(
-			&falha.ID, &falha.Codigo, &falha.Tipo, &falha.Descricao, &falha.Prioridade,
-			&falha.PointIndex, &falha.WordIndex, &falha.BitIndex, &falha.ClasseMensagem,
-			&setorCodigo, &setorNome, &eclusaCodigo)
-		
-		if err != nil {
-			log.Printf("❌ Erro ao ler linha do banco: %v", err)
-			continue
+	falhasPorWord := make(map[int]map[int]modelos.DefinicaoFalha)
+	for _, falha := range definicoes {
+		if falhasPorWord[falha.WordIndex] == nil {
+			falhasPorWord[falha.WordIndex] = make(map[int]modelos.DefinicaoFalha)
 		}
-		
-		// Configurar informações do setor
-		falha.SetorCodigo = setorCodigo
-		falha.SetorNome = setorNome
-		falha.EclusaCodigo = eclusaCodigo
-		
-		// Adicionar ao mapeamento
-		m.adicionarFalha(falha.WordIndex, falha.BitIndex, falha)
-		contador++
+		falhasPorWord[falha.WordIndex][falha.BitIndex] = falha
 	}
 	
-	log.Printf("✅ Carregadas %d definições de falhas/eventos do banco", contador)
+	m.mutex.Lock()
+	m.falhasPorWord = falhasPorWord
+	m.mutex.Unlock()
+	
+	log.Printf("🔄 Recarregadas %d definições de falhas/eventos do banco", len(definicoes))
 	return nil
 }
 
+// DefinirDefinicoes troca o repositório de definições e recarrega o mapeamento
+// (ex.: repositorio.Memoria nos testes)
+func (m *MapeamentoTags) DefinirDefinicoes(definicoes repositorio.Definicoes) error {
+	m.mutex.Lock()
+	m.definicoes = definicoes
+	m.mutex.Unlock()
+	return m.Recarregar()
+}
+
 // configurarMapeamentoPadrao define o mapeamento padrão das tags
 func (m *MapeamentoTags) configurarMapeamentoPadrao() {
 	// WORD 0 - ENCHIMENTO
@@ -166,6 +187,9 @@ func (m *MapeamentoTags) configurarMapeamentoPadrao() {
 
 // adicionarFalha adiciona uma falha ao mapeamento
 func (m *MapeamentoTags) adicionarFalha(wordIndex, bitIndex int, falha modelos.DefinicaoFalha) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	
 	if m.falhasPorWord[wordIndex] == nil {
 		m.falhasPorWord[wordIndex] = make(map[int]modelos.DefinicaoFalha)
 	}
@@ -180,6 +204,9 @@ func (m *MapeamentoTags) adicionarTag(enderecoWord, indiceBit int, tag modelos.T
 
 // ObterFalha retorna a falha correspondente à word e bit
 func (m *MapeamentoTags) ObterFalha(wordIndex, bitIndex int) (modelos.DefinicaoFalha, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
 	if bits, existe := m.falhasPorWord[wordIndex]; existe {
 		if falha, existe := bits[bitIndex]; existe {
 			return falha, true
@@ -207,6 +234,9 @@ func (m *MapeamentoTags) ObterTag(enderecoWord, indiceBit int) (modelos.TagEclus
 
 // ObterFalhasPorSetor retorna todas as falhas de um setor específico
 func (m *MapeamentoTags) ObterFalhasPorSetor(setorCodigo string) []modelos.DefinicaoFalha {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
 	var falhas []modelos.DefinicaoFalha
 	
 	for _, bits := range m.falhasPorWord {
@@ -243,6 +273,9 @@ func (m *MapeamentoTags) ObterTagsPorSetor(setor modelos.SetorEclusa) []modelos.
 
 // ObterFalhasPorTipo retorna todas as falhas de um tipo específico
 func (m *MapeamentoTags) ObterFalhasPorTipo(tipo string) []modelos.DefinicaoFalha {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
 	var falhas []modelos.DefinicaoFalha
 	
 	for _, bits := range m.falhasPorWord {
@@ -2,28 +2,85 @@ package plc
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/edp/falhas-backend/config"
 	"github.com/edp/falhas-backend/modelos"
+	"github.com/edp/falhas-backend/repositorio"
+	"github.com/edp/falhas-backend/series"
 )
 
 // ProcessadorDados processa WORDs recebidas e detecta mudanças de bits
 type ProcessadorDados struct {
-	wordsAnteriores map[int]uint16 // Armazena estado anterior das WORDs
+	wordsAnteriores map[int]uint16 // Estado anterior das WORDs (um único PLC/eclusa por servidor)
 	mutex          sync.RWMutex
 	mapeamento     *MapeamentoTags // Mapeamento de falhas
 	bancoDados     *sql.DB         // Conexão com banco de dados
+	ocorrencias    repositorio.Ocorrencias // Ocorrências, transições e avalanches (nil = não gravar)
+	analisador     *AnalisadorAlarmes // Avalanche e first-out
+	wordsFrame     []modelos.DadosWord // Frame em processamento (contexto das ocorrências)
+	soeAtivo       bool                // Gravar mudanças de bits em registros_soe
+	sequenciaFrame atomic.Uint64       // Último número sequencial de frame atribuído
+	analogicos     *MapeamentoAnalogico // WORDs com valores de processo
+	gravadorSeries *series.Gravador     // Amostras analógicas (nil se desativado)
+	projetorEstado *ProjetorEstado      // EstadoEclusa de cada eclusa
+	detector       *DetectorEclusagens  // Ciclos de eclusagem (nil se desativado)
 }
 
 // NovoProcessadorDados cria um novo processador de dados
-func NovoProcessadorDados(mapeamento *MapeamentoTags, db *sql.DB) *ProcessadorDados {
-	return &ProcessadorDados{
+func NovoProcessadorDados(cfg *config.Configuracoes, mapeamento *MapeamentoTags, db *sql.DB) *ProcessadorDados {
+	// O banco embutido (SQLite) só tem ocorrências, definições e histórico: analógicos, estado,
+	// séries, eclusagens e SOE ficam desligados
+	dbProcesso := db
+	if cfg.DB_Tipo == config.BancoSQLite {
+		dbProcesso = nil
+	}
+	
+	processador := &ProcessadorDados{
 		wordsAnteriores: make(map[int]uint16),
 		mapeamento:     mapeamento,
-		bancoDados:     db,
+		bancoDados:     dbProcesso,
+		analisador:     NovoAnalisadorAlarmes(cfg.Alarme_AvalancheLimite, cfg.Alarme_AvalancheJanela, cfg.Alarme_FirstOutJanela),
+		soeAtivo:       cfg.SOE_Ativo,
+		analogicos:     NovoMapeamentoAnalogico(dbProcesso),
+		projetorEstado: NovoProjetorEstado(dbProcesso),
+	}
+	
+	if db != nil {
+		repositorios := repositorio.Novo(db, cfg.DB_Tipo)
+		// Na eclusa que envia ao central, cada alteração entra também na fila de sincronização
+		if cfg.Sync_Ativo {
+			repositorios = repositorios.ComFilaSincronizacao()
+		}
+		processador.ocorrencias = repositorios.Ocorrencias
+	}
+	
+	if dbProcesso != nil && cfg.Series_Ativo {
+		processador.gravadorSeries = series.NovoGravador(dbProcesso)
+	}
+	
+	if dbProcesso != nil && cfg.Eclusagem_Ativa {
+		processador.detector = NovoDetectorEclusagens(dbProcesso, cfg.Eclusagem_TempoMaximoFase, cfg.Eclusagem_TempoMaximoCiclo)
+	}
+	
+	if dbProcesso != nil && cfg.SOE_Ativo {
+		processador.carregarSequenciaSOE()
 	}
+	
+	return processador
+}
+
+// DefinirRepositorioOcorrencias troca o repositório onde as ocorrências são gravadas
+// (ex.: repositorio.Memoria nos testes)
+func (p *ProcessadorDados) DefinirRepositorioOcorrencias(ocorrencias repositorio.Ocorrencias) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.ocorrencias = ocorrencias
 }
 
 // ProcessarWords processa uma lista de WORDs e detecta mudanças de bits
@@ -32,8 +89,14 @@ func (p *ProcessadorDados) ProcessarWords(words []modelos.DadosWord) []modelos.M
 	defer p.mutex.Unlock()
 
 	var mudancas []modelos.MudancaBit
+	p.wordsFrame = words
 
 	for _, word := range words {
+		// WORDs analógicas não têm bits de falha/evento
+		if p.analogicos.EhWordAnalogica(word.Endereco) {
+			continue
+		}
+		
 		// Obter valor anterior
 		valorAnterior, existe := p.wordsAnteriores[word.Endereco]
 
@@ -85,14 +148,14 @@ func (p *ProcessadorDados) ProcessarWords(words []modelos.DadosWord) []modelos.M
 							setorNome, word.Endereco, indiceBit, estado, descricaoFalha)
 						
 						// REGISTRAR OCORRÊNCIA NO BANCO DE DADOS
-						if p.mapeamento != nil && p.bancoDados != nil {
+						if p.mapeamento != nil && p.ocorrencias != nil {
 							if falha, existe := p.mapeamento.ObterFalha(word.Endereco, indiceBit); existe {
 								if bitNovo {
 									// Bit = 1: REGISTRAR nova ocorrência ATIVA
-									p.registrarOcorrenciaAtiva(falha.ID)
+									p.registrarOcorrenciaAtiva(falha, word.DataHora, false)
 								} else {
 									// Bit = 0: RESOLVER ocorrência existente
-									p.resolverOcorrenciaAtiva(falha.ID)
+									p.resolverOcorrenciaAtiva(falha, word.DataHora)
 								}
 							}
 						}
@@ -126,13 +189,14 @@ func (p *ProcessadorDados) ProcessarWords(words []modelos.DadosWord) []modelos.M
 						DataHora:     word.DataHora,
 						Setor:        setorNome,
 						Tipo:         codigoFalha,
+						LeituraInicial: true,
 					}
 					mudancas = append(mudancas, mudanca)
 					
 					// REGISTRAR OCORRÊNCIA INICIAL (bit já ativo)
-					if p.mapeamento != nil && p.bancoDados != nil {
+					if p.mapeamento != nil && p.ocorrencias != nil {
 						if falha, existe := p.mapeamento.ObterFalha(word.Endereco, indiceBit); existe {
-							p.registrarOcorrenciaAtiva(falha.ID)
+							p.registrarOcorrenciaAtiva(falha, word.DataHora, true)
 						}
 					}
 				}
@@ -186,63 +250,166 @@ func ObterEstadoWord(word uint16) []bool {
 	return estado
 }
 
-// registrarOcorrenciaAtiva registra uma nova ocorrência ativa no banco de dados
-func (p *ProcessadorDados) registrarOcorrenciaAtiva(definicaoID int) {
+// registrarOcorrenciaAtiva registra uma nova ocorrência ativa no banco de dados.
+// Bits já ativos na primeira leitura não entram na análise de first-out/avalanche.
+func (p *ProcessadorDados) registrarOcorrenciaAtiva(falha modelos.DefinicaoFalha, dataHora time.Time, leituraInicial bool) {
+	definicaoID := falha.ID
+
 	// Verificar se já existe ocorrência ativa para esta definição
-	var ocorrenciaExistente int
-	err := p.bancoDados.QueryRow(`
-		SELECT COUNT(*) FROM ocorrencias_falhas 
-		WHERE definicao_id = $1 AND status = 'ATIVO'`, 
-		definicaoID).Scan(&ocorrenciaExistente)
-	
+	existe, err := p.ocorrencias.ExisteAtiva(definicaoID)
 	if err != nil {
-		log.Printf("❌ Erro ao verificar ocorrência existente para definição %d: %v", definicaoID, err)
+		log.Printf("❌ %v", err)
 		return
 	}
 	
 	// Se já existe ocorrência ativa, não criar nova
-	if ocorrenciaExistente > 0 {
+	if existe {
 		log.Printf("⚠️ Ocorrência já ativa para definição %d", definicaoID)
 		return
 	}
 	
-	// Registrar nova ocorrência
-	_, err = p.bancoDados.Exec(`
-		INSERT INTO ocorrencias_falhas 
-		(definicao_id, status, timestamp_inicio) 
-		VALUES ($1, 'ATIVO', $2)`,
-		definicaoID, time.Now())
+	// Classificar ativação (first-out / avalanche) e procurar causa raiz ativa
+	var classificacao ClassificacaoAlarme
+	if !leituraInicial {
+		classificacao = p.analisador.ClassificarAtivacao(falha.EclusaCodigo, dataHora)
+	}
+	if classificacao.InicioAvalanche {
+		classificacao.AvalancheID = p.abrirAvalanche(falha, dataHora, classificacao.AtivacoesJanela)
+	}
+	suprimidaPor, err := p.ocorrencias.BuscarCausaRaizAtiva(definicaoID)
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	janelaManutencao, err := p.ocorrencias.BuscarJanelaManutencao(falha.EclusaID, falha.SetorID, dataHora)
+	if err != nil {
+		log.Printf("⚠️ %v (definição %d)", err, definicaoID)
+	}
 	
+	// Registrar nova ocorrência
+	ocorrenciaID, err := p.ocorrencias.Abrir(repositorio.NovaOcorrencia{
+		DefinicaoID:        definicaoID,
+		Inicio:             dataHora,
+		FirstOut:           classificacao.FirstOut,
+		GrupoFirstOutID:    classificacao.GrupoFirstOutID,
+		AvalancheID:        classificacao.AvalancheID,
+		SuprimidaPor:       suprimidaPor,
+		JanelaManutencaoID: janelaManutencao,
+		Contexto:           p.montarContexto(falha, dataHora),
+	})
 	if err != nil {
-		log.Printf("❌ Erro ao registrar ocorrência para definição %d: %v", definicaoID, err)
+		log.Printf("❌ %v", err)
 		return
 	}
 	
+	p.registrarTransicao(ocorrenciaID, "", "ATIVO", dataHora)
+	
+	if classificacao.FirstOut {
+		p.analisador.ConfirmarFirstOut(falha.EclusaCodigo, ocorrenciaID)
+		log.Printf("🥇 FIRST-OUT: %s (ocorrência %d)", falha.Codigo, ocorrenciaID)
+	}
+	if classificacao.AvalancheID != 0 {
+		if err := p.ocorrencias.ContarAlarmeAvalanche(classificacao.AvalancheID); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+	if suprimidaPor != 0 {
+		log.Printf("🔕 Ocorrência %d suprimida pela causa raiz %d", ocorrenciaID, suprimidaPor)
+	}
+	if janelaManutencao != 0 {
+		log.Printf("🛠️ Ocorrência %d aberta na janela de manutenção %d", ocorrenciaID, janelaManutencao)
+	}
+	
 	log.Printf("🔴 NOVA OCORRÊNCIA REGISTRADA: Definição ID %d", definicaoID)
 }
 
 // resolverOcorrenciaAtiva resolve uma ocorrência ativa no banco de dados
-func (p *ProcessadorDados) resolverOcorrenciaAtiva(definicaoID int) {
+func (p *ProcessadorDados) resolverOcorrenciaAtiva(falha modelos.DefinicaoFalha, dataHora time.Time) {
+	definicaoID := falha.ID
+
 	// Atualizar ocorrências ativas para resolvidas
-	result, err := p.bancoDados.Exec(`
-		UPDATE ocorrencias_falhas 
-		SET status = 'RESOLVIDO', timestamp_fim = $1 
-		WHERE definicao_id = $2 AND status = 'ATIVO'`,
-		time.Now(), definicaoID)
-	
+	resolvidas, err := p.ocorrencias.ResolverAtivas(definicaoID, dataHora)
 	if err != nil {
-		log.Printf("❌ Erro ao resolver ocorrência para definição %d: %v", definicaoID, err)
-		return
+		log.Printf("❌ %v", err)
+	}
+	
+	for _, ocorrenciaID := range resolvidas {
+		p.registrarTransicao(ocorrenciaID, "ATIVO", "RESOLVIDO", dataHora)
 	}
 	
-	// Verificar se alguma linha foi afetada
-	rowsAffected, err := result.RowsAffected()
+	if len(resolvidas) > 0 {
+		log.Printf("🟢 OCORRÊNCIA RESOLVIDA: Definição ID %d (%d registros atualizados)", definicaoID, len(resolvidas))
+	}
+}
+
+// verificarFimAvalanches encerra as avalanches das eclusas cuja taxa de alarmes voltou ao normal.
+// Corre a cada frame: com os alarmes simplesmente ativos, nenhum bit volta a mudar.
+func (p *ProcessadorDados) verificarFimAvalanches(dataHora time.Time) {
+	for _, eclusaCodigo := range p.analisador.EclusasEmAvalanche() {
+		if avalancheID := p.analisador.VerificarFimAvalanche(eclusaCodigo, dataHora); avalancheID != 0 {
+			p.fecharAvalanche(avalancheID, dataHora)
+		}
+	}
+}
+
+// abrirAvalanche registra o início de uma avalanche de alarmes na eclusa
+func (p *ProcessadorDados) abrirAvalanche(falha modelos.DefinicaoFalha, dataHora time.Time, totalAlarmes int) int64 {
+	avalancheID, err := p.ocorrencias.AbrirAvalanche(falha.EclusaID, dataHora.Add(-p.analisador.janelaAvalanche), dataHora, totalAlarmes)
 	if err != nil {
-		log.Printf("❌ Erro ao verificar linhas afetadas: %v", err)
-		return
+		log.Printf("❌ %v (eclusa %s)", err, falha.EclusaCodigo)
+		if avalancheID == 0 {
+			return 0
+		}
 	}
 	
-	if rowsAffected > 0 {
-		log.Printf("🟢 OCORRÊNCIA RESOLVIDA: Definição ID %d (%d registros atualizados)", definicaoID, rowsAffected)
+	p.analisador.ConfirmarAvalanche(falha.EclusaCodigo, avalancheID)
+	log.Printf("🌊 AVALANCHE DE ALARMES na eclusa %s: %d alarmes em %s", 
+		falha.EclusaCodigo, totalAlarmes, p.analisador.janelaAvalanche)
+	return avalancheID
+}
+
+// fecharAvalanche registra o fim de uma avalanche de alarmes
+func (p *ProcessadorDados) fecharAvalanche(avalancheID int64, dataHora time.Time) {
+	if err := p.ocorrencias.FecharAvalanche(avalancheID, dataHora); err != nil {
+		log.Printf("❌ %v", err)
+		return
+	}
+	log.Printf("🌤️ Avalanche %d encerrada", avalancheID)
+}
+
+// registrarTransicao grava uma mudança de estado de ocorrência feita pelo PLC
+func (p *ProcessadorDados) registrarTransicao(ocorrenciaID int64, statusAnterior, statusNovo string, dataHora time.Time) {
+	err := p.ocorrencias.RegistrarTransicao(repositorio.Transicao{
+		OcorrenciaID:   ocorrenciaID,
+		StatusAnterior: statusAnterior,
+		StatusNovo:     statusNovo,
+		Timestamp:      dataHora,
+		Origem:         "PLC",
+	})
+	if err != nil {
+		log.Printf("⚠️ %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// montarContexto serializa as WORDs do frame atual para a coluna dados_contexto
+func (p *ProcessadorDados) montarContexto(falha modelos.DefinicaoFalha, dataHora time.Time) string {
+	contexto := modelos.ContextoOcorrencia{
+		DataHoraFrame: dataHora,
+		WordIndex:     falha.WordIndex,
+		BitIndex:      falha.BitIndex,
+		Words:         make([]modelos.ValorWordContexto, 0, len(p.wordsFrame)),
+	}
+	for _, word := range p.wordsFrame {
+		contexto.Words = append(contexto.Words, modelos.ValorWordContexto{
+			Endereco: word.Endereco,
+			Valor:    word.Valor,
+			Hex:      fmt.Sprintf("0x%04X", word.Valor),
+		})
+	}
+
+	dados, err := json.Marshal(contexto)
+	if err != nil {
+		log.Printf("⚠️ Erro ao serializar contexto da definição %d: %v", falha.ID, err)
+		return ""
+	}
+	return string(dados)
+}
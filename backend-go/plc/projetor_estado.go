@@ -0,0 +1,243 @@
+package plc
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+)
+
+// Campos do EstadoEclusa que podem ser mapeados (coluna campo de mapeamentos_estado_eclusa)
+const (
+	CampoEnchimentoAtivo     = "enchimento_ativo"
+	CampoEsvaziamentoAtivo   = "esvaziamento_ativo"
+	CampoPortaJusanteAberta  = "porta_jusante_aberta"
+	CampoPortaMontanteAberta = "porta_montante_aberta"
+	CampoNivelAgua           = "nivel_agua"
+)
+
+// capacidadeAssinatura é o número de estados pendentes por assinante antes de descartar
+const capacidadeAssinatura = 16
+
+// SinalEstado liga um campo do estado a um bit do frame ou a uma tag analógica
+type SinalEstado struct {
+	EclusaCodigo string
+	Campo        string
+	WordIndex    int
+	BitIndex     int
+	TagAnalogica string // Apenas para nivel_agua
+	Invertido    bool   // O campo é verdadeiro com o bit a 0
+}
+
+// assinatura recebe os estados publicados de uma eclusa ("" = todas)
+type assinatura struct {
+	eclusa string
+	canal  chan modelos.EstadoEclusa
+}
+
+// ProjetorEstado mantém o EstadoEclusa de cada eclusa a partir dos frames e publica as mudanças
+type ProjetorEstado struct {
+	bancoDados  *sql.DB
+	mutex       sync.RWMutex
+	sinais      map[string][]SinalEstado // [eclusa] sinais configurados
+	estados     map[string]modelos.EstadoEclusa
+	assinantes  map[*assinatura]bool
+	carregadoEm time.Time
+}
+
+// NovoProjetorEstado carrega o mapeamento de estado (sem banco fica vazio)
+func NovoProjetorEstado(db *sql.DB) *ProjetorEstado {
+	projetor := &ProjetorEstado{
+		bancoDados: db,
+		sinais:     make(map[string][]SinalEstado),
+		estados:    make(map[string]modelos.EstadoEclusa),
+		assinantes: make(map[*assinatura]bool),
+	}
+
+	if db != nil {
+		if err := projetor.Recarregar(); err != nil {
+			log.Printf("⚠️ Erro ao carregar mapeamento de estado: %v", err)
+		}
+	}
+
+	return projetor
+}
+
+// Recarregar relê os sinais de estado ativos do banco de dados
+func (p *ProjetorEstado) Recarregar() error {
+	if p.bancoDados == nil {
+		return fmt.Errorf("sem conexão com o banco de dados")
+	}
+
+	rows, err := p.bancoDados.Query(`
+		SELECT e.codigo, m.campo, COALESCE(df.word_index, 0), COALESCE(df.bit_index, 0),
+			COALESCE(t.tag, ''), m.invertido
+		FROM mapeamentos_estado_eclusa m
+		JOIN eclusas e ON m.eclusa_id = e.id
+		LEFT JOIN definicoes_falhas df ON m.definicao_id = df.id
+		LEFT JOIN tags_analogicas t ON m.tag_analogica_id = t.id
+		WHERE m.ativo = true
+		AND (df.word_index IS NOT NULL OR t.id IS NOT NULL)
+		ORDER BY e.codigo, m.campo`)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar mapeamento de estado: %v", err)
+	}
+	defer rows.Close()
+
+	sinais := make(map[string][]SinalEstado)
+	for rows.Next() {
+		var sinal SinalEstado
+		if err := rows.Scan(&sinal.EclusaCodigo, &sinal.Campo, &sinal.WordIndex, &sinal.BitIndex,
+			&sinal.TagAnalogica, &sinal.Invertido); err != nil {
+			return fmt.Errorf("erro ao ler sinal de estado: %v", err)
+		}
+		sinais[sinal.EclusaCodigo] = append(sinais[sinal.EclusaCodigo], sinal)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	p.sinais = sinais
+	p.carregadoEm = time.Now()
+	for eclusa := range sinais {
+		if _, existe := p.estados[eclusa]; !existe {
+			p.estados[eclusa] = modelos.EstadoEclusa{EclusaCodigo: eclusa}
+		}
+	}
+	p.mutex.Unlock()
+
+	return nil
+}
+
+// Atualizar recalcula o estado das eclusas com os bits do frame e os últimos valores analógicos.
+// Campos cujos bits não vieram no frame mantêm o valor anterior. Só as mudanças são publicadas
+// (e devolvidas); o nível de água só conta como mudança quando sai da banda morta da tag.
+func (p *ProjetorEstado) Atualizar(words []modelos.DadosWord, analogicos *MapeamentoAnalogico, dataHora time.Time) []modelos.EstadoEclusa {
+	p.mutex.RLock()
+	recarregar := p.bancoDados != nil && time.Since(p.carregadoEm) > intervaloRecargaAnalogicos
+	p.mutex.RUnlock()
+	if recarregar {
+		if err := p.Recarregar(); err != nil {
+			log.Printf("⚠️ Erro ao recarregar mapeamento de estado: %v", err)
+			p.mutex.Lock()
+			p.carregadoEm = time.Now()
+			p.mutex.Unlock()
+		}
+	}
+
+	valores := make(map[int]uint16, len(words))
+	for _, word := range words {
+		valores[word.Endereco] = word.Valor
+	}
+
+	p.mutex.Lock()
+	var publicar []modelos.EstadoEclusa
+	for eclusa, sinais := range p.sinais {
+		anterior := p.estados[eclusa]
+		novo := anterior
+		novo.EclusaCodigo = eclusa
+
+		bits := make(map[string]bool)
+		lidos := make(map[string]bool)
+		bandaMorta := 0.0
+		for _, sinal := range sinais {
+			if sinal.Campo == CampoNivelAgua {
+				if analogicos == nil {
+					continue
+				}
+				if valor, existe := analogicos.UltimoValor(sinal.TagAnalogica); existe {
+					novo.NivelAgua = valor.Valor
+					bandaMorta = valor.Tag.BandaMorta
+				}
+				continue
+			}
+
+			word, existe := valores[sinal.WordIndex]
+			if !existe {
+				continue
+			}
+			lidos[sinal.Campo] = true
+			if ObterBit(word, sinal.BitIndex) != sinal.Invertido {
+				bits[sinal.Campo] = true
+			}
+		}
+
+		if lidos[CampoEnchimentoAtivo] {
+			novo.EnchimentoAtivo = bits[CampoEnchimentoAtivo]
+		}
+		if lidos[CampoEsvaziamentoAtivo] {
+			novo.EsvaziamentoAtivo = bits[CampoEsvaziamentoAtivo]
+		}
+		if lidos[CampoPortaJusanteAberta] {
+			novo.PortaJusanteAberta = bits[CampoPortaJusanteAberta]
+		}
+		if lidos[CampoPortaMontanteAberta] {
+			novo.PortaMontanteAberta = bits[CampoPortaMontanteAberta]
+		}
+
+		mudou := anterior.UltimaAtualizacao.IsZero() ||
+			novo.EnchimentoAtivo != anterior.EnchimentoAtivo ||
+			novo.EsvaziamentoAtivo != anterior.EsvaziamentoAtivo ||
+			novo.PortaJusanteAberta != anterior.PortaJusanteAberta ||
+			novo.PortaMontanteAberta != anterior.PortaMontanteAberta ||
+			math.Abs(novo.NivelAgua-anterior.NivelAgua) > bandaMorta
+		if !mudou {
+			continue
+		}
+
+		novo.UltimaAtualizacao = dataHora
+		p.estados[eclusa] = novo
+		publicar = append(publicar, novo)
+	}
+
+	for _, estado := range publicar {
+		for a := range p.assinantes {
+			if a.eclusa != "" && a.eclusa != estado.EclusaCodigo {
+				continue
+			}
+			select {
+			case a.canal <- estado:
+			default:
+				// Assinante lento: descarta, o próximo estado completo substitui este
+			}
+		}
+	}
+	p.mutex.Unlock()
+
+	return publicar
+}
+
+// Estado devolve o estado atual da eclusa; falso se a eclusa não tem mapeamento de estado
+func (p *ProjetorEstado) Estado(codigo string) (modelos.EstadoEclusa, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if _, mapeada := p.sinais[codigo]; !mapeada {
+		return modelos.EstadoEclusa{}, false
+	}
+	return p.estados[codigo], true
+}
+
+// Assinar devolve um canal com os estados publicados da eclusa ("" = todas) e a função
+// que termina a assinatura
+func (p *ProjetorEstado) Assinar(codigo string) (<-chan modelos.EstadoEclusa, func()) {
+	a := &assinatura{eclusa: codigo, canal: make(chan modelos.EstadoEclusa, capacidadeAssinatura)}
+
+	p.mutex.Lock()
+	p.assinantes[a] = true
+	p.mutex.Unlock()
+
+	var umaVez sync.Once
+	return a.canal, func() {
+		umaVez.Do(func() {
+			p.mutex.Lock()
+			delete(p.assinantes, a)
+			p.mutex.Unlock()
+		})
+	}
+}
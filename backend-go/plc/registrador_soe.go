@@ -0,0 +1,232 @@
+package plc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+)
+
+// Formatos de cabeçalho de timestamp aceitos no início do frame PLC
+const (
+	FormatoTimestampNenhum = ""
+	FormatoTimestampUnixMs = "unix_ms" // int64 big-endian com milissegundos desde 1970 (UTC)
+	FormatoTimestampS7DT   = "s7_dt"   // DATE_AND_TIME do S7 (8 bytes BCD, hora local do PLC)
+)
+
+// tamanhoCabecalhoTimestamp é o tamanho em bytes dos dois formatos de cabeçalho
+const tamanhoCabecalhoTimestamp = 8
+
+// colunasSOE é o número de parâmetros por linha inserida em registros_soe
+const colunasSOE = 12
+
+// DecodificarTimestampFrame separa o cabeçalho de timestamp (se configurado) das WORDs do frame
+func DecodificarTimestampFrame(formato string, dados []byte) (*time.Time, []byte, error) {
+	switch formato {
+	case FormatoTimestampNenhum:
+		return nil, dados, nil
+	case FormatoTimestampUnixMs, FormatoTimestampS7DT:
+	default:
+		return nil, dados, fmt.Errorf("formato de timestamp desconhecido: %s", formato)
+	}
+
+	if len(dados) < tamanhoCabecalhoTimestamp {
+		return nil, dados, fmt.Errorf("frame com %d bytes não contém cabeçalho de timestamp", len(dados))
+	}
+
+	cabecalho, words := dados[:tamanhoCabecalhoTimestamp], dados[tamanhoCabecalhoTimestamp:]
+
+	if formato == FormatoTimestampUnixMs {
+		dataHora := time.UnixMilli(int64(binary.BigEndian.Uint64(cabecalho)))
+		return &dataHora, words, nil
+	}
+
+	dataHora, err := decodificarS7DateAndTime(cabecalho)
+	if err != nil {
+		return nil, words, err
+	}
+	return &dataHora, words, nil
+}
+
+// decodificarS7DateAndTime converte o tipo DATE_AND_TIME do S7 (BCD) em time.Time
+func decodificarS7DateAndTime(b []byte) (time.Time, error) {
+	bcd := func(v byte) (int, error) {
+		alto, baixo := int(v>>4), int(v&0x0F)
+		if alto > 9 || baixo > 9 {
+			return 0, fmt.Errorf("valor BCD inválido 0x%02X", v)
+		}
+		return alto*10 + baixo, nil
+	}
+
+	var campos [6]int
+	for i := range campos {
+		valor, err := bcd(b[i])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("DATE_AND_TIME inválido: %v", err)
+		}
+		campos[i] = valor
+	}
+
+	ano := campos[0] + 2000
+	if campos[0] >= 90 {
+		ano = campos[0] + 1900
+	}
+
+	centenasDezenasMs, err := bcd(b[6])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("DATE_AND_TIME inválido: %v", err)
+	}
+	unidadesMs := int(b[7] >> 4)
+	if unidadesMs > 9 {
+		return time.Time{}, fmt.Errorf("DATE_AND_TIME inválido: milissegundos 0x%02X", b[7])
+	}
+	milissegundos := centenasDezenasMs*10 + unidadesMs
+
+	return time.Date(ano, time.Month(campos[1]), campos[2], campos[3], campos[4], campos[5],
+		milissegundos*int(time.Millisecond), time.Local), nil
+}
+
+// ProcessarMensagem processa um frame completo do PLC e grava as mudanças no SOE
+func (p *ProcessadorDados) ProcessarMensagem(mensagem modelos.MensagemPLC) []modelos.MudancaBit {
+	if mensagem.Sequencia == 0 {
+		mensagem.Sequencia = p.sequenciaFrame.Add(1)
+	}
+
+	mudancas := p.ProcessarWords(mensagem.Words)
+	if p.ocorrencias != nil {
+		p.verificarFimAvalanches(mensagem.DataHora)
+	}
+
+	for i := range mudancas {
+		mudancas[i].SequenciaFrame = mensagem.Sequencia
+		mudancas[i].DataHoraPLC = mensagem.DataHoraPLC
+	}
+
+	if p.soeAtivo && p.bancoDados != nil && len(mudancas) > 0 {
+		p.gravarSOE(mensagem, mudancas)
+	}
+
+	// Valores analógicos com o timestamp do PLC quando o frame o traz
+	dataHora := mensagem.DataHora
+	if mensagem.DataHoraPLC != nil {
+		dataHora = *mensagem.DataHoraPLC
+	}
+	for _, amostra := range p.analogicos.Extrair(mensagem.Words, dataHora) {
+		if p.gravadorSeries != nil {
+			p.gravadorSeries.Registrar(amostra)
+		}
+	}
+	estados := p.projetorEstado.Atualizar(mensagem.Words, p.analogicos, dataHora)
+	if p.detector != nil {
+		for _, estado := range estados {
+			p.detector.Processar(estado)
+		}
+		p.detector.VerificarTempos(dataHora)
+	}
+
+	return mudancas
+}
+
+// Analogicos devolve o mapeamento das tags analógicas (últimos valores lidos)
+func (p *ProcessadorDados) Analogicos() *MapeamentoAnalogico {
+	return p.analogicos
+}
+
+// ProjetorEstado devolve o estado de processo das eclusas (enchimento, portas, nível)
+func (p *ProcessadorDados) ProjetorEstado() *ProjetorEstado {
+	return p.projetorEstado
+}
+
+// Encerrar grava as amostras de séries pendentes
+func (p *ProcessadorDados) Encerrar() {
+	if p.gravadorSeries != nil {
+		p.gravadorSeries.Parar()
+	}
+}
+
+// carregarSequenciaSOE continua a numeração de frames a partir do último frame gravado
+func (p *ProcessadorDados) carregarSequenciaSOE() {
+	var ultima int64
+	err := p.bancoDados.QueryRow("SELECT COALESCE(MAX(sequencia_frame), 0) FROM registros_soe").Scan(&ultima)
+	if err != nil {
+		log.Printf("⚠️ Erro ao carregar sequência do SOE: %v", err)
+		return
+	}
+	p.sequenciaFrame.Store(uint64(ultima))
+}
+
+// gravarSOE insere todas as mudanças do frame numa única instrução (tabela append-only)
+func (p *ProcessadorDados) gravarSOE(mensagem modelos.MensagemPLC, mudancas []modelos.MudancaBit) {
+	var valores []string
+	args := make([]interface{}, 0, len(mudancas)*colunasSOE)
+
+	for ordem, mudanca := range mudancas {
+		var eclusaID, definicaoID interface{}
+		if p.mapeamento != nil {
+			if falha, existe := p.mapeamento.ObterFalha(mudanca.EnderecoWord, mudanca.IndiceBit); existe {
+				eclusaID = falha.EclusaID
+				definicaoID = falha.ID
+			}
+		}
+
+		var dataHoraPLC interface{}
+		if mudanca.DataHoraPLC != nil {
+			dataHoraPLC = *mudanca.DataHoraPLC
+		}
+
+		base := len(args)
+		marcadores := make([]string, colunasSOE)
+		for i := range marcadores {
+			marcadores[i] = fmt.Sprintf("$%d", base+i+1)
+		}
+		valores = append(valores, "("+strings.Join(marcadores, ", ")+")")
+
+		args = append(args,
+			mudanca.SequenciaFrame, ordem, mensagem.IdPLC, eclusaID, definicaoID,
+			mudanca.EnderecoWord, mudanca.IndiceBit, mudanca.ValorAntigo, mudanca.ValorNovo,
+			mudanca.LeituraInicial, dataHoraPLC, mensagem.DataHora)
+	}
+
+	_, err := p.bancoDados.Exec(`
+		INSERT INTO registros_soe
+		(sequencia_frame, ordem_no_frame, origem, eclusa_id, definicao_id,
+		 word_index, bit_index, valor_antigo, valor_novo,
+		 leitura_inicial, timestamp_plc, timestamp_recebimento)
+		VALUES `+strings.Join(valores, ", "), args...)
+	if err != nil {
+		log.Printf("❌ Erro ao gravar SOE do frame %d: %v", mensagem.Sequencia, err)
+	}
+}
+
+// CodificarTimestampFrame monta o cabeçalho de timestamp no formato configurado (usado pelo simulador)
+func CodificarTimestampFrame(formato string, dataHora time.Time) ([]byte, error) {
+	switch formato {
+	case FormatoTimestampNenhum:
+		return nil, nil
+	case FormatoTimestampUnixMs:
+		cabecalho := make([]byte, tamanhoCabecalhoTimestamp)
+		binary.BigEndian.PutUint64(cabecalho, uint64(dataHora.UnixMilli()))
+		return cabecalho, nil
+	case FormatoTimestampS7DT:
+		return codificarS7DateAndTime(dataHora.In(time.Local)), nil
+	default:
+		return nil, fmt.Errorf("formato de timestamp desconhecido: %s", formato)
+	}
+}
+
+// codificarS7DateAndTime converte time.Time no tipo DATE_AND_TIME do S7 (BCD)
+func codificarS7DateAndTime(t time.Time) []byte {
+	bcd := func(v int) byte { return byte((v/10)<<4 | v%10) }
+
+	milissegundos := t.Nanosecond() / int(time.Millisecond)
+	diaSemana := int(t.Weekday()) + 1 // S7: 1 = domingo
+
+	return []byte{
+		bcd(t.Year() % 100), bcd(int(t.Month())), bcd(t.Day()),
+		bcd(t.Hour()), bcd(t.Minute()), bcd(t.Second()),
+		bcd(milissegundos / 10), byte((milissegundos%10)<<4 | diaSemana),
+	}
+}
@@ -0,0 +1,122 @@
+package plc
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+)
+
+// OpcoesReproducao controla como uma captura é reinjetada no ProcessadorDados
+type OpcoesReproducao struct {
+	FormatoTimestamp string          // Mesmo formato de cabeçalho usado quando a captura foi gravada
+	Velocidade       float64         // 1 = tempo real, 10 = dez vezes mais rápido, 0 = sem espera
+	Parada           <-chan struct{} // Fechado para interromper a reprodução
+}
+
+// ResultadoReproducao resume uma reprodução
+type ResultadoReproducao struct {
+	Frames          int           `json:"frames"`
+	FramesInvalidos int           `json:"frames_invalidos"`
+	Mudancas        int           `json:"mudancas"`
+	Ativacoes       int           `json:"ativacoes"`
+	Desativacoes    int           `json:"desativacoes"`
+	InicioCaptura   time.Time     `json:"inicio_captura"`
+	FimCaptura      time.Time     `json:"fim_captura"`
+	Duracao         time.Duration `json:"duracao"`
+	Interrompida    bool          `json:"interrompida"`
+}
+
+// ReproduzirCaptura envia cada frame da captura ao processador, respeitando os intervalos originais
+// divididos pela velocidade. Os timestamps da captura são mantidos, por isso o resultado é determinístico.
+func ReproduzirCaptura(processador *ProcessadorDados, leitor *LeitorCaptura, opcoes OpcoesReproducao,
+	aoProcessar func(modelos.MensagemPLC, []modelos.MudancaBit)) (resultado ResultadoReproducao, err error) {
+
+	inicioReal := time.Now()
+	defer func() { resultado.Duracao = time.Since(inicioReal) }()
+
+	for {
+		frame, err := leitor.Proximo()
+		if err == io.EOF {
+			return resultado, nil
+		}
+		if err != nil {
+			return resultado, err
+		}
+
+		if resultado.Frames == 0 && resultado.FramesInvalidos == 0 {
+			resultado.InicioCaptura = frame.DataHora
+		}
+		resultado.FimCaptura = frame.DataHora
+
+		if !aguardarFrame(opcoes, inicioReal, frame.DataHora.Sub(resultado.InicioCaptura)) {
+			resultado.Interrompida = true
+			return resultado, nil
+		}
+
+		dados, err := frame.Bytes()
+		if err != nil {
+			resultado.FramesInvalidos++
+			log.Printf("⚠️  Frame de %s em %s com dados inválidos: %v", frame.Origem, frame.DataHora.Format(time.RFC3339Nano), err)
+			continue
+		}
+
+		mensagem, err := MontarMensagemPLC(opcoes.FormatoTimestamp, dados, frame.Origem, frame.DataHora)
+		if err != nil {
+			log.Printf("⚠️  Timestamp do frame de %s ignorado: %v", frame.Origem, err)
+		}
+
+		mudancas := processador.ProcessarMensagem(mensagem)
+
+		resultado.Frames++
+		resultado.Mudancas += len(mudancas)
+		for _, mudanca := range mudancas {
+			if mudanca.ValorNovo {
+				resultado.Ativacoes++
+			} else {
+				resultado.Desativacoes++
+			}
+		}
+
+		if aoProcessar != nil {
+			aoProcessar(mensagem, mudancas)
+		}
+	}
+}
+
+// aguardarFrame espera até o instante relativo do frame; devolve false se a reprodução foi interrompida
+func aguardarFrame(opcoes OpcoesReproducao, inicioReal time.Time, deslocamento time.Duration) bool {
+	espera := time.Duration(0)
+	if opcoes.Velocidade > 0 {
+		alvo := inicioReal.Add(time.Duration(float64(deslocamento) / opcoes.Velocidade))
+		espera = time.Until(alvo)
+	}
+
+	if espera <= 0 {
+		select {
+		case <-opcoes.Parada:
+			return false
+		default:
+			return true
+		}
+	}
+
+	temporizador := time.NewTimer(espera)
+	defer temporizador.Stop()
+
+	select {
+	case <-opcoes.Parada:
+		return false
+	case <-temporizador.C:
+		return true
+	}
+}
+
+// String resume o resultado para o log
+func (r ResultadoReproducao) String() string {
+	return fmt.Sprintf("%d frames (%d inválidos), %d mudanças (%d ativações, %d desativações), captura de %s em %s",
+		r.Frames, r.FramesInvalidos, r.Mudancas, r.Ativacoes, r.Desativacoes,
+		r.FimCaptura.Sub(r.InicioCaptura).Round(time.Millisecond), r.Duracao.Round(time.Millisecond))
+}
@@ -2,7 +2,6 @@ package plc
 
 import (
 	"database/sql"
-	"encoding/binary"
 	"fmt"
 	"io"
 	"log"
@@ -11,7 +10,7 @@ import (
 	"time"
 
 	"github.com/edp/falhas-backend/config"
-	"github.com/edp/falhas-backend/modelos"
+	"github.com/edp/falhas-backend/repositorio"
 )
 
 // ServidorTCP gerencia o servidor TCP para comunicação com PLC
@@ -23,19 +22,39 @@ type ServidorTCP struct {
 	canalParada     chan struct{}
 	grupoWait       sync.WaitGroup
 	processadorDados *ProcessadorDados
+	mapeamento       *MapeamentoTags
+	gravadorCapturas *GravadorCapturas // Captura bruta dos frames (opcional)
 }
 
 // NovoServidorTCP cria uma nova instância do servidor TCP
 func NovoServidorTCP(cfg *config.Configuracoes, db *sql.DB) *ServidorTCP {
-	// Criar mapeamento com banco de dados
-	mapeamento := NovoMapeamentoTags(db)
+	// Criar mapeamento com banco de dados (PostgreSQL ou SQLite, conforme DB_TIPO)
+	var definicoes repositorio.Definicoes
+	if db != nil {
+		definicoes = repositorio.Novo(db, cfg.DB_Tipo).Definicoes
+	}
+	mapeamento := NovoMapeamentoDefinicoes(definicoes)
 	
-	return &ServidorTCP{
+	servidor := &ServidorTCP{
 		configuracoes:      cfg,
 		clientesConectados: make(map[string]net.Conn),
 		canalParada:        make(chan struct{}),
-		processadorDados:   NovoProcessadorDados(mapeamento, db),
+		processadorDados:   NovoProcessadorDados(cfg, mapeamento, db),
+		mapeamento:         mapeamento,
+	}
+
+	// Captura bruta dos frames para replay
+	if cfg.Captura_Ativa {
+		gravador, err := NovoGravadorCapturas(cfg.Captura_Diretorio, cfg.Captura_TamanhoMaximoMB*1024*1024, cfg.Captura_ArquivosMaximos)
+		if err != nil {
+			log.Printf("⚠️  Captura de frames desativada: %v", err)
+		} else {
+			servidor.gravadorCapturas = gravador
+			log.Printf("🎞️  Captura de frames ativa em %s", cfg.Captura_Diretorio)
+		}
 	}
+
+	return servidor
 }
 
 // Iniciar inicia o servidor TCP
@@ -89,6 +108,30 @@ func (s *ServidorTCP) Parar() {
 	s.mutex.Unlock()
 
 	s.grupoWait.Wait()
+	s.processadorDados.Encerrar()
+
+	if s.gravadorCapturas != nil {
+		if err := s.gravadorCapturas.Fechar(); err != nil {
+			log.Printf("⚠️  Erro ao fechar captura de frames: %v", err)
+		}
+	}
+}
+
+// ProjetorEstado devolve o estado de processo das eclusas calculado a partir dos frames
+func (s *ServidorTCP) ProjetorEstado() *ProjetorEstado {
+	return s.processadorDados.ProjetorEstado()
+}
+
+// Mapeamento devolve o mapeamento word/bit → definição usado no processamento dos frames
+func (s *ServidorTCP) Mapeamento() *MapeamentoTags {
+	return s.mapeamento
+}
+
+// DefinirRepositorios troca as definições e as ocorrências usadas no processamento dos frames
+// (ex.: repositorio.Memoria nos testes)
+func (s *ServidorTCP) DefinirRepositorios(repositorios repositorio.Repositorios) error {
+	s.processadorDados.DefinirRepositorioOcorrencias(repositorios.Ocorrencias)
+	return s.mapeamento.DefinirDefinicoes(repositorios.Definicoes)
 }
 
 // gerenciarConexao gerencia uma conexão individual do PLC
@@ -147,33 +190,27 @@ func (s *ServidorTCP) processarDadosPLC(dados []byte, enderecoCliente string) {
 
 	log.Printf("📥 Recebidos %d bytes de %s", len(dados), enderecoCliente)
 
-	// Cada WORD tem 2 bytes (16 bits)
-	numeroWords := len(dados) / 2
-
-	var words []modelos.DadosWord
-
-	for i := 0; i < numeroWords; i++ {
-		offset := i * 2
-		if offset+1 < len(dados) {
-			// Ler WORD (16 bits) em Big Endian
-			valorWord := binary.BigEndian.Uint16(dados[offset : offset+2])
-
-			word := modelos.DadosWord{
-				Endereco: i,
-				Valor:    valorWord,
-				DataHora: timestamp,
-			}
+	// Gravar o frame bruto antes de qualquer decodificação
+	if s.gravadorCapturas != nil {
+		if err := s.gravadorCapturas.Gravar(timestamp, enderecoCliente, dados); err != nil {
+			log.Printf("⚠️  Erro ao capturar frame de %s: %v", enderecoCliente, err)
+		}
+	}
 
-			words = append(words, word)
+	// Separar o cabeçalho de timestamp e ler as WORDs (16 bits, Big Endian)
+	mensagem, err := MontarMensagemPLC(s.configuracoes.PLC_TimestampFrame, dados, enderecoCliente, timestamp)
+	if err != nil {
+		log.Printf("⚠️  Timestamp do frame de %s ignorado: %v", enderecoCliente, err)
+	}
 
-			// Log detalhado
-			log.Printf("  WORD[%02d] = 0x%04X (%016b) | Decimal: %d",
-				i, valorWord, valorWord, valorWord)
-		}
+	for _, word := range mensagem.Words {
+		// Log detalhado
+		log.Printf("  WORD[%02d] = 0x%04X (%016b) | Decimal: %d",
+			word.Endereco, word.Valor, word.Valor, word.Valor)
 	}
 
-	// Processar WORDs e detectar bits ativos
-	mudancas := s.processadorDados.ProcessarWords(words)
+	// Processar WORDs, detectar bits ativos e gravar o SOE
+	mudancas := s.processadorDados.ProcessarMensagem(mensagem)
 
 	if len(mudancas) > 0 {
 		log.Printf("🔔 Detectadas %d mudanças de bits:", len(mudancas))
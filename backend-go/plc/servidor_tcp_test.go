@@ -0,0 +1,154 @@
+package plc_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/modelos"
+	"github.com/edp/falhas-backend/plc"
+	"github.com/edp/falhas-backend/repositorio"
+	"github.com/edp/falhas-backend/simulador"
+)
+
+// roteiroTeste liga duas falhas do enchimento e resolve a primeira
+const roteiroTeste = `
+nome: falha de comunicação e emergência
+passos:
+  - apos: 100ms
+    ativar: [RG_ENCHIMENTO_006]
+  - apos: 100ms
+    ativar: ["9"]
+  - apos: 100ms
+    desativar: [RG_ENCHIMENTO_006]
+`
+
+func TestServidorTCPGravaOcorrenciasDoSimulador(t *testing.T) {
+	memoria := repositorio.NovaMemoria()
+	eclusaID := memoria.AdicionarEclusa(repositorio.Eclusa{Codigo: "RG", Nome: "Régua", Ativa: true})
+	setorID := memoria.AdicionarSetor(repositorio.Setor{Codigo: "ENCHIMENTO", Nome: "Enchimento"})
+	for _, ponto := range []int{6, 9} {
+		memoria.AdicionarDefinicao(modelos.DefinicaoFalha{
+			EclusaID: eclusaID, SetorID: setorID, Codigo: codigoPonto(ponto), Tipo: "FALHA",
+			Descricao: "falha de teste", Prioridade: "ALTA", PointIndex: ponto,
+			WordIndex: (ponto - 1) / 16, BitIndex: (ponto - 1) % 16, Ativa: true,
+		})
+	}
+
+	endereco := enderecoLivre(t)
+	host, porta, _ := net.SplitHostPort(endereco)
+	cfg := &config.Configuracoes{
+		ServidorTCP_Host:       host,
+		ServidorTCP_Porta:      porta,
+		PLC_Timeout:            200 * time.Millisecond,
+		DB_Tipo:                config.BancoPostgres,
+		Alarme_AvalancheLimite: 100,
+		Alarme_AvalancheJanela: time.Minute,
+		Alarme_FirstOutJanela:  2 * time.Second,
+	}
+
+	servidor := plc.NovoServidorTCP(cfg, nil)
+	if err := servidor.DefinirRepositorios(memoria.Repositorios()); err != nil {
+		t.Fatalf("DefinirRepositorios: %v", err)
+	}
+	go servidor.Iniciar()
+	defer servidor.Parar()
+	esperarPorta(t, endereco)
+
+	ctx, cancelar := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelar()
+
+	eclusa := simulador.NovaEclusaSimulada("RG", endereco, time.Second)
+	go eclusa.Executar(ctx)
+	select {
+	case <-eclusa.Conectada():
+	case <-ctx.Done():
+		t.Fatal("o simulador não ligou ao servidor TCP")
+	}
+
+	cenario, err := simulador.InterpretarCenarioScript([]byte(roteiroTeste))
+	if err != nil {
+		t.Fatalf("InterpretarCenarioScript: %v", err)
+	}
+	if err := cenario.Executar(ctx, eclusa); err != nil {
+		t.Fatalf("Executar: %v", err)
+	}
+
+	// Os frames são processados de forma assíncrona: esperar pelo estado final
+	var ocorrencias []repositorio.Ocorrencia
+	for {
+		pagina, err := memoria.Repositorios().Ocorrencias.ListarHistorico(repositorio.FiltroHistorico{Limite: 10})
+		if err != nil {
+			t.Fatalf("ListarHistorico: %v", err)
+		}
+		ocorrencias = pagina.Ocorrencias
+		if estados(ocorrencias)[codigoPonto(6)] == "RESOLVIDO" && estados(ocorrencias)[codigoPonto(9)] == "ATIVO" {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("ocorrências gravadas = %v; esperado 006 RESOLVIDO e 009 ATIVO", estados(ocorrencias))
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	if len(ocorrencias) != 2 {
+		t.Fatalf("%d ocorrências gravadas; esperadas 2", len(ocorrencias))
+	}
+	for _, oc := range ocorrencias {
+		if oc.EclusaCodigo != "RG" || oc.SetorCodigo != "ENCHIMENTO" {
+			t.Errorf("ocorrência %s na eclusa %s, setor %s", oc.Codigo, oc.EclusaCodigo, oc.SetorCodigo)
+		}
+		transicoes := memoria.Transicoes(oc.ID)
+		esperadas := 1
+		if oc.Status == "RESOLVIDO" {
+			esperadas = 2
+		}
+		if len(transicoes) != esperadas {
+			t.Errorf("ocorrência %s com %d transições; esperadas %d", oc.Codigo, len(transicoes), esperadas)
+		}
+	}
+}
+
+func codigoPonto(ponto int) string {
+	return fmt.Sprintf("RG_ENCHIMENTO_%03d", ponto)
+}
+
+func estados(ocorrencias []repositorio.Ocorrencia) map[string]string {
+	resultado := make(map[string]string)
+	for _, oc := range ocorrencias {
+		resultado[oc.Codigo] = oc.Status
+	}
+	return resultado
+}
+
+// enderecoLivre reserva uma porta local livre para o servidor de teste
+func enderecoLivre(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("sem porta livre: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// esperarPorta espera até o servidor aceitar ligações
+func esperarPorta(t *testing.T, endereco string) {
+	t.Helper()
+	limite := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", endereco)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		if time.Now().After(limite) {
+			t.Fatalf("servidor TCP não abriu %s: %v", endereco, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
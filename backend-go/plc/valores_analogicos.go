@@ -0,0 +1,217 @@
+package plc
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+)
+
+// intervaloRecargaAnalogicos define de quanto em quanto tempo as tags analógicas são relidas do banco
+const intervaloRecargaAnalogicos = time.Minute
+
+// ValorAnalogico é o último valor convertido de uma tag analógica
+type ValorAnalogico struct {
+	Tag      modelos.TagAnalogica
+	Valor    float64
+	DataHora time.Time
+}
+
+// MapeamentoAnalogico converte WORDs do frame em valores de engenharia (nível, posição, pressão...)
+type MapeamentoAnalogico struct {
+	bancoDados      *sql.DB
+	mutex           sync.RWMutex
+	tags            []modelos.TagAnalogica
+	wordsOcupadas   map[int]bool           // WORDs que não contêm bits de falha/evento
+	ultimos         map[int]ValorAnalogico // [tag_id] último valor lido
+	ultimosGravados map[int]ValorAnalogico // [tag_id] último valor enviado para a série
+	carregadoEm     time.Time
+}
+
+// NovoMapeamentoAnalogico carrega as tags analógicas ativas (sem banco fica vazio)
+func NovoMapeamentoAnalogico(db *sql.DB) *MapeamentoAnalogico {
+	mapeamento := &MapeamentoAnalogico{
+		bancoDados:      db,
+		wordsOcupadas:   make(map[int]bool),
+		ultimos:         make(map[int]ValorAnalogico),
+		ultimosGravados: make(map[int]ValorAnalogico),
+	}
+
+	if db != nil {
+		if err := mapeamento.Recarregar(); err != nil {
+			log.Printf("⚠️ Erro ao carregar tags analógicas: %v", err)
+		}
+	}
+
+	return mapeamento
+}
+
+// Recarregar relê as tags analógicas ativas do banco de dados
+func (m *MapeamentoAnalogico) Recarregar() error {
+	if m.bancoDados == nil {
+		return fmt.Errorf("sem conexão com o banco de dados")
+	}
+
+	rows, err := m.bancoDados.Query(`
+		SELECT t.id, t.tag, t.eclusa_id, COALESCE(e.codigo, ''), COALESCE(t.descricao, ''),
+			COALESCE(t.unidade, ''), t.word_index, t.tipo_dado, t.escala, t.deslocamento,
+			t.banda_morta, t.intervalo_maximo_segundos, t.ativa
+		FROM tags_analogicas t
+		LEFT JOIN eclusas e ON t.eclusa_id = e.id
+		WHERE t.ativa = true
+		ORDER BY t.word_index`)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar tags analógicas: %v", err)
+	}
+	defer rows.Close()
+
+	var tags []modelos.TagAnalogica
+	wordsOcupadas := make(map[int]bool)
+	for rows.Next() {
+		var tag modelos.TagAnalogica
+		var eclusaID sql.NullInt64
+		if err := rows.Scan(&tag.ID, &tag.Tag, &eclusaID, &tag.EclusaCodigo, &tag.Descricao,
+			&tag.Unidade, &tag.WordIndex, &tag.TipoDado, &tag.Escala, &tag.Deslocamento,
+			&tag.BandaMorta, &tag.IntervaloMaximoSegundos, &tag.Ativa); err != nil {
+			return fmt.Errorf("erro ao ler tag analógica: %v", err)
+		}
+		if eclusaID.Valid {
+			id := int(eclusaID.Int64)
+			tag.EclusaID = &id
+		}
+
+		tags = append(tags, tag)
+		for i := 0; i < tag.QuantidadeWords(); i++ {
+			wordsOcupadas[tag.WordIndex+i] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	m.tags = tags
+	m.wordsOcupadas = wordsOcupadas
+	m.carregadoEm = time.Now()
+	m.mutex.Unlock()
+
+	if len(tags) > 0 {
+		log.Printf("📈 %d tags analógicas carregadas", len(tags))
+	}
+	return nil
+}
+
+// EhWordAnalogica indica se a WORD contém um valor analógico (não deve ser lida bit a bit)
+func (m *MapeamentoAnalogico) EhWordAnalogica(enderecoWord int) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.wordsOcupadas[enderecoWord]
+}
+
+// Extrair converte as WORDs do frame e devolve as amostras a gravar. Uma amostra é gravada
+// quando o valor sai da banda morta ou quando passou o intervalo máximo desde a última.
+func (m *MapeamentoAnalogico) Extrair(words []modelos.DadosWord, dataHora time.Time) []modelos.AmostraSerie {
+	m.mutex.RLock()
+	recarregar := m.bancoDados != nil && time.Since(m.carregadoEm) > intervaloRecargaAnalogicos
+	m.mutex.RUnlock()
+	if recarregar {
+		if err := m.Recarregar(); err != nil {
+			log.Printf("⚠️ Erro ao recarregar tags analógicas: %v", err)
+			m.mutex.Lock()
+			m.carregadoEm = time.Now()
+			m.mutex.Unlock()
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(m.tags) == 0 {
+		return nil
+	}
+
+	valores := make(map[int]uint16, len(words))
+	for _, word := range words {
+		valores[word.Endereco] = word.Valor
+	}
+
+	var amostras []modelos.AmostraSerie
+	for _, tag := range m.tags {
+		valor, ok := converterValor(tag, valores)
+		if !ok {
+			continue
+		}
+
+		atual := ValorAnalogico{Tag: tag, Valor: valor, DataHora: dataHora}
+		m.ultimos[tag.ID] = atual
+
+		anterior, gravado := m.ultimosGravados[tag.ID]
+		intervaloMaximo := time.Duration(tag.IntervaloMaximoSegundos) * time.Second
+		if gravado &&
+			math.Abs(valor-anterior.Valor) <= tag.BandaMorta &&
+			(intervaloMaximo <= 0 || dataHora.Sub(anterior.DataHora) < intervaloMaximo) {
+			continue
+		}
+
+		m.ultimosGravados[tag.ID] = atual
+		amostras = append(amostras, modelos.AmostraSerie{TagID: tag.ID, Timestamp: dataHora, Valor: valor})
+	}
+
+	return amostras
+}
+
+// UltimoValor devolve o último valor lido de uma tag (pelo nome)
+func (m *MapeamentoAnalogico) UltimoValor(nomeTag string) (ValorAnalogico, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, tag := range m.tags {
+		if tag.Tag == nomeTag {
+			valor, existe := m.ultimos[tag.ID]
+			return valor, existe
+		}
+	}
+	return ValorAnalogico{}, false
+}
+
+// converterValor decodifica o tipo de dado (Big Endian, WORD mais significativa primeiro) e aplica a escala
+func converterValor(tag modelos.TagAnalogica, valores map[int]uint16) (float64, bool) {
+	alta, ok := valores[tag.WordIndex]
+	if !ok {
+		return 0, false
+	}
+
+	var bruto float64
+	switch tag.TipoDado {
+	case modelos.TipoDadoInt16:
+		bruto = float64(int16(alta))
+	case modelos.TipoDadoUint16:
+		bruto = float64(alta)
+	case modelos.TipoDadoInt32, modelos.TipoDadoReal:
+		baixa, ok := valores[tag.WordIndex+1]
+		if !ok {
+			return 0, false
+		}
+		palavra := uint32(alta)<<16 | uint32(baixa)
+		if tag.TipoDado == modelos.TipoDadoInt32 {
+			bruto = float64(int32(palavra))
+		} else {
+			bruto = float64(math.Float32frombits(palavra))
+			if math.IsNaN(bruto) || math.IsInf(bruto, 0) {
+				return 0, false
+			}
+		}
+	default:
+		return 0, false
+	}
+
+	escala := tag.Escala
+	if escala == 0 {
+		escala = 1
+	}
+	return bruto*escala + tag.Deslocamento, true
+}
@@ -0,0 +1,237 @@
+package relatorios
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+)
+
+// Origem de um relatório arquivado
+const (
+	OrigemAgendado = "AGENDADO"
+	OrigemManual   = "MANUAL"
+)
+
+// intervaloVerificacaoAgenda é a frequência com que o agendador verifica os minutos passados
+const intervaloVerificacaoAgenda = 20 * time.Second
+
+// Agendador gera e arquiva os relatórios de turno, diários e mensais segundo as agendas cron
+type Agendador struct {
+	bancoDados   *sql.DB
+	agendas      map[string]*ExpressaoCron // [tipo]
+	duracaoTurno time.Duration
+	eclusas      []string // Vazio = todas as eclusas ativas
+	canalParada  chan struct{}
+	grupoWait    sync.WaitGroup
+}
+
+// NovoAgendador interpreta as agendas da configuração ("-" desativa o tipo)
+func NovoAgendador(db *sql.DB, cfg *config.Configuracoes) (*Agendador, error) {
+	a := &Agendador{
+		bancoDados:   db,
+		agendas:      make(map[string]*ExpressaoCron),
+		duracaoTurno: cfg.Relatorios_DuracaoTurno,
+		canalParada:  make(chan struct{}),
+	}
+	if a.duracaoTurno <= 0 {
+		a.duracaoTurno = 8 * time.Hour
+	}
+
+	for tipo, texto := range map[string]string{
+		TipoTurno:  cfg.Relatorios_AgendaTurno,
+		TipoDiario: cfg.Relatorios_AgendaDiario,
+		TipoMensal: cfg.Relatorios_AgendaMensal,
+	} {
+		texto = strings.TrimSpace(texto)
+		if texto == "" || texto == AgendaDesativada {
+			continue
+		}
+		expressao, err := InterpretarCron(texto)
+		if err != nil {
+			return nil, fmt.Errorf("erro na agenda de relatórios %s: %v", tipo, err)
+		}
+		a.agendas[tipo] = expressao
+	}
+
+	for _, codigo := range strings.Split(cfg.Relatorios_Eclusas, ",") {
+		if codigo = strings.ToUpper(strings.TrimSpace(codigo)); codigo != "" {
+			a.eclusas = append(a.eclusas, codigo)
+		}
+	}
+
+	return a, nil
+}
+
+// DuracaoTurno devolve a duração de um turno
+func (a *Agendador) DuracaoTurno() time.Duration {
+	return a.duracaoTurno
+}
+
+// Agendas devolve a expressão de cada tipo ativo
+func (a *Agendador) Agendas() map[string]string {
+	agendas := make(map[string]string, len(a.agendas))
+	for tipo, expressao := range a.agendas {
+		agendas[tipo] = expressao.String()
+	}
+	return agendas
+}
+
+// Iniciar recupera os relatórios do último disparo de cada agenda que faltem (servidor parado
+// na hora marcada) e depois verifica a agenda minuto a minuto, até Parar
+func (a *Agendador) Iniciar() {
+	a.grupoWait.Add(1)
+	go func() {
+		defer a.grupoWait.Done()
+
+		agora := time.Now()
+		for tipo, expressao := range a.agendas {
+			if disparo := expressao.Anterior(agora); !disparo.IsZero() {
+				a.executar(tipo, disparo, true)
+			}
+		}
+
+		ultimoMinuto := agora.Truncate(time.Minute)
+		temporizador := time.NewTicker(intervaloVerificacaoAgenda)
+		defer temporizador.Stop()
+
+		for {
+			select {
+			case <-a.canalParada:
+				return
+			case <-temporizador.C:
+			}
+
+			// Percorre todos os minutos desde a última verificação (o relógio pode ter saltado)
+			atual := time.Now().Truncate(time.Minute)
+			for minuto := ultimoMinuto.Add(time.Minute); !minuto.After(atual); minuto = minuto.Add(time.Minute) {
+				for tipo, expressao := range a.agendas {
+					if expressao.Corresponde(minuto) {
+						a.executar(tipo, minuto, false)
+					}
+				}
+			}
+			ultimoMinuto = atual
+		}
+	}()
+}
+
+// Parar espera a geração em curso terminar
+func (a *Agendador) Parar() {
+	close(a.canalParada)
+	a.grupoWait.Wait()
+}
+
+// executar gera o relatório do período que termina no disparo para cada eclusa.
+// Na recuperação só gera os relatórios que ainda não existem.
+func (a *Agendador) executar(tipo string, disparo time.Time, apenasEmFalta bool) {
+	eclusas, err := a.eclusasAlvo()
+	if err != nil {
+		log.Printf("❌ Erro ao listar eclusas para relatórios: %v", err)
+		return
+	}
+
+	inicio, fim := Periodo(tipo, disparo, a.duracaoTurno)
+	for _, eclusa := range eclusas {
+		if apenasEmFalta {
+			existe, err := RelatorioExiste(a.bancoDados, tipo, eclusa, inicio)
+			if err != nil {
+				log.Printf("❌ Erro ao verificar relatório %s de %s: %v", tipo, eclusa, err)
+				continue
+			}
+			if existe {
+				continue
+			}
+		}
+
+		id, err := GerarRelatorio(a.bancoDados, tipo, eclusa, inicio, fim, OrigemAgendado, time.Now())
+		if err != nil {
+			log.Printf("❌ Erro ao gerar relatório %s de %s: %v", tipo, eclusa, err)
+			continue
+		}
+		log.Printf("📄 Relatório %s de %s (%s a %s) arquivado com id %d", tipo, eclusa,
+			inicio.Format(formatoDataHora), fim.Format(formatoDataHora), id)
+	}
+}
+
+// eclusasAlvo devolve as eclusas configuradas ou todas as ativas
+func (a *Agendador) eclusasAlvo() ([]string, error) {
+	if len(a.eclusas) > 0 {
+		return a.eclusas, nil
+	}
+
+	rows, err := a.bancoDados.Query("SELECT codigo FROM eclusas WHERE ativa = true ORDER BY codigo")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var eclusas []string
+	for rows.Next() {
+		var codigo string
+		if err := rows.Scan(&codigo); err != nil {
+			return nil, err
+		}
+		eclusas = append(eclusas, codigo)
+	}
+	return eclusas, rows.Err()
+}
+
+// RelatorioExiste indica se o relatório do tipo, eclusa e início já foi arquivado
+func RelatorioExiste(db *sql.DB, tipo, eclusa string, inicio time.Time) (bool, error) {
+	var existe bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM relatorios_gerados r
+			JOIN eclusas e ON r.eclusa_id = e.id
+			WHERE r.tipo = $1 AND e.codigo = $2 AND r.periodo_inicio = $3
+		)`, tipo, strings.ToUpper(eclusa), inicio).Scan(&existe)
+	return existe, err
+}
+
+// GerarRelatorio recolhe os dados, renderiza HTML e PDF e arquiva o relatório. Gerar de novo o
+// mesmo período substitui a versão anterior. Devolve o id do relatório arquivado.
+func GerarRelatorio(db *sql.DB, tipo, eclusa string, inicio, fim time.Time, origem string, agora time.Time) (int64, error) {
+	dados, err := ColetarDados(db, tipo, eclusa, inicio, fim, agora)
+	if err != nil {
+		return 0, err
+	}
+
+	html, err := RenderizarHTML(dados)
+	if err != nil {
+		return 0, err
+	}
+	pdf, err := RenderizarPDF(dados)
+	if err != nil {
+		return 0, err
+	}
+	resumo, err := json.Marshal(dados.Resumo)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao serializar resumo do relatório: %v", err)
+	}
+
+	var id int64
+	err = db.QueryRow(`
+		INSERT INTO relatorios_gerados (tipo, eclusa_id, periodo_inicio, periodo_fim, origem, html, pdf, resumo, gerado_em)
+		SELECT $1, e.id, $3, $4, $5, $6, $7, $8, $9
+		FROM eclusas e WHERE e.codigo = $2
+		ON CONFLICT (tipo, eclusa_id, periodo_inicio) DO UPDATE SET
+			periodo_fim = EXCLUDED.periodo_fim,
+			origem = EXCLUDED.origem,
+			html = EXCLUDED.html,
+			pdf = EXCLUDED.pdf,
+			resumo = EXCLUDED.resumo,
+			gerado_em = EXCLUDED.gerado_em
+		RETURNING id`,
+		tipo, dados.EclusaCodigo, inicio, fim, origem, html, pdf, string(resumo), agora).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao arquivar relatório: %v", err)
+	}
+
+	return id, nil
+}
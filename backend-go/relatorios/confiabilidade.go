@@ -0,0 +1,363 @@
+package relatorios
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/database"
+)
+
+// Agrupamentos aceites pelo Pareto
+const (
+	AgruparFalha  = "falha"
+	AgruparSetor  = "setor"
+	AgruparEclusa = "eclusa"
+)
+
+// limiteClasseA e limiteClasseB separam as classes do Pareto pela percentagem acumulada
+const (
+	limiteClasseA = 80.0
+	limiteClasseB = 95.0
+)
+
+// Filtros delimita o período e o âmbito dos indicadores de confiabilidade
+type Filtros struct {
+	Inicio            time.Time `json:"inicio"`
+	Fim               time.Time `json:"fim"`
+	Eclusa            string    `json:"eclusa,omitempty"`
+	Setor             string    `json:"setor,omitempty"`
+	Prioridade        string    `json:"prioridade,omitempty"`
+	IncluirSuprimidas bool      `json:"incluir_suprimidas"` // Por padrão só contam as causas (não as consequências suprimidas)
+	IncluirManutencao bool      `json:"incluir_manutencao"` // Por padrão as falhas ocultadas por janelas de manutenção não contam
+}
+
+// condicaoForaJanelaOculta exclui as ocorrências abertas numa janela de manutenção que as oculta
+const condicaoForaJanelaOculta = `NOT EXISTS (
+		SELECT 1 FROM janelas_manutencao jm WHERE jm.id = o.janela_manutencao_id AND jm.ocultar)`
+
+// Indicadores são os indicadores de confiabilidade de uma eclusa, setor ou falha no período.
+// Ocorrências sobrepostas do mesmo grupo contam como uma única paragem.
+type Indicadores struct {
+	Eclusa                    string   `json:"eclusa"`
+	Setor                     string   `json:"setor,omitempty"`
+	SetorNome                 string   `json:"setor_nome,omitempty"`
+	Codigo                    string   `json:"codigo,omitempty"`
+	Descricao                 string   `json:"descricao,omitempty"`
+	Ocorrencias               int      `json:"ocorrencias"`
+	Paragens                  int      `json:"paragens"` // Paragens com tempo dentro do período
+	Falhas                    int      `json:"falhas"`   // Paragens iniciadas dentro do período
+	TempoParadoHoras          float64  `json:"tempo_parado_horas"`
+	TempoOperacionalHoras     float64  `json:"tempo_operacional_horas"`
+	DisponibilidadePercentual float64  `json:"disponibilidade_percentual"`
+	MTBFHoras                 *float64 `json:"mtbf_horas,omitempty"` // Tempo operacional / falhas
+	MTTRHoras                 *float64 `json:"mttr_horas,omitempty"` // Tempo parado / paragens
+}
+
+// RelatorioConfiabilidade reúne os indicadores por eclusa, por setor e por falha
+type RelatorioConfiabilidade struct {
+	Filtros      Filtros       `json:"filtros"`
+	FimEfetivo   time.Time     `json:"fim_efetivo"` // O período não vai além do instante do cálculo
+	PeriodoHoras float64       `json:"periodo_horas"`
+	PorEclusa    []Indicadores `json:"por_eclusa"`
+	PorSetor     []Indicadores `json:"por_setor"`
+	PorFalha     []Indicadores `json:"por_falha"`
+}
+
+// ItemPareto é um contribuinte do tempo parado, por ordem decrescente
+type ItemPareto struct {
+	Eclusa              string  `json:"eclusa"`
+	Chave               string  `json:"chave"`
+	Descricao           string  `json:"descricao"`
+	Ocorrencias         int     `json:"ocorrencias"`
+	TempoParadoHoras    float64 `json:"tempo_parado_horas"`
+	Percentual          float64 `json:"percentual"`
+	PercentualAcumulado float64 `json:"percentual_acumulado"`
+	Classe              string  `json:"classe"` // A (até 80% acumulado), B (até 95%) ou C
+}
+
+// intervalo é uma ocorrência de falha com o fim em aberto substituído pelo instante do cálculo
+type intervalo struct {
+	inicio time.Time
+	fim    time.Time
+}
+
+// grupo acumula as ocorrências de uma eclusa, setor ou falha
+type grupo struct {
+	indicadores Indicadores
+	intervalos  []intervalo
+}
+
+// CalcularConfiabilidade calcula disponibilidade, MTBF, MTTR e tempo parado no período. As ocorrências
+// que começam antes do início ou terminam depois do fim contam apenas a parte dentro do período; as
+// ocorrências em curso contam até agora.
+func CalcularConfiabilidade(db *sql.DB, filtros Filtros, agora time.Time) (*RelatorioConfiabilidade, error) {
+	fimEfetivo := filtros.Fim
+	if fimEfetivo.After(agora) {
+		fimEfetivo = agora
+	}
+	if !fimEfetivo.After(filtros.Inicio) {
+		return nil, fmt.Errorf("período sem duração: 'inicio' deve ser anterior a 'fim' e ao instante atual")
+	}
+
+	query := `
+		SELECT e.codigo, s.codigo, s.nome, df.codigo, df.descricao, o.timestamp_inicio, o.timestamp_fim
+		FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		JOIN setores s ON df.setor_id = s.id
+		JOIN eclusas e ON df.eclusa_id = e.id
+		WHERE df.tipo = 'FALHA'
+		AND o.timestamp_inicio < $2
+		AND (o.timestamp_fim IS NULL OR o.timestamp_fim > $1)`
+	args := []interface{}{filtros.Inicio, fimEfetivo}
+
+	if filtros.Eclusa != "" {
+		args = append(args, strings.ToUpper(filtros.Eclusa))
+		query += fmt.Sprintf(" AND e.codigo = $%d", len(args))
+	}
+	if filtros.Setor != "" {
+		args = append(args, filtros.Setor)
+		query += fmt.Sprintf(" AND s.codigo = $%d", len(args))
+	}
+	if filtros.Prioridade != "" {
+		args = append(args, strings.ToUpper(filtros.Prioridade))
+		query += fmt.Sprintf(" AND df.prioridade = $%d", len(args))
+	}
+	if !filtros.IncluirSuprimidas {
+		query += " AND o.suprimida_por IS NULL"
+	}
+	if !filtros.IncluirManutencao {
+		query += " AND " + condicaoForaJanelaOculta
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar ocorrências do período: %v", err)
+	}
+	defer rows.Close()
+
+	porEclusa := make(map[string]*grupo)
+	porSetor := make(map[string]*grupo)
+	porFalha := make(map[string]*grupo)
+
+	for rows.Next() {
+		var eclusa, setor, setorNome, codigo, descricao string
+		var inicio time.Time
+		var fim sql.NullTime
+		if err := rows.Scan(&eclusa, &setor, &setorNome, &codigo, &descricao, &inicio, &fim); err != nil {
+			return nil, fmt.Errorf("erro ao ler ocorrência: %v", err)
+		}
+
+		i := intervalo{inicio: database.HoraLocal(inicio), fim: agora}
+		if fim.Valid {
+			i.fim = database.HoraLocal(fim.Time)
+		}
+
+		adicionar(porEclusa, eclusa, Indicadores{Eclusa: eclusa}, i)
+		adicionar(porSetor, eclusa+"/"+setor, Indicadores{Eclusa: eclusa, Setor: setor, SetorNome: setorNome}, i)
+		adicionar(porFalha, eclusa+"/"+codigo, Indicadores{
+			Eclusa: eclusa, Setor: setor, SetorNome: setorNome, Codigo: codigo, Descricao: descricao,
+		}, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// As eclusas sem falhas no período também entram (disponibilidade 100%)
+	consultaEclusas := "SELECT codigo FROM eclusas WHERE ativa = true"
+	argsEclusas := []interface{}{}
+	if filtros.Eclusa != "" {
+		consultaEclusas += " AND codigo = $1"
+		argsEclusas = append(argsEclusas, strings.ToUpper(filtros.Eclusa))
+	}
+	eclusas, err := db.Query(consultaEclusas, argsEclusas...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar eclusas: %v", err)
+	}
+	defer eclusas.Close()
+	for eclusas.Next() {
+		var codigo string
+		if err := eclusas.Scan(&codigo); err != nil {
+			return nil, fmt.Errorf("erro ao ler eclusa: %v", err)
+		}
+		if _, existe := porEclusa[codigo]; !existe {
+			porEclusa[codigo] = &grupo{indicadores: Indicadores{Eclusa: codigo}}
+		}
+	}
+
+	return &RelatorioConfiabilidade{
+		Filtros:      filtros,
+		FimEfetivo:   fimEfetivo,
+		PeriodoHoras: fimEfetivo.Sub(filtros.Inicio).Hours(),
+		PorEclusa:    consolidar(porEclusa, filtros.Inicio, fimEfetivo),
+		PorSetor:     consolidar(porSetor, filtros.Inicio, fimEfetivo),
+		PorFalha:     consolidar(porFalha, filtros.Inicio, fimEfetivo),
+	}, nil
+}
+
+// Pareto ordena os contribuintes do tempo parado (por falha, setor ou eclusa) com percentagem
+// acumulada e classe ABC. limite <= 0 devolve todos.
+func Pareto(relatorio *RelatorioConfiabilidade, agrupar string, limite int) ([]ItemPareto, error) {
+	var origem []Indicadores
+	switch agrupar {
+	case AgruparFalha:
+		origem = relatorio.PorFalha
+	case AgruparSetor:
+		origem = relatorio.PorSetor
+	case AgruparEclusa:
+		origem = relatorio.PorEclusa
+	default:
+		return nil, fmt.Errorf("agrupamento inválido: %s (use falha, setor ou eclusa)", agrupar)
+	}
+
+	itens := []ItemPareto{}
+	total := 0.0
+	for _, ind := range origem {
+		if ind.TempoParadoHoras <= 0 {
+			continue
+		}
+		item := ItemPareto{
+			Eclusa:           ind.Eclusa,
+			Ocorrencias:      ind.Ocorrencias,
+			TempoParadoHoras: ind.TempoParadoHoras,
+		}
+		switch agrupar {
+		case AgruparFalha:
+			item.Chave, item.Descricao = ind.Codigo, ind.Descricao
+		case AgruparSetor:
+			item.Chave, item.Descricao = ind.Setor, ind.SetorNome
+		default:
+			item.Chave, item.Descricao = ind.Eclusa, ind.Eclusa
+		}
+		itens = append(itens, item)
+		total += ind.TempoParadoHoras
+	}
+
+	sort.SliceStable(itens, func(a, b int) bool {
+		return itens[a].TempoParadoHoras > itens[b].TempoParadoHoras
+	})
+
+	acumulado := 0.0
+	for i := range itens {
+		anterior := acumulado
+		itens[i].Percentual = itens[i].TempoParadoHoras * 100 / total
+		acumulado += itens[i].Percentual
+		itens[i].PercentualAcumulado = acumulado
+
+		// A classe segue o acumulado antes do item: o item que cruza os 80% ainda é A
+		switch {
+		case anterior < limiteClasseA:
+			itens[i].Classe = "A"
+		case anterior < limiteClasseB:
+			itens[i].Classe = "B"
+		default:
+			itens[i].Classe = "C"
+		}
+	}
+
+	if limite > 0 && len(itens) > limite {
+		itens = itens[:limite]
+	}
+	return itens, nil
+}
+
+// adicionar junta a ocorrência ao grupo da chave, criando-o se preciso
+func adicionar(grupos map[string]*grupo, chave string, base Indicadores, i intervalo) {
+	g, existe := grupos[chave]
+	if !existe {
+		g = &grupo{indicadores: base}
+		grupos[chave] = g
+	}
+	g.indicadores.Ocorrencias++
+	g.intervalos = append(g.intervalos, i)
+}
+
+// consolidar calcula os indicadores de cada grupo e ordena por tempo parado decrescente
+func consolidar(grupos map[string]*grupo, inicio, fim time.Time) []Indicadores {
+	resultado := make([]Indicadores, 0, len(grupos))
+	for _, g := range grupos {
+		resultado = append(resultado, calcularIndicadores(g.indicadores, g.intervalos, inicio, fim))
+	}
+
+	sort.Slice(resultado, func(a, b int) bool {
+		if resultado[a].TempoParadoHoras != resultado[b].TempoParadoHoras {
+			return resultado[a].TempoParadoHoras > resultado[b].TempoParadoHoras
+		}
+		if resultado[a].Eclusa != resultado[b].Eclusa {
+			return resultado[a].Eclusa < resultado[b].Eclusa
+		}
+		if resultado[a].Setor != resultado[b].Setor {
+			return resultado[a].Setor < resultado[b].Setor
+		}
+		return resultado[a].Codigo < resultado[b].Codigo
+	})
+	return resultado
+}
+
+// calcularIndicadores une as ocorrências sobrepostas em paragens e recorta-as ao período
+func calcularIndicadores(ind Indicadores, intervalos []intervalo, inicio, fim time.Time) Indicadores {
+	periodo := fim.Sub(inicio)
+
+	var parado time.Duration
+	for _, paragem := range unirIntervalos(intervalos) {
+		if !paragem.inicio.Before(inicio) {
+			ind.Falhas++
+		}
+
+		recorteInicio, recorteFim := paragem.inicio, paragem.fim
+		if recorteInicio.Before(inicio) {
+			recorteInicio = inicio
+		}
+		if recorteFim.After(fim) {
+			recorteFim = fim
+		}
+		if recorteFim.After(recorteInicio) {
+			ind.Paragens++
+			parado += recorteFim.Sub(recorteInicio)
+		}
+	}
+
+	operacional := periodo - parado
+	ind.TempoParadoHoras = parado.Hours()
+	ind.TempoOperacionalHoras = operacional.Hours()
+	if periodo > 0 {
+		ind.DisponibilidadePercentual = float64(operacional) * 100 / float64(periodo)
+	}
+	if ind.Falhas > 0 {
+		mtbf := operacional.Hours() / float64(ind.Falhas)
+		ind.MTBFHoras = &mtbf
+	}
+	if ind.Paragens > 0 {
+		mttr := parado.Hours() / float64(ind.Paragens)
+		ind.MTTRHoras = &mttr
+	}
+	return ind
+}
+
+// unirIntervalos ordena e junta os intervalos que se sobrepõem ou se tocam
+func unirIntervalos(intervalos []intervalo) []intervalo {
+	if len(intervalos) == 0 {
+		return nil
+	}
+
+	ordenados := make([]intervalo, len(intervalos))
+	copy(ordenados, intervalos)
+	sort.Slice(ordenados, func(a, b int) bool {
+		return ordenados[a].inicio.Before(ordenados[b].inicio)
+	})
+
+	unidos := []intervalo{ordenados[0]}
+	for _, i := range ordenados[1:] {
+		ultimo := &unidos[len(unidos)-1]
+		if !i.inicio.After(ultimo.fim) {
+			if i.fim.After(ultimo.fim) {
+				ultimo.fim = i.fim
+			}
+			continue
+		}
+		unidos = append(unidos, i)
+	}
+	return unidos
+}
@@ -0,0 +1,161 @@
+package relatorios
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AgendaDesativada desativa um tipo de relatório na configuração
+const AgendaDesativada = "-"
+
+// campoCron descreve os limites de um campo da expressão
+type campoCron struct {
+	nome   string
+	minimo int
+	maximo int
+}
+
+// camposCron segue a ordem clássica: minuto hora dia-do-mês mês dia-da-semana (0 = domingo)
+var camposCron = []campoCron{
+	{"minuto", 0, 59},
+	{"hora", 0, 23},
+	{"dia", 1, 31},
+	{"mês", 1, 12},
+	{"dia da semana", 0, 7},
+}
+
+// ExpressaoCron é uma agenda de 5 campos com *, listas (a,b), intervalos (a-b) e passos (*/n, a-b/n)
+type ExpressaoCron struct {
+	texto          string
+	valores        [5]map[int]bool
+	diaQualquer    bool // Campo do dia do mês é "*"
+	semanaQualquer bool // Campo do dia da semana é "*"
+}
+
+// InterpretarCron valida e interpreta uma expressão cron de 5 campos
+func InterpretarCron(texto string) (*ExpressaoCron, error) {
+	partes := strings.Fields(texto)
+	if len(partes) != len(camposCron) {
+		return nil, fmt.Errorf("expressão cron inválida '%s': são esperados 5 campos", texto)
+	}
+
+	expressao := &ExpressaoCron{
+		texto:          texto,
+		diaQualquer:    partes[2] == "*",
+		semanaQualquer: partes[4] == "*",
+	}
+	for i, parte := range partes {
+		valores, err := interpretarCampoCron(parte, camposCron[i])
+		if err != nil {
+			return nil, fmt.Errorf("expressão cron inválida '%s': %v", texto, err)
+		}
+		expressao.valores[i] = valores
+	}
+
+	// 7 também é domingo
+	if expressao.valores[4][7] {
+		expressao.valores[4][0] = true
+	}
+
+	return expressao, nil
+}
+
+// interpretarCampoCron expande um campo nos valores que aceita
+func interpretarCampoCron(parte string, campo campoCron) (map[int]bool, error) {
+	valores := make(map[int]bool)
+
+	for _, item := range strings.Split(parte, ",") {
+		passo := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			passo, err = strconv.Atoi(item[i+1:])
+			if err != nil || passo <= 0 {
+				return nil, fmt.Errorf("passo inválido no campo %s: %s", campo.nome, item)
+			}
+			item = item[:i]
+		}
+
+		inicio, fim := campo.minimo, campo.maximo
+		if item != "*" {
+			limites := strings.SplitN(item, "-", 2)
+			var err error
+			inicio, err = strconv.Atoi(limites[0])
+			if err != nil {
+				return nil, fmt.Errorf("valor inválido no campo %s: %s", campo.nome, item)
+			}
+			fim = inicio
+			if len(limites) == 2 {
+				fim, err = strconv.Atoi(limites[1])
+				if err != nil {
+					return nil, fmt.Errorf("valor inválido no campo %s: %s", campo.nome, item)
+				}
+			} else if passo > 1 {
+				// "a/n" vai de a até ao máximo do campo
+				fim = campo.maximo
+			}
+		}
+
+		if inicio < campo.minimo || fim > campo.maximo || inicio > fim {
+			return nil, fmt.Errorf("campo %s fora do intervalo %d-%d: %s", campo.nome, campo.minimo, campo.maximo, item)
+		}
+		for v := inicio; v <= fim; v += passo {
+			valores[v] = true
+		}
+	}
+
+	return valores, nil
+}
+
+// Corresponde indica se o minuto do instante está na agenda. Como no cron clássico, com dia do mês
+// e dia da semana ambos restritos basta um deles corresponder.
+func (c *ExpressaoCron) Corresponde(instante time.Time) bool {
+	if !c.valores[0][instante.Minute()] || !c.valores[1][instante.Hour()] ||
+		!c.valores[3][int(instante.Month())] {
+		return false
+	}
+
+	dia := c.valores[2][instante.Day()]
+	semana := c.valores[4][int(instante.Weekday())]
+	switch {
+	case c.diaQualquer && c.semanaQualquer:
+		return true
+	case c.diaQualquer:
+		return semana
+	case c.semanaQualquer:
+		return dia
+	}
+	return dia || semana
+}
+
+// Proximo devolve o próximo minuto da agenda depois do instante (zero se não houver num ano)
+func (c *ExpressaoCron) Proximo(instante time.Time) time.Time {
+	candidato := instante.Truncate(time.Minute).Add(time.Minute)
+	limite := candidato.AddDate(1, 0, 1)
+	for candidato.Before(limite) {
+		if c.Corresponde(candidato) {
+			return candidato
+		}
+		candidato = candidato.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+// String devolve a expressão original
+func (c *ExpressaoCron) String() string {
+	return c.texto
+}
+
+// Anterior devolve o último minuto da agenda até ao instante, inclusive (zero se não houver num ano)
+func (c *ExpressaoCron) Anterior(instante time.Time) time.Time {
+	candidato := instante.Truncate(time.Minute)
+	limite := candidato.AddDate(-1, 0, -1)
+	for candidato.After(limite) {
+		if c.Corresponde(candidato) {
+			return candidato
+		}
+		candidato = candidato.Add(-time.Minute)
+	}
+	return time.Time{}
+}
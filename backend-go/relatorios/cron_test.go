@@ -0,0 +1,135 @@
+package relatorios
+
+import (
+	"testing"
+	"time"
+)
+
+// instante monta uma data local; 2026-03-01 é um domingo
+func instante(dia, hora, minuto int) time.Time {
+	return time.Date(2026, 3, dia, hora, minuto, 0, 0, time.Local)
+}
+
+func TestExpressaoCronCorresponde(t *testing.T) {
+	casos := []struct {
+		expressao string
+		instante  time.Time
+		esperado  bool
+	}{
+		{"* * * * *", instante(4, 13, 27), true},
+		{"0 6 * * *", instante(4, 6, 0), true},
+		{"0 6 * * *", instante(4, 6, 1), false},
+
+		// Listas, intervalos e passos
+		{"0,30 * * * *", instante(4, 10, 30), true},
+		{"0,30 * * * *", instante(4, 10, 15), false},
+		{"*/15 * * * *", instante(4, 10, 45), true},
+		{"*/15 * * * *", instante(4, 10, 50), false},
+		{"10-20/5 * * * *", instante(4, 10, 15), true},
+		{"10-20/5 * * * *", instante(4, 10, 25), false},
+		{"5/20 * * * *", instante(4, 10, 45), true},
+		{"5/20 * * * *", instante(4, 10, 0), false},
+		{"0 8-18/2 * * *", instante(4, 14, 0), true},
+		{"0 8-18/2 * * *", instante(4, 15, 0), false},
+		{"0 0 1,15 * *", instante(15, 0, 0), true},
+		{"0 0 * 2-4 *", instante(4, 0, 0), true},
+		{"0 0 * 4-6 *", instante(4, 0, 0), false},
+
+		// Dia da semana: 0 e 7 são domingo
+		{"0 7 * * 0", instante(1, 7, 0), true},
+		{"0 7 * * 7", instante(1, 7, 0), true},
+		{"0 7 * * 7", instante(2, 7, 0), false},
+		{"0 7 * * 5-7", instante(1, 7, 0), true},
+		{"0 7 * * 1-5", instante(1, 7, 0), false},
+		{"0 7 * * 1-5", instante(2, 7, 0), true},
+
+		// Dia do mês e dia da semana restritos: basta um (OU, como no cron clássico)
+		{"0 0 13 * 5", instante(13, 0, 0), true}, // sexta-feira 13
+		{"0 0 13 * 5", instante(6, 0, 0), true},  // sexta-feira
+		{"0 0 13 * 5", instante(14, 0, 0), false},
+		{"0 0 1 * 1", instante(1, 0, 0), true}, // dia 1, domingo
+		{"0 0 1 * 1", instante(2, 0, 0), true}, // segunda-feira
+		{"0 0 1 * 1", instante(3, 0, 0), false},
+
+		// Só um dos dois restrito: vale esse
+		{"0 0 13 * *", instante(6, 0, 0), false},
+		{"0 0 * * 5", instante(13, 0, 0), true},
+	}
+
+	for _, caso := range casos {
+		expressao, err := InterpretarCron(caso.expressao)
+		if err != nil {
+			t.Fatalf("InterpretarCron(%q): %v", caso.expressao, err)
+		}
+		if obtido := expressao.Corresponde(caso.instante); obtido != caso.esperado {
+			t.Errorf("%q em %s = %v; esperado %v", caso.expressao, caso.instante.Format("Mon 2006-01-02 15:04"), obtido, caso.esperado)
+		}
+	}
+}
+
+func TestInterpretarCronInvalida(t *testing.T) {
+	for _, expressao := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+	} {
+		if _, err := InterpretarCron(expressao); err == nil {
+			t.Errorf("InterpretarCron(%q) aceitou uma expressão inválida", expressao)
+		}
+	}
+}
+
+func TestExpressaoCronAnteriorEProximo(t *testing.T) {
+	casos := []struct {
+		expressao string
+		instante  time.Time
+		anterior  time.Time
+		proximo   time.Time
+	}{
+		// O próprio minuto conta como anterior, mas não como próximo
+		{"0 6 * * *", instante(4, 6, 0), instante(4, 6, 0), instante(5, 6, 0)},
+		{"0 6 * * *", instante(4, 5, 59), instante(3, 6, 0), instante(4, 6, 0)},
+		// Segundos são ignorados
+		{"*/15 * * * *", instante(4, 10, 14).Add(59 * time.Second), instante(4, 10, 0), instante(4, 10, 15)},
+		// Semanal ao domingo com 7
+		{"30 8 * * 7", instante(4, 12, 0), instante(1, 8, 30), instante(8, 8, 30)},
+		// Mensal: mudança de mês
+		{"0 0 1 * *", instante(31, 23, 59), instante(1, 0, 0), time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, caso := range casos {
+		expressao, err := InterpretarCron(caso.expressao)
+		if err != nil {
+			t.Fatalf("InterpretarCron(%q): %v", caso.expressao, err)
+		}
+		if obtido := expressao.Anterior(caso.instante); !obtido.Equal(caso.anterior) {
+			t.Errorf("Anterior(%q, %s) = %s; esperado %s", caso.expressao, caso.instante, obtido, caso.anterior)
+		}
+		if obtido := expressao.Proximo(caso.instante); !obtido.Equal(caso.proximo) {
+			t.Errorf("Proximo(%q, %s) = %s; esperado %s", caso.expressao, caso.instante, obtido, caso.proximo)
+		}
+	}
+}
+
+func TestExpressaoCronSemCorrespondencia(t *testing.T) {
+	// 31 de fevereiro nunca existe
+	expressao, err := InterpretarCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("InterpretarCron: %v", err)
+	}
+	if obtido := expressao.Anterior(instante(4, 0, 0)); !obtido.IsZero() {
+		t.Errorf("Anterior = %s; esperado zero", obtido)
+	}
+	if obtido := expressao.Proximo(instante(4, 0, 0)); !obtido.IsZero() {
+		t.Errorf("Proximo = %s; esperado zero", obtido)
+	}
+}
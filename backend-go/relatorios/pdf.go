@@ -0,0 +1,139 @@
+package relatorios
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Página A4 em pontos com fonte monoespaçada, para as tabelas do modelo de texto ficarem alinhadas
+const (
+	larguraPaginaPDF   = 595
+	alturaPaginaPDF    = 842
+	margemPDF          = 40
+	tamanhoFontePDF    = 9
+	tamanhoTituloPDF   = 12
+	entrelinhaPDF      = 11
+	caracteresLinhaPDF = 95 // (595 - 2*40) / (0.6 * 9)
+)
+
+// prefixoTituloPDF marca no modelo de texto as linhas a destacar em negrito
+const prefixoTituloPDF = "# "
+
+// gerarPDF pagina as linhas de texto num PDF 1.4 com as fontes padrão Courier (sem dependências)
+func gerarPDF(titulo string, linhas []string, geradoEm time.Time) []byte {
+	porPagina := (alturaPaginaPDF - 2*margemPDF) / entrelinhaPDF
+
+	var paginas [][]string
+	var atual []string
+	for _, linha := range quebrarLinhas(linhas) {
+		if len(atual) == porPagina {
+			paginas = append(paginas, atual)
+			atual = nil
+		}
+		atual = append(atual, linha)
+	}
+	if len(atual) > 0 || len(paginas) == 0 {
+		paginas = append(paginas, atual)
+	}
+
+	// Objetos: 1 catálogo, 2 árvore de páginas, 3 Courier, 4 Courier-Bold, 5 info, depois página + conteúdo
+	var objetos []string
+	kids := make([]string, len(paginas))
+	for i := range paginas {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	objetos = append(objetos,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(paginas)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (%s) /Producer (falhas-backend) /CreationDate (D:%s) >>",
+			textoPDF(titulo), geradoEm.Format("20060102150405")),
+	)
+
+	for i, pagina := range paginas {
+		var conteudo bytes.Buffer
+		y := alturaPaginaPDF - margemPDF
+		for _, linha := range pagina {
+			fonte, tamanho := "/F1", tamanhoFontePDF
+			if strings.HasPrefix(linha, prefixoTituloPDF) {
+				fonte, tamanho = "/F2", tamanhoTituloPDF
+				linha = strings.TrimPrefix(linha, prefixoTituloPDF)
+			}
+			fmt.Fprintf(&conteudo, "BT %s %d Tf %d %d Td (%s) Tj ET\n", fonte, tamanho, margemPDF, y, textoPDF(linha))
+			y -= entrelinhaPDF
+		}
+		fmt.Fprintf(&conteudo, "BT /F1 8 Tf %d %d Td (%s) Tj ET\n", larguraPaginaPDF-margemPDF-60, margemPDF/2,
+			textoPDF(fmt.Sprintf("Página %d/%d", i+1, len(paginas))))
+
+		objetos = append(objetos,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				larguraPaginaPDF, alturaPaginaPDF, 7+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", conteudo.Len(), conteudo.String()),
+		)
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	posicoes := make([]int, len(objetos))
+	for i, objeto := range objetos {
+		posicoes[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, objeto)
+	}
+
+	inicioXref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objetos)+1)
+	for _, posicao := range posicoes {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", posicao)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objetos)+1, inicioXref)
+
+	return pdf.Bytes()
+}
+
+// quebrarLinhas divide as linhas mais largas do que a página
+func quebrarLinhas(linhas []string) []string {
+	var resultado []string
+	for _, linha := range linhas {
+		runas := []rune(strings.TrimRight(linha, " \r"))
+		prefixo := ""
+		if strings.HasPrefix(linha, prefixoTituloPDF) {
+			prefixo = prefixoTituloPDF
+			runas = runas[len(prefixoTituloPDF):]
+		}
+		for len(runas) > caracteresLinhaPDF {
+			resultado = append(resultado, prefixo+string(runas[:caracteresLinhaPDF]))
+			runas = runas[caracteresLinhaPDF:]
+		}
+		resultado = append(resultado, prefixo+string(runas))
+	}
+	return resultado
+}
+
+// textoPDF converte para WinAnsi (os acentos do português cabem em Latin-1) e escapa a string literal
+func textoPDF(texto string) string {
+	var saida strings.Builder
+	for _, r := range texto {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			saida.WriteByte('\\')
+			saida.WriteByte(byte(r))
+		case r == '\t':
+			saida.WriteString("    ")
+		case r < 0x20:
+		case r < 0x80:
+			saida.WriteByte(byte(r))
+		case r == '€':
+			saida.WriteByte(0x80)
+		case r == '…':
+			saida.WriteByte(0x85)
+		case r >= 0xA0 && r <= 0xFF:
+			saida.WriteByte(byte(r))
+		default:
+			saida.WriteByte('?')
+		}
+	}
+	return saida.String()
+}
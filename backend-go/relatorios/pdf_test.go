@@ -0,0 +1,75 @@
+package relatorios
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGerarPDFTabelaXrefValida(t *testing.T) {
+	// Linhas suficientes para várias páginas, com acentos, parênteses e linhas longas
+	var linhas []string
+	linhas = append(linhas, "# Relatório (teste) de confiabilidade")
+	for i := 0; i < 150; i++ {
+		linhas = append(linhas, fmt.Sprintf("Eclusa RG | falha nº %03d | duração média: %s | ação: verificar (\\)", i, strings.Repeat("é", i%120)))
+	}
+	pdf := gerarPDF("Relatório ção", linhas, time.Date(2026, 3, 1, 6, 0, 0, 0, time.Local))
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("PDF sem cabeçalho ou sem marca de fim")
+	}
+
+	// startxref aponta para a tabela xref
+	indiceStartxref := bytes.LastIndex(pdf, []byte("startxref\n"))
+	if indiceStartxref < 0 {
+		t.Fatal("startxref ausente")
+	}
+	campos := strings.Fields(string(pdf[indiceStartxref+len("startxref\n"):]))
+	inicioXref, err := strconv.Atoi(campos[0])
+	if err != nil || !bytes.HasPrefix(pdf[inicioXref:], []byte("xref\n")) {
+		t.Fatalf("startxref %q não aponta para a tabela xref", campos[0])
+	}
+
+	// Cabeçalho da secção e uma entrada de 20 bytes por objeto
+	linhasXref := strings.SplitN(string(pdf[inicioXref:]), "\n", 3)
+	var primeiro, total int
+	if _, err := fmt.Sscanf(linhasXref[1], "%d %d", &primeiro, &total); err != nil || primeiro != 0 {
+		t.Fatalf("secção xref inválida: %q", linhasXref[1])
+	}
+	entradas := linhasXref[2]
+	if !strings.HasPrefix(entradas, "0000000000 65535 f \n") {
+		t.Fatalf("entrada livre 0 inválida: %q", entradas[:20])
+	}
+
+	for objeto := 1; objeto < total; objeto++ {
+		entrada := entradas[20*objeto : 20*objeto+20]
+		if !regexp.MustCompile(`^\d{10} 00000 n \n$`).MatchString(entrada) {
+			t.Fatalf("entrada xref do objeto %d inválida: %q", objeto, entrada)
+		}
+		posicao, _ := strconv.Atoi(entrada[:10])
+		esperado := fmt.Sprintf("%d 0 obj\n", objeto)
+		if !bytes.HasPrefix(pdf[posicao:], []byte(esperado)) {
+			t.Errorf("xref do objeto %d aponta para %q", objeto, pdf[posicao:min(posicao+20, len(pdf))])
+		}
+	}
+
+	if !strings.Contains(string(pdf[indiceStartxref-200:]), fmt.Sprintf("/Size %d", total)) {
+		t.Errorf("trailer sem /Size %d", total)
+	}
+
+	// /Length de cada stream corresponde aos bytes entre stream e endstream
+	streams := regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)endstream`).FindAllSubmatch(pdf, -1)
+	if len(streams) < 2 {
+		t.Fatalf("%d páginas; esperadas várias", len(streams))
+	}
+	for i, stream := range streams {
+		comprimento, _ := strconv.Atoi(string(stream[1]))
+		if comprimento != len(stream[2]) {
+			t.Errorf("stream %d com /Length %d e %d bytes", i+1, comprimento, len(stream[2]))
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package relatorios
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/database"
+)
+
+// Tipos de relatório periódico
+const (
+	TipoTurno  = "TURNO"
+	TipoDiario = "DIARIO"
+	TipoMensal = "MENSAL"
+)
+
+// linhasTopo limita as tabelas de maiores falhas e paragens
+const linhasTopo = 10
+
+// TipoValido indica se o tipo de relatório existe
+func TipoValido(tipo string) bool {
+	return tipo == TipoTurno || tipo == TipoDiario || tipo == TipoMensal
+}
+
+// Periodo devolve o último período completo do tipo que termina até à referência:
+// turno = as horas de turno até à hora cheia, diário = o dia anterior, mensal = o mês anterior
+func Periodo(tipo string, referencia time.Time, duracaoTurno time.Duration) (time.Time, time.Time) {
+	ano, mes, dia := referencia.Date()
+	switch tipo {
+	case TipoDiario:
+		fim := time.Date(ano, mes, dia, 0, 0, 0, 0, referencia.Location())
+		return fim.AddDate(0, 0, -1), fim
+	case TipoMensal:
+		fim := time.Date(ano, mes, 1, 0, 0, 0, 0, referencia.Location())
+		return fim.AddDate(0, -1, 0), fim
+	}
+	fim := time.Date(ano, mes, dia, referencia.Hour(), 0, 0, 0, referencia.Location())
+	return fim.Add(-duracaoTurno), fim
+}
+
+// ResumoRelatorio são os totais do período
+type ResumoRelatorio struct {
+	AlarmesAbertos            int      `json:"alarmes_abertos"`
+	AlarmesFechados           int      `json:"alarmes_fechados"`
+	FalhasAbertas             int      `json:"falhas_abertas"`
+	EventosAbertos            int      `json:"eventos_abertos"`
+	AtivosNoFim               int      `json:"ativos_no_fim"`
+	NaoReconhecidos           int      `json:"nao_reconhecidos"`
+	TempoParadoHoras          float64  `json:"tempo_parado_horas"`
+	DisponibilidadePercentual float64  `json:"disponibilidade_percentual"`
+	MTBFHoras                 *float64 `json:"mtbf_horas,omitempty"`
+	MTTRHoras                 *float64 `json:"mttr_horas,omitempty"`
+}
+
+// LinhaFalha é uma falha agregada no período
+type LinhaFalha struct {
+	Codigo           string
+	Descricao        string
+	Setor            string
+	Ocorrencias      int
+	TempoParadoHoras float64
+}
+
+// LinhaParagem é uma ocorrência de falha com a duração recortada ao período
+type LinhaParagem struct {
+	Codigo       string
+	Descricao    string
+	Setor        string
+	Prioridade   string
+	Inicio       time.Time
+	Fim          *time.Time // nil = ainda ativa no fim do período
+	DuracaoHoras float64
+}
+
+// LinhaNota é uma nota de operador ou uma observação numa transição de ocorrência
+type LinhaNota struct {
+	DataHora time.Time
+	Autor    string
+	Codigo   string // Ocorrência comentada (vazio nas notas de turno)
+	Texto    string
+}
+
+// DadosRelatorio é o conteúdo de um relatório de uma eclusa num período
+type DadosRelatorio struct {
+	Tipo            string
+	Titulo          string
+	EclusaCodigo    string
+	EclusaNome      string
+	Inicio          time.Time
+	Fim             time.Time
+	GeradoEm        time.Time
+	Resumo          ResumoRelatorio
+	TopFalhas       []LinhaFalha
+	MaioresParagens []LinhaParagem
+	NaoReconhecidas []LinhaParagem
+	Notas           []LinhaNota
+}
+
+// ColetarDados reúne os números do relatório. Ocorrências que atravessam os limites do período
+// contam só a parte dentro dele; "não reconhecidas" são as falhas ativas no fim do período sem
+// nenhuma ação de operador.
+func ColetarDados(db *sql.DB, tipo, eclusa string, inicio, fim, agora time.Time) (*DadosRelatorio, error) {
+	eclusa = strings.ToUpper(eclusa)
+	dados := &DadosRelatorio{
+		Tipo:         tipo,
+		Titulo:       tituloRelatorio(tipo),
+		EclusaCodigo: eclusa,
+		Inicio:       inicio,
+		Fim:          fim,
+		GeradoEm:     agora,
+	}
+
+	err := db.QueryRow("SELECT nome FROM eclusas WHERE codigo = $1", eclusa).Scan(&dados.EclusaNome)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("eclusa não encontrada: %s", eclusa)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar eclusa: %v", err)
+	}
+
+	// O instante de corte das ocorrências em curso: o fim do período ou agora, se ainda não terminou
+	corte := fim
+	if agora.Before(corte) {
+		corte = agora
+	}
+
+	err = db.QueryRow(`
+		SELECT
+			COUNT(*) FILTER (WHERE o.timestamp_inicio >= $2::timestamp AND o.timestamp_inicio < $3::timestamp),
+			COUNT(*) FILTER (WHERE o.timestamp_fim >= $2::timestamp AND o.timestamp_fim < $3::timestamp),
+			COUNT(*) FILTER (WHERE df.tipo = 'FALHA' AND o.timestamp_inicio >= $2::timestamp AND o.timestamp_inicio < $3::timestamp),
+			COUNT(*) FILTER (WHERE df.tipo = 'EVENTO' AND o.timestamp_inicio >= $2::timestamp AND o.timestamp_inicio < $3::timestamp),
+			COUNT(*) FILTER (WHERE o.timestamp_inicio < $4::timestamp AND (o.timestamp_fim IS NULL OR o.timestamp_fim > $4::timestamp))
+		FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		JOIN eclusas e ON df.eclusa_id = e.id
+		WHERE e.codigo = $1
+		AND o.timestamp_inicio < $3::timestamp
+		AND (o.timestamp_fim IS NULL OR o.timestamp_fim >= $2::timestamp)
+		AND `+condicaoForaJanelaOculta,
+		eclusa, inicio, fim, corte).Scan(&dados.Resumo.AlarmesAbertos, &dados.Resumo.AlarmesFechados,
+		&dados.Resumo.FalhasAbertas, &dados.Resumo.EventosAbertos, &dados.Resumo.AtivosNoFim)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar ocorrências do período: %v", err)
+	}
+
+	confiabilidade, err := CalcularConfiabilidade(db, Filtros{Inicio: inicio, Fim: fim, Eclusa: eclusa}, agora)
+	if err != nil {
+		return nil, err
+	}
+	for _, ind := range confiabilidade.PorEclusa {
+		if ind.Eclusa == eclusa {
+			dados.Resumo.TempoParadoHoras = ind.TempoParadoHoras
+			dados.Resumo.DisponibilidadePercentual = ind.DisponibilidadePercentual
+			dados.Resumo.MTBFHoras = ind.MTBFHoras
+			dados.Resumo.MTTRHoras = ind.MTTRHoras
+		}
+	}
+
+	falhas := append([]Indicadores(nil), confiabilidade.PorFalha...)
+	sort.SliceStable(falhas, func(a, b int) bool {
+		if falhas[a].Ocorrencias != falhas[b].Ocorrencias {
+			return falhas[a].Ocorrencias > falhas[b].Ocorrencias
+		}
+		return falhas[a].TempoParadoHoras > falhas[b].TempoParadoHoras
+	})
+	for i, ind := range falhas {
+		if i == linhasTopo {
+			break
+		}
+		dados.TopFalhas = append(dados.TopFalhas, LinhaFalha{
+			Codigo:           ind.Codigo,
+			Descricao:        ind.Descricao,
+			Setor:            ind.SetorNome,
+			Ocorrencias:      ind.Ocorrencias,
+			TempoParadoHoras: ind.TempoParadoHoras,
+		})
+	}
+
+	dados.MaioresParagens, err = buscarParagens(db, `
+		AND o.timestamp_inicio < $3::timestamp
+		AND (o.timestamp_fim IS NULL OR o.timestamp_fim > $2::timestamp)
+		AND o.suprimida_por IS NULL
+		ORDER BY duracao DESC
+		LIMIT `+fmt.Sprint(linhasTopo), eclusa, inicio, corte)
+	if err != nil {
+		return nil, err
+	}
+
+	dados.NaoReconhecidas, err = buscarParagens(db, `
+		AND o.timestamp_inicio < $3::timestamp
+		AND (o.timestamp_fim IS NULL OR o.timestamp_fim > $3::timestamp)
+		AND o.suprimida_por IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM transicoes_ocorrencias t
+			WHERE t.ocorrencia_id = o.id AND t.origem <> 'PLC' AND t.timestamp <= $3::timestamp
+		)
+		ORDER BY `+expressaoPesoPrioridade+` DESC, o.timestamp_inicio`, eclusa, inicio, corte)
+	if err != nil {
+		return nil, err
+	}
+	dados.Resumo.NaoReconhecidos = len(dados.NaoReconhecidas)
+
+	dados.Notas, err = buscarNotas(db, eclusa, inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+
+	return dados, nil
+}
+
+// expressaoPesoPrioridade ordena ALTA antes de MEDIA e BAIXA
+const expressaoPesoPrioridade = `CASE df.prioridade WHEN 'ALTA' THEN 3 WHEN 'MEDIA' THEN 2 ELSE 1 END`
+
+// buscarParagens lê falhas da eclusa com a duração recortada a [$2, $3]; condicoes completa o WHERE
+func buscarParagens(db *sql.DB, condicoes, eclusa string, inicio, corte time.Time) ([]LinhaParagem, error) {
+	rows, err := db.Query(`
+		SELECT df.codigo, df.descricao, s.nome, df.prioridade, o.timestamp_inicio,
+			CASE WHEN o.timestamp_fim <= $3::timestamp THEN o.timestamp_fim END,
+			EXTRACT(EPOCH FROM (
+				LEAST(COALESCE(o.timestamp_fim, $3::timestamp), $3::timestamp)
+				- GREATEST(o.timestamp_inicio, $2::timestamp)
+			)) / 3600 AS duracao
+		FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		JOIN setores s ON df.setor_id = s.id
+		JOIN eclusas e ON df.eclusa_id = e.id
+		WHERE e.codigo = $1 AND df.tipo = 'FALHA'
+		AND `+condicaoForaJanelaOculta+condicoes, eclusa, inicio, corte)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar paragens: %v", err)
+	}
+	defer rows.Close()
+
+	paragens := []LinhaParagem{}
+	for rows.Next() {
+		var p LinhaParagem
+		var fim sql.NullTime
+		if err := rows.Scan(&p.Codigo, &p.Descricao, &p.Setor, &p.Prioridade, &p.Inicio, &fim, &p.DuracaoHoras); err != nil {
+			return nil, fmt.Errorf("erro ao ler paragem: %v", err)
+		}
+		p.Inicio = database.HoraLocal(p.Inicio)
+		if fim.Valid {
+			t := database.HoraLocal(fim.Time)
+			p.Fim = &t
+		}
+		paragens = append(paragens, p)
+	}
+	return paragens, rows.Err()
+}
+
+// buscarNotas junta as notas de turno e as observações das transições feitas no período
+func buscarNotas(db *sql.DB, eclusa string, inicio, fim time.Time) ([]LinhaNota, error) {
+	rows, err := db.Query(`
+		SELECT n.timestamp, n.autor, '', n.texto
+		FROM notas_operador n
+		JOIN eclusas e ON n.eclusa_id = e.id
+		WHERE e.codigo = $1 AND n.timestamp >= $2 AND n.timestamp < $3
+		UNION ALL
+		SELECT t.timestamp, t.origem, df.codigo, t.observacao
+		FROM transicoes_ocorrencias t
+		JOIN ocorrencias_falhas o ON t.ocorrencia_id = o.id
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		JOIN eclusas e ON df.eclusa_id = e.id
+		WHERE e.codigo = $1 AND t.timestamp >= $2 AND t.timestamp < $3
+		AND t.observacao IS NOT NULL AND t.observacao <> ''
+		ORDER BY 1`, eclusa, inicio, fim)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar notas do período: %v", err)
+	}
+	defer rows.Close()
+
+	notas := []LinhaNota{}
+	for rows.Next() {
+		var n LinhaNota
+		if err := rows.Scan(&n.DataHora, &n.Autor, &n.Codigo, &n.Texto); err != nil {
+			return nil, fmt.Errorf("erro ao ler nota: %v", err)
+		}
+		n.DataHora = database.HoraLocal(n.DataHora)
+		notas = append(notas, n)
+	}
+	return notas, rows.Err()
+}
+
+// tituloRelatorio devolve o título apresentado no cabeçalho
+func tituloRelatorio(tipo string) string {
+	switch tipo {
+	case TipoTurno:
+		return "Relatório de Turno"
+	case TipoDiario:
+		return "Relatório Diário"
+	case TipoMensal:
+		return "Relatório Mensal"
+	}
+	return "Relatório"
+}
@@ -0,0 +1,96 @@
+package relatorios
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/relatorio.html templates/relatorio.txt
+var arquivosTemplates embed.FS
+
+// formatoDataHora é o formato pt-PT das datas nos relatórios
+const formatoDataHora = "02/01/2006 15:04"
+
+// funcoesTemplates são partilhadas pelos modelos HTML e texto (PDF)
+var funcoesTemplates = map[string]interface{}{
+	"data":          formatarData,
+	"horas":         formatarHoras,
+	"horasOpcional": formatarHorasOpcional,
+	"percentual":    func(valor float64) string { return strings.Replace(fmt.Sprintf("%.2f %%", valor), ".", ",", 1) },
+	"cortar":        cortarTexto,
+	"fimOuAtiva": func(fim *time.Time) string {
+		if fim == nil {
+			return "ativa"
+		}
+		return fim.Format("02/01 15:04")
+	},
+}
+
+var (
+	templateHTML  = htmltemplate.Must(htmltemplate.New("relatorio.html").Funcs(funcoesTemplates).ParseFS(arquivosTemplates, "templates/relatorio.html"))
+	templateTexto = template.Must(template.New("relatorio.txt").Funcs(funcoesTemplates).ParseFS(arquivosTemplates, "templates/relatorio.txt"))
+)
+
+// RenderizarHTML gera o relatório em HTML
+func RenderizarHTML(dados *DadosRelatorio) (string, error) {
+	var saida bytes.Buffer
+	if err := templateHTML.Execute(&saida, dados); err != nil {
+		return "", fmt.Errorf("erro ao renderizar relatório HTML: %v", err)
+	}
+	return saida.String(), nil
+}
+
+// RenderizarPDF gera o relatório em PDF a partir do modelo de texto
+func RenderizarPDF(dados *DadosRelatorio) ([]byte, error) {
+	var saida bytes.Buffer
+	if err := templateTexto.Execute(&saida, dados); err != nil {
+		return nil, fmt.Errorf("erro ao renderizar relatório PDF: %v", err)
+	}
+
+	titulo := fmt.Sprintf("%s - %s - %s", dados.Titulo, dados.EclusaNome, formatarData(dados.Inicio))
+	return gerarPDF(titulo, strings.Split(strings.TrimRight(saida.String(), "\n"), "\n"), dados.GeradoEm), nil
+}
+
+// formatarData aceita time.Time ou *time.Time (vazio quando nil)
+func formatarData(valor interface{}) string {
+	switch t := valor.(type) {
+	case time.Time:
+		return t.Format(formatoDataHora)
+	case *time.Time:
+		if t != nil {
+			return t.Format(formatoDataHora)
+		}
+	}
+	return ""
+}
+
+// formatarHoras mostra durações como "3h 25m" (ou "12m" abaixo de uma hora)
+func formatarHoras(horas float64) string {
+	minutos := int(horas*60 + 0.5)
+	if minutos < 60 {
+		return fmt.Sprintf("%dm", minutos)
+	}
+	return fmt.Sprintf("%dh %02dm", minutos/60, minutos%60)
+}
+
+// formatarHorasOpcional mostra "-" quando o indicador não se aplica
+func formatarHorasOpcional(horas *float64) string {
+	if horas == nil {
+		return "-"
+	}
+	return formatarHoras(*horas)
+}
+
+// cortarTexto limita o texto às colunas da tabela do PDF
+func cortarTexto(texto string, tamanho int) string {
+	runas := []rune(texto)
+	if len(runas) <= tamanho {
+		return texto
+	}
+	return string(runas[:tamanho-1]) + "…"
+}
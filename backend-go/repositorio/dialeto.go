@@ -0,0 +1,140 @@
+package repositorio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formatoDataHoraSQLite é o formato das datas gravadas no SQLite (hora local, comparável como texto)
+const formatoDataHoraSQLite = "2006-01-02 15:04:05.000"
+
+// dialeto reúne as diferenças de SQL entre o PostgreSQL e o SQLite. As queries são escritas com
+// marcadores '?' e as expressões abaixo; 'sql' converte os marcadores quando o banco pede $1, $2...
+type dialeto struct {
+	numerados       bool                        // Marcadores $1, $2... em vez de ?
+	agora           string                      // Data/hora atual (hora local)
+	duracaoSegundos string                      // Duração da ocorrência (em curso conta até agora)
+	slaViolado      string                      // A ocorrência ultrapassou o tempo de resposta
+	busca           string                      // Procura de texto na descrição da definição
+	json            string                      // Conversão do texto do contexto para a coluna
+	valorDataHora   func(time.Time) interface{} // Valor gravado numa coluna TIMESTAMP
+	literalDataHora func(time.Time) string      // Data/hora fixa escrita na query (troca 'agora')
+	horasAtras      func(horas int) string      // Data/hora atual menos as horas indicadas
+}
+
+// postgres é o dialeto do PostgreSQL (datas gravadas tal como chegam, como no resto do backend)
+var postgres = dialeto{
+	numerados:       true,
+	agora:           "NOW()",
+	duracaoSegundos: `EXTRACT(EPOCH FROM (COALESCE(o.timestamp_fim, NOW()) - o.timestamp_inicio))`,
+	slaViolado: `(df.tempo_resposta_minutos IS NOT NULL AND
+	COALESCE(o.timestamp_fim, NOW()) - o.timestamp_inicio > df.tempo_resposta_minutos * INTERVAL '1 minute')`,
+	// Usa o índice GIN idx_definicoes_descricao_fts
+	busca:         "to_tsvector('portuguese', df.descricao) @@ plainto_tsquery('portuguese', ?)",
+	json:          "NULLIF(?, '')::jsonb",
+	valorDataHora: func(t time.Time) interface{} { return t },
+	literalDataHora: func(t time.Time) string {
+		return "'" + t.Format("2006-01-02 15:04:05.999999") + "'::timestamp"
+	},
+	horasAtras: func(horas int) string { return fmt.Sprintf("NOW() - INTERVAL '%d hours'", horas) },
+}
+
+// sqlite é o dialeto do modo embutido (datas em texto, hora local)
+var sqlite = dialeto{
+	agora: `strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime')`,
+	duracaoSegundos: `((julianday(COALESCE(o.timestamp_fim, strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime')))
+	- julianday(o.timestamp_inicio)) * 86400.0)`,
+	slaViolado: `(df.tempo_resposta_minutos IS NOT NULL AND
+	(julianday(COALESCE(o.timestamp_fim, strftime('%Y-%m-%d %H:%M:%f', 'now', 'localtime')))
+	- julianday(o.timestamp_inicio)) * 1440.0 > df.tempo_resposta_minutos)`,
+	busca: "df.descricao LIKE '%' || ? || '%'",
+	json:  "NULLIF(?, '')",
+	valorDataHora: func(t time.Time) interface{} {
+		return t.In(time.Local).Format(formatoDataHoraSQLite)
+	},
+	literalDataHora: func(t time.Time) string {
+		return "'" + t.In(time.Local).Format(formatoDataHoraSQLite) + "'"
+	},
+	horasAtras: func(horas int) string {
+		return fmt.Sprintf("strftime('%%Y-%%m-%%d %%H:%%M:%%f', 'now', 'localtime', '-%d hours')", horas)
+	},
+}
+
+// sql converte os marcadores '?' da query para o formato do banco
+func (d dialeto) sql(query string) string {
+	if !d.numerados {
+		return query
+	}
+	var resultado strings.Builder
+	indice := 1
+	for _, c := range query {
+		if c == '?' {
+			resultado.WriteString("$" + strconv.Itoa(indice))
+			indice++
+			continue
+		}
+		resultado.WriteRune(c)
+	}
+	return resultado.String()
+}
+
+// dataHora lê colunas TIMESTAMP: o PostgreSQL devolve time.Time, o SQLite pode devolver texto
+type dataHora struct {
+	Time  time.Time
+	Valid bool
+}
+
+// Scan implementa sql.Scanner
+func (d *dataHora) Scan(valor interface{}) error {
+	switch v := valor.(type) {
+	case nil:
+		d.Time, d.Valid = time.Time{}, false
+		return nil
+	case time.Time:
+		d.Time, d.Valid = v, true
+		return nil
+	case []byte:
+		return d.lerTexto(string(v))
+	case string:
+		return d.lerTexto(v)
+	}
+	return fmt.Errorf("data/hora com tipo inesperado: %T", valor)
+}
+
+// formatosTexto são os formatos de data/hora aceites quando o banco devolve texto
+var formatosTexto = []string{formatoDataHoraSQLite, "2006-01-02 15:04:05", time.RFC3339Nano, "2006-01-02T15:04:05"}
+
+func (d *dataHora) lerTexto(texto string) error {
+	for _, formato := range formatosTexto {
+		if t, err := time.ParseInLocation(formato, texto, time.Local); err == nil {
+			d.Time, d.Valid = t, true
+			return nil
+		}
+	}
+	return fmt.Errorf("data/hora inválida: %q", texto)
+}
+
+// ponteiro devolve nil quando a coluna é NULL
+func (d dataHora) ponteiro() *time.Time {
+	if !d.Valid {
+		return nil
+	}
+	t := d.Time
+	return &t
+}
+
+// marcadores devolve "?, ?, ..." para uma lista IN com n valores
+func marcadores(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// argumentosTexto converte uma lista de textos em argumentos da query
+func argumentosTexto(valores []string) []interface{} {
+	args := make([]interface{}, len(valores))
+	for i, v := range valores {
+		args[i] = v
+	}
+	return args
+}
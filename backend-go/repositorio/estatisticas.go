@@ -0,0 +1,135 @@
+package repositorio
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// condicaoOcorrenciaNaEclusa limita as ocorrências (alias o) às definições da eclusa no marcador
+const condicaoOcorrenciaNaEclusa = `o.definicao_id IN (
+			SELECT dfe.id FROM definicoes_falhas dfe JOIN eclusas ee ON dfe.eclusa_id = ee.id WHERE ee.codigo = ?)`
+
+// limiteFalhasFrequentes é o tamanho do top de falhas frequentes (últimos 7 dias)
+const limiteFalhasFrequentes = 10
+
+type estatisticasSQL struct {
+	db *sql.DB
+	d  dialeto
+}
+
+// Dashboard resume as ocorrências da eclusa informada (vazio = todas)
+func (r *estatisticasSQL) Dashboard(eclusa string) (EstatisticasDashboard, error) {
+	stats := EstatisticasDashboard{
+		PorSetor:      make(map[string]int),
+		PorPrioridade: make(map[string]int),
+	}
+
+	// Filtro opcional por eclusa, aplicado a todos os indicadores
+	condicoes := " AND " + condicaoForaJanelaOculta
+	var args []interface{}
+	if eclusa != "" {
+		condicoes += " AND " + condicaoOcorrenciaNaEclusa
+		args = append(args, eclusa)
+	}
+
+	contar := func(descricao, query string, destino *int) error {
+		if err := r.db.QueryRow(r.d.sql(query+condicoes), args...).Scan(destino); err != nil {
+			return fmt.Errorf("erro ao contar %s: %v", descricao, err)
+		}
+		return nil
+	}
+
+	err := contar("ocorrências ativas", `
+		SELECT COUNT(*) FROM ocorrencias_falhas o
+		WHERE o.status = 'ATIVO'`, &stats.OcorrenciasAtivas)
+	if err != nil {
+		return stats, err
+	}
+	err = contar("falhas das últimas 24h", `
+		SELECT COUNT(*) FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		WHERE df.tipo = 'FALHA' AND o.timestamp_inicio >= `+r.d.horasAtras(24), &stats.FalhasUltimas24h)
+	if err != nil {
+		return stats, err
+	}
+	err = contar("eventos das últimas 24h", `
+		SELECT COUNT(*) FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		WHERE df.tipo = 'EVENTO' AND o.timestamp_inicio >= `+r.d.horasAtras(24), &stats.EventosUltimas24h)
+	if err != nil {
+		return stats, err
+	}
+	err = contar("ocorrências", `
+		SELECT COUNT(*) FROM ocorrencias_falhas o WHERE 1=1`, &stats.TotalOcorrencias)
+	if err != nil {
+		return stats, err
+	}
+
+	// Ativas por setor e por prioridade
+	agrupar := func(descricao, coluna, juncao string, destino map[string]int) error {
+		rows, err := r.db.Query(r.d.sql(`
+		SELECT `+coluna+`, COUNT(o.id)
+		FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id`+juncao+`
+		WHERE o.status = 'ATIVO'`+condicoes+`
+		GROUP BY `+coluna), args...)
+		if err != nil {
+			return fmt.Errorf("erro ao agrupar ocorrências ativas por %s: %v", descricao, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var chave string
+			var total int
+			if err := rows.Scan(&chave, &total); err != nil {
+				return fmt.Errorf("erro ao ler ocorrências ativas por %s: %v", descricao, err)
+			}
+			destino[chave] = total
+		}
+		return rows.Err()
+	}
+
+	if err := agrupar("setor", "s.nome", " JOIN setores s ON df.setor_id = s.id", stats.PorSetor); err != nil {
+		return stats, err
+	}
+	if err := agrupar("prioridade", "df.prioridade", "", stats.PorPrioridade); err != nil {
+		return stats, err
+	}
+
+	// Top falhas frequentes (últimos 7 dias)
+	rows, err := r.db.Query(r.d.sql(`
+		SELECT df.codigo, df.descricao, s.nome, COUNT(o.id) as freq
+		FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		JOIN setores s ON df.setor_id = s.id
+		WHERE o.timestamp_inicio >= `+r.d.horasAtras(7*24)+condicoes+`
+		GROUP BY df.codigo, df.descricao, s.nome
+		ORDER BY freq DESC, df.codigo
+		LIMIT ?`), append(args, limiteFalhasFrequentes)...)
+	if err != nil {
+		return stats, fmt.Errorf("erro ao buscar falhas frequentes: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var falha FalhaFrequente
+		if err := rows.Scan(&falha.Codigo, &falha.Descricao, &falha.SetorNome, &falha.Frequencia); err != nil {
+			return stats, fmt.Errorf("erro ao ler falha frequente: %v", err)
+		}
+		stats.TopFalhasFrequentes = append(stats.TopFalhasFrequentes, falha)
+	}
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("erro ao ler falhas frequentes: %v", err)
+	}
+
+	// Tempo médio de resolução (em horas)
+	var tempoMedio sql.NullFloat64
+	err = r.db.QueryRow(r.d.sql(`
+		SELECT AVG(`+r.d.duracaoSegundos+`) / 3600
+		FROM ocorrencias_falhas o
+		WHERE o.status = 'RESOLVIDO' AND o.timestamp_fim IS NOT NULL`+condicoes), args...).Scan(&tempoMedio)
+	if err != nil {
+		return stats, fmt.Errorf("erro ao calcular tempo médio de resolução: %v", err)
+	}
+	stats.TempoMedioResolucao = tempoMedio.Float64
+
+	return stats, nil
+}
@@ -0,0 +1,63 @@
+package repositorio
+
+import (
+	"log"
+	"time"
+)
+
+// ComFilaSincronizacao devolve os repositórios com as ocorrências abertas, resolvidas e as suas
+// transições acrescentadas à fila de sincronização com o central. Uma falha ao enfileirar só fica
+// no log: a gravação local já foi feita e não deve ser dada como falhada.
+func (r Repositorios) ComFilaSincronizacao() Repositorios {
+	r.Ocorrencias = &ocorrenciasComFila{Ocorrencias: r.Ocorrencias, fila: r.Sincronizacao}
+	return r
+}
+
+type ocorrenciasComFila struct {
+	Ocorrencias
+	fila Sincronizacao
+}
+
+func (r *ocorrenciasComFila) enfileirar(ocorrenciaID int64) {
+	if err := r.fila.EnfileirarOcorrencia(ocorrenciaID); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+// Abrir grava a ocorrência e enfileira-a
+func (r *ocorrenciasComFila) Abrir(nova NovaOcorrencia) (int64, error) {
+	id, err := r.Ocorrencias.Abrir(nova)
+	if err == nil {
+		r.enfileirar(id)
+	}
+	return id, err
+}
+
+// ResolverAtivas resolve as ocorrências e enfileira as resolvidas
+func (r *ocorrenciasComFila) ResolverAtivas(definicaoID int, dataHora time.Time) ([]int64, error) {
+	resolvidas, err := r.Ocorrencias.ResolverAtivas(definicaoID, dataHora)
+	for _, id := range resolvidas {
+		r.enfileirar(id)
+	}
+	return resolvidas, err
+}
+
+// ResolverManualmente resolve a ocorrência e enfileira-a se estava ativa
+func (r *ocorrenciasComFila) ResolverManualmente(id int64) (bool, error) {
+	resolvida, err := r.Ocorrencias.ResolverManualmente(id)
+	if resolvida {
+		r.enfileirar(id)
+	}
+	return resolvida, err
+}
+
+// RegistrarTransicao grava a transição e enfileira-a
+func (r *ocorrenciasComFila) RegistrarTransicao(t Transicao) error {
+	if err := r.Ocorrencias.RegistrarTransicao(t); err != nil {
+		return err
+	}
+	if err := r.fila.EnfileirarTransicao(t); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	return nil
+}
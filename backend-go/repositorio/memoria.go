@@ -0,0 +1,1220 @@
+package repositorio
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+)
+
+// Memoria guarda eclusas, setores, definições e ocorrências em memória, com o mesmo comportamento
+// dos repositórios PostgreSQL. Serve para testar a API e o processador do PLC sem banco.
+type Memoria struct {
+	mutex         sync.Mutex
+	eclusas       []Eclusa
+	setores       []Setor
+	definicoes    []modelos.DefinicaoFalha
+	ocorrencias   []*ocorrenciaMemoria
+	transicoes    []Transicao
+	avalanches    []*avalancheMemoria
+	supressoes    map[int][]int // definição filha → definições pai
+	janelas       []JanelaMemoria
+	fila          []*itemFilaMemoria
+	sequenciaFila int64
+	origens       map[string]*OrigemSincronizacao
+	agora         func() time.Time
+}
+
+// JanelaMemoria é uma janela de manutenção planeada (SetorID 0 = eclusa inteira)
+type JanelaMemoria struct {
+	ID       int64
+	EclusaID int
+	SetorID  int
+	Inicio   time.Time
+	Fim      time.Time
+	Ocultar  bool
+}
+
+type ocorrenciaMemoria struct {
+	id              int64
+	definicaoID     int
+	status          string
+	inicio          time.Time
+	fim             *time.Time
+	resolvidoPor    string
+	firstOut        bool
+	grupoFirstOutID int64
+	avalancheID     int64
+	suprimidaPor    int64
+	janelaID        int64
+	contexto        string
+	observacoes     string
+	origemEclusa    string // Eclusa e ID de origem das ocorrências recebidas por sincronização
+	origemID        int64
+	criacao         time.Time // created_at (o da eclusa nas ocorrências sincronizadas)
+}
+
+type itemFilaMemoria struct {
+	item       ItemSincronizacao
+	tentativas int
+	ultimoErro string
+	criadoEm   time.Time
+	enviadoEm  *time.Time
+}
+
+type avalancheMemoria struct {
+	id           int64
+	eclusaID     int
+	inicio       time.Time
+	fim          *time.Time
+	totalAlarmes int
+}
+
+// NovaMemoria cria um repositório em memória vazio
+func NovaMemoria() *Memoria {
+	return &Memoria{
+		supressoes: make(map[int][]int),
+		origens:    make(map[string]*OrigemSincronizacao),
+		agora:      time.Now,
+	}
+}
+
+// Repositorios devolve os repositórios sobre os dados em memória
+func (m *Memoria) Repositorios() Repositorios {
+	return Repositorios{
+		Ocorrencias: &ocorrenciasMemoria{m},
+		Definicoes:  &definicoesMemoria{m},
+		Eclusas:     &eclusasMemoria{m},
+		Setores:     &setoresMemoria{m},
+
+		Estatisticas: &estatisticasMemoria{m},
+
+		Sincronizacao: &sincronizacaoMemoria{m},
+	}
+}
+
+// DefinirRelogio troca o relógio usado no cálculo do SLA e nas resoluções manuais
+func (m *Memoria) DefinirRelogio(agora func() time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.agora = agora
+}
+
+// AdicionarEclusa acrescenta uma eclusa (ID 0 = próximo livre) e devolve o ID
+func (m *Memoria) AdicionarEclusa(eclusa Eclusa) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if eclusa.ID == 0 {
+		eclusa.ID = len(m.eclusas) + 1
+	}
+	m.eclusas = append(m.eclusas, eclusa)
+	return eclusa.ID
+}
+
+// AdicionarSetor acrescenta um setor (ID 0 = próximo livre) e devolve o ID
+func (m *Memoria) AdicionarSetor(setor Setor) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if setor.ID == 0 {
+		setor.ID = len(m.setores) + 1
+	}
+	m.setores = append(m.setores, setor)
+	return setor.ID
+}
+
+// AdicionarDefinicao acrescenta uma definição (ID 0 = próximo livre) e devolve o ID. Os códigos e
+// nomes do setor e da eclusa são preenchidos a partir de SetorID e EclusaID.
+func (m *Memoria) AdicionarDefinicao(definicao modelos.DefinicaoFalha) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if definicao.ID == 0 {
+		definicao.ID = len(m.definicoes) + 1
+	}
+	if setor, existe := m.setor(definicao.SetorID); existe {
+		definicao.SetorCodigo, definicao.SetorNome = setor.Codigo, setor.Nome
+	}
+	if eclusa, existe := m.eclusa(definicao.EclusaID); existe {
+		definicao.EclusaCodigo = eclusa.Codigo
+	}
+	m.definicoes = append(m.definicoes, definicao)
+	return definicao.ID
+}
+
+// AdicionarSupressao configura a definição pai como causa raiz da definição filha
+func (m *Memoria) AdicionarSupressao(definicaoPaiID, definicaoFilhaID int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.supressoes[definicaoFilhaID] = append(m.supressoes[definicaoFilhaID], definicaoPaiID)
+}
+
+// AdicionarJanela acrescenta uma janela de manutenção planeada
+func (m *Memoria) AdicionarJanela(janela JanelaMemoria) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.janelas = append(m.janelas, janela)
+}
+
+// Transicoes devolve as transições gravadas para a ocorrência, pela ordem de gravação
+func (m *Memoria) Transicoes(ocorrenciaID int64) []Transicao {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var transicoes []Transicao
+	for _, t := range m.transicoes {
+		if t.OcorrenciaID == ocorrenciaID {
+			transicoes = append(transicoes, t)
+		}
+	}
+	return transicoes
+}
+
+func (m *Memoria) eclusa(id int) (Eclusa, bool) {
+	for _, e := range m.eclusas {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Eclusa{}, false
+}
+
+// codigoEclusa devolve o código da eclusa (vazio se não existe)
+func (m *Memoria) codigoEclusa(id int) string {
+	eclusa, _ := m.eclusa(id)
+	return eclusa.Codigo
+}
+
+func (m *Memoria) setor(id int) (Setor, bool) {
+	for _, s := range m.setores {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Setor{}, false
+}
+
+func (m *Memoria) definicao(id int) (modelos.DefinicaoFalha, bool) {
+	for _, d := range m.definicoes {
+		if d.ID == id {
+			return d, true
+		}
+	}
+	return modelos.DefinicaoFalha{}, false
+}
+
+func (m *Memoria) ocorrencia(id int64) *ocorrenciaMemoria {
+	for _, o := range m.ocorrencias {
+		if o.id == id {
+			return o
+		}
+	}
+	return nil
+}
+
+// slaViolado replica expressaoSLAViolado
+func slaViolado(d modelos.DefinicaoFalha, o *ocorrenciaMemoria, agora time.Time) bool {
+	if d.TempoRespostaMinutos <= 0 {
+		return false
+	}
+	fim := agora
+	if o.fim != nil {
+		fim = *o.fim
+	}
+	return fim.Sub(o.inicio) > time.Duration(d.TempoRespostaMinutos)*time.Minute
+}
+
+// ordemPrioridade replica expressaoOrdemPrioridade (ALTA primeiro)
+func ordemPrioridade(prioridade string) int {
+	switch prioridade {
+	case "ALTA":
+		return 1
+	case "MEDIA":
+		return 2
+	}
+	return 3
+}
+
+type ocorrenciasMemoria struct {
+	*Memoria
+}
+
+// ListarAtivas devolve as ocorrências ativas com definição, setor e eclusa
+func (r *ocorrenciasMemoria) ListarAtivas(filtro FiltroAtivas) ([]Ocorrencia, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	agora := r.agora()
+
+	ocorrencias := []Ocorrencia{}
+	for _, o := range r.ocorrencias {
+		if o.status != "ATIVO" {
+			continue
+		}
+		if !filtro.IncluirSuprimidas {
+			if pai := r.ocorrencia(o.suprimidaPor); pai != nil && pai.status == "ATIVO" {
+				continue
+			}
+		}
+		if !filtro.IncluirManutencao && r.janelaOculta(o.janelaID) {
+			continue
+		}
+
+		d, _ := r.definicao(o.definicaoID)
+		if filtro.Eclusa != "" && r.codigoEclusa(d.EclusaID) != filtro.Eclusa {
+			continue
+		}
+		if len(filtro.Prioridades) > 0 && !contem(filtro.Prioridades, d.Prioridade) {
+			continue
+		}
+		violado := slaViolado(d, o, agora)
+		if filtro.SLAViolado != nil && *filtro.SLAViolado != violado {
+			continue
+		}
+
+		ocorrencias = append(ocorrencias, r.montarOcorrencia(d, o, violado))
+	}
+
+	ordenarAtivas(ocorrencias, filtro.Ordenar)
+	return ocorrencias, nil
+}
+
+// montarOcorrencia junta a ocorrência com a definição, o setor e a eclusa
+func (r *ocorrenciasMemoria) montarOcorrencia(d modelos.DefinicaoFalha, o *ocorrenciaMemoria, violado bool) Ocorrencia {
+	oc := Ocorrencia{
+		ID:                   o.id,
+		Status:               o.status,
+		TimestampInicio:      o.inicio,
+		TimestampFim:         o.fim,
+		DefinicaoID:          d.ID,
+		Codigo:               d.Codigo,
+		Tipo:                 d.Tipo,
+		Descricao:            d.Descricao,
+		Prioridade:           d.Prioridade,
+		WordIndex:            d.WordIndex,
+		BitIndex:             d.BitIndex,
+		ClasseMensagem:       d.ClasseMensagem,
+		Criticidade:          d.Criticidade,
+		RelacionadaSeguranca: d.RelacionadaSeguranca,
+		SLAViolado:           violado,
+		FirstOut:             o.firstOut,
+		GrupoFirstOutID:      ponteiroSeNaoZero(o.grupoFirstOutID),
+		AvalancheID:          ponteiroSeNaoZero(o.avalancheID),
+		SuprimidaPor:         ponteiroSeNaoZero(o.suprimidaPor),
+		JanelaManutencaoID:   ponteiroSeNaoZero(o.janelaID),
+		ResolvidoPor:         o.resolvidoPor,
+		Observacoes:          o.observacoes,
+	}
+	if d.TempoRespostaMinutos > 0 {
+		minutos := d.TempoRespostaMinutos
+		oc.TempoRespostaMinutos = &minutos
+	}
+	if setor, existe := r.setor(d.SetorID); existe {
+		oc.SetorCodigo, oc.SetorNome = setor.Codigo, setor.Nome
+	}
+	if eclusa, existe := r.eclusa(d.EclusaID); existe {
+		oc.EclusaCodigo, oc.EclusaNome = eclusa.Codigo, eclusa.Nome
+	}
+	for _, filha := range r.ocorrencias {
+		if filha.suprimidaPor == o.id && filha.status == "ATIVO" {
+			oc.TotalSuprimidas++
+		}
+	}
+	return oc
+}
+
+// janelaOculta indica se a janela existe e oculta as suas ocorrências
+func (r *ocorrenciasMemoria) janelaOculta(id int64) bool {
+	for _, j := range r.janelas {
+		if j.ID == id {
+			return j.Ocultar
+		}
+	}
+	return false
+}
+
+// ordenarAtivas replica as ordenações do PostgreSQL (vazio = first-out primeiro, mais recentes)
+func ordenarAtivas(ocorrencias []Ocorrencia, ordenar string) {
+	recente := func(a, b Ocorrencia) bool {
+		if !a.TimestampInicio.Equal(b.TimestampInicio) {
+			return a.TimestampInicio.After(b.TimestampInicio)
+		}
+		return a.ID > b.ID
+	}
+	porPrioridade := func(a, b Ocorrencia) (bool, bool) {
+		pa, pb := ordemPrioridade(a.Prioridade), ordemPrioridade(b.Prioridade)
+		return pa < pb, pa != pb
+	}
+
+	sort.SliceStable(ocorrencias, func(i, j int) bool {
+		a, b := ocorrencias[i], ocorrencias[j]
+		switch ordenar {
+		case "inicio":
+			return recente(a, b)
+		case "prioridade":
+			if menor, difere := porPrioridade(a, b); difere {
+				return menor
+			}
+			if a.Criticidade != b.Criticidade {
+				return a.Criticidade > b.Criticidade
+			}
+			return recente(a, b)
+		case "criticidade":
+			if a.Criticidade != b.Criticidade {
+				return a.Criticidade > b.Criticidade
+			}
+			if menor, difere := porPrioridade(a, b); difere {
+				return menor
+			}
+			return recente(a, b)
+		case "sla":
+			if a.SLAViolado != b.SLAViolado {
+				return a.SLAViolado
+			}
+			if menor, difere := porPrioridade(a, b); difere {
+				return menor
+			}
+			return recente(a, b)
+		}
+		if a.FirstOut != b.FirstOut {
+			return a.FirstOut
+		}
+		return recente(a, b)
+	})
+}
+
+// ListarHistorico devolve uma página do histórico pela ordenação do filtro (desempate por início e ID)
+func (r *ocorrenciasMemoria) ListarHistorico(filtro FiltroHistorico) (PaginaHistorico, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if filtro.Ordenar != "" && !contem(OrdenacoesHistorico, filtro.Ordenar) {
+		return PaginaHistorico{}, fmt.Errorf("ordenação inválida: %s", filtro.Ordenar)
+	}
+	referencia := filtro.Referencia
+	if referencia.IsZero() {
+		referencia = r.agora()
+	}
+	var apos posicaoMemoria
+	if filtro.Apos != nil {
+		apos = posicaoMemoria{inicio: filtro.Apos.Inicio, id: filtro.Apos.ID}
+		if filtro.Apos.Chave != "" {
+			chave, err := strconv.ParseFloat(filtro.Apos.Chave, 64)
+			if err != nil {
+				return PaginaHistorico{}, fmt.Errorf("posição do histórico inválida: %v", err)
+			}
+			apos.chave = chave
+		}
+	}
+
+	ocorrencias := r.filtrarHistorico(filtro)
+	posicoes := make(map[int64]posicaoMemoria, len(ocorrencias))
+	for _, oc := range ocorrencias {
+		posicoes[oc.ID] = posicaoMemoria{chaveHistorico(oc, filtro.Ordenar, referencia), oc.TimestampInicio, oc.ID}
+	}
+	// Sentido da paginação: cada linha seguinte é anterior (-1) ou posterior (1) à anterior
+	sentido := -1
+	if filtro.Ascendente {
+		sentido = 1
+	}
+	sort.SliceStable(ocorrencias, func(i, j int) bool {
+		return posicoes[ocorrencias[j].ID].comparar(posicoes[ocorrencias[i].ID]) == sentido
+	})
+
+	pagina := PaginaHistorico{Ocorrencias: []Ocorrencia{}}
+	for _, oc := range ocorrencias {
+		posicao := posicoes[oc.ID]
+		if filtro.Apos != nil && posicao.comparar(apos) != sentido {
+			continue
+		}
+		if filtro.Limite > 0 && len(pagina.Ocorrencias) == filtro.Limite {
+			ultima := posicoes[pagina.Ocorrencias[len(pagina.Ocorrencias)-1].ID]
+			pagina.Proxima = ultima.posicaoHistorico(filtro.Ordenar)
+			break
+		}
+		pagina.Ocorrencias = append(pagina.Ocorrencias, oc)
+	}
+	return pagina, nil
+}
+
+// PercorrerHistorico entrega as ocorrências do histórico (fora do lock: visitar pode usar o repositório)
+func (r *ocorrenciasMemoria) PercorrerHistorico(filtro FiltroHistorico, visitar func(Ocorrencia) error) error {
+	filtro.Apos, filtro.Limite = nil, 0
+	pagina, err := r.ListarHistorico(filtro)
+	if err != nil {
+		return err
+	}
+	for _, oc := range pagina.Ocorrencias {
+		if err := visitar(oc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ContarHistorico conta as ocorrências do histórico que atendem aos filtros
+func (r *ocorrenciasMemoria) ContarHistorico(filtro FiltroHistorico) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.filtrarHistorico(filtro)), nil
+}
+
+// filtrarHistorico replica as condições do histórico em SQL (sem a posição)
+func (r *ocorrenciasMemoria) filtrarHistorico(filtro FiltroHistorico) []Ocorrencia {
+	agora := r.agora()
+	ocorrencias := []Ocorrencia{}
+	for _, o := range r.ocorrencias {
+		d, _ := r.definicao(o.definicaoID)
+		oc := r.montarOcorrencia(d, o, slaViolado(d, o, agora))
+		fim := agora
+		if o.fim != nil {
+			fim = *o.fim
+		}
+		duracao := int64(fim.Sub(o.inicio).Seconds())
+		oc.DuracaoSegundos = &duracao
+
+		if (filtro.Inicio != nil && o.inicio.Before(*filtro.Inicio)) ||
+			(filtro.Fim != nil && !o.inicio.Before(*filtro.Fim)) ||
+			(filtro.Eclusa != "" && oc.EclusaCodigo != filtro.Eclusa) ||
+			(filtro.Setor != "" && oc.SetorCodigo != filtro.Setor) ||
+			(filtro.Tipo != "" && oc.Tipo != filtro.Tipo) ||
+			(filtro.Status != "" && oc.Status != filtro.Status) ||
+			(filtro.Codigo != "" && oc.Codigo != filtro.Codigo) ||
+			(filtro.Busca != "" && !strings.Contains(strings.ToLower(oc.Descricao), strings.ToLower(filtro.Busca))) ||
+			(filtro.DuracaoMinima > 0 && duracao < filtro.DuracaoMinima) ||
+			(filtro.Manutencao != nil && *filtro.Manutencao != (o.janelaID != 0)) ||
+			(len(filtro.Prioridades) > 0 && !contem(filtro.Prioridades, oc.Prioridade)) ||
+			(filtro.SLAViolado != nil && *filtro.SLAViolado != oc.SLAViolado) {
+			continue
+		}
+		ocorrencias = append(ocorrencias, oc)
+	}
+	return ocorrencias
+}
+
+// posicaoMemoria é a posição de uma linha do histórico: chave de ordenação, início e ID
+type posicaoMemoria struct {
+	chave  float64
+	inicio time.Time
+	id     int64
+}
+
+// comparar compara com outra posição: -1 antes, 0 igual, 1 depois
+func (p posicaoMemoria) comparar(outra posicaoMemoria) int {
+	switch {
+	case p.chave != outra.chave:
+		if p.chave < outra.chave {
+			return -1
+		}
+		return 1
+	case !p.inicio.Equal(outra.inicio):
+		if p.inicio.Before(outra.inicio) {
+			return -1
+		}
+		return 1
+	case p.id != outra.id:
+		if p.id < outra.id {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+// posicaoHistorico converte a posição para a paginação (sem chave na ordenação por início)
+func (p posicaoMemoria) posicaoHistorico(ordenar string) *PosicaoHistorico {
+	posicao := &PosicaoHistorico{Inicio: p.inicio, ID: p.id}
+	if ordenar != "" && ordenar != "inicio" {
+		posicao.Chave = strconv.FormatFloat(p.chave, 'f', -1, 64)
+	}
+	return posicao
+}
+
+// chaveHistorico replica a chave de ordenação do histórico em SQL, com as ocorrências em curso
+// medidas até à referência (0 na ordenação por início)
+func chaveHistorico(oc Ocorrencia, ordenar string, referencia time.Time) float64 {
+	fim := referencia
+	if oc.TimestampFim != nil {
+		fim = *oc.TimestampFim
+	}
+	switch ordenar {
+	case "duracao":
+		return fim.Sub(oc.TimestampInicio).Seconds()
+	case "prioridade":
+		return float64(4 - ordemPrioridade(oc.Prioridade))
+	case "criticidade":
+		return float64(oc.Criticidade)
+	case "sla":
+		if oc.TempoRespostaMinutos != nil && fim.Sub(oc.TimestampInicio) > time.Duration(*oc.TempoRespostaMinutos)*time.Minute {
+			return 1
+		}
+	}
+	return 0
+}
+
+// ExisteAtiva indica se a definição já tem uma ocorrência ativa
+func (r *ocorrenciasMemoria) ExisteAtiva(definicaoID int) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, o := range r.ocorrencias {
+		if o.definicaoID == definicaoID && o.status == "ATIVO" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Abrir grava uma nova ocorrência ATIVO e devolve o seu ID
+func (r *ocorrenciasMemoria) Abrir(nova NovaOcorrencia) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	o := &ocorrenciaMemoria{
+		id:              int64(len(r.ocorrencias) + 1),
+		definicaoID:     nova.DefinicaoID,
+		status:          "ATIVO",
+		inicio:          nova.Inicio,
+		firstOut:        nova.FirstOut,
+		grupoFirstOutID: nova.GrupoFirstOutID,
+		avalancheID:     nova.AvalancheID,
+		suprimidaPor:    nova.SuprimidaPor,
+		janelaID:        nova.JanelaManutencaoID,
+		contexto:        nova.Contexto,
+		criacao:         r.agora(),
+	}
+	r.ocorrencias = append(r.ocorrencias, o)
+	return o.id, nil
+}
+
+// ResolverAtivas resolve (pelo PLC) as ocorrências ativas da definição e devolve os IDs resolvidos
+func (r *ocorrenciasMemoria) ResolverAtivas(definicaoID int, dataHora time.Time) ([]int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var resolvidas []int64
+	for _, o := range r.ocorrencias {
+		if o.definicaoID == definicaoID && o.status == "ATIVO" {
+			fim := dataHora
+			o.status, o.fim, o.resolvidoPor = "RESOLVIDO", &fim, "PLC"
+			resolvidas = append(resolvidas, o.id)
+		}
+	}
+	return resolvidas, nil
+}
+
+// ResolverManualmente resolve uma ocorrência ativa pelo utilizador; false se não estava ativa
+func (r *ocorrenciasMemoria) ResolverManualmente(id int64) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	o := r.ocorrencia(id)
+	if o == nil || o.status != "ATIVO" {
+		return false, nil
+	}
+	fim := r.agora()
+	o.status, o.fim, o.resolvidoPor = "RESOLVIDO", &fim, "USUARIO_MANUAL"
+	return true, nil
+}
+
+// RegistrarTransicao grava uma mudança de estado de ocorrência
+func (r *ocorrenciasMemoria) RegistrarTransicao(t Transicao) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if t.Timestamp.IsZero() {
+		t.Timestamp = r.agora()
+	}
+	r.transicoes = append(r.transicoes, t)
+	return nil
+}
+
+// BuscarCausaRaizAtiva devolve a ocorrência ativa de uma definição causa raiz da informada (0 = nenhuma)
+func (r *ocorrenciasMemoria) BuscarCausaRaizAtiva(definicaoID int) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var pai *ocorrenciaMemoria
+	for _, paiID := range r.supressoes[definicaoID] {
+		for _, o := range r.ocorrencias {
+			if o.definicaoID != paiID || o.status != "ATIVO" {
+				continue
+			}
+			if pai == nil || o.inicio.Before(pai.inicio) || (o.inicio.Equal(pai.inicio) && o.id < pai.id) {
+				pai = o
+			}
+		}
+	}
+	if pai == nil {
+		return 0, nil
+	}
+	return pai.id, nil
+}
+
+// BuscarJanelaManutencao devolve a janela de manutenção em vigor na eclusa/setor (0 = nenhuma)
+func (r *ocorrenciasMemoria) BuscarJanelaManutencao(eclusaID, setorID int, dataHora time.Time) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var escolhida *JanelaMemoria
+	for i := range r.janelas {
+		j := &r.janelas[i]
+		if j.EclusaID != eclusaID || (j.SetorID != 0 && j.SetorID != setorID) {
+			continue
+		}
+		if j.Inicio.After(dataHora) || !j.Fim.After(dataHora) {
+			continue
+		}
+		if escolhida == nil || (j.Ocultar && !escolhida.Ocultar) ||
+			(j.Ocultar == escolhida.Ocultar && j.ID < escolhida.ID) {
+			escolhida = j
+		}
+	}
+	if escolhida == nil {
+		return 0, nil
+	}
+	return escolhida.ID, nil
+}
+
+// AbrirAvalanche regista uma avalanche na eclusa e associa-lhe as ocorrências abertas desde 'desde'
+func (r *ocorrenciasMemoria) AbrirAvalanche(eclusaID int, desde, dataHora time.Time, totalAlarmes int) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	avalanche := &avalancheMemoria{
+		id:           int64(len(r.avalanches) + 1),
+		eclusaID:     eclusaID,
+		inicio:       dataHora,
+		totalAlarmes: totalAlarmes - 1,
+	}
+	for _, o := range r.ocorrencias {
+		d, _ := r.definicao(o.definicaoID)
+		if d.EclusaID != eclusaID || o.inicio.Before(desde) {
+			continue
+		}
+		if o.inicio.Before(avalanche.inicio) {
+			avalanche.inicio = o.inicio
+		}
+		if o.avalancheID == 0 {
+			o.avalancheID = avalanche.id
+		}
+	}
+	r.avalanches = append(r.avalanches, avalanche)
+	return avalanche.id, nil
+}
+
+// ContarAlarmeAvalanche soma um alarme à avalanche
+func (r *ocorrenciasMemoria) ContarAlarmeAvalanche(avalancheID int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, a := range r.avalanches {
+		if a.id == avalancheID {
+			a.totalAlarmes++
+		}
+	}
+	return nil
+}
+
+// FecharAvalanche regista o fim da avalanche
+func (r *ocorrenciasMemoria) FecharAvalanche(avalancheID int64, dataHora time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, a := range r.avalanches {
+		if a.id == avalancheID {
+			fim := dataHora
+			a.fim = &fim
+		}
+	}
+	return nil
+}
+
+type definicoesMemoria struct {
+	*Memoria
+}
+
+// Listar devolve as definições, ordenadas por word e bit
+func (r *definicoesMemoria) Listar(filtro FiltroDefinicoes) ([]Definicao, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	definicoes := []Definicao{}
+	for _, d := range r.definicoesOrdenadas() {
+		if (filtro.Eclusa != "" && r.codigoEclusa(d.EclusaID) != filtro.Eclusa) ||
+			(filtro.Setor != "" && d.SetorCodigo != filtro.Setor) ||
+			(filtro.Tipo != "" && d.Tipo != filtro.Tipo) ||
+			(filtro.Prioridade != "" && d.Prioridade != strings.ToUpper(filtro.Prioridade)) {
+			continue
+		}
+		def := Definicao{
+			ID:                   d.ID,
+			Codigo:               d.Codigo,
+			Tipo:                 d.Tipo,
+			Descricao:            d.Descricao,
+			Prioridade:           d.Prioridade,
+			WordIndex:            d.WordIndex,
+			BitIndex:             d.BitIndex,
+			ClasseMensagem:       d.ClasseMensagem,
+			SetorCodigo:          d.SetorCodigo,
+			SetorNome:            d.SetorNome,
+			EclusaCodigo:         d.EclusaCodigo,
+			Ativa:                d.Ativa,
+			Criticidade:          d.Criticidade,
+			RelacionadaSeguranca: d.RelacionadaSeguranca,
+		}
+		if eclusa, existe := r.eclusa(d.EclusaID); existe {
+			def.EclusaNome = eclusa.Nome
+		}
+		if d.TempoRespostaMinutos > 0 {
+			minutos := d.TempoRespostaMinutos
+			def.TempoRespostaMinutos = &minutos
+		}
+		definicoes = append(definicoes, def)
+	}
+	return definicoes, nil
+}
+
+// ListarMapeadas devolve as definições ativas para o mapeamento de tags
+func (r *definicoesMemoria) ListarMapeadas() ([]modelos.DefinicaoFalha, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var definicoes []modelos.DefinicaoFalha
+	for _, d := range r.definicoesOrdenadas() {
+		if d.Ativa {
+			definicoes = append(definicoes, d)
+		}
+	}
+	return definicoes, nil
+}
+
+// definicoesOrdenadas devolve uma cópia das definições por word e bit
+func (m *Memoria) definicoesOrdenadas() []modelos.DefinicaoFalha {
+	definicoes := append([]modelos.DefinicaoFalha(nil), m.definicoes...)
+	sort.SliceStable(definicoes, func(i, j int) bool {
+		if definicoes[i].WordIndex != definicoes[j].WordIndex {
+			return definicoes[i].WordIndex < definicoes[j].WordIndex
+		}
+		return definicoes[i].BitIndex < definicoes[j].BitIndex
+	})
+	return definicoes
+}
+
+type eclusasMemoria struct {
+	*Memoria
+}
+
+// Listar devolve as eclusas por nome
+func (r *eclusasMemoria) Listar() ([]Eclusa, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	eclusas := append([]Eclusa{}, r.eclusas...)
+	sort.SliceStable(eclusas, func(i, j int) bool { return eclusas[i].Nome < eclusas[j].Nome })
+	return eclusas, nil
+}
+
+type setoresMemoria struct {
+	*Memoria
+}
+
+// Listar devolve os setores por nome (só os com definições na eclusa, se informada)
+func (r *setoresMemoria) Listar(eclusa string) ([]Setor, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	setores := []Setor{}
+	for _, setor := range r.setores {
+		if eclusa == "" || r.setorNaEclusa(setor.ID, eclusa) {
+			setores = append(setores, setor)
+		}
+	}
+	sort.SliceStable(setores, func(i, j int) bool { return setores[i].Nome < setores[j].Nome })
+	return setores, nil
+}
+
+// setorNaEclusa indica se o setor tem definições na eclusa
+func (m *Memoria) setorNaEclusa(setorID int, eclusa string) bool {
+	for _, d := range m.definicoes {
+		if d.SetorID == setorID && m.codigoEclusa(d.EclusaID) == eclusa {
+			return true
+		}
+	}
+	return false
+}
+
+type estatisticasMemoria struct {
+	*Memoria
+}
+
+// Dashboard resume as ocorrências da eclusa informada (vazio = todas)
+func (r *estatisticasMemoria) Dashboard(eclusa string) (EstatisticasDashboard, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	agora := r.agora()
+
+	stats := EstatisticasDashboard{
+		PorSetor:      make(map[string]int),
+		PorPrioridade: make(map[string]int),
+	}
+	frequencias := make(map[FalhaFrequente]int)
+	var horasResolucao float64
+	resolvidas := 0
+
+	ocorrencias := &ocorrenciasMemoria{r.Memoria}
+	for _, o := range r.ocorrencias {
+		d, _ := r.definicao(o.definicaoID)
+		if ocorrencias.janelaOculta(o.janelaID) || (eclusa != "" && r.codigoEclusa(d.EclusaID) != eclusa) {
+			continue
+		}
+		setor, _ := r.setor(d.SetorID)
+
+		stats.TotalOcorrencias++
+		if o.status == "ATIVO" {
+			stats.OcorrenciasAtivas++
+			stats.PorSetor[setor.Nome]++
+			stats.PorPrioridade[d.Prioridade]++
+		}
+		if !o.inicio.Before(agora.Add(-24 * time.Hour)) {
+			switch d.Tipo {
+			case "FALHA":
+				stats.FalhasUltimas24h++
+			case "EVENTO":
+				stats.EventosUltimas24h++
+			}
+		}
+		if !o.inicio.Before(agora.Add(-7 * 24 * time.Hour)) {
+			frequencias[FalhaFrequente{Codigo: d.Codigo, Descricao: d.Descricao, SetorNome: setor.Nome}]++
+		}
+		if o.status == "RESOLVIDO" && o.fim != nil {
+			horasResolucao += o.fim.Sub(o.inicio).Hours()
+			resolvidas++
+		}
+	}
+
+	for falha, frequencia := range frequencias {
+		falha.Frequencia = frequencia
+		stats.TopFalhasFrequentes = append(stats.TopFalhasFrequentes, falha)
+	}
+	sort.Slice(stats.TopFalhasFrequentes, func(i, j int) bool {
+		a, b := stats.TopFalhasFrequentes[i], stats.TopFalhasFrequentes[j]
+		if a.Frequencia != b.Frequencia {
+			return a.Frequencia > b.Frequencia
+		}
+		return a.Codigo < b.Codigo
+	})
+	if len(stats.TopFalhasFrequentes) > limiteFalhasFrequentes {
+		stats.TopFalhasFrequentes = stats.TopFalhasFrequentes[:limiteFalhasFrequentes]
+	}
+	if resolvidas > 0 {
+		stats.TempoMedioResolucao = horasResolucao / float64(resolvidas)
+	}
+	return stats, nil
+}
+
+type sincronizacaoMemoria struct {
+	*Memoria
+}
+
+// EnfileirarOcorrencia acrescenta à fila o estado atual da ocorrência (lido no envio)
+func (r *sincronizacaoMemoria) EnfileirarOcorrencia(ocorrenciaID int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.enfileirar(ItemSincronizacao{Tipo: ItemOcorrencia, OcorrenciaID: ocorrenciaID})
+	return nil
+}
+
+// EnfileirarTransicao acrescenta à fila uma mudança de estado de ocorrência
+func (r *sincronizacaoMemoria) EnfileirarTransicao(t Transicao) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if t.Timestamp.IsZero() {
+		t.Timestamp = r.agora()
+	}
+	r.enfileirar(ItemSincronizacao{
+		Tipo:         ItemTransicao,
+		OcorrenciaID: t.OcorrenciaID,
+		Transicao: &TransicaoSincronizada{
+			StatusAnterior: t.StatusAnterior,
+			StatusNovo:     t.StatusNovo,
+			Timestamp:      t.Timestamp,
+			Origem:         t.Origem,
+			Observacao:     t.Observacao,
+		},
+	})
+	return nil
+}
+
+func (r *sincronizacaoMemoria) enfileirar(item ItemSincronizacao) {
+	// As sequências nunca se repetem, mesmo depois de limpar a fila (como o AUTOINCREMENT)
+	r.sequenciaFila++
+	item.Sequencia = r.sequenciaFila
+	r.fila = append(r.fila, &itemFilaMemoria{item: item, criadoEm: r.agora()})
+}
+
+// ListarPendentes devolve, por ordem, os itens por enviar com o estado atual das ocorrências
+func (r *sincronizacaoMemoria) ListarPendentes(limite int) ([]ItemSincronizacao, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	itens := []ItemSincronizacao{}
+	for _, f := range r.fila {
+		if f.enviadoEm != nil {
+			continue
+		}
+		if len(itens) == limite {
+			break
+		}
+		item := f.item
+		if item.Tipo == ItemOcorrencia {
+			item.Ocorrencia = r.ocorrenciaSincronizada(item.OcorrenciaID)
+		}
+		itens = append(itens, item)
+	}
+	return itens, nil
+}
+
+// ocorrenciaSincronizada devolve o estado atual da ocorrência (nil se não existe)
+func (r *sincronizacaoMemoria) ocorrenciaSincronizada(id int64) *OcorrenciaSincronizada {
+	o := r.ocorrencia(id)
+	if o == nil {
+		return nil
+	}
+	d, existe := r.definicao(o.definicaoID)
+	if !existe {
+		return nil
+	}
+	oc := &OcorrenciaSincronizada{
+		PointIndex:      d.PointIndex,
+		Status:          o.status,
+		TimestampInicio: o.inicio,
+		TimestampFim:    o.fim,
+		ResolvidoPor:    o.resolvidoPor,
+		Observacoes:     o.observacoes,
+		FirstOut:        o.firstOut,
+		SuprimidaPor:    ponteiroSeNaoZero(o.suprimidaPor),
+		Contexto:        o.contexto,
+		Criacao:         &o.criacao,
+	}
+	if eclusa, existe := r.eclusa(d.EclusaID); existe {
+		oc.EclusaCodigo = eclusa.Codigo
+	}
+	return oc
+}
+
+// MarcarEnviados marca como enviados os itens até à sequência confirmada pelo central
+func (r *sincronizacaoMemoria) MarcarEnviados(ateSequencia int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	agora := r.agora()
+	for _, f := range r.fila {
+		if f.item.Sequencia <= ateSequencia && f.enviadoEm == nil {
+			f.enviadoEm = &agora
+		}
+	}
+	return nil
+}
+
+// RegistrarFalhaEnvio conta uma tentativa falhada no primeiro item pendente
+func (r *sincronizacaoMemoria) RegistrarFalhaEnvio(erro string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, f := range r.fila {
+		if f.enviadoEm == nil {
+			f.tentativas++
+			f.ultimoErro = erro
+			break
+		}
+	}
+	return nil
+}
+
+// RemoverEnviados apaga os itens enviados antes do instante informado e devolve quantos
+func (r *sincronizacaoMemoria) RemoverEnviados(antes time.Time) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var mantidos []*itemFilaMemoria
+	for _, f := range r.fila {
+		if f.enviadoEm == nil || !f.enviadoEm.Before(antes) {
+			mantidos = append(mantidos, f)
+		}
+	}
+	removidos := int64(len(r.fila) - len(mantidos))
+	r.fila = mantidos
+	return removidos, nil
+}
+
+// EstadoFila resume a fila local
+func (r *sincronizacaoMemoria) EstadoFila() (EstadoFila, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var estado EstadoFila
+	for _, f := range r.fila {
+		if f.enviadoEm != nil {
+			if estado.UltimoEnvio == nil || f.enviadoEm.After(*estado.UltimoEnvio) {
+				estado.UltimoEnvio = f.enviadoEm
+			}
+			continue
+		}
+		if estado.Pendentes == 0 {
+			criado := f.criadoEm
+			estado.PendenteMaisAntigo = &criado
+			estado.Tentativas, estado.UltimoErro = f.tentativas, f.ultimoErro
+		}
+		estado.Pendentes++
+	}
+	return estado, nil
+}
+
+// AplicarLote aplica os itens de uma eclusa posteriores à última sequência já aplicada; um item
+// que não pode ser aplicado é contado como rejeitado e a sequência avança sobre ele
+func (r *sincronizacaoMemoria) AplicarLote(origem string, itens []ItemSincronizacao) (ResultadoLote, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var ultima int64
+	if o, existe := r.origens[origem]; existe {
+		ultima = o.UltimaSequencia
+	}
+
+	resultado := ResultadoLote{}
+	for _, item := range itens {
+		if item.Sequencia <= ultima {
+			continue
+		}
+		motivo := ""
+		switch item.Tipo {
+		case ItemOcorrencia:
+			if oc := item.Ocorrencia; oc != nil {
+				if r.definicaoPorPonto(oc.EclusaCodigo, oc.PointIndex) == 0 {
+					motivo = fmt.Sprintf("definição %s/%d não existe no central", oc.EclusaCodigo, oc.PointIndex)
+				} else {
+					r.aplicarOcorrencia(origem, item.OcorrenciaID, *oc)
+				}
+			}
+		case ItemTransicao:
+			t := item.Transicao
+			if t == nil {
+				motivo = "transição sem dados"
+			} else if o := r.ocorrenciaPorOrigem(origem, item.OcorrenciaID); o == nil {
+				motivo = fmt.Sprintf("ocorrência %d não existe no central", item.OcorrenciaID)
+			} else {
+				r.transicoes = append(r.transicoes, Transicao{
+					OcorrenciaID:   o.id,
+					StatusAnterior: t.StatusAnterior,
+					StatusNovo:     t.StatusNovo,
+					Timestamp:      t.Timestamp,
+					Origem:         t.Origem,
+					Observacao:     t.Observacao,
+				})
+			}
+		default:
+			motivo = fmt.Sprintf("tipo de item desconhecido: %s", item.Tipo)
+		}
+		if motivo != "" {
+			resultado.Rejeitados = append(resultado.Rejeitados, ItemRejeitado{
+				Sequencia: item.Sequencia, OcorrenciaID: item.OcorrenciaID, Motivo: motivo,
+			})
+		} else {
+			resultado.Aplicados++
+		}
+		ultima = item.Sequencia
+	}
+
+	o, existe := r.origens[origem]
+	if !existe {
+		o = &OrigemSincronizacao{Origem: origem}
+		r.origens[origem] = o
+	}
+	agora := r.agora()
+	o.UltimaSequencia, o.UltimoLote = ultima, &agora
+	o.TotalItens += int64(resultado.Aplicados)
+	o.TotalRejeitados += int64(len(resultado.Rejeitados))
+	resultado.UltimaSequencia = ultima
+	return resultado, nil
+}
+
+// aplicarOcorrencia replica o upsert do banco e as suas regras de conflito
+func (r *sincronizacaoMemoria) aplicarOcorrencia(origem string, origemID int64, oc OcorrenciaSincronizada) {
+	var suprimidaPor int64
+	if oc.SuprimidaPor != nil {
+		if pai := r.ocorrenciaPorOrigem(origem, *oc.SuprimidaPor); pai != nil {
+			suprimidaPor = pai.id
+		}
+	}
+
+	o := r.ocorrenciaPorOrigem(origem, origemID)
+	if o == nil {
+		criacao := oc.TimestampInicio
+		if oc.Criacao != nil {
+			criacao = *oc.Criacao
+		}
+		r.ocorrencias = append(r.ocorrencias, &ocorrenciaMemoria{
+			id:           int64(len(r.ocorrencias) + 1),
+			definicaoID:  r.definicaoPorPonto(oc.EclusaCodigo, oc.PointIndex),
+			status:       oc.Status,
+			inicio:       oc.TimestampInicio,
+			fim:          oc.TimestampFim,
+			resolvidoPor: oc.ResolvidoPor,
+			observacoes:  oc.Observacoes,
+			firstOut:     oc.FirstOut,
+			suprimidaPor: suprimidaPor,
+			contexto:     oc.Contexto,
+			origemEclusa: origem,
+			origemID:     origemID,
+			criacao:      criacao,
+		})
+		return
+	}
+
+	if o.status != "RESOLVIDO" && !(o.status == "EM_ANALISE" && oc.Status == "ATIVO") {
+		o.status = oc.Status
+	}
+	if o.fim == nil {
+		o.fim = oc.TimestampFim
+	}
+	if o.resolvidoPor == "" {
+		o.resolvidoPor = oc.ResolvidoPor
+	}
+	if o.observacoes == "" {
+		o.observacoes = oc.Observacoes
+	}
+	if o.suprimidaPor == 0 {
+		o.suprimidaPor = suprimidaPor
+	}
+}
+
+// definicaoPorPonto devolve o ID da definição da eclusa com o point_index (0 = não existe)
+func (m *Memoria) definicaoPorPonto(eclusaCodigo string, pointIndex int) int {
+	for _, d := range m.definicoes {
+		if eclusa, existe := m.eclusa(d.EclusaID); existe && eclusa.Codigo == eclusaCodigo && d.PointIndex == pointIndex {
+			return d.ID
+		}
+	}
+	return 0
+}
+
+func (m *Memoria) ocorrenciaPorOrigem(origem string, origemID int64) *ocorrenciaMemoria {
+	for _, o := range m.ocorrencias {
+		if o.origemEclusa == origem && o.origemID == origemID {
+			return o
+		}
+	}
+	return nil
+}
+
+// ListarOrigens devolve as eclusas que já enviaram lotes ao central
+func (r *sincronizacaoMemoria) ListarOrigens() ([]OrigemSincronizacao, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	origens := []OrigemSincronizacao{}
+	for _, o := range r.origens {
+		origens = append(origens, *o)
+	}
+	sort.Slice(origens, func(i, j int) bool { return origens[i].Origem < origens[j].Origem })
+	return origens, nil
+}
+
+// ponteiroSeNaoZero devolve nil para IDs zerados (NULL no banco)
+func ponteiroSeNaoZero(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// contem indica se o valor está na lista
+func contem(lista []string, valor string) bool {
+	for _, item := range lista {
+		if item == valor {
+			return true
+		}
+	}
+	return false
+}
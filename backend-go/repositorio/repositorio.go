@@ -0,0 +1,368 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+)
+
+// Repositorios agrupa os repositórios usados pela API e pelo processador do PLC
+type Repositorios struct {
+	Ocorrencias Ocorrencias
+	Definicoes  Definicoes
+	Eclusas     Eclusas
+	Setores     Setores
+
+	// Indicadores do dashboard
+	Estatisticas Estatisticas
+
+	// Fila de envio para a instância central (na eclusa) e aplicação dos lotes recebidos (no central)
+	Sincronizacao Sincronizacao
+}
+
+// Ocorrencias lê e grava as ocorrências de falhas/eventos, as suas transições e as avalanches
+type Ocorrencias interface {
+	// ListarAtivas devolve as ocorrências ativas com definição, setor e eclusa
+	ListarAtivas(filtro FiltroAtivas) ([]Ocorrencia, error)
+	// ListarHistorico devolve uma página do histórico pela ordenação do filtro (desempate por data
+	// de início e ID), a seguir à posição indicada no filtro
+	ListarHistorico(filtro FiltroHistorico) (PaginaHistorico, error)
+	// ContarHistorico conta as ocorrências do histórico que atendem aos filtros (ignora a posição)
+	ContarHistorico(filtro FiltroHistorico) (int, error)
+	// PercorrerHistorico entrega, uma a uma e pela ordenação do filtro, todas as ocorrências do
+	// histórico que atendem aos filtros (ignora a posição e o limite). Pára no primeiro erro de visitar.
+	PercorrerHistorico(filtro FiltroHistorico, visitar func(Ocorrencia) error) error
+	// ExisteAtiva indica se a definição já tem uma ocorrência ativa
+	ExisteAtiva(definicaoID int) (bool, error)
+	// Abrir grava uma nova ocorrência ATIVO e devolve o seu ID
+	Abrir(nova NovaOcorrencia) (int64, error)
+	// ResolverAtivas resolve (pelo PLC) as ocorrências ativas da definição e devolve os IDs resolvidos
+	ResolverAtivas(definicaoID int, dataHora time.Time) ([]int64, error)
+	// ResolverManualmente resolve uma ocorrência ativa pelo utilizador; false se não estava ativa
+	ResolverManualmente(id int64) (bool, error)
+	// RegistrarTransicao grava uma mudança de estado de ocorrência
+	RegistrarTransicao(transicao Transicao) error
+	// BuscarCausaRaizAtiva devolve a ocorrência ativa de uma definição configurada como causa raiz
+	// (pai) da definição informada, ou 0 se não houver
+	BuscarCausaRaizAtiva(definicaoID int) (int64, error)
+	// BuscarJanelaManutencao devolve a janela de manutenção da eclusa (inteira ou do setor) em vigor
+	// no instante informado, ou 0 se não houver; as janelas que ocultam têm preferência
+	BuscarJanelaManutencao(eclusaID, setorID int, dataHora time.Time) (int64, error)
+	// AbrirAvalanche regista uma avalanche na eclusa, associa-lhe as ocorrências abertas desde
+	// 'desde' e devolve o seu ID
+	AbrirAvalanche(eclusaID int, desde, dataHora time.Time, totalAlarmes int) (int64, error)
+	// ContarAlarmeAvalanche soma um alarme à avalanche
+	ContarAlarmeAvalanche(avalancheID int64) error
+	// FecharAvalanche regista o fim da avalanche
+	FecharAvalanche(avalancheID int64, dataHora time.Time) error
+}
+
+// Sincronizacao guarda, na eclusa, a fila de alterações por enviar à instância central e aplica,
+// no central, os lotes recebidos das eclusas
+type Sincronizacao interface {
+	// EnfileirarOcorrencia acrescenta à fila o estado atual da ocorrência (lido no envio)
+	EnfileirarOcorrencia(ocorrenciaID int64) error
+	// EnfileirarTransicao acrescenta à fila uma mudança de estado de ocorrência
+	EnfileirarTransicao(transicao Transicao) error
+	// ListarPendentes devolve, por ordem, os itens por enviar com o estado atual das ocorrências
+	ListarPendentes(limite int) ([]ItemSincronizacao, error)
+	// MarcarEnviados marca como enviados os itens até à sequência confirmada pelo central
+	MarcarEnviados(ateSequencia int64) error
+	// RegistrarFalhaEnvio conta uma tentativa falhada no primeiro item pendente
+	RegistrarFalhaEnvio(erro string) error
+	// RemoverEnviados apaga os itens enviados antes do instante informado e devolve quantos
+	RemoverEnviados(antes time.Time) (int64, error)
+	// EstadoFila resume a fila local
+	EstadoFila() (EstadoFila, error)
+	// AplicarLote aplica, numa transação, os itens de uma eclusa posteriores à última sequência já
+	// aplicada. Os itens que o central não consegue aplicar ficam registados como rejeitados e a
+	// sequência avança sobre eles; só um erro do banco faz falhar o lote.
+	AplicarLote(origem string, itens []ItemSincronizacao) (ResultadoLote, error)
+	// ListarOrigens devolve as eclusas que já enviaram lotes ao central
+	ListarOrigens() ([]OrigemSincronizacao, error)
+}
+
+// Estatisticas calcula os indicadores do dashboard. As ocorrências ocultadas por uma janela de
+// manutenção não entram em nenhum indicador.
+type Estatisticas interface {
+	// Dashboard resume as ocorrências da eclusa informada (vazio = todas)
+	Dashboard(eclusa string) (EstatisticasDashboard, error)
+}
+
+// Definicoes lê as definições de falhas/eventos
+type Definicoes interface {
+	// Listar devolve as definições com endereço no PLC, ordenadas por word e bit
+	Listar(filtro FiltroDefinicoes) ([]Definicao, error)
+	// ListarMapeadas devolve as definições ativas com endereço no PLC para o mapeamento de tags
+	ListarMapeadas() ([]modelos.DefinicaoFalha, error)
+}
+
+// Eclusas lê as eclusas
+type Eclusas interface {
+	Listar() ([]Eclusa, error)
+}
+
+// Setores lê os setores
+type Setores interface {
+	// Listar devolve os setores com definições na eclusa informada (vazio = todos)
+	Listar(eclusa string) ([]Setor, error)
+}
+
+// Ocorrencia representa uma ocorrência com todas as informações para o front-end
+type Ocorrencia struct {
+	ID              int64      `json:"id"`
+	Status          string     `json:"status"`
+	TimestampInicio time.Time  `json:"timestamp_inicio"`
+	TimestampFim    *time.Time `json:"timestamp_fim,omitempty"`
+
+	// Dados da Definição de Falha
+	DefinicaoID    int    `json:"definicao_id"`
+	Codigo         string `json:"codigo"`
+	Tipo           string `json:"tipo"`
+	Descricao      string `json:"descricao"`
+	Prioridade     string `json:"prioridade"`
+	WordIndex      int    `json:"word_index"`
+	BitIndex       int    `json:"bit_index"`
+	ClasseMensagem string `json:"classe_mensagem"`
+
+	// Dados do Setor
+	SetorCodigo string `json:"setor_codigo"`
+	SetorNome   string `json:"setor_nome"`
+
+	// Dados da Eclusa
+	EclusaCodigo string `json:"eclusa_codigo"`
+	EclusaNome   string `json:"eclusa_nome"`
+
+	// Modelo de severidade
+	Criticidade          int  `json:"criticidade"`
+	RelacionadaSeguranca bool `json:"relacionada_seguranca"`
+	TempoRespostaMinutos *int `json:"tempo_resposta_minutos,omitempty"`
+	SLAViolado           bool `json:"sla_violado"`
+
+	// Dados calculados
+	DuracaoSegundos *int64 `json:"duracao_segundos,omitempty"`
+
+	// Análise de alarmes (first-out, avalanche e supressão)
+	FirstOut        bool   `json:"first_out"`
+	GrupoFirstOutID *int64 `json:"grupo_first_out_id,omitempty"`
+	AvalancheID     *int64 `json:"avalanche_id,omitempty"`
+	SuprimidaPor    *int64 `json:"suprimida_por,omitempty"`
+	TotalSuprimidas int    `json:"total_suprimidas"`
+
+	// Janela de manutenção planeada em que a ocorrência foi aberta
+	JanelaManutencaoID *int64 `json:"janela_manutencao_id,omitempty"`
+
+	// Resolução manual
+	ResolvidoPor string `json:"resolvido_por,omitempty"`
+	Observacoes  string `json:"observacoes,omitempty"`
+}
+
+// Definicao representa uma definição de falha para o front-end
+type Definicao struct {
+	ID             int    `json:"id"`
+	Codigo         string `json:"codigo"`
+	Tipo           string `json:"tipo"`
+	Descricao      string `json:"descricao"`
+	Prioridade     string `json:"prioridade"`
+	WordIndex      int    `json:"word_index"`
+	BitIndex       int    `json:"bit_index"`
+	ClasseMensagem string `json:"classe_mensagem"`
+	SetorCodigo    string `json:"setor_codigo"`
+	SetorNome      string `json:"setor_nome"`
+	EclusaCodigo   string `json:"eclusa_codigo"`
+	EclusaNome     string `json:"eclusa_nome"`
+	Ativa          bool   `json:"ativa"`
+
+	// Modelo de severidade
+	Criticidade          int  `json:"criticidade"`
+	RelacionadaSeguranca bool `json:"relacionada_seguranca"`
+	TempoRespostaMinutos *int `json:"tempo_resposta_minutos,omitempty"`
+}
+
+// Setor representa um setor do sistema
+type Setor struct {
+	ID      int    `json:"id"`
+	Codigo  string `json:"codigo"`
+	Nome    string `json:"nome"`
+	CorTema string `json:"cor_tema"`
+}
+
+// Eclusa representa uma eclusa do sistema
+type Eclusa struct {
+	ID          int    `json:"id"`
+	Codigo      string `json:"codigo"`
+	Nome        string `json:"nome"`
+	Localizacao string `json:"localizacao"`
+	Ativa       bool   `json:"ativa"`
+}
+
+// FiltroAtivas seleciona e ordena a lista de ocorrências ativas
+type FiltroAtivas struct {
+	Eclusa            string   // Código da eclusa (vazio = todas)
+	IncluirSuprimidas bool     // Incluir alarmes escondidos atrás de uma causa raiz ativa
+	IncluirManutencao bool     // Incluir ocorrências de janelas de manutenção que as ocultam
+	Prioridades       []string // Vazio = todas
+	SLAViolado        *bool
+	Ordenar           string // inicio, prioridade, criticidade, sla (vazio = first-out primeiro)
+}
+
+// FiltroHistorico seleciona uma página do histórico de ocorrências (campos vazios = sem filtro)
+type FiltroHistorico struct {
+	Inicio        *time.Time
+	Fim           *time.Time
+	Eclusa        string
+	Setor         string
+	Tipo          string
+	Status        string
+	Codigo        string
+	Busca         string // Texto na descrição da definição
+	DuracaoMinima int64  // Segundos
+	Manutencao    *bool  // true = só as abertas numa janela de manutenção
+	Prioridades   []string
+	SLAViolado    *bool
+	Ordenar       string // inicio, duracao, prioridade, criticidade, sla (vazio = inicio)
+	Ascendente    bool
+	// Referencia substitui o instante atual na chave de ordenação (duração e SLA das ocorrências em
+	// curso), para a chave não mudar entre páginas; zero = agora
+	Referencia time.Time
+	Limite     int               // 0 = sem limite
+	Apos       *PosicaoHistorico // Última linha da página anterior (nil = primeira página)
+}
+
+// OrdenacoesHistorico são as ordenações aceites em FiltroHistorico.Ordenar
+var OrdenacoesHistorico = []string{"inicio", "duracao", "prioridade", "criticidade", "sla"}
+
+// PosicaoHistorico identifica uma linha do histórico para a paginação por cursor
+type PosicaoHistorico struct {
+	Chave  string // Valor da chave de ordenação, em texto (vazio na ordenação por início)
+	Inicio time.Time
+	ID     int64
+}
+
+// PaginaHistorico é uma página do histórico; Proxima é a posição da última linha quando há mais
+type PaginaHistorico struct {
+	Ocorrencias []Ocorrencia
+	Proxima     *PosicaoHistorico
+}
+
+// EstatisticasDashboard representa as estatísticas principais do sistema
+type EstatisticasDashboard struct {
+	OcorrenciasAtivas   int              `json:"ocorrencias_ativas"`
+	FalhasUltimas24h    int              `json:"falhas_ultimas_24h"`
+	EventosUltimas24h   int              `json:"eventos_ultimas_24h"`
+	TotalOcorrencias    int              `json:"total_ocorrencias"`
+	PorSetor            map[string]int   `json:"por_setor"`
+	PorPrioridade       map[string]int   `json:"por_prioridade"`
+	TopFalhasFrequentes []FalhaFrequente `json:"top_falhas_frequentes"`
+	TempoMedioResolucao float64          `json:"tempo_medio_resolucao_horas"`
+}
+
+// FalhaFrequente representa uma falha com sua frequência
+type FalhaFrequente struct {
+	Codigo     string `json:"codigo"`
+	Descricao  string `json:"descricao"`
+	SetorNome  string `json:"setor_nome"`
+	Frequencia int    `json:"frequencia"`
+}
+
+// FiltroDefinicoes seleciona definições por eclusa, setor, tipo e prioridade (vazio = todos)
+type FiltroDefinicoes struct {
+	Eclusa     string
+	Setor      string
+	Tipo       string
+	Prioridade string
+}
+
+// NovaOcorrencia são os dados de uma ocorrência aberta pelo PLC (IDs a 0 = sem associação)
+type NovaOcorrencia struct {
+	DefinicaoID        int
+	Inicio             time.Time
+	FirstOut           bool
+	GrupoFirstOutID    int64
+	AvalancheID        int64
+	SuprimidaPor       int64
+	JanelaManutencaoID int64
+	Contexto           string // JSON do frame (vazio = sem contexto)
+}
+
+// Transicao é uma mudança de estado de ocorrência
+type Transicao struct {
+	OcorrenciaID   int64
+	StatusAnterior string // Vazio na abertura
+	StatusNovo     string
+	Timestamp      time.Time // Zero = agora
+	Origem         string
+	Observacao     string
+}
+
+// Tipos de item da fila de sincronização
+const (
+	ItemOcorrencia = "OCORRENCIA"
+	ItemTransicao  = "TRANSICAO"
+)
+
+// ItemSincronizacao é um item da fila enviado ao central. A sequência é o ID na fila da eclusa e
+// só cresce; as ocorrências são identificadas pelo ID na eclusa e as definições pelo point_index.
+type ItemSincronizacao struct {
+	Sequencia    int64                   `json:"sequencia"`
+	Tipo         string                  `json:"tipo"`
+	OcorrenciaID int64                   `json:"ocorrencia_id"`
+	Ocorrencia   *OcorrenciaSincronizada `json:"ocorrencia,omitempty"` // Tipo OCORRENCIA (nil se já não existe)
+	Transicao    *TransicaoSincronizada  `json:"transicao,omitempty"`  // Tipo TRANSICAO
+}
+
+// OcorrenciaSincronizada é o estado de uma ocorrência da eclusa no momento do envio
+type OcorrenciaSincronizada struct {
+	EclusaCodigo    string     `json:"eclusa_codigo"`
+	PointIndex      int        `json:"point_index"`
+	Status          string     `json:"status"`
+	TimestampInicio time.Time  `json:"timestamp_inicio"`
+	TimestampFim    *time.Time `json:"timestamp_fim,omitempty"`
+	ResolvidoPor    string     `json:"resolvido_por,omitempty"`
+	Observacoes     string     `json:"observacoes,omitempty"`
+	FirstOut        bool       `json:"first_out"`
+	SuprimidaPor    *int64     `json:"suprimida_por,omitempty"` // ID na eclusa
+	Contexto        string     `json:"contexto,omitempty"`      // JSON do frame
+	Criacao         *time.Time `json:"criacao,omitempty"`       // created_at na eclusa (vazio = início)
+}
+
+// TransicaoSincronizada é uma mudança de estado registada na eclusa
+type TransicaoSincronizada struct {
+	StatusAnterior string    `json:"status_anterior,omitempty"`
+	StatusNovo     string    `json:"status_novo"`
+	Timestamp      time.Time `json:"timestamp"`
+	Origem         string    `json:"origem"`
+	Observacao     string    `json:"observacao,omitempty"`
+}
+
+// EstadoFila resume a fila de sincronização da eclusa
+type EstadoFila struct {
+	Pendentes          int        `json:"pendentes"`
+	PendenteMaisAntigo *time.Time `json:"pendente_mais_antigo,omitempty"`
+	Tentativas         int        `json:"tentativas"` // Do primeiro item pendente
+	UltimoErro         string     `json:"ultimo_erro,omitempty"`
+	UltimoEnvio        *time.Time `json:"ultimo_envio,omitempty"`
+}
+
+// OrigemSincronizacao é uma eclusa que envia lotes ao central
+type OrigemSincronizacao struct {
+	Origem          string     `json:"origem"`
+	UltimaSequencia int64      `json:"ultima_sequencia"`
+	TotalItens      int64      `json:"total_itens"`
+	TotalRejeitados int64      `json:"total_rejeitados"`
+	UltimoLote      *time.Time `json:"ultimo_lote,omitempty"`
+}
+
+// ResultadoLote é o resultado da aplicação de um lote no central
+type ResultadoLote struct {
+	UltimaSequencia int64           `json:"ultima_sequencia"`
+	Aplicados       int             `json:"aplicados"`
+	Rejeitados      []ItemRejeitado `json:"rejeitados,omitempty"`
+}
+
+// ItemRejeitado é um item do lote que o central não aplicou (ex.: definição inexistente)
+type ItemRejeitado struct {
+	Sequencia    int64  `json:"sequencia"`
+	OcorrenciaID int64  `json:"ocorrencia_id"`
+	Motivo       string `json:"motivo"`
+}
@@ -0,0 +1,370 @@
+package repositorio
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type sincronizacaoSQL struct {
+	db *sql.DB
+	d  dialeto
+}
+
+// EnfileirarOcorrencia acrescenta à fila o estado atual da ocorrência (lido no envio)
+func (r *sincronizacaoSQL) EnfileirarOcorrencia(ocorrenciaID int64) error {
+	_, err := r.db.Exec(r.d.sql(`
+		INSERT INTO fila_sincronizacao (tipo, ocorrencia_id) VALUES ('OCORRENCIA', ?)`), ocorrenciaID)
+	if err != nil {
+		return fmt.Errorf("erro ao enfileirar ocorrência %d para sincronização: %v", ocorrenciaID, err)
+	}
+	return nil
+}
+
+// EnfileirarTransicao acrescenta à fila uma mudança de estado de ocorrência
+func (r *sincronizacaoSQL) EnfileirarTransicao(t Transicao) error {
+	var timestamp interface{}
+	if !t.Timestamp.IsZero() {
+		timestamp = r.d.valorDataHora(t.Timestamp)
+	}
+	_, err := r.db.Exec(r.d.sql(`
+		INSERT INTO fila_sincronizacao (tipo, ocorrencia_id, status_anterior, status_novo, timestamp, origem, observacao)
+		VALUES ('TRANSICAO', ?, NULLIF(?, ''), ?, COALESCE(?, `+r.d.agora+`), ?, NULLIF(?, ''))`),
+		t.OcorrenciaID, t.StatusAnterior, t.StatusNovo, timestamp, t.Origem, t.Observacao)
+	if err != nil {
+		return fmt.Errorf("erro ao enfileirar transição da ocorrência %d para sincronização: %v", t.OcorrenciaID, err)
+	}
+	return nil
+}
+
+// ListarPendentes devolve, por ordem, os itens por enviar com o estado atual das ocorrências
+func (r *sincronizacaoSQL) ListarPendentes(limite int) ([]ItemSincronizacao, error) {
+	rows, err := r.db.Query(r.d.sql(`
+		SELECT f.id, f.tipo, f.ocorrencia_id,
+			COALESCE(f.status_anterior, ''), COALESCE(f.status_novo, ''), f.timestamp,
+			COALESCE(f.origem, ''), COALESCE(f.observacao, ''),
+			o.id, o.status, o.timestamp_inicio, o.timestamp_fim,
+			COALESCE(o.resolvido_por, ''), COALESCE(o.observacoes, ''),
+			o.first_out, o.suprimida_por, CAST(o.dados_contexto AS TEXT), o.created_at,
+			df.point_index, e.codigo
+		FROM fila_sincronizacao f
+		LEFT JOIN ocorrencias_falhas o ON o.id = f.ocorrencia_id
+		LEFT JOIN definicoes_falhas df ON df.id = o.definicao_id
+		LEFT JOIN eclusas e ON e.id = df.eclusa_id
+		WHERE f.enviado_em IS NULL
+		ORDER BY f.id
+		LIMIT ?`), limite)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar fila de sincronização: %v", err)
+	}
+	defer rows.Close()
+
+	itens := []ItemSincronizacao{}
+	for rows.Next() {
+		var item ItemSincronizacao
+		var transicao TransicaoSincronizada
+		var oc OcorrenciaSincronizada
+		var timestamp, inicio, fim, criacao dataHora
+		var ocorrenciaID, suprimidaPor, pointIndex sql.NullInt64
+		var status, contexto, eclusa sql.NullString
+		var firstOut sql.NullBool
+
+		err := rows.Scan(
+			&item.Sequencia, &item.Tipo, &item.OcorrenciaID,
+			&transicao.StatusAnterior, &transicao.StatusNovo, &timestamp,
+			&transicao.Origem, &transicao.Observacao,
+			&ocorrenciaID, &status, &inicio, &fim,
+			&oc.ResolvidoPor, &oc.Observacoes,
+			&firstOut, &suprimidaPor, &contexto, &criacao,
+			&pointIndex, &eclusa)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler item da fila de sincronização: %v", err)
+		}
+
+		switch item.Tipo {
+		case ItemTransicao:
+			transicao.Timestamp = timestamp.Time
+			item.Transicao = &transicao
+		case ItemOcorrencia:
+			// Ocorrência apagada na eclusa: o item segue sem estado e o central só avança a sequência
+			if ocorrenciaID.Valid && pointIndex.Valid {
+				oc.EclusaCodigo = eclusa.String
+				oc.PointIndex = int(pointIndex.Int64)
+				oc.Status = status.String
+				oc.TimestampInicio = inicio.Time
+				oc.TimestampFim = fim.ponteiro()
+				oc.FirstOut = firstOut.Bool
+				oc.SuprimidaPor = ponteiroInt64(suprimidaPor)
+				oc.Contexto = contexto.String
+				oc.Criacao = criacao.ponteiro()
+				item.Ocorrencia = &oc
+			}
+		}
+		itens = append(itens, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler fila de sincronização: %v", err)
+	}
+	return itens, nil
+}
+
+// MarcarEnviados marca como enviados os itens até à sequência confirmada pelo central
+func (r *sincronizacaoSQL) MarcarEnviados(ateSequencia int64) error {
+	_, err := r.db.Exec(r.d.sql(`
+		UPDATE fila_sincronizacao SET enviado_em = `+r.d.agora+`
+		WHERE id <= ? AND enviado_em IS NULL`), ateSequencia)
+	if err != nil {
+		return fmt.Errorf("erro ao marcar itens enviados até %d: %v", ateSequencia, err)
+	}
+	return nil
+}
+
+// RegistrarFalhaEnvio conta uma tentativa falhada no primeiro item pendente
+func (r *sincronizacaoSQL) RegistrarFalhaEnvio(erro string) error {
+	_, err := r.db.Exec(r.d.sql(`
+		UPDATE fila_sincronizacao SET tentativas = tentativas + 1, ultimo_erro = ?
+		WHERE id = (SELECT MIN(id) FROM fila_sincronizacao WHERE enviado_em IS NULL)`), erro)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar falha de envio: %v", err)
+	}
+	return nil
+}
+
+// RemoverEnviados apaga os itens enviados antes do instante informado e devolve quantos
+func (r *sincronizacaoSQL) RemoverEnviados(antes time.Time) (int64, error) {
+	resultado, err := r.db.Exec(r.d.sql(`
+		DELETE FROM fila_sincronizacao WHERE enviado_em IS NOT NULL AND enviado_em < ?`),
+		r.d.valorDataHora(antes))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao limpar fila de sincronização: %v", err)
+	}
+	return resultado.RowsAffected()
+}
+
+// EstadoFila resume a fila local
+func (r *sincronizacaoSQL) EstadoFila() (EstadoFila, error) {
+	var estado EstadoFila
+	var maisAntigo, ultimoEnvio dataHora
+
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), MIN(criado_em) FROM fila_sincronizacao WHERE enviado_em IS NULL`).
+		Scan(&estado.Pendentes, &maisAntigo)
+	if err != nil {
+		return estado, fmt.Errorf("erro ao consultar fila de sincronização: %v", err)
+	}
+	estado.PendenteMaisAntigo = maisAntigo.ponteiro()
+
+	err = r.db.QueryRow(`
+		SELECT tentativas, COALESCE(ultimo_erro, '') FROM fila_sincronizacao
+		WHERE enviado_em IS NULL ORDER BY id LIMIT 1`).Scan(&estado.Tentativas, &estado.UltimoErro)
+	if err != nil && err != sql.ErrNoRows {
+		return estado, fmt.Errorf("erro ao consultar fila de sincronização: %v", err)
+	}
+
+	if err := r.db.QueryRow(`SELECT MAX(enviado_em) FROM fila_sincronizacao`).Scan(&ultimoEnvio); err != nil {
+		return estado, fmt.Errorf("erro ao consultar fila de sincronização: %v", err)
+	}
+	estado.UltimoEnvio = ultimoEnvio.ponteiro()
+	return estado, nil
+}
+
+// AplicarLote aplica, numa transação, os itens de uma eclusa posteriores à última sequência já
+// aplicada. Os itens repetidos (reenvio depois de uma falha de rede) são ignorados pela sequência e
+// as ocorrências são gravadas por upsert em (origem_eclusa, origem_id). Um item que o central não
+// consegue aplicar fica em itens_rejeitados_sincronizacao e a sequência avança sobre ele.
+func (r *sincronizacaoSQL) AplicarLote(origem string, itens []ItemSincronizacao) (ResultadoLote, error) {
+	resultado := ResultadoLote{}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return resultado, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Bloqueia a linha da origem: dois lotes da mesma eclusa nunca são aplicados em paralelo
+	_, err = tx.Exec(r.d.sql(`
+		INSERT INTO origens_sincronizacao (origem) VALUES (?)
+		ON CONFLICT (origem) DO UPDATE SET origem = excluded.origem`), origem)
+	if err != nil {
+		return resultado, fmt.Errorf("erro ao registrar origem %s: %v", origem, err)
+	}
+	var ultima int64
+	err = tx.QueryRow(r.d.sql(`
+		SELECT ultima_sequencia FROM origens_sincronizacao WHERE origem = ?`), origem).Scan(&ultima)
+	if err != nil {
+		return resultado, fmt.Errorf("erro ao ler última sequência de %s: %v", origem, err)
+	}
+
+	for _, item := range itens {
+		if item.Sequencia <= ultima {
+			continue
+		}
+		var motivo string
+		switch item.Tipo {
+		case ItemOcorrencia:
+			motivo, err = r.aplicarOcorrencia(tx, origem, item)
+		case ItemTransicao:
+			motivo, err = r.aplicarTransicao(tx, origem, item)
+		default:
+			motivo = fmt.Sprintf("tipo de item desconhecido: %s", item.Tipo)
+		}
+		if err != nil {
+			return resultado, fmt.Errorf("item %d de %s: %v", item.Sequencia, origem, err)
+		}
+		if motivo != "" {
+			if err := r.rejeitarItem(tx, origem, item, motivo); err != nil {
+				return resultado, err
+			}
+			resultado.Rejeitados = append(resultado.Rejeitados, ItemRejeitado{
+				Sequencia: item.Sequencia, OcorrenciaID: item.OcorrenciaID, Motivo: motivo,
+			})
+		} else {
+			resultado.Aplicados++
+		}
+		ultima = item.Sequencia
+	}
+
+	_, err = tx.Exec(r.d.sql(`
+		UPDATE origens_sincronizacao
+		SET ultima_sequencia = ?, total_itens = total_itens + ?, total_rejeitados = total_rejeitados + ?,
+			ultimo_lote_em = `+r.d.agora+`
+		WHERE origem = ?`), ultima, resultado.Aplicados, len(resultado.Rejeitados), origem)
+	if err != nil {
+		return resultado, fmt.Errorf("erro ao atualizar origem %s: %v", origem, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return resultado, fmt.Errorf("erro ao confirmar lote de %s: %v", origem, err)
+	}
+	resultado.UltimaSequencia = ultima
+	return resultado, nil
+}
+
+// rejeitarItem guarda o item recebido e o motivo da rejeição (um reenvio do mesmo item é ignorado)
+func (r *sincronizacaoSQL) rejeitarItem(tx *sql.Tx, origem string, item ItemSincronizacao, motivo string) error {
+	conteudo, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar item %d de %s: %v", item.Sequencia, origem, err)
+	}
+	_, err = tx.Exec(r.d.sql(`
+		INSERT INTO itens_rejeitados_sincronizacao (origem, sequencia, tipo, ocorrencia_id, motivo, conteudo)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (origem, sequencia) DO NOTHING`),
+		origem, item.Sequencia, item.Tipo, item.OcorrenciaID, motivo, string(conteudo))
+	if err != nil {
+		return fmt.Errorf("erro ao registrar item rejeitado %d de %s: %v", item.Sequencia, origem, err)
+	}
+	return nil
+}
+
+// aplicarOcorrencia grava o estado enviado pela eclusa. O central prevalece nas edições manuais:
+// uma ocorrência resolvida no central não volta a ficar ativa, EM_ANALISE não é substituído por
+// ATIVO, e o fim, quem resolveu e as observações já gravados no central são mantidos. O created_at
+// é o da eclusa: o atraso de uma eclusa que esteve sem ligação não torna novas as suas ocorrências
+// para as notificações, escalonamentos e ordens de trabalho do central. Devolve o motivo quando o
+// item tem de ser rejeitado.
+func (r *sincronizacaoSQL) aplicarOcorrencia(tx *sql.Tx, origem string, item ItemSincronizacao) (string, error) {
+	oc := item.Ocorrencia
+	if oc == nil {
+		return "", nil
+	}
+
+	var definicaoID int
+	err := tx.QueryRow(r.d.sql(`
+		SELECT df.id FROM definicoes_falhas df
+		JOIN eclusas e ON df.eclusa_id = e.id
+		WHERE e.codigo = ? AND df.point_index = ?`), oc.EclusaCodigo, oc.PointIndex).Scan(&definicaoID)
+	if err == sql.ErrNoRows {
+		return fmt.Sprintf("definição %s/%d não existe no central", oc.EclusaCodigo, oc.PointIndex), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar definição %s/%d: %v", oc.EclusaCodigo, oc.PointIndex, err)
+	}
+
+	var fim interface{}
+	if oc.TimestampFim != nil {
+		fim = r.d.valorDataHora(*oc.TimestampFim)
+	}
+	// Lotes de versões anteriores não trazem o created_at: o início é o melhor substituto
+	criacao := oc.TimestampInicio
+	if oc.Criacao != nil {
+		criacao = *oc.Criacao
+	}
+	_, err = tx.Exec(r.d.sql(`
+		INSERT INTO ocorrencias_falhas
+		(definicao_id, status, timestamp_inicio, timestamp_fim, resolvido_por, observacoes, first_out,
+			suprimida_por, dados_contexto, origem_eclusa, origem_id, created_at)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?,
+			(SELECT s.id FROM ocorrencias_falhas s WHERE s.origem_eclusa = ? AND s.origem_id = ?),
+			`+r.d.json+`, ?, ?, ?)
+		ON CONFLICT (origem_eclusa, origem_id, timestamp_inicio) DO UPDATE SET
+			status = CASE
+				WHEN ocorrencias_falhas.status = 'RESOLVIDO' THEN ocorrencias_falhas.status
+				WHEN ocorrencias_falhas.status = 'EM_ANALISE' AND excluded.status = 'ATIVO' THEN ocorrencias_falhas.status
+				ELSE excluded.status END,
+			timestamp_fim = COALESCE(ocorrencias_falhas.timestamp_fim, excluded.timestamp_fim),
+			resolvido_por = COALESCE(ocorrencias_falhas.resolvido_por, excluded.resolvido_por),
+			observacoes = COALESCE(ocorrencias_falhas.observacoes, excluded.observacoes),
+			suprimida_por = COALESCE(ocorrencias_falhas.suprimida_por, excluded.suprimida_por)`),
+		definicaoID, oc.Status, r.d.valorDataHora(oc.TimestampInicio), fim, oc.ResolvidoPor, oc.Observacoes, oc.FirstOut,
+		origem, oc.SuprimidaPor, oc.Contexto, origem, item.OcorrenciaID, r.d.valorDataHora(criacao))
+	if err != nil {
+		return "", fmt.Errorf("erro ao gravar ocorrência %d: %v", item.OcorrenciaID, err)
+	}
+	return "", nil
+}
+
+// aplicarTransicao grava a transição na ocorrência correspondente do central. Devolve o motivo
+// quando o item tem de ser rejeitado.
+func (r *sincronizacaoSQL) aplicarTransicao(tx *sql.Tx, origem string, item ItemSincronizacao) (string, error) {
+	t := item.Transicao
+	if t == nil {
+		return "transição sem dados", nil
+	}
+	// A ocorrência chegou num item anterior (a abertura é enfileirada antes da transição)
+	var ocorrenciaID int64
+	err := tx.QueryRow(r.d.sql(`
+		SELECT id FROM ocorrencias_falhas WHERE origem_eclusa = ? AND origem_id = ?`),
+		origem, item.OcorrenciaID).Scan(&ocorrenciaID)
+	if err == sql.ErrNoRows {
+		// Ocorrência apagada na eclusa antes do envio, ou rejeitada pelo central
+		return fmt.Sprintf("ocorrência %d não existe no central", item.OcorrenciaID), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar ocorrência %d: %v", item.OcorrenciaID, err)
+	}
+
+	_, err = tx.Exec(r.d.sql(`
+		INSERT INTO transicoes_ocorrencias (ocorrencia_id, status_anterior, status_novo, timestamp, origem, observacao)
+		VALUES (?, NULLIF(?, ''), ?, ?, ?, NULLIF(?, ''))`),
+		ocorrenciaID, t.StatusAnterior, t.StatusNovo, r.d.valorDataHora(t.Timestamp), t.Origem, t.Observacao)
+	if err != nil {
+		return "", fmt.Errorf("erro ao gravar transição da ocorrência %d: %v", item.OcorrenciaID, err)
+	}
+	return "", nil
+}
+
+// ListarOrigens devolve as eclusas que já enviaram lotes ao central
+func (r *sincronizacaoSQL) ListarOrigens() ([]OrigemSincronizacao, error) {
+	rows, err := r.db.Query(`
+		SELECT origem, ultima_sequencia, total_itens, total_rejeitados, ultimo_lote_em
+		FROM origens_sincronizacao ORDER BY origem`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar origens de sincronização: %v", err)
+	}
+	defer rows.Close()
+
+	origens := []OrigemSincronizacao{}
+	for rows.Next() {
+		var o OrigemSincronizacao
+		var ultimoLote dataHora
+		if err := rows.Scan(&o.Origem, &o.UltimaSequencia, &o.TotalItens, &o.TotalRejeitados, &ultimoLote); err != nil {
+			return nil, fmt.Errorf("erro ao ler origem de sincronização: %v", err)
+		}
+		o.UltimoLote = ultimoLote.ponteiro()
+		origens = append(origens, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler origens de sincronização: %v", err)
+	}
+	return origens, nil
+}
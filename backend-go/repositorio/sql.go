@@ -0,0 +1,764 @@
+package repositorio
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/modelos"
+)
+
+// expressaoOrdemPrioridade converte a prioridade textual numa ordem numérica (ALTA primeiro)
+const expressaoOrdemPrioridade = `CASE df.prioridade WHEN 'ALTA' THEN 1 WHEN 'MEDIA' THEN 2 ELSE 3 END`
+
+// expressaoPesoPrioridade converte a prioridade num peso (ALTA = 3) para a ordenação do histórico
+const expressaoPesoPrioridade = `CASE df.prioridade WHEN 'ALTA' THEN 3 WHEN 'MEDIA' THEN 2 ELSE 1 END`
+
+// condicaoForaJanelaOculta exclui as ocorrências abertas numa janela de manutenção que as oculta
+const condicaoForaJanelaOculta = `NOT EXISTS (
+			SELECT 1 FROM janelas_manutencao jm WHERE jm.id = o.janela_manutencao_id AND jm.ocultar = true)`
+
+// colunasOcorrencia são as colunas lidas por lerOcorrencia (a duração e o SLA dependem do dialeto)
+const colunasOcorrencia = `
+			o.id, o.status, o.timestamp_inicio, o.timestamp_fim,
+			df.id, df.codigo, df.tipo, df.descricao, df.prioridade,
+			df.word_index, df.bit_index, COALESCE(df.classe_mensagem, ''),
+			s.codigo, s.nome,
+			e.codigo, e.nome,
+			o.first_out, o.grupo_first_out_id, o.avalanche_id, o.suprimida_por,
+			df.criticidade, df.relacionada_seguranca, df.tempo_resposta_minutos,
+			o.janela_manutencao_id, COALESCE(o.resolvido_por, ''), COALESCE(o.observacoes, '')`
+
+// juncoesOcorrencia liga a ocorrência à definição, ao setor e à eclusa
+const juncoesOcorrencia = `
+		FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		JOIN setores s ON df.setor_id = s.id
+		JOIN eclusas e ON df.eclusa_id = e.id`
+
+// Novo cria os repositórios para o tipo de armazenamento configurado (config.BancoPostgres ou
+// config.BancoSQLite)
+func Novo(db *sql.DB, tipo string) Repositorios {
+	if tipo == config.BancoSQLite {
+		return NovoSQLite(db)
+	}
+	return NovoPostgres(db)
+}
+
+// NovoPostgres cria os repositórios sobre o banco PostgreSQL
+func NovoPostgres(db *sql.DB) Repositorios {
+	return novoSQL(db, postgres)
+}
+
+// NovoSQLite cria os repositórios sobre o banco SQLite do modo embutido
+func NovoSQLite(db *sql.DB) Repositorios {
+	return novoSQL(db, sqlite)
+}
+
+func novoSQL(db *sql.DB, d dialeto) Repositorios {
+	return Repositorios{
+		Ocorrencias: &ocorrenciasSQL{db: db, d: d},
+		Definicoes:  &definicoesSQL{db: db, d: d},
+		Eclusas:     &eclusasSQL{db: db},
+		Setores:     &setoresSQL{db: db, d: d},
+
+		Estatisticas: &estatisticasSQL{db: db, d: d},
+
+		Sincronizacao: &sincronizacaoSQL{db: db, d: d},
+	}
+}
+
+type ocorrenciasSQL struct {
+	db *sql.DB
+	d  dialeto
+}
+
+// ordenacaoAtivas devolve a cláusula ORDER BY de FiltroAtivas.Ordenar
+func (r *ocorrenciasSQL) ordenacaoAtivas(ordenar string) string {
+	switch ordenar {
+	case "inicio":
+		return "o.timestamp_inicio DESC, o.id DESC"
+	case "prioridade":
+		return expressaoOrdemPrioridade + ", df.criticidade DESC, o.timestamp_inicio DESC, o.id DESC"
+	case "criticidade":
+		return "df.criticidade DESC, " + expressaoOrdemPrioridade + ", o.timestamp_inicio DESC, o.id DESC"
+	case "sla":
+		return r.d.slaViolado + " DESC, " + expressaoOrdemPrioridade + ", o.timestamp_inicio DESC, o.id DESC"
+	}
+	return "o.first_out DESC, o.timestamp_inicio DESC"
+}
+
+// condicoesSeveridade acrescenta os filtros de prioridade e de SLA
+func (r *ocorrenciasSQL) condicoesSeveridade(prioridades []string, slaViolado *bool, args []interface{}) (string, []interface{}) {
+	where := ""
+	if len(prioridades) > 0 {
+		where += " AND df.prioridade IN (" + marcadores(len(prioridades)) + ")"
+		args = append(args, argumentosTexto(prioridades)...)
+	}
+	if slaViolado != nil {
+		if *slaViolado {
+			where += " AND " + r.d.slaViolado
+		} else {
+			where += " AND NOT " + r.d.slaViolado
+		}
+	}
+	return where, args
+}
+
+// ListarAtivas devolve as ocorrências ativas com definição, setor e eclusa
+func (r *ocorrenciasSQL) ListarAtivas(filtro FiltroAtivas) ([]Ocorrencia, error) {
+	query := `
+		SELECT` + colunasOcorrencia + `,
+			` + r.d.slaViolado + ` as sla_violado,
+			(SELECT COUNT(*) FROM ocorrencias_falhas f
+				WHERE f.suprimida_por = o.id AND f.status = 'ATIVO') as total_suprimidas` +
+		juncoesOcorrencia + `
+		WHERE o.status = 'ATIVO'`
+	var args []interface{}
+
+	// Alarmes consequentes ficam escondidos atrás da causa raiz enquanto ela estiver ativa
+	if !filtro.IncluirSuprimidas {
+		query += `
+		AND NOT EXISTS (
+			SELECT 1 FROM ocorrencias_falhas pai
+			WHERE pai.id = o.suprimida_por AND pai.status = 'ATIVO')`
+	}
+	if !filtro.IncluirManutencao {
+		query += `
+		AND ` + condicaoForaJanelaOculta
+	}
+	if filtro.Eclusa != "" {
+		query += " AND e.codigo = ?"
+		args = append(args, filtro.Eclusa)
+	}
+	severidade, args := r.condicoesSeveridade(filtro.Prioridades, filtro.SLAViolado, args)
+	query += severidade + " ORDER BY " + r.ordenacaoAtivas(filtro.Ordenar)
+
+	rows, err := r.db.Query(r.d.sql(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar ocorrências ativas: %v", err)
+	}
+	defer rows.Close()
+
+	ocorrencias := []Ocorrencia{}
+	for rows.Next() {
+		var oc Ocorrencia
+		if err := lerOcorrencia(rows, &oc, &oc.SLAViolado, &oc.TotalSuprimidas); err != nil {
+			return nil, fmt.Errorf("erro ao ler ocorrência ativa: %v", err)
+		}
+		ocorrencias = append(ocorrencias, oc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler ocorrências ativas: %v", err)
+	}
+	return ocorrencias, nil
+}
+
+// condicoesHistorico monta as condições WHERE do histórico (sem a posição)
+func (r *ocorrenciasSQL) condicoesHistorico(filtro FiltroHistorico) (string, []interface{}) {
+	where := " WHERE 1=1"
+	var args []interface{}
+
+	adicionar := func(condicao string, valor interface{}) {
+		where += " AND " + condicao
+		args = append(args, valor)
+	}
+
+	if filtro.Inicio != nil {
+		adicionar("o.timestamp_inicio >= ?", r.d.valorDataHora(*filtro.Inicio))
+	}
+	if filtro.Fim != nil {
+		adicionar("o.timestamp_inicio < ?", r.d.valorDataHora(*filtro.Fim))
+	}
+	if filtro.Eclusa != "" {
+		adicionar("e.codigo = ?", filtro.Eclusa)
+	}
+	if filtro.Setor != "" {
+		adicionar("s.codigo = ?", filtro.Setor)
+	}
+	if filtro.Tipo != "" {
+		adicionar("df.tipo = ?", filtro.Tipo)
+	}
+	if filtro.Status != "" {
+		adicionar("o.status = ?", filtro.Status)
+	}
+	if filtro.Codigo != "" {
+		adicionar("df.codigo = ?", filtro.Codigo)
+	}
+	if filtro.Busca != "" {
+		adicionar(r.d.busca, filtro.Busca)
+	}
+	if filtro.DuracaoMinima > 0 {
+		adicionar(r.d.duracaoSegundos+" >= ?", filtro.DuracaoMinima)
+	}
+	if filtro.Manutencao != nil {
+		if *filtro.Manutencao {
+			where += " AND o.janela_manutencao_id IS NOT NULL"
+		} else {
+			where += " AND o.janela_manutencao_id IS NULL"
+		}
+	}
+
+	severidade, args := r.condicoesSeveridade(filtro.Prioridades, filtro.SLAViolado, args)
+	return where + severidade, args
+}
+
+// chaveHistorico devolve a expressão da chave de ordenação do histórico e o tipo para converter
+// o valor da posição (vazio na ordenação por início, que não tem chave além do desempate).
+// O instante atual é trocado pela referência do filtro.
+func (r *ocorrenciasSQL) chaveHistorico(filtro FiltroHistorico) (string, string) {
+	var expressao, tipo string
+	switch filtro.Ordenar {
+	case "", "inicio":
+		return "", ""
+	case "duracao":
+		expressao, tipo = "CAST("+r.d.duracaoSegundos+" AS NUMERIC)", "NUMERIC"
+	case "prioridade":
+		expressao, tipo = expressaoPesoPrioridade, "INTEGER"
+	case "criticidade":
+		expressao, tipo = "df.criticidade", "INTEGER"
+	case "sla":
+		expressao, tipo = "CASE WHEN "+r.d.slaViolado+" THEN 1 ELSE 0 END", "INTEGER"
+	}
+	if !filtro.Referencia.IsZero() {
+		expressao = strings.ReplaceAll(expressao, r.d.agora, r.d.literalDataHora(filtro.Referencia))
+	}
+	return expressao, tipo
+}
+
+// consultaHistorico monta a consulta do histórico pela ordenação do filtro (desempate por início e
+// ID), a seguir à posição do filtro e sem limite. A última coluna é o valor da chave de ordenação.
+func (r *ocorrenciasSQL) consultaHistorico(filtro FiltroHistorico) (string, []interface{}, error) {
+	where, args := r.condicoesHistorico(filtro)
+
+	chave, tipo := r.chaveHistorico(filtro)
+	if filtro.Ordenar != "" && filtro.Ordenar != "inicio" && chave == "" {
+		return "", nil, fmt.Errorf("ordenação inválida: %s", filtro.Ordenar)
+	}
+	colunaChave, ordem := "''", "o.timestamp_inicio %[1]s, o.id %[1]s"
+	if chave != "" {
+		colunaChave, ordem = "CAST("+chave+" AS TEXT)", chave+" %[1]s, "+ordem
+	}
+
+	operador, direcao := "<", "DESC"
+	if filtro.Ascendente {
+		operador, direcao = ">", "ASC"
+	}
+	if filtro.Apos != nil {
+		inicio := r.d.valorDataHora(filtro.Apos.Inicio)
+		if chave == "" {
+			where += fmt.Sprintf(" AND (o.timestamp_inicio, o.id) %s (?, ?)", operador)
+			args = append(args, inicio, filtro.Apos.ID)
+		} else {
+			where += fmt.Sprintf(" AND (%s, o.timestamp_inicio, o.id) %s (CAST(? AS %s), ?, ?)", chave, operador, tipo)
+			args = append(args, filtro.Apos.Chave, inicio, filtro.Apos.ID)
+		}
+	}
+
+	query := `
+		SELECT` + colunasOcorrencia + `,
+			` + r.d.slaViolado + ` as sla_violado,
+			` + r.d.duracaoSegundos + ` as duracao_segundos,
+			` + colunaChave + juncoesOcorrencia + where +
+		" ORDER BY " + fmt.Sprintf(ordem, direcao)
+	return query, args, nil
+}
+
+// lerOcorrenciaHistorico lê uma linha da consultaHistorico e devolve o valor da chave de ordenação
+func lerOcorrenciaHistorico(rows *sql.Rows, oc *Ocorrencia) (string, error) {
+	var duracaoSegundos sql.NullFloat64
+	var valorChave string
+	if err := lerOcorrencia(rows, oc, &oc.SLAViolado, &duracaoSegundos, &valorChave); err != nil {
+		return "", err
+	}
+	if duracaoSegundos.Valid {
+		duracao := int64(duracaoSegundos.Float64)
+		oc.DuracaoSegundos = &duracao
+	}
+	return valorChave, nil
+}
+
+// ListarHistorico devolve uma página do histórico pela ordenação do filtro (desempate por início e ID)
+func (r *ocorrenciasSQL) ListarHistorico(filtro FiltroHistorico) (PaginaHistorico, error) {
+	query, args, err := r.consultaHistorico(filtro)
+	if err != nil {
+		return PaginaHistorico{}, err
+	}
+	// Uma linha a mais indica que há próxima página
+	if filtro.Limite > 0 {
+		query += " LIMIT ?"
+		args = append(args, filtro.Limite+1)
+	}
+
+	rows, err := r.db.Query(r.d.sql(query), args...)
+	if err != nil {
+		return PaginaHistorico{}, fmt.Errorf("erro ao buscar histórico: %v", err)
+	}
+	defer rows.Close()
+
+	pagina := PaginaHistorico{Ocorrencias: []Ocorrencia{}}
+	var ultima PosicaoHistorico
+	for rows.Next() {
+		if filtro.Limite > 0 && len(pagina.Ocorrencias) == filtro.Limite {
+			pagina.Proxima = &ultima
+			break
+		}
+
+		var oc Ocorrencia
+		valorChave, err := lerOcorrenciaHistorico(rows, &oc)
+		if err != nil {
+			return PaginaHistorico{}, fmt.Errorf("erro ao ler histórico: %v", err)
+		}
+		ultima = PosicaoHistorico{Chave: valorChave, Inicio: oc.TimestampInicio, ID: oc.ID}
+		pagina.Ocorrencias = append(pagina.Ocorrencias, oc)
+	}
+	if err := rows.Err(); err != nil {
+		return PaginaHistorico{}, fmt.Errorf("erro ao ler histórico: %v", err)
+	}
+	return pagina, nil
+}
+
+// PercorrerHistorico entrega as ocorrências do histórico à medida que são lidas do banco
+func (r *ocorrenciasSQL) PercorrerHistorico(filtro FiltroHistorico, visitar func(Ocorrencia) error) error {
+	filtro.Apos, filtro.Limite = nil, 0
+	query, args, err := r.consultaHistorico(filtro)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.db.Query(r.d.sql(query), args...)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar histórico: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var oc Ocorrencia
+		if _, err := lerOcorrenciaHistorico(rows, &oc); err != nil {
+			return fmt.Errorf("erro ao ler histórico: %v", err)
+		}
+		if err := visitar(oc); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("erro ao ler histórico: %v", err)
+	}
+	return nil
+}
+
+// ContarHistorico conta as ocorrências do histórico que atendem aos filtros
+func (r *ocorrenciasSQL) ContarHistorico(filtro FiltroHistorico) (int, error) {
+	where, args := r.condicoesHistorico(filtro)
+
+	var total int
+	err := r.db.QueryRow(r.d.sql("SELECT COUNT(*)"+juncoesOcorrencia+where), args...).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao contar histórico: %v", err)
+	}
+	return total, nil
+}
+
+// ExisteAtiva indica se a definição já tem uma ocorrência ativa
+func (r *ocorrenciasSQL) ExisteAtiva(definicaoID int) (bool, error) {
+	var existe bool
+	err := r.db.QueryRow(r.d.sql(`
+		SELECT EXISTS(SELECT 1 FROM ocorrencias_falhas WHERE definicao_id = ? AND status = 'ATIVO')`),
+		definicaoID).Scan(&existe)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar ocorrência ativa da definição %d: %v", definicaoID, err)
+	}
+	return existe, nil
+}
+
+// Abrir grava uma nova ocorrência ATIVO e devolve o seu ID
+func (r *ocorrenciasSQL) Abrir(nova NovaOcorrencia) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(r.d.sql(`
+		INSERT INTO ocorrencias_falhas
+		(definicao_id, status, timestamp_inicio, first_out, grupo_first_out_id, avalanche_id, suprimida_por, dados_contexto, janela_manutencao_id)
+		VALUES (?, 'ATIVO', ?, ?, ?, ?, ?, `+r.d.json+`, ?)
+		RETURNING id`),
+		nova.DefinicaoID, r.d.valorDataHora(nova.Inicio), nova.FirstOut,
+		nuloSeZero(nova.GrupoFirstOutID), nuloSeZero(nova.AvalancheID), nuloSeZero(nova.SuprimidaPor),
+		nova.Contexto, nuloSeZero(nova.JanelaManutencaoID)).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao registrar ocorrência da definição %d: %v", nova.DefinicaoID, err)
+	}
+	return id, nil
+}
+
+// ResolverAtivas resolve (pelo PLC) as ocorrências ativas da definição e devolve os IDs resolvidos
+func (r *ocorrenciasSQL) ResolverAtivas(definicaoID int, dataHora time.Time) ([]int64, error) {
+	rows, err := r.db.Query(r.d.sql(`
+		UPDATE ocorrencias_falhas
+		SET status = 'RESOLVIDO', timestamp_fim = ?, resolvido_por = 'PLC'
+		WHERE definicao_id = ? AND status = 'ATIVO'
+		RETURNING id`),
+		r.d.valorDataHora(dataHora), definicaoID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resolver ocorrência da definição %d: %v", definicaoID, err)
+	}
+	defer rows.Close()
+
+	var resolvidas []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return resolvidas, fmt.Errorf("erro ao ler ocorrência resolvida: %v", err)
+		}
+		resolvidas = append(resolvidas, id)
+	}
+	if err := rows.Err(); err != nil {
+		return resolvidas, fmt.Errorf("erro ao ler ocorrências resolvidas: %v", err)
+	}
+	return resolvidas, nil
+}
+
+// ResolverManualmente resolve uma ocorrência ativa pelo utilizador; false se não estava ativa
+func (r *ocorrenciasSQL) ResolverManualmente(id int64) (bool, error) {
+	resultado, err := r.db.Exec(r.d.sql(`
+		UPDATE ocorrencias_falhas
+		SET status = 'RESOLVIDO', timestamp_fim = `+r.d.agora+`, resolvido_por = 'USUARIO_MANUAL'
+		WHERE id = ? AND status = 'ATIVO'`), id)
+	if err != nil {
+		return false, fmt.Errorf("erro ao resolver ocorrência %d: %v", id, err)
+	}
+	afetadas, err := resultado.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar resultado: %v", err)
+	}
+	return afetadas > 0, nil
+}
+
+// RegistrarTransicao grava uma mudança de estado de ocorrência
+func (r *ocorrenciasSQL) RegistrarTransicao(t Transicao) error {
+	var timestamp interface{}
+	if !t.Timestamp.IsZero() {
+		timestamp = r.d.valorDataHora(t.Timestamp)
+	}
+	_, err := r.db.Exec(r.d.sql(`
+		INSERT INTO transicoes_ocorrencias (ocorrencia_id, status_anterior, status_novo, timestamp, origem, observacao)
+		VALUES (?, NULLIF(?, ''), ?, COALESCE(?, `+r.d.agora+`), ?, NULLIF(?, ''))`),
+		t.OcorrenciaID, t.StatusAnterior, t.StatusNovo, timestamp, t.Origem, t.Observacao)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar transição da ocorrência %d: %v", t.OcorrenciaID, err)
+	}
+	return nil
+}
+
+// BuscarCausaRaizAtiva devolve a ocorrência ativa de uma definição causa raiz da informada (0 = nenhuma)
+func (r *ocorrenciasSQL) BuscarCausaRaizAtiva(definicaoID int) (int64, error) {
+	var paiID int64
+	err := r.db.QueryRow(r.d.sql(`
+		SELECT o.id FROM relacoes_supressao r
+		JOIN ocorrencias_falhas o ON o.definicao_id = r.definicao_pai_id
+		WHERE r.definicao_filha_id = ? AND r.ativa = true AND o.status = 'ATIVO'
+		ORDER BY o.timestamp_inicio, o.id
+		LIMIT 1`), definicaoID).Scan(&paiID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("erro ao verificar supressão da definição %d: %v", definicaoID, err)
+	}
+	return paiID, nil
+}
+
+// BuscarJanelaManutencao devolve a janela de manutenção em vigor na eclusa/setor (0 = nenhuma)
+func (r *ocorrenciasSQL) BuscarJanelaManutencao(eclusaID, setorID int, dataHora time.Time) (int64, error) {
+	var janelaID int64
+	instante := r.d.valorDataHora(dataHora)
+	err := r.db.QueryRow(r.d.sql(`
+		SELECT id FROM janelas_manutencao
+		WHERE eclusa_id = ? AND (setor_id IS NULL OR setor_id = ?)
+		AND inicio <= ? AND COALESCE(terminada_em, fim) > ?
+		ORDER BY ocultar DESC, id
+		LIMIT 1`), eclusaID, setorID, instante, instante).Scan(&janelaID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("erro ao verificar janela de manutenção: %v", err)
+	}
+	return janelaID, nil
+}
+
+// AbrirAvalanche regista uma avalanche na eclusa e associa-lhe as ocorrências abertas desde 'desde'
+func (r *ocorrenciasSQL) AbrirAvalanche(eclusaID int, desde, dataHora time.Time, totalAlarmes int) (int64, error) {
+	var avalancheID int64
+	inicioJanela := r.d.valorDataHora(desde)
+	err := r.db.QueryRow(r.d.sql(`
+		INSERT INTO avalanches_alarmes (eclusa_id, timestamp_inicio, total_alarmes, first_out_ocorrencia_id)
+		SELECT CAST(? AS INTEGER), COALESCE(MIN(o.timestamp_inicio), ?), CAST(? AS INTEGER), (
+			SELECT fo.id FROM ocorrencias_falhas fo
+			JOIN definicoes_falhas fd ON fo.definicao_id = fd.id
+			WHERE fd.eclusa_id = ? AND fo.first_out = true AND fo.timestamp_inicio >= ?
+			ORDER BY fo.timestamp_inicio, fo.id LIMIT 1)
+		FROM ocorrencias_falhas o
+		JOIN definicoes_falhas df ON o.definicao_id = df.id
+		WHERE df.eclusa_id = ? AND o.timestamp_inicio >= ?
+		RETURNING id`),
+		eclusaID, r.d.valorDataHora(dataHora), totalAlarmes-1,
+		eclusaID, inicioJanela,
+		eclusaID, inicioJanela).Scan(&avalancheID)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao registrar avalanche: %v", err)
+	}
+
+	// Associar as ocorrências da janela que originaram a avalanche
+	_, err = r.db.Exec(r.d.sql(`
+		UPDATE ocorrencias_falhas SET avalanche_id = ?
+		WHERE avalanche_id IS NULL AND timestamp_inicio >= ?
+		AND definicao_id IN (SELECT id FROM definicoes_falhas WHERE eclusa_id = ?)`),
+		avalancheID, inicioJanela, eclusaID)
+	if err != nil {
+		return avalancheID, fmt.Errorf("erro ao associar ocorrências à avalanche %d: %v", avalancheID, err)
+	}
+	return avalancheID, nil
+}
+
+// ContarAlarmeAvalanche soma um alarme à avalanche
+func (r *ocorrenciasSQL) ContarAlarmeAvalanche(avalancheID int64) error {
+	_, err := r.db.Exec(r.d.sql(`
+		UPDATE avalanches_alarmes SET total_alarmes = total_alarmes + 1 WHERE id = ?`), avalancheID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar avalanche %d: %v", avalancheID, err)
+	}
+	return nil
+}
+
+// FecharAvalanche regista o fim da avalanche
+func (r *ocorrenciasSQL) FecharAvalanche(avalancheID int64, dataHora time.Time) error {
+	_, err := r.db.Exec(r.d.sql(`
+		UPDATE avalanches_alarmes SET timestamp_fim = ? WHERE id = ?`), r.d.valorDataHora(dataHora), avalancheID)
+	if err != nil {
+		return fmt.Errorf("erro ao encerrar avalanche %d: %v", avalancheID, err)
+	}
+	return nil
+}
+
+// lerOcorrencia lê as colunasOcorrencia seguidas das colunas extra da query
+func lerOcorrencia(rows *sql.Rows, oc *Ocorrencia, extras ...interface{}) error {
+	var inicio, fim dataHora
+	var grupoFirstOut, avalanche, suprimidaPor sql.NullInt64
+	var tempoResposta, janelaManutencao sql.NullInt64
+
+	destinos := []interface{}{
+		&oc.ID, &oc.Status, &inicio, &fim,
+		&oc.DefinicaoID, &oc.Codigo, &oc.Tipo, &oc.Descricao, &oc.Prioridade,
+		&oc.WordIndex, &oc.BitIndex, &oc.ClasseMensagem,
+		&oc.SetorCodigo, &oc.SetorNome,
+		&oc.EclusaCodigo, &oc.EclusaNome,
+		&oc.FirstOut, &grupoFirstOut, &avalanche, &suprimidaPor,
+		&oc.Criticidade, &oc.RelacionadaSeguranca, &tempoResposta,
+		&janelaManutencao, &oc.ResolvidoPor, &oc.Observacoes,
+	}
+	if err := rows.Scan(append(destinos, extras...)...); err != nil {
+		return err
+	}
+
+	oc.TimestampInicio = inicio.Time
+	oc.TimestampFim = fim.ponteiro()
+	oc.GrupoFirstOutID = ponteiroInt64(grupoFirstOut)
+	oc.AvalancheID = ponteiroInt64(avalanche)
+	oc.SuprimidaPor = ponteiroInt64(suprimidaPor)
+	oc.TempoRespostaMinutos = ponteiroInt(tempoResposta)
+	oc.JanelaManutencaoID = ponteiroInt64(janelaManutencao)
+	return nil
+}
+
+type definicoesSQL struct {
+	db *sql.DB
+	d  dialeto
+}
+
+// Listar devolve as definições com endereço no PLC, ordenadas por word e bit
+func (r *definicoesSQL) Listar(filtro FiltroDefinicoes) ([]Definicao, error) {
+	query := `
+		SELECT
+			df.id, df.codigo, df.tipo, df.descricao, df.prioridade,
+			df.word_index, df.bit_index, COALESCE(df.classe_mensagem, ''), COALESCE(df.ativa, true),
+			s.codigo, s.nome,
+			e.codigo, e.nome,
+			df.criticidade, df.relacionada_seguranca, df.tempo_resposta_minutos
+		FROM definicoes_falhas df
+		JOIN setores s ON df.setor_id = s.id
+		JOIN eclusas e ON df.eclusa_id = e.id
+		WHERE df.word_index IS NOT NULL AND df.bit_index IS NOT NULL`
+	var args []interface{}
+
+	if filtro.Eclusa != "" {
+		query += " AND e.codigo = ?"
+		args = append(args, filtro.Eclusa)
+	}
+	if filtro.Setor != "" {
+		query += " AND s.codigo = ?"
+		args = append(args, filtro.Setor)
+	}
+	if filtro.Tipo != "" {
+		query += " AND df.tipo = ?"
+		args = append(args, filtro.Tipo)
+	}
+	if filtro.Prioridade != "" {
+		query += " AND df.prioridade = ?"
+		args = append(args, filtro.Prioridade)
+	}
+	query += " ORDER BY df.word_index, df.bit_index"
+
+	rows, err := r.db.Query(r.d.sql(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar definições: %v", err)
+	}
+	defer rows.Close()
+
+	definicoes := []Definicao{}
+	for rows.Next() {
+		var def Definicao
+		var tempoResposta sql.NullInt64
+
+		err := rows.Scan(
+			&def.ID, &def.Codigo, &def.Tipo, &def.Descricao, &def.Prioridade,
+			&def.WordIndex, &def.BitIndex, &def.ClasseMensagem, &def.Ativa,
+			&def.SetorCodigo, &def.SetorNome,
+			&def.EclusaCodigo, &def.EclusaNome,
+			&def.Criticidade, &def.RelacionadaSeguranca, &tempoResposta)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler definição: %v", err)
+		}
+		def.TempoRespostaMinutos = ponteiroInt(tempoResposta)
+
+		definicoes = append(definicoes, def)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler definições: %v", err)
+	}
+	return definicoes, nil
+}
+
+// ListarMapeadas devolve as definições ativas com endereço no PLC para o mapeamento de tags
+func (r *definicoesSQL) ListarMapeadas() ([]modelos.DefinicaoFalha, error) {
+	rows, err := r.db.Query(`
+		SELECT
+			df.id, df.eclusa_id, df.setor_id, df.codigo, df.tipo, df.descricao, df.prioridade,
+			df.point_index, df.word_index, df.bit_index, COALESCE(df.classe_mensagem, ''),
+			df.criticidade, df.relacionada_seguranca, COALESCE(df.tempo_resposta_minutos, 0),
+			s.codigo, s.nome, e.codigo
+		FROM definicoes_falhas df
+		JOIN setores s ON df.setor_id = s.id
+		JOIN eclusas e ON df.eclusa_id = e.id
+		WHERE df.ativa = true
+		AND df.word_index IS NOT NULL
+		AND df.bit_index IS NOT NULL
+		ORDER BY df.word_index, df.bit_index`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar definições do banco: %v", err)
+	}
+	defer rows.Close()
+
+	var definicoes []modelos.DefinicaoFalha
+	for rows.Next() {
+		falha := modelos.DefinicaoFalha{Ativa: true}
+		err := rows.Scan(
+			&falha.ID, &falha.EclusaID, &falha.SetorID, &falha.Codigo, &falha.Tipo, &falha.Descricao, &falha.Prioridade,
+			&falha.PointIndex, &falha.WordIndex, &falha.BitIndex, &falha.ClasseMensagem,
+			&falha.Criticidade, &falha.RelacionadaSeguranca, &falha.TempoRespostaMinutos,
+			&falha.SetorCodigo, &falha.SetorNome, &falha.EclusaCodigo)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler definição do banco: %v", err)
+		}
+		definicoes = append(definicoes, falha)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler definições do banco: %v", err)
+	}
+	return definicoes, nil
+}
+
+type eclusasSQL struct {
+	db *sql.DB
+}
+
+// Listar devolve as eclusas por nome
+func (r *eclusasSQL) Listar() ([]Eclusa, error) {
+	rows, err := r.db.Query(`
+		SELECT id, codigo, nome, COALESCE(localizacao, ''), COALESCE(ativa, true)
+		FROM eclusas ORDER BY nome`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar eclusas: %v", err)
+	}
+	defer rows.Close()
+
+	eclusas := []Eclusa{}
+	for rows.Next() {
+		var eclusa Eclusa
+		if err := rows.Scan(&eclusa.ID, &eclusa.Codigo, &eclusa.Nome, &eclusa.Localizacao, &eclusa.Ativa); err != nil {
+			return nil, fmt.Errorf("erro ao ler eclusa: %v", err)
+		}
+		eclusas = append(eclusas, eclusa)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler eclusas: %v", err)
+	}
+	return eclusas, nil
+}
+
+type setoresSQL struct {
+	db *sql.DB
+	d  dialeto
+}
+
+// Listar devolve os setores por nome (só os com definições na eclusa, se informada)
+func (r *setoresSQL) Listar(eclusa string) ([]Setor, error) {
+	query := `SELECT id, codigo, nome, COALESCE(cor_tema, '') FROM setores`
+	var args []interface{}
+	if eclusa != "" {
+		query += `
+		WHERE EXISTS (
+			SELECT 1 FROM definicoes_falhas df JOIN eclusas e ON df.eclusa_id = e.id
+			WHERE df.setor_id = setores.id AND e.codigo = ?)`
+		args = append(args, eclusa)
+	}
+	rows, err := r.db.Query(r.d.sql(query+" ORDER BY nome"), args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar setores: %v", err)
+	}
+	defer rows.Close()
+
+	setores := []Setor{}
+	for rows.Next() {
+		var setor Setor
+		if err := rows.Scan(&setor.ID, &setor.Codigo, &setor.Nome, &setor.CorTema); err != nil {
+			return nil, fmt.Errorf("erro ao ler setor: %v", err)
+		}
+		setores = append(setores, setor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler setores: %v", err)
+	}
+	return setores, nil
+}
+
+// nuloSeZero converte IDs zerados em NULL
+func nuloSeZero(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+// ponteiroInt64 converte um sql.NullInt64 em ponteiro (nil quando NULL)
+func ponteiroInt64(valor sql.NullInt64) *int64 {
+	if !valor.Valid {
+		return nil
+	}
+	return &valor.Int64
+}
+
+// ponteiroInt converte um sql.NullInt64 em ponteiro para int (nil quando NULL)
+func ponteiroInt(valor sql.NullInt64) *int {
+	if !valor.Valid {
+		return nil
+	}
+	inteiro := int(valor.Int64)
+	return &inteiro
+}
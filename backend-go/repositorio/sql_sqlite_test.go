@@ -0,0 +1,216 @@
+//go:build sqlite
+
+package repositorio_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/database"
+	"github.com/edp/falhas-backend/modelos"
+	"github.com/edp/falhas-backend/repositorio"
+)
+
+// repositoriosSQLite abre um banco embutido novo (esquema e definições da Régua) e devolve o banco,
+// os repositórios sobre ele e duas definições mapeadas da mesma eclusa
+func repositoriosSQLite(t *testing.T) (*sql.DB, repositorio.Repositorios, []modelos.DefinicaoFalha) {
+	t.Helper()
+	db, err := database.AbrirSQLite(&config.Configuracoes{SQLite_Arquivo: filepath.Join(t.TempDir(), "falhas.db")})
+	if err != nil {
+		t.Fatalf("AbrirSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repos := repositorio.NovoSQLite(db)
+	definicoes, err := repos.Definicoes.ListarMapeadas()
+	if err != nil {
+		t.Fatalf("ListarMapeadas: %v", err)
+	}
+	var escolhidas []modelos.DefinicaoFalha
+	for _, d := range definicoes {
+		if len(escolhidas) < 2 && (len(escolhidas) == 0 || d.EclusaID == escolhidas[0].EclusaID) {
+			escolhidas = append(escolhidas, d)
+		}
+	}
+	if len(escolhidas) < 2 {
+		t.Fatalf("o esquema embutido tem %d definições mapeadas; esperadas pelo menos 2", len(definicoes))
+	}
+	return db, repos, escolhidas
+}
+
+func TestOcorrenciasSQLiteAbrirResolverEPaginar(t *testing.T) {
+	_, repos, definicoes := repositoriosSQLite(t)
+	ocorrencias := repos.Ocorrencias
+	base := time.Now().Add(-6 * time.Hour).Truncate(time.Second)
+
+	// Cinco ocorrências resolvidas, alternando as duas definições, e uma ativa
+	for i := 0; i < 5; i++ {
+		definicaoID := definicoes[i%2].ID
+		if _, err := ocorrencias.Abrir(repositorio.NovaOcorrencia{DefinicaoID: definicaoID, Inicio: base.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("Abrir: %v", err)
+		}
+		resolvidas, err := ocorrencias.ResolverAtivas(definicaoID, base.Add(time.Duration(i+1)*10*time.Minute))
+		if err != nil || len(resolvidas) != 1 {
+			t.Fatalf("ResolverAtivas = %v, %v; esperada 1 ocorrência", resolvidas, err)
+		}
+	}
+	ativa, err := ocorrencias.Abrir(repositorio.NovaOcorrencia{DefinicaoID: definicoes[0].ID, Inicio: base.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Abrir: %v", err)
+	}
+	if existe, err := ocorrencias.ExisteAtiva(definicoes[0].ID); err != nil || !existe {
+		t.Fatalf("ExisteAtiva = %v, %v; esperado true", existe, err)
+	}
+
+	ativas, err := ocorrencias.ListarAtivas(repositorio.FiltroAtivas{})
+	if err != nil {
+		t.Fatalf("ListarAtivas: %v", err)
+	}
+	if len(ativas) != 1 || ativas[0].ID != ativa || !ativas[0].TimestampInicio.Equal(base.Add(time.Hour)) {
+		t.Fatalf("ativas = %+v; esperada a ocorrência %d com o início gravado", ativas, ativa)
+	}
+
+	// Paginação pela chave de início (a única ordenação do modo embutido), nos dois sentidos
+	for _, ascendente := range []bool{true, false} {
+		filtro := repositorio.FiltroHistorico{Ordenar: "inicio", Ascendente: ascendente, Limite: 2, Referencia: time.Now()}
+		total, err := ocorrencias.ContarHistorico(filtro)
+		if err != nil || total != 6 {
+			t.Fatalf("ContarHistorico = %d, %v; esperado 6", total, err)
+		}
+		var anterior time.Time
+		vistos := make(map[int64]bool)
+		for pagina := 0; pagina < 10; pagina++ {
+			resultado, err := ocorrencias.ListarHistorico(filtro)
+			if err != nil {
+				t.Fatalf("ListarHistorico: %v", err)
+			}
+			for _, o := range resultado.Ocorrencias {
+				if vistos[o.ID] {
+					t.Fatalf("ocorrência %d repetida", o.ID)
+				}
+				if !anterior.IsZero() && (o.TimestampInicio.Before(anterior) == ascendente && !o.TimestampInicio.Equal(anterior)) {
+					t.Fatalf("ocorrência %d fora de ordem (%v depois de %v)", o.ID, o.TimestampInicio, anterior)
+				}
+				vistos[o.ID], anterior = true, o.TimestampInicio
+			}
+			if resultado.Proxima == nil {
+				break
+			}
+			filtro.Apos = resultado.Proxima
+		}
+		if len(vistos) != 6 {
+			t.Fatalf("ascendente=%v: %d ocorrências percorridas; esperado 6", ascendente, len(vistos))
+		}
+
+		// A exportação percorre tudo de uma vez, pela mesma ordem, ignorando a posição e o limite
+		percorridas := 0
+		err = ocorrencias.PercorrerHistorico(filtro, func(o repositorio.Ocorrencia) error {
+			percorridas++
+			return nil
+		})
+		if err != nil || percorridas != 6 {
+			t.Fatalf("PercorrerHistorico = %d, %v; esperado 6", percorridas, err)
+		}
+	}
+
+	// Filtro de duração (cálculo de datas no SQLite): durações de 10, 19, 28, 37 e 46 minutos
+	duracao, err := ocorrencias.ContarHistorico(repositorio.FiltroHistorico{Status: "RESOLVIDO", DuracaoMinima: 30 * 60})
+	if err != nil || duracao != 2 {
+		t.Fatalf("ContarHistorico(duração >= 30min) = %d, %v; esperado 2", duracao, err)
+	}
+
+	stats, err := repos.Estatisticas.Dashboard("")
+	if err != nil {
+		t.Fatalf("Dashboard: %v", err)
+	}
+	if stats.OcorrenciasAtivas != 1 || stats.TotalOcorrencias != 6 || stats.FalhasUltimas24h+stats.EventosUltimas24h != 6 {
+		t.Fatalf("dashboard = %+v; esperadas 1 ativa e 6 ocorrências nas últimas 24h", stats)
+	}
+	if stats.TempoMedioResolucao <= 0 {
+		t.Fatalf("tempo médio de resolução = %v; esperado > 0", stats.TempoMedioResolucao)
+	}
+}
+
+func TestSincronizacaoSQLiteFilaEUpsert(t *testing.T) {
+	db, repos, definicoes := repositoriosSQLite(t)
+	inicio := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	// Fila local: enfileirar, ler o estado atual e marcar como enviado
+	id, err := repos.Ocorrencias.Abrir(repositorio.NovaOcorrencia{DefinicaoID: definicoes[0].ID, Inicio: inicio})
+	if err != nil {
+		t.Fatalf("Abrir: %v", err)
+	}
+	if err := repos.Sincronizacao.EnfileirarOcorrencia(id); err != nil {
+		t.Fatalf("EnfileirarOcorrencia: %v", err)
+	}
+	pendentes, err := repos.Sincronizacao.ListarPendentes(10)
+	if err != nil || len(pendentes) != 1 || pendentes[0].Ocorrencia == nil || pendentes[0].Ocorrencia.Status != "ATIVO" ||
+		pendentes[0].Ocorrencia.Criacao == nil {
+		t.Fatalf("ListarPendentes = %+v, %v; esperado o item da ocorrência ativa", pendentes, err)
+	}
+	if err := repos.Sincronizacao.MarcarEnviados(pendentes[0].Sequencia); err != nil {
+		t.Fatalf("MarcarEnviados: %v", err)
+	}
+	if estado, err := repos.Sincronizacao.EstadoFila(); err != nil || estado.Pendentes != 0 || estado.UltimoEnvio == nil {
+		t.Fatalf("EstadoFila = %+v, %v; esperada a fila vazia com último envio", estado, err)
+	}
+
+	// Central: o mesmo item aplicado duas vezes atualiza a ocorrência (ON CONFLICT) sem a duplicar
+	oc := *pendentes[0].Ocorrencia
+	aplicar := func(sequencia int64, oc repositorio.OcorrenciaSincronizada) repositorio.ResultadoLote {
+		t.Helper()
+		resultado, err := repos.Sincronizacao.AplicarLote("REMOTA", []repositorio.ItemSincronizacao{
+			{Sequencia: sequencia, Tipo: repositorio.ItemOcorrencia, OcorrenciaID: 500, Ocorrencia: &oc},
+		})
+		if err != nil {
+			t.Fatalf("AplicarLote: %v", err)
+		}
+		return resultado
+	}
+	if resultado := aplicar(1, oc); resultado.Aplicados != 1 {
+		t.Fatalf("primeiro lote = %+v; esperado 1 aplicado", resultado)
+	}
+	fim := inicio.Add(30 * time.Minute)
+	oc.Status, oc.TimestampFim = "RESOLVIDO", &fim
+	if resultado := aplicar(2, oc); resultado.Aplicados != 1 || resultado.UltimaSequencia != 2 {
+		t.Fatalf("segundo lote = %+v; esperado 1 aplicado até à sequência 2", resultado)
+	}
+	total, err := repos.Ocorrencias.ContarHistorico(repositorio.FiltroHistorico{Status: "RESOLVIDO"})
+	if err != nil || total != 1 {
+		t.Fatalf("ocorrências resolvidas = %d, %v; esperada 1 (a sincronizada)", total, err)
+	}
+
+	// O created_at é o da eclusa (um atraso no envio não torna a ocorrência nova no central) e
+	// as atualizações seguintes não o alteram
+	oc.Criacao = &inicio
+	aplicar(3, oc)
+	var criacao string
+	if err := db.QueryRow("SELECT created_at FROM ocorrencias_falhas WHERE origem_eclusa = 'REMOTA'").Scan(&criacao); err != nil {
+		t.Fatalf("ler created_at: %v", err)
+	}
+	if lida, err := time.Parse(time.RFC3339Nano, criacao); err != nil || !lida.Equal(*pendentes[0].Ocorrencia.Criacao) {
+		t.Fatalf("created_at no central = %s; esperado o da eclusa %v", criacao, *pendentes[0].Ocorrencia.Criacao)
+	}
+
+	// Uma transição de uma ocorrência que o central não tem é rejeitada
+	transicao := &repositorio.TransicaoSincronizada{StatusNovo: "RESOLVIDO", Timestamp: inicio, Origem: "PLC"}
+	resultado, err := repos.Sincronizacao.AplicarLote("REMOTA", []repositorio.ItemSincronizacao{
+		{Sequencia: 4, Tipo: repositorio.ItemTransicao, OcorrenciaID: 999, Transicao: transicao},
+	})
+	if err != nil || len(resultado.Rejeitados) != 1 || resultado.UltimaSequencia != 4 {
+		t.Fatalf("transição sem ocorrência = %+v, %v; esperado 1 rejeitado até à sequência 4", resultado, err)
+	}
+
+	// Uma definição que não existe é rejeitada e a sequência avança
+	oc.PointIndex = -1
+	if resultado := aplicar(5, oc); len(resultado.Rejeitados) != 1 || resultado.UltimaSequencia != 5 {
+		t.Fatalf("lote com definição inexistente = %+v; esperado 1 rejeitado até à sequência 5", resultado)
+	}
+	origens, err := repos.Sincronizacao.ListarOrigens()
+	if err != nil || len(origens) != 1 || origens[0].TotalItens != 3 || origens[0].TotalRejeitados != 2 {
+		t.Fatalf("ListarOrigens = %+v, %v; esperados 3 itens e 2 rejeitados", origens, err)
+	}
+}
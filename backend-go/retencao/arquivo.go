@@ -0,0 +1,486 @@
+package retencao
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/edp/falhas-backend/database"
+)
+
+// Um arquivo é um ficheiro gzip com uma linha JSON por registo: o cabeçalho, as ocorrências de um
+// mês e tipo, as suas transições, os envios de notificação, escalonamentos e ligações a ordens de
+// trabalho e, no fim, o rodapé com as contagens (um ficheiro sem rodapé ficou incompleto). Os
+// registos guardam as colunas tal como estão no banco (row_to_json). A versão 1 só tinha
+// ocorrências e transições e continua a ser restaurada.
+const (
+	formatoArquivo = "ocorrencias_falhas"
+	versaoArquivo  = 2
+
+	// versaoEsquemaParticionado é a migração que particionou ocorrencias_falhas
+	versaoEsquemaParticionado = 4
+
+	// linhasPorInsercao agrupa os registos restaurados numa única instrução
+	linhasPorInsercao = 500
+)
+
+// tabelasArquivadas são as tabelas gravadas no arquivo, pela ordem em que são restauradas
+var tabelasArquivadas = []string{
+	"ocorrencias_falhas", "transicoes_ocorrencias", "envios_notificacao",
+	"escalonamentos_ocorrencias", "ordens_trabalho_ocorrencias",
+}
+
+// tabelasVinculo são as tabelas arquivadas ligadas à ocorrência além das transições (contadas no
+// rodapé em Vinculos), com a ordem das suas linhas no arquivo
+var tabelasVinculo = map[string]string{
+	"envios_notificacao":          "x.id",
+	"escalonamentos_ocorrencias":  "x.ocorrencia_id",
+	"ordens_trabalho_ocorrencias": "x.ocorrencia_id, x.ordem_id",
+}
+
+// selecaoRestauro lê um lote de linhas do arquivo ($1) com as colunas da tabela. As referências a
+// regras, políticas e ordens de trabalho apagadas depois do arquivo não podem voltar: a regra e a
+// política ficam NULL (como no ON DELETE SET NULL) e a ligação à ordem é descartada (ON DELETE CASCADE).
+var selecaoRestauro = map[string]string{
+	"envios_notificacao": `SELECT a.* FROM json_populate_recordset(NULL::envios_notificacao, $1) r,
+		LATERAL jsonb_populate_record(r, jsonb_build_object('regra_id',
+			(SELECT rn.id FROM regras_notificacao rn WHERE rn.id = r.regra_id))) a`,
+	"escalonamentos_ocorrencias": `SELECT a.* FROM json_populate_recordset(NULL::escalonamentos_ocorrencias, $1) r,
+		LATERAL jsonb_populate_record(r, jsonb_build_object('politica_id',
+			(SELECT pe.id FROM politicas_escalonamento pe WHERE pe.id = r.politica_id))) a`,
+	"ordens_trabalho_ocorrencias": `SELECT r.* FROM json_populate_recordset(NULL::ordens_trabalho_ocorrencias, $1) r
+		WHERE EXISTS (SELECT 1 FROM ordens_trabalho ot WHERE ot.id = r.ordem_id)`,
+}
+
+// Cabecalho é a primeira linha de um arquivo
+type Cabecalho struct {
+	Formato       string    `json:"formato"`
+	Versao        int       `json:"versao"`
+	VersaoEsquema int       `json:"versao_esquema"`
+	Mes           string    `json:"mes"` // AAAA-MM
+	Tipo          string    `json:"tipo"`
+	GeradoEm      time.Time `json:"gerado_em"`
+}
+
+// linhaArquivo é um registo (tabela e dados) ou o rodapé (fim e contagens)
+type linhaArquivo struct {
+	Tabela      string          `json:"tabela,omitempty"`
+	Dados       json.RawMessage `json:"dados,omitempty"`
+	Fim         bool            `json:"fim,omitempty"`
+	Ocorrencias int             `json:"ocorrencias,omitempty"`
+	Transicoes  int             `json:"transicoes,omitempty"`
+	Vinculos    map[string]int  `json:"vinculos,omitempty"` // Linhas de cada tabela de tabelasVinculo
+}
+
+// Arquivo é um ficheiro gerado pela retenção (registado em arquivos_ocorrencias)
+type Arquivo struct {
+	Arquivo      string     `json:"arquivo"`
+	Mes          time.Time  `json:"mes"`
+	Tipo         string     `json:"tipo"`
+	Ocorrencias  int        `json:"ocorrencias"`
+	Transicoes   int        `json:"transicoes"`
+	TamanhoBytes int64      `json:"tamanho_bytes"`
+	SHA256       string     `json:"sha256"`
+	CriadoEm     time.Time  `json:"criado_em"`
+	RestauradoEm *time.Time `json:"restaurado_em,omitempty"`
+	ManterAte    *time.Time `json:"manter_ate,omitempty"`
+}
+
+// arquivar grava num ficheiro as ocorrências não ativas de um tipo numa partição, com as suas
+// transições, envios, escalonamentos e ligações a ordens de trabalho, e apaga-as com esses registos
+// e os itens já enviados da fila de sincronização. As ocorrências que ficam no banco e eram
+// suprimidas por uma arquivada perdem suprimida_por. Tudo corre numa transação REPEATABLE READ: o
+// que é apagado é exatamente o que foi escrito, e nada fica a referir as ocorrências apagadas.
+// Devolve nil quando não há nada a arquivar.
+func arquivar(db *sql.DB, diretorio, particao string, mes time.Time, tipo string, agora time.Time) (*Arquivo, error) {
+	versaoEsquema, err := database.VersaoEsquema(db)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	// As ocorrências ativas e as que ainda esperam envio ao central ficam no banco
+	condicao := condicaoTipo(tipo) + ` AND o.status <> 'ATIVO'
+		AND NOT EXISTS (SELECT 1 FROM fila_sincronizacao f WHERE f.ocorrencia_id = o.id AND f.enviado_em IS NULL)`
+	selecao := "SELECT o.id FROM " + particao + " o WHERE " + condicao
+
+	var total int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM (" + selecao + ") s").Scan(&total); err != nil {
+		return nil, fmt.Errorf("erro ao contar ocorrências de %s: %v", particao, err)
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(diretorio, 0o755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório de arquivo: %v", err)
+	}
+	nome := fmt.Sprintf("ocorrencias_%s_%s_%s.jsonl.gz", mes.Format("2006_01"), strings.ToLower(tipo), agora.Format("20060102T150405"))
+	caminho := filepath.Join(diretorio, nome)
+	arquivo := &Arquivo{Arquivo: nome, Mes: mes, Tipo: tipo, CriadoEm: agora}
+
+	escrito := false
+	defer func() {
+		if !escrito {
+			os.Remove(caminho + ".parcial")
+			os.Remove(caminho)
+		}
+	}()
+	if err := escreverArquivo(tx, caminho, arquivo, Cabecalho{
+		Formato: formatoArquivo, Versao: versaoArquivo, VersaoEsquema: versaoEsquema,
+		Mes: mes.Format("2006-01"), Tipo: tipo, GeradoEm: agora,
+	}, particao, condicao); err != nil {
+		return nil, err
+	}
+	if arquivo.Ocorrencias != total {
+		return nil, fmt.Errorf("arquivo de %s com %d ocorrências, esperadas %d", particao, arquivo.Ocorrencias, total)
+	}
+
+	// Os ids ficam guardados para confirmar, depois de apagar, que nada os refere
+	if _, err := tx.Exec("CREATE TEMP TABLE ids_arquivados ON COMMIT DROP AS " + selecao); err != nil {
+		return nil, fmt.Errorf("erro ao guardar ocorrências arquivadas de %s: %v", particao, err)
+	}
+	arquivados := "SELECT id FROM ids_arquivados"
+	for _, tabela := range database.TabelasDependentesOcorrencia {
+		if _, err := tx.Exec("DELETE FROM " + tabela + " WHERE ocorrencia_id IN (" + arquivados + ")"); err != nil {
+			return nil, fmt.Errorf("erro ao apagar %s das ocorrências arquivadas: %v", tabela, err)
+		}
+	}
+	_, err = tx.Exec("UPDATE ocorrencias_falhas SET suprimida_por = NULL WHERE suprimida_por IN (" + arquivados + ")")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao desligar supressões das ocorrências arquivadas: %v", err)
+	}
+	resultado, err := tx.Exec("DELETE FROM " + particao + " o WHERE o.id IN (" + arquivados + ")")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao apagar ocorrências arquivadas de %s: %v", particao, err)
+	}
+	if apagadas, _ := resultado.RowsAffected(); int(apagadas) != total {
+		return nil, fmt.Errorf("apagadas %d ocorrências de %s, arquivadas %d", apagadas, particao, total)
+	}
+	if err := database.VerificarVinculosOcorrencias(tx, arquivados); err != nil {
+		return nil, fmt.Errorf("arquivo de %s: %v", particao, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO arquivos_ocorrencias (arquivo, mes, tipo, ocorrencias, transicoes, tamanho_bytes, sha256, criado_em)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		arquivo.Arquivo, arquivo.Mes, arquivo.Tipo, arquivo.Ocorrencias, arquivo.Transicoes,
+		arquivo.TamanhoBytes, arquivo.SHA256, agora)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registar arquivo %s: %v", nome, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar arquivo %s: %v", nome, err)
+	}
+	escrito = true
+	return arquivo, nil
+}
+
+// escreverArquivo grava o ficheiro (primeiro como .parcial) e preenche contagens, tamanho e sha256
+func escreverArquivo(tx *sql.Tx, caminho string, arquivo *Arquivo, cabecalho Cabecalho, particao, condicao string) error {
+	f, err := os.Create(caminho + ".parcial")
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo: %v", err)
+	}
+	defer f.Close()
+
+	soma := sha256.New()
+	tamanho := &contadorBytes{}
+	compressor := gzip.NewWriter(io.MultiWriter(f, soma, tamanho))
+	saida := bufio.NewWriter(compressor)
+	codificador := json.NewEncoder(saida)
+
+	if err := codificador.Encode(cabecalho); err != nil {
+		return err
+	}
+
+	selecao := "SELECT o.id FROM " + particao + " o WHERE " + condicao
+	consultas := map[string]string{
+		"ocorrencias_falhas": "SELECT row_to_json(o)::text FROM " + particao + " o WHERE " + condicao + " ORDER BY o.id",
+		"transicoes_ocorrencias": `SELECT row_to_json(t)::text FROM transicoes_ocorrencias t
+			WHERE t.ocorrencia_id IN (` + selecao + `) ORDER BY t.id`,
+	}
+	for tabela, ordem := range tabelasVinculo {
+		consultas[tabela] = "SELECT row_to_json(x)::text FROM " + tabela + " x WHERE x.ocorrencia_id IN (" + selecao + ") ORDER BY " + ordem
+	}
+	contagens := make(map[string]int)
+	for _, tabela := range tabelasArquivadas {
+		rows, err := tx.Query(consultas[tabela])
+		if err != nil {
+			return fmt.Errorf("erro ao ler %s de %s: %v", tabela, particao, err)
+		}
+		for rows.Next() {
+			var dados string
+			if err := rows.Scan(&dados); err != nil {
+				rows.Close()
+				return err
+			}
+			if err := codificador.Encode(linhaArquivo{Tabela: tabela, Dados: json.RawMessage(dados)}); err != nil {
+				rows.Close()
+				return fmt.Errorf("erro ao escrever arquivo: %v", err)
+			}
+			contagens[tabela]++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("erro ao ler %s de %s: %v", tabela, particao, err)
+		}
+	}
+	arquivo.Ocorrencias = contagens["ocorrencias_falhas"]
+	arquivo.Transicoes = contagens["transicoes_ocorrencias"]
+
+	rodape := linhaArquivo{Fim: true, Ocorrencias: arquivo.Ocorrencias, Transicoes: arquivo.Transicoes, Vinculos: make(map[string]int)}
+	for tabela := range tabelasVinculo {
+		rodape.Vinculos[tabela] = contagens[tabela]
+	}
+	if err := codificador.Encode(rodape); err != nil {
+		return err
+	}
+	if err := saida.Flush(); err != nil {
+		return fmt.Errorf("erro ao escrever arquivo: %v", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("erro ao escrever arquivo: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("erro ao gravar arquivo no disco: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("erro ao fechar arquivo: %v", err)
+	}
+	arquivo.SHA256 = hex.EncodeToString(soma.Sum(nil))
+	arquivo.TamanhoBytes = tamanho.total
+	return os.Rename(caminho+".parcial", caminho)
+}
+
+type contadorBytes struct{ total int64 }
+
+func (c *contadorBytes) Write(p []byte) (int, error) {
+	c.total += int64(len(p))
+	return len(p), nil
+}
+
+// Restaurar volta a inserir no banco as ocorrências de um arquivo e os registos ligados (os que já
+// existem são ignorados) e protege o mês e tipo da retenção durante o tempo indicado. Uma
+// ocorrência suprimida cuja first-out já não está no banco volta com suprimida_por NULL.
+func Restaurar(db *sql.DB, caminho string, manter time.Duration) (*Arquivo, error) {
+	f, err := os.Open(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo: %v", err)
+	}
+	defer f.Close()
+	nome := filepath.Base(caminho)
+	arquivo := &Arquivo{Arquivo: nome}
+
+	// O sha256 é calculado enquanto o ficheiro é lido e verificado antes de confirmar o restauro
+	soma := sha256.New()
+	tamanho := &contadorBytes{}
+	original := io.TeeReader(f, io.MultiWriter(soma, tamanho))
+	descompressor, err := gzip.NewReader(original)
+	if err != nil {
+		return nil, fmt.Errorf("arquivo %s não é gzip: %v", nome, err)
+	}
+	defer descompressor.Close()
+	leitor := bufio.NewReaderSize(descompressor, 1<<20)
+
+	primeira, err := leitor.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("arquivo %s sem cabeçalho: %v", nome, err)
+	}
+	var cabecalho Cabecalho
+	if err := json.Unmarshal(primeira, &cabecalho); err != nil || cabecalho.Formato != formatoArquivo {
+		return nil, fmt.Errorf("arquivo %s não é um arquivo de ocorrências", nome)
+	}
+	if cabecalho.Tipo != TipoEvento && cabecalho.Tipo != TipoFalha {
+		return nil, fmt.Errorf("tipo inválido no cabeçalho: %s", cabecalho.Tipo)
+	}
+	if cabecalho.Versao < 1 || cabecalho.Versao > versaoArquivo {
+		return nil, fmt.Errorf("versão %d do arquivo não suportada (esperada até %d)", cabecalho.Versao, versaoArquivo)
+	}
+	versaoEsquema, err := database.VersaoEsquema(db)
+	if err != nil {
+		return nil, err
+	}
+	if versaoEsquema < versaoEsquemaParticionado {
+		return nil, fmt.Errorf("o banco está na versão %d do esquema: aplique 'migrate up' antes de restaurar", versaoEsquema)
+	}
+	if cabecalho.VersaoEsquema > versaoEsquema {
+		return nil, fmt.Errorf("arquivo gerado com a versão %d do esquema, o banco está na %d: aplique 'migrate up' antes de restaurar",
+			cabecalho.VersaoEsquema, versaoEsquema)
+	}
+	mes, err := time.Parse("2006-01", cabecalho.Mes)
+	if err != nil {
+		return nil, fmt.Errorf("mês inválido no cabeçalho: %s", cabecalho.Mes)
+	}
+	arquivo.Mes, arquivo.Tipo, arquivo.CriadoEm = mes, cabecalho.Tipo, cabecalho.GeradoEm
+
+	if err := GarantirParticao(db, mes); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	permitidas := make(map[string]bool)
+	for _, tabela := range tabelasArquivadas {
+		permitidas[tabela] = true
+	}
+	contagens := make(map[string]int)
+	var pendentes []json.RawMessage
+	tabelaPendente := ""
+	inserir := func() error {
+		if len(pendentes) == 0 {
+			return nil
+		}
+		lote, err := json.Marshal(pendentes)
+		if err != nil {
+			return err
+		}
+		selecao, existe := selecaoRestauro[tabelaPendente]
+		if !existe {
+			selecao = "SELECT * FROM json_populate_recordset(NULL::" + tabelaPendente + ", $1)"
+		}
+		_, err = tx.Exec("INSERT INTO "+tabelaPendente+" "+selecao+" ON CONFLICT DO NOTHING", string(lote))
+		if err != nil {
+			return fmt.Errorf("erro ao restaurar %s: %v", tabelaPendente, err)
+		}
+		pendentes = pendentes[:0]
+		return nil
+	}
+
+	var rodape *linhaArquivo
+	for {
+		conteudoLinha, err := leitor.ReadBytes('\n')
+		if err == io.EOF && len(conteudoLinha) == 0 {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("erro ao ler arquivo %s: %v", nome, err)
+		}
+		if rodape != nil {
+			return nil, fmt.Errorf("arquivo %s com registos depois do rodapé", nome)
+		}
+
+		var linha linhaArquivo
+		if err := json.Unmarshal(conteudoLinha, &linha); err != nil {
+			return nil, fmt.Errorf("linha inválida no arquivo %s: %v", nome, err)
+		}
+		if linha.Fim {
+			rodape = &linha
+			continue
+		}
+		if !permitidas[linha.Tabela] {
+			return nil, fmt.Errorf("tabela '%s' não pode ser restaurada de um arquivo", linha.Tabela)
+		}
+		if linha.Tabela != tabelaPendente || len(pendentes) >= linhasPorInsercao {
+			if err := inserir(); err != nil {
+				return nil, err
+			}
+			tabelaPendente = linha.Tabela
+		}
+		pendentes = append(pendentes, linha.Dados)
+		contagens[linha.Tabela]++
+	}
+	if err := inserir(); err != nil {
+		return nil, err
+	}
+
+	if rodape == nil {
+		return nil, fmt.Errorf("arquivo %s incompleto (sem rodapé)", nome)
+	}
+	if _, err := io.Copy(io.Discard, original); err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo %s: %v", nome, err)
+	}
+	arquivo.SHA256, arquivo.TamanhoBytes = hex.EncodeToString(soma.Sum(nil)), tamanho.total
+
+	// A ocorrência first-out de uma suprimida pode ter sido arquivada noutro ficheiro (ex.: de outro tipo)
+	_, err = tx.Exec(`
+		UPDATE ocorrencias_falhas x SET suprimida_por = NULL
+		WHERE x.timestamp_inicio >= $1 AND x.timestamp_inicio < $2 AND x.suprimida_por IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM ocorrencias_falhas o WHERE o.id = x.suprimida_por)`, mes, mes.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao desligar supressões de %s: %v", nome, err)
+	}
+	if err := database.VerificarVinculosOcorrencias(tx, ""); err != nil {
+		return nil, fmt.Errorf("restauro de %s: %v", nome, err)
+	}
+
+	// Um arquivo registado tem de estar intacto
+	var registado string
+	err = tx.QueryRow("SELECT sha256 FROM arquivos_ocorrencias WHERE arquivo = $1", nome).Scan(&registado)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("erro ao buscar arquivo %s: %v", nome, err)
+	}
+	if registado != "" && registado != arquivo.SHA256 {
+		return nil, fmt.Errorf("arquivo %s alterado desde que foi gerado (sha256 %s, registado %s)", nome, arquivo.SHA256, registado)
+	}
+	arquivo.Ocorrencias, arquivo.Transicoes = contagens["ocorrencias_falhas"], contagens["transicoes_ocorrencias"]
+	if rodape.Ocorrencias != arquivo.Ocorrencias || rodape.Transicoes != arquivo.Transicoes {
+		return nil, fmt.Errorf("arquivo %s incompleto: %d ocorrências e %d transições, o rodapé indica %d e %d",
+			nome, arquivo.Ocorrencias, arquivo.Transicoes, rodape.Ocorrencias, rodape.Transicoes)
+	}
+	for tabela := range tabelasVinculo {
+		if rodape.Vinculos[tabela] != contagens[tabela] {
+			return nil, fmt.Errorf("arquivo %s incompleto: %d linhas de %s, o rodapé indica %d",
+				nome, contagens[tabela], tabela, rodape.Vinculos[tabela])
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO arquivos_ocorrencias (arquivo, mes, tipo, ocorrencias, transicoes, tamanho_bytes, sha256, criado_em,
+			restaurado_em, manter_ate)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW() + $9::bigint * INTERVAL '1 second')
+		ON CONFLICT (arquivo) DO UPDATE SET restaurado_em = excluded.restaurado_em, manter_ate = excluded.manter_ate
+		RETURNING restaurado_em, manter_ate`,
+		nome, mes, arquivo.Tipo, arquivo.Ocorrencias, arquivo.Transicoes, arquivo.TamanhoBytes, arquivo.SHA256,
+		arquivo.CriadoEm, int64(manter.Seconds())).Scan(&arquivo.RestauradoEm, &arquivo.ManterAte)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registar restauro de %s: %v", nome, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar restauro de %s: %v", nome, err)
+	}
+	return arquivo, nil
+}
+
+// ListarArquivos devolve os arquivos gerados e restaurados, do mais recente para o mais antigo
+func ListarArquivos(db *sql.DB) ([]Arquivo, error) {
+	rows, err := db.Query(`
+		SELECT arquivo, mes, tipo, ocorrencias, transicoes, tamanho_bytes, sha256, criado_em, restaurado_em, manter_ate
+		FROM arquivos_ocorrencias
+		ORDER BY mes DESC, tipo, criado_em DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar arquivos: %v", err)
+	}
+	defer rows.Close()
+
+	var arquivos []Arquivo
+	for rows.Next() {
+		var a Arquivo
+		err := rows.Scan(&a.Arquivo, &a.Mes, &a.Tipo, &a.Ocorrencias, &a.Transicoes, &a.TamanhoBytes, &a.SHA256,
+			&a.CriadoEm, &a.RestauradoEm, &a.ManterAte)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler arquivo: %v", err)
+		}
+		arquivos = append(arquivos, a)
+	}
+	return arquivos, rows.Err()
+}
@@ -0,0 +1,168 @@
+package retencao
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/database"
+)
+
+// bancoTeste cria um banco migrado para o teste e apaga-o no fim. Só corre com TESTE_POSTGRES=1 e
+// as variáveis DB_* de um servidor onde o utilizador cria bancos.
+func bancoTeste(t *testing.T) *sql.DB {
+	t.Helper()
+	if os.Getenv("TESTE_POSTGRES") != "1" {
+		t.Skip("defina TESTE_POSTGRES=1 (e DB_HOST, DB_USER, DB_PASSWORD) para correr os testes com PostgreSQL")
+	}
+
+	cfg := config.CarregarConfiguracoes()
+	admin, err := database.Abrir(cfg, "postgres")
+	if err != nil {
+		t.Fatalf("Abrir: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	nome := fmt.Sprintf("falhas_teste_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE DATABASE " + nome); err != nil {
+		t.Fatalf("criar banco de teste: %v", err)
+	}
+	db, err := database.Abrir(cfg, nome)
+	if err != nil {
+		t.Fatalf("Abrir %s: %v", nome, err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		if _, err := admin.Exec("DROP DATABASE IF EXISTS " + nome); err != nil {
+			t.Logf("apagar banco de teste %s: %v", nome, err)
+		}
+	})
+	if _, err := database.Migrar(db); err != nil {
+		t.Fatalf("Migrar: %v", err)
+	}
+	return db
+}
+
+// exec corre uma instrução de preparação e devolve o id devolvido por RETURNING, se houver
+func exec(t *testing.T, db *sql.DB, consulta string, args ...interface{}) int64 {
+	t.Helper()
+	var id int64
+	err := db.QueryRow(consulta, args...).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		t.Fatalf("%s: %v", consulta, err)
+	}
+	return id
+}
+
+// contar devolve o resultado de um SELECT COUNT(*)
+func contar(t *testing.T, db *sql.DB, consulta string, args ...interface{}) int {
+	t.Helper()
+	var total int
+	if err := db.QueryRow(consulta, args...).Scan(&total); err != nil {
+		t.Fatalf("%s: %v", consulta, err)
+	}
+	return total
+}
+
+func TestArquivarERestaurarSemReferenciasOrfas(t *testing.T) {
+	db := bancoTeste(t)
+	agora := time.Now()
+	mes := InicioMes(agora).AddDate(0, -6, 0)
+	if err := GarantirParticao(db, mes); err != nil {
+		t.Fatalf("GarantirParticao: %v", err)
+	}
+	particao := nomeParticao(mes)
+	inicio := mes.Add(10 * 24 * time.Hour)
+
+	eclusa := exec(t, db, "INSERT INTO eclusas (codigo, nome) VALUES ('TESTE', 'Eclusa de teste') RETURNING id")
+	setor := exec(t, db, "INSERT INTO setores (codigo, nome) VALUES ('TESTE', 'Setor de teste') RETURNING id")
+	definicao := func(codigo, tipo string, ponto int) int64 {
+		return exec(t, db, `INSERT INTO definicoes_falhas (eclusa_id, setor_id, codigo, tipo, descricao, point_index)
+			VALUES ($1, $2, $3, $4, $3, $5) RETURNING id`, eclusa, setor, codigo, tipo, ponto)
+	}
+	falha, evento := definicao("TESTE_FALHA", TipoFalha, 1), definicao("TESTE_EVENTO", TipoEvento, 2)
+	ocorrencia := func(definicaoID int64, status string, suprimidaPor interface{}) int64 {
+		return exec(t, db, `INSERT INTO ocorrencias_falhas (definicao_id, status, timestamp_inicio, suprimida_por)
+			VALUES ($1, $2, $3, $4) RETURNING id`, definicaoID, status, inicio, suprimidaPor)
+	}
+
+	// firstOut suprime uma falha arquivada com ela, um evento (arquivado noutro ficheiro) e uma falha ativa
+	firstOut := ocorrencia(falha, "RESOLVIDO", nil)
+	suprimida := ocorrencia(falha, "RESOLVIDO", firstOut)
+	eventoSuprimido := ocorrencia(evento, "RESOLVIDO", firstOut)
+	ativa := ocorrencia(falha, "ATIVO", firstOut)
+	for _, id := range []int64{firstOut, suprimida, eventoSuprimido} {
+		exec(t, db, `INSERT INTO transicoes_ocorrencias (ocorrencia_id, status_anterior, status_novo, origem)
+			VALUES ($1, 'ATIVO', 'RESOLVIDO', 'PLC')`, id)
+	}
+	exec(t, db, `INSERT INTO envios_notificacao (ocorrencia_id, canal, endereco, assunto, mensagem, status)
+		VALUES ($1, 'EMAIL', 'turno@example.com', 'Falha', 'Falha', 'ENVIADO')`, firstOut)
+	exec(t, db, "INSERT INTO escalonamentos_ocorrencias (ocorrencia_id, estado) VALUES ($1, 'RESOLVIDO')", firstOut)
+	ordem := exec(t, db, `INSERT INTO ordens_trabalho (titulo, eclusa_codigo, setor_codigo)
+		VALUES ('Ordem de teste', 'TESTE', 'TESTE') RETURNING id`)
+	exec(t, db, "INSERT INTO ordens_trabalho_ocorrencias (ordem_id, ocorrencia_id) VALUES ($1, $2)", ordem, firstOut)
+	exec(t, db, `INSERT INTO fila_sincronizacao (tipo, ocorrencia_id, enviado_em) VALUES ('OCORRENCIA', $1, NOW())`, firstOut)
+
+	diretorio := t.TempDir()
+	arquivoEventos, err := arquivar(db, diretorio, particao, mes, TipoEvento, agora)
+	if err != nil || arquivoEventos == nil || arquivoEventos.Ocorrencias != 1 {
+		t.Fatalf("arquivar eventos = %+v, %v; esperada 1 ocorrência", arquivoEventos, err)
+	}
+	arquivoFalhas, err := arquivar(db, diretorio, particao, mes, TipoFalha, agora)
+	if err != nil || arquivoFalhas == nil || arquivoFalhas.Ocorrencias != 2 || arquivoFalhas.Transicoes != 2 {
+		t.Fatalf("arquivar falhas = %+v, %v; esperadas 2 ocorrências e 2 transições", arquivoFalhas, err)
+	}
+
+	if err := database.VerificarVinculosOcorrencias(db, ""); err != nil {
+		t.Fatalf("depois de arquivar: %v", err)
+	}
+	for _, v := range database.VinculosOcorrencia {
+		if total := contar(t, db, "SELECT COUNT(*) FROM "+v.Tabela+" WHERE "+v.Coluna+" = $1", firstOut); total != 0 {
+			t.Errorf("%d linhas de %s.%s referem a ocorrência arquivada", total, v.Tabela, v.Coluna)
+		}
+	}
+	if total := contar(t, db, "SELECT COUNT(*) FROM fila_sincronizacao WHERE ocorrencia_id = $1", firstOut); total != 0 {
+		t.Errorf("%d itens da fila de sincronização referem a ocorrência arquivada", total)
+	}
+	if total := contar(t, db, "SELECT COUNT(*) FROM ocorrencias_falhas WHERE id = $1 AND suprimida_por IS NULL", ativa); total != 1 {
+		t.Errorf("a ocorrência ativa foi arquivada ou mantém suprimida_por da first-out arquivada")
+	}
+
+	// O evento volta sem a first-out, que continua arquivada
+	if _, err := Restaurar(db, filepath.Join(diretorio, arquivoEventos.Arquivo), time.Hour); err != nil {
+		t.Fatalf("Restaurar eventos: %v", err)
+	}
+	if err := database.VerificarVinculosOcorrencias(db, ""); err != nil {
+		t.Fatalf("depois de restaurar os eventos: %v", err)
+	}
+	if total := contar(t, db, "SELECT COUNT(*) FROM ocorrencias_falhas WHERE id = $1 AND suprimida_por IS NULL", eventoSuprimido); total != 1 {
+		t.Errorf("evento restaurado mantém suprimida_por da first-out arquivada")
+	}
+
+	// As falhas voltam com as transições, envios, escalonamentos e ligações às ordens
+	restaurado, err := Restaurar(db, filepath.Join(diretorio, arquivoFalhas.Arquivo), time.Hour)
+	if err != nil || restaurado.Ocorrencias != 2 {
+		t.Fatalf("Restaurar falhas = %+v, %v; esperadas 2 ocorrências", restaurado, err)
+	}
+	if err := database.VerificarVinculosOcorrencias(db, ""); err != nil {
+		t.Fatalf("depois de restaurar as falhas: %v", err)
+	}
+	if total := contar(t, db, "SELECT COUNT(*) FROM ocorrencias_falhas WHERE id = $1 AND suprimida_por = $2", suprimida, firstOut); total != 1 {
+		t.Errorf("falha restaurada perdeu suprimida_por da first-out restaurada com ela")
+	}
+	for _, tabela := range []string{"transicoes_ocorrencias", "envios_notificacao", "escalonamentos_ocorrencias", "ordens_trabalho_ocorrencias"} {
+		if total := contar(t, db, "SELECT COUNT(*) FROM "+tabela+" WHERE ocorrencia_id = $1", firstOut); total != 1 {
+			t.Errorf("%d linhas de %s da ocorrência restaurada; esperada 1", total, tabela)
+		}
+	}
+
+	// E uma referência órfã é detetada
+	exec(t, db, `INSERT INTO transicoes_ocorrencias (ocorrencia_id, status_novo, origem) VALUES (-1, 'ATIVO', 'PLC')`)
+	if err := database.VerificarVinculosOcorrencias(db, ""); err == nil {
+		t.Fatal("VerificarVinculosOcorrencias não detetou a transição órfã")
+	}
+}
@@ -0,0 +1,158 @@
+// Package retencao gere as partições mensais de ocorrencias_falhas e a retenção por tipo: as
+// ocorrências mais antigas do que a retenção do seu tipo (EVENTO ou FALHA) são arquivadas em
+// ficheiros gzip e apagadas do banco, e as partições que ficam vazias são removidas.
+package retencao
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// TipoEvento e TipoFalha são os tipos de definição com retenção própria
+	TipoEvento = "EVENTO"
+	TipoFalha  = "FALHA"
+
+	// particaoPadrao recebe as ocorrências fora das partições mensais (ex.: relógio do PLC errado)
+	particaoPadrao = "ocorrencias_falhas_padrao"
+
+	// mesesAntecipados é o número de partições criadas além do mês atual
+	mesesAntecipados = 2
+)
+
+// Particao é uma partição mensal de ocorrencias_falhas
+type Particao struct {
+	Nome    string    `json:"nome"`
+	Mes     time.Time `json:"mes"` // Primeiro dia do mês (zero na partição padrão)
+	Eventos int64     `json:"eventos"`
+	Falhas  int64     `json:"falhas"`
+	Ativas  int64     `json:"ativas"`
+}
+
+// InicioMes devolve o primeiro dia do mês do instante (hora local, como os TIMESTAMP do banco)
+func InicioMes(instante time.Time) time.Time {
+	return time.Date(instante.Year(), instante.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// nomeParticao devolve o nome da partição mensal (ocorrencias_falhas_AAAAMM)
+func nomeParticao(mes time.Time) string {
+	return "ocorrencias_falhas_" + mes.Format("200601")
+}
+
+// GarantirParticao cria a partição do mês que contém o instante indicado. As ocorrências desse mês
+// que já tenham caído na partição padrão passam para a nova partição.
+func GarantirParticao(db *sql.DB, instante time.Time) error {
+	inicio := InicioMes(instante)
+	fim := inicio.AddDate(0, 1, 0)
+	nome := nomeParticao(inicio)
+
+	var existe bool
+	if err := db.QueryRow("SELECT to_regclass($1) IS NOT NULL", nome).Scan(&existe); err != nil {
+		return fmt.Errorf("erro ao verificar partição %s: %v", nome, err)
+	}
+	if existe {
+		return nil
+	}
+
+	limites := fmt.Sprintf("FOR VALUES FROM ('%s') TO ('%s')", inicio.Format("2006-01-02"), fim.Format("2006-01-02"))
+
+	var naPadrao bool
+	err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM `+particaoPadrao+` WHERE timestamp_inicio >= $1 AND timestamp_inicio < $2)`,
+		inicio, fim).Scan(&naPadrao)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar a partição padrão: %v", err)
+	}
+	if !naPadrao {
+		if _, err := db.Exec("CREATE TABLE IF NOT EXISTS " + nome + " PARTITION OF ocorrencias_falhas " + limites); err != nil {
+			return fmt.Errorf("erro ao criar partição %s: %v", nome, err)
+		}
+		return nil
+	}
+
+	// Com linhas do mês na partição padrão, a partição é criada à parte, recebe essas linhas e só
+	// depois é anexada (o PostgreSQL recusa anexar se a padrão ainda tiver linhas do intervalo)
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	intervalo := []interface{}{inicio, fim}
+	for _, passo := range []struct {
+		comando string
+		args    []interface{}
+	}{
+		{"CREATE TABLE " + nome + " (LIKE ocorrencias_falhas INCLUDING DEFAULTS INCLUDING CONSTRAINTS)", nil},
+		{"INSERT INTO " + nome + " SELECT * FROM " + particaoPadrao + " WHERE timestamp_inicio >= $1 AND timestamp_inicio < $2", intervalo},
+		{"DELETE FROM " + particaoPadrao + " WHERE timestamp_inicio >= $1 AND timestamp_inicio < $2", intervalo},
+		{"ALTER TABLE ocorrencias_falhas ATTACH PARTITION " + nome + " " + limites, nil},
+	} {
+		if _, err := tx.Exec(passo.comando, passo.args...); err != nil {
+			return fmt.Errorf("erro ao criar partição %s a partir da padrão: %v", nome, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListarParticoes devolve as partições de ocorrencias_falhas, por mês, com as ocorrências por tipo
+func ListarParticoes(db *sql.DB) ([]Particao, error) {
+	nomes, err := nomesParticoes(db)
+	if err != nil {
+		return nil, err
+	}
+
+	particoes := make([]Particao, 0, len(nomes))
+	for _, nome := range nomes {
+		p := Particao{Nome: nome}
+		if mes, err := time.Parse("ocorrencias_falhas_200601", nome); err == nil {
+			p.Mes = mes
+		}
+		err := db.QueryRow(`
+			SELECT
+				COUNT(CASE WHEN df.tipo = 'EVENTO' THEN 1 END),
+				COUNT(CASE WHEN df.tipo IS DISTINCT FROM 'EVENTO' THEN 1 END),
+				COUNT(CASE WHEN o.status = 'ATIVO' THEN 1 END)
+			FROM `+nome+` o
+			LEFT JOIN definicoes_falhas df ON o.definicao_id = df.id`).Scan(&p.Eventos, &p.Falhas, &p.Ativas)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao contar ocorrências de %s: %v", nome, err)
+		}
+		particoes = append(particoes, p)
+	}
+	return particoes, nil
+}
+
+// nomesParticoes devolve os nomes das partições, por ordem (a padrão fica no fim)
+func nomesParticoes(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON i.inhrelid = c.oid
+		JOIN pg_class p ON i.inhparent = p.oid
+		WHERE p.relname = 'ocorrencias_falhas'
+		ORDER BY c.relname = $1, c.relname`, particaoPadrao)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar partições: %v", err)
+	}
+	defer rows.Close()
+
+	var nomes []string
+	for rows.Next() {
+		var nome string
+		if err := rows.Scan(&nome); err != nil {
+			return nil, err
+		}
+		nomes = append(nomes, nome)
+	}
+	return nomes, rows.Err()
+}
+
+// condicaoTipo seleciona as ocorrências (alias o) de um tipo; sem definição contam como FALHA
+func condicaoTipo(tipo string) string {
+	if tipo == TipoEvento {
+		return "o.definicao_id IN (SELECT id FROM definicoes_falhas WHERE tipo = 'EVENTO')"
+	}
+	return "(o.definicao_id IS NULL OR o.definicao_id NOT IN (SELECT id FROM definicoes_falhas WHERE tipo = 'EVENTO'))"
+}
@@ -0,0 +1,166 @@
+package retencao
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+)
+
+// Servico prepara as partições dos próximos meses e, com a retenção ativa, arquiva e apaga as
+// ocorrências antigas e remove as partições vazias
+type Servico struct {
+	bancoDados  *sql.DB
+	ativo       bool
+	retencoes   map[string]time.Duration // Por tipo (0 = sem limite)
+	diretorio   string
+	intervalo   time.Duration
+	canalParada chan struct{}
+	grupoWait   sync.WaitGroup
+}
+
+// Resultado resume uma execução da retenção
+type Resultado struct {
+	Arquivos           []Arquivo `json:"arquivos"`
+	ParticoesRemovidas []string  `json:"particoes_removidas"`
+}
+
+// NovoServico cria o serviço com a configuração RETENCAO_*
+func NovoServico(db *sql.DB, cfg *config.Configuracoes) *Servico {
+	intervalo := cfg.Retencao_Intervalo
+	if intervalo <= 0 {
+		intervalo = time.Hour
+	}
+
+	return &Servico{
+		bancoDados: db,
+		ativo:      cfg.Retencao_Ativo,
+		retencoes: map[string]time.Duration{
+			TipoEvento: cfg.Retencao_Eventos,
+			TipoFalha:  cfg.Retencao_Falhas,
+		},
+		diretorio:   cfg.Retencao_Diretorio,
+		intervalo:   intervalo,
+		canalParada: make(chan struct{}),
+	}
+}
+
+// Iniciar executa um ciclo imediatamente e depois a cada intervalo, até Parar
+func (s *Servico) Iniciar() {
+	s.grupoWait.Add(1)
+	go func() {
+		defer s.grupoWait.Done()
+
+		temporizador := time.NewTicker(s.intervalo)
+		defer temporizador.Stop()
+
+		for {
+			s.ExecutarCiclo(time.Now())
+
+			select {
+			case <-s.canalParada:
+				return
+			case <-temporizador.C:
+			}
+		}
+	}()
+}
+
+// Parar espera o ciclo em curso terminar
+func (s *Servico) Parar() {
+	close(s.canalParada)
+	s.grupoWait.Wait()
+}
+
+// ExecutarCiclo prepara as partições do mês atual e dos seguintes e, se ativa, aplica a retenção
+func (s *Servico) ExecutarCiclo(agora time.Time) {
+	for i := 0; i <= mesesAntecipados; i++ {
+		if err := GarantirParticao(s.bancoDados, InicioMes(agora).AddDate(0, i, 0)); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+	}
+
+	if !s.ativo {
+		return
+	}
+	if _, err := s.AplicarRetencao(agora); err != nil {
+		log.Printf("❌ Erro ao aplicar retenção das ocorrências: %v", err)
+	}
+}
+
+// AplicarRetencao arquiva as ocorrências não ativas dos meses já fora da retenção do seu tipo e
+// remove as partições que ficam vazias. O mês atual, os futuros e a partição padrão nunca são
+// tocados; um mês e tipo restaurado fica no banco até ao fim do prazo pedido no restauro.
+func (s *Servico) AplicarRetencao(agora time.Time) (*Resultado, error) {
+	nomes, err := nomesParticoes(s.bancoDados)
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := &Resultado{Arquivos: []Arquivo{}, ParticoesRemovidas: []string{}}
+	mesAtual := InicioMes(agora)
+	for _, nome := range nomes {
+		mes, err := time.Parse("ocorrencias_falhas_200601", nome)
+		if err != nil || !mes.Before(mesAtual) {
+			continue
+		}
+		fimMes := mes.AddDate(0, 1, 0)
+
+		expirados := 0
+		for _, tipo := range []string{TipoEvento, TipoFalha} {
+			retencao := s.retencoes[tipo]
+			if retencao <= 0 || fimMes.After(relogioParede(agora.Add(-retencao))) {
+				continue
+			}
+			expirados++
+
+			var protegido bool
+			err := s.bancoDados.QueryRow(`
+				SELECT EXISTS (SELECT 1 FROM arquivos_ocorrencias WHERE mes = $1 AND tipo = $2 AND manter_ate > NOW())`,
+				mes, tipo).Scan(&protegido)
+			if err != nil {
+				return resultado, fmt.Errorf("erro ao verificar restauros de %s: %v", nome, err)
+			}
+			if protegido {
+				continue
+			}
+
+			arquivo, err := arquivar(s.bancoDados, s.diretorio, nome, mes, tipo, agora)
+			if err != nil {
+				return resultado, err
+			}
+			if arquivo != nil {
+				log.Printf("📦 %d ocorrência(s) %s de %s arquivada(s) em %s (retenção %s)",
+					arquivo.Ocorrencias, tipo, mes.Format("2006-01"), arquivo.Arquivo, retencao)
+				resultado.Arquivos = append(resultado.Arquivos, *arquivo)
+			}
+		}
+		if expirados < 2 {
+			continue
+		}
+
+		// Com os dois tipos fora da retenção, a partição sai quando não tem mais nada (ativas ou restauradas)
+		var restantes int
+		if err := s.bancoDados.QueryRow("SELECT COUNT(*) FROM " + nome).Scan(&restantes); err != nil {
+			return resultado, fmt.Errorf("erro ao contar ocorrências de %s: %v", nome, err)
+		}
+		if restantes > 0 {
+			continue
+		}
+		if _, err := s.bancoDados.Exec("DROP TABLE IF EXISTS " + nome); err != nil {
+			return resultado, fmt.Errorf("erro ao remover partição %s: %v", nome, err)
+		}
+		log.Printf("🗑️  Partição de ocorrências %s removida", nome)
+		resultado.ParticoesRemovidas = append(resultado.ParticoesRemovidas, nome)
+	}
+	return resultado, nil
+}
+
+// relogioParede reinterpreta o instante como hora local sem fuso, como os TIMESTAMP do banco
+func relogioParede(instante time.Time) time.Time {
+	return time.Date(instante.Year(), instante.Month(), instante.Day(),
+		instante.Hour(), instante.Minute(), instante.Second(), instante.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,276 @@
+package series
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+)
+
+// ResolucaoBruta identifica as amostras sem agregação
+const ResolucaoBruta = "bruto"
+
+// Resolucao descreve um nível de agregação e de onde ele é calculado
+type Resolucao struct {
+	Nome     string
+	Tabela   string
+	Duracao  time.Duration
+	Retencao time.Duration // 0 = sem limite
+
+	consultaOrigem string // SELECT que agrega o nível anterior entre $1 e $2 (TIMESTAMP sem fuso, hora local)
+}
+
+// consultaDeBrutas agrega as amostras brutas por minuto
+const consultaDeBrutas = `
+	SELECT tag_id, %s AS bucket, MIN(valor), MAX(valor), AVG(valor), COUNT(*)
+	FROM series_brutas
+	WHERE timestamp >= $1 AND timestamp < $2
+	GROUP BY 1, 2`
+
+// consultaDeAgregado reagrega um nível agregado (média ponderada pelo número de amostras)
+const consultaDeAgregado = `
+	SELECT tag_id, %s AS novo_bucket, MIN(minimo), MAX(maximo),
+		SUM(media * amostras) / SUM(amostras), SUM(amostras)
+	FROM %s
+	WHERE bucket >= $1 AND bucket < $2
+	GROUP BY 1, 2`
+
+// NovasResolucoes devolve os níveis de agregação, do mais fino ao mais grosso, com a retenção configurada
+func NovasResolucoes(cfg *config.Configuracoes) []Resolucao {
+	return []Resolucao{
+		{
+			Nome: "1m", Tabela: "series_1m", Duracao: time.Minute, Retencao: cfg.Series_Retencao1m,
+			consultaOrigem: fmt.Sprintf(consultaDeBrutas, "date_trunc('minute', timestamp)"),
+		},
+		{
+			Nome: "15m", Tabela: "series_15m", Duracao: 15 * time.Minute, Retencao: cfg.Series_Retencao15m,
+			consultaOrigem: fmt.Sprintf(consultaDeAgregado,
+				"date_trunc('hour', bucket) + FLOOR(EXTRACT(MINUTE FROM bucket) / 15) * INTERVAL '15 minutes'", "series_1m"),
+		},
+		{
+			Nome: "1h", Tabela: "series_1h", Duracao: time.Hour, Retencao: cfg.Series_Retencao1h,
+			consultaOrigem: fmt.Sprintf(consultaDeAgregado, "date_trunc('hour', bucket)", "series_15m"),
+		},
+	}
+}
+
+// Agregador calcula periodicamente os agregados, aplica a retenção e prepara as partições
+type Agregador struct {
+	bancoDados    *sql.DB
+	resolucoes    []Resolucao
+	retencaoBruto time.Duration
+	intervalo     time.Duration
+	canalParada   chan struct{}
+	grupoWait     sync.WaitGroup
+}
+
+// NovoAgregador cria o agregador com as retenções da configuração
+func NovoAgregador(db *sql.DB, cfg *config.Configuracoes) *Agregador {
+	intervalo := cfg.Series_IntervaloAgregacao
+	if intervalo <= 0 {
+		intervalo = time.Minute
+	}
+
+	return &Agregador{
+		bancoDados:    db,
+		resolucoes:    NovasResolucoes(cfg),
+		retencaoBruto: cfg.Series_RetencaoBruto,
+		intervalo:     intervalo,
+		canalParada:   make(chan struct{}),
+	}
+}
+
+// Iniciar executa um ciclo imediatamente e depois a cada intervalo, até Parar
+func (a *Agregador) Iniciar() {
+	a.grupoWait.Add(1)
+	go func() {
+		defer a.grupoWait.Done()
+
+		temporizador := time.NewTicker(a.intervalo)
+		defer temporizador.Stop()
+
+		for {
+			a.ExecutarCiclo(time.Now())
+
+			select {
+			case <-a.canalParada:
+				return
+			case <-temporizador.C:
+			}
+		}
+	}()
+}
+
+// Parar espera o ciclo em curso terminar
+func (a *Agregador) Parar() {
+	close(a.canalParada)
+	a.grupoWait.Wait()
+}
+
+// ExecutarCiclo prepara as partições de hoje e amanhã, atualiza os agregados e aplica a retenção
+func (a *Agregador) ExecutarCiclo(agora time.Time) {
+	for _, dia := range []time.Time{agora, agora.AddDate(0, 0, 1)} {
+		if err := GarantirParticao(a.bancoDados, dia); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+	}
+
+	for _, resolucao := range a.resolucoes {
+		if err := a.agregar(resolucao, agora); err != nil {
+			log.Printf("❌ Erro ao agregar séries (%s): %v", resolucao.Nome, err)
+			// Os níveis seguintes dependem deste; tentar de novo no próximo ciclo
+			break
+		}
+	}
+
+	if err := a.aplicarRetencao(agora); err != nil {
+		log.Printf("❌ Erro ao aplicar retenção das séries: %v", err)
+	}
+}
+
+// agregar recalcula os intervalos fechados desde a última execução. O último intervalo já
+// calculado é refeito, para incluir amostras que chegaram atrasadas.
+func (a *Agregador) agregar(r Resolucao, agora time.Time) error {
+	limite := RelogioParede(TruncarBucket(agora, r.Duracao))
+
+	var processadoAte sql.NullTime
+	err := a.bancoDados.QueryRow(
+		"SELECT processado_ate FROM series_agregacao_estado WHERE resolucao = $1", r.Nome).Scan(&processadoAte)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	var desde time.Time
+	if processadoAte.Valid {
+		desde = processadoAte.Time.Add(-r.Duracao)
+	} else {
+		// Primeira execução: começar pelo dado mais antigo disponível
+		var maisAntigo sql.NullTime
+		if r.Nome == "1m" {
+			err = a.bancoDados.QueryRow("SELECT MIN(timestamp) FROM series_brutas").Scan(&maisAntigo)
+		} else {
+			err = a.bancoDados.QueryRow("SELECT MIN(bucket) FROM " + origemDe(a.resolucoes, r)).Scan(&maisAntigo)
+		}
+		if err != nil {
+			return err
+		}
+		if !maisAntigo.Valid {
+			return a.salvarProgresso(r, limite)
+		}
+		desde = TruncarBucket(maisAntigo.Time, r.Duracao)
+	}
+
+	if !desde.Before(limite) {
+		return nil
+	}
+
+	_, err = a.bancoDados.Exec(fmt.Sprintf(`
+		INSERT INTO %s (tag_id, bucket, minimo, maximo, media, amostras)
+		%s
+		ON CONFLICT (tag_id, bucket) DO UPDATE SET
+			minimo = EXCLUDED.minimo,
+			maximo = EXCLUDED.maximo,
+			media = EXCLUDED.media,
+			amostras = EXCLUDED.amostras`, r.Tabela, r.consultaOrigem), desde, limite)
+	if err != nil {
+		return err
+	}
+
+	return a.salvarProgresso(r, limite)
+}
+
+// salvarProgresso regista até onde o agregado está calculado
+func (a *Agregador) salvarProgresso(r Resolucao, limite time.Time) error {
+	_, err := a.bancoDados.Exec(`
+		INSERT INTO series_agregacao_estado (resolucao, processado_ate) VALUES ($1, $2)
+		ON CONFLICT (resolucao) DO UPDATE SET processado_ate = EXCLUDED.processado_ate`, r.Nome, limite)
+	return err
+}
+
+// aplicarRetencao apaga partições brutas e agregados fora da retenção. Uma partição bruta só é
+// apagada depois de o agregado de 1 minuto a ter coberto por completo.
+func (a *Agregador) aplicarRetencao(agora time.Time) error {
+	if a.retencaoBruto > 0 {
+		var agregadoAte sql.NullTime
+		a.bancoDados.QueryRow("SELECT processado_ate FROM series_agregacao_estado WHERE resolucao = '1m'").Scan(&agregadoAte)
+
+		rows, err := a.bancoDados.Query(`
+			SELECT c.relname
+			FROM pg_inherits i
+			JOIN pg_class c ON i.inhrelid = c.oid
+			JOIN pg_class p ON i.inhparent = p.oid
+			WHERE p.relname = 'series_brutas'`)
+		if err != nil {
+			return err
+		}
+
+		var antigas []string
+		corte := RelogioParede(agora.Add(-a.retencaoBruto))
+		for rows.Next() {
+			var nome string
+			if err := rows.Scan(&nome); err != nil {
+				rows.Close()
+				return err
+			}
+
+			dia, err := time.Parse("series_brutas_20060102", nome)
+			if err != nil {
+				continue
+			}
+			fimDia := dia.AddDate(0, 0, 1)
+			agregada := agregadoAte.Valid && !agregadoAte.Time.Before(fimDia)
+			if fimDia.Before(corte) && agregada {
+				antigas = append(antigas, nome)
+			}
+		}
+		rows.Close()
+
+		for _, nome := range antigas {
+			if _, err := a.bancoDados.Exec("DROP TABLE IF EXISTS " + nome); err != nil {
+				return fmt.Errorf("erro ao remover partição %s: %v", nome, err)
+			}
+			log.Printf("🗑️  Partição de séries %s removida (retenção %s)", nome, a.retencaoBruto)
+		}
+	}
+
+	for _, r := range a.resolucoes {
+		if r.Retencao <= 0 {
+			continue
+		}
+		if _, err := a.bancoDados.Exec("DELETE FROM "+r.Tabela+" WHERE bucket < $1", agora.Add(-r.Retencao)); err != nil {
+			return fmt.Errorf("erro ao aplicar retenção de %s: %v", r.Tabela, err)
+		}
+	}
+
+	return nil
+}
+
+// origemDe devolve a tabela do nível anterior ao indicado
+func origemDe(resolucoes []Resolucao, r Resolucao) string {
+	for i := 1; i < len(resolucoes); i++ {
+		if resolucoes[i].Nome == r.Nome {
+			return resolucoes[i-1].Tabela
+		}
+	}
+	return "series_brutas"
+}
+
+// TruncarBucket arredonda para baixo ao início do intervalo, em hora local (como date_trunc)
+func TruncarBucket(instante time.Time, duracao time.Duration) time.Time {
+	inicioHora := time.Date(instante.Year(), instante.Month(), instante.Day(), instante.Hour(), 0, 0, 0, instante.Location())
+	if duracao >= time.Hour {
+		return inicioHora
+	}
+	passados := instante.Sub(inicioHora)
+	return inicioHora.Add(passados - passados%duracao)
+}
+
+// RelogioParede devolve o mesmo relógio de parede marcado como UTC, que é como o lib/pq lê as
+// colunas TIMESTAMP sem fuso; permite comparar instantes de Go com valores lidos do banco
+func RelogioParede(instante time.Time) time.Time {
+	return time.Date(instante.Year(), instante.Month(), instante.Day(),
+		instante.Hour(), instante.Minute(), instante.Second(), instante.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,274 @@
+package series
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/database"
+	"github.com/edp/falhas-backend/modelos"
+)
+
+// ResolucaoAutomatica escolhe a resolução pelo intervalo pedido e pela retenção de cada nível
+const ResolucaoAutomatica = "auto"
+
+// janelaMaximaBruto limita a resolução automática "bruto" a intervalos curtos
+const janelaMaximaBruto = 6 * time.Hour
+
+// ResultadoConsulta é a série devolvida pela API
+type ResultadoConsulta struct {
+	Tag             modelos.TagAnalogica
+	Resolucao       string
+	Decimado        bool // Os pontos foram reduzidos para caber em PontosMaximos
+	PontosOriginais int
+	Pontos          []modelos.PontoSerie
+}
+
+// Consultor lê as séries na resolução adequada ao intervalo
+type Consultor struct {
+	bancoDados    *sql.DB
+	resolucoes    []Resolucao
+	retencaoBruto time.Duration
+	pontosMaximos int
+}
+
+// NovoConsultor cria o consultor com as retenções e o limite de pontos da configuração
+func NovoConsultor(db *sql.DB, cfg *config.Configuracoes) *Consultor {
+	pontosMaximos := cfg.Series_PontosMaximos
+	if pontosMaximos <= 0 {
+		pontosMaximos = 1500
+	}
+
+	return &Consultor{
+		bancoDados:    db,
+		resolucoes:    NovasResolucoes(cfg),
+		retencaoBruto: cfg.Series_RetencaoBruto,
+		pontosMaximos: pontosMaximos,
+	}
+}
+
+// PontosMaximos devolve o limite de pontos por consulta
+func (c *Consultor) PontosMaximos() int {
+	return c.pontosMaximos
+}
+
+// ResolucaoValida indica se o nome é "auto", "bruto" ou um dos níveis de agregação
+func (c *Consultor) ResolucaoValida(nome string) bool {
+	if nome == ResolucaoAutomatica || nome == ResolucaoBruta {
+		return true
+	}
+	_, existe := c.resolucao(nome)
+	return existe
+}
+
+// EscolherResolucao devolve a resolução mais fina que ainda tem dados para o início do intervalo
+// e que cabe no número de pontos pedido
+func (c *Consultor) EscolherResolucao(inicio, fim time.Time, pontosMaximos int, agora time.Time) string {
+	intervalo := fim.Sub(inicio)
+	dentroRetencao := func(retencao time.Duration) bool {
+		return retencao <= 0 || !inicio.Before(agora.Add(-retencao))
+	}
+
+	if intervalo <= janelaMaximaBruto && dentroRetencao(c.retencaoBruto) {
+		return ResolucaoBruta
+	}
+
+	for _, r := range c.resolucoes {
+		if int(intervalo/r.Duracao) <= pontosMaximos && dentroRetencao(r.Retencao) {
+			return r.Nome
+		}
+	}
+	return c.resolucoes[len(c.resolucoes)-1].Nome
+}
+
+// BuscarTag devolve a tag analógica pelo nome
+func (c *Consultor) BuscarTag(nome string) (modelos.TagAnalogica, error) {
+	var tag modelos.TagAnalogica
+	var eclusaID sql.NullInt64
+	err := c.bancoDados.QueryRow(`
+		SELECT t.id, t.tag, t.eclusa_id, COALESCE(e.codigo, ''), COALESCE(t.descricao, ''),
+			COALESCE(t.unidade, ''), t.word_index, t.tipo_dado, t.escala, t.deslocamento,
+			t.banda_morta, t.intervalo_maximo_segundos, t.ativa
+		FROM tags_analogicas t
+		LEFT JOIN eclusas e ON t.eclusa_id = e.id
+		WHERE t.tag = $1`, nome).Scan(
+		&tag.ID, &tag.Tag, &eclusaID, &tag.EclusaCodigo, &tag.Descricao,
+		&tag.Unidade, &tag.WordIndex, &tag.TipoDado, &tag.Escala, &tag.Deslocamento,
+		&tag.BandaMorta, &tag.IntervaloMaximoSegundos, &tag.Ativa)
+	if err != nil {
+		return tag, err
+	}
+	if eclusaID.Valid {
+		id := int(eclusaID.Int64)
+		tag.EclusaID = &id
+	}
+	return tag, nil
+}
+
+// Consultar devolve os pontos da tag entre inicio e fim, decimados para no máximo pontosMaximos
+func (c *Consultor) Consultar(tag modelos.TagAnalogica, inicio, fim time.Time, resolucao string, pontosMaximos int) (ResultadoConsulta, error) {
+	if pontosMaximos <= 0 || pontosMaximos > c.pontosMaximos {
+		pontosMaximos = c.pontosMaximos
+	}
+	if resolucao == "" || resolucao == ResolucaoAutomatica {
+		resolucao = c.EscolherResolucao(inicio, fim, pontosMaximos, time.Now())
+	}
+
+	resultado := ResultadoConsulta{Tag: tag, Resolucao: resolucao}
+
+	// As colunas são TIMESTAMP sem fuso, gravadas em hora local
+	inicio, fim = inicio.Local(), fim.Local()
+
+	var rows *sql.Rows
+	var err error
+	if resolucao == ResolucaoBruta {
+		rows, err = c.bancoDados.Query(`
+			SELECT timestamp, valor FROM series_brutas
+			WHERE tag_id = $1 AND timestamp >= $2 AND timestamp < $3
+			ORDER BY timestamp`, tag.ID, inicio, fim)
+	} else {
+		r, existe := c.resolucao(resolucao)
+		if !existe {
+			return resultado, fmt.Errorf("resolução desconhecida: %s", resolucao)
+		}
+		rows, err = c.bancoDados.Query(`
+			SELECT bucket, media, minimo, maximo, amostras FROM `+r.Tabela+`
+			WHERE tag_id = $1 AND bucket >= $2 AND bucket < $3
+			ORDER BY bucket`, tag.ID, inicio, fim)
+	}
+	if err != nil {
+		return resultado, fmt.Errorf("erro ao consultar série: %v", err)
+	}
+	defer rows.Close()
+
+	var pontos []modelos.PontoSerie
+	for rows.Next() {
+		var ponto modelos.PontoSerie
+		if resolucao == ResolucaoBruta {
+			err = rows.Scan(&ponto.Timestamp, &ponto.Valor)
+		} else {
+			var minimo, maximo float64
+			err = rows.Scan(&ponto.Timestamp, &ponto.Valor, &minimo, &maximo, &ponto.Amostras)
+			ponto.Minimo, ponto.Maximo = &minimo, &maximo
+		}
+		if err != nil {
+			return resultado, fmt.Errorf("erro ao ler ponto da série: %v", err)
+		}
+		ponto.Timestamp = database.HoraLocal(ponto.Timestamp)
+		pontos = append(pontos, ponto)
+	}
+	if err := rows.Err(); err != nil {
+		return resultado, err
+	}
+
+	resultado.PontosOriginais = len(pontos)
+	if len(pontos) > pontosMaximos {
+		resultado.Decimado = true
+		if resolucao == ResolucaoBruta {
+			pontos = DecimarLTTB(pontos, pontosMaximos)
+		} else {
+			pontos = ReagruparAgregados(pontos, pontosMaximos)
+		}
+	}
+	if pontos == nil {
+		pontos = []modelos.PontoSerie{}
+	}
+	resultado.Pontos = pontos
+
+	return resultado, nil
+}
+
+// resolucao procura um nível de agregação pelo nome
+func (c *Consultor) resolucao(nome string) (Resolucao, bool) {
+	for _, r := range c.resolucoes {
+		if r.Nome == nome {
+			return r, true
+		}
+	}
+	return Resolucao{}, false
+}
+
+// DecimarLTTB reduz a série com Largest-Triangle-Three-Buckets, que mantém picos e vales
+// visíveis no gráfico. O primeiro e o último ponto são sempre mantidos.
+func DecimarLTTB(pontos []modelos.PontoSerie, limite int) []modelos.PontoSerie {
+	if limite >= len(pontos) || limite < 3 {
+		return pontos
+	}
+
+	resultado := make([]modelos.PontoSerie, 0, limite)
+	resultado = append(resultado, pontos[0])
+
+	tamanhoBucket := float64(len(pontos)-2) / float64(limite-2)
+	selecionado := 0
+
+	for i := 0; i < limite-2; i++ {
+		// Média do bucket seguinte (terceiro vértice do triângulo)
+		inicioSeguinte := int(float64(i+1)*tamanhoBucket) + 1
+		fimSeguinte := min(int(float64(i+2)*tamanhoBucket)+1, len(pontos))
+		var mediaX, mediaY float64
+		for _, p := range pontos[inicioSeguinte:fimSeguinte] {
+			mediaX += float64(p.Timestamp.UnixMilli())
+			mediaY += p.Valor
+		}
+		quantidade := float64(fimSeguinte - inicioSeguinte)
+		mediaX /= quantidade
+		mediaY /= quantidade
+
+		// Ponto do bucket atual que forma o maior triângulo com o anterior e a média seguinte
+		inicioAtual := int(float64(i)*tamanhoBucket) + 1
+		fimAtual := int(float64(i+1)*tamanhoBucket) + 1
+		anteriorX := float64(pontos[selecionado].Timestamp.UnixMilli())
+		anteriorY := pontos[selecionado].Valor
+
+		maiorArea := -1.0
+		for j := inicioAtual; j < fimAtual; j++ {
+			area := math.Abs((anteriorX-mediaX)*(pontos[j].Valor-anteriorY) -
+				(anteriorX-float64(pontos[j].Timestamp.UnixMilli()))*(mediaY-anteriorY))
+			if area > maiorArea {
+				maiorArea = area
+				selecionado = j
+			}
+		}
+		resultado = append(resultado, pontos[selecionado])
+	}
+
+	return append(resultado, pontos[len(pontos)-1])
+}
+
+// ReagruparAgregados junta intervalos consecutivos de um agregado até caber no limite,
+// mantendo o mínimo, o máximo e a média ponderada pelo número de amostras
+func ReagruparAgregados(pontos []modelos.PontoSerie, limite int) []modelos.PontoSerie {
+	if limite <= 0 || limite >= len(pontos) {
+		return pontos
+	}
+
+	tamanhoGrupo := (len(pontos) + limite - 1) / limite
+	resultado := make([]modelos.PontoSerie, 0, limite)
+
+	for inicio := 0; inicio < len(pontos); inicio += tamanhoGrupo {
+		grupo := pontos[inicio:min(inicio+tamanhoGrupo, len(pontos))]
+
+		agrupado := modelos.PontoSerie{Timestamp: grupo[0].Timestamp}
+		minimo, maximo := math.Inf(1), math.Inf(-1)
+		var soma float64
+		for _, p := range grupo {
+			amostras := max(p.Amostras, 1)
+			soma += p.Valor * float64(amostras)
+			agrupado.Amostras += amostras
+			if p.Minimo != nil {
+				minimo = math.Min(minimo, *p.Minimo)
+			}
+			if p.Maximo != nil {
+				maximo = math.Max(maximo, *p.Maximo)
+			}
+		}
+		agrupado.Valor = soma / float64(agrupado.Amostras)
+		agrupado.Minimo, agrupado.Maximo = &minimo, &maximo
+
+		resultado = append(resultado, agrupado)
+	}
+
+	return resultado
+}
@@ -0,0 +1,157 @@
+// Package series guarda valores analógicos: amostras brutas particionadas por dia,
+// agregados contínuos de 1 min, 15 min e 1 h, retenção e consultas com decimação.
+package series
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/edp/falhas-backend/modelos"
+)
+
+const (
+	tamanhoLote       = 500         // Amostras por INSERT
+	intervaloGravacao = time.Second // Tempo máximo de uma amostra em memória
+	capacidadeFila    = 10000
+)
+
+// Gravador acumula amostras e grava-as em lote em series_brutas
+type Gravador struct {
+	bancoDados  *sql.DB
+	fila        chan modelos.AmostraSerie
+	particoes   map[string]bool // Partições diárias já garantidas (AAAAMMDD)
+	descartadas atomic.Uint64
+	canalParada chan struct{}
+	grupoWait   sync.WaitGroup
+	pararUmaVez sync.Once
+}
+
+// NovoGravador cria o gravador e inicia a gravação em segundo plano
+func NovoGravador(db *sql.DB) *Gravador {
+	g := &Gravador{
+		bancoDados:  db,
+		fila:        make(chan modelos.AmostraSerie, capacidadeFila),
+		particoes:   make(map[string]bool),
+		canalParada: make(chan struct{}),
+	}
+
+	g.grupoWait.Add(1)
+	go g.executar()
+	return g
+}
+
+// Registrar enfileira uma amostra sem bloquear o processamento do frame
+func (g *Gravador) Registrar(amostra modelos.AmostraSerie) {
+	select {
+	case g.fila <- amostra:
+	default:
+		if g.descartadas.Add(1)%1000 == 1 {
+			log.Printf("⚠️  Fila de séries cheia: %d amostras descartadas", g.descartadas.Load())
+		}
+	}
+}
+
+// Parar grava as amostras pendentes e encerra o gravador
+func (g *Gravador) Parar() {
+	g.pararUmaVez.Do(func() {
+		close(g.canalParada)
+		g.grupoWait.Wait()
+	})
+}
+
+// executar grava um lote quando enche ou a cada intervaloGravacao
+func (g *Gravador) executar() {
+	defer g.grupoWait.Done()
+
+	temporizador := time.NewTicker(intervaloGravacao)
+	defer temporizador.Stop()
+
+	lote := make([]modelos.AmostraSerie, 0, tamanhoLote)
+	gravar := func() {
+		if len(lote) == 0 {
+			return
+		}
+		if err := g.gravarLote(lote); err != nil {
+			log.Printf("❌ Erro ao gravar %d amostras de séries: %v", len(lote), err)
+		}
+		lote = lote[:0]
+	}
+
+	for {
+		select {
+		case amostra := <-g.fila:
+			lote = append(lote, amostra)
+			if len(lote) >= tamanhoLote {
+				gravar()
+			}
+		case <-temporizador.C:
+			gravar()
+		case <-g.canalParada:
+			// Esvaziar a fila antes de sair
+			for {
+				select {
+				case amostra := <-g.fila:
+					lote = append(lote, amostra)
+					if len(lote) >= tamanhoLote {
+						gravar()
+					}
+				default:
+					gravar()
+					return
+				}
+			}
+		}
+	}
+}
+
+// gravarLote garante as partições dos dias envolvidos e insere o lote numa única instrução
+func (g *Gravador) gravarLote(lote []modelos.AmostraSerie) error {
+	for _, amostra := range lote {
+		dia := amostra.Timestamp.Format("20060102")
+		if g.particoes[dia] {
+			continue
+		}
+		if err := GarantirParticao(g.bancoDados, amostra.Timestamp); err != nil {
+			return err
+		}
+		g.particoes[dia] = true
+	}
+
+	valores := make([]string, 0, len(lote))
+	args := make([]interface{}, 0, len(lote)*3)
+	for i, amostra := range lote {
+		valores = append(valores, fmt.Sprintf("($%d, $%d, $%d)", i*3+1, i*3+2, i*3+3))
+		args = append(args, amostra.TagID, amostra.Timestamp, amostra.Valor)
+	}
+
+	_, err := g.bancoDados.Exec(`
+		INSERT INTO series_brutas (tag_id, timestamp, valor)
+		VALUES `+strings.Join(valores, ", ")+`
+		ON CONFLICT (tag_id, timestamp) DO NOTHING`, args...)
+	return err
+}
+
+// GarantirParticao cria a partição diária de series_brutas que contém o instante indicado
+func GarantirParticao(db *sql.DB, instante time.Time) error {
+	inicio := time.Date(instante.Year(), instante.Month(), instante.Day(), 0, 0, 0, 0, time.UTC)
+	fim := inicio.AddDate(0, 0, 1)
+
+	_, err := db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s PARTITION OF series_brutas
+		FOR VALUES FROM ('%s') TO ('%s')`,
+		nomeParticao(inicio), inicio.Format("2006-01-02"), fim.Format("2006-01-02")))
+	if err != nil {
+		return fmt.Errorf("erro ao criar partição de %s: %v", inicio.Format("2006-01-02"), err)
+	}
+	return nil
+}
+
+// nomeParticao devolve o nome da partição diária (series_brutas_AAAAMMDD)
+func nomeParticao(dia time.Time) string {
+	return "series_brutas_" + dia.Format("20060102")
+}
@@ -0,0 +1,112 @@
+package simulador
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PassoScript é uma linha do roteiro YAML
+type PassoScript struct {
+	Apos        time.Duration `yaml:"apos"`        // Espera antes do passo
+	Descricao   string        `yaml:"descricao"`   // Texto para o log
+	Ativar      []string      `yaml:"ativar"`      // Pontos a ligar (código, número ou WORD:BIT)
+	Desativar   []string      `yaml:"desativar"`   // Pontos a desligar
+	Limpar      bool          `yaml:"limpar"`      // Zerar todas as WORDs
+	Desconectar time.Duration `yaml:"desconectar"` // Derrubar a ligação durante este tempo
+
+	pontosAtivar    []Ponto
+	pontosDesativar []Ponto
+}
+
+// CenarioScript executa uma sequência de passos lida de um arquivo YAML:
+//
+//	nome: Perda de comunicação seguida de emergência
+//	repetir: 1
+//	passos:
+//	  - apos: 2s
+//	    ativar: [RG_ENCHIMENTO_006]
+//	  - apos: 500ms
+//	    ativar: ["9", "0:13"]
+//	  - apos: 10s
+//	    desativar: [RG_ENCHIMENTO_006, RG_ENCHIMENTO_009, "0:13"]
+//	  - apos: 1s
+//	    desconectar: 5s
+type CenarioScript struct {
+	Titulo  string        `yaml:"nome"`
+	Repetir int           `yaml:"repetir"` // Execuções do roteiro (0 = sem fim)
+	Passos  []PassoScript `yaml:"passos"`
+}
+
+// CarregarCenarioScript lê e valida um roteiro YAML
+func CarregarCenarioScript(caminho string) (*CenarioScript, error) {
+	dados, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler roteiro: %w", err)
+	}
+	return InterpretarCenarioScript(dados)
+}
+
+// InterpretarCenarioScript valida um roteiro YAML já em memória (útil em testes)
+func InterpretarCenarioScript(dados []byte) (*CenarioScript, error) {
+	cenario := &CenarioScript{Repetir: 1}
+	if err := yaml.Unmarshal(dados, cenario); err != nil {
+		return nil, fmt.Errorf("roteiro YAML inválido: %w", err)
+	}
+	if len(cenario.Passos) == 0 {
+		return nil, fmt.Errorf("roteiro sem passos")
+	}
+
+	for i := range cenario.Passos {
+		passo := &cenario.Passos[i]
+		var err error
+		if passo.pontosAtivar, err = InterpretarPontos(passo.Ativar); err != nil {
+			return nil, fmt.Errorf("passo %d: %w", i+1, err)
+		}
+		if passo.pontosDesativar, err = InterpretarPontos(passo.Desativar); err != nil {
+			return nil, fmt.Errorf("passo %d: %w", i+1, err)
+		}
+		if passo.Apos < 0 || passo.Desconectar < 0 {
+			return nil, fmt.Errorf("passo %d: durações não podem ser negativas", i+1)
+		}
+	}
+	return cenario, nil
+}
+
+// Nome identifica o cenário
+func (c *CenarioScript) Nome() string { return "script" }
+
+// Executar aplica os passos na ordem do roteiro
+func (c *CenarioScript) Executar(ctx context.Context, eclusa *EclusaSimulada) error {
+	for repeticao := 0; c.Repetir == 0 || repeticao < c.Repetir; repeticao++ {
+		log.Printf("📜 [%s] Roteiro '%s' (execução %d)", eclusa.Nome, c.Titulo, repeticao+1)
+
+		for i, passo := range c.Passos {
+			if !dormir(ctx, passo.Apos) {
+				return nil
+			}
+
+			if passo.Limpar {
+				eclusa.Limpar()
+			}
+			if len(passo.pontosDesativar) > 0 {
+				eclusa.Desativar(passo.pontosDesativar...)
+			}
+			if len(passo.pontosAtivar) > 0 {
+				eclusa.Ativar(passo.pontosAtivar...)
+			}
+			if passo.Desconectar > 0 {
+				eclusa.Desconectar(passo.Desconectar)
+			}
+
+			if passo.Descricao != "" {
+				log.Printf("   [%s] Passo %d: %s", eclusa.Nome, i+1, passo.Descricao)
+			}
+		}
+	}
+	return nil
+}
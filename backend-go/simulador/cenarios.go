@@ -0,0 +1,236 @@
+package simulador
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// Cenario gera alterações de bits numa eclusa simulada até o contexto terminar
+type Cenario interface {
+	Nome() string
+	Executar(ctx context.Context, eclusa *EclusaSimulada) error
+}
+
+// CenarioAleatorio ativa falhas aleatórias que se resolvem sozinhas após algum tempo
+type CenarioAleatorio struct {
+	Intervalo      time.Duration // Tempo médio entre novas falhas
+	DuracaoMinima  time.Duration // Tempo mínimo que uma falha fica ativa
+	DuracaoMaxima  time.Duration // Tempo máximo que uma falha fica ativa
+	FalhasMaximas  int           // Falhas ativas ao mesmo tempo
+	Semente        int64         // Mesma semente = mesma sequência
+	IncluirEventos bool          // Sortear também pontos de evento
+}
+
+// Nome identifica o cenário
+func (c CenarioAleatorio) Nome() string { return "aleatorio" }
+
+// Executar sorteia falhas até o contexto terminar
+func (c CenarioAleatorio) Executar(ctx context.Context, eclusa *EclusaSimulada) error {
+	aleatorio := rand.New(rand.NewSource(c.Semente))
+	ultimoPonto := UltimoPontoFalha
+	if c.IncluirEventos {
+		ultimoPonto = TotalPontosRegua
+	}
+
+	ativas := make(map[Ponto]time.Time) // ponto -> momento de resolução
+	for {
+		espera := time.Duration(aleatorio.ExpFloat64() * float64(c.Intervalo))
+		if !dormir(ctx, espera) {
+			return nil
+		}
+
+		agora := time.Now()
+		var resolvidas []Ponto
+		for ponto, fim := range ativas {
+			if agora.After(fim) {
+				resolvidas = append(resolvidas, ponto)
+				delete(ativas, ponto)
+			}
+		}
+		if len(resolvidas) > 0 {
+			eclusa.Desativar(resolvidas...)
+		}
+
+		if c.FalhasMaximas > 0 && len(ativas) >= c.FalhasMaximas {
+			continue
+		}
+
+		ponto, _ := PontoPorID(aleatorio.Intn(ultimoPonto) + 1)
+		if _, existe := ativas[ponto]; existe {
+			continue
+		}
+
+		duracao := c.DuracaoMinima
+		if c.DuracaoMaxima > c.DuracaoMinima {
+			duracao += time.Duration(aleatorio.Int63n(int64(c.DuracaoMaxima - c.DuracaoMinima)))
+		}
+		ativas[ponto] = agora.Add(duracao)
+		eclusa.Ativar(ponto)
+		log.Printf("🎲 [%s] Ponto %d (WORD %d bit %d) ativo por %s", eclusa.Nome, ponto.ID(), ponto.Word, ponto.Bit, duracao.Round(time.Second))
+	}
+}
+
+// passoCiclo é uma etapa de uma eclusagem: pontos ligados e desligados ao mesmo tempo
+type passoCiclo struct {
+	descricao string
+	ativar    []int
+	desativar []int
+}
+
+// Eclusagens com os pontos de evento da Régua (ver EVENTOS em database/setup.go)
+var (
+	// cicloEnchimento é uma eclusagem automática de subida
+	cicloEnchimento = []passoCiclo{
+		{"subida preparada", []int{673, 499, 500}, []int{679}},
+		{"subida ativa, comportas a abrir", []int{674, 515, 516, 523, 524}, []int{673}},
+		{"comportas de enchimento abertas", []int{501, 504}, []int{515, 516}},
+		{"igualdade de níveis a montante", []int{667, 665}, nil},
+		{"comportas de enchimento a fechar", []int{519, 520}, []int{501, 504}},
+		{"comportas de enchimento fechadas", []int{503, 522}, []int{519, 520, 523, 524}},
+		{"porta montante a abrir", []int{654}, nil},
+		{"porta montante aberta, saída autorizada", []int{629, 657}, []int{654}},
+		{"subida concluída", []int{675}, []int{674, 499, 500, 667, 665, 629, 657, 503, 522}},
+	}
+
+	// cicloEsvaziamento é uma eclusagem automática de descida
+	cicloEsvaziamento = []passoCiclo{
+		{"descida preparada", []int{677, 547, 548}, []int{675}},
+		{"descida ativa, comportas a abrir", []int{678, 563, 564, 571, 572}, []int{677}},
+		{"comportas de esvaziamento abertas", []int{549, 552}, []int{563, 564}},
+		{"igualdade de níveis a jusante", []int{668, 666}, nil},
+		{"comportas de esvaziamento a fechar", []int{567, 568}, []int{549, 552}},
+		{"comportas de esvaziamento fechadas", []int{551, 570}, []int{567, 568, 571, 572}},
+		{"porta jusante a abrir", []int{621}, nil},
+		{"porta jusante aberta, saída autorizada", []int{595, 662}, []int{621}},
+		{"descida concluída", []int{679}, []int{678, 547, 548, 668, 666, 595, 662, 551, 570}},
+	}
+)
+
+// CenarioCiclo alterna eclusagens de subida (enchimento) e descida (esvaziamento)
+type CenarioCiclo struct {
+	Passo  time.Duration // Tempo entre etapas
+	Ciclos int           // Número de eclusagens (0 = sem fim)
+}
+
+// Nome identifica o cenário
+func (c CenarioCiclo) Nome() string { return "ciclo" }
+
+// Executar percorre as etapas das eclusagens
+func (c CenarioCiclo) Executar(ctx context.Context, eclusa *EclusaSimulada) error {
+	eclusa.Ativar(pontosPorID(507, 555, 603, 635)...) // Sistemas em automático
+
+	for ciclo := 0; c.Ciclos == 0 || ciclo < c.Ciclos; ciclo++ {
+		etapas, nome := cicloEnchimento, "enchimento"
+		if ciclo%2 == 1 {
+			etapas, nome = cicloEsvaziamento, "esvaziamento"
+		}
+
+		log.Printf("🚢 [%s] Eclusagem %d (%s)", eclusa.Nome, ciclo+1, nome)
+		for _, etapa := range etapas {
+			if !dormir(ctx, c.Passo) {
+				return nil
+			}
+			eclusa.Desativar(pontosPorID(etapa.desativar...)...)
+			eclusa.Ativar(pontosPorID(etapa.ativar...)...)
+			log.Printf("   [%s] %s", eclusa.Nome, etapa.descricao)
+		}
+	}
+	return nil
+}
+
+// CenarioAvalanche ativa muitas falhas em sequência rápida, como uma perda de alimentação
+type CenarioAvalanche struct {
+	Alarmes int           // Falhas ativadas na avalanche
+	Janela  time.Duration // Tempo em que todas são ativadas
+	Manter  time.Duration // Tempo até serem resolvidas
+	Pausa   time.Duration // Tempo entre avalanches
+	Repetir int           // Número de avalanches (0 = sem fim)
+	Semente int64
+}
+
+// Nome identifica o cenário
+func (c CenarioAvalanche) Nome() string { return "avalanche" }
+
+// Executar gera avalanches: a primeira falha ativada é a causa (first-out)
+func (c CenarioAvalanche) Executar(ctx context.Context, eclusa *EclusaSimulada) error {
+	aleatorio := rand.New(rand.NewSource(c.Semente))
+	alarmes := c.Alarmes
+	if alarmes <= 0 || alarmes > UltimoPontoFalha {
+		return fmt.Errorf("número de alarmes da avalanche inválido: %d (1..%d)", c.Alarmes, UltimoPontoFalha)
+	}
+
+	for repeticao := 0; c.Repetir == 0 || repeticao < c.Repetir; repeticao++ {
+		if !dormir(ctx, c.Pausa) {
+			return nil
+		}
+
+		ids := aleatorio.Perm(UltimoPontoFalha)[:alarmes]
+		intervalo := c.Janela / time.Duration(alarmes)
+		log.Printf("🌊 [%s] Avalanche de %d alarmes em %s (first-out: ponto %d)", eclusa.Nome, alarmes, c.Janela, ids[0]+1)
+
+		var ativados []Ponto
+		for _, id := range ids {
+			ponto, _ := PontoPorID(id + 1)
+			eclusa.Ativar(ponto)
+			ativados = append(ativados, ponto)
+			if !dormir(ctx, intervalo) {
+				return nil
+			}
+		}
+
+		if !dormir(ctx, c.Manter) {
+			return nil
+		}
+		eclusa.Desativar(ativados...)
+		log.Printf("✅ [%s] Avalanche resolvida", eclusa.Nome)
+	}
+	return nil
+}
+
+// CenarioDesconexao simula quedas da ligação do PLC, com uma falha ativa durante a queda
+type CenarioDesconexao struct {
+	Apos    time.Duration // Tempo ligado antes de cada queda
+	Duracao time.Duration // Duração da queda
+	Repetir int           // Número de quedas (0 = sem fim)
+}
+
+// Nome identifica o cenário
+func (c CenarioDesconexao) Nome() string { return "desconexao" }
+
+// Executar desliga e religa a eclusa; a falha de comunicação (ponto 6) muda durante a queda
+func (c CenarioDesconexao) Executar(ctx context.Context, eclusa *EclusaSimulada) error {
+	falhaComunicacao := pontosPorID(6)
+
+	for repeticao := 0; c.Repetir == 0 || repeticao < c.Repetir; repeticao++ {
+		if !dormir(ctx, c.Apos) {
+			return nil
+		}
+
+		eclusa.Desconectar(c.Duracao)
+		eclusa.Ativar(falhaComunicacao...) // Só chega ao backend no primeiro frame após religar
+		if !dormir(ctx, c.Duracao) {
+			return nil
+		}
+		if !dormir(ctx, c.Apos) {
+			return nil
+		}
+		eclusa.Desativar(falhaComunicacao...)
+	}
+	return nil
+}
+
+// pontosPorID converte números de ponto fixos (válidos por construção)
+func pontosPorID(ids ...int) []Ponto {
+	pontos := make([]Ponto, 0, len(ids))
+	for _, id := range ids {
+		ponto, err := PontoPorID(id)
+		if err != nil {
+			panic(err)
+		}
+		pontos = append(pontos, ponto)
+	}
+	return pontos
+}
@@ -0,0 +1,239 @@
+package simulador
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/plc"
+)
+
+// intervaloMinimoFrames separa dois envios seguidos. O servidor trata cada leitura TCP
+// como um frame, por isso frames colados seriam lidos como um só.
+const intervaloMinimoFrames = 20 * time.Millisecond
+
+// EclusaSimulada mantém as WORDs de uma eclusa e envia os frames ao backend
+type EclusaSimulada struct {
+	Nome             string
+	Endereco         string        // host:porta do ServidorTCP
+	Periodo          time.Duration // Reenvio periódico do frame completo, como o PLC
+	FormatoTimestamp string        // Cabeçalho de timestamp (PLC_TIMESTAMP_FRAME)
+
+	mutex           sync.Mutex
+	words           [WordsRegua]uint16
+	desconectarAte  time.Time
+	framesEnviados  int
+	canalAlteracao  chan struct{}
+	canalConectado  chan struct{}
+	conectadaUmaVez sync.Once
+}
+
+// NovaEclusaSimulada cria uma eclusa com todas as WORDs a zero
+func NovaEclusaSimulada(nome, endereco string, periodo time.Duration) *EclusaSimulada {
+	return &EclusaSimulada{
+		Nome:           nome,
+		Endereco:       endereco,
+		Periodo:        periodo,
+		canalAlteracao: make(chan struct{}, 1),
+		canalConectado: make(chan struct{}),
+	}
+}
+
+// Definir altera vários bits de uma vez; o frame é enviado uma única vez com todas as alterações
+func (e *EclusaSimulada) Definir(valor bool, pontos ...Ponto) {
+	e.mutex.Lock()
+	for _, p := range pontos {
+		e.words[p.Word] = plc.DefinirBit(e.words[p.Word], p.Bit, valor)
+	}
+	e.mutex.Unlock()
+
+	e.notificarAlteracao()
+}
+
+// Ativar coloca os bits a 1
+func (e *EclusaSimulada) Ativar(pontos ...Ponto) {
+	e.Definir(true, pontos...)
+}
+
+// Desativar coloca os bits a 0
+func (e *EclusaSimulada) Desativar(pontos ...Ponto) {
+	e.Definir(false, pontos...)
+}
+
+// Limpar coloca todas as WORDs a zero
+func (e *EclusaSimulada) Limpar() {
+	e.mutex.Lock()
+	e.words = [WordsRegua]uint16{}
+	e.mutex.Unlock()
+
+	e.notificarAlteracao()
+}
+
+// Ativo indica o estado atual de um bit
+func (e *EclusaSimulada) Ativo(p Ponto) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return plc.ObterBit(e.words[p.Word], p.Bit)
+}
+
+// Desconectar fecha a ligação e só volta a ligar depois da duração indicada
+func (e *EclusaSimulada) Desconectar(duracao time.Duration) {
+	e.mutex.Lock()
+	e.desconectarAte = time.Now().Add(duracao)
+	e.mutex.Unlock()
+
+	e.notificarAlteracao()
+}
+
+// FramesEnviados devolve quantos frames já foram enviados
+func (e *EclusaSimulada) FramesEnviados() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.framesEnviados
+}
+
+// Conectada fecha quando a primeira ligação ao backend é estabelecida (útil em testes)
+func (e *EclusaSimulada) Conectada() <-chan struct{} {
+	return e.canalConectado
+}
+
+// Frame monta o frame atual: cabeçalho de timestamp (se configurado) + WORDs em Big Endian
+func (e *EclusaSimulada) Frame(agora time.Time) ([]byte, error) {
+	cabecalho, err := plc.CodificarTimestampFrame(e.FormatoTimestamp, agora)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	frame := make([]byte, len(cabecalho), len(cabecalho)+WordsRegua*2)
+	copy(frame, cabecalho)
+	for _, word := range e.words {
+		frame = binary.BigEndian.AppendUint16(frame, word)
+	}
+	return frame, nil
+}
+
+// Executar mantém a ligação ao backend e envia o frame a cada alteração e a cada período.
+// Reconecta sozinha após quedas; termina quando o contexto é cancelado.
+func (e *EclusaSimulada) Executar(ctx context.Context) error {
+	if _, err := plc.CodificarTimestampFrame(e.FormatoTimestamp, time.Now()); err != nil {
+		return err
+	}
+
+	espera := time.Second
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if pausa := e.tempoDesconectada(); pausa > 0 {
+			log.Printf("🔌 [%s] Desconectada por %s", e.Nome, pausa.Round(time.Millisecond))
+			if !dormir(ctx, pausa) {
+				return nil
+			}
+		}
+
+		conn, err := (&net.Dialer{Timeout: 5 * time.Second}).DialContext(ctx, "tcp", e.Endereco)
+		if err != nil {
+			log.Printf("⚠️  [%s] Erro ao conectar a %s: %v (nova tentativa em %s)", e.Nome, e.Endereco, err, espera)
+			if !dormir(ctx, espera) {
+				return nil
+			}
+			if espera < 30*time.Second {
+				espera *= 2
+			}
+			continue
+		}
+
+		espera = time.Second
+		log.Printf("🔗 [%s] Conectada a %s", e.Nome, e.Endereco)
+		e.conectadaUmaVez.Do(func() { close(e.canalConectado) })
+
+		err = e.enviarFrames(ctx, conn)
+		conn.Close()
+		if err != nil {
+			log.Printf("⚠️  [%s] Ligação perdida: %v", e.Nome, err)
+		}
+	}
+}
+
+// enviarFrames envia frames até o contexto terminar, a ligação falhar ou ser pedida uma desconexão
+func (e *EclusaSimulada) enviarFrames(ctx context.Context, conn net.Conn) error {
+	periodo := e.Periodo
+	if periodo <= 0 {
+		periodo = time.Second
+	}
+	temporizador := time.NewTicker(periodo)
+	defer temporizador.Stop()
+
+	var ultimoEnvio time.Time
+	for {
+		if e.tempoDesconectada() > 0 {
+			return nil
+		}
+
+		// Respeitar o intervalo mínimo entre frames
+		if desde := time.Since(ultimoEnvio); desde < intervaloMinimoFrames {
+			if !dormir(ctx, intervaloMinimoFrames-desde) {
+				return nil
+			}
+		}
+
+		agora := time.Now()
+		frame, err := e.Frame(agora)
+		if err != nil {
+			return err
+		}
+
+		conn.SetWriteDeadline(agora.Add(5 * time.Second))
+		if _, err := conn.Write(frame); err != nil {
+			return fmt.Errorf("erro ao enviar frame: %w", err)
+		}
+		ultimoEnvio = agora
+
+		e.mutex.Lock()
+		e.framesEnviados++
+		e.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-temporizador.C:
+		case <-e.canalAlteracao:
+		}
+	}
+}
+
+// tempoDesconectada devolve quanto falta da desconexão pedida (0 = pode conectar)
+func (e *EclusaSimulada) tempoDesconectada() time.Duration {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return time.Until(e.desconectarAte)
+}
+
+// notificarAlteracao acorda o laço de envio sem bloquear
+func (e *EclusaSimulada) notificarAlteracao() {
+	select {
+	case e.canalAlteracao <- struct{}{}:
+	default:
+	}
+}
+
+// dormir espera a duração indicada; devolve false se o contexto terminar antes
+func dormir(ctx context.Context, duracao time.Duration) bool {
+	temporizador := time.NewTimer(duracao)
+	defer temporizador.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-temporizador.C:
+		return true
+	}
+}
@@ -0,0 +1,79 @@
+package simulador
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Layout dos frames da Régua: 736 pontos (496 falhas + 240 eventos) em 46 WORDs.
+// O ponto N (1..736) fica na WORD (N-1)/16, bit (N-1)%16, como em database/setup.go.
+const (
+	TotalPontosRegua = 736
+	WordsRegua       = TotalPontosRegua / 16
+	UltimoPontoFalha = 496 // Pontos 1..496 são FALHAS, 497..736 são EVENTOS
+)
+
+// Ponto identifica um bit do frame
+type Ponto struct {
+	Word int
+	Bit  int
+}
+
+// PontoPorID converte o número do ponto (1..736) na posição do bit
+func PontoPorID(id int) (Ponto, error) {
+	if id < 1 || id > TotalPontosRegua {
+		return Ponto{}, fmt.Errorf("ponto %d fora do layout da Régua (1..%d)", id, TotalPontosRegua)
+	}
+	return Ponto{Word: (id - 1) / 16, Bit: (id - 1) % 16}, nil
+}
+
+// ID devolve o número do ponto (1..736)
+func (p Ponto) ID() int {
+	return p.Word*16 + p.Bit + 1
+}
+
+// EhFalha indica se o ponto pertence à faixa de falhas
+func (p Ponto) EhFalha() bool {
+	return p.ID() <= UltimoPontoFalha
+}
+
+// InterpretarPonto aceita o código da definição (RG_ENCHIMENTO_009), o número do ponto (9)
+// ou a posição WORD:BIT (0:8)
+func InterpretarPonto(texto string) (Ponto, error) {
+	texto = strings.TrimSpace(texto)
+
+	if word, bit, existe := strings.Cut(texto, ":"); existe {
+		w, errWord := strconv.Atoi(word)
+		b, errBit := strconv.Atoi(bit)
+		if errWord != nil || errBit != nil || w < 0 || w >= WordsRegua || b < 0 || b > 15 {
+			return Ponto{}, fmt.Errorf("posição inválida: %s (use WORD:BIT, WORD 0..%d, BIT 0..15)", texto, WordsRegua-1)
+		}
+		return Ponto{Word: w, Bit: b}, nil
+	}
+
+	// O código termina no número do ponto: RG_<SETOR>_<NNN>
+	numero := texto
+	if i := strings.LastIndex(texto, "_"); i >= 0 {
+		numero = texto[i+1:]
+	}
+
+	id, err := strconv.Atoi(numero)
+	if err != nil {
+		return Ponto{}, fmt.Errorf("ponto inválido: %s (use código RG_..._NNN, número do ponto ou WORD:BIT)", texto)
+	}
+	return PontoPorID(id)
+}
+
+// InterpretarPontos converte uma lista de pontos em texto
+func InterpretarPontos(textos []string) ([]Ponto, error) {
+	pontos := make([]Ponto, 0, len(textos))
+	for _, texto := range textos {
+		ponto, err := InterpretarPonto(texto)
+		if err != nil {
+			return nil, err
+		}
+		pontos = append(pontos, ponto)
+	}
+	return pontos, nil
+}
@@ -0,0 +1,111 @@
+// Package simulador envia frames no layout da Régua ao ServidorTCP, em substituição ao PLC.
+// É usado pelo comando "simulador" e pode ser usado diretamente em testes de integração:
+//
+//	sim, _ := simulador.Novo(simulador.Configuracao{Enderecos: []string{"127.0.0.1:8502"}, Eclusas: 1})
+//	ctx, cancelar := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancelar()
+//	go sim.Executar(ctx, func(int) simulador.Cenario { return nil })
+//	<-sim.Eclusas[0].Conectada()
+//	sim.Eclusas[0].Ativar(simulador.Ponto{Word: 0, Bit: 8})
+package simulador
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Configuracao descreve as eclusas simuladas
+type Configuracao struct {
+	Enderecos        []string      // Backend de cada eclusa; a última entrada vale para as restantes
+	Eclusas          int           // Número de eclusas simuladas
+	Periodo          time.Duration // Reenvio periódico do frame
+	FormatoTimestamp string        // Cabeçalho de timestamp (PLC_TIMESTAMP_FRAME)
+}
+
+// Simulador agrupa várias eclusas simuladas, cada uma com a sua ligação TCP
+type Simulador struct {
+	Eclusas []*EclusaSimulada
+}
+
+// Novo cria as eclusas simuladas (SIM1, SIM2, ...). Cada eclusa precisa do seu backend: o
+// ServidorTCP guarda um único estado de bits e o mapeamento de uma só eclusa, por isso duas
+// eclusas no mesmo backend ativariam e resolveriam as ocorrências uma da outra.
+func Novo(cfg Configuracao) (*Simulador, error) {
+	if cfg.Eclusas <= 0 {
+		cfg.Eclusas = 1
+	}
+
+	simulador := &Simulador{}
+	eclusaPorEndereco := make(map[string]string)
+	for i := 0; i < cfg.Eclusas; i++ {
+		endereco := ""
+		if len(cfg.Enderecos) > 0 {
+			endereco = strings.TrimSpace(cfg.Enderecos[min(i, len(cfg.Enderecos)-1)])
+		}
+
+		nome := fmt.Sprintf("SIM%d", i+1)
+		if outra, existe := eclusaPorEndereco[endereco]; existe {
+			return nil, fmt.Errorf("%s e %s usariam o mesmo backend %s: indique um alvo por eclusa", outra, nome, endereco)
+		}
+		eclusaPorEndereco[endereco] = nome
+
+		eclusa := NovaEclusaSimulada(nome, endereco, cfg.Periodo)
+		eclusa.FormatoTimestamp = cfg.FormatoTimestamp
+		simulador.Eclusas = append(simulador.Eclusas, eclusa)
+	}
+	return simulador, nil
+}
+
+// Executar liga todas as eclusas e corre o cenário de cada uma (nil = só envia o estado atual).
+// Termina quando o contexto é cancelado; se todos os cenários terminarem, as eclusas continuam
+// a enviar o estado final até ao cancelamento.
+func (s *Simulador) Executar(ctx context.Context, cenarioPara func(indice int) Cenario) error {
+	ctx, cancelar := context.WithCancel(ctx)
+	defer cancelar()
+
+	var grupo sync.WaitGroup
+	erros := make(chan error, len(s.Eclusas)*2)
+
+	for i, eclusa := range s.Eclusas {
+		grupo.Add(1)
+		go func(eclusa *EclusaSimulada) {
+			defer grupo.Done()
+			if err := eclusa.Executar(ctx); err != nil {
+				erros <- fmt.Errorf("%s: %w", eclusa.Nome, err)
+				cancelar()
+			}
+		}(eclusa)
+
+		var cenario Cenario
+		if cenarioPara != nil {
+			cenario = cenarioPara(i)
+		}
+		if cenario == nil {
+			continue
+		}
+
+		grupo.Add(1)
+		go func(eclusa *EclusaSimulada, cenario Cenario) {
+			defer grupo.Done()
+
+			// O cenário só começa quando a eclusa estiver ligada
+			select {
+			case <-eclusa.Conectada():
+			case <-ctx.Done():
+				return
+			}
+
+			if err := cenario.Executar(ctx, eclusa); err != nil {
+				erros <- fmt.Errorf("%s (%s): %w", eclusa.Nome, cenario.Nome(), err)
+				cancelar()
+			}
+		}(eclusa, cenario)
+	}
+
+	grupo.Wait()
+	close(erros)
+	return <-erros
+}
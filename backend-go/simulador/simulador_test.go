@@ -0,0 +1,35 @@
+package simulador
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNovoRecusaDuasEclusasNoMesmoBackend(t *testing.T) {
+	casos := []struct {
+		nome      string
+		enderecos []string
+		eclusas   int
+		erro      bool
+	}{
+		{nome: "uma eclusa", enderecos: []string{"127.0.0.1:8502"}, eclusas: 1},
+		{nome: "um alvo por eclusa", enderecos: []string{"127.0.0.1:8502", "127.0.0.1:8503"}, eclusas: 2},
+		{nome: "duas eclusas num alvo", enderecos: []string{"127.0.0.1:8502"}, eclusas: 2, erro: true},
+		{nome: "alvo repetido", enderecos: []string{"127.0.0.1:8502", " 127.0.0.1:8502"}, eclusas: 2, erro: true},
+		{nome: "última entrada reutilizada", enderecos: []string{"127.0.0.1:8502", "127.0.0.1:8503"}, eclusas: 3, erro: true},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			sim, err := Novo(Configuracao{Enderecos: caso.enderecos, Eclusas: caso.eclusas})
+			if caso.erro {
+				if err == nil || !strings.Contains(err.Error(), "mesmo backend") {
+					t.Fatalf("erro = %v; esperada a recusa do backend partilhado", err)
+				}
+				return
+			}
+			if err != nil || len(sim.Eclusas) != caso.eclusas {
+				t.Fatalf("Novo = %v, %v; esperadas %d eclusas", sim, err, caso.eclusas)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package sincronizacao
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edp/falhas-backend/config"
+	"github.com/edp/falhas-backend/repositorio"
+)
+
+// CaminhoLotes é a rota do central que recebe os lotes das eclusas
+const CaminhoLotes = "/api/v1/sincronizacao/lotes"
+
+// intervaloLimpeza é o tempo entre limpezas dos itens já enviados
+const intervaloLimpeza = time.Hour
+
+// Lote é o corpo enviado ao central: os itens da fila de uma eclusa, por ordem de sequência
+type Lote struct {
+	Origem string                          `json:"origem"`
+	Itens  []repositorio.ItemSincronizacao `json:"itens"`
+}
+
+// RespostaLote é a resposta do central a um lote
+type RespostaLote struct {
+	Success         bool                        `json:"success"`
+	UltimaSequencia int64                       `json:"ultima_sequencia"`
+	Aplicados       int                         `json:"aplicados"`
+	Rejeitados      []repositorio.ItemRejeitado `json:"rejeitados,omitempty"` // Ficam no central e a sequência avança
+}
+
+// Enviador envia a fila de sincronização da eclusa ao central (store-and-forward). Os itens só
+// saem da fila quando o central confirma a sequência aplicada; enquanto o central não responde, a
+// fila cresce e as tentativas são repetidas com espera crescente, por ordem, a partir do primeiro
+// item pendente.
+type Enviador struct {
+	fila         repositorio.Sincronizacao
+	cliente      *http.Client
+	url          string
+	origem       string
+	token        string
+	lote         int
+	intervalo    time.Duration
+	esperaMaxima time.Duration
+	retencao     time.Duration
+
+	espera           time.Duration // Espera atual depois de falhas (0 = sem falhas)
+	proximaTentativa time.Time
+	ultimaLimpeza    time.Time
+
+	canalParada chan struct{}
+	grupoWait   sync.WaitGroup
+}
+
+// NovoEnviador cria o enviador com a configuração SYNC_*
+func NovoEnviador(fila repositorio.Sincronizacao, cfg *config.Configuracoes) (*Enviador, error) {
+	if cfg.Sync_URLCentral == "" {
+		return nil, fmt.Errorf("SYNC_URL_CENTRAL não configurado")
+	}
+	if cfg.Sync_Origem == "" {
+		return nil, fmt.Errorf("SYNC_ORIGEM não configurado (código da eclusa desta instalação)")
+	}
+	if !strings.HasPrefix(cfg.Sync_URLCentral, "https://") {
+		log.Printf("⚠️ SYNC_URL_CENTRAL sem HTTPS: os lotes seguem sem cifra (usar só em testes)")
+	}
+
+	transporte := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Sync_CAArquivo != "" {
+		certificado, err := os.ReadFile(cfg.Sync_CAArquivo)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler SYNC_CA_ARQUIVO: %v", err)
+		}
+		autoridades := x509.NewCertPool()
+		if !autoridades.AppendCertsFromPEM(certificado) {
+			return nil, fmt.Errorf("SYNC_CA_ARQUIVO sem certificados PEM válidos")
+		}
+		transporte.TLSClientConfig = &tls.Config{RootCAs: autoridades, MinVersion: tls.VersionTLS12}
+	}
+
+	e := &Enviador{
+		fila:         fila,
+		cliente:      &http.Client{Timeout: cfg.Sync_Timeout, Transport: transporte},
+		url:          strings.TrimSuffix(cfg.Sync_URLCentral, "/") + CaminhoLotes,
+		origem:       cfg.Sync_Origem,
+		token:        cfg.Sync_Token,
+		lote:         cfg.Sync_Lote,
+		intervalo:    cfg.Sync_Intervalo,
+		esperaMaxima: cfg.Sync_EsperaMaxima,
+		retencao:     cfg.Sync_RetencaoEnviados,
+		canalParada:  make(chan struct{}),
+	}
+	if e.lote <= 0 {
+		e.lote = 200
+	}
+	if e.intervalo <= 0 {
+		e.intervalo = 10 * time.Second
+	}
+	if e.esperaMaxima < e.intervalo {
+		e.esperaMaxima = e.intervalo
+	}
+	return e, nil
+}
+
+// Iniciar envia os pendentes a cada intervalo, até Parar
+func (e *Enviador) Iniciar() {
+	log.Printf("🔁 Sincronização da eclusa %s com %s", e.origem, e.url)
+
+	e.grupoWait.Add(1)
+	go func() {
+		defer e.grupoWait.Done()
+
+		temporizador := time.NewTicker(e.intervalo)
+		defer temporizador.Stop()
+
+		for {
+			e.ciclo()
+
+			select {
+			case <-e.canalParada:
+				return
+			case <-temporizador.C:
+			}
+		}
+	}()
+}
+
+// Parar espera o envio em curso terminar
+func (e *Enviador) Parar() {
+	close(e.canalParada)
+	e.grupoWait.Wait()
+}
+
+func (e *Enviador) ciclo() {
+	if time.Now().Before(e.proximaTentativa) {
+		return
+	}
+
+	enviados, err := e.enviarPendentes()
+	if err != nil {
+		// Espera crescente: o central pode estar em baixo durante dias sem inundar a rede ou o log
+		e.espera *= 2
+		if e.espera == 0 {
+			e.espera = e.intervalo
+		}
+		if e.espera > e.esperaMaxima {
+			e.espera = e.esperaMaxima
+		}
+		e.proximaTentativa = time.Now().Add(e.espera)
+		log.Printf("❌ Erro ao sincronizar com o central (nova tentativa em %v): %v", e.espera, err)
+		if errFila := e.fila.RegistrarFalhaEnvio(err.Error()); errFila != nil {
+			log.Printf("❌ %v", errFila)
+		}
+	} else {
+		if e.espera > 0 {
+			log.Printf("✅ Sincronização com o central retomada")
+		}
+		e.espera = 0
+	}
+	if enviados > 0 {
+		log.Printf("🔁 %d itens sincronizados com o central", enviados)
+	}
+
+	if e.retencao > 0 && time.Since(e.ultimaLimpeza) >= intervaloLimpeza {
+		e.ultimaLimpeza = time.Now()
+		if _, err := e.fila.RemoverEnviados(time.Now().Add(-e.retencao)); err != nil {
+			log.Printf("❌ %v", err)
+		}
+	}
+}
+
+// enviarPendentes envia lotes até esvaziar a fila (ou até Parar) e devolve quantos itens saíram
+func (e *Enviador) enviarPendentes() (int, error) {
+	total := 0
+	for {
+		itens, err := e.fila.ListarPendentes(e.lote)
+		if err != nil {
+			return total, err
+		}
+		if len(itens) == 0 {
+			return total, nil
+		}
+
+		ultima := itens[len(itens)-1].Sequencia
+		lote := compactar(itens)
+		resposta, err := e.enviarLote(lote)
+		if err != nil {
+			return total, err
+		}
+		confirmada := resposta.UltimaSequencia
+		for _, rejeitado := range resposta.Rejeitados {
+			log.Printf("⚠️ Central rejeitou o item %d (ocorrência %d): %s",
+				rejeitado.Sequencia, rejeitado.OcorrenciaID, rejeitado.Motivo)
+		}
+
+		enviadaAte := lote[len(lote)-1].Sequencia
+		if confirmada < enviadaAte {
+			return total, fmt.Errorf("central confirmou a sequência %d de %d", confirmada, enviadaAte)
+		}
+		if confirmada > ultima {
+			// O central já tinha sequências maiores: a fila desta eclusa foi recriada (ex.: banco novo)
+			log.Printf("⚠️ Central já aplicou a sequência %d de %s (lote até %d): itens ignorados pelo central",
+				confirmada, e.origem, ultima)
+		}
+		if err := e.fila.MarcarEnviados(ultima); err != nil {
+			return total, err
+		}
+		total += len(itens)
+
+		if len(itens) < e.lote {
+			return total, nil
+		}
+		select {
+		case <-e.canalParada:
+			return total, nil
+		default:
+		}
+	}
+}
+
+// compactar tira do lote os itens de ocorrência repetidos: todos levam o estado lido agora, por
+// isso basta o primeiro (que fica antes das transições da mesma ocorrência)
+func compactar(itens []repositorio.ItemSincronizacao) []repositorio.ItemSincronizacao {
+	vistas := make(map[int64]bool)
+	lote := make([]repositorio.ItemSincronizacao, 0, len(itens))
+	for _, item := range itens {
+		if item.Tipo == repositorio.ItemOcorrencia {
+			if vistas[item.OcorrenciaID] {
+				continue
+			}
+			vistas[item.OcorrenciaID] = true
+		}
+		lote = append(lote, item)
+	}
+	return lote
+}
+
+// enviarLote envia o lote ao central e devolve a resposta (última sequência aplicada e rejeitados)
+func (e *Enviador) enviarLote(itens []repositorio.ItemSincronizacao) (RespostaLote, error) {
+	var resultado RespostaLote
+	corpo, err := json.Marshal(Lote{Origem: e.origem, Itens: itens})
+	if err != nil {
+		return resultado, err
+	}
+
+	pedido, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(corpo))
+	if err != nil {
+		return resultado, fmt.Errorf("erro ao criar pedido HTTP: %v", err)
+	}
+	pedido.Header.Set("Content-Type", "application/json")
+	pedido.Header.Set("User-Agent", "falhas-backend")
+	if e.token != "" {
+		pedido.Header.Set("Authorization", "Bearer "+e.token)
+	}
+
+	resposta, err := e.cliente.Do(pedido)
+	if err != nil {
+		return resultado, fmt.Errorf("erro no pedido HTTP: %v", err)
+	}
+	defer resposta.Body.Close()
+
+	conteudo, _ := io.ReadAll(io.LimitReader(resposta.Body, 1<<20))
+	if resposta.StatusCode < 200 || resposta.StatusCode > 299 {
+		return resultado, fmt.Errorf("central respondeu %s: %s", resposta.Status, strings.TrimSpace(string(conteudo)))
+	}
+
+	if err := json.Unmarshal(conteudo, &resultado); err != nil || !resultado.Success {
+		return resultado, fmt.Errorf("resposta inválida do central: %s", strings.TrimSpace(string(conteudo)))
+	}
+	return resultado, nil
+}